@@ -0,0 +1,83 @@
+package renderconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/targetconfigcontroller"
+)
+
+// renderConfigOpts holds values to drive the render-config command.
+type renderConfigOpts struct {
+	operatorConfigFile string
+}
+
+// NewRenderConfigCommand creates a render-config command.
+func NewRenderConfigCommand() *cobra.Command {
+	opts := renderConfigOpts{}
+	cmd := &cobra.Command{
+		Use:   "render-config",
+		Short: "Render the kube-apiserver config the operator would produce for the given operator config",
+		Long: `Render the kube-apiserver config the operator would produce for the given operator config.
+
+The operator config is the kubeapiserver/cluster resource, e.g. from "oc get kubeapiserver cluster -o yaml". Its
+observed config and unsupported config overrides are merged over the default config exactly like the operator does,
+and the result is printed to stdout as JSON. Nothing is applied to the cluster.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			return opts.Run(cmd.OutOrStdout())
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (r *renderConfigOpts) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&r.operatorConfigFile, "operator-config", r.operatorConfigFile, "File with the kubeapiserver/cluster operator config, in YAML or JSON.")
+}
+
+// Validate verifies the inputs.
+func (r *renderConfigOpts) Validate() error {
+	if len(r.operatorConfigFile) == 0 {
+		return errors.New("missing required flag: --operator-config")
+	}
+	return nil
+}
+
+// Run prints the rendered config.
+func (r *renderConfigOpts) Run(out io.Writer) error {
+	data, err := ioutil.ReadFile(r.operatorConfigFile)
+	if err != nil {
+		return err
+	}
+	operatorConfig := &operatorv1.KubeAPIServer{}
+	if err := yaml.Unmarshal(data, operatorConfig); err != nil {
+		return fmt.Errorf("failed to parse the operator config %s: %v", r.operatorConfigFile, err)
+	}
+
+	config, err := targetconfigcontroller.RenderKubeAPIServerConfig(&operatorConfig.Spec.StaticPodOperatorSpec)
+	if err != nil {
+		return fmt.Errorf("failed to render the kube-apiserver config: %v", err)
+	}
+	indented := &bytes.Buffer{}
+	if err := json.Indent(indented, config, "", "  "); err != nil {
+		return err
+	}
+	indented.WriteString("\n")
+	_, err = indented.WriteTo(out)
+	return err
+}
@@ -0,0 +1,98 @@
+package renderconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const operatorConfig = `apiVersion: operator.openshift.io/v1
+kind: KubeAPIServer
+metadata:
+  name: cluster
+spec:
+  managementState: Managed
+  observedConfig:
+    apiServerArguments:
+      etcd-servers:
+      - https://10.0.0.1:2379
+      - https://10.0.0.2:2379
+    servingInfo:
+      bindAddress: 0.0.0.0:6443
+  unsupportedConfigOverrides:
+    apiServerArguments:
+      audit-log-maxsize:
+      - "200"
+`
+
+func renderConfig(t *testing.T, args ...string) ([]byte, error) {
+	t.Helper()
+	cmd := NewRenderConfigCommand()
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	cmd.SetErr(ioutil.Discard)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.Bytes(), err
+}
+
+func TestRenderConfig(t *testing.T) {
+	operatorConfigFile := filepath.Join(t.TempDir(), "kubeapiserver.yaml")
+	if err := ioutil.WriteFile(operatorConfigFile, []byte(operatorConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := renderConfig(t, "--operator-config", operatorConfigFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := struct {
+		Kind               string              `json:"kind"`
+		APIServerArguments map[string][]string `json:"apiServerArguments"`
+		ServingInfo        map[string]string   `json:"servingInfo"`
+	}{}
+	if err := json.Unmarshal(out, &config); err != nil {
+		t.Fatalf("expected JSON output, got %v:\n%s", err, out)
+	}
+	if config.Kind != "KubeAPIServerConfig" {
+		t.Errorf("expected a KubeAPIServerConfig, got kind %q", config.Kind)
+	}
+	if expected := []string{"https://10.0.0.1:2379", "https://10.0.0.2:2379"}; !reflect.DeepEqual(config.APIServerArguments["etcd-servers"], expected) {
+		t.Errorf("expected the observed etcd-servers %v, got %v", expected, config.APIServerArguments["etcd-servers"])
+	}
+	if expected := []string{"200"}; !reflect.DeepEqual(config.APIServerArguments["audit-log-maxsize"], expected) {
+		t.Errorf("expected the unsupported override of audit-log-maxsize %v, got %v", expected, config.APIServerArguments["audit-log-maxsize"])
+	}
+	if len(config.APIServerArguments["enable-admission-plugins"]) == 0 {
+		t.Errorf("expected the admission plugins of the default config")
+	}
+	if config.ServingInfo["bindAddress"] != "0.0.0.0:6443" {
+		t.Errorf("expected the observed serving info, got %v", config.ServingInfo)
+	}
+
+	// the output is stable
+	again, err := renderConfig(t, "--operator-config", operatorConfigFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, again) {
+		t.Errorf("expected the same output for the same input, got:\n%s\nand:\n%s", out, again)
+	}
+}
+
+func TestRenderConfigInvalidInput(t *testing.T) {
+	if _, err := renderConfig(t); err == nil {
+		t.Errorf("expected an error without operator config")
+	}
+
+	operatorConfigFile := filepath.Join(t.TempDir(), "kubeapiserver.yaml")
+	if err := ioutil.WriteFile(operatorConfigFile, []byte("spec: ["), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := renderConfig(t, "--operator-config", operatorConfigFile); err == nil {
+		t.Errorf("expected an error for a malformed operator config")
+	}
+}
@@ -0,0 +1,134 @@
+package apiserviceavailabilitycontroller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// AggregatedAPIsUnavailableConditionType is True when an aggregated APIService is not available. It is
+	// informational and does not degrade the operator: the kube-apiserver keeps serving, but discovery of the
+	// aggregated group versions fails, which breaks clients enumerating all the APIs like namespace deletion and the
+	// garbage collector. Fixing the backing service is up to its owner.
+	AggregatedAPIsUnavailableConditionType = "KubeAPIServerAggregatedAPIsUnavailable"
+
+	AggregatedAPIsUnavailableReason = "AggregatedAPIsUnavailable"
+	AsExpectedReason                = "AsExpected"
+)
+
+// APIServicesResource is the resource of the APIServices, read through a dynamic informer.
+var APIServicesResource = schema.GroupVersionResource{Group: "apiregistration.k8s.io", Version: "v1", Resource: "apiservices"}
+
+// APIServiceAvailabilityController lists the aggregated APIServices, the ones backed by a service rather than served
+// by the kube-apiserver itself, whose Available condition is not True in the KubeAPIServerAggregatedAPIsUnavailable
+// condition. The APIServices are never modified.
+type APIServiceAvailabilityController struct {
+	factory.Controller
+	operatorClient   v1helpers.OperatorClient
+	apiServiceLister cache.GenericLister
+}
+
+func NewAPIServiceAvailabilityController(
+	operatorClient v1helpers.OperatorClient,
+	apiServiceInformer informers.GenericInformer,
+	recorder events.Recorder,
+) *APIServiceAvailabilityController {
+	c := &APIServiceAvailabilityController{
+		operatorClient:   operatorClient,
+		apiServiceLister: apiServiceInformer.Lister(),
+	}
+	c.Controller = factory.New().
+		WithSync(c.sync).
+		WithInformers(operatorClient.Informer(), apiServiceInformer.Informer()).
+		ToController("APIServiceAvailabilityController", recorder.WithComponentSuffix("apiservice-availability-controller"))
+	return c
+}
+
+func (c *APIServiceAvailabilityController) sync(_ context.Context, syncCtx factory.SyncContext) error {
+	operatorSpec, operatorStatus, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+
+	apiServices, err := c.apiServiceLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	var unavailable []string
+	for _, obj := range apiServices {
+		apiService, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		if reason, ok := unavailableAggregatedAPIService(apiService); ok {
+			unavailable = append(unavailable, fmt.Sprintf("apiservice/%s: %s", apiService.GetName(), reason))
+		}
+	}
+	sort.Strings(unavailable)
+
+	condition := operatorv1.OperatorCondition{
+		Type:   AggregatedAPIsUnavailableConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: AsExpectedReason,
+	}
+	if len(unavailable) > 0 {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = AggregatedAPIsUnavailableReason
+		condition.Message = fmt.Sprintf("Discovery of these aggregated APIs fails: %s", strings.Join(unavailable, "; "))
+	}
+
+	if existing := v1helpers.FindOperatorCondition(operatorStatus.Conditions, AggregatedAPIsUnavailableConditionType); condition.Status == operatorv1.ConditionTrue && (existing == nil || existing.Status != operatorv1.ConditionTrue) {
+		syncCtx.Recorder().Warningf("AggregatedAPIsUnavailable", "%s", condition.Message)
+	}
+
+	_, _, err = v1helpers.UpdateStatus(c.operatorClient, v1helpers.UpdateConditionFn(condition))
+	return err
+}
+
+// unavailableAggregatedAPIService returns why the APIService is unavailable if it is aggregated and its Available
+// condition is not True. An APIService without a service is served locally by the kube-apiserver.
+func unavailableAggregatedAPIService(apiService *unstructured.Unstructured) (string, bool) {
+	service, found, _ := unstructured.NestedMap(apiService.Object, "spec", "service")
+	if !found || service == nil {
+		return "", false
+	}
+	conditions, _, _ := unstructured.NestedSlice(apiService.Object, "status", "conditions")
+	for _, obj := range conditions {
+		condition, ok := obj.(map[string]interface{})
+		if !ok || condition["type"] != "Available" {
+			continue
+		}
+		if condition["status"] == "True" {
+			return "", false
+		}
+		reason, _ := condition["reason"].(string)
+		message, _ := condition["message"].(string)
+		switch {
+		case len(reason) > 0 && len(message) > 0:
+			return fmt.Sprintf("%s: %s", reason, message), true
+		case len(reason) > 0:
+			return reason, true
+		case len(message) > 0:
+			return message, true
+		default:
+			return "not available", true
+		}
+	}
+	return "no Available condition", true
+}
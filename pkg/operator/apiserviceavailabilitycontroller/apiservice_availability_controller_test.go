@@ -0,0 +1,114 @@
+package apiserviceavailabilitycontroller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+)
+
+func apiService(name string, aggregated bool, available string, reason string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiregistration.k8s.io/v1",
+		"kind":       "APIService",
+		"metadata":   map[string]interface{}{"name": name},
+		"spec":       map[string]interface{}{"group": strings.SplitN(name, ".", 2)[1]},
+	}}
+	if aggregated {
+		obj.Object["spec"].(map[string]interface{})["service"] = map[string]interface{}{"namespace": "openshift-apiserver", "name": "api"}
+	}
+	if len(available) > 0 {
+		obj.Object["status"] = map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Available", "status": available, "reason": reason},
+		}}
+	}
+	return obj
+}
+
+func TestAPIServiceAvailabilityController(t *testing.T) {
+	tests := []struct {
+		name              string
+		apiServices       []*unstructured.Unstructured
+		expectedStatus    operatorv1.ConditionStatus
+		expectedUnlisted  []string
+		expectedListed    []string
+		expectedWarnEvent bool
+	}{
+		{
+			name: "all available",
+			apiServices: []*unstructured.Unstructured{
+				apiService("v1.apps", false, "True", "Local"),
+				apiService("v1.apps.openshift.io", true, "True", "Passed"),
+			},
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name: "unavailable aggregated APIServices are listed",
+			apiServices: []*unstructured.Unstructured{
+				apiService("v1.apps", false, "True", "Local"),
+				apiService("v1.apps.openshift.io", true, "True", "Passed"),
+				apiService("v1.build.openshift.io", true, "False", "FailedDiscoveryCheck"),
+				apiService("v1beta1.metrics.k8s.io", true, "", ""),
+			},
+			expectedStatus:    operatorv1.ConditionTrue,
+			expectedListed:    []string{"apiservice/v1.build.openshift.io: FailedDiscoveryCheck", "apiservice/v1beta1.metrics.k8s.io: no Available condition"},
+			expectedUnlisted:  []string{"v1.apps.openshift.io", "v1.apps:"},
+			expectedWarnEvent: true,
+		},
+		{
+			name: "local APIServices are not listed",
+			apiServices: []*unstructured.Unstructured{
+				apiService("v1.apps", false, "False", "Local"),
+			},
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, obj := range test.apiServices {
+				if err := indexer.Add(obj); err != nil {
+					t.Fatal(err)
+				}
+			}
+			operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+			c := &APIServiceAvailabilityController{
+				operatorClient:   operatorClient,
+				apiServiceLister: cache.NewGenericLister(indexer, APIServicesResource.GroupResource()),
+			}
+			recorder := events.NewInMemoryRecorder("test")
+			if err := c.sync(context.TODO(), factory.NewSyncContext("test", recorder)); err != nil {
+				t.Fatal(err)
+			}
+
+			_, status, _, err := operatorClient.GetOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			condition := v1helpers.FindOperatorCondition(status.Conditions, AggregatedAPIsUnavailableConditionType)
+			if condition == nil || condition.Status != test.expectedStatus {
+				t.Fatalf("expected condition status %q, got %#v", test.expectedStatus, condition)
+			}
+			for _, listed := range test.expectedListed {
+				if !strings.Contains(condition.Message, listed) {
+					t.Errorf("expected %q in the message %q", listed, condition.Message)
+				}
+			}
+			for _, unlisted := range test.expectedUnlisted {
+				if strings.Contains(condition.Message, "apiservice/"+unlisted) {
+					t.Errorf("expected %q not to be in the message %q", unlisted, condition.Message)
+				}
+			}
+			if warned := len(recorder.Events()) > 0; warned != test.expectedWarnEvent {
+				t.Errorf("expected warning event %v, got %v", test.expectedWarnEvent, recorder.Events())
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package auditpolicycontroller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configinformers "github.com/openshift/client-go/config/informers/externalversions"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/apiserver/audit"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+	auditpolicy "k8s.io/apiserver/pkg/audit/policy"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CustomPolicyAnnotation is the annotation on the cluster APIServer config holding an inline
+// audit.k8s.io/v1 Policy. The config.openshift.io/v1 API has no field for it, so it is carried
+// as an annotation. When set, it replaces the policy computed from spec.audit.
+const CustomPolicyAnnotation = "audit.openshift.io/custom-policy"
+
+// ExcludedUsersAnnotation is the annotation on the cluster APIServer config holding the users and groups, like health
+// checking service accounts, whose requests are not audited, as YAML:
+//
+//	users: ["system:serviceaccount:openshift-monitoring:prometheus-k8s"]
+//	userGroups: ["system:serviceaccounts:openshift-monitoring"]
+//
+// The None level rules dropping them come first in the rendered policy, before the rules of the profile or custom policy.
+// The broadGroups cannot be excluded.
+const ExcludedUsersAnnotation = "audit.openshift.io/excluded-users"
+
+// broadGroups are the groups of all the users, or of all the users of a kind, or granting cluster-admin. Excluding
+// one of them would switch auditing off for most requests or for the most privileged ones.
+var broadGroups = sets.NewString(
+	"system:authenticated",
+	"system:authenticated:oauth",
+	"system:unauthenticated",
+	"system:masters",
+	"system:cluster-admins",
+	"system:serviceaccounts",
+	"system:nodes",
+)
+
+var knownProfiles = sets.NewString(
+	string(configv1.NoneAuditProfileType),
+	string(configv1.DefaultAuditProfileType),
+	string(configv1.WriteRequestBodiesAuditProfileType),
+	string(configv1.AllRequestBodiesAuditProfileType),
+)
+
+type auditPolicyController struct {
+	apiserverConfigLister                configv1listers.APIServerLister
+	kubeClient                           kubernetes.Interface
+	operatorClient                       v1helpers.OperatorClient
+	targetNamespace, targetConfigMapName string
+}
+
+// NewAuditPolicyController create a controller that watches the config.openshift.io/v1 APIServer object
+// and reconciles a ConfigMap in the target namespace with the audit.k8s.io/v1 policy.yaml file.
+// It behaves like the library-go controller, except that it also honours a custom policy
+// set through the CustomPolicyAnnotation.
+func NewAuditPolicyController(
+	targetNamespace string,
+	targetConfigMapName string,
+	apiserverConfigLister configv1listers.APIServerLister,
+	operatorClient v1helpers.OperatorClient,
+	kubeClient kubernetes.Interface,
+	configInformers configinformers.SharedInformerFactory,
+	kubeInformersForTargetNamesace kubeinformers.SharedInformerFactory,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &auditPolicyController{
+		operatorClient:        operatorClient,
+		apiserverConfigLister: apiserverConfigLister,
+		kubeClient:            kubeClient,
+		targetNamespace:       targetNamespace,
+		targetConfigMapName:   targetConfigMapName,
+	}
+
+	return factory.New().WithSync(c.sync).ResyncEvery(10*time.Second).WithInformers(
+		configInformers.Config().V1().APIServers().Informer(),
+		kubeInformersForTargetNamesace.Core().V1().ConfigMaps().Informer(),
+		operatorClient.Informer(),
+	).ToController("auditPolicyController", eventRecorder.WithComponentSuffix("audit-policy-controller"))
+}
+
+func (c *auditPolicyController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	operatorConfigSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+
+	switch operatorConfigSpec.ManagementState {
+	case operatorv1.Managed:
+	case operatorv1.Unmanaged:
+		return nil
+	case operatorv1.Removed:
+		return c.kubeClient.CoreV1().ConfigMaps(c.targetNamespace).Delete(ctx, c.targetConfigMapName, metav1.DeleteOptions{})
+	default:
+		syncCtx.Recorder().Warningf("ManagementStateUnknown", "Unrecognized operator management state %q", operatorConfigSpec.ManagementState)
+		return nil
+	}
+
+	config, err := c.apiserverConfigLister.Get("cluster")
+	if err != nil {
+		return err
+	}
+
+	err = c.syncAuditPolicy(ctx, config, syncCtx.Recorder())
+
+	// update failing condition
+	cond := operatorv1.OperatorCondition{
+		Type:   "AuditPolicyDegraded",
+		Status: operatorv1.ConditionFalse,
+	}
+	if err != nil {
+		cond.Status = operatorv1.ConditionTrue
+		cond.Reason = "Error"
+		cond.Message = err.Error()
+	}
+	if _, _, updateError := v1helpers.UpdateStatus(c.operatorClient, v1helpers.UpdateConditionFn(cond)); updateError != nil {
+		if err == nil {
+			return updateError
+		}
+	}
+
+	return err
+}
+
+func (c *auditPolicyController) syncAuditPolicy(ctx context.Context, config *configv1.APIServer, recorder events.Recorder) error {
+	var desired *auditv1.Policy
+	if customPolicy, ok := config.Annotations[CustomPolicyAnnotation]; ok {
+		policy, err := parseCustomPolicy([]byte(customPolicy))
+		if err != nil {
+			recorder.Warningf("AuditPolicyInvalid", "Rejected custom audit policy from annotation %s: %v", CustomPolicyAnnotation, err)
+			return err
+		}
+		desired = policy
+	} else {
+		if err := validateCustomRules(config.Spec.Audit.CustomRules); err != nil {
+			recorder.Warningf("AuditPolicyInvalid", "Rejected audit customRules: %v", err)
+			return err
+		}
+		policy, err := audit.GetAuditPolicy(config.Spec.Audit)
+		if err != nil {
+			return err
+		}
+		desired = policy.DeepCopy()
+	}
+	desired.Kind = "Policy"
+	desired.APIVersion = auditv1.SchemeGroupVersion.String()
+
+	if excludedUsers, ok := config.Annotations[ExcludedUsersAnnotation]; ok {
+		dropRules, err := parseExcludedUsers([]byte(excludedUsers))
+		if err != nil {
+			recorder.Warningf("AuditPolicyInvalid", "Rejected audit excluded users from annotation %s: %v", ExcludedUsersAnnotation, err)
+			return err
+		}
+		desired.Rules = append(dropRules, desired.Rules...)
+	}
+
+	bs, err := yaml.Marshal(desired)
+	if err != nil {
+		return err
+	}
+	if _, err := auditpolicy.LoadPolicyFromBytes(bs); err != nil {
+		return fmt.Errorf("invalid rendered audit policy: %v", err)
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: c.targetNamespace,
+			Name:      c.targetConfigMapName,
+		},
+		Data: map[string]string{
+			"policy.yaml": string(bs),
+		},
+	}
+
+	_, _, err = resourceapply.ApplyConfigMap(ctx, c.kubeClient.CoreV1(), recorder, cm)
+	return err
+}
+
+// validateCustomRules checks that every custom rule of spec.audit names a group and one of the known profiles. The
+// rules of the custom rules' profiles, restricted to their group, are layered in order on top of the ones of the
+// top-level profile, so that the first matching custom rule applies.
+func validateCustomRules(rules []configv1.AuditCustomRule) error {
+	for i, rule := range rules {
+		if len(rule.Group) == 0 {
+			return fmt.Errorf("customRules[%d]: group must not be empty", i)
+		}
+		if !knownProfiles.Has(string(rule.Profile)) {
+			return fmt.Errorf("customRules[%d]: unknown profile %q for group %q, must be one of %s", i, rule.Profile, rule.Group, strings.Join(knownProfiles.List(), ", "))
+		}
+	}
+	return nil
+}
+
+// excludedUsers is the content of the ExcludedUsersAnnotation.
+type excludedUsers struct {
+	Users      []string `json:"users,omitempty"`
+	UserGroups []string `json:"userGroups,omitempty"`
+}
+
+// parseExcludedUsers returns the None level rules dropping the requests of the excluded users and groups. Users and
+// groups get a rule each, a rule naming both only matches the excluded users that are also in one of the groups.
+func parseExcludedUsers(raw []byte) ([]auditv1.PolicyRule, error) {
+	rawJSON, err := yaml.YAMLToJSON(raw)
+	if err != nil {
+		return nil, err
+	}
+	excluded := excludedUsers{}
+	decoder := json.NewDecoder(bytes.NewReader(rawJSON))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&excluded); err != nil {
+		return nil, err
+	}
+	if len(excluded.Users) == 0 && len(excluded.UserGroups) == 0 {
+		return nil, fmt.Errorf("no users or userGroups")
+	}
+	if err := validateExcludedNames("users", excluded.Users); err != nil {
+		return nil, err
+	}
+	if err := validateExcludedNames("userGroups", excluded.UserGroups); err != nil {
+		return nil, err
+	}
+	for i, group := range excluded.UserGroups {
+		if broadGroups.Has(group) {
+			return nil, fmt.Errorf("userGroups[%d]: %q is too broad to be excluded from auditing", i, group)
+		}
+	}
+
+	var rules []auditv1.PolicyRule
+	if len(excluded.Users) > 0 {
+		rules = append(rules, auditv1.PolicyRule{Level: auditv1.LevelNone, Users: excluded.Users})
+	}
+	if len(excluded.UserGroups) > 0 {
+		rules = append(rules, auditv1.PolicyRule{Level: auditv1.LevelNone, UserGroups: excluded.UserGroups})
+	}
+	return rules, nil
+}
+
+func validateExcludedNames(field string, names []string) error {
+	for i, name := range names {
+		if len(strings.TrimSpace(name)) == 0 || strings.TrimSpace(name) != name {
+			return fmt.Errorf("%s[%d]: %q must not be empty or have surrounding whitespace", field, i, name)
+		}
+	}
+	return nil
+}
+
+// parseCustomPolicy validates the given policy with the same loader the kube-apiserver uses for
+// --audit-policy-file and returns it as an audit.k8s.io/v1 Policy. Older API versions are rejected,
+// as they are deprecated and the rendered policy is always v1.
+func parseCustomPolicy(raw []byte) (*auditv1.Policy, error) {
+	if _, err := auditpolicy.LoadPolicyFromBytes(raw); err != nil {
+		return nil, err
+	}
+
+	policy := &auditv1.Policy{}
+	if err := yaml.Unmarshal(raw, policy); err != nil {
+		return nil, err
+	}
+	if policy.APIVersion != auditv1.SchemeGroupVersion.String() {
+		return nil, fmt.Errorf("unsupported apiVersion %q, only %q is allowed", policy.APIVersion, auditv1.SchemeGroupVersion.String())
+	}
+
+	return policy, nil
+}
@@ -0,0 +1,227 @@
+package auditpolicycontroller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+const validCustomPolicy = `apiVersion: audit.k8s.io/v1
+kind: Policy
+omitStages:
+- RequestReceived
+rules:
+- level: None
+  resources:
+  - group: ""
+    resources: ["events"]
+- level: RequestResponse
+  userGroups: ["system:authenticated:oauth"]
+  verbs: ["create", "update", "patch", "delete"]
+- level: Metadata
+`
+
+const invalidCustomPolicy = `apiVersion: audit.k8s.io/v1
+kind: Policy
+rules:
+- level: Verbose
+  nonResourceURLs: ["healthz*"]
+`
+
+func TestAuditPolicyControllerSync(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		annotations    map[string]string
+		customRules    []configv1.AuditCustomRule
+		expectErr      bool
+		expectDegraded operatorv1.ConditionStatus
+		expectInPolicy []string
+		expectOrder    []string
+		expectEvent    string
+	}{
+		{
+			name:           "profile based policy",
+			expectDegraded: operatorv1.ConditionFalse,
+			expectInPolicy: []string{"kind: Policy", "level: Metadata"},
+		},
+		{
+			name:           "valid custom policy",
+			annotations:    map[string]string{CustomPolicyAnnotation: validCustomPolicy},
+			expectDegraded: operatorv1.ConditionFalse,
+			expectInPolicy: []string{"kind: Policy", "level: RequestResponse", "system:authenticated:oauth", "- RequestReceived"},
+		},
+		{
+			name:           "single custom rule",
+			customRules:    []configv1.AuditCustomRule{{Group: "system:authenticated:oauth", Profile: configv1.AllRequestBodiesAuditProfileType}},
+			expectDegraded: operatorv1.ConditionFalse,
+			expectInPolicy: []string{"kind: Policy", "level: RequestResponse"},
+			expectOrder:    []string{"- system:authenticated:oauth", "level: Metadata\n  omitStages"},
+		},
+		{
+			name: "multiple custom rules",
+			customRules: []configv1.AuditCustomRule{
+				{Group: "system:serviceaccounts:openshift-monitoring", Profile: configv1.NoneAuditProfileType},
+				{Group: "system:authenticated:oauth", Profile: configv1.WriteRequestBodiesAuditProfileType},
+			},
+			expectDegraded: operatorv1.ConditionFalse,
+			expectOrder:    []string{"- system:serviceaccounts:openshift-monitoring", "- system:authenticated:oauth", "level: Metadata\n  omitStages"},
+		},
+		{
+			name:           "custom rule with an unknown profile",
+			customRules:    []configv1.AuditCustomRule{{Group: "system:authenticated:oauth", Profile: "Verbose"}},
+			expectErr:      true,
+			expectDegraded: operatorv1.ConditionTrue,
+			expectEvent:    "AuditPolicyInvalid",
+		},
+		{
+			name:           "custom rule without a group",
+			customRules:    []configv1.AuditCustomRule{{Profile: configv1.AllRequestBodiesAuditProfileType}},
+			expectErr:      true,
+			expectDegraded: operatorv1.ConditionTrue,
+			expectEvent:    "AuditPolicyInvalid",
+		},
+		{
+			name: "excluded users before the profile rules",
+			annotations: map[string]string{ExcludedUsersAnnotation: `users: ["system:serviceaccount:openshift-monitoring:prometheus-k8s"]
+userGroups: ["system:serviceaccounts:openshift-monitoring"]`},
+			expectDegraded: operatorv1.ConditionFalse,
+			expectOrder: []string{
+				"level: None\n  users:\n  - system:serviceaccount:openshift-monitoring:prometheus-k8s",
+				"level: None\n  userGroups:\n  - system:serviceaccounts:openshift-monitoring",
+				"level: Metadata\n  omitStages",
+			},
+		},
+		{
+			name:           "excluded users before the custom policy rules",
+			annotations:    map[string]string{CustomPolicyAnnotation: validCustomPolicy, ExcludedUsersAnnotation: `users: ["health-checker"]`},
+			expectDegraded: operatorv1.ConditionFalse,
+			expectOrder:    []string{"- health-checker", "resources:\n    - events", "system:authenticated:oauth"},
+		},
+		{
+			name:           "empty excluded user",
+			annotations:    map[string]string{ExcludedUsersAnnotation: `users: ["health-checker", ""]`},
+			expectErr:      true,
+			expectDegraded: operatorv1.ConditionTrue,
+			expectEvent:    "AuditPolicyInvalid",
+		},
+		{
+			name:           "excluded broad group",
+			annotations:    map[string]string{ExcludedUsersAnnotation: `userGroups: ["system:serviceaccounts:openshift-monitoring", "system:authenticated"]`},
+			expectErr:      true,
+			expectDegraded: operatorv1.ConditionTrue,
+			expectEvent:    "AuditPolicyInvalid",
+		},
+		{
+			name:           "unknown excluded users field",
+			annotations:    map[string]string{ExcludedUsersAnnotation: `user: health-checker`},
+			expectErr:      true,
+			expectDegraded: operatorv1.ConditionTrue,
+			expectEvent:    "AuditPolicyInvalid",
+		},
+		{
+			name:           "malformed custom policy",
+			annotations:    map[string]string{CustomPolicyAnnotation: invalidCustomPolicy},
+			expectErr:      true,
+			expectDegraded: operatorv1.ConditionTrue,
+			expectEvent:    "AuditPolicyInvalid",
+		},
+		{
+			name:           "deprecated custom policy version",
+			annotations:    map[string]string{CustomPolicyAnnotation: strings.Replace(validCustomPolicy, "audit.k8s.io/v1", "audit.k8s.io/v1beta1", 1)},
+			expectErr:      true,
+			expectDegraded: operatorv1.ConditionTrue,
+			expectEvent:    "AuditPolicyInvalid",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := indexer.Add(&configv1.APIServer{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster", Annotations: tc.annotations},
+				Spec:       configv1.APIServerSpec{Audit: configv1.Audit{Profile: configv1.DefaultAuditProfileType, CustomRules: tc.customRules}},
+			}); err != nil {
+				t.Fatal(err)
+			}
+			kubeClient := fake.NewSimpleClientset()
+			operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+			recorder := events.NewInMemoryRecorder("test")
+
+			c := &auditPolicyController{
+				apiserverConfigLister: configlistersv1.NewAPIServerLister(indexer),
+				kubeClient:            kubeClient,
+				operatorClient:        operatorClient,
+				targetNamespace:       "openshift-kube-apiserver",
+				targetConfigMapName:   "kube-apiserver-audit-policies",
+			}
+
+			err := c.sync(context.TODO(), factory.NewSyncContext("test", recorder))
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+
+			_, status, _, _ := operatorClient.GetOperatorState()
+			if cond := v1helpers.FindOperatorCondition(status.Conditions, "AuditPolicyDegraded"); cond == nil || cond.Status != tc.expectDegraded {
+				t.Errorf("expected AuditPolicyDegraded=%s, got %#v", tc.expectDegraded, cond)
+			}
+
+			cm, getErr := kubeClient.CoreV1().ConfigMaps("openshift-kube-apiserver").Get(context.TODO(), "kube-apiserver-audit-policies", metav1.GetOptions{})
+			if tc.expectErr {
+				if getErr == nil {
+					t.Errorf("expected no configmap to be written, got %v", cm.Data)
+				}
+			} else {
+				if getErr != nil {
+					t.Fatal(getErr)
+				}
+				for _, s := range tc.expectInPolicy {
+					if !strings.Contains(cm.Data["policy.yaml"], s) {
+						t.Errorf("expected policy to contain %q, got:\n%s", s, cm.Data["policy.yaml"])
+					}
+				}
+				policy := cm.Data["policy.yaml"]
+				for _, s := range tc.expectOrder {
+					i := strings.Index(policy, s)
+					if i < 0 {
+						t.Errorf("expected %q next in the policy, got:\n%s", s, cm.Data["policy.yaml"])
+						break
+					}
+					policy = policy[i+len(s):]
+				}
+			}
+
+			if len(tc.expectEvent) > 0 {
+				found := false
+				for _, ev := range recorder.Events() {
+					if ev.Reason == tc.expectEvent {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("expected %q event, got %v", tc.expectEvent, recorder.Events())
+				}
+			}
+		})
+	}
+}
+
+func TestParseExcludedUsersRejectsBroadGroups(t *testing.T) {
+	for _, group := range broadGroups.List() {
+		if _, err := parseExcludedUsers([]byte(`userGroups: ["` + group + `"]`)); err == nil || !strings.Contains(err.Error(), "too broad") {
+			t.Errorf("expected %q to be rejected as too broad, got %v", group, err)
+		}
+	}
+	for _, group := range []string{"system:serviceaccounts:openshift-monitoring", "health-checkers"} {
+		if _, err := parseExcludedUsers([]byte(`userGroups: ["` + group + `"]`)); err != nil {
+			t.Errorf("expected %q to be excluded, got %v", group, err)
+		}
+	}
+}
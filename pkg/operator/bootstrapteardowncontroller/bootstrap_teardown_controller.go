@@ -0,0 +1,152 @@
+package bootstrapteardowncontroller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+const (
+	// BootstrapTeardownSafeConditionType is True once the bootstrap control plane can be torn down without
+	// losing the kube-apiserver. The installer waits for it before tearing the bootstrap node down.
+	BootstrapTeardownSafeConditionType = "BootstrapTeardownSafe"
+
+	QuorumAvailableReason  = "QuorumAvailable"
+	WaitingForQuorumReason = "WaitingForQuorum"
+	NoMastersReason        = "NoMasters"
+)
+
+var kubeAPIServerPodSelector = labels.SelectorFromSet(labels.Set{"app": "openshift-kube-apiserver"})
+
+// BootstrapTeardownController sets BootstrapTeardownSafe=True when a quorum of the masters serves a revision
+// of the kube-apiserver, i.e. when a ready kube-apiserver pod of a revision runs on more than half of the
+// masters. The bootstrap kube-apiserver is not a revision and never counts.
+type BootstrapTeardownController interface {
+	factory.Controller
+}
+
+func NewBootstrapTeardownController(
+	operatorClient v1helpers.StaticPodOperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	recorder events.Recorder,
+) *bootstrapTeardownController {
+	podInformer := kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Pods()
+	c := &bootstrapTeardownController{
+		operatorClient: operatorClient,
+		podLister:      podInformer.Lister(),
+	}
+	c.Controller = factory.New().
+		WithSync(c.sync).
+		WithInformers(operatorClient.Informer(), podInformer.Informer()).
+		ToController("BootstrapTeardownController", recorder.WithComponentSuffix("bootstrap-teardown-controller"))
+	return c
+}
+
+type bootstrapTeardownController struct {
+	factory.Controller
+	operatorClient v1helpers.StaticPodOperatorClient
+	podLister      corev1listers.PodLister
+}
+
+func (c *bootstrapTeardownController) sync(_ context.Context, syncCtx factory.SyncContext) error {
+	operatorSpec, operatorStatus, _, err := c.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+
+	pods, err := c.podLister.Pods(operatorclient.TargetNamespace).List(kubeAPIServerPodSelector)
+	if err != nil {
+		return err
+	}
+
+	condition := teardownCondition(operatorStatus.NodeStatuses, pods)
+	if existing := v1helpers.FindOperatorCondition(operatorStatus.Conditions, BootstrapTeardownSafeConditionType); condition.Status == operatorv1.ConditionTrue && (existing == nil || existing.Status != operatorv1.ConditionTrue) {
+		syncCtx.Recorder().Eventf("BootstrapTeardownSafe", "%s", condition.Message)
+	}
+
+	_, _, err = v1helpers.UpdateStaticPodStatus(c.operatorClient, v1helpers.UpdateStaticPodConditionFn(condition))
+	return err
+}
+
+// teardownCondition computes the BootstrapTeardownSafe condition for the masters tracked in the node statuses.
+func teardownCondition(nodeStatuses []operatorv1.NodeStatus, pods []*corev1.Pod) operatorv1.OperatorCondition {
+	condition := operatorv1.OperatorCondition{
+		Type:   BootstrapTeardownSafeConditionType,
+		Status: operatorv1.ConditionFalse,
+	}
+	if len(nodeStatuses) == 0 {
+		condition.Reason = NoMastersReason
+		condition.Message = "No master nodes are known yet"
+		return condition
+	}
+
+	available := availableNodes(nodeStatuses, pods)
+	quorum := len(nodeStatuses)/2 + 1
+	if len(available) < quorum {
+		condition.Reason = WaitingForQuorumReason
+		condition.Message = fmt.Sprintf("%d of %d masters serve a kube-apiserver revision, %d are required", len(available), len(nodeStatuses), quorum)
+		if len(available) > 0 {
+			condition.Message += fmt.Sprintf(": %s", strings.Join(available, ", "))
+		}
+		return condition
+	}
+
+	condition.Status = operatorv1.ConditionTrue
+	condition.Reason = QuorumAvailableReason
+	condition.Message = fmt.Sprintf("%d of %d masters serve a kube-apiserver revision: %s", len(available), len(nodeStatuses), strings.Join(available, ", "))
+	return condition
+}
+
+// availableNodes returns the sorted names of the masters running a ready kube-apiserver pod of a revision
+// that the node has been installed with.
+func availableNodes(nodeStatuses []operatorv1.NodeStatus, pods []*corev1.Pod) []string {
+	var available []string
+	for _, ns := range nodeStatuses {
+		if ns.CurrentRevision == 0 {
+			continue
+		}
+		for _, pod := range pods {
+			if pod.Spec.NodeName != ns.NodeName {
+				continue
+			}
+			revision, err := strconv.Atoi(pod.Labels["revision"])
+			if err != nil || revision == 0 {
+				continue
+			}
+			if isPodReady(pod) {
+				available = append(available, ns.NodeName)
+				break
+			}
+		}
+	}
+	sort.Strings(available)
+	return available
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
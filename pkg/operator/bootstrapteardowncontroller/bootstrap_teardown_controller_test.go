@@ -0,0 +1,227 @@
+package bootstrapteardowncontroller
+
+import (
+	"context"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+func kubeAPIServerPod(node, revision string, ready bool) *corev1.Pod {
+	readyStatus := corev1.ConditionFalse
+	if ready {
+		readyStatus = corev1.ConditionTrue
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: operatorclient.TargetNamespace,
+			Name:      "kube-apiserver-" + node,
+			Labels:    map[string]string{"app": "openshift-kube-apiserver", "revision": revision},
+		},
+		Spec: corev1.PodSpec{NodeName: node},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: readyStatus}},
+		},
+	}
+}
+
+func threeMasters(currentRevisions ...int32) []operatorv1.NodeStatus {
+	nodeStatuses := []operatorv1.NodeStatus{}
+	for i, name := range []string{"master-0", "master-1", "master-2"} {
+		nodeStatuses = append(nodeStatuses, operatorv1.NodeStatus{NodeName: name, CurrentRevision: currentRevisions[i]})
+	}
+	return nodeStatuses
+}
+
+func TestBootstrapTeardownControllerSync(t *testing.T) {
+	testCases := []struct {
+		name            string
+		nodeStatuses    []operatorv1.NodeStatus
+		pods            []*corev1.Pod
+		expectedStatus  operatorv1.ConditionStatus
+		expectedMessage string
+	}{
+		{
+			name:            "NoMasters",
+			expectedStatus:  operatorv1.ConditionFalse,
+			expectedMessage: "No master nodes are known yet",
+		},
+		{
+			name:            "NothingInstalled",
+			nodeStatuses:    threeMasters(0, 0, 0),
+			expectedStatus:  operatorv1.ConditionFalse,
+			expectedMessage: "0 of 3 masters serve a kube-apiserver revision, 2 are required",
+		},
+		{
+			name:         "OneOfThree",
+			nodeStatuses: threeMasters(2, 0, 0),
+			pods: []*corev1.Pod{
+				kubeAPIServerPod("master-0", "2", true),
+			},
+			expectedStatus:  operatorv1.ConditionFalse,
+			expectedMessage: "1 of 3 masters serve a kube-apiserver revision, 2 are required: master-0",
+		},
+		{
+			name:         "SecondMasterNotReady",
+			nodeStatuses: threeMasters(2, 2, 0),
+			pods: []*corev1.Pod{
+				kubeAPIServerPod("master-0", "2", true),
+				kubeAPIServerPod("master-1", "2", false),
+			},
+			expectedStatus:  operatorv1.ConditionFalse,
+			expectedMessage: "1 of 3 masters serve a kube-apiserver revision, 2 are required: master-0",
+		},
+		{
+			name:         "SecondMasterNotInstalled",
+			nodeStatuses: threeMasters(2, 0, 0),
+			pods: []*corev1.Pod{
+				kubeAPIServerPod("master-0", "2", true),
+				// the installer has not reported the revision yet
+				kubeAPIServerPod("master-1", "2", true),
+			},
+			expectedStatus:  operatorv1.ConditionFalse,
+			expectedMessage: "1 of 3 masters serve a kube-apiserver revision, 2 are required: master-0",
+		},
+		{
+			name:         "UnlabeledPodDoesNotCount",
+			nodeStatuses: threeMasters(2, 2, 0),
+			pods: []*corev1.Pod{
+				kubeAPIServerPod("master-0", "2", true),
+				kubeAPIServerPod("master-1", "", true),
+			},
+			expectedStatus:  operatorv1.ConditionFalse,
+			expectedMessage: "1 of 3 masters serve a kube-apiserver revision, 2 are required: master-0",
+		},
+		{
+			name:         "TwoOfThree",
+			nodeStatuses: threeMasters(2, 3, 0),
+			pods: []*corev1.Pod{
+				kubeAPIServerPod("master-0", "2", true),
+				kubeAPIServerPod("master-1", "3", true),
+			},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedMessage: "2 of 3 masters serve a kube-apiserver revision: master-0, master-1",
+		},
+		{
+			name: "SingleMaster",
+			nodeStatuses: []operatorv1.NodeStatus{
+				{NodeName: "master-0", CurrentRevision: 1},
+			},
+			pods: []*corev1.Pod{
+				kubeAPIServerPod("master-0", "1", true),
+			},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedMessage: "1 of 1 masters serve a kube-apiserver revision: master-0",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for _, pod := range tc.pods {
+				if err := indexer.Add(pod); err != nil {
+					t.Fatal(err)
+				}
+			}
+			operatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+				&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+				&operatorv1.StaticPodOperatorStatus{NodeStatuses: tc.nodeStatuses},
+				nil,
+				nil,
+			)
+			c := &bootstrapTeardownController{
+				operatorClient: operatorClient,
+				podLister:      corev1listers.NewPodLister(indexer),
+			}
+			if err := c.sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+				t.Fatal(err)
+			}
+
+			_, status, _, err := operatorClient.GetStaticPodOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			condition := v1helpers.FindOperatorCondition(status.Conditions, BootstrapTeardownSafeConditionType)
+			if condition == nil {
+				t.Fatalf("expected %s condition", BootstrapTeardownSafeConditionType)
+			}
+			if condition.Status != tc.expectedStatus || condition.Message != tc.expectedMessage {
+				t.Errorf("expected %s %q, got %s %q", tc.expectedStatus, tc.expectedMessage, condition.Status, condition.Message)
+			}
+		})
+	}
+}
+
+func TestBootstrapTeardownControllerPartialRollout(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	operatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+		&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+		&operatorv1.StaticPodOperatorStatus{NodeStatuses: threeMasters(0, 0, 0)},
+		nil,
+		nil,
+	)
+	c := &bootstrapTeardownController{
+		operatorClient: operatorClient,
+		podLister:      corev1listers.NewPodLister(indexer),
+	}
+	recorder := events.NewInMemoryRecorder("test")
+	syncCtx := factory.NewSyncContext("test", recorder)
+
+	// installs the revision on a master and starts its kube-apiserver
+	rollOut := func(master int, ready bool) {
+		t.Helper()
+		_, status, rv, err := operatorClient.GetStaticPodOperatorState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		status = status.DeepCopy()
+		status.NodeStatuses[master].CurrentRevision = 1
+		if _, err := operatorClient.UpdateStaticPodOperatorStatus(rv, status); err != nil {
+			t.Fatal(err)
+		}
+		if err := indexer.Update(kubeAPIServerPod(status.NodeStatuses[master].NodeName, "1", ready)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectGate := func(expected operatorv1.ConditionStatus) {
+		t.Helper()
+		if err := c.sync(context.TODO(), syncCtx); err != nil {
+			t.Fatal(err)
+		}
+		_, status, _, err := operatorClient.GetStaticPodOperatorState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !v1helpers.IsOperatorConditionPresentAndEqual(status.Conditions, BootstrapTeardownSafeConditionType, expected) {
+			t.Fatalf("expected %s=%s, got %v", BootstrapTeardownSafeConditionType, expected, status.Conditions)
+		}
+	}
+
+	expectGate(operatorv1.ConditionFalse)
+	rollOut(0, true)
+	expectGate(operatorv1.ConditionFalse)
+	rollOut(1, false)
+	expectGate(operatorv1.ConditionFalse)
+	rollOut(1, true)
+	expectGate(operatorv1.ConditionTrue)
+	rollOut(2, true)
+	expectGate(operatorv1.ConditionTrue)
+
+	reasons := []string{}
+	for _, ev := range recorder.Events() {
+		reasons = append(reasons, ev.Reason)
+	}
+	if len(reasons) != 1 || reasons[0] != "BootstrapTeardownSafe" {
+		t.Errorf("expected a single BootstrapTeardownSafe event, got %v", reasons)
+	}
+}
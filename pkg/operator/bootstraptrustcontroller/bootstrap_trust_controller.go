@@ -0,0 +1,169 @@
+package bootstraptrustcontroller
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	certutil "k8s.io/client-go/util/cert"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+const (
+	// StaleBootstrapTrustConditionType is True when the kube-apiserver still trusts a bootstrap client CA other than the
+	// one of the kubelet-bootstrap-kubeconfig config map after the bootstrap control plane has been torn down.
+	StaleBootstrapTrustConditionType = "StaleBootstrapTrust"
+
+	BootstrapClientCATrustedReason = "BootstrapClientCATrusted"
+	BootstrapInProgressReason      = "BootstrapInProgress"
+	AsExpectedReason               = "AsExpected"
+
+	// bootstrapClientCAConfigMapName in the openshift-config-managed namespace holds the client CA of the kubelet
+	// bootstrap credential. It is combined into the client CA bundle of the kube-apiserver on purpose, the kubelets of
+	// new nodes bootstrap with it.
+	bootstrapClientCAConfigMapName = "kubelet-bootstrap-kubeconfig"
+
+	// bootstrapSignerCommonName is the subject of the bootstrap client CAs generated by the installer. It identifies
+	// them in the client CA bundle when they are no longer in the kubelet-bootstrap-kubeconfig config map.
+	bootstrapSignerCommonName = "kubelet-bootstrap-kubeconfig-signer"
+)
+
+// BootstrapTrustController verifies that no unexpected bootstrap client CA is trusted once the installation completed,
+// as recorded by the kube-system/bootstrap config map. The CA of the kubelet-bootstrap-kubeconfig config map is
+// expected in the client CA bundle of the kube-apiserver. Any other bootstrap CA, e.g. one left over after that CA
+// was replaced, keeps every credential it issued accepted. StaleBootstrapTrust is True while the bundle contains one
+// past the teardown.
+type BootstrapTrustController struct {
+	factory.Controller
+	operatorClient               v1helpers.StaticPodOperatorClient
+	kubeSystemConfigMapLister    corev1listers.ConfigMapNamespaceLister
+	configManagedConfigMapLister corev1listers.ConfigMapNamespaceLister
+	targetConfigMapLister        corev1listers.ConfigMapNamespaceLister
+}
+
+func NewBootstrapTrustController(
+	operatorClient v1helpers.StaticPodOperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	recorder events.Recorder,
+) *BootstrapTrustController {
+	kubeSystemInformer := kubeInformersForNamespaces.InformersFor("kube-system").Core().V1().ConfigMaps()
+	configManagedInformer := kubeInformersForNamespaces.InformersFor(operatorclient.GlobalMachineSpecifiedConfigNamespace).Core().V1().ConfigMaps()
+	targetNamespaceInformer := kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().ConfigMaps()
+	c := &BootstrapTrustController{
+		operatorClient:               operatorClient,
+		kubeSystemConfigMapLister:    kubeSystemInformer.Lister().ConfigMaps("kube-system"),
+		configManagedConfigMapLister: configManagedInformer.Lister().ConfigMaps(operatorclient.GlobalMachineSpecifiedConfigNamespace),
+		targetConfigMapLister:        targetNamespaceInformer.Lister().ConfigMaps(operatorclient.TargetNamespace),
+	}
+	c.Controller = factory.New().
+		WithSync(c.sync).
+		WithInformers(operatorClient.Informer(), kubeSystemInformer.Informer(), configManagedInformer.Informer(), targetNamespaceInformer.Informer()).
+		ToController("BootstrapTrustController", recorder.WithComponentSuffix("bootstrap-trust-controller"))
+	return c
+}
+
+func (c *BootstrapTrustController) sync(_ context.Context, syncCtx factory.SyncContext) error {
+	operatorSpec, operatorStatus, _, err := c.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+
+	condition, err := c.trustCondition()
+	if err != nil {
+		return err
+	}
+	if existing := v1helpers.FindOperatorCondition(operatorStatus.Conditions, StaleBootstrapTrustConditionType); condition.Status == operatorv1.ConditionTrue && (existing == nil || existing.Status != operatorv1.ConditionTrue) {
+		syncCtx.Recorder().Warningf("StaleBootstrapTrust", "%s", condition.Message)
+	}
+
+	_, _, err = v1helpers.UpdateStaticPodStatus(c.operatorClient, v1helpers.UpdateStaticPodConditionFn(condition))
+	return err
+}
+
+// trustCondition computes the StaleBootstrapTrust condition.
+func (c *BootstrapTrustController) trustCondition() (operatorv1.OperatorCondition, error) {
+	condition := operatorv1.OperatorCondition{
+		Type:   StaleBootstrapTrustConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: AsExpectedReason,
+	}
+
+	bootstrap, err := c.kubeSystemConfigMapLister.Get("bootstrap")
+	if err != nil && !apierrors.IsNotFound(err) {
+		return condition, err
+	}
+	if err != nil || bootstrap.Data["status"] != "complete" {
+		condition.Reason = BootstrapInProgressReason
+		condition.Message = "The bootstrap control plane has not been torn down yet"
+		return condition, nil
+	}
+
+	var expectedCAs []*x509.Certificate
+	switch source, err := c.configManagedConfigMapLister.Get(bootstrapClientCAConfigMapName); {
+	case apierrors.IsNotFound(err):
+	case err != nil:
+		return condition, err
+	default:
+		if expectedCAs, err = certutil.ParseCertsPEM([]byte(source.Data["ca-bundle.crt"])); err != nil {
+			return condition, fmt.Errorf("failed to parse %s/%s: %w", operatorclient.GlobalMachineSpecifiedConfigNamespace, bootstrapClientCAConfigMapName, err)
+		}
+	}
+
+	clientCA, err := c.targetConfigMapLister.Get("client-ca")
+	if apierrors.IsNotFound(err) {
+		return condition, nil
+	}
+	if err != nil {
+		return condition, err
+	}
+	trusted, err := certutil.ParseCertsPEM([]byte(clientCA.Data["ca-bundle.crt"]))
+	if err != nil {
+		return condition, fmt.Errorf("failed to parse %s/client-ca: %w", operatorclient.TargetNamespace, err)
+	}
+
+	var stale []string
+	for _, cert := range trusted {
+		if !isUnexpectedBootstrapCA(cert, expectedCAs) {
+			continue
+		}
+		stale = append(stale, fmt.Sprintf("%q (valid until %s)", cert.Subject.CommonName, cert.NotAfter.UTC().Format(time.RFC3339)))
+	}
+	if len(stale) == 0 {
+		return condition, nil
+	}
+	sort.Strings(stale)
+
+	condition.Status = operatorv1.ConditionTrue
+	condition.Reason = BootstrapClientCATrustedReason
+	condition.Message = fmt.Sprintf("The bootstrap control plane has been torn down but the kube-apiserver still trusts the bootstrap client CA %s, which is not the CA of the %s/%s config map", strings.Join(stale, ", "), operatorclient.GlobalMachineSpecifiedConfigNamespace, bootstrapClientCAConfigMapName)
+	return condition, nil
+}
+
+// isUnexpectedBootstrapCA returns whether the cert was issued as a bootstrap client CA by the installer and is not one
+// of the expected CAs of the kubelet-bootstrap-kubeconfig config map.
+func isUnexpectedBootstrapCA(cert *x509.Certificate, expectedCAs []*x509.Certificate) bool {
+	if cert.Subject.CommonName != bootstrapSignerCommonName {
+		return false
+	}
+	for _, expectedCA := range expectedCAs {
+		if bytes.Equal(cert.Raw, expectedCA.Raw) {
+			return false
+		}
+	}
+	return true
+}
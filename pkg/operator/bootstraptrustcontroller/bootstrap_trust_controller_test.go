@@ -0,0 +1,151 @@
+package bootstraptrustcontroller
+
+import (
+	"context"
+	"crypto/x509"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+func caBundle(t *testing.T, names ...string) string {
+	t.Helper()
+	var certs []*x509.Certificate
+	for _, name := range names {
+		config, err := crypto.MakeSelfSignedCAConfigForDuration(name, 24*time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		certs = append(certs, config.Certs...)
+	}
+	bundle, err := crypto.EncodeCertificates(certs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(bundle)
+}
+
+func configMap(namespace, name string, data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}, Data: data}
+}
+
+func TestBootstrapTrustControllerSync(t *testing.T) {
+	bootstrapCA := caBundle(t, bootstrapSignerCommonName)
+	leftoverBootstrapCA := caBundle(t, bootstrapSignerCommonName)
+	otherCA := caBundle(t, "admin-kubeconfig-signer")
+
+	testCases := []struct {
+		name           string
+		configMaps     []*corev1.ConfigMap
+		expectedStatus operatorv1.ConditionStatus
+		expectedReason string
+		// expectedStale is the number of CAs the message lists
+		expectedStale int
+	}{
+		{
+			name: "BootstrapInProgress",
+			configMaps: []*corev1.ConfigMap{
+				configMap("kube-system", "bootstrap", map[string]string{"status": "progressing"}),
+				configMap(operatorclient.GlobalMachineSpecifiedConfigNamespace, "kubelet-bootstrap-kubeconfig", map[string]string{"ca-bundle.crt": bootstrapCA}),
+				configMap(operatorclient.TargetNamespace, "client-ca", map[string]string{"ca-bundle.crt": otherCA + bootstrapCA}),
+			},
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: BootstrapInProgressReason,
+		},
+		{
+			name: "ExpectedBootstrapCATrusted",
+			configMaps: []*corev1.ConfigMap{
+				configMap("kube-system", "bootstrap", map[string]string{"status": "complete"}),
+				configMap(operatorclient.GlobalMachineSpecifiedConfigNamespace, "kubelet-bootstrap-kubeconfig", map[string]string{"ca-bundle.crt": bootstrapCA}),
+				configMap(operatorclient.TargetNamespace, "client-ca", map[string]string{"ca-bundle.crt": otherCA + bootstrapCA}),
+			},
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: AsExpectedReason,
+		},
+		{
+			name: "StaleTrustPresent",
+			configMaps: []*corev1.ConfigMap{
+				configMap("kube-system", "bootstrap", map[string]string{"status": "complete"}),
+				configMap(operatorclient.GlobalMachineSpecifiedConfigNamespace, "kubelet-bootstrap-kubeconfig", map[string]string{"ca-bundle.crt": bootstrapCA}),
+				configMap(operatorclient.TargetNamespace, "client-ca", map[string]string{"ca-bundle.crt": otherCA + leftoverBootstrapCA + bootstrapCA}),
+			},
+			expectedStatus: operatorv1.ConditionTrue,
+			expectedReason: BootstrapClientCATrustedReason,
+			expectedStale:  1,
+		},
+		{
+			name: "StaleTrustPresentAfterSourceRemoval",
+			configMaps: []*corev1.ConfigMap{
+				configMap("kube-system", "bootstrap", map[string]string{"status": "complete"}),
+				configMap(operatorclient.TargetNamespace, "client-ca", map[string]string{"ca-bundle.crt": otherCA + leftoverBootstrapCA}),
+			},
+			expectedStatus: operatorv1.ConditionTrue,
+			expectedReason: BootstrapClientCATrustedReason,
+			expectedStale:  1,
+		},
+		{
+			name: "Revoked",
+			configMaps: []*corev1.ConfigMap{
+				configMap("kube-system", "bootstrap", map[string]string{"status": "complete"}),
+				configMap(operatorclient.TargetNamespace, "client-ca", map[string]string{"ca-bundle.crt": otherCA}),
+			},
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: AsExpectedReason,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for _, cm := range tc.configMaps {
+				if err := indexer.Add(cm); err != nil {
+					t.Fatal(err)
+				}
+			}
+			lister := corev1listers.NewConfigMapLister(indexer)
+			operatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+				&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+				&operatorv1.StaticPodOperatorStatus{},
+				nil,
+				nil,
+			)
+			c := &BootstrapTrustController{
+				operatorClient:               operatorClient,
+				kubeSystemConfigMapLister:    lister.ConfigMaps("kube-system"),
+				configManagedConfigMapLister: lister.ConfigMaps(operatorclient.GlobalMachineSpecifiedConfigNamespace),
+				targetConfigMapLister:        lister.ConfigMaps(operatorclient.TargetNamespace),
+			}
+
+			if err := c.sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+				t.Fatal(err)
+			}
+
+			_, status, _, err := operatorClient.GetStaticPodOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			condition := v1helpers.FindOperatorCondition(status.Conditions, StaleBootstrapTrustConditionType)
+			if condition == nil {
+				t.Fatalf("expected the %s condition to be set", StaleBootstrapTrustConditionType)
+			}
+			if condition.Status != tc.expectedStatus || condition.Reason != tc.expectedReason {
+				t.Errorf("expected %s/%s, got %#v", tc.expectedStatus, tc.expectedReason, condition)
+			}
+			if stale := strings.Count(condition.Message, "valid until"); stale != tc.expectedStale {
+				t.Errorf("expected %d stale CAs in the message, got %q", tc.expectedStale, condition.Message)
+			}
+		})
+	}
+}
@@ -103,6 +103,28 @@ func (c *BoundSATokenSignerController) ensureNextOperatorSigningSecret(ctx conte
 
 	// Create or update the secret if it is missing or lacks the expected keypair data
 	needKeypair := secret == nil || len(secret.Data[PrivateKeyKey]) == 0 || len(secret.Data[PublicKeyKey]) == 0
+	if !needKeypair {
+		// Repair a keypair that drifted: a private key that cannot be used is replaced
+		// by a new keypair, a public key that does not match the private key is
+		// replaced by the public key of the private key.
+		privateKey, publicKeyMatches, err := parseSigningKeyPair(secret)
+		switch {
+		case err != nil:
+			syncCtx.Recorder().Warningf("BoundSATokenSignerKeyPairRegenerated", "Generating a new keypair, the private key of the %s/%s secret is invalid: %v", operatorNamespace, NextSigningKeySecretName, err)
+			needKeypair = true
+		case !publicKeyMatches:
+			publicBytes, err := publicKeyToPem(&privateKey.PublicKey)
+			if err != nil {
+				return err
+			}
+			repairedSecret := secret.DeepCopy()
+			repairedSecret.Data[PublicKeyKey] = publicBytes
+			if _, _, err := resourceapply.ApplySecret(ctx, c.secretClient, syncCtx.Recorder(), repairedSecret); err != nil {
+				return err
+			}
+			syncCtx.Recorder().Warningf("BoundSATokenSignerPublicKeyRepaired", "Replaced the public key of the %s/%s secret that did not match its private key", operatorNamespace, NextSigningKeySecretName)
+		}
+	}
 	if needKeypair {
 		klog.V(2).Infof("Creating a new signing secret for bound service account tokens.")
 		newSecret, err := newNextSigningSecret()
@@ -122,13 +144,27 @@ func (c *BoundSATokenSignerController) ensureNextOperatorSigningSecret(ctx conte
 // ensurePublicKeyConfigMap ensures that the public key in the operator secret is
 // present in the operand configmap. If the configmap is missing, it will be created
 // with the current public key. If the configmap exists but does not contain the
-// current public key, the key will be added.
+// current public key, the key will be added. The public key of the operand secret,
+// the one tokens are signed with, is republished if it went missing. A public key is
+// only ever published if it matches the private key of its secret.
 func (c *BoundSATokenSignerController) ensurePublicKeyConfigMap(ctx context.Context, syncCtx factory.SyncContext) error {
 	// Retrieve the operator secret that contains the current public key
 	operatorSecret, err := c.secretClient.Secrets(operatorNamespace).Get(ctx, NextSigningKeySecretName, metav1.GetOptions{})
 	if err != nil {
 		return err
 	}
+	if _, publicKeyMatches, err := parseSigningKeyPair(operatorSecret); err != nil || !publicKeyMatches {
+		return fmt.Errorf("not publishing the public key of the %s/%s secret until its keypair is consistent", operatorNamespace, NextSigningKeySecretName)
+	}
+
+	// Retrieve the operand secret whose public key must stay published
+	operandSecret, err := c.secretClient.Secrets(targetNamespace).Get(ctx, SigningKeySecretName, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if errors.IsNotFound(err) {
+		operandSecret = nil
+	}
 
 	// Retrieve the configmap that needs to contain the current public key
 	cachedConfigMap, err := c.configMapClient.ConfigMaps(targetNamespace).Get(ctx, PublicKeyConfigMapName, metav1.GetOptions{})
@@ -156,35 +192,59 @@ func (c *BoundSATokenSignerController) ensurePublicKeyConfigMap(ctx context.Cont
 	if currPublicKey == "" {
 		return fmt.Errorf("no current %s found, one must be set in %s/%s secret", PublicKeyKey, operatorNamespace, NextSigningKeySecretName)
 	}
-	hasKey := configMapHasValue(configMap, currPublicKey)
-	if !hasKey {
-		// Increment until a unique name is found to ensure that the new public key
-		// does not overwrite an existing one. Except where key revocation is
-		// involved (which would require manual deletion of the verifying public
-		// key), existing public keys in the configmap should be maintained to
-		// minimize the potential for not being able to validate issued tokens.
-		nextKeyIndex := len(configMap.Data) + 1
-		nextKeyKey := ""
-		for {
-			possibleKey := fmt.Sprintf("service-account-%03d.pub", nextKeyIndex)
-			_, ok := configMap.Data[possibleKey]
-			if !ok {
-				nextKeyKey = possibleKey
-				break
-			}
-			nextKeyIndex += 1
-		}
+	changed := false
+	if !configMapHasValue(configMap, currPublicKey) {
+		addPublicKey(configMap, currPublicKey)
+		changed = true
+	}
 
-		// Ensure the configmap is updated with the current public key
-		configMap.Data[nextKeyKey] = currPublicKey
-		configMap, _, err = resourceapply.ApplyConfigMap(ctx, c.configMapClient, syncCtx.Recorder(), configMap)
+	// The operand public key is only missing if the configmap drifted, tokens signed
+	// with the operand private key could not be validated anymore.
+	republishedOperandKey := false
+	if operandSecret != nil {
+		operandPrivateKey, _, err := parseSigningKeyPair(operandSecret)
 		if err != nil {
-			return err
+			klog.Warningf("Not publishing the public key of the %s/%s secret: %v", targetNamespace, SigningKeySecretName, err)
+		} else if !configMapHasPublicKey(configMap, &operandPrivateKey.PublicKey) {
+			operandPublicKey, err := publicKeyToPem(&operandPrivateKey.PublicKey)
+			if err != nil {
+				return err
+			}
+			addPublicKey(configMap, string(operandPublicKey))
+			changed, republishedOperandKey = true, true
 		}
 	}
+
+	if !changed {
+		return nil
+	}
+	if _, _, err := resourceapply.ApplyConfigMap(ctx, c.configMapClient, syncCtx.Recorder(), configMap); err != nil {
+		return err
+	}
+	if republishedOperandKey {
+		syncCtx.Recorder().Warningf("BoundSATokenSignerPublicKeyRepublished", "Republished the public key of the %s/%s secret missing from the %s/%s configmap", targetNamespace, SigningKeySecretName, targetNamespace, PublicKeyConfigMapName)
+	}
 	return nil
 }
 
+// addPublicKey adds the public key to the configmap under a new name.
+func addPublicKey(configMap *corev1.ConfigMap, publicKey string) {
+	// Increment until a unique name is found to ensure that the new public key
+	// does not overwrite an existing one. Except where key revocation is
+	// involved (which would require manual deletion of the verifying public
+	// key), existing public keys in the configmap should be maintained to
+	// minimize the potential for not being able to validate issued tokens.
+	nextKeyIndex := len(configMap.Data) + 1
+	for {
+		possibleKey := fmt.Sprintf("service-account-%03d.pub", nextKeyIndex)
+		if _, ok := configMap.Data[possibleKey]; !ok {
+			configMap.Data[possibleKey] = publicKey
+			return
+		}
+		nextKeyIndex += 1
+	}
+}
+
 // ensureOperandSigningSecret ensures that the signing key secret in the operator
 // namespace is copied to the operand namespace. If the operand secret is missing, it
 // will be copied immediately to ensure the installer has something to deploy. If the
@@ -212,6 +272,11 @@ func (c *BoundSATokenSignerController) ensureOperandSigningSecret(ctx context.Co
 		return nil
 	}
 
+	// A keypair that drifted is repaired before it is promoted.
+	if _, publicKeyMatches, err := parseSigningKeyPair(operatorSecret); err != nil || !publicKeyMatches {
+		return fmt.Errorf("unable to promote bound sa token signing key until the keypair of the %s/%s secret is consistent", operatorNamespace, NextSigningKeySecretName)
+	}
+
 	currPublicKey := string(operatorSecret.Data[PublicKeyKey])
 
 	// The current public key must be present in the configmap before ensuring that
@@ -331,6 +396,41 @@ func publicKeyToPem(key *rsa.PublicKey) ([]byte, error) {
 	return keyinPem, nil
 }
 
+// parseSigningKeyPair returns the private key of the signing secret and whether the
+// public key of the secret is the public key of the private key.
+func parseSigningKeyPair(secret *corev1.Secret) (*rsa.PrivateKey, bool, error) {
+	key, err := keyutil.ParsePrivateKeyPEM(secret.Data[PrivateKeyKey])
+	if err != nil {
+		return nil, false, err
+	}
+	privateKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, false, fmt.Errorf("%s is not an RSA private key", PrivateKeyKey)
+	}
+	return privateKey, isPublicKey(secret.Data[PublicKeyKey], &privateKey.PublicKey), nil
+}
+
+// isPublicKey indicates whether the PEM data holds the given public key only.
+func isPublicKey(data []byte, publicKey *rsa.PublicKey) bool {
+	keys, err := keyutil.ParsePublicKeysPEM(data)
+	if err != nil || len(keys) != 1 {
+		return false
+	}
+	rsaKey, ok := keys[0].(*rsa.PublicKey)
+	return ok && rsaKey.Equal(publicKey)
+}
+
+// configMapHasPublicKey compares the keys rather than their PEM encoding, the
+// installer does not encode public keys like the operator.
+func configMapHasPublicKey(configMap *corev1.ConfigMap, publicKey *rsa.PublicKey) bool {
+	for _, value := range configMap.Data {
+		if isPublicKey([]byte(value), publicKey) {
+			return true
+		}
+	}
+	return false
+}
+
 func configMapHasValue(configMap *corev1.ConfigMap, desiredValue string) bool {
 	for _, value := range configMap.Data {
 		if value == desiredValue {
@@ -0,0 +1,219 @@
+package boundsatokensignercontroller
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/util/keyutil"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+type keyPair struct {
+	private []byte
+	public  []byte
+}
+
+func newKeyPair(t *testing.T) keyPair {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, keySize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateBytes, err := keyutil.MarshalPrivateKeyToPEM(rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicBytes, err := publicKeyToPem(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keyPair{private: privateBytes, public: publicBytes}
+}
+
+func signingSecret(namespace, name string, private, public []byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Data:       map[string][]byte{PrivateKeyKey: private, PublicKeyKey: public},
+	}
+}
+
+func publicKeyConfigMap(name string, publicKeys ...[]byte) *corev1.ConfigMap {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: targetNamespace, Name: name},
+		Data:       map[string]string{},
+	}
+	for _, publicKey := range publicKeys {
+		addPublicKey(configMap, string(publicKey))
+	}
+	return configMap
+}
+
+func TestBoundSATokenSignerKeyPairReconciliation(t *testing.T) {
+	current, next, other := newKeyPair(t), newKeyPair(t), newKeyPair(t)
+
+	testCases := []struct {
+		name    string
+		objects []runtime.Object
+		// expectedNextPublicKey is the public key the operator secret must end up with, a new keypair is expected if unset
+		expectedNextPublicKey []byte
+		expectedPublished     [][]byte
+		expectedNotPublished  [][]byte
+		expectedEvent         string
+	}{
+		{
+			name: "consistent",
+			objects: []runtime.Object{
+				signingSecret(operatorNamespace, NextSigningKeySecretName, current.private, current.public),
+				signingSecret(targetNamespace, SigningKeySecretName, current.private, current.public),
+				publicKeyConfigMap(PublicKeyConfigMapName, current.public),
+			},
+			expectedNextPublicKey: current.public,
+			expectedPublished:     [][]byte{current.public},
+		},
+		{
+			name: "next public key drifted from its private key",
+			objects: []runtime.Object{
+				signingSecret(operatorNamespace, NextSigningKeySecretName, next.private, other.public),
+				signingSecret(targetNamespace, SigningKeySecretName, current.private, current.public),
+				publicKeyConfigMap(PublicKeyConfigMapName, current.public),
+			},
+			expectedNextPublicKey: next.public,
+			expectedPublished:     [][]byte{current.public, next.public},
+			expectedNotPublished:  [][]byte{other.public},
+			expectedEvent:         "BoundSATokenSignerPublicKeyRepaired",
+		},
+		{
+			name: "next private key invalid",
+			objects: []runtime.Object{
+				signingSecret(operatorNamespace, NextSigningKeySecretName, []byte("not a key"), other.public),
+				signingSecret(targetNamespace, SigningKeySecretName, current.private, current.public),
+				publicKeyConfigMap(PublicKeyConfigMapName, current.public),
+			},
+			expectedPublished:    [][]byte{current.public},
+			expectedNotPublished: [][]byte{other.public},
+			expectedEvent:        "BoundSATokenSignerKeyPairRegenerated",
+		},
+		{
+			name: "operand public key dropped from the configmap",
+			objects: []runtime.Object{
+				signingSecret(operatorNamespace, NextSigningKeySecretName, next.private, next.public),
+				signingSecret(targetNamespace, SigningKeySecretName, current.private, current.public),
+				publicKeyConfigMap(PublicKeyConfigMapName, next.public),
+			},
+			expectedNextPublicKey: next.public,
+			expectedPublished:     [][]byte{next.public, current.public},
+			expectedEvent:         "BoundSATokenSignerPublicKeyRepublished",
+		},
+		{
+			name: "operand public key not published without its private key",
+			objects: []runtime.Object{
+				signingSecret(operatorNamespace, NextSigningKeySecretName, next.private, next.public),
+				signingSecret(targetNamespace, SigningKeySecretName, []byte("not a key"), other.public),
+				publicKeyConfigMap(PublicKeyConfigMapName, next.public),
+			},
+			expectedNextPublicKey: next.public,
+			expectedPublished:     [][]byte{next.public},
+			expectedNotPublished:  [][]byte{other.public},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset(append(tc.objects,
+				publicKeyConfigMap(PublicKeyConfigMapName+"-1", current.public, next.public),
+			)...)
+			operatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+				&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+				&operatorv1.StaticPodOperatorStatus{NodeStatuses: []operatorv1.NodeStatus{{NodeName: "master-0", CurrentRevision: 1}}},
+				nil, nil,
+			)
+			controller := &BoundSATokenSignerController{
+				operatorClient:  operatorClient,
+				secretClient:    kubeClient.CoreV1(),
+				configMapClient: kubeClient.CoreV1(),
+			}
+			recorder := events.NewInMemoryRecorder("test")
+
+			if err := controller.sync(context.TODO(), factory.NewSyncContext("test", recorder)); err != nil {
+				t.Fatal(err)
+			}
+
+			nextSecret, err := kubeClient.CoreV1().Secrets(operatorNamespace).Get(context.TODO(), NextSigningKeySecretName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, publicKeyMatches, err := parseSigningKeyPair(nextSecret); err != nil || !publicKeyMatches {
+				t.Errorf("expected a consistent keypair in the operator secret, got error %v", err)
+			}
+			if tc.expectedNextPublicKey != nil && string(nextSecret.Data[PublicKeyKey]) != string(tc.expectedNextPublicKey) {
+				t.Errorf("unexpected public key in the operator secret")
+			}
+
+			configMap, err := kubeClient.CoreV1().ConfigMaps(targetNamespace).Get(context.TODO(), PublicKeyConfigMapName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !configMapHasValue(configMap, string(nextSecret.Data[PublicKeyKey])) {
+				t.Errorf("expected the public key of the operator secret to be published")
+			}
+			for _, publicKey := range tc.expectedPublished {
+				if !configMapHasValue(configMap, string(publicKey)) {
+					t.Errorf("expected public key to be published, got %v", configMap.Data)
+				}
+			}
+			for _, publicKey := range tc.expectedNotPublished {
+				if configMapHasValue(configMap, string(publicKey)) {
+					t.Errorf("expected public key without a private key not to be published, got %v", configMap.Data)
+				}
+			}
+
+			repairReasons := sets.NewString("BoundSATokenSignerKeyPairRegenerated", "BoundSATokenSignerPublicKeyRepaired", "BoundSATokenSignerPublicKeyRepublished")
+			var repairs []string
+			for _, event := range recorder.Events() {
+				if repairReasons.Has(event.Reason) {
+					repairs = append(repairs, event.Reason)
+				}
+			}
+			var expectedRepairs []string
+			if len(tc.expectedEvent) > 0 {
+				expectedRepairs = []string{tc.expectedEvent}
+			}
+			if !reflect.DeepEqual(expectedRepairs, repairs) {
+				t.Errorf("expected repair events %v, got %v", expectedRepairs, repairs)
+			}
+		})
+	}
+}
+
+func TestConfigMapHasPublicKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, keySize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the installer encodes public keys as PUBLIC KEY rather than RSA PUBLIC KEY
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	installerPublicKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyBytes})
+	if !configMapHasPublicKey(publicKeyConfigMap(PublicKeyConfigMapName, installerPublicKey), &rsaKey.PublicKey) {
+		t.Errorf("expected the public key to be found in a different encoding")
+	}
+	if configMapHasPublicKey(publicKeyConfigMap(PublicKeyConfigMapName, newKeyPair(t).public), &rsaKey.PublicKey) {
+		t.Errorf("expected another public key not to match")
+	}
+}
@@ -0,0 +1,136 @@
+package certrotationcontroller
+
+import (
+	"bytes"
+	"crypto/x509"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apiserver/pkg/authentication/user"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+)
+
+const (
+	// extensionAPIServerAuthenticationConfigMapName is published by the kube-apiserver in kube-system. The aggregated
+	// apiservers read the CAs they verify the aggregator client certificate with from its requestheader-client-ca-file.
+	extensionAPIServerAuthenticationConfigMapName = "extension-apiserver-authentication"
+	requestHeaderClientCAFileKey                  = "requestheader-client-ca-file"
+)
+
+// AggregatorClientRotation creates the aggregator client certificate, like certrotation.ClientRotation, but only
+// re-signs it with a new signer once the signer is trusted by the requestheader-client-ca-file the kube-apiserver
+// publishes to the aggregated apiservers. The aggregator-client-ca bundle keeps the old and the new CA until the old
+// one expires, so until the new CA is published the current certificate keeps being accepted, and afterwards both
+// certificates are. The kube-apiserver reloads the bundle and the certificate without a new revision.
+type AggregatorClientRotation struct {
+	certrotation.ClientRotation
+
+	// configMapLister lists the configmaps of kube-system.
+	configMapLister corev1listers.ConfigMapLister
+	published       chan struct{}
+}
+
+func NewAggregatorClientRotation(configMapLister corev1listers.ConfigMapLister) *AggregatorClientRotation {
+	return &AggregatorClientRotation{
+		ClientRotation:  certrotation.ClientRotation{UserInfo: &user.DefaultInfo{Name: "system:openshift-aggregator"}},
+		configMapLister: configMapLister,
+		published:       make(chan struct{}, 10),
+	}
+}
+
+func (r *AggregatorClientRotation) NeedNewTargetCertKeyPair(annotations map[string]string, signer *crypto.CA, caBundleCerts []*x509.Certificate, refresh time.Duration, refreshOnlyWhenExpired bool) string {
+	reason := r.ClientRotation.NeedNewTargetCertKeyPair(annotations, signer, caBundleCerts, refresh, refreshOnlyWhenExpired)
+	if len(reason) == 0 || !currentCertUsable(annotations, caBundleCerts) {
+		return reason
+	}
+
+	published, err := r.signerPublished(signer)
+	if err != nil {
+		klog.Warningf("Failed to check the aggregator client signer is published in kube-system/%s: %v", extensionAPIServerAuthenticationConfigMapName, err)
+		return ""
+	}
+	if !published {
+		klog.V(2).Infof("Waiting for the aggregator client signer %q to be published in kube-system/%s before rotating the aggregator client certificate (%s)", signer.Config.Certs[0].Subject.CommonName, extensionAPIServerAuthenticationConfigMapName, reason)
+		return ""
+	}
+	return reason
+}
+
+// RecheckChannel queues the cert rotation controller whenever the published CAs change, so that a rotation which
+// waited for the new signer to be published happens right away.
+func (r *AggregatorClientRotation) RecheckChannel() <-chan struct{} {
+	return r.published
+}
+
+// signerPublished returns true if the requestheader-client-ca-file published in kube-system contains the signer.
+func (r *AggregatorClientRotation) signerPublished(signer *crypto.CA) (bool, error) {
+	configMap, err := r.configMapLister.ConfigMaps("kube-system").Get(extensionAPIServerAuthenticationConfigMapName)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	caBundle := configMap.Data[requestHeaderClientCAFileKey]
+	if len(caBundle) == 0 {
+		return false, nil
+	}
+	publishedCerts, err := cert.ParseCertsPEM([]byte(caBundle))
+	if err != nil {
+		return false, err
+	}
+	for _, publishedCert := range publishedCerts {
+		if bytes.Equal(publishedCert.Raw, signer.Config.Certs[0].Raw) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// eventHandler notifies the recheck channel about changes of the extension-apiserver-authentication configmap.
+func (r *AggregatorClientRotation) eventHandler() cache.ResourceEventHandler {
+	return cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			accessor, ok := obj.(interface{ GetName() string })
+			return ok && accessor.GetName() == extensionAPIServerAuthenticationConfigMapName
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { r.recheck() },
+			UpdateFunc: func(old, new interface{}) { r.recheck() },
+			DeleteFunc: func(obj interface{}) { r.recheck() },
+		},
+	}
+}
+
+func (r *AggregatorClientRotation) recheck() {
+	select {
+	case r.published <- struct{}{}:
+	default:
+		// a recheck is already pending
+	}
+}
+
+// currentCertUsable returns false if there is no point in keeping the current certificate: it is missing, expired or
+// its issuer is no longer trusted.
+func currentCertUsable(annotations map[string]string, caBundleCerts []*x509.Certificate) bool {
+	notAfter, err := time.Parse(time.RFC3339, annotations[certrotation.CertificateNotAfterAnnotation])
+	if err != nil || time.Now().After(notAfter) {
+		return false
+	}
+	issuer := annotations[certrotation.CertificateIssuer]
+	for _, caCert := range caBundleCerts {
+		if len(issuer) > 0 && caCert.Subject.CommonName == issuer {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,200 @@
+package certrotationcontroller
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+)
+
+func newTestSigner(t *testing.T, name string) *crypto.CA {
+	t.Helper()
+	config, err := crypto.MakeSelfSignedCAConfigForDuration(name, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &crypto.CA{Config: config, SerialGenerator: &crypto.RandomSerialGenerator{}}
+}
+
+func caBundlePEM(t *testing.T, signers ...*crypto.CA) string {
+	t.Helper()
+	var certs []*x509.Certificate
+	for _, signer := range signers {
+		certs = append(certs, signer.Config.Certs...)
+	}
+	bundle, err := crypto.EncodeCertificates(certs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(bundle)
+}
+
+// verifyTrusted checks the client certificate is trusted by the given PEM bundle, like an aggregated apiserver would.
+func verifyTrusted(t *testing.T, bundle string, clientCert *crypto.TLSCertificateConfig) error {
+	t.Helper()
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(bundle)) {
+		t.Fatal("failed to parse the CA bundle")
+	}
+	_, err := clientCert.Certs[0].Verify(x509.VerifyOptions{Roots: pool, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	return err
+}
+
+func TestAggregatorClientRotation(t *testing.T) {
+	oldSigner := newTestSigner(t, "aggregator-client-signer-old")
+	newSigner := newTestSigner(t, "aggregator-client-signer-new")
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	publish := func(signers ...*crypto.CA) *corev1.ConfigMap {
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "extension-apiserver-authentication"},
+			Data:       map[string]string{"requestheader-client-ca-file": caBundlePEM(t, signers...)},
+		}
+		if err := indexer.Update(configMap); err != nil {
+			t.Fatal(err)
+		}
+		return configMap
+	}
+	rotation := NewAggregatorClientRotation(corev1listers.NewConfigMapLister(indexer))
+
+	oldCert, err := rotation.NewCertificate(oldSigner, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the current certificate is past its refresh time, so it is due for rotation
+	annotations := map[string]string{
+		certrotation.CertificateNotBeforeAnnotation: time.Now().Add(-29 * time.Hour).Format(time.RFC3339),
+		certrotation.CertificateNotAfterAnnotation:  time.Now().Add(time.Hour).Format(time.RFC3339),
+		certrotation.CertificateIssuer:              oldSigner.Config.Certs[0].Subject.CommonName,
+	}
+	// the signer has rotated: the aggregator-client-ca bundle holds the new and the old CA
+	caBundleCerts := append(append([]*x509.Certificate{}, newSigner.Config.Certs...), oldSigner.Config.Certs...)
+	refresh := 15 * time.Hour
+
+	if reason := (&certrotation.ClientRotation{}).NeedNewTargetCertKeyPair(annotations, newSigner, caBundleCerts, refresh, false); len(reason) == 0 {
+		t.Fatal("expected the plain client rotation to want a new certificate")
+	}
+
+	// the kube-apiserver still publishes the old CA only
+	publish(oldSigner)
+	if reason := rotation.NeedNewTargetCertKeyPair(annotations, newSigner, caBundleCerts, refresh, false); len(reason) > 0 {
+		t.Fatalf("expected no new certificate before the new signer is published, got %q", reason)
+	}
+
+	// the kube-apiserver publishes the new CA next to the old one, which requeues the rotation
+	published := publish(newSigner, oldSigner)
+	rotation.eventHandler().OnUpdate(published, published)
+	select {
+	case <-rotation.RecheckChannel():
+	default:
+		t.Fatal("expected a recheck when the published CAs change")
+	}
+	if reason := rotation.NeedNewTargetCertKeyPair(annotations, newSigner, caBundleCerts, refresh, false); len(reason) == 0 {
+		t.Fatal("expected a new certificate once the new signer is published")
+	}
+
+	// mid-rotation, the aggregated apiservers trust both the certificate in use and the new one
+	newCert, err := rotation.NewCertificate(newSigner, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle := published.Data["requestheader-client-ca-file"]
+	if err := verifyTrusted(t, bundle, oldCert); err != nil {
+		t.Errorf("expected the old certificate to be trusted mid-rotation: %v", err)
+	}
+	if err := verifyTrusted(t, bundle, newCert); err != nil {
+		t.Errorf("expected the new certificate to be trusted mid-rotation: %v", err)
+	}
+	if err := verifyTrusted(t, caBundlePEM(t, oldSigner), newCert); err == nil {
+		t.Errorf("expected the new certificate not to be trusted by the old CA alone")
+	}
+}
+
+func TestAggregatorClientRotationUnusableCurrentCert(t *testing.T) {
+	oldSigner := newTestSigner(t, "aggregator-client-signer-old")
+	newSigner := newTestSigner(t, "aggregator-client-signer-new")
+	caBundleCerts := append(append([]*x509.Certificate{}, newSigner.Config.Certs...), oldSigner.Config.Certs...)
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	if err := indexer.Add(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "extension-apiserver-authentication"},
+		Data:       map[string]string{"requestheader-client-ca-file": caBundlePEM(t, oldSigner)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	rotation := NewAggregatorClientRotation(corev1listers.NewConfigMapLister(indexer))
+
+	scenarios := []struct {
+		name          string
+		annotations   map[string]string
+		caBundleCerts []*x509.Certificate
+	}{
+		{
+			name:          "no current certificate",
+			annotations:   map[string]string{},
+			caBundleCerts: caBundleCerts,
+		},
+		{
+			name: "expired current certificate",
+			annotations: map[string]string{
+				certrotation.CertificateNotBeforeAnnotation: time.Now().Add(-30 * time.Hour).Format(time.RFC3339),
+				certrotation.CertificateNotAfterAnnotation:  time.Now().Add(-time.Hour).Format(time.RFC3339),
+				certrotation.CertificateIssuer:              oldSigner.Config.Certs[0].Subject.CommonName,
+			},
+			caBundleCerts: caBundleCerts,
+		},
+		{
+			name: "issuer no longer trusted",
+			annotations: map[string]string{
+				certrotation.CertificateNotBeforeAnnotation: time.Now().Add(-time.Hour).Format(time.RFC3339),
+				certrotation.CertificateNotAfterAnnotation:  time.Now().Add(29 * time.Hour).Format(time.RFC3339),
+				certrotation.CertificateIssuer:              oldSigner.Config.Certs[0].Subject.CommonName,
+			},
+			caBundleCerts: newSigner.Config.Certs,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			if reason := rotation.NeedNewTargetCertKeyPair(scenario.annotations, newSigner, scenario.caBundleCerts, 15*time.Hour, false); len(reason) == 0 {
+				t.Errorf("expected a new certificate without waiting for the new signer to be published")
+			}
+		})
+	}
+}
+
+func TestAggregatorClientRotationThroughCertRotationController(t *testing.T) {
+	oldSigner := newTestSignerValidFrom(t, "aggregator-client-signer-old", time.Now().Add(-48*time.Hour), 100*time.Hour)
+	newSigner := newTestSignerValidFrom(t, "aggregator-client-signer-new", time.Now().Add(-24*time.Hour), 100*time.Hour)
+	validity, refresh := 10*time.Hour, 5*time.Hour
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	publish := func(signers ...*crypto.CA) {
+		if err := indexer.Update(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "extension-apiserver-authentication"},
+			Data:       map[string]string{"requestheader-client-ca-file": caBundlePEM(t, signers...)},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rotation := NewAggregatorClientRotation(corev1listers.NewConfigMapLister(indexer))
+	// the current certificate of the old signer is past its refresh time, the signer has rotated since
+	target := newTestTargetSecret(t, oldSigner, rotation, time.Now().Add(-6*time.Hour), validity)
+
+	publish(oldSigner)
+	if syncTargetRotation(t, newSigner, []*crypto.CA{oldSigner}, target, rotation, validity, refresh) {
+		t.Errorf("expected the certificate to be kept until the new signer is published")
+	}
+
+	publish(newSigner, oldSigner)
+	if !syncTargetRotation(t, newSigner, []*crypto.CA{oldSigner}, target, rotation, validity, refresh) {
+		t.Errorf("expected the certificate to be rotated once the new signer is published")
+	}
+}
@@ -0,0 +1,129 @@
+package certrotationcontroller
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corelistersv1 "k8s.io/client-go/listers/core/v1"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+const (
+	ClientCertificateCNCollisionConditionType = "ClientCertificateCommonNameCollision"
+
+	CommonNameCollisionReason = "CommonNameCollision"
+	AsExpectedReason          = "AsExpected"
+)
+
+// clientCertCNCollisionController reports the ClientCertificateCommonNameCollision condition when two valid client
+// certificates managed by the cert rotation controllers share a subject common name. The kube-apiserver takes the
+// common name as user name, such certificates authenticate as the same user.
+type clientCertCNCollisionController struct {
+	operatorClient v1helpers.StaticPodOperatorClient
+	secretListers  map[string]corelistersv1.SecretLister
+	now            func() time.Time
+}
+
+func newClientCertCNCollisionController(
+	namespaces []string,
+	operatorClient v1helpers.StaticPodOperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &clientCertCNCollisionController{
+		operatorClient: operatorClient,
+		secretListers:  map[string]corelistersv1.SecretLister{},
+		now:            time.Now,
+	}
+
+	informers := []factory.Informer{operatorClient.Informer()}
+	for _, ns := range namespaces {
+		secretInformer := kubeInformersForNamespaces.InformersFor(ns).Core().V1().Secrets()
+		c.secretListers[ns] = secretInformer.Lister()
+		informers = append(informers, secretInformer.Informer())
+	}
+
+	// certificates expire even when nothing changes, resync to drop them from the check
+	return factory.New().WithInformers(informers...).WithSync(c.sync).ResyncEvery(time.Hour).
+		ToController("CertRotationCNCollisionController", eventRecorder.WithComponentSuffix("cert-cn-collision-controller"))
+}
+
+func (c *clientCertCNCollisionController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	operatorSpec, _, _, err := c.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{certrotation.ManagedCertificateTypeLabelName: string(certrotation.CertificateTypeTarget)})
+	secretsByCommonName := map[string][]string{}
+	for ns, lister := range c.secretListers {
+		secrets, err := lister.Secrets(ns).List(selector)
+		if err != nil {
+			return err
+		}
+		for _, secret := range secrets {
+			certs, err := certutil.ParseCertsPEM(secret.Data["tls.crt"])
+			if err != nil || len(certs) == 0 {
+				klog.V(4).Infof("Unable to parse the certificate in secret %s/%s: %v", secret.Namespace, secret.Name, err)
+				continue
+			}
+			if !isActiveClientCertificate(certs[0], c.now()) {
+				continue
+			}
+			commonName := certs[0].Subject.CommonName
+			secretsByCommonName[commonName] = append(secretsByCommonName[commonName], secret.Namespace+"/"+secret.Name)
+		}
+	}
+
+	var collisions []string
+	for commonName, secrets := range secretsByCommonName {
+		if len(secrets) > 1 {
+			sort.Strings(secrets)
+			collisions = append(collisions, fmt.Sprintf("%q is shared by %s", commonName, strings.Join(secrets, ", ")))
+		}
+	}
+	sort.Strings(collisions)
+
+	condition := operatorv1.OperatorCondition{
+		Type:   ClientCertificateCNCollisionConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: AsExpectedReason,
+	}
+	if len(collisions) > 0 {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = CommonNameCollisionReason
+		condition.Message = fmt.Sprintf("Client certificates authenticate as the same user: %s", strings.Join(collisions, "; "))
+	}
+	_, _, err = v1helpers.UpdateStaticPodStatus(c.operatorClient, v1helpers.UpdateStaticPodConditionFn(condition))
+	return err
+}
+
+// isActiveClientCertificate returns whether the certificate is valid at the given time and usable for client
+// authentication.
+func isActiveClientCertificate(cert *x509.Certificate, now time.Time) bool {
+	if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return false
+	}
+	for _, usage := range cert.ExtKeyUsage {
+		if usage == x509.ExtKeyUsageClientAuth {
+			return true
+		}
+	}
+	return false
+}
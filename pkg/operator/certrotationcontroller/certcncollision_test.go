@@ -0,0 +1,152 @@
+package certrotationcontroller
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+	corelistersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+func newTargetSecret(t *testing.T, namespace, name string, cert *crypto.TLSCertificateConfig) *corev1.Secret {
+	certBytes, keyBytes := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := cert.WriteCertConfig(certBytes, keyBytes); err != nil {
+		t.Fatal(err)
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			Labels:    map[string]string{certrotation.ManagedCertificateTypeLabelName: string(certrotation.CertificateTypeTarget)},
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{"tls.crt": certBytes.Bytes(), "tls.key": keyBytes.Bytes()},
+	}
+}
+
+func TestClientCertCNCollisionController(t *testing.T) {
+	signer, err := crypto.MakeSelfSignedCAConfigForDuration("signer", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca := &crypto.CA{Config: signer, SerialGenerator: &crypto.RandomSerialGenerator{}}
+	clientCert := func(name string, lifetime time.Duration) *crypto.TLSCertificateConfig {
+		cert, err := ca.MakeClientCertificateForDuration(&user.DefaultInfo{Name: name}, lifetime)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert
+	}
+	servingCert, err := ca.MakeServerCertForDuration(sets.NewString("system:kube-scheduler"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name            string
+		secrets         []*corev1.Secret
+		expectedStatus  operatorv1.ConditionStatus
+		expectedSecrets []string
+	}{
+		{
+			name: "distinct common names",
+			secrets: []*corev1.Secret{
+				newTargetSecret(t, "openshift-kube-apiserver", "kubelet-client", clientCert("system:kube-apiserver", time.Hour)),
+				newTargetSecret(t, "openshift-config-managed", "kube-scheduler-client-cert-key", clientCert("system:kube-scheduler", time.Hour)),
+			},
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name: "colliding common names",
+			secrets: []*corev1.Secret{
+				newTargetSecret(t, "openshift-kube-apiserver", "kubelet-client", clientCert("system:kube-apiserver", time.Hour)),
+				newTargetSecret(t, "openshift-config-managed", "kube-scheduler-client-cert-key", clientCert("system:kube-scheduler", time.Hour)),
+				newTargetSecret(t, "openshift-kube-apiserver", "check-endpoints-client-cert-key", clientCert("system:kube-scheduler", time.Hour)),
+			},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedSecrets: []string{"openshift-config-managed/kube-scheduler-client-cert-key", "openshift-kube-apiserver/check-endpoints-client-cert-key"},
+		},
+		{
+			name: "serving certificates do not collide with client certificates",
+			secrets: []*corev1.Secret{
+				newTargetSecret(t, "openshift-config-managed", "kube-scheduler-client-cert-key", clientCert("system:kube-scheduler", time.Hour)),
+				newTargetSecret(t, "openshift-kube-apiserver", "localhost-serving-cert-certkey", servingCert),
+			},
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name: "expired certificates do not collide",
+			secrets: []*corev1.Secret{
+				newTargetSecret(t, "openshift-config-managed", "kube-scheduler-client-cert-key", clientCert("system:kube-scheduler", time.Hour)),
+				newTargetSecret(t, "openshift-kube-apiserver", "check-endpoints-client-cert-key", clientCert("system:kube-scheduler", time.Nanosecond)),
+			},
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			secretListers := map[string]corelistersv1.SecretLister{}
+			indexers := map[string]cache.Indexer{}
+			for _, secret := range tc.secrets {
+				if indexers[secret.Namespace] == nil {
+					indexers[secret.Namespace] = cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+					secretListers[secret.Namespace] = corelistersv1.NewSecretLister(indexers[secret.Namespace])
+				}
+				if err := indexers[secret.Namespace].Add(secret); err != nil {
+					t.Fatal(err)
+				}
+			}
+			operatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+				&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+				&operatorv1.StaticPodOperatorStatus{},
+				nil,
+				nil,
+			)
+			c := &clientCertCNCollisionController{
+				operatorClient: operatorClient,
+				secretListers:  secretListers,
+				// after the short lived certificate expired
+				now: func() time.Time { return time.Now().Add(time.Minute) },
+			}
+
+			if err := c.sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+				t.Fatal(err)
+			}
+
+			_, status, _, err := operatorClient.GetStaticPodOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			condition := v1helpers.FindOperatorCondition(status.Conditions, ClientCertificateCNCollisionConditionType)
+			if condition == nil {
+				t.Fatalf("expected the %s condition", ClientCertificateCNCollisionConditionType)
+			}
+			if condition.Status != tc.expectedStatus {
+				t.Errorf("expected status %s, got %s: %s", tc.expectedStatus, condition.Status, condition.Message)
+			}
+			for _, secret := range tc.expectedSecrets {
+				if !strings.Contains(condition.Message, secret) {
+					t.Errorf("expected %s to be listed, got %q", secret, condition.Message)
+				}
+			}
+			if len(tc.expectedSecrets) > 0 && strings.Contains(condition.Message, "kubelet-client") {
+				t.Errorf("expected the distinct certificate not to be listed, got %q", condition.Message)
+			}
+		})
+	}
+}
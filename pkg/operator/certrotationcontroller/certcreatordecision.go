@@ -0,0 +1,114 @@
+package certrotationcontroller
+
+import (
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+// newCertCreatorRotationController returns a certrotation.NewCertRotationController which rotates the target certificate
+// when its CertCreator decides so. The vendored certrotation only asks the CertCreator for the new certificate, it
+// decides itself when one is needed, with the checks of certrotation.ClientRotation. The target secret is therefore
+// read through a certCreatorDecisionLister.
+func newCertCreatorRotationController(
+	name string,
+	signer certrotation.RotatedSigningCASecret,
+	caBundle certrotation.CABundleConfigMap,
+	target certrotation.RotatedSelfSignedCertKeySecret,
+	operatorClient v1helpers.StaticPodOperatorClient,
+	recorder events.Recorder,
+) factory.Controller {
+	target.Lister = &certCreatorDecisionLister{SecretLister: target.Lister, signer: signer, caBundle: caBundle, target: target}
+	return certrotation.NewCertRotationController(name, signer, caBundle, target, operatorClient, recorder)
+}
+
+// certCreatorDecisionLister reads the target secret for the vendored certrotation. Where the CertCreator and the
+// default checks disagree, the annotations of the secret read are adjusted so that the default checks come to the
+// decision of the CertCreator: without the notAfter annotation the certificate is rotated, with a notBefore of now a
+// certificate which is neither expired nor issued by an untrusted signer is kept. The adjusted annotations are never
+// written: the certificate is either kept untouched, or rotated and annotated anew.
+type certCreatorDecisionLister struct {
+	corev1listers.SecretLister
+
+	signer   certrotation.RotatedSigningCASecret
+	caBundle certrotation.CABundleConfigMap
+	target   certrotation.RotatedSelfSignedCertKeySecret
+}
+
+func (l *certCreatorDecisionLister) Secrets(namespace string) corev1listers.SecretNamespaceLister {
+	lister := l.SecretLister.Secrets(namespace)
+	if namespace != l.target.Namespace {
+		return lister
+	}
+	return &certCreatorDecisionNamespaceLister{SecretNamespaceLister: lister, decision: l}
+}
+
+type certCreatorDecisionNamespaceLister struct {
+	corev1listers.SecretNamespaceLister
+	decision *certCreatorDecisionLister
+}
+
+func (l *certCreatorDecisionNamespaceLister) Get(name string) (*corev1.Secret, error) {
+	secret, err := l.SecretNamespaceLister.Get(name)
+	if err != nil || name != l.decision.target.Name {
+		return secret, err
+	}
+	return l.decision.decide(secret), nil
+}
+
+// decide returns the target secret with the annotations the default checks come to the decision of the CertCreator
+// with.
+func (l *certCreatorDecisionLister) decide(secret *corev1.Secret) *corev1.Secret {
+	signer, caBundleCerts, err := l.signingInputs()
+	if err != nil {
+		klog.Warningf("Unable to read the signer of %q in %q, leaving its rotation to the default checks: %v", l.target.Name, l.target.Namespace, err)
+		return secret
+	}
+
+	reason := l.target.CertCreator.NeedNewTargetCertKeyPair(secret.Annotations, signer, caBundleCerts, l.target.Refresh, l.target.RefreshOnlyWhenExpired)
+	defaultReason := (&certrotation.ClientRotation{}).NeedNewTargetCertKeyPair(secret.Annotations, signer, caBundleCerts, l.target.Refresh, l.target.RefreshOnlyWhenExpired)
+	switch {
+	case len(reason) > 0 && len(defaultReason) == 0:
+		klog.Infof("%q in %q requires a new target cert/key pair: %v", l.target.Name, l.target.Namespace, reason)
+		secret = secret.DeepCopy()
+		delete(secret.Annotations, certrotation.CertificateNotAfterAnnotation)
+	case len(reason) == 0 && len(defaultReason) > 0 && len(secret.Annotations[certrotation.CertificateNotAfterAnnotation]) > 0:
+		klog.V(2).Infof("%q in %q is kept as decided by its cert creator, although it is %v", l.target.Name, l.target.Namespace, defaultReason)
+		secret = secret.DeepCopy()
+		secret.Annotations[certrotation.CertificateNotBeforeAnnotation] = time.Now().Format(time.RFC3339)
+	}
+	return secret
+}
+
+// signingInputs returns the signer and the CA bundle certificates the certrotation checks the target certificate
+// against, as they are cached.
+func (l *certCreatorDecisionLister) signingInputs() (*crypto.CA, []*x509.Certificate, error) {
+	signerSecret, err := l.signer.Lister.Secrets(l.signer.Namespace).Get(l.signer.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	signer, err := crypto.GetCAFromBytes(signerSecret.Data["tls.crt"], signerSecret.Data["tls.key"])
+	if err != nil {
+		return nil, nil, err
+	}
+	caBundle, err := l.caBundle.Lister.ConfigMaps(l.caBundle.Namespace).Get(l.caBundle.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	caBundleCerts, err := cert.ParseCertsPEM([]byte(caBundle.Data["ca-bundle.crt"]))
+	if err != nil {
+		return nil, nil, fmt.Errorf("bad %s/%s: %v", l.caBundle.Namespace, l.caBundle.Name, err)
+	}
+	return signer, caBundleCerts, nil
+}
@@ -0,0 +1,241 @@
+package certrotationcontroller
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+const (
+	testSignerNamespace = "openshift-kube-apiserver-operator"
+	testSignerName      = "test-signer"
+	testCABundleName    = "test-ca"
+	testTargetNamespace = "openshift-kube-apiserver"
+	testTargetName      = "test-target"
+)
+
+// newTestSignerValidFrom returns a signer issued at notBefore. The certrotation only re-signs the target certificate
+// at its refresh time once the signer is old enough.
+func newTestSignerValidFrom(t *testing.T, name string, notBefore time.Time, validity time.Duration) *crypto.CA {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.Add(validity),
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &crypto.CA{
+		Config:          &crypto.TLSCertificateConfig{Certs: []*x509.Certificate{cert}, Key: key},
+		SerialGenerator: &crypto.RandomSerialGenerator{},
+	}
+}
+
+// newTestTargetSecret returns the target secret of a certificate created by the certCreator, annotated as if it was
+// issued at notBefore for the validity.
+func newTestTargetSecret(t *testing.T, signer *crypto.CA, certCreator certrotation.TargetCertCreator, notBefore time.Time, validity time.Duration) *corev1.Secret {
+	t.Helper()
+	targetCert, err := certCreator.NewCertificate(signer, validity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, keyPEM, err := targetCert.GetPEMBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotations := map[string]string{
+		certrotation.CertificateNotBeforeAnnotation: notBefore.Format(time.RFC3339),
+		certrotation.CertificateNotAfterAnnotation:  notBefore.Add(validity).Format(time.RFC3339),
+		certrotation.CertificateIssuer:              signer.Config.Certs[0].Subject.CommonName,
+	}
+	certCreator.SetAnnotations(targetCert, annotations)
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testTargetNamespace, Name: testTargetName, Annotations: annotations},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{"tls.crt": certPEM, "tls.key": keyPEM},
+	}
+}
+
+// syncTargetRotation runs the cert rotation controller of newCertCreatorRotationController once, with the signer,
+// a CA bundle of the signer and the other trustedSigners, and the target secret, and returns whether the target
+// certificate was rotated.
+func syncTargetRotation(t *testing.T, signer *crypto.CA, trustedSigners []*crypto.CA, target *corev1.Secret, certCreator certrotation.TargetCertCreator, validity, refresh time.Duration) bool {
+	t.Helper()
+	signerCert, signerKey, err := signer.Config.GetPEMBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signerSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testSignerNamespace,
+			Name:      testSignerName,
+			Annotations: map[string]string{
+				certrotation.CertificateNotBeforeAnnotation: signer.Config.Certs[0].NotBefore.Format(time.RFC3339),
+				certrotation.CertificateNotAfterAnnotation:  signer.Config.Certs[0].NotAfter.Format(time.RFC3339),
+			},
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{"tls.crt": signerCert, "tls.key": signerKey},
+	}
+	caBundle := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testSignerNamespace, Name: testCABundleName},
+		Data:       map[string]string{"ca-bundle.crt": caBundlePEM(t, append([]*crypto.CA{signer}, trustedSigners...)...)},
+	}
+
+	objects := []runtime.Object{signerSecret, caBundle, target}
+	kubeClient := fake.NewSimpleClientset(objects...)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, obj := range objects {
+		if err := indexer.Add(obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	kubeInformers := informers.NewSharedInformerFactory(kubeClient, 0)
+	recorder := events.NewInMemoryRecorder("test")
+	operatorClient := v1helpers.NewFakeStaticPodOperatorClient(&operatorv1.StaticPodOperatorSpec{}, &operatorv1.StaticPodOperatorStatus{}, nil, nil)
+
+	signerValidity := signer.Config.Certs[0].NotAfter.Sub(signer.Config.Certs[0].NotBefore)
+	controller := newCertCreatorRotationController(
+		"Test",
+		certrotation.RotatedSigningCASecret{
+			Namespace:     testSignerNamespace,
+			Name:          testSignerName,
+			Validity:      signerValidity,
+			Refresh:       signerValidity,
+			Informer:      kubeInformers.Core().V1().Secrets(),
+			Lister:        corev1listers.NewSecretLister(indexer),
+			Client:        kubeClient.CoreV1(),
+			EventRecorder: recorder,
+		},
+		certrotation.CABundleConfigMap{
+			Namespace:     testSignerNamespace,
+			Name:          testCABundleName,
+			Informer:      kubeInformers.Core().V1().ConfigMaps(),
+			Lister:        corev1listers.NewConfigMapLister(indexer),
+			Client:        kubeClient.CoreV1(),
+			EventRecorder: recorder,
+		},
+		certrotation.RotatedSelfSignedCertKeySecret{
+			Namespace:     testTargetNamespace,
+			Name:          testTargetName,
+			Validity:      validity,
+			Refresh:       refresh,
+			CertCreator:   certCreator,
+			Informer:      kubeInformers.Core().V1().Secrets(),
+			Lister:        corev1listers.NewSecretLister(indexer),
+			Client:        kubeClient.CoreV1(),
+			EventRecorder: recorder,
+		},
+		operatorClient,
+		recorder,
+	)
+	if err := controller.Sync(context.TODO(), factory.NewSyncContext("test", recorder)); err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := kubeClient.CoreV1().Secrets(testTargetNamespace).Get(context.TODO(), testTargetName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return !bytes.Equal(actual.Data["tls.crt"], target.Data["tls.crt"])
+}
+
+// decidingCertCreator is a ClientRotation which needs a new certificate as told.
+type decidingCertCreator struct {
+	certrotation.ClientRotation
+	reason string
+}
+
+func (c *decidingCertCreator) NeedNewTargetCertKeyPair(map[string]string, *crypto.CA, []*x509.Certificate, time.Duration, bool) string {
+	return c.reason
+}
+
+func TestCertCreatorDecidesTheTargetRotation(t *testing.T) {
+	validity, refresh := 10*time.Hour, 5*time.Hour
+	signer := newTestSignerValidFrom(t, "test-signer", time.Now().Add(-24*time.Hour), 100*time.Hour)
+
+	scenarios := []struct {
+		name     string
+		issued   time.Time
+		reason   string
+		expected bool
+	}{
+		{
+			name:     "both keep a fresh certificate",
+			issued:   time.Now().Add(-time.Hour),
+			expected: false,
+		},
+		{
+			name:     "the creator rotates a fresh certificate",
+			issued:   time.Now().Add(-time.Hour),
+			reason:   "the certificate is stale",
+			expected: true,
+		},
+		{
+			name:     "the creator keeps a certificate past its refresh time",
+			issued:   time.Now().Add(-6 * time.Hour),
+			expected: false,
+		},
+		{
+			name:     "the creator keeps a certificate past 80% of its validity",
+			issued:   time.Now().Add(-9 * time.Hour),
+			expected: false,
+		},
+		{
+			name:     "both rotate a certificate past its refresh time",
+			issued:   time.Now().Add(-6 * time.Hour),
+			reason:   "past its refresh time",
+			expected: true,
+		},
+		{
+			name:     "an expired certificate is rotated regardless",
+			issued:   time.Now().Add(-11 * time.Hour),
+			expected: true,
+		},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			creator := &decidingCertCreator{ClientRotation: certrotation.ClientRotation{UserInfo: &user.DefaultInfo{Name: "test"}}, reason: scenario.reason}
+			target := newTestTargetSecret(t, signer, creator, scenario.issued, validity)
+			if rotated := syncTargetRotation(t, signer, nil, target, creator, validity, refresh); rotated != scenario.expected {
+				t.Errorf("expected rotated %v, got %v", scenario.expected, rotated)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package certrotationcontroller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corelistersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+// certExpiryController reports the remaining validity of the signer and target certificates
+// managed by the cert rotation controllers in the kube_apiserver_operator_cert_expiry_seconds metric.
+type certExpiryController struct {
+	secretListers map[string]corelistersv1.SecretLister
+	now           func() time.Time
+
+	// reported holds the namespace/name of the secrets currently exposed by the metric,
+	// so that the series of deleted secrets can be removed.
+	reported sets.String
+}
+
+func newCertExpiryController(
+	namespaces []string,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &certExpiryController{
+		secretListers: map[string]corelistersv1.SecretLister{},
+		now:           time.Now,
+		reported:      sets.NewString(),
+	}
+
+	informers := []factory.Informer{}
+	for _, ns := range namespaces {
+		secretInformer := kubeInformersForNamespaces.InformersFor(ns).Core().V1().Secrets()
+		c.secretListers[ns] = secretInformer.Lister()
+		informers = append(informers, secretInformer.Informer())
+	}
+
+	// the remaining validity decreases even when nothing changes, resync to keep the metric current
+	return factory.New().WithInformers(informers...).WithSync(c.sync).ResyncEvery(time.Minute).
+		ToController("CertRotationExpiryController", eventRecorder.WithComponentSuffix("cert-expiry-controller"))
+}
+
+func (c *certExpiryController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	selector, err := managedCertificateSelector()
+	if err != nil {
+		return err
+	}
+
+	seen := sets.NewString()
+	for ns, lister := range c.secretListers {
+		secrets, err := lister.Secrets(ns).List(selector)
+		if err != nil {
+			return err
+		}
+		for _, secret := range secrets {
+			certs, err := certutil.ParseCertsPEM(secret.Data["tls.crt"])
+			if err != nil || len(certs) == 0 {
+				klog.V(4).Infof("Unable to parse the certificate in secret %s/%s: %v", secret.Namespace, secret.Name, err)
+				continue
+			}
+			certExpirySecondsGauge.WithLabelValues(secret.Name, secret.Namespace).Set(certs[0].NotAfter.Sub(c.now()).Seconds())
+			key, _ := cache.MetaNamespaceKeyFunc(secret)
+			seen.Insert(key)
+		}
+	}
+
+	for _, key := range c.reported.Difference(seen).UnsortedList() {
+		ns, name, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil {
+			return fmt.Errorf("invalid secret key %q: %v", key, err)
+		}
+		certExpirySecondsGauge.Delete(map[string]string{"name": name, "namespace": ns})
+	}
+	c.reported = seen
+
+	return nil
+}
+
+// managedCertificateSelector selects the signer and target secrets written by the cert rotation controllers.
+func managedCertificateSelector() (labels.Selector, error) {
+	requirement, err := labels.NewRequirement(certrotation.ManagedCertificateTypeLabelName, selection.In, []string{
+		string(certrotation.CertificateTypeSigner),
+		string(certrotation.CertificateTypeTarget),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return labels.NewSelector().Add(*requirement), nil
+}
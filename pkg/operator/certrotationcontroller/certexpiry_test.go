@@ -0,0 +1,70 @@
+package certrotationcontroller
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corelistersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/testutil"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestCertExpiryController(t *testing.T) {
+	registry := metrics.NewKubeRegistry()
+	registry.MustRegister(certExpirySecondsGauge)
+	certExpirySecondsGauge.Reset()
+
+	shortLived := newSignerSecret(t, "short-lived-signer", 10*time.Minute, nil)
+	unmanaged := newSignerSecret(t, "unmanaged", time.Hour, nil)
+	unmanaged.Labels = nil
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, s := range []*corev1.Secret{shortLived, unmanaged} {
+		if err := indexer.Add(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := &certExpiryController{
+		secretListers: map[string]corelistersv1.SecretLister{shortLived.Namespace: corelistersv1.NewSecretLister(indexer)},
+		now:           time.Now,
+		reported:      sets.NewString(),
+	}
+	syncCtx := factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))
+	if err := c.sync(context.TODO(), syncCtx); err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := testutil.GetGaugeMetricValue(certExpirySecondsGauge.WithLabelValues(shortLived.Name, shortLived.Namespace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the certificate is valid for 10 minutes, allow for the time elapsed since it was created
+	if expected := (10 * time.Minute).Seconds(); math.Abs(actual-expected) > 5 {
+		t.Errorf("expected the gauge to be about %v, got %v", expected, actual)
+	}
+	if c.reported.Has(unmanaged.Namespace + "/" + unmanaged.Name) {
+		t.Errorf("expected unmanaged secret not to be reported")
+	}
+
+	// the series goes away with the secret
+	if err := indexer.Delete(shortLived); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.sync(context.TODO(), syncCtx); err != nil {
+		t.Fatal(err)
+	}
+	if c.reported.Len() != 0 {
+		t.Errorf("expected no reported secrets, got %v", c.reported.List())
+	}
+	if deleted := certExpirySecondsGauge.Delete(map[string]string{"name": shortLived.Name, "namespace": shortLived.Namespace}); deleted {
+		t.Errorf("expected the series of the deleted secret to be removed by the controller")
+	}
+}
@@ -29,6 +29,14 @@ const defaultRotationDay = 24 * time.Hour
 
 type CertRotationController struct {
 	certRotators []factory.Controller
+	// forceRotation rotates the signers annotated with ForceRotationAnnotation.
+	forceRotation factory.Controller
+	// certExpiry reports the remaining validity of the managed certificates.
+	certExpiry factory.Controller
+	// cnCollision reports managed client certificates sharing a common name.
+	cnCollision factory.Controller
+	// localhostRecovery keeps the localhost-recovery serving certificate valid.
+	localhostRecovery factory.Controller
 
 	networkLister        configlisterv1.NetworkLister
 	infrastructureLister configlisterv1.InfrastructureLister
@@ -114,8 +122,41 @@ func newCertRotationController(
 		},
 	}
 
+	ret.forceRotation = newForceRotationController(
+		operatorclient.OperatorNamespace,
+		kubeInformersForNamespaces.InformersFor(operatorclient.OperatorNamespace).Core().V1().Secrets(),
+		kubeClient.CoreV1(),
+		eventRecorder,
+	)
+	ret.certExpiry = newCertExpiryController(
+		[]string{
+			operatorclient.OperatorNamespace,
+			operatorclient.TargetNamespace,
+			operatorclient.GlobalMachineSpecifiedConfigNamespace,
+		},
+		kubeInformersForNamespaces,
+		eventRecorder,
+	)
+	ret.cnCollision = newClientCertCNCollisionController(
+		[]string{
+			operatorclient.OperatorNamespace,
+			operatorclient.TargetNamespace,
+			operatorclient.GlobalMachineSpecifiedConfigNamespace,
+		},
+		operatorClient,
+		kubeInformersForNamespaces,
+		eventRecorder,
+	)
+	ret.localhostRecovery = newLocalhostRecoveryServingController(
+		operatorClient,
+		kubeInformersForNamespaces,
+		kubeClient.CoreV1(),
+		eventRecorder,
+	)
+
 	configInformer.Config().V1().Networks().Informer().AddEventHandler(ret.serviceHostnameEventHandler())
 	configInformer.Config().V1().Infrastructures().Informer().AddEventHandler(ret.externalLoadBalancerHostnameEventHandler())
+	configInformer.Config().V1().Infrastructures().Informer().AddEventHandler(ret.internalLoadBalancerHostnameEventHandler())
 
 	rotationDay := defaultRotationDay
 	if day != time.Duration(0) {
@@ -128,7 +169,13 @@ func newCertRotationController(
 		rotationDay = rotationDay / 60
 	}
 
-	certRotator := certrotation.NewCertRotationController(
+	// the aggregator client certificate is only re-signed once the new signer is published to the aggregated apiservers
+	kubeSystemConfigMaps := kubeInformersForNamespaces.InformersFor("kube-system").Core().V1().ConfigMaps()
+	aggregatorClientRotation := NewAggregatorClientRotation(kubeSystemConfigMaps.Lister())
+	kubeSystemConfigMaps.Informer().AddEventHandler(aggregatorClientRotation.eventHandler())
+	ret.cachesToSync = append(ret.cachesToSync, kubeSystemConfigMaps.Informer().HasSynced)
+
+	certRotator := newCertCreatorRotationController(
 		"AggregatorProxyClientCert",
 		certrotation.RotatedSigningCASecret{
 			Namespace:              operatorclient.OperatorNamespace,
@@ -155,20 +202,18 @@ func newCertRotationController(
 			Validity:               30 * rotationDay,
 			Refresh:                15 * rotationDay,
 			RefreshOnlyWhenExpired: refreshOnlyWhenExpired,
-			CertCreator: &certrotation.ClientRotation{
-				UserInfo: &user.DefaultInfo{Name: "system:openshift-aggregator"},
-			},
-			Informer:      kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets(),
-			Lister:        kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets().Lister(),
-			Client:        kubeClient.CoreV1(),
-			EventRecorder: eventRecorder,
+			CertCreator:            newStaggeredRotation("aggregator-client", aggregatorClientRotation),
+			Informer:               kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets(),
+			Lister:                 kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets().Lister(),
+			Client:                 kubeClient.CoreV1(),
+			EventRecorder:          eventRecorder,
 		},
 		operatorClient,
 		eventRecorder,
 	)
 	ret.certRotators = append(ret.certRotators, certRotator)
 
-	certRotator = certrotation.NewCertRotationController(
+	certRotator = newCertCreatorRotationController(
 		"KubeAPIServerToKubeletClientCert",
 		certrotation.RotatedSigningCASecret{
 			Namespace:              operatorclient.OperatorNamespace,
@@ -195,9 +240,9 @@ func newCertRotationController(
 			Validity:               30 * rotationDay,
 			Refresh:                15 * rotationDay,
 			RefreshOnlyWhenExpired: refreshOnlyWhenExpired,
-			CertCreator: &certrotation.ClientRotation{
+			CertCreator: newStaggeredRotation("kubelet-client", &certrotation.ClientRotation{
 				UserInfo: &user.DefaultInfo{Name: "system:kube-apiserver", Groups: []string{"kube-master"}},
-			},
+			}),
 			Informer:      kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets(),
 			Lister:        kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets().Lister(),
 			Client:        kubeClient.CoreV1(),
@@ -208,7 +253,7 @@ func newCertRotationController(
 	)
 	ret.certRotators = append(ret.certRotators, certRotator)
 
-	certRotator = certrotation.NewCertRotationController(
+	certRotator = newCertCreatorRotationController(
 		"LocalhostServing",
 		certrotation.RotatedSigningCASecret{
 			Namespace:              operatorclient.OperatorNamespace,
@@ -235,9 +280,9 @@ func newCertRotationController(
 			Validity:               30 * rotationDay,
 			Refresh:                15 * rotationDay,
 			RefreshOnlyWhenExpired: refreshOnlyWhenExpired,
-			CertCreator: &certrotation.ServingRotation{
+			CertCreator: newStaggeredRotation("localhost-serving-cert-certkey", &certrotation.ServingRotation{
 				Hostnames: func() []string { return []string{"localhost", "127.0.0.1"} },
-			},
+			}),
 			Informer:      kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets(),
 			Lister:        kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets().Lister(),
 			Client:        kubeClient.CoreV1(),
@@ -248,7 +293,7 @@ func newCertRotationController(
 	)
 	ret.certRotators = append(ret.certRotators, certRotator)
 
-	certRotator = certrotation.NewCertRotationController(
+	certRotator = newCertCreatorRotationController(
 		"ServiceNetworkServing",
 		certrotation.RotatedSigningCASecret{
 			Namespace:              operatorclient.OperatorNamespace,
@@ -275,10 +320,10 @@ func newCertRotationController(
 			Validity:               30 * rotationDay,
 			Refresh:                15 * rotationDay,
 			RefreshOnlyWhenExpired: refreshOnlyWhenExpired,
-			CertCreator: &certrotation.ServingRotation{
+			CertCreator: newStaggeredRotation("service-network-serving-certkey", &certrotation.ServingRotation{
 				Hostnames:        ret.serviceNetwork.GetHostnames,
 				HostnamesChanged: ret.serviceNetwork.hostnamesChanged,
-			},
+			}),
 			Informer:      kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets(),
 			Lister:        kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets().Lister(),
 			Client:        kubeClient.CoreV1(),
@@ -289,7 +334,7 @@ func newCertRotationController(
 	)
 	ret.certRotators = append(ret.certRotators, certRotator)
 
-	certRotator = certrotation.NewCertRotationController(
+	certRotator = newCertCreatorRotationController(
 		"ExternalLoadBalancerServing",
 		certrotation.RotatedSigningCASecret{
 			Namespace:              operatorclient.OperatorNamespace,
@@ -316,10 +361,11 @@ func newCertRotationController(
 			Validity:               30 * rotationDay,
 			Refresh:                15 * rotationDay,
 			RefreshOnlyWhenExpired: refreshOnlyWhenExpired,
-			CertCreator: &certrotation.ServingRotation{
-				Hostnames:        ret.externalLoadBalancer.GetHostnames,
-				HostnamesChanged: ret.externalLoadBalancer.hostnamesChanged,
-			},
+			CertCreator: newStaggeredRotation("external-loadbalancer-serving-certkey", NewLoadBalancerServingRotation(
+				ret.externalLoadBalancer,
+				kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets().Lister().Secrets(operatorclient.TargetNamespace),
+				"external-loadbalancer-serving-certkey",
+			)),
 			Informer:      kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets(),
 			Lister:        kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets().Lister(),
 			Client:        kubeClient.CoreV1(),
@@ -330,7 +376,7 @@ func newCertRotationController(
 	)
 	ret.certRotators = append(ret.certRotators, certRotator)
 
-	certRotator = certrotation.NewCertRotationController(
+	certRotator = newCertCreatorRotationController(
 		"InternalLoadBalancerServing",
 		certrotation.RotatedSigningCASecret{
 			Namespace:              operatorclient.OperatorNamespace,
@@ -357,10 +403,11 @@ func newCertRotationController(
 			Validity:               30 * rotationDay,
 			Refresh:                15 * rotationDay,
 			RefreshOnlyWhenExpired: refreshOnlyWhenExpired,
-			CertCreator: &certrotation.ServingRotation{
-				Hostnames:        ret.internalLoadBalancer.GetHostnames,
-				HostnamesChanged: ret.internalLoadBalancer.hostnamesChanged,
-			},
+			CertCreator: newStaggeredRotation("internal-loadbalancer-serving-certkey", NewLoadBalancerServingRotation(
+				ret.internalLoadBalancer,
+				kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets().Lister().Secrets(operatorclient.TargetNamespace),
+				"internal-loadbalancer-serving-certkey",
+			)),
 			Informer:      kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets(),
 			Lister:        kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets().Lister(),
 			Client:        kubeClient.CoreV1(),
@@ -371,11 +418,11 @@ func newCertRotationController(
 	)
 	ret.certRotators = append(ret.certRotators, certRotator)
 
-	certRotator = certrotation.NewCertRotationController(
+	certRotator = newCertCreatorRotationController(
 		"LocalhostRecoveryServing",
 		certrotation.RotatedSigningCASecret{
 			Namespace:     operatorclient.OperatorNamespace,
-			Name:          "localhost-recovery-serving-signer",
+			Name:          localhostRecoveryServingSignerName,
 			Validity:      10 * 365 * defaultRotationDay, // this comes from the installer
 			Refresh:       8 * 365 * defaultRotationDay,  // this means we effectively do not rotate
 			Informer:      kubeInformersForNamespaces.InformersFor(operatorclient.OperatorNamespace).Core().V1().Secrets(),
@@ -393,11 +440,11 @@ func newCertRotationController(
 		},
 		certrotation.RotatedSelfSignedCertKeySecret{
 			Namespace: operatorclient.TargetNamespace,
-			Name:      "localhost-recovery-serving-certkey",
-			Validity:  10 * 365 * defaultRotationDay,
-			Refresh:   8 * 365 * defaultRotationDay, // this means we effectively do not rotate
+			Name:      localhostRecoveryServingCertKeyName,
+			Validity:  localhostRecoveryServingValidity,
+			Refresh:   localhostRecoveryServingRefresh, // this means we effectively do not rotate
 			CertCreator: &certrotation.ServingRotation{
-				Hostnames: func() []string { return []string{"localhost-recovery"} },
+				Hostnames: func() []string { return []string{localhostRecoveryServingHostname} },
 			},
 			Informer:      kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets(),
 			Lister:        kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets().Lister(),
@@ -409,7 +456,7 @@ func newCertRotationController(
 	)
 	ret.certRotators = append(ret.certRotators, certRotator)
 
-	certRotator = certrotation.NewCertRotationController(
+	certRotator = newCertCreatorRotationController(
 		"KubeControllerManagerClient",
 		certrotation.RotatedSigningCASecret{
 			Namespace:              operatorclient.OperatorNamespace,
@@ -436,9 +483,9 @@ func newCertRotationController(
 			Validity:               30 * rotationDay,
 			Refresh:                15 * rotationDay,
 			RefreshOnlyWhenExpired: refreshOnlyWhenExpired,
-			CertCreator: &certrotation.ClientRotation{
+			CertCreator: newStaggeredRotation("kube-controller-manager-client-cert-key", &certrotation.ClientRotation{
 				UserInfo: &user.DefaultInfo{Name: "system:kube-controller-manager"},
-			},
+			}),
 			Informer:      kubeInformersForNamespaces.InformersFor(operatorclient.GlobalMachineSpecifiedConfigNamespace).Core().V1().Secrets(),
 			Lister:        kubeInformersForNamespaces.InformersFor(operatorclient.GlobalMachineSpecifiedConfigNamespace).Core().V1().Secrets().Lister(),
 			Client:        kubeClient.CoreV1(),
@@ -449,7 +496,7 @@ func newCertRotationController(
 	)
 	ret.certRotators = append(ret.certRotators, certRotator)
 
-	certRotator = certrotation.NewCertRotationController(
+	certRotator = newCertCreatorRotationController(
 		"KubeSchedulerClient",
 		certrotation.RotatedSigningCASecret{
 			Namespace:              operatorclient.OperatorNamespace,
@@ -476,9 +523,9 @@ func newCertRotationController(
 			Validity:               30 * rotationDay,
 			Refresh:                15 * rotationDay,
 			RefreshOnlyWhenExpired: refreshOnlyWhenExpired,
-			CertCreator: &certrotation.ClientRotation{
+			CertCreator: newStaggeredRotation("kube-scheduler-client-cert-key", &certrotation.ClientRotation{
 				UserInfo: &user.DefaultInfo{Name: "system:kube-scheduler"},
-			},
+			}),
 			Informer:      kubeInformersForNamespaces.InformersFor(operatorclient.GlobalMachineSpecifiedConfigNamespace).Core().V1().Secrets(),
 			Lister:        kubeInformersForNamespaces.InformersFor(operatorclient.GlobalMachineSpecifiedConfigNamespace).Core().V1().Secrets().Lister(),
 			Client:        kubeClient.CoreV1(),
@@ -489,7 +536,7 @@ func newCertRotationController(
 	)
 	ret.certRotators = append(ret.certRotators, certRotator)
 
-	certRotator = certrotation.NewCertRotationController(
+	certRotator = newCertCreatorRotationController(
 		"ControlPlaneNodeAdminClient",
 		certrotation.RotatedSigningCASecret{
 			Namespace:              operatorclient.OperatorNamespace,
@@ -516,9 +563,9 @@ func newCertRotationController(
 			Validity:               30 * rotationDay,
 			Refresh:                15 * rotationDay,
 			RefreshOnlyWhenExpired: refreshOnlyWhenExpired,
-			CertCreator: &certrotation.ClientRotation{
+			CertCreator: newStaggeredRotation("control-plane-node-admin-client-cert-key", &certrotation.ClientRotation{
 				UserInfo: &user.DefaultInfo{Name: "system:control-plane-node-admin", Groups: []string{"system:masters"}},
-			},
+			}),
 			Informer:      kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets(),
 			Lister:        kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets().Lister(),
 			Client:        kubeClient.CoreV1(),
@@ -529,7 +576,7 @@ func newCertRotationController(
 	)
 	ret.certRotators = append(ret.certRotators, certRotator)
 
-	certRotator = certrotation.NewCertRotationController(
+	certRotator = newCertCreatorRotationController(
 		"CheckEndpointsClient",
 		certrotation.RotatedSigningCASecret{
 			Namespace:              operatorclient.OperatorNamespace,
@@ -556,9 +603,9 @@ func newCertRotationController(
 			Validity:               30 * rotationDay,
 			Refresh:                15 * rotationDay,
 			RefreshOnlyWhenExpired: refreshOnlyWhenExpired,
-			CertCreator: &certrotation.ClientRotation{
+			CertCreator: newStaggeredRotation("check-endpoints-client-cert-key", &certrotation.ClientRotation{
 				UserInfo: &user.DefaultInfo{Name: "system:serviceaccount:openshift-kube-apiserver:check-endpoints"},
-			},
+			}),
 			Informer:      kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets(),
 			Lister:        kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets().Lister(),
 			Client:        kubeClient.CoreV1(),
@@ -569,7 +616,7 @@ func newCertRotationController(
 	)
 	ret.certRotators = append(ret.certRotators, certRotator)
 
-	certRotator = certrotation.NewCertRotationController(
+	certRotator = newCertCreatorRotationController(
 		"NodeSystemAdminClient",
 		certrotation.RotatedSigningCASecret{
 			Namespace:              operatorclient.OperatorNamespace,
@@ -666,6 +713,10 @@ func (c *CertRotationController) Run(ctx context.Context, workers int) {
 	for _, certRotator := range c.certRotators {
 		go certRotator.Run(ctx, workers)
 	}
+	go c.forceRotation.Run(ctx, workers)
+	go c.certExpiry.Run(ctx, workers)
+	go c.cnCollision.Run(ctx, workers)
+	go c.localhostRecovery.Run(ctx, workers)
 
 	<-ctx.Done()
 }
@@ -2,7 +2,6 @@ package certrotationcontroller
 
 import (
 	"fmt"
-	"strings"
 
 	"k8s.io/klog/v2"
 
@@ -20,9 +19,11 @@ func (c *CertRotationController) syncExternalLoadBalancerHostnames() error {
 		klog.Warningf("Failed to set external loadbalancer: APIServerURL is not set")
 		return nil
 	}
-	hostname = strings.Replace(hostname, "https://", "", 1)
-	hostname_arr := strings.Split(hostname, ":")
-	hostname = hostname_arr[0]
+	hostname, err = loadBalancerHostname(hostname)
+	if err != nil {
+		klog.Warningf("Failed to set external loadbalancer: invalid APIServerURL %q: %v", infrastructureConfig.Status.APIServerURL, err)
+		return nil
+	}
 
 	klog.V(2).Infof("syncing external loadbalancer hostnames: %v", hostname)
 	c.externalLoadBalancer.setHostnames([]string{hostname})
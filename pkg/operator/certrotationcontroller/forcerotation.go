@@ -0,0 +1,122 @@
+package certrotationcontroller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	coreinformersv1 "k8s.io/client-go/informers/core/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelistersv1 "k8s.io/client-go/listers/core/v1"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+// ForceRotationAnnotation requests the immediate regeneration of the signing CA stored in the annotated
+// signer secret, regardless of its remaining validity. It is removed once the signer has been rotated.
+const ForceRotationAnnotation = "certrotation.openshift.io/force-rotation"
+
+// forceRotationController regenerates the signer secrets carrying the ForceRotationAnnotation.
+// The cert rotation controllers then pick up the new signer like after a regular rotation.
+type forceRotationController struct {
+	namespace    string
+	secretLister corelistersv1.SecretLister
+	secretClient corev1client.SecretsGetter
+}
+
+func newForceRotationController(
+	namespace string,
+	secretInformer coreinformersv1.SecretInformer,
+	secretClient corev1client.SecretsGetter,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &forceRotationController{
+		namespace:    namespace,
+		secretLister: secretInformer.Lister(),
+		secretClient: secretClient,
+	}
+
+	return factory.New().WithInformers(
+		secretInformer.Informer(),
+	).WithSync(c.sync).ToController("CertRotationForceRotationController", eventRecorder.WithComponentSuffix("cert-force-rotation-controller"))
+}
+
+func (c *forceRotationController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	selector := labels.SelectorFromSet(labels.Set{certrotation.ManagedCertificateTypeLabelName: string(certrotation.CertificateTypeSigner)})
+	signers, err := c.secretLister.Secrets(c.namespace).List(selector)
+	if err != nil {
+		return err
+	}
+
+	errs := []error{}
+	for _, signer := range signers {
+		if _, ok := signer.Annotations[ForceRotationAnnotation]; !ok {
+			continue
+		}
+		if err := c.rotateSigner(ctx, signer.DeepCopy()); err != nil {
+			syncCtx.Recorder().Warningf("SignerForcedRotationFailed", "Failed to force the rotation of %q in %q: %v", signer.Name, signer.Namespace, err)
+			errs = append(errs, err)
+			continue
+		}
+		syncCtx.Recorder().Eventf("SignerForcedRotation", "%q in %q was rotated as requested by the %s annotation", signer.Name, signer.Namespace, ForceRotationAnnotation)
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// rotateSigner replaces the signing cert/key pair with a new one valid for as long as the current one was,
+// and drops the ForceRotationAnnotation in the same update.
+func (c *forceRotationController) rotateSigner(ctx context.Context, signer *corev1.Secret) error {
+	validity, err := signerValidity(signer)
+	if err != nil {
+		return err
+	}
+
+	signerName := fmt.Sprintf("%s_%s@%d", signer.Namespace, signer.Name, time.Now().Unix())
+	ca, err := crypto.MakeSelfSignedCAConfigForDuration(signerName, validity)
+	if err != nil {
+		return err
+	}
+	certBytes := &bytes.Buffer{}
+	keyBytes := &bytes.Buffer{}
+	if err := ca.WriteCertConfig(certBytes, keyBytes); err != nil {
+		return err
+	}
+
+	if signer.Data == nil {
+		signer.Data = map[string][]byte{}
+	}
+	signer.Data["tls.crt"] = certBytes.Bytes()
+	signer.Data["tls.key"] = keyBytes.Bytes()
+	signer.Annotations[certrotation.CertificateNotAfterAnnotation] = ca.Certs[0].NotAfter.Format(time.RFC3339)
+	signer.Annotations[certrotation.CertificateNotBeforeAnnotation] = ca.Certs[0].NotBefore.Format(time.RFC3339)
+	signer.Annotations[certrotation.CertificateIssuer] = ca.Certs[0].Issuer.CommonName
+	delete(signer.Annotations, ForceRotationAnnotation)
+
+	_, err = c.secretClient.Secrets(signer.Namespace).Update(ctx, signer, metav1.UpdateOptions{})
+	return err
+}
+
+// signerValidity returns the validity the signer was created with.
+func signerValidity(signer *corev1.Secret) (time.Duration, error) {
+	notBefore, err := time.Parse(time.RFC3339, signer.Annotations[certrotation.CertificateNotBeforeAnnotation])
+	if err != nil {
+		return 0, fmt.Errorf("bad %s annotation: %v", certrotation.CertificateNotBeforeAnnotation, err)
+	}
+	notAfter, err := time.Parse(time.RFC3339, signer.Annotations[certrotation.CertificateNotAfterAnnotation])
+	if err != nil {
+		return 0, fmt.Errorf("bad %s annotation: %v", certrotation.CertificateNotAfterAnnotation, err)
+	}
+	if !notAfter.After(notBefore) {
+		return 0, fmt.Errorf("invalid validity window from %v to %v", notBefore, notAfter)
+	}
+	return notAfter.Sub(notBefore), nil
+}
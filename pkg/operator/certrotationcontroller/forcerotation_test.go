@@ -0,0 +1,111 @@
+package certrotationcontroller
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	corelistersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func newSignerSecret(t *testing.T, name string, validity time.Duration, annotations map[string]string) *corev1.Secret {
+	ca, err := crypto.MakeSelfSignedCAConfigForDuration(name, validity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certBytes, keyBytes := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := ca.WriteCertConfig(certBytes, keyBytes); err != nil {
+		t.Fatal(err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-kube-apiserver-operator",
+			Name:      name,
+			Labels:    map[string]string{certrotation.ManagedCertificateTypeLabelName: string(certrotation.CertificateTypeSigner)},
+			Annotations: map[string]string{
+				certrotation.CertificateNotAfterAnnotation:  ca.Certs[0].NotAfter.Format(time.RFC3339),
+				certrotation.CertificateNotBeforeAnnotation: ca.Certs[0].NotBefore.Format(time.RFC3339),
+				certrotation.CertificateIssuer:              ca.Certs[0].Issuer.CommonName,
+			},
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{"tls.crt": certBytes.Bytes(), "tls.key": keyBytes.Bytes()},
+	}
+	for k, v := range annotations {
+		secret.Annotations[k] = v
+	}
+	return secret
+}
+
+func TestForceRotationController(t *testing.T) {
+	forced := newSignerSecret(t, "kube-apiserver-to-kubelet-signer", 365*24*time.Hour, map[string]string{ForceRotationAnnotation: "compromised"})
+	untouched := newSignerSecret(t, "aggregator-client-signer", 30*24*time.Hour, nil)
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, s := range []*corev1.Secret{forced, untouched} {
+		if err := indexer.Add(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	kubeClient := fake.NewSimpleClientset(forced, untouched)
+	recorder := events.NewInMemoryRecorder("test")
+
+	c := &forceRotationController{
+		namespace:    "openshift-kube-apiserver-operator",
+		secretLister: corelistersv1.NewSecretLister(indexer),
+		secretClient: kubeClient.CoreV1(),
+	}
+	if err := c.sync(context.TODO(), factory.NewSyncContext("test", recorder)); err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := kubeClient.CoreV1().Secrets(forced.Namespace).Get(context.TODO(), forced.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := rotated.Annotations[ForceRotationAnnotation]; ok {
+		t.Errorf("expected %s annotation to be cleared", ForceRotationAnnotation)
+	}
+	if bytes.Equal(rotated.Data["tls.crt"], forced.Data["tls.crt"]) {
+		t.Errorf("expected a new signing certificate")
+	}
+	if rotated.Annotations[certrotation.CertificateIssuer] == forced.Annotations[certrotation.CertificateIssuer] {
+		t.Errorf("expected a new issuer, got %q", rotated.Annotations[certrotation.CertificateIssuer])
+	}
+	ca, err := crypto.GetCAFromBytes(rotated.Data["tls.crt"], rotated.Data["tls.key"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if validity := ca.Config.Certs[0].NotAfter.Sub(ca.Config.Certs[0].NotBefore); validity < 364*24*time.Hour || validity > 366*24*time.Hour {
+		t.Errorf("expected the rotated signer to keep its one year validity, got %v", validity)
+	}
+
+	notRotated, err := kubeClient.CoreV1().Secrets(untouched.Namespace).Get(context.TODO(), untouched.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(notRotated.Data["tls.crt"], untouched.Data["tls.crt"]) {
+		t.Errorf("expected %q not to be rotated", untouched.Name)
+	}
+
+	found := false
+	for _, ev := range recorder.Events() {
+		if ev.Reason == "SignerForcedRotation" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected SignerForcedRotation event, got %v", recorder.Events())
+	}
+}
@@ -2,7 +2,6 @@ package certrotationcontroller
 
 import (
 	"fmt"
-	"strings"
 
 	"k8s.io/klog/v2"
 
@@ -16,13 +15,15 @@ func (c *CertRotationController) syncInternalLoadBalancerHostnames() error {
 		return err
 	}
 	hostname := infrastructureConfig.Status.APIServerInternalURL
-	// if hostname is not set do not panic with slice bounds out of range
 	if len(hostname) == 0 {
 		klog.Warningf("Failed to set internal loadbalancer: APIServerInternalURL is not set")
 		return nil
 	}
-	hostname = strings.Replace(hostname, "https://", "", 1)
-	hostname = hostname[0:strings.LastIndex(hostname, ":")]
+	hostname, err = loadBalancerHostname(hostname)
+	if err != nil {
+		klog.Warningf("Failed to set internal loadbalancer: invalid APIServerInternalURL %q: %v", infrastructureConfig.Status.APIServerInternalURL, err)
+		return nil
+	}
 
 	klog.V(2).Infof("syncing internal loadbalancer hostnames: %v", hostname)
 	c.internalLoadBalancer.setHostnames([]string{hostname})
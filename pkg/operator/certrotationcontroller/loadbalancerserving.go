@@ -0,0 +1,105 @@
+package certrotationcontroller
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+)
+
+// loadBalancerHostname returns the host of an Infrastructure API server URL, which the loadbalancer serving
+// certificate is valid for, e.g. "api.example.com" for "https://api.example.com:6443" and "fd00::1" for
+// "https://[fd00::1]:6443". IP addresses are canonicalized, "https://[FD00:0::0001]:6443" is "fd00::1" as well, so
+// that they match the IP SANs of the certificate as they are read back.
+func loadBalancerHostname(apiServerURL string) (string, error) {
+	u, err := url.Parse(apiServerURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "https" {
+		return "", fmt.Errorf("scheme must be https")
+	}
+	host := u.Hostname()
+	if len(host) == 0 {
+		return "", fmt.Errorf("no host")
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String(), nil
+	}
+	return host, nil
+}
+
+// LoadBalancerServingRotation creates the loadbalancer serving certificates like certrotation.ServingRotation. On top
+// of the hostnames recorded in the annotation of the secret, it checks the SANs of the certificate itself against the
+// required hostnames, so that a certificate whose SANs drifted, e.g. because the secret was restored or edited with
+// stale annotations, is regenerated as well. A certificate with the required SANs is kept.
+type LoadBalancerServingRotation struct {
+	certrotation.ServingRotation
+
+	// secretLister lists the secrets of the namespace of the target secret.
+	secretLister corev1listers.SecretNamespaceLister
+	secretName   string
+}
+
+func NewLoadBalancerServingRotation(hostnames *DynamicServingRotation, secretLister corev1listers.SecretNamespaceLister, secretName string) *LoadBalancerServingRotation {
+	return &LoadBalancerServingRotation{
+		ServingRotation: certrotation.ServingRotation{
+			Hostnames:        hostnames.GetHostnames,
+			HostnamesChanged: hostnames.hostnamesChanged,
+		},
+		secretLister: secretLister,
+		secretName:   secretName,
+	}
+}
+
+func (r *LoadBalancerServingRotation) NeedNewTargetCertKeyPair(annotations map[string]string, signer *crypto.CA, caBundleCerts []*x509.Certificate, refresh time.Duration, refreshOnlyWhenExpired bool) string {
+	if reason := r.ServingRotation.NeedNewTargetCertKeyPair(annotations, signer, caBundleCerts, refresh, refreshOnlyWhenExpired); len(reason) > 0 {
+		return reason
+	}
+
+	secret, err := r.secretLister.Get(r.secretName)
+	if apierrors.IsNotFound(err) {
+		return ""
+	}
+	if err != nil {
+		klog.Warningf("Failed to check the SANs of the %s certificate: %v", r.secretName, err)
+		return ""
+	}
+	// a cached secret older than the annotations checked above would regenerate the certificate just written
+	if secret.Annotations[certrotation.CertificateNotAfterAnnotation] != annotations[certrotation.CertificateNotAfterAnnotation] {
+		return ""
+	}
+	actual, err := certificateSANs(secret)
+	if err != nil {
+		return fmt.Sprintf("the certificate cannot be read: %v", err)
+	}
+	if required := sets.NewString(r.Hostnames()...); !actual.Equal(required) {
+		return fmt.Sprintf("the certificate SANs %q are not the required %q", strings.Join(actual.List(), ","), strings.Join(required.List(), ","))
+	}
+	return ""
+}
+
+// certificateSANs returns the DNS names and IP addresses of the serving certificate of the secret.
+func certificateSANs(secret *corev1.Secret) (sets.String, error) {
+	certs, err := cert.ParseCertsPEM(secret.Data[corev1.TLSCertKey])
+	if err != nil {
+		return nil, err
+	}
+	sans := sets.NewString(certs[0].DNSNames...)
+	for _, ip := range certs[0].IPAddresses {
+		sans.Insert(ip.String())
+	}
+	return sans, nil
+}
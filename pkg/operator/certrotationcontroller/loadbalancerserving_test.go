@@ -0,0 +1,220 @@
+package certrotationcontroller
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+)
+
+func TestLoadBalancerHostname(t *testing.T) {
+	for url, expected := range map[string]string{
+		"https://api.example.com:6443":     "api.example.com",
+		"https://api-int.example.com:6443": "api-int.example.com",
+		"https://api.example.com":          "api.example.com",
+		"https://[fd00::1]:6443":           "fd00::1",
+		"https://[FD00:0::0001]:6443":      "fd00::1",
+		"https://[::ffff:10.0.0.1]:6443":   "10.0.0.1",
+		"https://192.168.0.10:6443":        "192.168.0.10",
+		"http://api.example.com:6443":      "",
+		"api.example.com:6443":             "",
+		"https://:6443":                    "",
+	} {
+		hostname, err := loadBalancerHostname(url)
+		if len(expected) == 0 {
+			if err == nil {
+				t.Errorf("expected an error for %q, got %q", url, hostname)
+			}
+			continue
+		}
+		if err != nil || hostname != expected {
+			t.Errorf("expected %q for %q, got %q, %v", expected, url, hostname, err)
+		}
+	}
+}
+
+func TestSyncExternalLoadBalancerHostnames(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	c := &CertRotationController{
+		infrastructureLister: configlistersv1.NewInfrastructureLister(indexer),
+		externalLoadBalancer: &DynamicServingRotation{hostnamesChanged: make(chan struct{}, 10)},
+	}
+	// syncURL syncs the hostnames for the given APIServerURL and returns whether they changed
+	syncURL := func(apiServerURL string) bool {
+		t.Helper()
+		if err := indexer.Update(&configv1.Infrastructure{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+			Status:     configv1.InfrastructureStatus{APIServerURL: apiServerURL},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.syncExternalLoadBalancerHostnames(); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-c.externalLoadBalancer.hostnamesChanged:
+			return true
+		default:
+			return false
+		}
+	}
+
+	if !syncURL("https://api.example.com:6443") {
+		t.Fatalf("expected the initial hostnames to be set")
+	}
+	if syncURL("https://api.example.com:6443") {
+		t.Errorf("expected no change for the same URL")
+	}
+	if !syncURL("https://api.new.example.com:6443") || !sets.NewString(c.externalLoadBalancer.GetHostnames()...).Equal(sets.NewString("api.new.example.com")) {
+		t.Errorf("expected the hostnames to follow the URL change, got %v", c.externalLoadBalancer.GetHostnames())
+	}
+	if syncURL("not a url") || !sets.NewString(c.externalLoadBalancer.GetHostnames()...).Equal(sets.NewString("api.new.example.com")) {
+		t.Errorf("expected an invalid URL to keep the hostnames, got %v", c.externalLoadBalancer.GetHostnames())
+	}
+}
+
+func TestLoadBalancerServingRotation(t *testing.T) {
+	signer := newTestSigner(t, "loadbalancer-serving-signer")
+	caBundleCerts := signer.Config.Certs
+
+	// servingCertSecret returns a secret with a serving cert for the given SANs and the given hostnames annotation,
+	// set the way the cert rotation controller does
+	servingCertSecret := func(sans []string, annotatedHostnames []string) *corev1.Secret {
+		t.Helper()
+		servingCert, err := signer.MakeServerCertForDuration(sets.NewString(sans...), time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		certPEM, keyPEM, err := servingCert.GetPEMBytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "openshift-kube-apiserver",
+				Name:      "external-loadbalancer-serving-certkey",
+				Annotations: map[string]string{
+					certrotation.CertificateNotBeforeAnnotation: servingCert.Certs[0].NotBefore.Format(time.RFC3339),
+					certrotation.CertificateNotAfterAnnotation:  servingCert.Certs[0].NotAfter.Format(time.RFC3339),
+					certrotation.CertificateIssuer:              signer.Config.Certs[0].Subject.CommonName,
+					certrotation.CertificateHostnames:           strings.Join(annotatedHostnames, ","),
+				},
+			},
+			Data: map[string][]byte{corev1.TLSCertKey: certPEM, corev1.TLSPrivateKeyKey: keyPEM},
+		}
+	}
+
+	scenarios := []struct {
+		name           string
+		hostnames      []string
+		secret         *corev1.Secret
+		cachedSecret   *corev1.Secret
+		expectNewCerts bool
+	}{
+		{
+			name:      "SANs unchanged",
+			hostnames: []string{"api.example.com"},
+			secret:    servingCertSecret([]string{"api.example.com"}, []string{"api.example.com"}),
+		},
+		{
+			name:           "URL changed",
+			hostnames:      []string{"api.new.example.com"},
+			secret:         servingCertSecret([]string{"api.example.com"}, []string{"api.example.com"}),
+			expectNewCerts: true,
+		},
+		{
+			name:           "SANs drifted from the annotation",
+			hostnames:      []string{"api.example.com"},
+			secret:         servingCertSecret([]string{"api.old.example.com"}, []string{"api.example.com"}),
+			expectNewCerts: true,
+		},
+		{
+			name:         "cached secret older than the checked one",
+			hostnames:    []string{"api.example.com"},
+			secret:       servingCertSecret([]string{"api.example.com"}, []string{"api.example.com"}),
+			cachedSecret: servingCertSecret([]string{"api.old.example.com"}, []string{"api.old.example.com"}),
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			cached := scenario.cachedSecret
+			if cached == nil {
+				cached = scenario.secret
+			} else {
+				// the cached secret is valid from another time than the checked one
+				cached.Annotations[certrotation.CertificateNotAfterAnnotation] = time.Now().Add(30 * time.Minute).Format(time.RFC3339)
+			}
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			if err := indexer.Add(cached); err != nil {
+				t.Fatal(err)
+			}
+			hostnames := &DynamicServingRotation{hostnames: scenario.hostnames, hostnamesChanged: make(chan struct{}, 10)}
+			rotation := NewLoadBalancerServingRotation(hostnames, corev1listers.NewSecretLister(indexer).Secrets("openshift-kube-apiserver"), "external-loadbalancer-serving-certkey")
+
+			reason := rotation.NeedNewTargetCertKeyPair(scenario.secret.Annotations, signer, caBundleCerts, time.Hour, false)
+			if newCerts := len(reason) > 0; newCerts != scenario.expectNewCerts {
+				t.Errorf("expected a new certificate %v, got reason %q", scenario.expectNewCerts, reason)
+			}
+		})
+	}
+}
+
+func TestLoadBalancerServingRotationThroughCertRotationController(t *testing.T) {
+	signer := newTestSignerValidFrom(t, "loadbalancer-serving-signer", time.Now().Add(-24*time.Hour), 100*time.Hour)
+	validity, refresh := 10*time.Hour, 5*time.Hour
+	required := []string{"api.example.com"}
+
+	scenarios := []struct {
+		name       string
+		sans       []string
+		annotation string
+		expected   bool
+	}{
+		{
+			name:       "the certificate has the required SANs",
+			sans:       required,
+			annotation: "api.example.com",
+			expected:   false,
+		},
+		{
+			name:       "the SANs of the certificate drifted from its annotation",
+			sans:       []string{"stale.example.com"},
+			annotation: "api.example.com",
+			expected:   true,
+		},
+		{
+			name:       "the required hostnames changed",
+			sans:       []string{"stale.example.com"},
+			annotation: "stale.example.com",
+			expected:   true,
+		},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			sans := scenario.sans
+			issuing := &certrotation.ServingRotation{Hostnames: func() []string { return sans }}
+			target := newTestTargetSecret(t, signer, issuing, time.Now().Add(-time.Hour), validity)
+			target.Annotations[certrotation.CertificateHostnames] = scenario.annotation
+
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			if err := indexer.Add(target); err != nil {
+				t.Fatal(err)
+			}
+			hostnames := &DynamicServingRotation{hostnames: required, hostnamesChanged: make(chan struct{}, 10)}
+			rotation := NewLoadBalancerServingRotation(hostnames, corev1listers.NewSecretLister(indexer).Secrets(testTargetNamespace), testTargetName)
+			if rotated := syncTargetRotation(t, signer, nil, target, rotation, validity, refresh); rotated != scenario.expected {
+				t.Errorf("expected rotated %v, got %v", scenario.expected, rotated)
+			}
+		})
+	}
+}
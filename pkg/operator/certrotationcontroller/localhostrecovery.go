@@ -0,0 +1,205 @@
+package certrotationcontroller
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelistersv1 "k8s.io/client-go/listers/core/v1"
+	certutil "k8s.io/client-go/util/cert"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+const (
+	// LocalhostRecoveryServingCertificateDegradedConditionType is True when the localhost-recovery serving certificate
+	// cannot be kept valid, break-glass access through the localhost-recovery endpoint is then at risk.
+	LocalhostRecoveryServingCertificateDegradedConditionType = "LocalhostRecoveryServingCertificateDegraded"
+
+	SignerNotValidReason    = "SignerNotValid"
+	IssuingCertFailedReason = "IssuingCertificateFailed"
+
+	localhostRecoveryServingSignerName    = "localhost-recovery-serving-signer"
+	localhostRecoveryServingCertKeyName   = "localhost-recovery-serving-certkey"
+	localhostRecoveryServingHostname      = "localhost-recovery"
+	localhostRecoveryServingValidity      = 10 * 365 * defaultRotationDay
+	localhostRecoveryServingRefresh       = 8 * 365 * defaultRotationDay
+	localhostRecoveryMinRemainingValidity = 90 * defaultRotationDay
+)
+
+// localhostRecoveryServingController keeps the localhost-recovery serving certificate valid independently of the
+// LocalhostRecoveryServing cert rotation controller, which effectively never rotates it. The certificate is reissued
+// by the localhost-recovery-serving-signer when it is missing, does not parse, does not match its key, is not signed
+// by the current signer, does not cover localhost-recovery, or has less than localhostRecoveryMinRemainingValidity
+// left. The new certificate carries the annotations of the cert rotation controller, which then keeps it. A failure
+// to keep the certificate valid is reported in the LocalhostRecoveryServingCertificateDegraded condition.
+type localhostRecoveryServingController struct {
+	operatorClient     v1helpers.StaticPodOperatorClient
+	signerLister       corelistersv1.SecretNamespaceLister
+	targetSecretLister corelistersv1.SecretNamespaceLister
+	secretClient       corev1client.SecretsGetter
+	now                func() time.Time
+}
+
+func newLocalhostRecoveryServingController(
+	operatorClient v1helpers.StaticPodOperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	secretClient corev1client.SecretsGetter,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	signerInformer := kubeInformersForNamespaces.InformersFor(operatorclient.OperatorNamespace).Core().V1().Secrets()
+	targetInformer := kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets()
+	c := &localhostRecoveryServingController{
+		operatorClient:     operatorClient,
+		signerLister:       signerInformer.Lister().Secrets(operatorclient.OperatorNamespace),
+		targetSecretLister: targetInformer.Lister().Secrets(operatorclient.TargetNamespace),
+		secretClient:       secretClient,
+		now:                time.Now,
+	}
+
+	// the certificate comes close to its expiry even when nothing changes, resync to notice it
+	return factory.New().WithInformers(operatorClient.Informer(), signerInformer.Informer(), targetInformer.Informer()).
+		WithSync(c.sync).ResyncEvery(time.Hour).
+		ToController("CertRotationLocalhostRecoveryServingController", eventRecorder.WithComponentSuffix("cert-localhost-recovery-serving-controller"))
+}
+
+func (c *localhostRecoveryServingController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	operatorSpec, _, _, err := c.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+
+	condition := operatorv1.OperatorCondition{
+		Type:   LocalhostRecoveryServingCertificateDegradedConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: AsExpectedReason,
+	}
+	var syncErr error
+	signer, err := c.signer()
+	if err != nil {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = SignerNotValidReason
+		condition.Message = err.Error()
+	} else if err := c.ensureServingCertificate(ctx, syncCtx.Recorder(), signer); err != nil {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = IssuingCertFailedReason
+		condition.Message = fmt.Sprintf("Unable to issue the %s/%s serving certificate: %v", operatorclient.TargetNamespace, localhostRecoveryServingCertKeyName, err)
+		syncErr = err
+	}
+
+	if _, _, err := v1helpers.UpdateStaticPodStatus(c.operatorClient, v1helpers.UpdateStaticPodConditionFn(condition)); err != nil {
+		return err
+	}
+	return syncErr
+}
+
+// signer returns the localhost-recovery serving signer, it has to outlive localhostRecoveryMinRemainingValidity for
+// the certificates it issues to be of any use.
+func (c *localhostRecoveryServingController) signer() (*crypto.CA, error) {
+	secret, err := c.signerLister.Get(localhostRecoveryServingSignerName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the %s/%s signer: %v", operatorclient.OperatorNamespace, localhostRecoveryServingSignerName, err)
+	}
+	signer, err := crypto.GetCAFromBytes(secret.Data["tls.crt"], secret.Data["tls.key"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s/%s signer: %v", operatorclient.OperatorNamespace, localhostRecoveryServingSignerName, err)
+	}
+	if notAfter := signer.Config.Certs[0].NotAfter; notAfter.Sub(c.now()) < localhostRecoveryMinRemainingValidity {
+		return nil, fmt.Errorf("the %s/%s signer expires at %s, it has to be rotated to keep localhost-recovery access",
+			operatorclient.OperatorNamespace, localhostRecoveryServingSignerName, notAfter.UTC().Format(time.RFC3339))
+	}
+	return signer, nil
+}
+
+// ensureServingCertificate reissues the serving certificate when it is not valid.
+func (c *localhostRecoveryServingController) ensureServingCertificate(ctx context.Context, recorder events.Recorder, signer *crypto.CA) error {
+	existing, err := c.targetSecretLister.Get(localhostRecoveryServingCertKeyName)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	var reason string
+	if apierrors.IsNotFound(err) {
+		reason = "it is missing"
+	} else if reason = c.invalidReason(existing, signer); len(reason) == 0 {
+		return nil
+	}
+
+	// like the cert rotation controller, do not issue certificates outliving their signer
+	validity := localhostRecoveryServingValidity
+	if remaining := signer.Config.Certs[0].NotAfter.Sub(c.now()); remaining < validity {
+		validity = remaining
+	}
+	serving, err := signer.MakeServerCertForDuration(sets.NewString(localhostRecoveryServingHostname), validity)
+	if err != nil {
+		return err
+	}
+	certPEM, keyPEM, err := serving.GetPEMBytes()
+	if err != nil {
+		return err
+	}
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: localhostRecoveryServingCertKeyName}}
+	if existing != nil {
+		secret = existing.DeepCopy()
+	}
+	secret.Type = corev1.SecretTypeTLS
+	secret.Data = map[string][]byte{"tls.crt": certPEM, "tls.key": keyPEM}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[certrotation.CertificateNotAfterAnnotation] = serving.Certs[0].NotAfter.Format(time.RFC3339)
+	secret.Annotations[certrotation.CertificateNotBeforeAnnotation] = serving.Certs[0].NotBefore.Format(time.RFC3339)
+	secret.Annotations[certrotation.CertificateIssuer] = serving.Certs[0].Issuer.CommonName
+	secret.Annotations[certrotation.CertificateHostnames] = localhostRecoveryServingHostname
+	certrotation.LabelAsManagedSecret(secret, certrotation.CertificateTypeTarget)
+
+	if existing == nil {
+		_, err = c.secretClient.Secrets(operatorclient.TargetNamespace).Create(ctx, secret, metav1.CreateOptions{})
+	} else {
+		_, err = c.secretClient.Secrets(operatorclient.TargetNamespace).Update(ctx, secret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return err
+	}
+	recorder.Eventf("LocalhostRecoveryServingCertificateIssued", "%q in %q was reissued because %s, it is valid until %s",
+		localhostRecoveryServingCertKeyName, operatorclient.TargetNamespace, reason, serving.Certs[0].NotAfter.UTC().Format(time.RFC3339))
+	return nil
+}
+
+// invalidReason returns why the serving certificate in the secret has to be reissued, empty if it is valid.
+func (c *localhostRecoveryServingController) invalidReason(secret *corev1.Secret, signer *crypto.CA) string {
+	if _, err := tls.X509KeyPair(secret.Data["tls.crt"], secret.Data["tls.key"]); err != nil {
+		return fmt.Sprintf("its cert/key pair is invalid: %v", err)
+	}
+	certs, err := certutil.ParseCertsPEM(secret.Data["tls.crt"])
+	if err != nil {
+		return fmt.Sprintf("its certificate is invalid: %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(signer.Config.Certs[0])
+	if _, err := certs[0].Verify(x509.VerifyOptions{DNSName: localhostRecoveryServingHostname, Roots: roots, CurrentTime: c.now()}); err != nil {
+		return fmt.Sprintf("it does not verify against the current signer: %v", err)
+	}
+	if certs[0].NotAfter.Sub(c.now()) < localhostRecoveryMinRemainingValidity {
+		return fmt.Sprintf("it expires at %s", certs[0].NotAfter.UTC().Format(time.RFC3339))
+	}
+	return ""
+}
@@ -0,0 +1,179 @@
+package certrotationcontroller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes/fake"
+	corelistersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	certutil "k8s.io/client-go/util/cert"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+func TestLocalhostRecoveryServingController(t *testing.T) {
+	newSigner := func(lifetime time.Duration) (*crypto.CA, *corev1.Secret) {
+		config, err := crypto.MakeSelfSignedCAConfigForDuration("localhost-recovery-serving-signer", lifetime)
+		if err != nil {
+			t.Fatal(err)
+		}
+		certPEM, keyPEM, err := config.GetPEMBytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &crypto.CA{Config: config, SerialGenerator: &crypto.RandomSerialGenerator{}}, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-kube-apiserver-operator", Name: "localhost-recovery-serving-signer"},
+			Data:       map[string][]byte{"tls.crt": certPEM, "tls.key": keyPEM},
+		}
+	}
+	signer, signerSecret := newSigner(10 * 365 * 24 * time.Hour)
+	otherSigner, _ := newSigner(10 * 365 * 24 * time.Hour)
+	_, expiringSignerSecret := newSigner(30 * 24 * time.Hour)
+	servingSecret := func(ca *crypto.CA, hostname string, lifetime time.Duration) *corev1.Secret {
+		cert, err := ca.MakeServerCertForDuration(sets.NewString(hostname), lifetime)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return newTargetSecret(t, "openshift-kube-apiserver", "localhost-recovery-serving-certkey", cert)
+	}
+
+	testCases := []struct {
+		name           string
+		signer         *corev1.Secret
+		serving        *corev1.Secret
+		expectIssued   bool
+		expectedStatus operatorv1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name:           "missing",
+			signer:         signerSecret,
+			expectIssued:   true,
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: AsExpectedReason,
+		},
+		{
+			name:           "near expiry",
+			signer:         signerSecret,
+			serving:        servingSecret(signer, "localhost-recovery", 30*24*time.Hour),
+			expectIssued:   true,
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: AsExpectedReason,
+		},
+		{
+			name:           "healthy",
+			signer:         signerSecret,
+			serving:        servingSecret(signer, "localhost-recovery", 365*24*time.Hour),
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: AsExpectedReason,
+		},
+		{
+			name:           "signed by a previous signer",
+			signer:         signerSecret,
+			serving:        servingSecret(otherSigner, "localhost-recovery", 365*24*time.Hour),
+			expectIssued:   true,
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: AsExpectedReason,
+		},
+		{
+			name:           "wrong hostname",
+			signer:         signerSecret,
+			serving:        servingSecret(signer, "localhost", 365*24*time.Hour),
+			expectIssued:   true,
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: AsExpectedReason,
+		},
+		{
+			name:           "missing signer",
+			expectedStatus: operatorv1.ConditionTrue,
+			expectedReason: SignerNotValidReason,
+		},
+		{
+			name:           "expiring signer",
+			signer:         expiringSignerSecret,
+			expectedStatus: operatorv1.ConditionTrue,
+			expectedReason: SignerNotValidReason,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			signerIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			targetIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			kubeClient := fake.NewSimpleClientset()
+			if tc.signer != nil {
+				if err := signerIndexer.Add(tc.signer); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tc.serving != nil {
+				if err := targetIndexer.Add(tc.serving); err != nil {
+					t.Fatal(err)
+				}
+				if err := kubeClient.Tracker().Add(tc.serving); err != nil {
+					t.Fatal(err)
+				}
+			}
+			operatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+				&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+				&operatorv1.StaticPodOperatorStatus{},
+				nil,
+				nil,
+			)
+			c := &localhostRecoveryServingController{
+				operatorClient:     operatorClient,
+				signerLister:       corelistersv1.NewSecretLister(signerIndexer).Secrets("openshift-kube-apiserver-operator"),
+				targetSecretLister: corelistersv1.NewSecretLister(targetIndexer).Secrets("openshift-kube-apiserver"),
+				secretClient:       kubeClient.CoreV1(),
+				now:                time.Now,
+			}
+			kubeClient.ClearActions()
+			if err := c.sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+				t.Fatal(err)
+			}
+
+			_, status, _, err := operatorClient.GetStaticPodOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			condition := v1helpers.FindOperatorCondition(status.Conditions, LocalhostRecoveryServingCertificateDegradedConditionType)
+			if condition == nil || condition.Status != tc.expectedStatus || condition.Reason != tc.expectedReason {
+				t.Fatalf("expected condition %s with reason %s, got %#v", tc.expectedStatus, tc.expectedReason, condition)
+			}
+
+			if issued := len(kubeClient.Actions()) > 0; issued != tc.expectIssued {
+				t.Fatalf("expected issued %v, got actions %v", tc.expectIssued, kubeClient.Actions())
+			}
+			if !tc.expectIssued {
+				return
+			}
+			secret, err := kubeClient.CoreV1().Secrets("openshift-kube-apiserver").Get(context.TODO(), "localhost-recovery-serving-certkey", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reason := c.invalidReason(secret, signer); len(reason) > 0 {
+				t.Fatalf("expected a valid certificate, got: %s", reason)
+			}
+			certs, err := certutil.ParseCertsPEM(secret.Data["tls.crt"])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if notAfter := secret.Annotations[certrotation.CertificateNotAfterAnnotation]; notAfter != certs[0].NotAfter.Format(time.RFC3339) {
+				t.Errorf("expected the %s annotation to match the certificate, got %q", certrotation.CertificateNotAfterAnnotation, notAfter)
+			}
+			if secret.Annotations[certrotation.CertificateHostnames] != "localhost-recovery" {
+				t.Errorf("expected the localhost-recovery hostname annotation, got %q", secret.Annotations[certrotation.CertificateHostnames])
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package certrotationcontroller
+
+import (
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+var (
+	registerMetrics sync.Once
+
+	certExpirySecondsGauge = metrics.NewGaugeVec(&metrics.GaugeOpts{
+		Name: "kube_apiserver_operator_cert_expiry_seconds",
+		Help: "Report the number of seconds until the certificate stored in a secret managed by the cert rotation controllers expires",
+	}, []string{"name", "namespace"})
+)
+
+// RegisterMetrics registers the cert rotation metrics with the legacy registry.
+func RegisterMetrics() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(certExpirySecondsGauge)
+	})
+}
@@ -0,0 +1,78 @@
+package certrotationcontroller
+
+import (
+	"crypto/x509"
+	"time"
+
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+)
+
+// staggeredTargetCerts are the short lived target certificates, all created at installation time with the same
+// validity and refresh. Without staggering they keep being rotated in the same narrow window, and so do the
+// kube-apiserver rollouts they cause. Their order sets their phase in the refresh period, new certificates are
+// appended.
+var staggeredTargetCerts = []string{
+	"aggregator-client",
+	"kubelet-client",
+	"localhost-serving-cert-certkey",
+	"service-network-serving-certkey",
+	"external-loadbalancer-serving-certkey",
+	"internal-loadbalancer-serving-certkey",
+	"kube-controller-manager-client-cert-key",
+	"kube-scheduler-client-cert-key",
+	"control-plane-node-admin-client-cert-key",
+	"check-endpoints-client-cert-key",
+}
+
+// staggeredRotation refreshes the certificate of one of the staggeredTargetCerts at the refresh times of its phase:
+// the certificate in slot i of n is refreshed at i/n of the refresh period, counted from the Unix epoch, plus a whole
+// number of periods. The period is unchanged, only the first refresh moves to the phase: it happens at the first
+// phase time more than a tenth of the refresh after the certificate was issued, and every later certificate is
+// issued at a phase time, so it is refreshed one period later. The schedule only depends on the certificate name.
+type staggeredRotation struct {
+	certrotation.TargetCertCreator
+	slot int
+}
+
+// staggeredRecheckingRotation is a staggeredRotation of a certificate creator asking for rechecks.
+type staggeredRecheckingRotation struct {
+	*staggeredRotation
+	certrotation.TargetCertRechecker
+}
+
+// newStaggeredRotation returns the certCreator with the refresh times of the phase of the named certificate, or the
+// certCreator itself for any other certificate.
+func newStaggeredRotation(name string, certCreator certrotation.TargetCertCreator) certrotation.TargetCertCreator {
+	for i, staggered := range staggeredTargetCerts {
+		if staggered != name {
+			continue
+		}
+		rotation := &staggeredRotation{TargetCertCreator: certCreator, slot: i}
+		if rechecker, ok := certCreator.(certrotation.TargetCertRechecker); ok {
+			return &staggeredRecheckingRotation{staggeredRotation: rotation, TargetCertRechecker: rechecker}
+		}
+		return rotation
+	}
+	return certCreator
+}
+
+func (r *staggeredRotation) NeedNewTargetCertKeyPair(annotations map[string]string, signer *crypto.CA, caBundleCerts []*x509.Certificate, refresh time.Duration, refreshOnlyWhenExpired bool) string {
+	if notBefore, err := time.Parse(time.RFC3339, annotations[certrotation.CertificateNotBeforeAnnotation]); err == nil {
+		refresh = staggeredRefresh(notBefore, refresh, r.slot)
+	}
+	return r.TargetCertCreator.NeedNewTargetCertKeyPair(annotations, signer, caBundleCerts, refresh, refreshOnlyWhenExpired)
+}
+
+// staggeredRefresh returns the refresh of a certificate issued at notBefore, up to its first phase time of the given
+// slot in (notBefore + refresh/10, notBefore + refresh/10 + refresh]. A certificate issued at a phase time, which is
+// backdated by a second, is refreshed at the next one.
+func staggeredRefresh(notBefore time.Time, refresh time.Duration, slot int) time.Duration {
+	if refresh <= 0 {
+		return refresh
+	}
+	phase := refresh * time.Duration(slot) / time.Duration(len(staggeredTargetCerts))
+	start := notBefore.Add(refresh / 10)
+	sincePhase := time.Duration(start.UnixNano()) - phase
+	return start.Add(refresh - sincePhase%refresh).Sub(notBefore)
+}
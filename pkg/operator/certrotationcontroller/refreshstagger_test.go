@@ -0,0 +1,136 @@
+package certrotationcontroller
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+)
+
+func TestStaggeredRefresh(t *testing.T) {
+	for _, rotationDay := range []time.Duration{defaultRotationDay, defaultRotationDay / 60} {
+		validity, refresh := 30*rotationDay, 15*rotationDay
+		// the certificates are all issued at once, at installation time
+		notBefore := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+		phases := map[time.Duration]string{}
+		for slot, name := range staggeredTargetCerts {
+			first := staggeredRefresh(notBefore, refresh, slot)
+			if again := staggeredRefresh(notBefore, refresh, slot); again != first {
+				t.Fatalf("%s: expected a deterministic refresh, got %v then %v", name, first, again)
+			}
+			if first <= refresh/10 || first > refresh/10+refresh {
+				t.Errorf("%s: expected the first refresh after %v and at most %v, got %v", name, refresh/10, refresh/10+refresh, first)
+			}
+			if first > validity*4/5 {
+				t.Errorf("%s: the first refresh %v is past 80%% of the validity", name, first)
+			}
+
+			// the phase is the refresh time modulo the period, the certificates are spread evenly over it
+			refreshTime := notBefore.Add(first)
+			phase := time.Duration(refreshTime.UnixNano()) % refresh
+			if expected := refresh * time.Duration(slot) / time.Duration(len(staggeredTargetCerts)); phase != expected {
+				t.Errorf("%s: expected the phase %v, got %v", name, expected, phase)
+			}
+			if other, ok := phases[phase]; ok {
+				t.Errorf("%s: refreshed in the same phase as %s", name, other)
+			}
+			phases[phase] = name
+
+			// the next certificates are backdated by a second and keep the period
+			for i := 0; i < 3; i++ {
+				issued := refreshTime.Add(-time.Second)
+				next := staggeredRefresh(issued, refresh, slot)
+				if next != refresh+time.Second {
+					t.Fatalf("%s: expected the period %v to be kept, got a refresh of %v after the one at %v", name, refresh, next-time.Second, refreshTime)
+				}
+				refreshTime = issued.Add(next)
+			}
+		}
+	}
+}
+
+type fakeCertCreator struct {
+	certrotation.TargetCertCreator
+	refresh time.Duration
+}
+
+func (f *fakeCertCreator) NeedNewTargetCertKeyPair(_ map[string]string, _ *crypto.CA, _ []*x509.Certificate, refresh time.Duration, _ bool) string {
+	f.refresh = refresh
+	return ""
+}
+
+func TestNewStaggeredRotation(t *testing.T) {
+	refresh := 15 * defaultRotationDay
+	notBefore := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	annotations := map[string]string{certrotation.CertificateNotBeforeAnnotation: notBefore.Format(time.RFC3339)}
+
+	creator := &fakeCertCreator{}
+	newStaggeredRotation("kubelet-client", creator).NeedNewTargetCertKeyPair(annotations, nil, nil, refresh, false)
+	if expected := staggeredRefresh(notBefore, refresh, 1); creator.refresh != expected {
+		t.Errorf("expected the staggered refresh %v, got %v", expected, creator.refresh)
+	}
+
+	creator = &fakeCertCreator{}
+	newStaggeredRotation("kubelet-client", creator).NeedNewTargetCertKeyPair(map[string]string{}, nil, nil, refresh, false)
+	if creator.refresh != refresh {
+		t.Errorf("expected the refresh of a certificate without validity to be unchanged, got %v", creator.refresh)
+	}
+
+	if unlisted := newStaggeredRotation("localhost-recovery-serving-certkey", creator); unlisted != certrotation.TargetCertCreator(creator) {
+		t.Errorf("expected the creator of an unlisted certificate to be unchanged, got %#v", unlisted)
+	}
+
+	serving := newStaggeredRotation("localhost-serving-cert-certkey", &certrotation.ServingRotation{HostnamesChanged: make(chan struct{})})
+	if _, ok := serving.(certrotation.TargetCertRechecker); !ok {
+		t.Errorf("expected the staggered serving rotation to keep asking for rechecks")
+	}
+	if _, ok := newStaggeredRotation("kubelet-client", &certrotation.ClientRotation{}).(certrotation.TargetCertRechecker); ok {
+		t.Errorf("expected the staggered client rotation not to ask for rechecks")
+	}
+}
+
+func TestStaggeredRotationThroughCertRotationController(t *testing.T) {
+	signer := newTestSignerValidFrom(t, "test-signer", time.Now().Add(-24*time.Hour), 100*time.Hour)
+	creator := &certrotation.ClientRotation{UserInfo: &user.DefaultInfo{Name: "test"}}
+
+	now := time.Now()
+	refresh, slot, issued := pastRefreshBeforePhaseTime(t, now)
+	validity := 2 * refresh
+	if staggered := staggeredRefresh(issued, refresh, slot); now.Sub(issued) >= staggered {
+		t.Fatalf("expected the certificate issued %v ago not to be at its staggered refresh %v yet", now.Sub(issued), staggered)
+	}
+
+	rotation := &staggeredRotation{TargetCertCreator: creator, slot: slot}
+	target := newTestTargetSecret(t, signer, rotation, issued, validity)
+	if syncTargetRotation(t, signer, nil, target, rotation, validity, refresh) {
+		t.Errorf("expected the certificate past its refresh to be kept until the first phase time of its slot")
+	}
+
+	target = newTestTargetSecret(t, signer, rotation, now.Add(-refresh-refresh/10-time.Minute), validity)
+	if !syncTargetRotation(t, signer, nil, target, rotation, validity, refresh) {
+		t.Errorf("expected the certificate past the first phase time of its slot to be rotated")
+	}
+}
+
+// pastRefreshBeforePhaseTime returns a refresh, a slot and the time a certificate of that slot was issued at, such
+// that the certificate is past the refresh at now, but not yet at the first phase time of the slot.
+func pastRefreshBeforePhaseTime(t *testing.T, now time.Time) (time.Duration, int, time.Time) {
+	t.Helper()
+	for refresh := 10 * time.Hour; refresh < 20*time.Hour; refresh += 10 * time.Minute {
+		for slot := range staggeredTargetCerts {
+			phase := refresh * time.Duration(slot) / time.Duration(len(staggeredTargetCerts))
+			nextPhaseTime := now.Add(refresh - (time.Duration(now.UnixNano())-phase)%refresh)
+			issued := nextPhaseTime.Add(-refresh - refresh/10 + time.Minute).Truncate(time.Second)
+			if now.Sub(issued) > refresh+time.Minute && nextPhaseTime.After(now.Add(time.Minute)) {
+				return refresh, slot, issued
+			}
+		}
+	}
+	t.Fatal("no certificate between its refresh and its first phase time")
+	return 0, 0, time.Time{}
+}
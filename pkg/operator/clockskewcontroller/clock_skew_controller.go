@@ -0,0 +1,152 @@
+package clockskewcontroller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+)
+
+const (
+	ClockSkewDegradedConditionType = "ControlPlaneClockSkewDegraded"
+
+	ClockSkewDetectedReason = "ClockSkewDetected"
+	AsExpectedReason        = "AsExpected"
+
+	// maxClockSkew is the offset from the other control plane nodes past which a node is reported. Both timestamps an
+	// offset is computed from have a second precision, and the kubelet request takes some time to be served.
+	maxClockSkew = 5 * time.Second
+)
+
+var masterNodeSelector = labels.SelectorFromSet(labels.Set{"node-role.kubernetes.io/master": ""})
+
+// ClockSkewController sets ControlPlaneClockSkewDegraded=True when the clock of a control plane node drifts from the
+// others. A kubelet status update carries the heartbeat time of the node clock, and the kube-apiserver serving it
+// records the time of its own clock in the managed fields of the kubelet. Their difference is the offset of the node
+// clock, and a node whose offset differs from the median offset of the control plane nodes by more than maxClockSkew
+// is named in the condition. Certificates and tokens issued on such a node, or verified there, are rejected as not
+// yet or no longer valid elsewhere.
+type ClockSkewController struct {
+	operatorClient v1helpers.OperatorClient
+	nodeLister     corev1listers.NodeLister
+}
+
+func NewClockSkewController(
+	operatorClient v1helpers.OperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	recorder events.Recorder,
+) factory.Controller {
+	nodeInformer := kubeInformersForNamespaces.InformersFor("").Core().V1().Nodes()
+	c := &ClockSkewController{
+		operatorClient: operatorClient,
+		nodeLister:     nodeInformer.Lister(),
+	}
+	return factory.New().
+		WithSync(c.sync).
+		WithInformers(operatorClient.Informer(), nodeInformer.Informer()).
+		ToController("ClockSkewController", recorder.WithComponentSuffix("clock-skew-controller"))
+}
+
+// nodeClockOffset is how far the clock of a node is ahead of the kube-apiserver, negative if it is behind.
+type nodeClockOffset struct {
+	node   string
+	offset time.Duration
+}
+
+func (c *ClockSkewController) sync(_ context.Context, _ factory.SyncContext) error {
+	operatorSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+
+	nodes, err := c.nodeLister.List(masterNodeSelector)
+	if err != nil {
+		return err
+	}
+
+	condition := operatorv1.OperatorCondition{
+		Type:   ClockSkewDegradedConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: AsExpectedReason,
+	}
+	if skewed := skewedNodes(nodes); len(skewed) > 0 {
+		var descriptions []string
+		for _, n := range skewed {
+			offset, direction := n.offset, "ahead of"
+			if offset < 0 {
+				offset, direction = -offset, "behind"
+			}
+			descriptions = append(descriptions, fmt.Sprintf("node %s is %s %s the other control plane nodes", n.node, offset, direction))
+		}
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = ClockSkewDetectedReason
+		condition.Message = fmt.Sprintf("Clock skew detected: %s", strings.Join(descriptions, ", "))
+	}
+
+	_, _, err = v1helpers.UpdateStatus(c.operatorClient, v1helpers.UpdateConditionFn(condition))
+	return err
+}
+
+// skewedNodes returns the nodes whose clock offset differs from the median offset by more than maxClockSkew, sorted
+// by name, with their offset to the median. Nodes without a kubelet status update to compute an offset from are
+// ignored.
+func skewedNodes(nodes []*corev1.Node) []nodeClockOffset {
+	var offsets []nodeClockOffset
+	for _, node := range nodes {
+		if offset, ok := clockOffset(node); ok {
+			offsets = append(offsets, nodeClockOffset{node: node.Name, offset: offset})
+		}
+	}
+	if len(offsets) < 2 {
+		return nil
+	}
+
+	sorted := make([]time.Duration, 0, len(offsets))
+	for _, o := range offsets {
+		sorted = append(sorted, o.offset)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	median := sorted[len(sorted)/2]
+
+	var skewed []nodeClockOffset
+	for _, o := range offsets {
+		if relative := o.offset - median; relative > maxClockSkew || relative < -maxClockSkew {
+			skewed = append(skewed, nodeClockOffset{node: o.node, offset: relative})
+		}
+	}
+	sort.Slice(skewed, func(i, j int) bool { return skewed[i].node < skewed[j].node })
+	return skewed
+}
+
+// clockOffset returns the heartbeat time of the Ready condition, set by the kubelet, less the time the kube-apiserver
+// recorded for the last status update of the kubelet.
+func clockOffset(node *corev1.Node) (time.Duration, bool) {
+	var heartbeat time.Time
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			heartbeat = condition.LastHeartbeatTime.Time
+		}
+	}
+	if heartbeat.IsZero() {
+		return 0, false
+	}
+	for _, entry := range node.ManagedFields {
+		if entry.Manager == "kubelet" && entry.Subresource == "status" && entry.Time != nil {
+			return heartbeat.Sub(entry.Time.Time), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,129 @@
+package clockskewcontroller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// masterNode returns a control plane node whose last kubelet status update was served at the given kube-apiserver
+// time while the node clock read served+offset.
+func masterNode(name string, served time.Time, offset time.Duration) *corev1.Node {
+	servedTime := metav1.NewTime(served)
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"node-role.kubernetes.io/master": ""},
+			ManagedFields: []metav1.ManagedFieldsEntry{
+				{Manager: "kubelet", Operation: metav1.ManagedFieldsOperationUpdate, Time: &servedTime},
+				{Manager: "kubelet", Operation: metav1.ManagedFieldsOperationUpdate, Subresource: "status", Time: &servedTime},
+			},
+		},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue, LastHeartbeatTime: metav1.NewTime(served.Add(offset))},
+			},
+		},
+	}
+}
+
+func TestClockSkewController(t *testing.T) {
+	now := time.Now()
+	scenarios := []struct {
+		name            string
+		nodes           []*corev1.Node
+		expectedStatus  operatorv1.ConditionStatus
+		expectedMessage string
+	}{
+		{
+			name: "in sync",
+			nodes: []*corev1.Node{
+				masterNode("master-0", now, 0),
+				masterNode("master-1", now.Add(-time.Minute), time.Second),
+				masterNode("master-2", now.Add(-2*time.Minute), -time.Second),
+			},
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name: "one node ahead",
+			nodes: []*corev1.Node{
+				masterNode("master-0", now, 0),
+				masterNode("master-1", now, 42*time.Second),
+				masterNode("master-2", now, time.Second),
+			},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedMessage: "node master-1 is 41s ahead of the other control plane nodes",
+		},
+		{
+			name: "one node behind",
+			nodes: []*corev1.Node{
+				masterNode("master-0", now, 0),
+				masterNode("master-1", now, 0),
+				masterNode("master-2", now, -time.Minute),
+			},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedMessage: "node master-2 is 1m0s behind the other control plane nodes",
+		},
+		{
+			name: "the kube-apiserver handling the updates is skewed, not the nodes",
+			nodes: []*corev1.Node{
+				masterNode("master-0", now, 30*time.Second),
+				masterNode("master-1", now, 31*time.Second),
+				masterNode("master-2", now, 30*time.Second),
+			},
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name: "a single node has nothing to compare to",
+			nodes: []*corev1.Node{
+				masterNode("master-0", now, time.Hour),
+			},
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, node := range scenario.nodes {
+				if err := indexer.Add(node); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// a worker with a skewed clock is not a control plane node
+			worker := masterNode("worker-0", now, time.Hour)
+			worker.Labels = map[string]string{"node-role.kubernetes.io/worker": ""}
+			if err := indexer.Add(worker); err != nil {
+				t.Fatal(err)
+			}
+
+			operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+			c := &ClockSkewController{
+				operatorClient: operatorClient,
+				nodeLister:     corev1listers.NewNodeLister(indexer),
+			}
+			if err := c.sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+				t.Fatal(err)
+			}
+
+			_, status, _, err := operatorClient.GetOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			cond := v1helpers.FindOperatorCondition(status.Conditions, ClockSkewDegradedConditionType)
+			if cond == nil || cond.Status != scenario.expectedStatus || !strings.Contains(cond.Message, scenario.expectedMessage) {
+				t.Errorf("expected %s with message %q, got %#v", scenario.expectedStatus, scenario.expectedMessage, cond)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package cloudprovidercontroller
+
+import (
+	"context"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configinformers "github.com/openshift/client-go/config/informers/externalversions"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation/cloudprovider"
+)
+
+const (
+	CloudProviderObservedConditionType = "CloudProviderObserved"
+
+	CloudProviderExternalReason = "External"
+	CloudProviderInTreeReason   = "InTree"
+	NoCloudProviderReason       = "NoCloudProvider"
+)
+
+// CloudProviderController reports the cloud provider mode the kube-apiserver is configured with
+// in the CloudProviderObserved condition, so that it is visible without reading the observed config.
+type CloudProviderController struct {
+	operatorClient       v1helpers.OperatorClient
+	infrastructureLister configlistersv1.InfrastructureLister
+	featureGateLister    configlistersv1.FeatureGateLister
+}
+
+func NewCloudProviderController(
+	operatorClient v1helpers.OperatorClient,
+	configInformer configinformers.SharedInformerFactory,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &CloudProviderController{
+		operatorClient:       operatorClient,
+		infrastructureLister: configInformer.Config().V1().Infrastructures().Lister(),
+		featureGateLister:    configInformer.Config().V1().FeatureGates().Lister(),
+	}
+
+	return factory.New().WithInformers(
+		operatorClient.Informer(),
+		configInformer.Config().V1().Infrastructures().Informer(),
+		configInformer.Config().V1().FeatureGates().Informer(),
+	).WithSync(c.sync).ToController("CloudProviderController", eventRecorder.WithComponentSuffix("cloud-provider-controller"))
+}
+
+// FeatureGateLister implements cloudprovider.FeatureGateLister.
+func (c *CloudProviderController) FeatureGateLister() configlistersv1.FeatureGateLister {
+	return c.featureGateLister
+}
+
+func (c *CloudProviderController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	infrastructure, err := c.infrastructureLister.Get("cluster")
+	if err != nil {
+		return err
+	}
+
+	external, err := cloudprovider.IsCloudProviderExternal(c, infrastructure.Status.PlatformStatus)
+	if err != nil {
+		return err
+	}
+
+	cond := newCloudProviderObservedCondition(external, cloudprovider.GetPlatformName(infrastructure.Status.Platform, syncCtx.Recorder()))
+	if _, _, updateError := v1helpers.UpdateStatus(c.operatorClient, v1helpers.UpdateConditionFn(cond)); updateError != nil {
+		return updateError
+	}
+
+	return nil
+}
+
+func newCloudProviderObservedCondition(external bool, cloudProvider string) operatorv1.OperatorCondition {
+	switch {
+	case external:
+		return operatorv1.OperatorCondition{
+			Type:    CloudProviderObservedConditionType,
+			Status:  operatorv1.ConditionTrue,
+			Reason:  CloudProviderExternalReason,
+			Message: "external",
+		}
+	case len(cloudProvider) > 0:
+		return operatorv1.OperatorCondition{
+			Type:    CloudProviderObservedConditionType,
+			Status:  operatorv1.ConditionTrue,
+			Reason:  CloudProviderInTreeReason,
+			Message: cloudProvider,
+		}
+	default:
+		return operatorv1.OperatorCondition{
+			Type:   CloudProviderObservedConditionType,
+			Status: operatorv1.ConditionTrue,
+			Reason: NoCloudProviderReason,
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package cloudprovidercontroller
+
+import (
+	"context"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestCloudProviderControllerSync(t *testing.T) {
+	externalCloudProviderGate := &configv1.FeatureGate{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: configv1.FeatureGateSpec{
+			FeatureGateSelection: configv1.FeatureGateSelection{
+				FeatureSet: configv1.CustomNoUpgrade,
+				CustomNoUpgrade: &configv1.CustomFeatureGates{
+					Enabled: []string{"ExternalCloudProvider"},
+				},
+			},
+		},
+	}
+
+	infraIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	featureGateIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+	c := &CloudProviderController{
+		operatorClient:       operatorClient,
+		infrastructureLister: configlistersv1.NewInfrastructureLister(infraIndexer),
+		featureGateLister:    configlistersv1.NewFeatureGateLister(featureGateIndexer),
+	}
+
+	// the steps mutate the same listers to verify the condition follows platform and feature gate changes
+	steps := []struct {
+		name            string
+		platformType    configv1.PlatformType
+		featureGate     *configv1.FeatureGate
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			name:            "AWS in-tree",
+			platformType:    configv1.AWSPlatformType,
+			expectedReason:  CloudProviderInTreeReason,
+			expectedMessage: "aws",
+		},
+		{
+			name:            "AWS with external cloud provider feature gate",
+			platformType:    configv1.AWSPlatformType,
+			featureGate:     externalCloudProviderGate,
+			expectedReason:  CloudProviderExternalReason,
+			expectedMessage: "external",
+		},
+		{
+			name:           "BareMetal",
+			platformType:   configv1.BareMetalPlatformType,
+			featureGate:    externalCloudProviderGate,
+			expectedReason: NoCloudProviderReason,
+		},
+		{
+			name:            "IBMCloud",
+			platformType:    configv1.IBMCloudPlatformType,
+			expectedReason:  CloudProviderExternalReason,
+			expectedMessage: "external",
+		},
+	}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			if err := infraIndexer.Update(&configv1.Infrastructure{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Status: configv1.InfrastructureStatus{
+					Platform:       step.platformType,
+					PlatformStatus: &configv1.PlatformStatus{Type: step.platformType},
+				},
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if err := featureGateIndexer.Replace(nil, ""); err != nil {
+				t.Fatal(err)
+			}
+			if step.featureGate != nil {
+				if err := featureGateIndexer.Add(step.featureGate); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := c.sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder(t.Name()))); err != nil {
+				t.Fatalf("sync() unexpected err: %v", err)
+			}
+			_, status, _, err := operatorClient.GetOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			condition := v1helpers.FindOperatorCondition(status.Conditions, CloudProviderObservedConditionType)
+			if condition == nil {
+				t.Fatalf("expected %s condition", CloudProviderObservedConditionType)
+			}
+			if condition.Reason != step.expectedReason {
+				t.Errorf("condition reason: expected %s, actual %s", step.expectedReason, condition.Reason)
+			}
+			if condition.Message != step.expectedMessage {
+				t.Errorf("condition message: expected %q, actual %q", step.expectedMessage, condition.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package admission
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ghodss/yaml"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+// AdmissionPluginConfigsConfigMapName is the configmap in openshift-config holding the configuration of admission
+// plugins, one key per plugin name.
+const AdmissionPluginConfigsConfigMapName = "kube-apiserver-admission-plugin-configs"
+
+var pluginConfigPath = []string{"admission", "pluginConfig"}
+
+// admissionPluginConfigKinds holds the configuration kind of the admission plugins that can be configured.
+var admissionPluginConfigKinds = map[string]schema.GroupVersionKind{
+	"EventRateLimit":           {Group: "eventratelimit.admission.k8s.io", Version: "v1alpha1", Kind: "Configuration"},
+	"PodTolerationRestriction": {Group: "podtolerationrestriction.admission.k8s.io", Version: "v1alpha1", Kind: "Configuration"},
+}
+
+// ObserveAdmissionPluginConfigs adds the plugin configurations of the AdmissionPluginConfigsConfigMapName configmap to
+// the admission pluginConfig. The kube-apiserver assembles its admission control config file from the pluginConfig,
+// next to the plugin configurations of the default config. A plugin configuration that cannot be parsed, that is not
+// of the expected kind or configures an unknown plugin is rejected with a warning, the others are applied. The plugins
+// still have to be enabled.
+func ObserveAdmissionPluginConfigs(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	defer func() {
+		var pluginConfigPaths [][]string
+		for plugin := range admissionPluginConfigKinds {
+			pluginConfigPaths = append(pluginConfigPaths, append(append([]string{}, pluginConfigPath...), plugin))
+		}
+		ret = configobserver.Pruned(ret, pluginConfigPaths...)
+	}()
+
+	listers := genericListers.(configobservation.Listers)
+	configMap, err := listers.ConfigMapLister().ConfigMaps(operatorclient.GlobalUserSpecifiedConfigNamespace).Get(AdmissionPluginConfigsConfigMapName)
+	if apierrors.IsNotFound(err) {
+		return map[string]interface{}{}, errs
+	}
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+
+	plugins := make([]string, 0, len(configMap.Data))
+	for plugin := range configMap.Data {
+		plugins = append(plugins, plugin)
+	}
+	sort.Strings(plugins)
+
+	pluginConfig := map[string]interface{}{}
+	for _, plugin := range plugins {
+		configuration, err := parseAdmissionPluginConfig(plugin, configMap.Data[plugin])
+		if err != nil {
+			recorder.Warningf("ObserveAdmissionPluginConfigs", "Rejecting the %s admission plugin configuration of the %s/%s configmap: %v", plugin, operatorclient.GlobalUserSpecifiedConfigNamespace, AdmissionPluginConfigsConfigMapName, err)
+			continue
+		}
+		pluginConfig[plugin] = map[string]interface{}{"configuration": configuration}
+	}
+	if len(pluginConfig) == 0 {
+		return map[string]interface{}{}, errs
+	}
+
+	observedConfig := map[string]interface{}{}
+	if err := unstructured.SetNestedField(observedConfig, pluginConfig, pluginConfigPath...); err != nil {
+		return existingConfig, append(errs, err)
+	}
+	return observedConfig, errs
+}
+
+// parseAdmissionPluginConfig parses the configuration of the plugin and checks it is of the kind the plugin expects.
+func parseAdmissionPluginConfig(plugin, data string) (map[string]interface{}, error) {
+	expectedKind, ok := admissionPluginConfigKinds[plugin]
+	if !ok {
+		return nil, fmt.Errorf("the plugin cannot be configured")
+	}
+	configuration := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(data), &configuration); err != nil {
+		return nil, fmt.Errorf("failed to parse the configuration: %v", err)
+	}
+	configObj := &unstructured.Unstructured{Object: configuration}
+	if gvk := configObj.GroupVersionKind(); gvk != expectedKind {
+		return nil, fmt.Errorf("the configuration must be a %s, got %q kind %q", expectedKind, configObj.GetAPIVersion(), configObj.GetKind())
+	}
+	return configuration, nil
+}
@@ -0,0 +1,126 @@
+package admission
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelistersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+)
+
+const (
+	eventRateLimitConfig = `apiVersion: eventratelimit.admission.k8s.io/v1alpha1
+kind: Configuration
+limits:
+- type: Namespace
+  qps: 50
+  burst: 100
+`
+	podTolerationRestrictionConfig = `apiVersion: podtolerationrestriction.admission.k8s.io/v1alpha1
+kind: Configuration
+default:
+- key: dedicated
+  operator: Exists
+`
+)
+
+func TestObserveAdmissionPluginConfigs(t *testing.T) {
+	eventRateLimitPluginConfig := map[string]interface{}{"configuration": map[string]interface{}{
+		"apiVersion": "eventratelimit.admission.k8s.io/v1alpha1",
+		"kind":       "Configuration",
+		"limits":     []interface{}{map[string]interface{}{"type": "Namespace", "qps": float64(50), "burst": float64(100)}},
+	}}
+	podTolerationRestrictionPluginConfig := map[string]interface{}{"configuration": map[string]interface{}{
+		"apiVersion": "podtolerationrestriction.admission.k8s.io/v1alpha1",
+		"kind":       "Configuration",
+		"default":    []interface{}{map[string]interface{}{"key": "dedicated", "operator": "Exists"}},
+	}}
+
+	scenarios := []struct {
+		name            string
+		pluginConfigs   map[string]string
+		expectedConfig  map[string]interface{}
+		expectedWarning bool
+	}{
+		{
+			name:           "no plugin configs",
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name: "plugin configs are assembled",
+			pluginConfigs: map[string]string{
+				"EventRateLimit":           eventRateLimitConfig,
+				"PodTolerationRestriction": podTolerationRestrictionConfig,
+			},
+			expectedConfig: map[string]interface{}{"admission": map[string]interface{}{"pluginConfig": map[string]interface{}{
+				"EventRateLimit":           eventRateLimitPluginConfig,
+				"PodTolerationRestriction": podTolerationRestrictionPluginConfig,
+			}}},
+		},
+		{
+			name: "malformed plugin config is rejected, the others applied",
+			pluginConfigs: map[string]string{
+				"EventRateLimit":           "limits: [",
+				"PodTolerationRestriction": podTolerationRestrictionConfig,
+			},
+			expectedConfig: map[string]interface{}{"admission": map[string]interface{}{"pluginConfig": map[string]interface{}{
+				"PodTolerationRestriction": podTolerationRestrictionPluginConfig,
+			}}},
+			expectedWarning: true,
+		},
+		{
+			name: "plugin config of the wrong kind is rejected",
+			pluginConfigs: map[string]string{
+				"EventRateLimit":           podTolerationRestrictionConfig,
+				"PodTolerationRestriction": podTolerationRestrictionConfig,
+			},
+			expectedConfig: map[string]interface{}{"admission": map[string]interface{}{"pluginConfig": map[string]interface{}{
+				"PodTolerationRestriction": podTolerationRestrictionPluginConfig,
+			}}},
+			expectedWarning: true,
+		},
+		{
+			name: "config of an unknown plugin is rejected",
+			pluginConfigs: map[string]string{
+				"network.openshift.io/ExternalIPRanger": eventRateLimitConfig,
+			},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			if scenario.pluginConfigs != nil {
+				if err := indexer.Add(&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-config", Name: AdmissionPluginConfigsConfigMapName},
+					Data:       scenario.pluginConfigs,
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			eventRecorder := events.NewInMemoryRecorder("")
+			listers := configobservation.Listers{
+				ConfigmapLister_: corelistersv1.NewConfigMapLister(indexer),
+			}
+
+			observedConfig, errs := ObserveAdmissionPluginConfigs(listers, eventRecorder, map[string]interface{}{})
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if !cmp.Equal(scenario.expectedConfig, observedConfig) {
+				t.Fatalf("unexpected configuration, diff = %v", cmp.Diff(scenario.expectedConfig, observedConfig))
+			}
+			if warned := len(eventRecorder.Events()) > 0; warned != scenario.expectedWarning {
+				t.Fatalf("expected warning %v, got events %v", scenario.expectedWarning, eventRecorder.Events())
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package admission
+
+import (
+	"github.com/ghodss/yaml"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/bindata"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+)
+
+var (
+	enableAdmissionPluginsPath  = []string{"apiServerArguments", "enable-admission-plugins"}
+	disableAdmissionPluginsPath = []string{"apiServerArguments", "disable-admission-plugins"}
+)
+
+type featureGateAdmissionPlugins struct {
+	// pluginsForFeatureGate lists the admission plugins enabled with, and disabled without, each feature gate.
+	pluginsForFeatureGate map[string][]string
+	// baseline holds the admission plugins enabled by default. It has to be repeated in the observed config
+	// because the observed enable-admission-plugins replaces the default one instead of being merged into it.
+	baseline []string
+}
+
+// NewFeatureGateAdmissionPluginsObserver returns an observer enabling the admission plugins of pluginsForFeatureGate
+// whose feature gate is enabled and disabling the ones whose feature gate is disabled. The plugins of a feature gate
+// that is neither enabled nor disabled are left as they are in the default config. A plugin tied to both an enabled
+// and a disabled feature gate is disabled.
+func NewFeatureGateAdmissionPluginsObserver(pluginsForFeatureGate map[string][]string) configobserver.ObserveConfigFunc {
+	return (&featureGateAdmissionPlugins{
+		pluginsForFeatureGate: pluginsForFeatureGate,
+		baseline:              defaultEnabledAdmissionPlugins(),
+	}).observe
+}
+
+func (f *featureGateAdmissionPlugins) observe(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	defer func() {
+		ret = configobserver.Pruned(ret, enableAdmissionPluginsPath, disableAdmissionPluginsPath)
+	}()
+
+	if len(f.pluginsForFeatureGate) == 0 {
+		return map[string]interface{}{}, errs
+	}
+
+	listers := genericListers.(configobservation.Listers)
+	enabledFeatures, disabledFeatures, _, err := listers.FeatureGates().ClusterFeatures()
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+
+	toEnable, toDisable := sets.NewString(), sets.NewString()
+	for _, gate := range sets.StringKeySet(f.pluginsForFeatureGate).List() {
+		switch {
+		case enabledFeatures.Has(gate):
+			toEnable.Insert(f.pluginsForFeatureGate[gate]...)
+		case disabledFeatures.Has(gate):
+			toDisable.Insert(f.pluginsForFeatureGate[gate]...)
+		case !knownFeatureGates().Has(gate):
+			recorder.Warningf("ObserveFeatureGateAdmissionPlugins", "Ignoring the admission plugins %v of the unknown feature gate %q", f.pluginsForFeatureGate[gate], gate)
+		}
+	}
+	if conflicting := toEnable.Intersection(toDisable); conflicting.Len() > 0 {
+		recorder.Warningf("ObserveFeatureGateAdmissionPlugins", "Admission plugins %v are tied to both enabled and disabled feature gates, disabling them", conflicting.List())
+		toEnable = toEnable.Difference(conflicting)
+	}
+
+	observedConfig := map[string]interface{}{}
+	baseline := sets.NewString(f.baseline...)
+	added := toEnable.Difference(baseline)
+	// leave the default config untouched unless the feature gates change it
+	if added.Len() == 0 && toDisable.Len() == 0 {
+		return observedConfig, errs
+	}
+
+	enabled := []string{}
+	for _, plugin := range f.baseline {
+		if !toDisable.Has(plugin) {
+			enabled = append(enabled, plugin)
+		}
+	}
+	enabled = append(enabled, added.List()...)
+	disabled := toDisable.List()
+
+	if err := unstructured.SetNestedStringSlice(observedConfig, enabled, enableAdmissionPluginsPath...); err != nil {
+		return existingConfig, append(errs, err)
+	}
+	if len(disabled) > 0 {
+		if err := unstructured.SetNestedStringSlice(observedConfig, disabled, disableAdmissionPluginsPath...); err != nil {
+			return existingConfig, append(errs, err)
+		}
+	}
+	return observedConfig, errs
+}
+
+// knownFeatureGates returns the feature gates of all the feature sets.
+func knownFeatureGates() sets.String {
+	known := sets.NewString()
+	for _, featureSet := range configv1.FeatureSets {
+		known.Insert(featureSet.Enabled...)
+		known.Insert(featureSet.Disabled...)
+	}
+	return known
+}
+
+// defaultEnabledAdmissionPlugins returns the admission plugins enabled by the default config.
+func defaultEnabledAdmissionPlugins() []string {
+	defaultConfig := map[string]interface{}{}
+	if err := yaml.Unmarshal(bindata.MustAsset("assets/config/defaultconfig.yaml"), &defaultConfig); err != nil {
+		panic(err)
+	}
+	plugins, _, err := unstructured.NestedStringSlice(defaultConfig, enableAdmissionPluginsPath...)
+	if err != nil {
+		panic(err)
+	}
+	return plugins
+}
@@ -0,0 +1,140 @@
+package admission
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+)
+
+func TestObserveFeatureGateAdmissionPlugins(t *testing.T) {
+	customFeatureGate := func(enabled, disabled []string) *configv1.FeatureGate {
+		return &configv1.FeatureGate{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+			Spec: configv1.FeatureGateSpec{
+				FeatureGateSelection: configv1.FeatureGateSelection{
+					FeatureSet:      configv1.CustomNoUpgrade,
+					CustomNoUpgrade: &configv1.CustomFeatureGates{Enabled: enabled, Disabled: disabled},
+				},
+			},
+		}
+	}
+	baseline := []string{"NamespaceLifecycle", "PodSecurity", "ValidatingAdmissionWebhook"}
+
+	scenarios := []struct {
+		name                  string
+		pluginsForFeatureGate map[string][]string
+		featureGate           *configv1.FeatureGate
+		expectedConfig        map[string]interface{}
+		expectedWarning       bool
+	}{
+		{
+			name:           "no feature gate tied admission plugins",
+			featureGate:    customFeatureGate([]string{"GateA"}, nil),
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name:                  "enabled feature gate",
+			pluginsForFeatureGate: map[string][]string{"GateA": {"PluginA"}},
+			featureGate:           customFeatureGate([]string{"GateA"}, nil),
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"enable-admission-plugins": []interface{}{"NamespaceLifecycle", "PodSecurity", "ValidatingAdmissionWebhook", "PluginA"},
+			}},
+		},
+		{
+			name:                  "enabled feature gate of a plugin already enabled by default",
+			pluginsForFeatureGate: map[string][]string{"GateA": {"PodSecurity"}},
+			featureGate:           customFeatureGate([]string{"GateA"}, nil),
+			expectedConfig:        map[string]interface{}{},
+		},
+		{
+			name:                  "disabled feature gate",
+			pluginsForFeatureGate: map[string][]string{"GateA": {"PodSecurity"}},
+			featureGate:           customFeatureGate(nil, []string{"GateA"}),
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"enable-admission-plugins":  []interface{}{"NamespaceLifecycle", "ValidatingAdmissionWebhook"},
+				"disable-admission-plugins": []interface{}{"PodSecurity"},
+			}},
+		},
+		{
+			name:                  "feature gate neither enabled nor disabled",
+			pluginsForFeatureGate: map[string][]string{"LegacyNodeRoleBehavior": {"PodSecurity"}},
+			featureGate:           customFeatureGate(nil, nil),
+			expectedConfig:        map[string]interface{}{},
+		},
+		{
+			name:                  "conflicting feature gates",
+			pluginsForFeatureGate: map[string][]string{"GateA": {"PluginA", "PluginB"}, "GateB": {"PluginB"}},
+			featureGate:           customFeatureGate([]string{"GateA"}, []string{"GateB"}),
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"enable-admission-plugins":  []interface{}{"NamespaceLifecycle", "PodSecurity", "ValidatingAdmissionWebhook", "PluginA"},
+				"disable-admission-plugins": []interface{}{"PluginB"},
+			}},
+			expectedWarning: true,
+		},
+		{
+			name:                  "unknown feature gate",
+			pluginsForFeatureGate: map[string][]string{"NoSuchGate": {"PluginA"}},
+			featureGate:           customFeatureGate(nil, nil),
+			expectedConfig:        map[string]interface{}{},
+			expectedWarning:       true,
+		},
+		{
+			name:                  "missing feature gate means the default feature set",
+			pluginsForFeatureGate: map[string][]string{"LegacyNodeRoleBehavior": {"PodSecurity"}},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"enable-admission-plugins":  []interface{}{"NamespaceLifecycle", "ValidatingAdmissionWebhook"},
+				"disable-admission-plugins": []interface{}{"PodSecurity"},
+			}},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if scenario.featureGate != nil {
+				if err := indexer.Add(scenario.featureGate); err != nil {
+					t.Fatal(err)
+				}
+			}
+			listers := configobservation.Listers{
+				FeatureGateLister_: configlistersv1.NewFeatureGateLister(indexer),
+			}
+			eventRecorder := events.NewInMemoryRecorder("")
+
+			observe := (&featureGateAdmissionPlugins{pluginsForFeatureGate: scenario.pluginsForFeatureGate, baseline: baseline}).observe
+			observedConfig, errs := observe(listers, eventRecorder, map[string]interface{}{})
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if !cmp.Equal(scenario.expectedConfig, observedConfig) {
+				t.Fatalf("unexpected configuration, diff = %v", cmp.Diff(scenario.expectedConfig, observedConfig))
+			}
+			if warned := len(eventRecorder.Events()) > 0; warned != scenario.expectedWarning {
+				t.Fatalf("expected warning %v, got events %v", scenario.expectedWarning, eventRecorder.Events())
+			}
+		})
+	}
+}
+
+func TestDefaultEnabledAdmissionPlugins(t *testing.T) {
+	plugins := defaultEnabledAdmissionPlugins()
+	for _, expected := range []string{"NamespaceLifecycle", "PodSecurity", "security.openshift.io/SecurityContextConstraint"} {
+		found := false
+		for _, plugin := range plugins {
+			if plugin == expected {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected %q in the default admission plugins, got %v", expected, plugins)
+		}
+	}
+}
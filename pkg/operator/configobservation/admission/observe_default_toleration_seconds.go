@@ -0,0 +1,78 @@
+package admission
+
+import (
+	"fmt"
+	"strconv"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation/apiserver"
+)
+
+const (
+	// DefaultNotReadyTolerationSecondsAnnotation on the cluster APIServer config sets
+	// default-not-ready-toleration-seconds, how long the DefaultTolerationSeconds admission plugin lets pods without
+	// their own toleration stay on a node that is not ready.
+	DefaultNotReadyTolerationSecondsAnnotation = "kubeapiserver.operator.openshift.io/default-not-ready-toleration-seconds"
+	// DefaultUnreachableTolerationSecondsAnnotation on the cluster APIServer config sets
+	// default-unreachable-toleration-seconds, the same for an unreachable node.
+	DefaultUnreachableTolerationSecondsAnnotation = "kubeapiserver.operator.openshift.io/default-unreachable-toleration-seconds"
+)
+
+var defaultTolerationSecondsArguments = []struct {
+	annotation string
+	path       []string
+}{
+	{annotation: DefaultNotReadyTolerationSecondsAnnotation, path: []string{"apiServerArguments", "default-not-ready-toleration-seconds"}},
+	{annotation: DefaultUnreachableTolerationSecondsAnnotation, path: []string{"apiServerArguments", "default-unreachable-toleration-seconds"}},
+}
+
+// ObserveDefaultTolerationSeconds sets the default toleration seconds of the DefaultTolerationSeconds admission plugin
+// from the annotations of the cluster APIServer config. The kube-apiserver default of 300 seconds applies to the
+// arguments whose annotation is not set. A value that is not a non-negative integer is rejected with a warning and
+// the previously observed value, if any, is kept.
+func ObserveDefaultTolerationSeconds(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	defaultTolerationSecondsPaths := [][]string{}
+	for _, arg := range defaultTolerationSecondsArguments {
+		defaultTolerationSecondsPaths = append(defaultTolerationSecondsPaths, arg.path)
+	}
+	defer func() {
+		ret = configobserver.Pruned(ret, defaultTolerationSecondsPaths...)
+	}()
+
+	listers := genericListers.(configobservation.Listers)
+	apiServer, err := listers.APIServerLister().Get("cluster")
+	if apierrors.IsNotFound(err) {
+		return map[string]interface{}{}, errs
+	}
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+
+	observedConfig := map[string]interface{}{}
+	for _, arg := range defaultTolerationSecondsArguments {
+		value, ok := apiServer.Annotations[arg.annotation]
+		if !ok {
+			continue
+		}
+
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || seconds < 0 {
+			if err := apiserver.KeepPreviousValue(recorder, "ObserveDefaultTolerationSeconds", arg.annotation, value, fmt.Errorf("must be a non-negative number of seconds"), existingConfig, observedConfig, arg.path); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		if err := unstructured.SetNestedStringSlice(observedConfig, []string{strconv.FormatInt(seconds, 10)}, arg.path...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return observedConfig, errs
+}
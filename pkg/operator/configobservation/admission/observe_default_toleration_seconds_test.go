@@ -0,0 +1,94 @@
+package admission
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+)
+
+func TestObserveDefaultTolerationSeconds(t *testing.T) {
+	scenarios := []struct {
+		name            string
+		annotations     map[string]string
+		existingConfig  map[string]interface{}
+		expectedConfig  map[string]interface{}
+		expectedWarning bool
+	}{
+		{
+			name: "not set: the kube-apiserver defaults apply",
+			existingConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"default-not-ready-toleration-seconds": []interface{}{"60"},
+			}},
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name: "custom values",
+			annotations: map[string]string{
+				DefaultNotReadyTolerationSecondsAnnotation:    "60",
+				DefaultUnreachableTolerationSecondsAnnotation: "0",
+			},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"default-not-ready-toleration-seconds":   []interface{}{"60"},
+				"default-unreachable-toleration-seconds": []interface{}{"0"},
+			}},
+		},
+		{
+			name: "negative value is rejected, the valid one applied",
+			annotations: map[string]string{
+				DefaultNotReadyTolerationSecondsAnnotation:    "-1",
+				DefaultUnreachableTolerationSecondsAnnotation: "120",
+			},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"default-unreachable-toleration-seconds": []interface{}{"120"},
+			}},
+			expectedWarning: true,
+		},
+		{
+			name:        "a rejected value keeps the previously observed one",
+			annotations: map[string]string{DefaultUnreachableTolerationSecondsAnnotation: "5m"},
+			existingConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"default-unreachable-toleration-seconds": []interface{}{"120"},
+			}},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"default-unreachable-toleration-seconds": []interface{}{"120"},
+			}},
+			expectedWarning: true,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := indexer.Add(&configv1.APIServer{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Annotations: scenario.annotations}}); err != nil {
+				t.Fatal(err)
+			}
+			eventRecorder := events.NewInMemoryRecorder("")
+			listers := configobservation.Listers{
+				APIServerLister_: configlistersv1.NewAPIServerLister(indexer),
+			}
+			existingConfig := scenario.existingConfig
+			if existingConfig == nil {
+				existingConfig = map[string]interface{}{}
+			}
+
+			observedConfig, errs := ObserveDefaultTolerationSeconds(listers, eventRecorder, existingConfig)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if !cmp.Equal(scenario.expectedConfig, observedConfig) {
+				t.Fatalf("unexpected configuration, diff = %v", cmp.Diff(scenario.expectedConfig, observedConfig))
+			}
+			if warned := len(eventRecorder.Events()) > 0; warned != scenario.expectedWarning {
+				t.Fatalf("expected warning %v, got events %v", scenario.expectedWarning, eventRecorder.Events())
+			}
+		})
+	}
+}
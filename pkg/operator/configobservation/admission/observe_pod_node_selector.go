@@ -0,0 +1,79 @@
+package admission
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+const (
+	// PodNodeSelectorsConfigMapName is the configmap in openshift-config holding the node selectors of the
+	// PodNodeSelector admission plugin, comma separated key=value labels. The ClusterDefaultNodeSelectorKey key holds
+	// the node selector of the pods of namespaces without a node selector annotation, the other keys are namespace
+	// names and hold the node selectors the pods of the namespace are restricted to.
+	PodNodeSelectorsConfigMapName = "kube-apiserver-pod-node-selectors"
+
+	ClusterDefaultNodeSelectorKey = "clusterDefaultNodeSelector"
+)
+
+var podNodeSelectorConfigPath = []string{"admission", "pluginConfig", "PodNodeSelector"}
+
+// ObservePodNodeSelector sets the PodNodeSelector admission plugin configuration from the PodNodeSelectorsConfigMapName
+// configmap. When one of the selectors is invalid the configmap is rejected with a warning and the existing
+// configuration is kept, applying only the valid selectors could lift a namespace restriction.
+func ObservePodNodeSelector(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	defer func() {
+		ret = configobserver.Pruned(ret, podNodeSelectorConfigPath)
+	}()
+
+	listers := genericListers.(configobservation.Listers)
+	configMap, err := listers.ConfigMapLister().ConfigMaps(operatorclient.GlobalUserSpecifiedConfigNamespace).Get(PodNodeSelectorsConfigMapName)
+	if apierrors.IsNotFound(err) {
+		return map[string]interface{}{}, errs
+	}
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+	if len(configMap.Data) == 0 {
+		return map[string]interface{}{}, errs
+	}
+
+	var invalid []string
+	nodeSelectors := map[string]interface{}{}
+	for key, selector := range configMap.Data {
+		if key != ClusterDefaultNodeSelectorKey {
+			if msgs := validation.IsDNS1123Label(key); len(msgs) > 0 {
+				invalid = append(invalid, fmt.Sprintf("%q is not a namespace name: %s", key, strings.Join(msgs, ", ")))
+				continue
+			}
+		}
+		if _, err := labels.ConvertSelectorToLabelsMap(selector); err != nil {
+			invalid = append(invalid, fmt.Sprintf("invalid node selector %q of %s: %v", selector, key, err))
+			continue
+		}
+		nodeSelectors[key] = selector
+	}
+	if len(invalid) > 0 {
+		sort.Strings(invalid)
+		recorder.Warningf("ObservePodNodeSelector", "Rejecting the %s/%s configmap, keeping the current PodNodeSelector configuration: %s", operatorclient.GlobalUserSpecifiedConfigNamespace, PodNodeSelectorsConfigMapName, strings.Join(invalid, "; "))
+		return existingConfig, errs
+	}
+
+	observedConfig := map[string]interface{}{}
+	pluginConfig := map[string]interface{}{"configuration": map[string]interface{}{"podNodeSelectorPluginConfig": nodeSelectors}}
+	if err := unstructured.SetNestedField(observedConfig, pluginConfig, podNodeSelectorConfigPath...); err != nil {
+		return existingConfig, append(errs, err)
+	}
+	return observedConfig, errs
+}
@@ -0,0 +1,117 @@
+package admission
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelistersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+)
+
+func TestObservePodNodeSelector(t *testing.T) {
+	podNodeSelectorConfig := func(nodeSelectors map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"admission": map[string]interface{}{"pluginConfig": map[string]interface{}{
+			"PodNodeSelector": map[string]interface{}{"configuration": map[string]interface{}{"podNodeSelectorPluginConfig": nodeSelectors}},
+		}}}
+	}
+	existingConfig := podNodeSelectorConfig(map[string]interface{}{"clusterDefaultNodeSelector": "region=east"})
+
+	scenarios := []struct {
+		name            string
+		nodeSelectors   map[string]string
+		existingConfig  map[string]interface{}
+		expectedConfig  map[string]interface{}
+		expectedWarning bool
+	}{
+		{
+			name:           "no node selectors",
+			existingConfig: existingConfig,
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name:           "cluster default",
+			nodeSelectors:  map[string]string{"clusterDefaultNodeSelector": "node-role.kubernetes.io/worker=, region=west"},
+			expectedConfig: podNodeSelectorConfig(map[string]interface{}{"clusterDefaultNodeSelector": "node-role.kubernetes.io/worker=, region=west"}),
+		},
+		{
+			name: "per-namespace selectors",
+			nodeSelectors: map[string]string{
+				"clusterDefaultNodeSelector": "region=west",
+				"team-a":                     "region=east,tier=gold",
+				"team-b":                     "",
+			},
+			existingConfig: existingConfig,
+			expectedConfig: podNodeSelectorConfig(map[string]interface{}{
+				"clusterDefaultNodeSelector": "region=west",
+				"team-a":                     "region=east,tier=gold",
+				"team-b":                     "",
+			}),
+		},
+		{
+			name: "invalid selector keeps the existing config",
+			nodeSelectors: map[string]string{
+				"clusterDefaultNodeSelector": "region=west",
+				"team-a":                     "region in (east)",
+			},
+			existingConfig:  existingConfig,
+			expectedConfig:  existingConfig,
+			expectedWarning: true,
+		},
+		{
+			name: "invalid label value keeps the existing config",
+			nodeSelectors: map[string]string{
+				"clusterDefaultNodeSelector": "region=west coast",
+			},
+			existingConfig:  existingConfig,
+			expectedConfig:  existingConfig,
+			expectedWarning: true,
+		},
+		{
+			name: "invalid namespace name keeps the existing config",
+			nodeSelectors: map[string]string{
+				"Team_A": "region=east",
+			},
+			existingConfig:  existingConfig,
+			expectedConfig:  existingConfig,
+			expectedWarning: true,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			if scenario.nodeSelectors != nil {
+				if err := indexer.Add(&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-config", Name: PodNodeSelectorsConfigMapName},
+					Data:       scenario.nodeSelectors,
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if scenario.existingConfig == nil {
+				scenario.existingConfig = map[string]interface{}{}
+			}
+			eventRecorder := events.NewInMemoryRecorder("")
+			listers := configobservation.Listers{
+				ConfigmapLister_: corelistersv1.NewConfigMapLister(indexer),
+			}
+
+			observedConfig, errs := ObservePodNodeSelector(listers, eventRecorder, scenario.existingConfig)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if !cmp.Equal(scenario.expectedConfig, observedConfig) {
+				t.Fatalf("unexpected configuration, diff = %v", cmp.Diff(scenario.expectedConfig, observedConfig))
+			}
+			if warned := len(eventRecorder.Events()) > 0; warned != scenario.expectedWarning {
+				t.Fatalf("expected warning %v, got events %v", scenario.expectedWarning, eventRecorder.Events())
+			}
+		})
+	}
+}
@@ -2,7 +2,6 @@ package apiserver
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/imdario/mergo"
 	"k8s.io/klog/v2"
@@ -87,7 +86,7 @@ func observeUserClientCABundle(apiServer *configv1.APIServer, recorder events.Re
 }
 
 // observeNamedCertificates observes user managed Secrets containing TLS cert info for serving secure traffic to
-// specific hostnames.
+// specific hostnames. A hostname listed by several entries is only served by the first one.
 func observeNamedCertificates(apiServer *configv1.APIServer, recorder events.Recorder, previouslyObservedConfig map[string]interface{}) (map[string]interface{}, syncActionRules, []error) {
 	var errs []error
 	observedConfig := map[string]interface{}{}
@@ -122,19 +121,31 @@ func observeNamedCertificates(apiServer *configv1.APIServer, recorder events.Rec
 		"certFile": "/etc/kubernetes/static-pod-resources/secrets/localhost-recovery-serving-certkey/tls.crt",
 		"keyFile":  "/etc/kubernetes/static-pod-resources/secrets/localhost-recovery-serving-certkey/tls.key"})
 
-	// specifiedNameToIndex has keys that are namedCertificate.Names and values that are the index they are used in.
-	// we use this to detect if the same name is specified multiple times and fail.
-	specifiedNameToIndex := map[string][]string{}
+	// nameToIndex has keys that are namedCertificate.Names and values that are the index of the first entry using them.
+	// we use this to detect if the same name is specified multiple times and only keep the first entry serving it.
+	nameToIndex := map[string]int{}
 	for index, namedCertificate := range namedCertificates {
+		var names []string
+		for _, name := range namedCertificate.Names {
+			if firstIndex, ok := nameToIndex[name]; ok {
+				recorder.Warningf("ObserveNamedCertificatesDuplicateName", "spec.servingCerts.namedCertificates[%d].names %q is already used by spec.servingCerts.namedCertificates[%d], ignoring it", index, name, firstIndex)
+				continue
+			}
+			nameToIndex[name] = index
+			names = append(names, name)
+		}
+		if len(namedCertificate.Names) > 0 && len(names) == 0 {
+			// without names the certificate would be served for the names in its SANs, which are likely the duplicates.
+			recorder.Warningf("ObserveNamedCertificatesDuplicateName", "all names of spec.servingCerts.namedCertificates[%d] are already used by other entries, ignoring it", index)
+			continue
+		}
+
 		observedNamedCertificate := map[string]interface{}{}
-		if len(namedCertificate.Names) > 0 {
-			if err := unstructured.SetNestedStringSlice(observedNamedCertificate, namedCertificate.Names, "names"); err != nil {
+		if len(names) > 0 {
+			if err := unstructured.SetNestedStringSlice(observedNamedCertificate, names, "names"); err != nil {
 				return previouslyObservedConfig, nil, append(errs, err)
 			}
 		}
-		for _, name := range namedCertificate.Names {
-			specifiedNameToIndex[name] = append(specifiedNameToIndex[name], fmt.Sprintf("%d", index))
-		}
 
 		sourceSecretName := namedCertificate.ServingCertificate.Name
 		if len(sourceSecretName) == 0 {
@@ -162,13 +173,6 @@ func observeNamedCertificates(apiServer *configv1.APIServer, recorder events.Rec
 		observedNamedCertificates = append(observedNamedCertificates, observedNamedCertificate)
 	}
 
-	for name, indexes := range specifiedNameToIndex {
-		if len(indexes) == 1 {
-			continue
-		}
-		errs = append(errs, fmt.Errorf("spec.servingCerts.namedCertificates[...].servingCertificate.name %q is used by other indexes: [%s]", name, strings.Join(indexes, ",")))
-	}
-
 	if len(observedNamedCertificates) > 0 {
 		if err := unstructured.SetNestedField(observedConfig, observedNamedCertificates, namedCertificatesPath...); err != nil {
 			return previouslyObservedConfig, nil, append(errs, err)
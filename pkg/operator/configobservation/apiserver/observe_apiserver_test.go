@@ -99,12 +99,13 @@ func TestObserveNamedCertificates(t *testing.T) {
 	}
 
 	testCases := []struct {
-		name           string
-		config         *configv1.APIServer
-		existing       map[string]interface{}
-		expected       map[string]interface{}
-		expectErrs     bool
-		expectedSynced map[string]string
+		name            string
+		config          *configv1.APIServer
+		existing        map[string]interface{}
+		expected        map[string]interface{}
+		expectErrs      bool
+		expectedSynced  map[string]string
+		expectedWarning string
 	}{
 		{
 			name:     "NoAPIServerConfig",
@@ -236,9 +237,119 @@ func TestObserveNamedCertificates(t *testing.T) {
 					withSecret("third"),
 				),
 			),
-			existing:   existingConfig,
-			expected:   existingConfig,
-			expectErrs: true,
+			existing: existingConfig,
+			expected: map[string]interface{}{
+				"servingInfo": map[string]interface{}{
+					"namedCertificates": []interface{}{
+						map[string]interface{}{
+							"certFile": "/etc/kubernetes/static-pod-certs/secrets/localhost-serving-cert-certkey/tls.crt",
+							"keyFile":  "/etc/kubernetes/static-pod-certs/secrets/localhost-serving-cert-certkey/tls.key",
+						},
+						map[string]interface{}{
+							"certFile": "/etc/kubernetes/static-pod-certs/secrets/service-network-serving-certkey/tls.crt",
+							"keyFile":  "/etc/kubernetes/static-pod-certs/secrets/service-network-serving-certkey/tls.key",
+						},
+						map[string]interface{}{
+							"certFile": "/etc/kubernetes/static-pod-certs/secrets/external-loadbalancer-serving-certkey/tls.crt",
+							"keyFile":  "/etc/kubernetes/static-pod-certs/secrets/external-loadbalancer-serving-certkey/tls.key",
+						},
+						map[string]interface{}{
+							"certFile": "/etc/kubernetes/static-pod-certs/secrets/internal-loadbalancer-serving-certkey/tls.crt",
+							"keyFile":  "/etc/kubernetes/static-pod-certs/secrets/internal-loadbalancer-serving-certkey/tls.key",
+						},
+						map[string]interface{}{
+							"certFile": "/etc/kubernetes/static-pod-resources/secrets/localhost-recovery-serving-certkey/tls.crt",
+							"keyFile":  "/etc/kubernetes/static-pod-resources/secrets/localhost-recovery-serving-certkey/tls.key",
+						},
+						map[string]interface{}{
+							"certFile": "/etc/kubernetes/static-pod-certs/secrets/user-serving-cert-000/tls.crt",
+							"keyFile":  "/etc/kubernetes/static-pod-certs/secrets/user-serving-cert-000/tls.key",
+							"names":    []interface{}{"*.foo.org", "something.com", "colliding.com"},
+						},
+						map[string]interface{}{
+							"certFile": "/etc/kubernetes/static-pod-certs/secrets/user-serving-cert-001/tls.crt",
+							"keyFile":  "/etc/kubernetes/static-pod-certs/secrets/user-serving-cert-001/tls.key",
+							"names":    []interface{}{"safe.com"},
+						},
+						map[string]interface{}{
+							"certFile": "/etc/kubernetes/static-pod-certs/secrets/user-serving-cert-002/tls.crt",
+							"keyFile":  "/etc/kubernetes/static-pod-certs/secrets/user-serving-cert-002/tls.key",
+							"names":    []interface{}{"non-collision.io"},
+						},
+					},
+				},
+			},
+			expectedSynced: map[string]string{
+				"secret/user-serving-cert-000.openshift-kube-apiserver": "secret/foo.openshift-config",
+				"secret/user-serving-cert-001.openshift-kube-apiserver": "secret/bar.openshift-config",
+				"secret/user-serving-cert-002.openshift-kube-apiserver": "secret/third.openshift-config",
+				"secret/user-serving-cert-003.openshift-kube-apiserver": "DELETE",
+				"secret/user-serving-cert-004.openshift-kube-apiserver": "DELETE",
+				"secret/user-serving-cert-005.openshift-kube-apiserver": "DELETE",
+				"secret/user-serving-cert-006.openshift-kube-apiserver": "DELETE",
+				"secret/user-serving-cert-007.openshift-kube-apiserver": "DELETE",
+				"secret/user-serving-cert-008.openshift-kube-apiserver": "DELETE",
+				"secret/user-serving-cert-009.openshift-kube-apiserver": "DELETE",
+			},
+			expectedWarning: "ObserveNamedCertificatesDuplicateName",
+		},
+		{
+			name: "NamedCertificateWithOnlyOverlappingNames",
+			config: newAPIServerConfig(
+				withCertificate(
+					withNames("*.foo.org", "colliding.com"),
+					withSecret("foo"),
+				),
+				withCertificate(
+					withNames("colliding.com"),
+					withSecret("bar"),
+				),
+			),
+			existing: existingConfig,
+			expected: map[string]interface{}{
+				"servingInfo": map[string]interface{}{
+					"namedCertificates": []interface{}{
+						map[string]interface{}{
+							"certFile": "/etc/kubernetes/static-pod-certs/secrets/localhost-serving-cert-certkey/tls.crt",
+							"keyFile":  "/etc/kubernetes/static-pod-certs/secrets/localhost-serving-cert-certkey/tls.key",
+						},
+						map[string]interface{}{
+							"certFile": "/etc/kubernetes/static-pod-certs/secrets/service-network-serving-certkey/tls.crt",
+							"keyFile":  "/etc/kubernetes/static-pod-certs/secrets/service-network-serving-certkey/tls.key",
+						},
+						map[string]interface{}{
+							"certFile": "/etc/kubernetes/static-pod-certs/secrets/external-loadbalancer-serving-certkey/tls.crt",
+							"keyFile":  "/etc/kubernetes/static-pod-certs/secrets/external-loadbalancer-serving-certkey/tls.key",
+						},
+						map[string]interface{}{
+							"certFile": "/etc/kubernetes/static-pod-certs/secrets/internal-loadbalancer-serving-certkey/tls.crt",
+							"keyFile":  "/etc/kubernetes/static-pod-certs/secrets/internal-loadbalancer-serving-certkey/tls.key",
+						},
+						map[string]interface{}{
+							"certFile": "/etc/kubernetes/static-pod-resources/secrets/localhost-recovery-serving-certkey/tls.crt",
+							"keyFile":  "/etc/kubernetes/static-pod-resources/secrets/localhost-recovery-serving-certkey/tls.key",
+						},
+						map[string]interface{}{
+							"certFile": "/etc/kubernetes/static-pod-certs/secrets/user-serving-cert-000/tls.crt",
+							"keyFile":  "/etc/kubernetes/static-pod-certs/secrets/user-serving-cert-000/tls.key",
+							"names":    []interface{}{"*.foo.org", "colliding.com"},
+						},
+					},
+				},
+			},
+			expectedSynced: map[string]string{
+				"secret/user-serving-cert-000.openshift-kube-apiserver": "secret/foo.openshift-config",
+				"secret/user-serving-cert-001.openshift-kube-apiserver": "DELETE",
+				"secret/user-serving-cert-002.openshift-kube-apiserver": "DELETE",
+				"secret/user-serving-cert-003.openshift-kube-apiserver": "DELETE",
+				"secret/user-serving-cert-004.openshift-kube-apiserver": "DELETE",
+				"secret/user-serving-cert-005.openshift-kube-apiserver": "DELETE",
+				"secret/user-serving-cert-006.openshift-kube-apiserver": "DELETE",
+				"secret/user-serving-cert-007.openshift-kube-apiserver": "DELETE",
+				"secret/user-serving-cert-008.openshift-kube-apiserver": "DELETE",
+				"secret/user-serving-cert-009.openshift-kube-apiserver": "DELETE",
+			},
+			expectedWarning: "ObserveNamedCertificatesDuplicateName",
 		},
 		{
 			name: "NamedCertificateWithoutName",
@@ -477,7 +588,8 @@ func TestObserveNamedCertificates(t *testing.T) {
 				APIServerLister_: configlistersv1.NewAPIServerLister(indexer),
 				ResourceSync:     &mockResourceSyncer{t: t, synced: synced},
 			}
-			result, errs := ObserveNamedCertificates(listers, events.NewInMemoryRecorder(t.Name()), tc.existing)
+			recorder := events.NewInMemoryRecorder(t.Name())
+			result, errs := ObserveNamedCertificates(listers, recorder, tc.existing)
 			if tc.expectErrs && len(errs) == 0 {
 				t.Error("Expected errors.", errs)
 			}
@@ -494,6 +606,17 @@ func TestObserveNamedCertificates(t *testing.T) {
 			if !equality.Semantic.DeepEqual(tc.expectedSynced, synced) {
 				t.Errorf("expected resources not synced: %s", diff.ObjectReflectDiff(tc.expectedSynced, synced))
 			}
+			if len(tc.expectedWarning) > 0 {
+				found := false
+				for _, ev := range recorder.Events() {
+					if ev.Type == corev1.EventTypeWarning && ev.Reason == tc.expectedWarning {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("expected a %q warning event, got %v", tc.expectedWarning, recorder.Events())
+				}
+			}
 		})
 	}
 
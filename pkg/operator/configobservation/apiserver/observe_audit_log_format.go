@@ -0,0 +1,97 @@
+package apiserver
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+const (
+	// AuditLogFormatAnnotation on the cluster APIServer config sets audit-log-format, "json" or "legacy".
+	AuditLogFormatAnnotation = "kubeapiserver.operator.openshift.io/audit-log-format"
+	// AuditLogPathAnnotation on the cluster APIServer config sets audit-log-path, the file the audit log is written
+	// to. It must be in auditLogDir, the only audit log directory mounted into the kube-apiserver pod.
+	AuditLogPathAnnotation = "kubeapiserver.operator.openshift.io/audit-log-path"
+
+	auditLogDir = "/var/log/kube-apiserver"
+)
+
+var (
+	auditLogFormatPath = []string{"apiServerArguments", "audit-log-format"}
+	auditLogPathPath   = []string{"apiServerArguments", "audit-log-path"}
+)
+
+// ObserveAuditLogFormat sets audit-log-format and audit-log-path from the AuditLogFormatAnnotation and
+// AuditLogPathAnnotation of the cluster APIServer config. The default config values, json to
+// /var/log/kube-apiserver/audit.log, are used for the arguments whose annotation is not set. An invalid value is
+// rejected with a warning and the previously observed value, if any, is kept.
+func ObserveAuditLogFormat(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	defer func() {
+		ret = configobserver.Pruned(ret, auditLogFormatPath, auditLogPathPath)
+	}()
+
+	listers := genericListers.(configobservation.Listers)
+	apiServer, err := listers.APIServerLister().Get("cluster")
+	if apierrors.IsNotFound(err) {
+		return map[string]interface{}{}, errs
+	}
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+
+	observedConfig := map[string]interface{}{}
+	for _, arg := range []struct {
+		annotation string
+		path       []string
+		validate   func(string) error
+	}{
+		{annotation: AuditLogFormatAnnotation, path: auditLogFormatPath, validate: validateAuditLogFormat},
+		{annotation: AuditLogPathAnnotation, path: auditLogPathPath, validate: validateAuditLogPath},
+	} {
+		value, ok := apiServer.Annotations[arg.annotation]
+		if !ok {
+			continue
+		}
+
+		if err := arg.validate(value); err != nil {
+			if err := KeepPreviousValue(recorder, "ObserveAuditLogFormat", arg.annotation, value, err, existingConfig, observedConfig, arg.path); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		if err := unstructured.SetNestedStringSlice(observedConfig, []string{value}, arg.path...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return observedConfig, errs
+}
+
+func validateAuditLogFormat(format string) error {
+	if format != "json" && format != "legacy" {
+		return fmt.Errorf("must be json or legacy")
+	}
+	return nil
+}
+
+// validateAuditLogPath accepts the clean absolute paths of files in auditLogDir.
+func validateAuditLogPath(p string) error {
+	if !path.IsAbs(p) || path.Clean(p) != p {
+		return fmt.Errorf("must be a clean absolute path")
+	}
+	if path.Dir(p) != auditLogDir {
+		return fmt.Errorf("must be a file in %s", auditLogDir)
+	}
+	if strings.HasPrefix(path.Base(p), ".") {
+		return fmt.Errorf("must not be a hidden file")
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestObserveAuditLogFormat(t *testing.T) {
+	scenarios := []struct {
+		name            string
+		annotations     map[string]string
+		existingConfig  map[string]interface{}
+		expectedConfig  map[string]interface{}
+		expectedWarning bool
+	}{
+		{
+			name:           "not set: the default config values apply",
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name: "json to a custom path",
+			annotations: map[string]string{
+				AuditLogFormatAnnotation: "json",
+				AuditLogPathAnnotation:   "/var/log/kube-apiserver/audit-shipped.log",
+			},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"audit-log-format": []interface{}{"json"},
+				"audit-log-path":   []interface{}{"/var/log/kube-apiserver/audit-shipped.log"},
+			}},
+		},
+		{
+			name:        "legacy",
+			annotations: map[string]string{AuditLogFormatAnnotation: "legacy"},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"audit-log-format": []interface{}{"legacy"},
+			}},
+		},
+		{
+			name:            "invalid format is rejected",
+			annotations:     map[string]string{AuditLogFormatAnnotation: "JSON"},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+		{
+			name:        "a rejected format keeps the previously observed one",
+			annotations: map[string]string{AuditLogFormatAnnotation: "text"},
+			existingConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"audit-log-format": []interface{}{"legacy"},
+			}},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"audit-log-format": []interface{}{"legacy"},
+			}},
+			expectedWarning: true,
+		},
+		{
+			name:            "path outside of the audit log directory is rejected",
+			annotations:     map[string]string{AuditLogPathAnnotation: "/var/log/audit.log"},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+		{
+			name:            "path escaping the audit log directory is rejected",
+			annotations:     map[string]string{AuditLogPathAnnotation: "/var/log/kube-apiserver/../audit.log"},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+		{
+			name:            "relative path is rejected",
+			annotations:     map[string]string{AuditLogPathAnnotation: "audit.log"},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			eventRecorder := events.NewInMemoryRecorder("")
+			listers := configobservation.Listers{
+				APIServerLister_: apiServerListerWithAnnotations(t, scenario.annotations),
+			}
+			existingConfig := scenario.existingConfig
+			if existingConfig == nil {
+				existingConfig = map[string]interface{}{}
+			}
+
+			observedConfig, errs := ObserveAuditLogFormat(listers, eventRecorder, existingConfig)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if !cmp.Equal(scenario.expectedConfig, observedConfig) {
+				t.Fatalf("unexpected configuration, diff = %v", cmp.Diff(scenario.expectedConfig, observedConfig))
+			}
+			if warned := len(eventRecorder.Events()) > 0; warned != scenario.expectedWarning {
+				t.Fatalf("expected warning %v, got events %v", scenario.expectedWarning, eventRecorder.Events())
+			}
+		})
+	}
+}
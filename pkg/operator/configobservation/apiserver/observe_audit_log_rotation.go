@@ -0,0 +1,99 @@
+package apiserver
+
+import (
+	"fmt"
+	"strconv"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+const (
+	// AuditLogMaxAgeAnnotation on the cluster APIServer config sets audit-log-maxage,
+	// the number of days to retain rotated audit log files.
+	AuditLogMaxAgeAnnotation = "kubeapiserver.operator.openshift.io/audit-log-maxage"
+	// AuditLogMaxBackupAnnotation on the cluster APIServer config sets audit-log-maxbackup,
+	// the number of rotated audit log files to retain.
+	AuditLogMaxBackupAnnotation = "kubeapiserver.operator.openshift.io/audit-log-maxbackup"
+	// AuditLogMaxSizeAnnotation on the cluster APIServer config sets audit-log-maxsize,
+	// the size in megabytes an audit log file reaches before it is rotated.
+	AuditLogMaxSizeAnnotation = "kubeapiserver.operator.openshift.io/audit-log-maxsize"
+
+	// maxAuditLogMaxSize caps audit-log-maxsize, the audit logs share the control plane nodes disk.
+	maxAuditLogMaxSize = 1024
+)
+
+type auditLogRotationArgument struct {
+	annotation string
+	path       []string
+	max        int
+}
+
+var auditLogRotationArguments = []auditLogRotationArgument{
+	{annotation: AuditLogMaxAgeAnnotation, path: []string{"apiServerArguments", "audit-log-maxage"}},
+	{annotation: AuditLogMaxBackupAnnotation, path: []string{"apiServerArguments", "audit-log-maxbackup"}},
+	{annotation: AuditLogMaxSizeAnnotation, path: []string{"apiServerArguments", "audit-log-maxsize"}, max: maxAuditLogMaxSize},
+}
+
+// ObserveAuditLogRotation sets the audit log rotation arguments from the annotations of the cluster APIServer config.
+// The default config values are used for the arguments whose annotation is not set. An invalid value is rejected
+// with a warning and the previously observed value, if any, is kept.
+func ObserveAuditLogRotation(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	auditLogRotationPaths := [][]string{}
+	for _, arg := range auditLogRotationArguments {
+		auditLogRotationPaths = append(auditLogRotationPaths, arg.path)
+	}
+	defer func() {
+		ret = configobserver.Pruned(ret, auditLogRotationPaths...)
+	}()
+
+	listers := genericListers.(configobservation.Listers)
+	apiServer, err := listers.APIServerLister().Get("cluster")
+	if apierrors.IsNotFound(err) {
+		return map[string]interface{}{}, errs
+	}
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+
+	observedConfig := map[string]interface{}{}
+	for _, arg := range auditLogRotationArguments {
+		value, ok := apiServer.Annotations[arg.annotation]
+		if !ok {
+			continue
+		}
+
+		n, err := parseAuditLogRotationValue(value, arg.max)
+		if err != nil {
+			if err := KeepPreviousValue(recorder, "ObserveAuditLogRotation", arg.annotation, value, err, existingConfig, observedConfig, arg.path); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		if err := unstructured.SetNestedStringSlice(observedConfig, []string{strconv.Itoa(n)}, arg.path...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return observedConfig, errs
+}
+
+// parseAuditLogRotationValue accepts positive integers up to max, when max is set.
+func parseAuditLogRotationValue(value string, max int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("must be an integer")
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("must be positive")
+	}
+	if max > 0 && n > max {
+		return 0, fmt.Errorf("must not exceed %d", max)
+	}
+	return n, nil
+}
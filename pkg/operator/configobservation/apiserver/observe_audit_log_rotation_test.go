@@ -0,0 +1,105 @@
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestObserveAuditLogRotation(t *testing.T) {
+	scenarios := []struct {
+		name            string
+		annotations     map[string]string
+		existingConfig  map[string]interface{}
+		expectedConfig  map[string]interface{}
+		expectedWarning bool
+	}{
+		{
+			name:           "not set: the default config values apply",
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name: "previously observed values are dropped once unset",
+			existingConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"audit-log-maxsize": []interface{}{"200"},
+			}},
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name: "valid overrides",
+			annotations: map[string]string{
+				AuditLogMaxAgeAnnotation:    "7",
+				AuditLogMaxBackupAnnotation: "20",
+				AuditLogMaxSizeAnnotation:   "200",
+			},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"audit-log-maxage":    []interface{}{"7"},
+				"audit-log-maxbackup": []interface{}{"20"},
+				"audit-log-maxsize":   []interface{}{"200"},
+			}},
+		},
+		{
+			name:        "maxsize at the cap",
+			annotations: map[string]string{AuditLogMaxSizeAnnotation: "1024"},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"audit-log-maxsize": []interface{}{"1024"},
+			}},
+		},
+		{
+			name:            "maxsize above the cap is rejected",
+			annotations:     map[string]string{AuditLogMaxSizeAnnotation: "1025"},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+		{
+			name: "invalid values are rejected, valid ones applied",
+			annotations: map[string]string{
+				AuditLogMaxAgeAnnotation:    "0",
+				AuditLogMaxBackupAnnotation: "ten",
+				AuditLogMaxSizeAnnotation:   "50",
+			},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"audit-log-maxsize": []interface{}{"50"},
+			}},
+			expectedWarning: true,
+		},
+		{
+			name:        "a rejected value keeps the previously observed one",
+			annotations: map[string]string{AuditLogMaxBackupAnnotation: "-1"},
+			existingConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"audit-log-maxbackup": []interface{}{"20"},
+			}},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"audit-log-maxbackup": []interface{}{"20"},
+			}},
+			expectedWarning: true,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			eventRecorder := events.NewInMemoryRecorder("")
+			listers := configobservation.Listers{
+				APIServerLister_: apiServerListerWithAnnotations(t, scenario.annotations),
+			}
+			existingConfig := scenario.existingConfig
+			if existingConfig == nil {
+				existingConfig = map[string]interface{}{}
+			}
+
+			observedConfig, errs := ObserveAuditLogRotation(listers, eventRecorder, existingConfig)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if !cmp.Equal(scenario.expectedConfig, observedConfig) {
+				t.Fatalf("unexpected configuration, diff = %v", cmp.Diff(scenario.expectedConfig, observedConfig))
+			}
+			if warned := len(eventRecorder.Events()) > 0; warned != scenario.expectedWarning {
+				t.Fatalf("expected warning %v, got events %v", scenario.expectedWarning, eventRecorder.Events())
+			}
+		})
+	}
+}
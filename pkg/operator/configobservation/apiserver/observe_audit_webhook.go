@@ -0,0 +1,136 @@
+package apiserver
+
+import (
+	"fmt"
+	"net/url"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resourcesynccontroller"
+)
+
+// AuditWebhookKubeConfigAnnotation on the cluster APIServer config names the secret in openshift-config holding, in
+// its kubeConfig key, the kubeconfig of the webhook the audit events are sent to.
+const AuditWebhookKubeConfigAnnotation = "kubeapiserver.operator.openshift.io/audit-webhook-kubeconfig"
+
+const auditWebhookSecretName = "audit-webhook"
+
+var (
+	auditWebhookConfigFilePath = []string{"apiServerArguments", "audit-webhook-config-file"}
+	auditWebhookConfigFile     = []interface{}{"/etc/kubernetes/static-pod-resources/secrets/audit-webhook/kubeConfig"}
+
+	// auditWebhookArguments send the audit events in batches in the background, so that a slow webhook does not slow
+	// down the requests. The batch sizes are the kube-apiserver defaults.
+	auditWebhookArguments = map[string][]interface{}{
+		"audit-webhook-mode":           {"batch"},
+		"audit-webhook-version":        {"audit.k8s.io/v1"},
+		"audit-webhook-batch-max-size": {"400"},
+		"audit-webhook-batch-max-wait": {"30s"},
+	}
+)
+
+// ObserveAuditWebhook sends the audit events to the webhook of the kubeconfig secret named by the
+// AuditWebhookKubeConfigAnnotation of the cluster APIServer config, in addition to the audit log. The secret is synced
+// to the openshift-kube-apiserver namespace. While the secret is missing or invalid the existing config is kept.
+func ObserveAuditWebhook(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	defer func() {
+		paths := [][]string{auditWebhookConfigFilePath}
+		for argument := range auditWebhookArguments {
+			paths = append(paths, []string{"apiServerArguments", argument})
+		}
+		ret = configobserver.Pruned(ret, paths...)
+	}()
+
+	listers := genericListers.(configobservation.Listers)
+	apiServer, err := listers.APIServerLister().Get("cluster")
+	if err != nil && !apierrors.IsNotFound(err) {
+		return existingConfig, append(errs, err)
+	}
+	var secretName string
+	if apiServer != nil {
+		secretName = apiServer.Annotations[AuditWebhookKubeConfigAnnotation]
+	}
+
+	existingConfigFile, _, err := unstructured.NestedSlice(existingConfig, auditWebhookConfigFilePath...)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	existingWebhookConfigured := len(existingConfigFile) > 0
+
+	if len(secretName) == 0 {
+		// remove whatever was synced
+		if err := listers.ResourceSyncer().SyncSecret(
+			resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: auditWebhookSecretName},
+			resourcesynccontroller.ResourceLocation{},
+		); err != nil {
+			return existingConfig, append(errs, err)
+		}
+		if existingWebhookConfigured {
+			recorder.Eventf("ObserveAuditWebhook", "audit webhook disabled")
+		}
+		return map[string]interface{}{}, errs
+	}
+
+	secret, err := listers.ConfigSecretLister().Secrets(operatorclient.GlobalUserSpecifiedConfigNamespace).Get(secretName)
+	if err != nil {
+		return existingConfig, append(errs, fmt.Errorf("failed to get the audit webhook secret %s/%s: %w", operatorclient.GlobalUserSpecifiedConfigNamespace, secretName, err))
+	}
+	if err := validateAuditWebhookKubeConfig(secret.Data["kubeConfig"]); err != nil {
+		return existingConfig, append(errs, fmt.Errorf("the audit webhook secret %s/%s is invalid: %w", operatorclient.GlobalUserSpecifiedConfigNamespace, secretName, err))
+	}
+
+	if err := listers.ResourceSyncer().SyncSecret(
+		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: auditWebhookSecretName},
+		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalUserSpecifiedConfigNamespace, Name: secretName},
+	); err != nil {
+		return existingConfig, append(errs, err)
+	}
+
+	observedConfig := map[string]interface{}{}
+	if err := unstructured.SetNestedSlice(observedConfig, auditWebhookConfigFile, auditWebhookConfigFilePath...); err != nil {
+		return existingConfig, append(errs, err)
+	}
+	for argument, value := range auditWebhookArguments {
+		if err := unstructured.SetNestedSlice(observedConfig, value, "apiServerArguments", argument); err != nil {
+			return existingConfig, append(errs, err)
+		}
+	}
+	if !existingWebhookConfigured {
+		recorder.Eventf("ObserveAuditWebhook", "audit webhook enabled with the kubeconfig of secret %s/%s", operatorclient.GlobalUserSpecifiedConfigNamespace, secretName)
+	}
+	return observedConfig, errs
+}
+
+// validateAuditWebhookKubeConfig checks the kubeconfig parses and its current context points to a cluster with a
+// server URL.
+func validateAuditWebhookKubeConfig(kubeConfig []byte) error {
+	if len(kubeConfig) == 0 {
+		return fmt.Errorf("missing or empty 'kubeConfig' key")
+	}
+	config, err := clientcmd.Load(kubeConfig)
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	context, ok := config.Contexts[config.CurrentContext]
+	if !ok {
+		return fmt.Errorf("the current context %q is not in the kubeconfig", config.CurrentContext)
+	}
+	cluster, ok := config.Clusters[context.Cluster]
+	if !ok {
+		return fmt.Errorf("the cluster %q of the current context is not in the kubeconfig", context.Cluster)
+	}
+	server, err := url.Parse(cluster.Server)
+	if err != nil {
+		return fmt.Errorf("invalid server URL of cluster %q: %w", context.Cluster, err)
+	}
+	if len(server.Scheme) == 0 || len(server.Host) == 0 {
+		return fmt.Errorf("cluster %q has no server URL", context.Cluster)
+	}
+	return nil
+}
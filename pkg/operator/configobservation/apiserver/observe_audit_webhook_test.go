@@ -0,0 +1,137 @@
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelistersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+const auditWebhookKubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: audit-sink
+  cluster:
+    server: https://audit.example.com/events
+contexts:
+- name: audit-sink
+  context:
+    cluster: audit-sink
+    user: kube-apiserver
+current-context: audit-sink
+users:
+- name: kube-apiserver
+  user:
+    token: secret-token
+`
+
+func TestObserveAuditWebhook(t *testing.T) {
+	webhookConfig := map[string]interface{}{"apiServerArguments": map[string]interface{}{
+		"audit-webhook-config-file":    []interface{}{"/etc/kubernetes/static-pod-resources/secrets/audit-webhook/kubeConfig"},
+		"audit-webhook-mode":           []interface{}{"batch"},
+		"audit-webhook-version":        []interface{}{"audit.k8s.io/v1"},
+		"audit-webhook-batch-max-size": []interface{}{"400"},
+		"audit-webhook-batch-max-wait": []interface{}{"30s"},
+	}}
+
+	scenarios := []struct {
+		name           string
+		annotations    map[string]string
+		secretData     map[string][]byte
+		existingConfig map[string]interface{}
+		expectedConfig map[string]interface{}
+		expectedSynced map[string]string
+		expectErrs     bool
+		expectEvents   bool
+	}{
+		{
+			name:           "disabled",
+			existingConfig: map[string]interface{}{},
+			expectedConfig: map[string]interface{}{},
+			expectedSynced: map[string]string{"secret/audit-webhook.openshift-kube-apiserver": "DELETE"},
+		},
+		{
+			name:           "configured webhook",
+			annotations:    map[string]string{AuditWebhookKubeConfigAnnotation: "audit-sink"},
+			secretData:     map[string][]byte{"kubeConfig": []byte(auditWebhookKubeConfig)},
+			existingConfig: map[string]interface{}{},
+			expectedConfig: webhookConfig,
+			expectedSynced: map[string]string{"secret/audit-webhook.openshift-kube-apiserver": "secret/audit-sink.openshift-config"},
+			expectEvents:   true,
+		},
+		{
+			name:           "missing secret keeps the existing config",
+			annotations:    map[string]string{AuditWebhookKubeConfigAnnotation: "audit-sink"},
+			existingConfig: webhookConfig,
+			expectedConfig: webhookConfig,
+			expectedSynced: map[string]string{},
+			expectErrs:     true,
+		},
+		{
+			name:           "kubeconfig without server keeps the existing config",
+			annotations:    map[string]string{AuditWebhookKubeConfigAnnotation: "audit-sink"},
+			secretData:     map[string][]byte{"kubeConfig": []byte("apiVersion: v1\nkind: Config\nclusters:\n- name: audit-sink\n  cluster: {}\ncontexts:\n- name: audit-sink\n  context:\n    cluster: audit-sink\ncurrent-context: audit-sink\n")},
+			existingConfig: map[string]interface{}{},
+			expectedConfig: map[string]interface{}{},
+			expectedSynced: map[string]string{},
+			expectErrs:     true,
+		},
+		{
+			name:           "unparsable kubeconfig keeps the existing config",
+			annotations:    map[string]string{AuditWebhookKubeConfigAnnotation: "audit-sink"},
+			secretData:     map[string][]byte{"kubeConfig": []byte("clusters: [")},
+			existingConfig: webhookConfig,
+			expectedConfig: webhookConfig,
+			expectedSynced: map[string]string{},
+			expectErrs:     true,
+		},
+		{
+			name:           "disabling removes the synced secret",
+			existingConfig: webhookConfig,
+			expectedConfig: map[string]interface{}{},
+			expectedSynced: map[string]string{"secret/audit-webhook.openshift-kube-apiserver": "DELETE"},
+			expectEvents:   true,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			secretIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			if scenario.secretData != nil {
+				if err := secretIndexer.Add(&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-config", Name: "audit-sink"},
+					Data:       scenario.secretData,
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			synced := map[string]string{}
+			eventRecorder := events.NewInMemoryRecorder("")
+			listers := configobservation.Listers{
+				APIServerLister_:    apiServerListerWithAnnotations(t, scenario.annotations),
+				ConfigSecretLister_: corelistersv1.NewSecretLister(secretIndexer),
+				ResourceSync:        &mockResourceSyncer{t: t, synced: synced},
+			}
+
+			observedConfig, errs := ObserveAuditWebhook(listers, eventRecorder, scenario.existingConfig)
+			if scenario.expectErrs != (len(errs) > 0) {
+				t.Fatalf("expected errors %v, got %v", scenario.expectErrs, errs)
+			}
+			if !cmp.Equal(scenario.expectedConfig, observedConfig) {
+				t.Fatalf("unexpected configuration, diff = %v", cmp.Diff(scenario.expectedConfig, observedConfig))
+			}
+			if !cmp.Equal(scenario.expectedSynced, synced) {
+				t.Errorf("unexpected synced resources, diff = %v", cmp.Diff(scenario.expectedSynced, synced))
+			}
+			if emitted := len(eventRecorder.Events()) > 0; emitted != scenario.expectEvents {
+				t.Errorf("expected events %v, got %v", scenario.expectEvents, eventRecorder.Events())
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package apiserver
+
+import (
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+// EnableContentionProfilingAnnotation on the cluster APIServer config enables the lock contention profiling of the
+// kube-apiserver when set to exactly "true". It samples every blocking event of the process, so it costs more than
+// regular profiling and is only honoured while the EnableProfilingAnnotation enables the pprof handlers too.
+const EnableContentionProfilingAnnotation = "kubeapiserver.operator.openshift.io/enable-contention-profiling"
+
+var contentionProfilingPath = []string{"apiServerArguments", "contention-profiling"}
+
+// ObserveContentionProfiling sets contention-profiling, to "false" unless both the EnableContentionProfilingAnnotation
+// and the EnableProfilingAnnotation of the cluster APIServer config are "true". Like profiling, every observation warns
+// while it is enabled and turning it off again is announced. Contention profiling requested without profiling is
+// rejected with a warning and keeps it disabled. Any other annotation value is rejected with a warning and the
+// previously observed value is kept, enabled only while profiling is.
+func ObserveContentionProfiling(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	defer func() {
+		ret = configobserver.Pruned(ret, contentionProfilingPath)
+	}()
+
+	listers := genericListers.(configobservation.Listers)
+	apiServer, err := listers.APIServerLister().Get("cluster")
+	if err != nil && !apierrors.IsNotFound(err) {
+		return existingConfig, append(errs, err)
+	}
+
+	enabled := false
+	if err == nil {
+		switch value, ok := apiServer.Annotations[EnableContentionProfilingAnnotation]; {
+		case !ok:
+		case value != "true":
+			observedConfig := map[string]interface{}{}
+			if err := KeepPreviousValue(recorder, "ObserveContentionProfiling", EnableContentionProfilingAnnotation, value, fmt.Errorf("it must be \"true\" to enable contention profiling"), existingConfig, observedConfig, contentionProfilingPath); err != nil {
+				errs = append(errs, err)
+			}
+			// the previous value is only kept enabled while profiling still is
+			if previous, _, _ := unstructured.NestedStringSlice(observedConfig, contentionProfilingPath...); len(previous) > 0 && (previous[0] != "true" || apiServer.Annotations[EnableProfilingAnnotation] == "true") {
+				return observedConfig, errs
+			}
+		case apiServer.Annotations[EnableProfilingAnnotation] != "true":
+			recorder.Warningf("ObserveContentionProfiling", "Ignoring the %s annotation, contention profiling requires profiling to be enabled by the %s annotation", EnableContentionProfilingAnnotation, EnableProfilingAnnotation)
+		default:
+			enabled = true
+			recorder.Warningf("ObserveContentionProfiling", "Contention profiling of the kube-apiserver is enabled by the %s annotation, remove it once done debugging", EnableContentionProfilingAnnotation)
+		}
+	}
+
+	if current, _, _ := unstructured.NestedStringSlice(existingConfig, contentionProfilingPath...); !enabled && len(current) == 1 && current[0] == "true" {
+		recorder.Eventf("ObserveContentionProfiling", "Contention profiling of the kube-apiserver is disabled again")
+	}
+
+	value := "false"
+	if enabled {
+		value = "true"
+	}
+	observedConfig := map[string]interface{}{}
+	if err := unstructured.SetNestedStringSlice(observedConfig, []string{value}, contentionProfilingPath...); err != nil {
+		return existingConfig, append(errs, err)
+	}
+	return observedConfig, errs
+}
@@ -0,0 +1,108 @@
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestObserveContentionProfiling(t *testing.T) {
+	contentionProfiling := func(value string) map[string]interface{} {
+		return map[string]interface{}{"apiServerArguments": map[string]interface{}{"contention-profiling": []interface{}{value}}}
+	}
+
+	scenarios := []struct {
+		name           string
+		annotations    map[string]string
+		existingConfig map[string]interface{}
+		expectedConfig map[string]interface{}
+		expectedEvents []string
+	}{
+		{
+			name:           "disabled by default",
+			expectedConfig: contentionProfiling("false"),
+		},
+		{
+			name:           "disabled with profiling only",
+			annotations:    map[string]string{EnableProfilingAnnotation: "true"},
+			expectedConfig: contentionProfiling("false"),
+		},
+		{
+			name:           "enabled with profiling, with a warning",
+			annotations:    map[string]string{EnableProfilingAnnotation: "true", EnableContentionProfilingAnnotation: "true"},
+			expectedConfig: contentionProfiling("true"),
+			expectedEvents: []string{"Warning"},
+		},
+		{
+			name:           "still warned about while enabled",
+			annotations:    map[string]string{EnableProfilingAnnotation: "true", EnableContentionProfilingAnnotation: "true"},
+			existingConfig: contentionProfiling("true"),
+			expectedConfig: contentionProfiling("true"),
+			expectedEvents: []string{"Warning"},
+		},
+		{
+			name:           "ignored without profiling",
+			annotations:    map[string]string{EnableContentionProfilingAnnotation: "true"},
+			expectedConfig: contentionProfiling("false"),
+			expectedEvents: []string{"Warning"},
+		},
+		{
+			name:           "disabling profiling disables contention profiling again",
+			annotations:    map[string]string{EnableContentionProfilingAnnotation: "true"},
+			existingConfig: contentionProfiling("true"),
+			expectedConfig: contentionProfiling("false"),
+			expectedEvents: []string{"Warning", "Normal"},
+		},
+		{
+			name:           "invalid value keeps contention profiling disabled",
+			annotations:    map[string]string{EnableProfilingAnnotation: "true", EnableContentionProfilingAnnotation: "on"},
+			expectedConfig: contentionProfiling("false"),
+			expectedEvents: []string{"Warning"},
+		},
+		{
+			name:           "invalid value keeps contention profiling enabled",
+			annotations:    map[string]string{EnableProfilingAnnotation: "true", EnableContentionProfilingAnnotation: "on"},
+			existingConfig: contentionProfiling("true"),
+			expectedConfig: contentionProfiling("true"),
+			expectedEvents: []string{"Warning"},
+		},
+		{
+			name:           "invalid value does not keep contention profiling enabled without profiling",
+			annotations:    map[string]string{EnableContentionProfilingAnnotation: "on"},
+			existingConfig: contentionProfiling("true"),
+			expectedConfig: contentionProfiling("false"),
+			expectedEvents: []string{"Warning", "Normal"},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			eventRecorder := events.NewInMemoryRecorder("")
+			listers := configobservation.Listers{
+				APIServerLister_: apiServerListerWithAnnotations(t, scenario.annotations),
+			}
+			existingConfig := scenario.existingConfig
+			if existingConfig == nil {
+				existingConfig = map[string]interface{}{}
+			}
+
+			observedConfig, errs := ObserveContentionProfiling(listers, eventRecorder, existingConfig)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if !cmp.Equal(scenario.expectedConfig, observedConfig) {
+				t.Fatalf("unexpected configuration, diff = %v", cmp.Diff(scenario.expectedConfig, observedConfig))
+			}
+			var eventTypes []string
+			for _, ev := range eventRecorder.Events() {
+				eventTypes = append(eventTypes, ev.Type)
+			}
+			if !cmp.Equal(scenario.expectedEvents, eventTypes) {
+				t.Fatalf("unexpected events, diff = %v", cmp.Diff(scenario.expectedEvents, eventTypes))
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package apiserver
+
+import (
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+const (
+	// EventTTLAnnotation on the cluster APIServer config sets event-ttl, how long events are retained, as a duration,
+	// e.g. "1h". Without it the default config value of 3h applies.
+	EventTTLAnnotation = "kubeapiserver.operator.openshift.io/event-ttl"
+
+	// maxEventTTL caps the event retention, every event is kept in etcd for that long and heavy event volumes would
+	// otherwise grow the etcd database without bound.
+	maxEventTTL = 24 * time.Hour
+)
+
+var eventTTLPath = []string{"apiServerArguments", "event-ttl"}
+
+// ObserveEventTTL sets the event-ttl argument from the EventTTLAnnotation of the cluster APIServer config. A value
+// longer than maxEventTTL is clamped to it with a warning. A value that cannot be parsed or is not positive is
+// rejected with a warning and the previously observed value is kept.
+func ObserveEventTTL(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	defer func() {
+		ret = configobserver.Pruned(ret, eventTTLPath)
+	}()
+
+	listers := genericListers.(configobservation.Listers)
+	apiServer, err := listers.APIServerLister().Get("cluster")
+	if apierrors.IsNotFound(err) {
+		return map[string]interface{}{}, errs
+	}
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+
+	value, ok := apiServer.Annotations[EventTTLAnnotation]
+	if !ok {
+		return map[string]interface{}{}, errs
+	}
+	ttl, err := time.ParseDuration(value)
+	if err == nil && ttl <= 0 {
+		err = fmt.Errorf("must be positive")
+	}
+	if err != nil {
+		observedConfig := map[string]interface{}{}
+		if err := KeepPreviousValue(recorder, "ObserveEventTTL", EventTTLAnnotation, value, err, existingConfig, observedConfig, eventTTLPath); err != nil {
+			errs = append(errs, err)
+		}
+		return observedConfig, errs
+	}
+	if ttl > maxEventTTL {
+		recorder.Warningf("ObserveEventTTL", "The %s annotation value %q exceeds the maximum of %v, using %v", EventTTLAnnotation, value, maxEventTTL, maxEventTTL)
+		ttl = maxEventTTL
+	}
+
+	observedConfig := map[string]interface{}{}
+	if err := unstructured.SetNestedStringSlice(observedConfig, []string{ttl.String()}, eventTTLPath...); err != nil {
+		return existingConfig, append(errs, err)
+	}
+	return observedConfig, errs
+}
@@ -0,0 +1,85 @@
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestObserveEventTTL(t *testing.T) {
+	scenarios := []struct {
+		name            string
+		annotations     map[string]string
+		existingConfig  map[string]interface{}
+		expectedConfig  map[string]interface{}
+		expectedWarning bool
+	}{
+		{
+			name:           "not set: the default applies",
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name:           "valid",
+			annotations:    map[string]string{EventTTLAnnotation: "90m"},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{"event-ttl": []interface{}{"1h30m0s"}}},
+		},
+		{
+			name:           "the maximum",
+			annotations:    map[string]string{EventTTLAnnotation: "24h"},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{"event-ttl": []interface{}{"24h0m0s"}}},
+		},
+		{
+			name:            "over the cap is clamped",
+			annotations:     map[string]string{EventTTLAnnotation: "168h"},
+			expectedConfig:  map[string]interface{}{"apiServerArguments": map[string]interface{}{"event-ttl": []interface{}{"24h0m0s"}}},
+			expectedWarning: true,
+		},
+		{
+			name:            "zero keeps the previous value",
+			annotations:     map[string]string{EventTTLAnnotation: "0s"},
+			existingConfig:  map[string]interface{}{"apiServerArguments": map[string]interface{}{"event-ttl": []interface{}{"1h0m0s"}}},
+			expectedConfig:  map[string]interface{}{"apiServerArguments": map[string]interface{}{"event-ttl": []interface{}{"1h0m0s"}}},
+			expectedWarning: true,
+		},
+		{
+			name:            "negative without a previous value",
+			annotations:     map[string]string{EventTTLAnnotation: "-1h"},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+		{
+			name:            "unparsable keeps the previous value",
+			annotations:     map[string]string{EventTTLAnnotation: "3 hours"},
+			existingConfig:  map[string]interface{}{"apiServerArguments": map[string]interface{}{"event-ttl": []interface{}{"2h0m0s"}}},
+			expectedConfig:  map[string]interface{}{"apiServerArguments": map[string]interface{}{"event-ttl": []interface{}{"2h0m0s"}}},
+			expectedWarning: true,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			eventRecorder := events.NewInMemoryRecorder("")
+			listers := configobservation.Listers{
+				APIServerLister_: apiServerListerWithAnnotations(t, scenario.annotations),
+			}
+			existingConfig := scenario.existingConfig
+			if existingConfig == nil {
+				existingConfig = map[string]interface{}{}
+			}
+
+			observedConfig, errs := ObserveEventTTL(listers, eventRecorder, existingConfig)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if !cmp.Equal(scenario.expectedConfig, observedConfig) {
+				t.Fatalf("unexpected configuration, diff = %v", cmp.Diff(scenario.expectedConfig, observedConfig))
+			}
+			if warned := len(eventRecorder.Events()) > 0; warned != scenario.expectedWarning {
+				t.Fatalf("expected warning %v, got events %v", scenario.expectedWarning, eventRecorder.Events())
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package apiserver
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+// GoawayChanceAnnotation on the cluster APIServer config sets goaway-chance, the probability a HTTP/2 client is sent
+// a GOAWAY to reconnect, possibly to another kube-apiserver, e.g. "0.001". It spreads the clients over the
+// kube-apiservers again after a restart.
+const GoawayChanceAnnotation = "kubeapiserver.operator.openshift.io/goaway-chance"
+
+// maxGoawayChance is the kube-apiserver bound.
+const maxGoawayChance = 0.02
+
+var goawayChancePath = []string{"apiServerArguments", "goaway-chance"}
+
+// ObserveGoawayChance sets goaway-chance from the GoawayChanceAnnotation of the cluster APIServer config. A value out
+// of [0, 0.02] is clamped with a warning, a value that is not a number is rejected with a warning and the previously
+// observed value is kept. Without the annotation the goaway-chance of 0 from the default config applies, no GOAWAY is
+// sent.
+func ObserveGoawayChance(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	defer func() {
+		ret = configobserver.Pruned(ret, goawayChancePath)
+	}()
+
+	listers := genericListers.(configobservation.Listers)
+	apiServer, err := listers.APIServerLister().Get("cluster")
+	if apierrors.IsNotFound(err) {
+		return map[string]interface{}{}, errs
+	}
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+	value, ok := apiServer.Annotations[GoawayChanceAnnotation]
+	if !ok {
+		return map[string]interface{}{}, errs
+	}
+
+	chance, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(chance) {
+		observedConfig := map[string]interface{}{}
+		if err := KeepPreviousValue(recorder, "ObserveGoawayChance", GoawayChanceAnnotation, value, fmt.Errorf("must be a number between 0 and %v", maxGoawayChance), existingConfig, observedConfig, goawayChancePath); err != nil {
+			errs = append(errs, err)
+		}
+		return observedConfig, errs
+	}
+	if clamped := math.Max(0, math.Min(chance, maxGoawayChance)); clamped != chance {
+		recorder.Warningf("ObserveGoawayChance", "The %s annotation value %q is out of [0, %v], using %v", GoawayChanceAnnotation, value, maxGoawayChance, clamped)
+		chance = clamped
+	}
+
+	observedConfig := map[string]interface{}{}
+	if err := unstructured.SetNestedStringSlice(observedConfig, []string{strconv.FormatFloat(chance, 'f', -1, 64)}, goawayChancePath...); err != nil {
+		return existingConfig, append(errs, err)
+	}
+	return observedConfig, errs
+}
@@ -0,0 +1,100 @@
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestObserveGoawayChance(t *testing.T) {
+	goawayChance := func(value string) map[string]interface{} {
+		return map[string]interface{}{"apiServerArguments": map[string]interface{}{"goaway-chance": []interface{}{value}}}
+	}
+
+	scenarios := []struct {
+		name            string
+		annotations     map[string]string
+		existingConfig  map[string]interface{}
+		expectedConfig  map[string]interface{}
+		expectedWarning bool
+	}{
+		{
+			name:           "not set: the default config applies",
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name:           "valid",
+			annotations:    map[string]string{GoawayChanceAnnotation: "0.001"},
+			expectedConfig: goawayChance("0.001"),
+		},
+		{
+			name:           "upper bound",
+			annotations:    map[string]string{GoawayChanceAnnotation: "0.02"},
+			expectedConfig: goawayChance("0.02"),
+		},
+		{
+			name:           "zero",
+			annotations:    map[string]string{GoawayChanceAnnotation: "0"},
+			expectedConfig: goawayChance("0"),
+		},
+		{
+			name:            "too high is clamped",
+			annotations:     map[string]string{GoawayChanceAnnotation: "0.5"},
+			expectedConfig:  goawayChance("0.02"),
+			expectedWarning: true,
+		},
+		{
+			name:            "negative is clamped",
+			annotations:     map[string]string{GoawayChanceAnnotation: "-0.01"},
+			expectedConfig:  goawayChance("0"),
+			expectedWarning: true,
+		},
+		{
+			name:            "not a number",
+			annotations:     map[string]string{GoawayChanceAnnotation: "often"},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+		{
+			name:            "NaN",
+			annotations:     map[string]string{GoawayChanceAnnotation: "NaN"},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+		{
+			name:            "not a number keeps the previous value",
+			annotations:     map[string]string{GoawayChanceAnnotation: "often"},
+			existingConfig:  goawayChance("0.001"),
+			expectedConfig:  goawayChance("0.001"),
+			expectedWarning: true,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			eventRecorder := events.NewInMemoryRecorder("")
+			listers := configobservation.Listers{
+				APIServerLister_: apiServerListerWithAnnotations(t, scenario.annotations),
+			}
+
+			existingConfig := scenario.existingConfig
+			if existingConfig == nil {
+				existingConfig = map[string]interface{}{}
+			}
+
+			observedConfig, errs := ObserveGoawayChance(listers, eventRecorder, existingConfig)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if !cmp.Equal(scenario.expectedConfig, observedConfig) {
+				t.Fatalf("unexpected configuration, diff = %v", cmp.Diff(scenario.expectedConfig, observedConfig))
+			}
+			if warned := len(eventRecorder.Events()) > 0; warned != scenario.expectedWarning {
+				t.Fatalf("expected warning %v, got events %v", scenario.expectedWarning, eventRecorder.Events())
+			}
+		})
+	}
+}
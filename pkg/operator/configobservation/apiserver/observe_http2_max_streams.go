@@ -0,0 +1,76 @@
+package apiserver
+
+import (
+	"fmt"
+	"strconv"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+const (
+	// HTTP2MaxStreamsPerConnectionAnnotation on the cluster APIServer config sets http2-max-streams-per-connection,
+	// the number of concurrent HTTP/2 streams a client may open on one connection. Without it the default config value
+	// of 2000 applies.
+	HTTP2MaxStreamsPerConnectionAnnotation = "kubeapiserver.operator.openshift.io/http2-max-streams-per-connection"
+
+	// maxHTTP2MaxStreamsPerConnection bounds the streams of a connection, every stream holds buffers in the
+	// kube-apiserver and a single client opening that many already requires a lot of memory.
+	maxHTTP2MaxStreamsPerConnection = 10000
+)
+
+var http2MaxStreamsPerConnectionPath = []string{"apiServerArguments", "http2-max-streams-per-connection"}
+
+// ObserveHTTP2MaxStreamsPerConnection sets the http2-max-streams-per-connection argument from the
+// HTTP2MaxStreamsPerConnectionAnnotation of the cluster APIServer config. A value that is not an integer between 1
+// and maxHTTP2MaxStreamsPerConnection is rejected with a warning and the previously observed value is kept.
+func ObserveHTTP2MaxStreamsPerConnection(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	defer func() {
+		ret = configobserver.Pruned(ret, http2MaxStreamsPerConnectionPath)
+	}()
+
+	listers := genericListers.(configobservation.Listers)
+	apiServer, err := listers.APIServerLister().Get("cluster")
+	if apierrors.IsNotFound(err) {
+		return map[string]interface{}{}, errs
+	}
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+
+	value, ok := apiServer.Annotations[HTTP2MaxStreamsPerConnectionAnnotation]
+	if !ok {
+		return map[string]interface{}{}, errs
+	}
+	n, err := parseHTTP2MaxStreamsPerConnection(value)
+	if err != nil {
+		observedConfig := map[string]interface{}{}
+		if err := KeepPreviousValue(recorder, "ObserveHTTP2MaxStreamsPerConnection", HTTP2MaxStreamsPerConnectionAnnotation, value, err, existingConfig, observedConfig, http2MaxStreamsPerConnectionPath); err != nil {
+			errs = append(errs, err)
+		}
+		return observedConfig, errs
+	}
+
+	observedConfig := map[string]interface{}{}
+	if err := unstructured.SetNestedStringSlice(observedConfig, []string{strconv.Itoa(n)}, http2MaxStreamsPerConnectionPath...); err != nil {
+		return existingConfig, append(errs, err)
+	}
+	return observedConfig, errs
+}
+
+// parseHTTP2MaxStreamsPerConnection accepts integers between 1 and maxHTTP2MaxStreamsPerConnection. 0 would mean the
+// Go default of 250 streams to the kube-apiserver, it is not accepted to not hide that.
+func parseHTTP2MaxStreamsPerConnection(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("must be an integer")
+	}
+	if n < 1 || n > maxHTTP2MaxStreamsPerConnection {
+		return 0, fmt.Errorf("must be between 1 and %d", maxHTTP2MaxStreamsPerConnection)
+	}
+	return n, nil
+}
@@ -0,0 +1,73 @@
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestObserveHTTP2MaxStreamsPerConnection(t *testing.T) {
+	scenarios := []struct {
+		name            string
+		annotations     map[string]string
+		existingConfig  map[string]interface{}
+		expectedConfig  map[string]interface{}
+		expectedWarning bool
+	}{
+		{
+			name:           "not set: the default applies",
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name:           "valid",
+			annotations:    map[string]string{HTTP2MaxStreamsPerConnectionAnnotation: "1000"},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{"http2-max-streams-per-connection": []interface{}{"1000"}}},
+		},
+		{
+			name:            "above the upper bound keeps the previous value",
+			annotations:     map[string]string{HTTP2MaxStreamsPerConnectionAnnotation: "100000"},
+			existingConfig:  map[string]interface{}{"apiServerArguments": map[string]interface{}{"http2-max-streams-per-connection": []interface{}{"1000"}}},
+			expectedConfig:  map[string]interface{}{"apiServerArguments": map[string]interface{}{"http2-max-streams-per-connection": []interface{}{"1000"}}},
+			expectedWarning: true,
+		},
+		{
+			name:            "zero is rejected",
+			annotations:     map[string]string{HTTP2MaxStreamsPerConnectionAnnotation: "0"},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+		{
+			name:            "not an integer",
+			annotations:     map[string]string{HTTP2MaxStreamsPerConnectionAnnotation: "1e3"},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			eventRecorder := events.NewInMemoryRecorder("")
+			listers := configobservation.Listers{
+				APIServerLister_: apiServerListerWithAnnotations(t, scenario.annotations),
+			}
+			existingConfig := scenario.existingConfig
+			if existingConfig == nil {
+				existingConfig = map[string]interface{}{}
+			}
+
+			observedConfig, errs := ObserveHTTP2MaxStreamsPerConnection(listers, eventRecorder, existingConfig)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if !cmp.Equal(scenario.expectedConfig, observedConfig) {
+				t.Fatalf("unexpected configuration, diff = %v", cmp.Diff(scenario.expectedConfig, observedConfig))
+			}
+			if warned := len(eventRecorder.Events()) > 0; warned != scenario.expectedWarning {
+				t.Fatalf("expected warning %v, got events %v", scenario.expectedWarning, eventRecorder.Events())
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package apiserver
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+// KubeletPreferredAddressTypesAnnotation on the cluster APIServer config sets kubelet-preferred-address-types, the
+// comma separated node address types the kube-apiserver tries in order to reach the kubelets, e.g.
+// "InternalIP,InternalDNS".
+const KubeletPreferredAddressTypesAnnotation = "kubeapiserver.operator.openshift.io/kubelet-preferred-address-types"
+
+var (
+	kubeletPreferredAddressTypesPath = []string{"apiServerArguments", "kubelet-preferred-address-types"}
+
+	knownNodeAddressTypes = sets.NewString(
+		string(corev1.NodeHostName),
+		string(corev1.NodeInternalIP),
+		string(corev1.NodeInternalDNS),
+		string(corev1.NodeExternalIP),
+		string(corev1.NodeExternalDNS),
+	)
+)
+
+// ObserveKubeletPreferredAddressTypes sets kubelet-preferred-address-types from the
+// KubeletPreferredAddressTypesAnnotation of the cluster APIServer config, keeping its order. The default of
+// bindata/assets/config/defaultconfig.yaml, InternalIP only, applies when it is not set. A list that is empty, repeats
+// an address type or names an unknown one is rejected with a warning and the previously observed value, if any, is
+// kept.
+func ObserveKubeletPreferredAddressTypes(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	defer func() {
+		ret = configobserver.Pruned(ret, kubeletPreferredAddressTypesPath)
+	}()
+
+	listers := genericListers.(configobservation.Listers)
+	apiServer, err := listers.APIServerLister().Get("cluster")
+	if apierrors.IsNotFound(err) {
+		return map[string]interface{}{}, errs
+	}
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+	value, ok := apiServer.Annotations[KubeletPreferredAddressTypesAnnotation]
+	if !ok {
+		return map[string]interface{}{}, errs
+	}
+
+	addressTypes, err := parseNodeAddressTypes(value)
+	if err != nil {
+		observedConfig := map[string]interface{}{}
+		if err := KeepPreviousValue(recorder, "ObserveKubeletPreferredAddressTypes", KubeletPreferredAddressTypesAnnotation, value, err, existingConfig, observedConfig, kubeletPreferredAddressTypesPath); err != nil {
+			errs = append(errs, err)
+		}
+		return observedConfig, errs
+	}
+
+	observedConfig := map[string]interface{}{}
+	if err := unstructured.SetNestedStringSlice(observedConfig, addressTypes, kubeletPreferredAddressTypesPath...); err != nil {
+		return existingConfig, append(errs, err)
+	}
+	return observedConfig, errs
+}
+
+// parseNodeAddressTypes returns the address types of the comma separated list in order.
+func parseNodeAddressTypes(value string) ([]string, error) {
+	var addressTypes []string
+	seen := sets.NewString()
+	for _, addressType := range strings.Split(value, ",") {
+		addressType = strings.TrimSpace(addressType)
+		if !knownNodeAddressTypes.Has(addressType) {
+			return nil, fmt.Errorf("unknown address type %q, must be one of %s", addressType, strings.Join(knownNodeAddressTypes.List(), ", "))
+		}
+		if seen.Has(addressType) {
+			return nil, fmt.Errorf("address type %q is listed more than once", addressType)
+		}
+		seen.Insert(addressType)
+		addressTypes = append(addressTypes, addressType)
+	}
+	return addressTypes, nil
+}
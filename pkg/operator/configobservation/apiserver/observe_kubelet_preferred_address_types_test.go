@@ -0,0 +1,89 @@
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestObserveKubeletPreferredAddressTypes(t *testing.T) {
+	addressTypes := func(values ...interface{}) map[string]interface{} {
+		return map[string]interface{}{"apiServerArguments": map[string]interface{}{"kubelet-preferred-address-types": values}}
+	}
+
+	scenarios := []struct {
+		name            string
+		annotations     map[string]string
+		existingConfig  map[string]interface{}
+		expectedConfig  map[string]interface{}
+		expectedWarning bool
+	}{
+		{
+			name:           "not set: the default applies",
+			existingConfig: addressTypes("InternalDNS", "InternalIP"),
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name:           "reordered",
+			annotations:    map[string]string{KubeletPreferredAddressTypesAnnotation: "InternalDNS, InternalIP,Hostname"},
+			expectedConfig: addressTypes("InternalDNS", "InternalIP", "Hostname"),
+		},
+		{
+			name:           "single",
+			annotations:    map[string]string{KubeletPreferredAddressTypesAnnotation: "ExternalIP"},
+			expectedConfig: addressTypes("ExternalIP"),
+		},
+		{
+			name:            "invalid address type keeps the previous value",
+			annotations:     map[string]string{KubeletPreferredAddressTypesAnnotation: "InternalIP,PublicIP"},
+			existingConfig:  addressTypes("InternalDNS", "InternalIP"),
+			expectedConfig:  addressTypes("InternalDNS", "InternalIP"),
+			expectedWarning: true,
+		},
+		{
+			name:            "invalid address type without previous value",
+			annotations:     map[string]string{KubeletPreferredAddressTypesAnnotation: "internalip"},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+		{
+			name:            "repeated address type",
+			annotations:     map[string]string{KubeletPreferredAddressTypesAnnotation: "InternalIP,Hostname,InternalIP"},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+		{
+			name:            "empty",
+			annotations:     map[string]string{KubeletPreferredAddressTypesAnnotation: ""},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			eventRecorder := events.NewInMemoryRecorder("")
+			listers := configobservation.Listers{
+				APIServerLister_: apiServerListerWithAnnotations(t, scenario.annotations),
+			}
+			existingConfig := scenario.existingConfig
+			if existingConfig == nil {
+				existingConfig = map[string]interface{}{}
+			}
+
+			observedConfig, errs := ObserveKubeletPreferredAddressTypes(listers, eventRecorder, existingConfig)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if !cmp.Equal(scenario.expectedConfig, observedConfig) {
+				t.Fatalf("unexpected configuration, diff = %v", cmp.Diff(scenario.expectedConfig, observedConfig))
+			}
+			if warned := len(eventRecorder.Events()) > 0; warned != scenario.expectedWarning {
+				t.Fatalf("expected warning %v, got events %v", scenario.expectedWarning, eventRecorder.Events())
+			}
+		})
+	}
+}
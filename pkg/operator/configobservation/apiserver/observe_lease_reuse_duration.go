@@ -0,0 +1,82 @@
+package apiserver
+
+import (
+	"fmt"
+	"strconv"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+const (
+	// LeaseReuseDurationAnnotation on the cluster APIServer config sets lease-reuse-duration-seconds, how long the
+	// kube-apiserver attaches the objects with a TTL, like events, to the same etcd lease. Without it the kube-apiserver
+	// default of 60 seconds applies. It is only honoured while the StorageLeaseTuning feature gate is enabled.
+	LeaseReuseDurationAnnotation = "kubeapiserver.operator.openshift.io/lease-reuse-duration-seconds"
+
+	// StorageLeaseTuningFeatureGate enables the storage lease tunables, an advanced setting for large clusters.
+	StorageLeaseTuningFeatureGate = "StorageLeaseTuning"
+
+	// minLeaseReuseDurationSeconds keeps the number of etcd leases, and of lease grants, bounded. A short reuse
+	// duration grants a lease for almost every event.
+	minLeaseReuseDurationSeconds = 10
+	// maxLeaseReuseDurationSeconds bounds how long an object outlives its TTL, a lease is granted for the TTL plus the
+	// reuse duration.
+	maxLeaseReuseDurationSeconds = 300
+)
+
+var leaseReuseDurationPath = []string{"apiServerArguments", "lease-reuse-duration-seconds"}
+
+// ObserveLeaseReuseDuration sets the lease-reuse-duration-seconds argument from the LeaseReuseDurationAnnotation of the
+// cluster APIServer config while the StorageLeaseTuning feature gate is enabled. A value that is not a number of
+// seconds between minLeaseReuseDurationSeconds and maxLeaseReuseDurationSeconds is rejected with a warning and the
+// previously observed value is kept. With the feature gate disabled the annotation is ignored with a warning.
+func ObserveLeaseReuseDuration(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	defer func() {
+		ret = configobserver.Pruned(ret, leaseReuseDurationPath)
+	}()
+
+	listers := genericListers.(configobservation.Listers)
+	apiServer, err := listers.APIServerLister().Get("cluster")
+	if apierrors.IsNotFound(err) {
+		return map[string]interface{}{}, errs
+	}
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+
+	value, ok := apiServer.Annotations[LeaseReuseDurationAnnotation]
+	if !ok {
+		return map[string]interface{}{}, errs
+	}
+	enabledFeatures, _, _, err := listers.FeatureGates().ClusterFeatures()
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+	if !enabledFeatures.Has(StorageLeaseTuningFeatureGate) {
+		recorder.Warningf("ObserveLeaseReuseDuration", "Ignoring the %s annotation, the %s feature gate is disabled", LeaseReuseDurationAnnotation, StorageLeaseTuningFeatureGate)
+		return map[string]interface{}{}, errs
+	}
+
+	seconds, err := strconv.Atoi(value)
+	if err == nil && (seconds < minLeaseReuseDurationSeconds || seconds > maxLeaseReuseDurationSeconds) {
+		err = fmt.Errorf("must be between %d and %d", minLeaseReuseDurationSeconds, maxLeaseReuseDurationSeconds)
+	}
+	if err != nil {
+		observedConfig := map[string]interface{}{}
+		if err := KeepPreviousValue(recorder, "ObserveLeaseReuseDuration", LeaseReuseDurationAnnotation, value, err, existingConfig, observedConfig, leaseReuseDurationPath); err != nil {
+			errs = append(errs, err)
+		}
+		return observedConfig, errs
+	}
+
+	observedConfig := map[string]interface{}{}
+	if err := unstructured.SetNestedStringSlice(observedConfig, []string{strconv.Itoa(seconds)}, leaseReuseDurationPath...); err != nil {
+		return existingConfig, append(errs, err)
+	}
+	return observedConfig, errs
+}
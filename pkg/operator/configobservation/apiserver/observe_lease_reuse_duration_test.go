@@ -0,0 +1,114 @@
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestObserveLeaseReuseDuration(t *testing.T) {
+	gateEnabled := &configv1.FeatureGate{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: configv1.FeatureGateSpec{FeatureGateSelection: configv1.FeatureGateSelection{
+			FeatureSet:      configv1.CustomNoUpgrade,
+			CustomNoUpgrade: &configv1.CustomFeatureGates{Enabled: []string{StorageLeaseTuningFeatureGate}},
+		}},
+	}
+	observed := func(seconds string) map[string]interface{} {
+		return map[string]interface{}{"apiServerArguments": map[string]interface{}{"lease-reuse-duration-seconds": []interface{}{seconds}}}
+	}
+
+	scenarios := []struct {
+		name            string
+		featureGate     *configv1.FeatureGate
+		annotations     map[string]string
+		existingConfig  map[string]interface{}
+		expectedConfig  map[string]interface{}
+		expectedWarning bool
+	}{
+		{
+			name:           "not set: the default applies",
+			featureGate:    gateEnabled,
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name:           "valid override",
+			featureGate:    gateEnabled,
+			annotations:    map[string]string{LeaseReuseDurationAnnotation: "120"},
+			expectedConfig: observed("120"),
+		},
+		{
+			name:           "the minimum",
+			featureGate:    gateEnabled,
+			annotations:    map[string]string{LeaseReuseDurationAnnotation: "10"},
+			expectedConfig: observed("10"),
+		},
+		{
+			name:            "ignored with the feature gate disabled",
+			annotations:     map[string]string{LeaseReuseDurationAnnotation: "120"},
+			existingConfig:  observed("120"),
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+		{
+			name:            "below the minimum keeps the previous value",
+			featureGate:     gateEnabled,
+			annotations:     map[string]string{LeaseReuseDurationAnnotation: "1"},
+			existingConfig:  observed("120"),
+			expectedConfig:  observed("120"),
+			expectedWarning: true,
+		},
+		{
+			name:            "above the maximum without a previous value",
+			featureGate:     gateEnabled,
+			annotations:     map[string]string{LeaseReuseDurationAnnotation: "3600"},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+		{
+			name:            "unparsable keeps the previous value",
+			featureGate:     gateEnabled,
+			annotations:     map[string]string{LeaseReuseDurationAnnotation: "2m"},
+			existingConfig:  observed("60"),
+			expectedConfig:  observed("60"),
+			expectedWarning: true,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			featureGateIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if scenario.featureGate != nil {
+				require.NoError(t, featureGateIndexer.Add(scenario.featureGate))
+			}
+			eventRecorder := events.NewInMemoryRecorder("")
+			listers := configobservation.Listers{
+				APIServerLister_:   apiServerListerWithAnnotations(t, scenario.annotations),
+				FeatureGateLister_: configlistersv1.NewFeatureGateLister(featureGateIndexer),
+			}
+			existingConfig := scenario.existingConfig
+			if existingConfig == nil {
+				existingConfig = map[string]interface{}{}
+			}
+
+			observedConfig, errs := ObserveLeaseReuseDuration(listers, eventRecorder, existingConfig)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if !cmp.Equal(scenario.expectedConfig, observedConfig) {
+				t.Fatalf("unexpected configuration, diff = %v", cmp.Diff(scenario.expectedConfig, observedConfig))
+			}
+			if warned := len(eventRecorder.Events()) > 0; warned != scenario.expectedWarning {
+				t.Fatalf("expected warning %v, got events %v", scenario.expectedWarning, eventRecorder.Events())
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package apiserver
+
+import (
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+const (
+	// LoggingFormatAnnotation on the cluster APIServer config sets logging-format, "text" or "json". Without it the
+	// kube-apiserver default, text, applies.
+	LoggingFormatAnnotation = "kubeapiserver.operator.openshift.io/logging-format"
+
+	defaultLoggingFormat = "text"
+)
+
+var loggingFormatPath = []string{"apiServerArguments", "logging-format"}
+
+// ObserveLoggingFormat sets the logging-format argument from the LoggingFormatAnnotation of the cluster APIServer
+// config. An invalid value is rejected with a warning and the previously observed value is kept. A change of the
+// effective format is reported in an event, the log pipelines parsing the kube-apiserver logs have to follow it.
+func ObserveLoggingFormat(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	defer func() {
+		ret = configobserver.Pruned(ret, loggingFormatPath)
+	}()
+
+	currentFormat := defaultLoggingFormat
+	current, _, err := unstructured.NestedStringSlice(existingConfig, loggingFormatPath...)
+	if err != nil {
+		errs = append(errs, err)
+		// keep going on read error from existing config
+	}
+	if len(current) > 0 {
+		currentFormat = current[0]
+	}
+
+	listers := genericListers.(configobservation.Listers)
+	apiServer, err := listers.APIServerLister().Get("cluster")
+	if err != nil && !apierrors.IsNotFound(err) {
+		return existingConfig, append(errs, err)
+	}
+
+	observedConfig := map[string]interface{}{}
+	format := defaultLoggingFormat
+	if err == nil {
+		if value, ok := apiServer.Annotations[LoggingFormatAnnotation]; ok {
+			if err := validateLoggingFormat(value); err != nil {
+				if err := KeepPreviousValue(recorder, "ObserveLoggingFormat", LoggingFormatAnnotation, value, err, existingConfig, observedConfig, loggingFormatPath); err != nil {
+					errs = append(errs, err)
+				}
+				return observedConfig, errs
+			}
+			format = value
+			if err := unstructured.SetNestedStringSlice(observedConfig, []string{format}, loggingFormatPath...); err != nil {
+				return existingConfig, append(errs, err)
+			}
+		}
+	}
+
+	if format != currentFormat {
+		recorder.Eventf("ObserveLoggingFormat", "The kube-apiserver logging-format changed from %s to %s", currentFormat, format)
+	}
+	return observedConfig, errs
+}
+
+func validateLoggingFormat(format string) error {
+	if format != "text" && format != "json" {
+		return fmt.Errorf("must be text or json")
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestObserveLoggingFormat(t *testing.T) {
+	observed := func(format string) map[string]interface{} {
+		return map[string]interface{}{"apiServerArguments": map[string]interface{}{"logging-format": []interface{}{format}}}
+	}
+
+	scenarios := []struct {
+		name           string
+		annotations    map[string]string
+		existingConfig map[string]interface{}
+		expectedConfig map[string]interface{}
+		expectedEvents []string
+	}{
+		{
+			name:           "not set: the default applies",
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name:           "json",
+			annotations:    map[string]string{LoggingFormatAnnotation: "json"},
+			expectedConfig: observed("json"),
+			expectedEvents: []string{"The kube-apiserver logging-format changed from text to json"},
+		},
+		{
+			name:           "json unchanged",
+			annotations:    map[string]string{LoggingFormatAnnotation: "json"},
+			existingConfig: observed("json"),
+			expectedConfig: observed("json"),
+		},
+		{
+			name:           "text",
+			annotations:    map[string]string{LoggingFormatAnnotation: "text"},
+			existingConfig: observed("json"),
+			expectedConfig: observed("text"),
+			expectedEvents: []string{"The kube-apiserver logging-format changed from json to text"},
+		},
+		{
+			name:           "explicit text is the default",
+			annotations:    map[string]string{LoggingFormatAnnotation: "text"},
+			expectedConfig: observed("text"),
+		},
+		{
+			name:           "back to the default",
+			existingConfig: observed("json"),
+			expectedConfig: map[string]interface{}{},
+			expectedEvents: []string{"The kube-apiserver logging-format changed from json to text"},
+		},
+		{
+			name:           "invalid keeps the previous value",
+			annotations:    map[string]string{LoggingFormatAnnotation: "JSON"},
+			existingConfig: observed("json"),
+			expectedConfig: observed("json"),
+			expectedEvents: []string{`Rejecting invalid kubeapiserver.operator.openshift.io/logging-format annotation value "JSON", keeping the previous value: must be text or json`},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			eventRecorder := events.NewInMemoryRecorder("")
+			listers := configobservation.Listers{
+				APIServerLister_: apiServerListerWithAnnotations(t, scenario.annotations),
+			}
+			existingConfig := scenario.existingConfig
+			if existingConfig == nil {
+				existingConfig = map[string]interface{}{}
+			}
+
+			observedConfig, errs := ObserveLoggingFormat(listers, eventRecorder, existingConfig)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if !cmp.Equal(scenario.expectedConfig, observedConfig) {
+				t.Fatalf("unexpected configuration, diff = %v", cmp.Diff(scenario.expectedConfig, observedConfig))
+			}
+			var messages []string
+			for _, event := range eventRecorder.Events() {
+				messages = append(messages, event.Message)
+			}
+			if !cmp.Equal(scenario.expectedEvents, messages) {
+				t.Fatalf("unexpected events, diff = %v", cmp.Diff(scenario.expectedEvents, messages))
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package apiserver
+
+import (
+	"fmt"
+	"strconv"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+// MaxConnectionBytesPerSecAnnotation on the cluster APIServer config sets max-connection-bytes-per-sec, the bandwidth
+// each client connection is throttled to, 0 for unlimited. Without it the kube-apiserver default, unlimited, applies.
+const MaxConnectionBytesPerSecAnnotation = "kubeapiserver.operator.openshift.io/max-connection-bytes-per-sec"
+
+var maxConnectionBytesPerSecPath = []string{"apiServerArguments", "max-connection-bytes-per-sec"}
+
+// ObserveMaxConnectionBytesPerSec sets the max-connection-bytes-per-sec argument from the
+// MaxConnectionBytesPerSecAnnotation of the cluster APIServer config. A value that is not a non-negative integer is
+// rejected with a warning and the previously observed value is kept.
+func ObserveMaxConnectionBytesPerSec(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	defer func() {
+		ret = configobserver.Pruned(ret, maxConnectionBytesPerSecPath)
+	}()
+
+	listers := genericListers.(configobservation.Listers)
+	apiServer, err := listers.APIServerLister().Get("cluster")
+	if apierrors.IsNotFound(err) {
+		return map[string]interface{}{}, errs
+	}
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+
+	value, ok := apiServer.Annotations[MaxConnectionBytesPerSecAnnotation]
+	if !ok {
+		return map[string]interface{}{}, errs
+	}
+	bytesPerSec, err := strconv.ParseInt(value, 10, 64)
+	if err == nil && bytesPerSec < 0 {
+		err = fmt.Errorf("must not be negative")
+	}
+	if err != nil {
+		observedConfig := map[string]interface{}{}
+		if err := KeepPreviousValue(recorder, "ObserveMaxConnectionBytesPerSec", MaxConnectionBytesPerSecAnnotation, value, err, existingConfig, observedConfig, maxConnectionBytesPerSecPath); err != nil {
+			errs = append(errs, err)
+		}
+		return observedConfig, errs
+	}
+
+	observedConfig := map[string]interface{}{}
+	if err := unstructured.SetNestedStringSlice(observedConfig, []string{strconv.FormatInt(bytesPerSec, 10)}, maxConnectionBytesPerSecPath...); err != nil {
+		return existingConfig, append(errs, err)
+	}
+	return observedConfig, errs
+}
@@ -0,0 +1,77 @@
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestObserveMaxConnectionBytesPerSec(t *testing.T) {
+	observed := func(value string) map[string]interface{} {
+		return map[string]interface{}{"apiServerArguments": map[string]interface{}{"max-connection-bytes-per-sec": []interface{}{value}}}
+	}
+
+	scenarios := []struct {
+		name            string
+		annotations     map[string]string
+		existingConfig  map[string]interface{}
+		expectedConfig  map[string]interface{}
+		expectedWarning bool
+	}{
+		{
+			name:           "not set: the default applies",
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name:           "positive",
+			annotations:    map[string]string{MaxConnectionBytesPerSecAnnotation: "1048576"},
+			expectedConfig: observed("1048576"),
+		},
+		{
+			name:           "zero is unlimited",
+			annotations:    map[string]string{MaxConnectionBytesPerSecAnnotation: "0"},
+			existingConfig: observed("1048576"),
+			expectedConfig: observed("0"),
+		},
+		{
+			name:            "negative keeps the previous value",
+			annotations:     map[string]string{MaxConnectionBytesPerSecAnnotation: "-1"},
+			existingConfig:  observed("1048576"),
+			expectedConfig:  observed("1048576"),
+			expectedWarning: true,
+		},
+		{
+			name:            "unparsable without a previous value",
+			annotations:     map[string]string{MaxConnectionBytesPerSecAnnotation: "1Mi"},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			eventRecorder := events.NewInMemoryRecorder("")
+			listers := configobservation.Listers{
+				APIServerLister_: apiServerListerWithAnnotations(t, scenario.annotations),
+			}
+			existingConfig := scenario.existingConfig
+			if existingConfig == nil {
+				existingConfig = map[string]interface{}{}
+			}
+
+			observedConfig, errs := ObserveMaxConnectionBytesPerSec(listers, eventRecorder, existingConfig)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if !cmp.Equal(scenario.expectedConfig, observedConfig) {
+				t.Fatalf("unexpected configuration, diff = %v", cmp.Diff(scenario.expectedConfig, observedConfig))
+			}
+			if warned := len(eventRecorder.Events()) > 0; warned != scenario.expectedWarning {
+				t.Fatalf("expected warning %v, got events %v", scenario.expectedWarning, eventRecorder.Events())
+			}
+		})
+	}
+}
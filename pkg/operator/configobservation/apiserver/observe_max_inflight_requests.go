@@ -0,0 +1,98 @@
+package apiserver
+
+import (
+	"fmt"
+	"strconv"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+const (
+	// MaxRequestsInflightAnnotation on the cluster APIServer config sets max-requests-inflight,
+	// the maximum number of non-mutating requests in flight. 0 means no limit.
+	MaxRequestsInflightAnnotation = "kubeapiserver.operator.openshift.io/max-requests-inflight"
+	// MaxMutatingRequestsInflightAnnotation on the cluster APIServer config sets max-mutating-requests-inflight,
+	// the maximum number of mutating requests in flight. 0 means no limit.
+	MaxMutatingRequestsInflightAnnotation = "kubeapiserver.operator.openshift.io/max-mutating-requests-inflight"
+)
+
+type maxInflightRequestsArgument struct {
+	annotation string
+	path       []string
+	// safeFloor is the upstream kube-apiserver default, lower limits throttle even a small cluster
+	// and are applied with a warning.
+	safeFloor int
+}
+
+var maxInflightRequestsArguments = []maxInflightRequestsArgument{
+	{annotation: MaxRequestsInflightAnnotation, path: []string{"apiServerArguments", "max-requests-inflight"}, safeFloor: 400},
+	{annotation: MaxMutatingRequestsInflightAnnotation, path: []string{"apiServerArguments", "max-mutating-requests-inflight"}, safeFloor: 200},
+}
+
+// ObserveMaxInflightRequests sets the max-requests-inflight and max-mutating-requests-inflight arguments from the
+// annotations of the cluster APIServer config. The default config values are used for the arguments whose annotation
+// is not set. A negative or non-integer value is rejected with a warning and the previously observed value, if any,
+// is kept. A value below the safe floor is applied with a warning.
+func ObserveMaxInflightRequests(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	maxInflightRequestsPaths := [][]string{}
+	for _, arg := range maxInflightRequestsArguments {
+		maxInflightRequestsPaths = append(maxInflightRequestsPaths, arg.path)
+	}
+	defer func() {
+		ret = configobserver.Pruned(ret, maxInflightRequestsPaths...)
+	}()
+
+	listers := genericListers.(configobservation.Listers)
+	apiServer, err := listers.APIServerLister().Get("cluster")
+	if apierrors.IsNotFound(err) {
+		return map[string]interface{}{}, errs
+	}
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+
+	observedConfig := map[string]interface{}{}
+	for _, arg := range maxInflightRequestsArguments {
+		value, ok := apiServer.Annotations[arg.annotation]
+		if !ok {
+			continue
+		}
+
+		n, err := parseMaxInflightRequestsValue(value)
+		if err != nil {
+			if err := KeepPreviousValue(recorder, "ObserveMaxInflightRequests", arg.annotation, value, err, existingConfig, observedConfig, arg.path); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		switch {
+		case n == 0:
+			recorder.Warningf("ObserveMaxInflightRequests", "The %s annotation removes the limit, the kube-apiserver is not protected against overload", arg.annotation)
+		case n < arg.safeFloor:
+			recorder.Warningf("ObserveMaxInflightRequests", "The %s annotation value %d is below the safe floor of %d, the kube-apiserver may throttle legitimate requests", arg.annotation, n, arg.safeFloor)
+		}
+		if err := unstructured.SetNestedStringSlice(observedConfig, []string{strconv.Itoa(n)}, arg.path...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return observedConfig, errs
+}
+
+// parseMaxInflightRequestsValue accepts non-negative integers.
+func parseMaxInflightRequestsValue(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("must be an integer")
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("must not be negative")
+	}
+	return n, nil
+}
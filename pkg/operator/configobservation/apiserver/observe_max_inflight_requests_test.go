@@ -0,0 +1,105 @@
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestObserveMaxInflightRequests(t *testing.T) {
+	scenarios := []struct {
+		name            string
+		annotations     map[string]string
+		existingConfig  map[string]interface{}
+		expectedConfig  map[string]interface{}
+		expectedWarning bool
+	}{
+		{
+			name:           "not set: the default config values apply",
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name: "valid overrides",
+			annotations: map[string]string{
+				MaxRequestsInflightAnnotation:         "6000",
+				MaxMutatingRequestsInflightAnnotation: "2000",
+			},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"max-requests-inflight":          []interface{}{"6000"},
+				"max-mutating-requests-inflight": []interface{}{"2000"},
+			}},
+		},
+		{
+			name:        "at the safe floor",
+			annotations: map[string]string{MaxMutatingRequestsInflightAnnotation: "200"},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"max-mutating-requests-inflight": []interface{}{"200"},
+			}},
+		},
+		{
+			name:        "below the safe floor is applied with a warning",
+			annotations: map[string]string{MaxRequestsInflightAnnotation: "100"},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"max-requests-inflight": []interface{}{"100"},
+			}},
+			expectedWarning: true,
+		},
+		{
+			name:        "no limit is applied with a warning",
+			annotations: map[string]string{MaxMutatingRequestsInflightAnnotation: "0"},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"max-mutating-requests-inflight": []interface{}{"0"},
+			}},
+			expectedWarning: true,
+		},
+		{
+			name: "invalid values are rejected, valid ones applied",
+			annotations: map[string]string{
+				MaxRequestsInflightAnnotation:         "-1",
+				MaxMutatingRequestsInflightAnnotation: "1500",
+			},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"max-mutating-requests-inflight": []interface{}{"1500"},
+			}},
+			expectedWarning: true,
+		},
+		{
+			name:        "a rejected value keeps the previously observed one",
+			annotations: map[string]string{MaxRequestsInflightAnnotation: "a lot"},
+			existingConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"max-requests-inflight": []interface{}{"6000"},
+			}},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"max-requests-inflight": []interface{}{"6000"},
+			}},
+			expectedWarning: true,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			eventRecorder := events.NewInMemoryRecorder("")
+			listers := configobservation.Listers{
+				APIServerLister_: apiServerListerWithAnnotations(t, scenario.annotations),
+			}
+			existingConfig := scenario.existingConfig
+			if existingConfig == nil {
+				existingConfig = map[string]interface{}{}
+			}
+
+			observedConfig, errs := ObserveMaxInflightRequests(listers, eventRecorder, existingConfig)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if !cmp.Equal(scenario.expectedConfig, observedConfig) {
+				t.Fatalf("unexpected configuration, diff = %v", cmp.Diff(scenario.expectedConfig, observedConfig))
+			}
+			if warned := len(eventRecorder.Events()) > 0; warned != scenario.expectedWarning {
+				t.Fatalf("expected warning %v, got events %v", scenario.expectedWarning, eventRecorder.Events())
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package apiserver
+
+import (
+	"fmt"
+	"strconv"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+const (
+	// EnablePriorityAndFairnessAnnotation on the cluster APIServer config set to "false" disables API Priority and
+	// Fairness. It cannot enable it while the APIPriorityAndFairness feature gate is disabled.
+	EnablePriorityAndFairnessAnnotation = "kubeapiserver.operator.openshift.io/enable-priority-and-fairness"
+
+	priorityAndFairnessFeatureGate = "APIPriorityAndFairness"
+)
+
+var enablePriorityAndFairnessPath = []string{"apiServerArguments", "enable-priority-and-fairness"}
+
+// ObservePriorityAndFairness sets enable-priority-and-fairness. API Priority and Fairness is enabled unless either the
+// APIPriorityAndFairness feature gate is disabled or the EnablePriorityAndFairnessAnnotation of the cluster APIServer
+// config is "false". The feature gate wins over the annotation. An invalid annotation value keeps the previously
+// observed one.
+func ObservePriorityAndFairness(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	defer func() {
+		ret = configobserver.Pruned(ret, enablePriorityAndFairnessPath)
+	}()
+
+	listers := genericListers.(configobservation.Listers)
+	_, disabledFeatures, _, err := listers.FeatureGates().ClusterFeatures()
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+	enabled := !disabledFeatures.Has(priorityAndFairnessFeatureGate)
+
+	apiServer, err := listers.APIServerLister().Get("cluster")
+	if err != nil && !apierrors.IsNotFound(err) {
+		return existingConfig, append(errs, err)
+	}
+	if err == nil {
+		if value, ok := apiServer.Annotations[EnablePriorityAndFairnessAnnotation]; ok {
+			requested, err := strconv.ParseBool(value)
+			switch {
+			case err != nil && enabled:
+				observedConfig := map[string]interface{}{}
+				if err := KeepPreviousValue(recorder, "ObservePriorityAndFairness", EnablePriorityAndFairnessAnnotation, value, fmt.Errorf("must be true or false"), existingConfig, observedConfig, enablePriorityAndFairnessPath); err != nil {
+					errs = append(errs, err)
+				}
+				if len(observedConfig) > 0 {
+					return observedConfig, errs
+				}
+			case err != nil:
+				recorder.Warningf("ObservePriorityAndFairness", "Ignoring invalid %s annotation value %q: must be true or false", EnablePriorityAndFairnessAnnotation, value)
+			case requested && !enabled:
+				recorder.Warningf("ObservePriorityAndFairness", "Ignoring the %s annotation, API Priority and Fairness cannot be enabled while the %s feature gate is disabled", EnablePriorityAndFairnessAnnotation, priorityAndFairnessFeatureGate)
+			case !requested:
+				enabled = false
+			}
+		}
+	}
+
+	currentValue, _, err := unstructured.NestedStringSlice(existingConfig, enablePriorityAndFairnessPath...)
+	if err != nil {
+		errs = append(errs, err)
+		// keep going on read error from existing config
+	}
+	if !enabled && (len(currentValue) == 0 || currentValue[0] != "false") {
+		recorder.Warningf("ObservePriorityAndFairnessDisabled", "API Priority and Fairness is disabled, the kube-apiserver only limits the number of inflight requests and no longer protects critical requests from noisy clients")
+	}
+
+	observedConfig := map[string]interface{}{}
+	if err := unstructured.SetNestedStringSlice(observedConfig, []string{strconv.FormatBool(enabled)}, enablePriorityAndFairnessPath...); err != nil {
+		return existingConfig, append(errs, err)
+	}
+	return observedConfig, errs
+}
@@ -0,0 +1,126 @@
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestObservePriorityAndFairness(t *testing.T) {
+	enabled := map[string]interface{}{"apiServerArguments": map[string]interface{}{
+		"enable-priority-and-fairness": []interface{}{"true"},
+	}}
+	disabled := map[string]interface{}{"apiServerArguments": map[string]interface{}{
+		"enable-priority-and-fairness": []interface{}{"false"},
+	}}
+	gateDisabled := &configv1.FeatureGate{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: configv1.FeatureGateSpec{FeatureGateSelection: configv1.FeatureGateSelection{
+			FeatureSet:      configv1.CustomNoUpgrade,
+			CustomNoUpgrade: &configv1.CustomFeatureGates{Disabled: []string{"APIPriorityAndFairness"}},
+		}},
+	}
+
+	scenarios := []struct {
+		name            string
+		featureGate     *configv1.FeatureGate
+		annotations     map[string]string
+		existingConfig  map[string]interface{}
+		expectedConfig  map[string]interface{}
+		expectedWarning bool
+	}{
+		{
+			name:           "enabled by default",
+			expectedConfig: enabled,
+		},
+		{
+			name:           "explicitly enabled",
+			annotations:    map[string]string{EnablePriorityAndFairnessAnnotation: "true"},
+			expectedConfig: enabled,
+		},
+		{
+			name:            "disabled by the feature gate",
+			featureGate:     gateDisabled,
+			expectedConfig:  disabled,
+			expectedWarning: true,
+		},
+		{
+			name:            "disabled by the annotation",
+			annotations:     map[string]string{EnablePriorityAndFairnessAnnotation: "false"},
+			expectedConfig:  disabled,
+			expectedWarning: true,
+		},
+		{
+			name:           "no warning once disabled",
+			annotations:    map[string]string{EnablePriorityAndFairnessAnnotation: "false"},
+			existingConfig: disabled,
+			expectedConfig: disabled,
+		},
+		{
+			name:            "the feature gate wins over the annotation",
+			featureGate:     gateDisabled,
+			annotations:     map[string]string{EnablePriorityAndFairnessAnnotation: "true"},
+			existingConfig:  disabled,
+			expectedConfig:  disabled,
+			expectedWarning: true,
+		},
+		{
+			name:            "an invalid annotation value keeps the previous value",
+			annotations:     map[string]string{EnablePriorityAndFairnessAnnotation: "maybe"},
+			existingConfig:  disabled,
+			expectedConfig:  disabled,
+			expectedWarning: true,
+		},
+		{
+			name:            "an invalid annotation value without a previous value",
+			annotations:     map[string]string{EnablePriorityAndFairnessAnnotation: "maybe"},
+			expectedConfig:  enabled,
+			expectedWarning: true,
+		},
+		{
+			name:            "the feature gate wins over the previous value",
+			featureGate:     gateDisabled,
+			annotations:     map[string]string{EnablePriorityAndFairnessAnnotation: "maybe"},
+			existingConfig:  enabled,
+			expectedConfig:  disabled,
+			expectedWarning: true,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			featureGateIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if scenario.featureGate != nil {
+				require.NoError(t, featureGateIndexer.Add(scenario.featureGate))
+			}
+			eventRecorder := events.NewInMemoryRecorder("")
+			listers := configobservation.Listers{
+				APIServerLister_:   apiServerListerWithAnnotations(t, scenario.annotations),
+				FeatureGateLister_: configlistersv1.NewFeatureGateLister(featureGateIndexer),
+			}
+			existingConfig := scenario.existingConfig
+			if existingConfig == nil {
+				existingConfig = map[string]interface{}{}
+			}
+
+			observedConfig, errs := ObservePriorityAndFairness(listers, eventRecorder, existingConfig)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if !cmp.Equal(scenario.expectedConfig, observedConfig) {
+				t.Fatalf("unexpected configuration, diff = %v", cmp.Diff(scenario.expectedConfig, observedConfig))
+			}
+			if warned := len(eventRecorder.Events()) > 0; warned != scenario.expectedWarning {
+				t.Fatalf("expected warning %v, got events %v", scenario.expectedWarning, eventRecorder.Events())
+			}
+		})
+	}
+}
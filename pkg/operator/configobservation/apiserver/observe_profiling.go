@@ -0,0 +1,75 @@
+package apiserver
+
+import (
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+// EnableProfilingAnnotation on the cluster APIServer config enables the pprof handlers of the kube-apiserver while
+// debugging when set to exactly "true". Profiling exposes the internals of the process to anyone allowed on
+// /debug/pprof, so it is meant to be removed again once done. It is an annotation rather than a field of the
+// kubeapiserver operator spec because the vendored openshift/api operator types have no such field, adding one is an
+// openshift/api change.
+const EnableProfilingAnnotation = "kubeapiserver.operator.openshift.io/enable-profiling"
+
+var profilingPath = []string{"apiServerArguments", "profiling"}
+
+// ObserveProfiling sets profiling, to "false" unless the EnableProfilingAnnotation of the cluster APIServer config is
+// "true". While it is enabled every observation warns about it, and turning it off again is announced, so that
+// neither state goes unnoticed. Without a previously observed value the kube-apiserver default of "true" applied, so
+// that is announced as turning it off too. Any other annotation value is rejected with a warning and the previously
+// observed value is kept.
+func ObserveProfiling(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	defer func() {
+		ret = configobserver.Pruned(ret, profilingPath)
+	}()
+
+	listers := genericListers.(configobservation.Listers)
+	apiServer, err := listers.APIServerLister().Get("cluster")
+	if err != nil && !apierrors.IsNotFound(err) {
+		return existingConfig, append(errs, err)
+	}
+
+	enabled := false
+	if err == nil {
+		switch value, ok := apiServer.Annotations[EnableProfilingAnnotation]; {
+		case !ok:
+		case value == "true":
+			enabled = true
+			recorder.Warningf("ObserveProfiling", "Profiling of the kube-apiserver is enabled by the %s annotation, remove it once done debugging", EnableProfilingAnnotation)
+		default:
+			observedConfig := map[string]interface{}{}
+			if err := KeepPreviousValue(recorder, "ObserveProfiling", EnableProfilingAnnotation, value, fmt.Errorf("it must be \"true\" to enable profiling"), existingConfig, observedConfig, profilingPath); err != nil {
+				errs = append(errs, err)
+			}
+			if len(observedConfig) > 0 {
+				return observedConfig, errs
+			}
+		}
+	}
+
+	if current, _, _ := unstructured.NestedStringSlice(existingConfig, profilingPath...); !enabled {
+		switch {
+		case len(current) == 1 && current[0] == "true":
+			recorder.Eventf("ObserveProfiling", "Profiling of the kube-apiserver is disabled again")
+		case len(current) == 0:
+			recorder.Eventf("ObserveProfiling", "Profiling of the kube-apiserver is disabled, it was enabled by the kube-apiserver default")
+		}
+	}
+
+	value := "false"
+	if enabled {
+		value = "true"
+	}
+	observedConfig := map[string]interface{}{}
+	if err := unstructured.SetNestedStringSlice(observedConfig, []string{value}, profilingPath...); err != nil {
+		return existingConfig, append(errs, err)
+	}
+	return observedConfig, errs
+}
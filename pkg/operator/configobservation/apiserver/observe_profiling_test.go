@@ -0,0 +1,105 @@
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestObserveProfiling(t *testing.T) {
+	profiling := func(value string) map[string]interface{} {
+		return map[string]interface{}{"apiServerArguments": map[string]interface{}{"profiling": []interface{}{value}}}
+	}
+
+	scenarios := []struct {
+		name           string
+		annotations    map[string]string
+		existingConfig map[string]interface{}
+		expectedConfig map[string]interface{}
+		expectedEvents []string
+	}{
+		{
+			name:           "disabled by default",
+			existingConfig: profiling("false"),
+			expectedConfig: profiling("false"),
+		},
+		{
+			name: "a config from before profiling was observed no longer gets the kube-apiserver default",
+			existingConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"shutdown-delay-duration": []interface{}{"70s"},
+			}},
+			expectedConfig: profiling("false"),
+			expectedEvents: []string{"Normal"},
+		},
+		{
+			name:           "enabled with a warning",
+			annotations:    map[string]string{EnableProfilingAnnotation: "true"},
+			expectedConfig: profiling("true"),
+			expectedEvents: []string{"Warning"},
+		},
+		{
+			name:           "still warned about while enabled",
+			annotations:    map[string]string{EnableProfilingAnnotation: "true"},
+			existingConfig: profiling("true"),
+			expectedConfig: profiling("true"),
+			expectedEvents: []string{"Warning"},
+		},
+		{
+			name:           "disabling again is announced",
+			existingConfig: profiling("true"),
+			expectedConfig: profiling("false"),
+			expectedEvents: []string{"Normal"},
+		},
+		{
+			name:           "invalid value keeps profiling disabled",
+			annotations:    map[string]string{EnableProfilingAnnotation: "yes"},
+			existingConfig: profiling("false"),
+			expectedConfig: profiling("false"),
+			expectedEvents: []string{"Warning"},
+		},
+		{
+			name:           "invalid value keeps profiling enabled",
+			annotations:    map[string]string{EnableProfilingAnnotation: "yes"},
+			existingConfig: profiling("true"),
+			expectedConfig: profiling("true"),
+			expectedEvents: []string{"Warning"},
+		},
+		{
+			name:           "invalid value without a previous value",
+			annotations:    map[string]string{EnableProfilingAnnotation: "yes"},
+			expectedConfig: profiling("false"),
+			expectedEvents: []string{"Warning", "Normal"},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			eventRecorder := events.NewInMemoryRecorder("")
+			listers := configobservation.Listers{
+				APIServerLister_: apiServerListerWithAnnotations(t, scenario.annotations),
+			}
+			existingConfig := scenario.existingConfig
+			if existingConfig == nil {
+				existingConfig = map[string]interface{}{}
+			}
+
+			observedConfig, errs := ObserveProfiling(listers, eventRecorder, existingConfig)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if !cmp.Equal(scenario.expectedConfig, observedConfig) {
+				t.Fatalf("unexpected configuration, diff = %v", cmp.Diff(scenario.expectedConfig, observedConfig))
+			}
+			var eventTypes []string
+			for _, ev := range eventRecorder.Events() {
+				eventTypes = append(eventTypes, ev.Type)
+			}
+			if !cmp.Equal(scenario.expectedEvents, eventTypes) {
+				t.Fatalf("unexpected events, diff = %v", cmp.Diff(scenario.expectedEvents, eventTypes))
+			}
+		})
+	}
+}
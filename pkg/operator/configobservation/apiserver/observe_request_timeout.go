@@ -0,0 +1,131 @@
+package apiserver
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+const (
+	// RequestTimeoutAnnotation on the cluster APIServer config sets request-timeout, the timeout of the requests
+	// that are not long running, as a duration, e.g. "90s".
+	RequestTimeoutAnnotation = "kubeapiserver.operator.openshift.io/request-timeout"
+	// MinRequestTimeoutAnnotation on the cluster APIServer config sets min-request-timeout, the number of seconds
+	// a watch is at least kept open before it is timed out.
+	MinRequestTimeoutAnnotation = "kubeapiserver.operator.openshift.io/min-request-timeout"
+
+	minRequestTimeout = 10 * time.Second
+	maxRequestTimeout = 10 * time.Minute
+
+	minMinRequestTimeout = 5 * time.Minute
+	maxMinRequestTimeout = 4 * time.Hour
+
+	// defaultRequestTimeout is the kube-apiserver default, request-timeout is not set by the default config.
+	defaultRequestTimeout = time.Minute
+	// defaultMinRequestTimeout is the value of the default config.
+	defaultMinRequestTimeout = time.Hour
+)
+
+var (
+	requestTimeoutPath    = []string{"apiServerArguments", "request-timeout"}
+	minRequestTimeoutPath = []string{"apiServerArguments", "min-request-timeout"}
+)
+
+// ObserveRequestTimeout sets the request-timeout and min-request-timeout arguments from the annotations of the cluster
+// APIServer config. A value that cannot be parsed or is out of bounds is rejected with a warning and the previously
+// observed value is kept. min-request-timeout bounds the watches, it must not be shorter than request-timeout or
+// watches would be timed out before regular requests; on such an inverted pair both annotations are rejected with a
+// warning and the previously observed values are kept.
+func ObserveRequestTimeout(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	defer func() {
+		ret = configobserver.Pruned(ret, requestTimeoutPath, minRequestTimeoutPath)
+	}()
+
+	listers := genericListers.(configobservation.Listers)
+	apiServer, err := listers.APIServerLister().Get("cluster")
+	if apierrors.IsNotFound(err) {
+		return map[string]interface{}{}, errs
+	}
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+
+	observedConfig := map[string]interface{}{}
+	if value, ok := apiServer.Annotations[RequestTimeoutAnnotation]; ok {
+		if d, err := parseRequestTimeout(value); err != nil {
+			if err := KeepPreviousValue(recorder, "ObserveRequestTimeout", RequestTimeoutAnnotation, value, err, existingConfig, observedConfig, requestTimeoutPath); err != nil {
+				errs = append(errs, err)
+			}
+		} else if err := unstructured.SetNestedStringSlice(observedConfig, []string{d.String()}, requestTimeoutPath...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if value, ok := apiServer.Annotations[MinRequestTimeoutAnnotation]; ok {
+		if d, err := parseMinRequestTimeout(value); err != nil {
+			if err := KeepPreviousValue(recorder, "ObserveRequestTimeout", MinRequestTimeoutAnnotation, value, err, existingConfig, observedConfig, minRequestTimeoutPath); err != nil {
+				errs = append(errs, err)
+			}
+		} else if err := unstructured.SetNestedStringSlice(observedConfig, []string{strconv.Itoa(int(d.Seconds()))}, minRequestTimeoutPath...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	requestTimeout := observedTimeout(observedConfig, requestTimeoutPath, parseRequestTimeout, defaultRequestTimeout)
+	minTimeout := observedTimeout(observedConfig, minRequestTimeoutPath, parseMinRequestTimeout, defaultMinRequestTimeout)
+	if minTimeout < requestTimeout {
+		recorder.Warningf("ObserveRequestTimeout", "Rejecting the %s and %s annotations, the min-request-timeout of %d seconds is shorter than the request-timeout of %v, keeping the previous values", RequestTimeoutAnnotation, MinRequestTimeoutAnnotation, int(minTimeout.Seconds()), requestTimeout)
+		previousConfig := map[string]interface{}{}
+		for _, path := range [][]string{requestTimeoutPath, minRequestTimeoutPath} {
+			if err := copyPreviousValue(existingConfig, previousConfig, path); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return previousConfig, errs
+	}
+	return observedConfig, errs
+}
+
+// observedTimeout returns the timeout observed at path, or the defaultTimeout when none is.
+func observedTimeout(observedConfig map[string]interface{}, path []string, parse func(string) (time.Duration, error), defaultTimeout time.Duration) time.Duration {
+	value, _, _ := unstructured.NestedStringSlice(observedConfig, path...)
+	if len(value) == 0 {
+		return defaultTimeout
+	}
+	d, err := parse(value[0])
+	if err != nil {
+		return defaultTimeout
+	}
+	return d
+}
+
+// parseRequestTimeout accepts durations between minRequestTimeout and maxRequestTimeout.
+func parseRequestTimeout(value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("must be a duration")
+	}
+	if d < minRequestTimeout || d > maxRequestTimeout {
+		return 0, fmt.Errorf("must be between %v and %v", minRequestTimeout, maxRequestTimeout)
+	}
+	return d, nil
+}
+
+// parseMinRequestTimeout accepts a number of seconds between minMinRequestTimeout and maxMinRequestTimeout.
+func parseMinRequestTimeout(value string) (time.Duration, error) {
+	seconds, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("must be a number of seconds")
+	}
+	d := time.Duration(seconds) * time.Second
+	if d < minMinRequestTimeout || d > maxMinRequestTimeout {
+		return 0, fmt.Errorf("must be between %d and %d seconds", int(minMinRequestTimeout.Seconds()), int(maxMinRequestTimeout.Seconds()))
+	}
+	return d, nil
+}
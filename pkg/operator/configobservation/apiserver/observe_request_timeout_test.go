@@ -0,0 +1,153 @@
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestObserveRequestTimeout(t *testing.T) {
+	scenarios := []struct {
+		name            string
+		annotations     map[string]string
+		existingConfig  map[string]interface{}
+		expectedConfig  map[string]interface{}
+		expectedWarning bool
+	}{
+		{
+			name:           "not set: the defaults apply",
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name: "valid pair",
+			annotations: map[string]string{
+				RequestTimeoutAnnotation:    "90s",
+				MinRequestTimeoutAnnotation: "1800",
+			},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"request-timeout":     []interface{}{"1m30s"},
+				"min-request-timeout": []interface{}{"1800"},
+			}},
+		},
+		{
+			name:        "request timeout only",
+			annotations: map[string]string{RequestTimeoutAnnotation: "5m"},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"request-timeout": []interface{}{"5m0s"},
+			}},
+		},
+		{
+			name:        "min request timeout equal to the request timeout",
+			annotations: map[string]string{MinRequestTimeoutAnnotation: "300", RequestTimeoutAnnotation: "300s"},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"request-timeout":     []interface{}{"5m0s"},
+				"min-request-timeout": []interface{}{"300"},
+			}},
+		},
+		{
+			name: "inverted pair keeps the previous values",
+			annotations: map[string]string{
+				RequestTimeoutAnnotation:    "10m",
+				MinRequestTimeoutAnnotation: "300",
+			},
+			existingConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"request-timeout":     []interface{}{"1m30s"},
+				"min-request-timeout": []interface{}{"1800"},
+			}},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"request-timeout":     []interface{}{"1m30s"},
+				"min-request-timeout": []interface{}{"1800"},
+			}},
+			expectedWarning: true,
+		},
+		{
+			name: "inverted pair without previous values",
+			annotations: map[string]string{
+				RequestTimeoutAnnotation:    "10m",
+				MinRequestTimeoutAnnotation: "300",
+			},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+		{
+			name:        "a rejected request timeout keeps the previous value",
+			annotations: map[string]string{RequestTimeoutAnnotation: "1s"},
+			existingConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"request-timeout": []interface{}{"2m0s"},
+			}},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"request-timeout": []interface{}{"2m0s"},
+			}},
+			expectedWarning: true,
+		},
+		{
+			name: "a kept previous value is checked against the other value",
+			annotations: map[string]string{
+				RequestTimeoutAnnotation:    "one minute",
+				MinRequestTimeoutAnnotation: "300",
+			},
+			existingConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"request-timeout":     []interface{}{"10m0s"},
+				"min-request-timeout": []interface{}{"1800"},
+			}},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"request-timeout":     []interface{}{"10m0s"},
+				"min-request-timeout": []interface{}{"1800"},
+			}},
+			expectedWarning: true,
+		},
+		{
+			name:            "request timeout out of bounds",
+			annotations:     map[string]string{RequestTimeoutAnnotation: "1s"},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+		{
+			name: "min request timeout out of bounds, the valid request timeout applies",
+			annotations: map[string]string{
+				RequestTimeoutAnnotation:    "2m",
+				MinRequestTimeoutAnnotation: "86400",
+			},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"request-timeout": []interface{}{"2m0s"},
+			}},
+			expectedWarning: true,
+		},
+		{
+			name: "unparsable values",
+			annotations: map[string]string{
+				RequestTimeoutAnnotation:    "one minute",
+				MinRequestTimeoutAnnotation: "1h",
+			},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			eventRecorder := events.NewInMemoryRecorder("")
+			listers := configobservation.Listers{
+				APIServerLister_: apiServerListerWithAnnotations(t, scenario.annotations),
+			}
+			existingConfig := scenario.existingConfig
+			if existingConfig == nil {
+				existingConfig = map[string]interface{}{}
+			}
+
+			observedConfig, errs := ObserveRequestTimeout(listers, eventRecorder, existingConfig)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if !cmp.Equal(scenario.expectedConfig, observedConfig) {
+				t.Fatalf("unexpected configuration, diff = %v", cmp.Diff(scenario.expectedConfig, observedConfig))
+			}
+			if warned := len(eventRecorder.Events()) > 0; warned != scenario.expectedWarning {
+				t.Fatalf("expected warning %v, got events %v", scenario.expectedWarning, eventRecorder.Events())
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package apiserver
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+const (
+	// EnabledAPIVersionsAnnotation on the cluster APIServer config lists the API group versions to serve on top of the
+	// default ones, comma separated, e.g. "batch/v2alpha1,storage.k8s.io/v1alpha1". The core group is "api/v1".
+	EnabledAPIVersionsAnnotation = "kubeapiserver.operator.openshift.io/enabled-api-versions"
+	// DisabledAPIVersionsAnnotation on the cluster APIServer config lists the API group versions not to serve, in the
+	// same format.
+	DisabledAPIVersionsAnnotation = "kubeapiserver.operator.openshift.io/disabled-api-versions"
+)
+
+var (
+	runtimeConfigPath = []string{"apiServerArguments", "runtime-config"}
+
+	apiVersionRegexp = regexp.MustCompile(`^v[1-9][0-9]*((alpha|beta)[1-9][0-9]*)?$`)
+
+	// protectedAPIVersions are served by the platform components and cannot be disabled, nor can all the APIs.
+	protectedAPIVersions = sets.NewString(
+		"api/all",
+		"api/ga",
+		"api/legacy",
+		"api/v1",
+		"admissionregistration.k8s.io/v1",
+		"apiextensions.k8s.io/v1",
+		"apiregistration.k8s.io/v1",
+		"apps/v1",
+		"authentication.k8s.io/v1",
+		"authorization.k8s.io/v1",
+		"autoscaling/v1",
+		"batch/v1",
+		"certificates.k8s.io/v1",
+		"coordination.k8s.io/v1",
+		"discovery.k8s.io/v1",
+		"events.k8s.io/v1",
+		"flowcontrol.apiserver.k8s.io/v1beta1",
+		"networking.k8s.io/v1",
+		"node.k8s.io/v1",
+		"policy/v1",
+		"rbac.authorization.k8s.io/v1",
+		"scheduling.k8s.io/v1",
+		"storage.k8s.io/v1",
+	)
+
+	// requiredRuntimeConfig is always part of a rendered runtime-config. The platform priority and fairness
+	// configuration is only served by flowcontrol.apiserver.k8s.io/v1beta1 in this release.
+	requiredRuntimeConfig = map[string]bool{
+		"flowcontrol.apiserver.k8s.io/v1beta1": true,
+	}
+)
+
+// ObserveRuntimeConfig sets runtime-config from the EnabledAPIVersionsAnnotation and DisabledAPIVersionsAnnotation of
+// the cluster APIServer config, merged with the platform required entries. An annotation with an entry which is not a
+// group/version is rejected with a warning and the previously observed runtime-config is kept. Entries which are
+// listed in both annotations or would disable a protected group version are refused with a warning, the remaining
+// ones still apply. Without entries runtime-config is not set.
+func ObserveRuntimeConfig(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	defer func() {
+		ret = configobserver.Pruned(ret, runtimeConfigPath)
+	}()
+
+	listers := genericListers.(configobservation.Listers)
+	apiServer, err := listers.APIServerLister().Get("cluster")
+	if apierrors.IsNotFound(err) {
+		return map[string]interface{}{}, errs
+	}
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+
+	observedConfig := map[string]interface{}{}
+	enabled, enabledErr := parseAPIVersions(apiServer.Annotations[EnabledAPIVersionsAnnotation])
+	disabled, disabledErr := parseAPIVersions(apiServer.Annotations[DisabledAPIVersionsAnnotation])
+	if enabledErr != nil || disabledErr != nil {
+		for _, rejected := range []struct {
+			annotation string
+			err        error
+		}{{EnabledAPIVersionsAnnotation, enabledErr}, {DisabledAPIVersionsAnnotation, disabledErr}} {
+			if rejected.err == nil {
+				continue
+			}
+			if err := KeepPreviousValue(recorder, "ObserveRuntimeConfig", rejected.annotation, apiServer.Annotations[rejected.annotation], rejected.err, existingConfig, observedConfig, runtimeConfigPath); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return observedConfig, errs
+	}
+
+	runtimeConfig := map[string]bool{}
+	for _, groupVersion := range enabled.List() {
+		if disabled.Has(groupVersion) {
+			recorder.Warningf("ObserveRuntimeConfig", "Ignoring %s, it is listed in both the %s and %s annotations", groupVersion, EnabledAPIVersionsAnnotation, DisabledAPIVersionsAnnotation)
+			continue
+		}
+		runtimeConfig[groupVersion] = true
+	}
+	for _, groupVersion := range disabled.List() {
+		if enabled.Has(groupVersion) {
+			continue
+		}
+		if protectedAPIVersions.Has(groupVersion) {
+			recorder.Warningf("ObserveRuntimeConfig", "Refusing to disable %s, the platform depends on it", groupVersion)
+			continue
+		}
+		runtimeConfig[groupVersion] = false
+	}
+	if len(runtimeConfig) == 0 {
+		return map[string]interface{}{}, errs
+	}
+	for groupVersion, value := range requiredRuntimeConfig {
+		runtimeConfig[groupVersion] = value
+	}
+
+	var entries []string
+	for groupVersion, value := range runtimeConfig {
+		entries = append(entries, fmt.Sprintf("%s=%t", groupVersion, value))
+	}
+	sort.Strings(entries)
+
+	if err := unstructured.SetNestedStringSlice(observedConfig, entries, runtimeConfigPath...); err != nil {
+		return existingConfig, append(errs, err)
+	}
+	return observedConfig, errs
+}
+
+// parseAPIVersions returns the group versions of the comma separated annotation value, or an error listing the
+// invalid entries.
+func parseAPIVersions(value string) (sets.String, error) {
+	groupVersions := sets.NewString()
+	var errs []error
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		if err := validateAPIVersion(entry); err != nil {
+			errs = append(errs, fmt.Errorf("entry %q: %v", entry, err))
+			continue
+		}
+		groupVersions.Insert(entry)
+	}
+	return groupVersions, utilerrors.NewAggregate(errs)
+}
+
+// validateAPIVersion accepts group/version, the core group being "api", and the api/all, api/ga and api/legacy
+// aliases.
+func validateAPIVersion(groupVersion string) error {
+	parts := strings.Split(groupVersion, "/")
+	if len(parts) != 2 {
+		return fmt.Errorf("must be group/version")
+	}
+	group, version := parts[0], parts[1]
+	if group == "api" {
+		if version == "all" || version == "ga" || version == "legacy" || version == "v1" {
+			return nil
+		}
+		return fmt.Errorf("the core group only has the v1 version")
+	}
+	if errs := validation.IsDNS1123Subdomain(group); len(errs) > 0 {
+		return fmt.Errorf("invalid group: %s", strings.Join(errs, ", "))
+	}
+	if !apiVersionRegexp.MatchString(version) {
+		return fmt.Errorf("invalid version %q", version)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestObserveRuntimeConfig(t *testing.T) {
+	runtimeConfig := func(entries ...interface{}) map[string]interface{} {
+		return map[string]interface{}{"apiServerArguments": map[string]interface{}{"runtime-config": entries}}
+	}
+
+	scenarios := []struct {
+		name            string
+		annotations     map[string]string
+		existingConfig  map[string]interface{}
+		expectedConfig  map[string]interface{}
+		expectedWarning bool
+	}{
+		{
+			name:           "not set",
+			existingConfig: runtimeConfig("batch/v2alpha1=true", "flowcontrol.apiserver.k8s.io/v1beta1=true"),
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name:           "enable a group version",
+			annotations:    map[string]string{EnabledAPIVersionsAnnotation: "batch/v2alpha1"},
+			expectedConfig: runtimeConfig("batch/v2alpha1=true", "flowcontrol.apiserver.k8s.io/v1beta1=true"),
+		},
+		{
+			name: "disable group versions",
+			annotations: map[string]string{
+				EnabledAPIVersionsAnnotation:  "storage.k8s.io/v1alpha1",
+				DisabledAPIVersionsAnnotation: "autoscaling/v2beta1, policy/v1beta1",
+			},
+			expectedConfig: runtimeConfig("autoscaling/v2beta1=false", "flowcontrol.apiserver.k8s.io/v1beta1=true", "policy/v1beta1=false", "storage.k8s.io/v1alpha1=true"),
+		},
+		{
+			name:            "disabling a protected group version is refused",
+			annotations:     map[string]string{DisabledAPIVersionsAnnotation: "rbac.authorization.k8s.io/v1,policy/v1beta1"},
+			expectedConfig:  runtimeConfig("flowcontrol.apiserver.k8s.io/v1beta1=true", "policy/v1beta1=false"),
+			expectedWarning: true,
+		},
+		{
+			name:            "disabling the platform required group version is refused",
+			annotations:     map[string]string{DisabledAPIVersionsAnnotation: "flowcontrol.apiserver.k8s.io/v1beta1"},
+			existingConfig:  runtimeConfig("flowcontrol.apiserver.k8s.io/v1beta1=true", "policy/v1beta1=false"),
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+		{
+			name:            "disabling all the APIs is refused",
+			annotations:     map[string]string{DisabledAPIVersionsAnnotation: "api/all"},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+		{
+			name: "invalid entries keep the previous value",
+			annotations: map[string]string{
+				EnabledAPIVersionsAnnotation:  "batch/v2alpha1,batch,apps/v1/deployments,Batch/v1,batch/version1,api/v2",
+				DisabledAPIVersionsAnnotation: "policy/v1beta1=false",
+			},
+			existingConfig:  runtimeConfig("flowcontrol.apiserver.k8s.io/v1beta1=true", "policy/v1beta1=false"),
+			expectedConfig:  runtimeConfig("flowcontrol.apiserver.k8s.io/v1beta1=true", "policy/v1beta1=false"),
+			expectedWarning: true,
+		},
+		{
+			name: "invalid entries without a previous value",
+			annotations: map[string]string{
+				EnabledAPIVersionsAnnotation: "batch/v2alpha1,batch",
+			},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+		{
+			name: "enabled and disabled at once",
+			annotations: map[string]string{
+				EnabledAPIVersionsAnnotation:  "batch/v2alpha1",
+				DisabledAPIVersionsAnnotation: "batch/v2alpha1",
+			},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			eventRecorder := events.NewInMemoryRecorder("")
+			listers := configobservation.Listers{
+				APIServerLister_: apiServerListerWithAnnotations(t, scenario.annotations),
+			}
+			existingConfig := scenario.existingConfig
+			if existingConfig == nil {
+				existingConfig = map[string]interface{}{}
+			}
+
+			observedConfig, errs := ObserveRuntimeConfig(listers, eventRecorder, existingConfig)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if !cmp.Equal(scenario.expectedConfig, observedConfig) {
+				t.Fatalf("unexpected configuration, diff = %v", cmp.Diff(scenario.expectedConfig, observedConfig))
+			}
+			if warned := len(eventRecorder.Events()) > 0; warned != scenario.expectedWarning {
+				t.Fatalf("expected warning %v, got events %v", scenario.expectedWarning, eventRecorder.Events())
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package apiserver
+
+import (
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+const (
+	// StorageMediaTypeAnnotation on the cluster APIServer config sets storage-media-type, the encoding of the objects
+	// written to etcd. Without it the default config value, protobuf, applies.
+	StorageMediaTypeAnnotation = "kubeapiserver.operator.openshift.io/storage-media-type"
+
+	defaultStorageMediaType = "application/vnd.kubernetes.protobuf"
+)
+
+var (
+	storageMediaTypePath = []string{"apiServerArguments", "storage-media-type"}
+
+	// allowedStorageMediaTypes are the encodings every kube-apiserver and etcd tool reads back. YAML is accepted by
+	// the kube-apiserver too, but nothing else understands it in etcd.
+	allowedStorageMediaTypes = sets.NewString(defaultStorageMediaType, "application/json")
+)
+
+// ObserveStorageMediaType sets the storage-media-type argument from the StorageMediaTypeAnnotation of the cluster
+// APIServer config. Only the allowedStorageMediaTypes are accepted, any other value is rejected with a warning and the
+// previously observed value is kept. Objects are only re-encoded when they are written again, so etcd holds both
+// encodings for a while and backups taken then need a kube-apiserver reading both: every observation of a media type
+// other than the default warns about it.
+func ObserveStorageMediaType(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	defer func() {
+		ret = configobserver.Pruned(ret, storageMediaTypePath)
+	}()
+
+	listers := genericListers.(configobservation.Listers)
+	apiServer, err := listers.APIServerLister().Get("cluster")
+	if apierrors.IsNotFound(err) {
+		return map[string]interface{}{}, errs
+	}
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+
+	value, ok := apiServer.Annotations[StorageMediaTypeAnnotation]
+	if !ok {
+		return map[string]interface{}{}, errs
+	}
+	if !allowedStorageMediaTypes.Has(value) {
+		observedConfig := map[string]interface{}{}
+		if err := KeepPreviousValue(recorder, "ObserveStorageMediaType", StorageMediaTypeAnnotation, value, fmt.Errorf("must be one of %s", strings.Join(allowedStorageMediaTypes.List(), ", ")), existingConfig, observedConfig, storageMediaTypePath); err != nil {
+			errs = append(errs, err)
+		}
+		return observedConfig, errs
+	}
+	if value != defaultStorageMediaType {
+		recorder.Warningf("ObserveStorageMediaType", "The %s annotation stores the objects in etcd as %s instead of %s. This affects the compatibility of the etcd data and backups, remove it unless it is required", StorageMediaTypeAnnotation, value, defaultStorageMediaType)
+	}
+
+	observedConfig := map[string]interface{}{}
+	if err := unstructured.SetNestedStringSlice(observedConfig, []string{value}, storageMediaTypePath...); err != nil {
+		return existingConfig, append(errs, err)
+	}
+	return observedConfig, errs
+}
@@ -0,0 +1,73 @@
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestObserveStorageMediaType(t *testing.T) {
+	scenarios := []struct {
+		name            string
+		annotations     map[string]string
+		existingConfig  map[string]interface{}
+		expectedConfig  map[string]interface{}
+		expectedWarning bool
+	}{
+		{
+			name:           "not set: the default applies",
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name:           "the default",
+			annotations:    map[string]string{StorageMediaTypeAnnotation: "application/vnd.kubernetes.protobuf"},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{"storage-media-type": []interface{}{"application/vnd.kubernetes.protobuf"}}},
+		},
+		{
+			name:            "allowed value warns",
+			annotations:     map[string]string{StorageMediaTypeAnnotation: "application/json"},
+			expectedConfig:  map[string]interface{}{"apiServerArguments": map[string]interface{}{"storage-media-type": []interface{}{"application/json"}}},
+			expectedWarning: true,
+		},
+		{
+			name:            "disallowed value keeps the previous value",
+			annotations:     map[string]string{StorageMediaTypeAnnotation: "application/yaml"},
+			existingConfig:  map[string]interface{}{"apiServerArguments": map[string]interface{}{"storage-media-type": []interface{}{"application/json"}}},
+			expectedConfig:  map[string]interface{}{"apiServerArguments": map[string]interface{}{"storage-media-type": []interface{}{"application/json"}}},
+			expectedWarning: true,
+		},
+		{
+			name:            "disallowed value without a previous value",
+			annotations:     map[string]string{StorageMediaTypeAnnotation: "protobuf"},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			eventRecorder := events.NewInMemoryRecorder("")
+			listers := configobservation.Listers{
+				APIServerLister_: apiServerListerWithAnnotations(t, scenario.annotations),
+			}
+			existingConfig := scenario.existingConfig
+			if existingConfig == nil {
+				existingConfig = map[string]interface{}{}
+			}
+
+			observedConfig, errs := ObserveStorageMediaType(listers, eventRecorder, existingConfig)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if !cmp.Equal(scenario.expectedConfig, observedConfig) {
+				t.Fatalf("unexpected configuration, diff = %v", cmp.Diff(scenario.expectedConfig, observedConfig))
+			}
+			if warned := len(eventRecorder.Events()) > 0; warned != scenario.expectedWarning {
+				t.Fatalf("expected warning %v, got events %v", scenario.expectedWarning, eventRecorder.Events())
+			}
+		})
+	}
+}
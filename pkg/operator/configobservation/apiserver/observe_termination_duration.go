@@ -2,6 +2,8 @@ package apiserver
 
 import (
 	"fmt"
+	"strconv"
+	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -14,11 +16,27 @@ import (
 
 var shutdownDelayDurationPath = []string{"apiServerArguments", "shutdown-delay-duration"}
 
+var shutdownSendRetryAfterPath = []string{"apiServerArguments", "shutdown-send-retry-after"}
+
 var gracefulTerminationDurationPath = []string{"gracefulTerminationDuration"}
 
+const (
+	// ShutdownDelayDurationAnnotation on the cluster APIServer config overrides the shutdown-delay-duration
+	// chosen for the platform. The value is a duration, e.g. "90s".
+	ShutdownDelayDurationAnnotation = "kubeapiserver.operator.openshift.io/shutdown-delay-duration"
+	// ShutdownSendRetryAfterAnnotation on the cluster APIServer config sets shutdown-send-retry-after.
+	// The value is a boolean.
+	ShutdownSendRetryAfterAnnotation = "kubeapiserver.operator.openshift.io/shutdown-send-retry-after"
+
+	// maxShutdownDelayDuration caps the shutdown-delay-duration override, a longer delay only
+	// slows down rollouts without giving load balancers a meaningful amount of extra time.
+	maxShutdownDelayDuration = 3 * time.Minute
+)
+
 // ObserveShutdownDelayDuration allows for overwriting shutdown-delay-duration value.
 // It exists because the time needed for an LB to notice and remove unhealthy instances might vary by platform.
-func ObserveShutdownDelayDuration(genericListers configobserver.Listers, _ events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+// The ShutdownDelayDurationAnnotation takes precedence over the platform specific values.
+func ObserveShutdownDelayDuration(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
 	defer func() {
 		// Prune the observed config so that it only contains shutdown-delay-duration field.
 		ret = configobserver.Pruned(ret, shutdownDelayDurationPath)
@@ -33,7 +51,17 @@ func ObserveShutdownDelayDuration(genericListers configobserver.Listers, _ event
 		return existingConfig, append(errs, err)
 	}
 
+	overrideDelay, hasOverride, err := shutdownDelayDurationOverride(listers)
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+	if len(overrideDelay.warning) > 0 {
+		recorder.Warningf("ObserveShutdownDelayDuration", overrideDelay.warning)
+	}
+
 	switch {
+	case hasOverride:
+		observedShutdownDelayDuration = overrideDelay.duration.String()
 	case infra.Status.ControlPlaneTopology == configv1.SingleReplicaTopologyMode:
 		// reduce the shutdown delay to 0 to reach the maximum downtime for SNO
 		observedShutdownDelayDuration = "0s"
@@ -89,7 +117,16 @@ func ObserveGracefulTerminationDuration(genericListers configobserver.Listers, _
 		return existingConfig, append(errs, err)
 	}
 
+	overrideDelay, hasOverride, err := shutdownDelayDurationOverride(listers)
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+
 	switch {
+	case hasOverride:
+		// like for AWS below: the shutdown delay, additional 60s for finishing all in-flight requests
+		// and an extra 5s to make sure the potential SIGTERM will be sent after the server terminates itself
+		observedGracefulTerminationDuration = strconv.Itoa(int(overrideDelay.duration.Seconds()) + 60 + 5)
 	case infra.Status.ControlPlaneTopology == configv1.SingleReplicaTopologyMode:
 		// reduce termination duration from 135s (default) to 15s to reach the maximum downtime for SNO:
 		// - the shutdown-delay-duration is set to 0s because there is no load-balancer, and no fallback apiserver
@@ -130,3 +167,76 @@ func ObserveGracefulTerminationDuration(genericListers configobserver.Listers, _
 	// nothing has changed return the original configuration
 	return existingConfig, errs
 }
+
+// ObserveShutdownSendRetryAfter sets shutdown-send-retry-after from the ShutdownSendRetryAfterAnnotation
+// of the cluster APIServer config, the default config value is used when the annotation is not set. An invalid
+// value keeps the previously observed one.
+func ObserveShutdownSendRetryAfter(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	defer func() {
+		ret = configobserver.Pruned(ret, shutdownSendRetryAfterPath)
+	}()
+
+	listers := genericListers.(configobservation.Listers)
+	apiServer, err := listers.APIServerLister().Get("cluster")
+	if apierrors.IsNotFound(err) {
+		return map[string]interface{}{}, errs
+	}
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+
+	value, ok := apiServer.Annotations[ShutdownSendRetryAfterAnnotation]
+	if !ok {
+		return map[string]interface{}{}, errs
+	}
+	observedConfig := map[string]interface{}{}
+	sendRetryAfter, err := strconv.ParseBool(value)
+	if err != nil {
+		if err := KeepPreviousValue(recorder, "ObserveShutdownSendRetryAfter", ShutdownSendRetryAfterAnnotation, value, fmt.Errorf("must be a boolean"), existingConfig, observedConfig, shutdownSendRetryAfterPath); err != nil {
+			errs = append(errs, err)
+		}
+		return observedConfig, errs
+	}
+
+	if err := unstructured.SetNestedStringSlice(observedConfig, []string{strconv.FormatBool(sendRetryAfter)}, shutdownSendRetryAfterPath...); err != nil {
+		return existingConfig, append(errs, err)
+	}
+	return observedConfig, errs
+}
+
+type shutdownDelay struct {
+	duration time.Duration
+	// warning is set when the requested value had to be ignored or adjusted.
+	warning string
+}
+
+// shutdownDelayDurationOverride returns the shutdown delay requested through the ShutdownDelayDurationAnnotation.
+// Invalid and negative values are ignored, values above maxShutdownDelayDuration are capped.
+func shutdownDelayDurationOverride(listers configobservation.Listers) (shutdownDelay, bool, error) {
+	apiServer, err := listers.APIServerLister().Get("cluster")
+	if apierrors.IsNotFound(err) {
+		return shutdownDelay{}, false, nil
+	}
+	if err != nil {
+		return shutdownDelay{}, false, err
+	}
+
+	value, ok := apiServer.Annotations[ShutdownDelayDurationAnnotation]
+	if !ok {
+		return shutdownDelay{}, false, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return shutdownDelay{warning: fmt.Sprintf("Ignoring invalid %s annotation value %q: %v", ShutdownDelayDurationAnnotation, value, err)}, false, nil
+	}
+	if duration < 0 {
+		return shutdownDelay{warning: fmt.Sprintf("Ignoring negative %s annotation value %q", ShutdownDelayDurationAnnotation, value)}, false, nil
+	}
+	if duration > maxShutdownDelayDuration {
+		return shutdownDelay{
+			duration: maxShutdownDelayDuration,
+			warning:  fmt.Sprintf("The %s annotation value %q exceeds the maximum of %v, using the maximum", ShutdownDelayDurationAnnotation, value, maxShutdownDelayDuration),
+		}, true, nil
+	}
+	return shutdownDelay{duration: duration}, true, nil
+}
@@ -27,6 +27,7 @@ func TestObserveWatchTerminationDuration(t *testing.T) {
 		expectedKubeAPIConfig   map[string]interface{}
 		platformType            configv1.PlatformType
 		controlPlaneTopology    configv1.TopologyMode
+		annotations             map[string]string
 	}{
 
 		// scenario 1
@@ -64,6 +65,29 @@ func TestObserveWatchTerminationDuration(t *testing.T) {
 			controlPlaneTopology:  configv1.SingleReplicaTopologyMode,
 			platformType:          configv1.AWSPlatformType,
 		},
+
+		// scenario 6
+		{
+			name:                  "shutdown-delay-duration override takes precedence and leaves time for in-flight requests",
+			expectedKubeAPIConfig: map[string]interface{}{"gracefulTerminationDuration": "155"},
+			controlPlaneTopology:  configv1.SingleReplicaTopologyMode,
+			annotations:           map[string]string{ShutdownDelayDurationAnnotation: "90s"},
+		},
+
+		// scenario 7
+		{
+			name:                  "capped shutdown-delay-duration override",
+			expectedKubeAPIConfig: map[string]interface{}{"gracefulTerminationDuration": "245"},
+			annotations:           map[string]string{ShutdownDelayDurationAnnotation: "1h"},
+		},
+
+		// scenario 8
+		{
+			name:                  "negative shutdown-delay-duration override is ignored",
+			expectedKubeAPIConfig: map[string]interface{}{"gracefulTerminationDuration": "194"},
+			platformType:          configv1.AWSPlatformType,
+			annotations:           map[string]string{ShutdownDelayDurationAnnotation: "-10s"},
+		},
 	}
 
 	for _, scenario := range scenarios {
@@ -78,6 +102,7 @@ func TestObserveWatchTerminationDuration(t *testing.T) {
 			})
 			listers := configobservation.Listers{
 				InfrastructureLister_: configlistersv1.NewInfrastructureLister(infrastructureIndexer),
+				APIServerLister_:      apiServerListerWithAnnotations(t, scenario.annotations),
 			}
 
 			// act
@@ -101,6 +126,8 @@ func TestObserveShutdownDelayDuration(t *testing.T) {
 		existingConfig          kubecontrolplanev1.KubeAPIServerConfig
 		platformType            configv1.PlatformType
 		controlPlaneTopology    configv1.TopologyMode
+		annotations             map[string]string
+		expectedWarning         bool
 	}{
 
 		// scenario 1
@@ -177,6 +204,47 @@ func TestObserveShutdownDelayDuration(t *testing.T) {
 			controlPlaneTopology: configv1.SingleReplicaTopologyMode,
 			platformType:         configv1.AWSPlatformType,
 		},
+
+		// scenario 6
+		{
+			name:                    "valid shutdown-delay-duration override takes precedence over the platform",
+			validateKubeAPIConfigFn: expectShutdownDelayDuration("1m30s"),
+			existingConfig:          kubecontrolplanev1.KubeAPIServerConfig{APIServerArguments: map[string]kubecontrolplanev1.Arguments{"shutdown-delay-duration": {"70s"}}},
+			platformType:            configv1.AWSPlatformType,
+			annotations:             map[string]string{ShutdownDelayDurationAnnotation: "90s"},
+		},
+
+		// scenario 7
+		{
+			name:                    "zero shutdown-delay-duration override",
+			validateKubeAPIConfigFn: expectShutdownDelayDuration("0s"),
+			annotations:             map[string]string{ShutdownDelayDurationAnnotation: "0s"},
+		},
+
+		// scenario 8
+		{
+			name:                    "negative shutdown-delay-duration override is rejected",
+			validateKubeAPIConfigFn: expectShutdownDelayDuration("129s"),
+			platformType:            configv1.AWSPlatformType,
+			annotations:             map[string]string{ShutdownDelayDurationAnnotation: "-10s"},
+			expectedWarning:         true,
+		},
+
+		// scenario 9
+		{
+			name:                    "invalid shutdown-delay-duration override is rejected",
+			validateKubeAPIConfigFn: expectShutdownDelayDuration(""),
+			annotations:             map[string]string{ShutdownDelayDurationAnnotation: "soon"},
+			expectedWarning:         true,
+		},
+
+		// scenario 10
+		{
+			name:                    "shutdown-delay-duration override above the maximum is capped",
+			validateKubeAPIConfigFn: expectShutdownDelayDuration("3m0s"),
+			annotations:             map[string]string{ShutdownDelayDurationAnnotation: "10m"},
+			expectedWarning:         true,
+		},
 	}
 
 	for _, scenario := range scenarios {
@@ -191,6 +259,7 @@ func TestObserveShutdownDelayDuration(t *testing.T) {
 			})
 			listers := configobservation.Listers{
 				InfrastructureLister_: configlistersv1.NewInfrastructureLister(infrastructureIndexer),
+				APIServerLister_:      apiServerListerWithAnnotations(t, scenario.annotations),
 			}
 
 			// act
@@ -205,10 +274,108 @@ func TestObserveShutdownDelayDuration(t *testing.T) {
 			if err := scenario.validateKubeAPIConfigFn(actualKubeAPIServerConfig); err != nil {
 				t.Fatal(err)
 			}
+			if warned := len(eventRecorder.Events()) > 0; warned != scenario.expectedWarning {
+				t.Fatalf("expected warning %v, got events %v", scenario.expectedWarning, eventRecorder.Events())
+			}
+		})
+	}
+}
+
+func TestObserveShutdownSendRetryAfter(t *testing.T) {
+	scenarios := []struct {
+		name            string
+		annotations     map[string]string
+		existingConfig  map[string]interface{}
+		expectedConfig  map[string]interface{}
+		expectedWarning bool
+	}{
+		{
+			name:           "not set",
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name:        "disabled",
+			annotations: map[string]string{ShutdownSendRetryAfterAnnotation: "false"},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"shutdown-send-retry-after": []interface{}{"false"},
+			}},
+		},
+		{
+			name:        "enabled",
+			annotations: map[string]string{ShutdownSendRetryAfterAnnotation: "True"},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"shutdown-send-retry-after": []interface{}{"true"},
+			}},
+		},
+		{
+			name:            "invalid without a previous value",
+			annotations:     map[string]string{ShutdownSendRetryAfterAnnotation: "sometimes"},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+		{
+			name:        "invalid keeps the previous value",
+			annotations: map[string]string{ShutdownSendRetryAfterAnnotation: "sometimes"},
+			existingConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"shutdown-send-retry-after": []interface{}{"true"},
+			}},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"shutdown-send-retry-after": []interface{}{"true"},
+			}},
+			expectedWarning: true,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			eventRecorder := events.NewInMemoryRecorder("")
+			listers := configobservation.Listers{
+				APIServerLister_: apiServerListerWithAnnotations(t, scenario.annotations),
+			}
+
+			existingConfig := scenario.existingConfig
+			if existingConfig == nil {
+				existingConfig = map[string]interface{}{}
+			}
+			observedConfig, errs := ObserveShutdownSendRetryAfter(listers, eventRecorder, existingConfig)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if !cmp.Equal(scenario.expectedConfig, observedConfig) {
+				t.Fatalf("unexpected configuration, diff = %v", cmp.Diff(scenario.expectedConfig, observedConfig))
+			}
+			if warned := len(eventRecorder.Events()) > 0; warned != scenario.expectedWarning {
+				t.Fatalf("expected warning %v, got events %v", scenario.expectedWarning, eventRecorder.Events())
+			}
 		})
 	}
 }
 
+func expectShutdownDelayDuration(expected string) func(kubecontrolplanev1.KubeAPIServerConfig) error {
+	return func(actualKasConfig kubecontrolplanev1.KubeAPIServerConfig) error {
+		shutdownDurationArgs := actualKasConfig.APIServerArguments["shutdown-delay-duration"]
+		if len(expected) == 0 {
+			if len(shutdownDurationArgs) > 0 {
+				return fmt.Errorf("didn't expect to find a value for shutdown-delay-duration key, got %v", shutdownDurationArgs)
+			}
+			return nil
+		}
+		if len(shutdownDurationArgs) != 1 {
+			return fmt.Errorf("expected only one argument under shutdown-delay-duration key, got %d", len(shutdownDurationArgs))
+		}
+		if shutdownDurationArgs[0] != expected {
+			return fmt.Errorf("incorrect shutdown-delay-duration value, expected = %s, got %v", expected, shutdownDurationArgs[0])
+		}
+		return nil
+	}
+}
+
+func apiServerListerWithAnnotations(t *testing.T, annotations map[string]string) configlistersv1.APIServerLister {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, indexer.Add(&configv1.APIServer{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Annotations: annotations}}))
+	return configlistersv1.NewAPIServerLister(indexer)
+}
+
 func unstructuredAPIConfig(t *testing.T, existingCfg kubecontrolplanev1.KubeAPIServerConfig) map[string]interface{} {
 	existingCfg.TypeMeta = metav1.TypeMeta{
 		Kind: "KubeAPIServerConfig",
@@ -0,0 +1,191 @@
+package apiserver
+
+import (
+	"fmt"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+)
+
+// TLSMinVersionAnnotation on the cluster APIServer config raises the minimum TLS version, e.g. "VersionTLS13", above
+// the minimum version of the TLS security profile. It cannot lower it.
+const TLSMinVersionAnnotation = "kubeapiserver.operator.openshift.io/tls-min-version"
+
+var (
+	minTLSVersionPath = []string{"servingInfo", "minTLSVersion"}
+	cipherSuitesPath  = []string{"servingInfo", "cipherSuites"}
+
+	// tls13Ciphers are not configurable in Go, they are always used in TLS 1.3 flows
+	tls13Ciphers = sets.NewString("TLS_AES_128_GCM_SHA256", "TLS_AES_256_GCM_SHA384", "TLS_CHACHA20_POLY1305_SHA256")
+)
+
+// NewTLSSecurityProfileObserver returns an ObserveConfigFunc that observes APIServer.Spec.TLSSecurityProfile and sets
+// the servingInfo.minTLSVersion and servingInfo.cipherSuites fields of the observed config.
+// The requiredCipherSuites (IANA names) are appended after the ciphers of the profile, which keep their order.
+// Duplicates are dropped. Profiles with a minimum version of TLS 1.3 are left untouched as ciphers are not configurable there.
+// The TLSMinVersionAnnotation overrides the minimum version of the profile, with a warning if the ciphers of the
+// profile do not fit the overridden version. An unknown version, or one below the minimum version of the profile, is
+// rejected with a warning and the previously observed version is kept, unless the profile now requires more.
+func NewTLSSecurityProfileObserver(requiredCipherSuites ...string) configobserver.ObserveConfigFunc {
+	return func(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, _ []error) {
+		defer func() {
+			ret = configobserver.Pruned(ret, minTLSVersionPath, cipherSuitesPath)
+		}()
+
+		listers := genericListers.(configobservation.Listers)
+		errs := []error{}
+
+		currentMinTLSVersion, _, err := unstructured.NestedString(existingConfig, minTLSVersionPath...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to retrieve servingInfo.minTLSVersion: %v", err))
+			// keep going on read error from existing config
+		}
+		currentCipherSuites, _, err := unstructured.NestedStringSlice(existingConfig, cipherSuitesPath...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to retrieve servingInfo.cipherSuites: %v", err))
+			// keep going on read error from existing config
+		}
+
+		apiServer, err := listers.APIServerLister().Get("cluster")
+		if errors.IsNotFound(err) {
+			klog.Warningf("apiserver.config.openshift.io/cluster: not found")
+			apiServer = &configv1.APIServer{}
+		} else if err != nil {
+			return existingConfig, append(errs, err)
+		}
+
+		profile := apiServer.Spec.TLSSecurityProfile
+		profileSpec := tlsProfileSpec(profile)
+		if profile != nil && profile.Type == configv1.TLSProfileCustomType {
+			// the predefined profiles include OpenSSL ciphers Go does not support, only user provided ones are worth a warning.
+			// The unknown ciphers are dropped, the remaining ones are still observed.
+			if unknown := unknownCiphers(profileSpec.Ciphers); len(unknown) > 0 {
+				recorder.Warningf("ObserveTLSSecurityProfile", "Ignoring unknown ciphers %q of the custom TLS security profile", unknown)
+			}
+		}
+
+		minTLSVersion := profileSpec.MinTLSVersion
+		observedCipherSuites := crypto.OpenSSLToIANACipherSuites(profileSpec.Ciphers)
+		if value, ok := apiServer.Annotations[TLSMinVersionAnnotation]; ok {
+			if invalid := validateTLSMinVersion(value, profileSpec.MinTLSVersion); invalid != nil {
+				previousConfig := map[string]interface{}{}
+				if err := KeepPreviousValue(recorder, "ObserveTLSSecurityProfile", TLSMinVersionAnnotation, value, invalid, existingConfig, previousConfig, minTLSVersionPath); err != nil {
+					errs = append(errs, err)
+				}
+				// the profile may have been raised since the previous version was observed, it still wins
+				if previous, _, _ := unstructured.NestedString(previousConfig, minTLSVersionPath...); len(previous) > 0 && validateTLSMinVersion(previous, profileSpec.MinTLSVersion) == nil {
+					minTLSVersion = configv1.TLSProtocolVersion(previous)
+				}
+			} else {
+				minTLSVersion = configv1.TLSProtocolVersion(value)
+				if conflict := tlsMinVersionConflict(minTLSVersion, observedCipherSuites); len(conflict) > 0 {
+					recorder.Warningf("ObserveTLSSecurityProfile", "The %s annotation value %q conflicts with the TLS security profile: %s", TLSMinVersionAnnotation, value, conflict)
+				}
+			}
+		}
+
+		observedMinTLSVersion := string(minTLSVersion)
+		if minTLSVersion != configv1.VersionTLS13 {
+			observedCipherSuites = mergeCipherSuites(observedCipherSuites, requiredCipherSuites)
+		}
+
+		observedConfig := map[string]interface{}{}
+		if err := unstructured.SetNestedField(observedConfig, observedMinTLSVersion, minTLSVersionPath...); err != nil {
+			return existingConfig, append(errs, err)
+		}
+		if err := unstructured.SetNestedStringSlice(observedConfig, observedCipherSuites, cipherSuitesPath...); err != nil {
+			return existingConfig, append(errs, err)
+		}
+
+		if observedMinTLSVersion != currentMinTLSVersion {
+			recorder.Eventf("ObserveTLSSecurityProfile", "minTLSVersion changed to %s", observedMinTLSVersion)
+		}
+		if !reflect.DeepEqual(observedCipherSuites, currentCipherSuites) {
+			recorder.Eventf("ObserveTLSSecurityProfile", "cipherSuites changed to %q", observedCipherSuites)
+		}
+
+		return observedConfig, errs
+	}
+}
+
+// validateTLSMinVersion accepts a known TLS version not below the minimum version of the profile.
+func validateTLSMinVersion(value string, profileMinTLSVersion configv1.TLSProtocolVersion) error {
+	version, err := crypto.TLSVersion(value)
+	if err != nil {
+		return err
+	}
+	if profileVersion, err := crypto.TLSVersion(string(profileMinTLSVersion)); err == nil && version < profileVersion {
+		return fmt.Errorf("below the minimum version %s of the TLS security profile", profileMinTLSVersion)
+	}
+	return nil
+}
+
+// tlsProfileSpec returns the spec of the given profile, or of the Intermediate profile if none is set.
+func tlsProfileSpec(profile *configv1.TLSSecurityProfile) *configv1.TLSProfileSpec {
+	profileType := configv1.TLSProfileIntermediateType
+	if profile != nil {
+		profileType = profile.Type
+	}
+
+	var profileSpec *configv1.TLSProfileSpec
+	if profileType == configv1.TLSProfileCustomType {
+		if profile.Custom != nil {
+			profileSpec = &profile.Custom.TLSProfileSpec
+		}
+	} else {
+		profileSpec = configv1.TLSProfiles[profileType]
+	}
+
+	// nothing found / custom type set but no actual custom spec
+	if profileSpec == nil {
+		profileSpec = configv1.TLSProfiles[configv1.TLSProfileIntermediateType]
+	}
+	return profileSpec
+}
+
+// tlsMinVersionConflict describes why the cipher suites of a profile do not fit minTLSVersion, empty if they do. The
+// cipher suites are only used below TLS 1.3, and are needed there.
+func tlsMinVersionConflict(minTLSVersion configv1.TLSProtocolVersion, cipherSuites []string) string {
+	switch {
+	case minTLSVersion == configv1.VersionTLS13 && len(cipherSuites) > 0:
+		return fmt.Sprintf("the cipher suites %q of the profile are not used with TLS 1.3", cipherSuites)
+	case minTLSVersion != configv1.VersionTLS13 && len(cipherSuites) == 0:
+		return "the profile has no cipher suites for versions below TLS 1.3"
+	}
+	return ""
+}
+
+// unknownCiphers returns the OpenSSL cipher names that have no IANA equivalent known to Go.
+func unknownCiphers(ciphers []string) []string {
+	var unknown []string
+	for _, cipher := range ciphers {
+		if !tls13Ciphers.Has(cipher) && len(crypto.OpenSSLToIANACipherSuites([]string{cipher})) == 0 {
+			unknown = append(unknown, cipher)
+		}
+	}
+	return unknown
+}
+
+// mergeCipherSuites appends the required cipher suites that are missing from cipherSuites, preserving the order of both.
+func mergeCipherSuites(cipherSuites, requiredCipherSuites []string) []string {
+	seen := sets.NewString()
+	merged := []string{}
+	for _, cipherSuite := range append(append([]string{}, cipherSuites...), requiredCipherSuites...) {
+		if seen.Has(cipherSuite) {
+			continue
+		}
+		seen.Insert(cipherSuite)
+		merged = append(merged, cipherSuite)
+	}
+	return merged
+}
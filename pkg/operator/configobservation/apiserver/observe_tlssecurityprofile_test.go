@@ -0,0 +1,240 @@
+package apiserver
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/diff"
+	"k8s.io/client-go/tools/cache"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+)
+
+func TestObserveTLSSecurityProfile(t *testing.T) {
+	required := []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_RSA_WITH_AES_128_GCM_SHA256"}
+
+	testCases := []struct {
+		name                  string
+		profile               *configv1.TLSSecurityProfile
+		annotations           map[string]string
+		existingMinTLSVersion string
+		required              []string
+		expectedMinTLSVersion string
+		expectedCipherSuites  []string
+		expectWarning         bool
+	}{
+		{
+			name:                  "NoProfile",
+			expectedMinTLSVersion: "VersionTLS12",
+			expectedCipherSuites:  crypto.OpenSSLToIANACipherSuites(configv1.TLSProfiles[configv1.TLSProfileIntermediateType].Ciphers),
+		},
+		{
+			name:                  "Old",
+			profile:               &configv1.TLSSecurityProfile{Type: configv1.TLSProfileOldType},
+			expectedMinTLSVersion: "VersionTLS10",
+			expectedCipherSuites:  crypto.OpenSSLToIANACipherSuites(configv1.TLSProfiles[configv1.TLSProfileOldType].Ciphers),
+		},
+		{
+			name:                  "Intermediate",
+			profile:               &configv1.TLSSecurityProfile{Type: configv1.TLSProfileIntermediateType},
+			expectedMinTLSVersion: "VersionTLS12",
+			expectedCipherSuites:  crypto.OpenSSLToIANACipherSuites(configv1.TLSProfiles[configv1.TLSProfileIntermediateType].Ciphers),
+		},
+		{
+			name:                  "Modern",
+			profile:               &configv1.TLSSecurityProfile{Type: configv1.TLSProfileModernType},
+			expectedMinTLSVersion: "VersionTLS13",
+			expectedCipherSuites:  []string{},
+		},
+		{
+			name: "Custom",
+			profile: &configv1.TLSSecurityProfile{
+				Type: configv1.TLSProfileCustomType,
+				Custom: &configv1.CustomTLSProfile{TLSProfileSpec: configv1.TLSProfileSpec{
+					Ciphers:       []string{"ECDHE-RSA-AES256-GCM-SHA384", "ECDHE-ECDSA-AES128-GCM-SHA256"},
+					MinTLSVersion: configv1.VersionTLS12,
+				}},
+			},
+			expectedMinTLSVersion: "VersionTLS12",
+			expectedCipherSuites:  []string{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384", "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"},
+		},
+		{
+			name: "CustomWithUnknownCiphers",
+			profile: &configv1.TLSSecurityProfile{
+				Type: configv1.TLSProfileCustomType,
+				Custom: &configv1.CustomTLSProfile{TLSProfileSpec: configv1.TLSProfileSpec{
+					Ciphers:       []string{"ECDHE-RSA-AES256-GCM-SHA384", "NOT-A-CIPHER"},
+					MinTLSVersion: configv1.VersionTLS12,
+				}},
+			},
+			expectedMinTLSVersion: "VersionTLS12",
+			expectedCipherSuites:  []string{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"},
+			expectWarning:         true,
+		},
+		{
+			name: "CustomMergesRequired",
+			profile: &configv1.TLSSecurityProfile{
+				Type: configv1.TLSProfileCustomType,
+				Custom: &configv1.CustomTLSProfile{TLSProfileSpec: configv1.TLSProfileSpec{
+					Ciphers:       []string{"AES128-GCM-SHA256", "ECDHE-RSA-AES256-GCM-SHA384"},
+					MinTLSVersion: configv1.VersionTLS12,
+				}},
+			},
+			required:              required,
+			expectedMinTLSVersion: "VersionTLS12",
+			// user ordering first, the already present required cipher is not repeated
+			expectedCipherSuites: []string{"TLS_RSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384", "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+		},
+		{
+			name:                  "ModernIgnoresRequired",
+			profile:               &configv1.TLSSecurityProfile{Type: configv1.TLSProfileModernType},
+			required:              required,
+			expectedMinTLSVersion: "VersionTLS13",
+			expectedCipherSuites:  []string{},
+		},
+		{
+			name:                  "MinVersionOverride",
+			profile:               &configv1.TLSSecurityProfile{Type: configv1.TLSProfileOldType},
+			annotations:           map[string]string{TLSMinVersionAnnotation: "VersionTLS12"},
+			expectedMinTLSVersion: "VersionTLS12",
+			expectedCipherSuites:  crypto.OpenSSLToIANACipherSuites(configv1.TLSProfiles[configv1.TLSProfileOldType].Ciphers),
+		},
+		{
+			name:                  "MinVersionOverrideToTLS13ConflictsWithProfileCiphers",
+			annotations:           map[string]string{TLSMinVersionAnnotation: "VersionTLS13"},
+			required:              required,
+			expectedMinTLSVersion: "VersionTLS13",
+			expectedCipherSuites:  crypto.OpenSSLToIANACipherSuites(configv1.TLSProfiles[configv1.TLSProfileIntermediateType].Ciphers),
+			expectWarning:         true,
+		},
+		{
+			name: "MinVersionOverrideBelowTLS13ConflictsWithCustomCiphers",
+			profile: &configv1.TLSSecurityProfile{
+				Type: configv1.TLSProfileCustomType,
+				Custom: &configv1.CustomTLSProfile{TLSProfileSpec: configv1.TLSProfileSpec{
+					Ciphers:       []string{},
+					MinTLSVersion: configv1.VersionTLS11,
+				}},
+			},
+			annotations:           map[string]string{TLSMinVersionAnnotation: "VersionTLS12"},
+			required:              required,
+			expectedMinTLSVersion: "VersionTLS12",
+			expectedCipherSuites:  required,
+			expectWarning:         true,
+		},
+		{
+			name:                  "MinVersionDowngradeOfModernRejected",
+			profile:               &configv1.TLSSecurityProfile{Type: configv1.TLSProfileModernType},
+			annotations:           map[string]string{TLSMinVersionAnnotation: "VersionTLS12"},
+			required:              required,
+			expectedMinTLSVersion: "VersionTLS13",
+			expectedCipherSuites:  []string{},
+			expectWarning:         true,
+		},
+		{
+			name:                  "MinVersionDowngradeOfIntermediateRejected",
+			annotations:           map[string]string{TLSMinVersionAnnotation: "VersionTLS10"},
+			expectedMinTLSVersion: "VersionTLS12",
+			expectedCipherSuites:  crypto.OpenSSLToIANACipherSuites(configv1.TLSProfiles[configv1.TLSProfileIntermediateType].Ciphers),
+			expectWarning:         true,
+		},
+		{
+			name:                  "MinVersionOverrideEqualToProfile",
+			annotations:           map[string]string{TLSMinVersionAnnotation: "VersionTLS12"},
+			expectedMinTLSVersion: "VersionTLS12",
+			expectedCipherSuites:  crypto.OpenSSLToIANACipherSuites(configv1.TLSProfiles[configv1.TLSProfileIntermediateType].Ciphers),
+		},
+		{
+			name:                  "InvalidMinVersionOverrideKeepsPreviousVersion",
+			annotations:           map[string]string{TLSMinVersionAnnotation: "TLSv1.3"},
+			existingMinTLSVersion: "VersionTLS13",
+			required:              required,
+			expectedMinTLSVersion: "VersionTLS13",
+			expectedCipherSuites:  crypto.OpenSSLToIANACipherSuites(configv1.TLSProfiles[configv1.TLSProfileIntermediateType].Ciphers),
+			expectWarning:         true,
+		},
+		{
+			name:                  "MinVersionDowngradeKeepsPreviousVersion",
+			profile:               &configv1.TLSSecurityProfile{Type: configv1.TLSProfileOldType},
+			annotations:           map[string]string{TLSMinVersionAnnotation: "VersionTLS9"},
+			existingMinTLSVersion: "VersionTLS12",
+			expectedMinTLSVersion: "VersionTLS12",
+			expectedCipherSuites:  crypto.OpenSSLToIANACipherSuites(configv1.TLSProfiles[configv1.TLSProfileOldType].Ciphers),
+			expectWarning:         true,
+		},
+		{
+			name:                  "RaisedProfileWinsOverPreviousVersion",
+			profile:               &configv1.TLSSecurityProfile{Type: configv1.TLSProfileModernType},
+			annotations:           map[string]string{TLSMinVersionAnnotation: "TLSv1.2"},
+			existingMinTLSVersion: "VersionTLS12",
+			expectedMinTLSVersion: "VersionTLS13",
+			expectedCipherSuites:  []string{},
+			expectWarning:         true,
+		},
+		{
+			name:                  "InvalidMinVersionOverride",
+			profile:               &configv1.TLSSecurityProfile{Type: configv1.TLSProfileModernType},
+			annotations:           map[string]string{TLSMinVersionAnnotation: "TLSv1.2"},
+			expectedMinTLSVersion: "VersionTLS13",
+			expectedCipherSuites:  []string{},
+			expectWarning:         true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := indexer.Add(newAPIServerConfig(func(apiServer *configv1.APIServer) {
+				apiServer.Spec.TLSSecurityProfile = tc.profile
+				apiServer.Annotations = tc.annotations
+			})); err != nil {
+				t.Fatal(err)
+			}
+			listers := configobservation.Listers{
+				APIServerLister_: configlistersv1.NewAPIServerLister(indexer),
+			}
+			recorder := events.NewInMemoryRecorder(t.Name())
+
+			existingConfig := map[string]interface{}{}
+			if len(tc.existingMinTLSVersion) > 0 {
+				if err := unstructured.SetNestedField(existingConfig, tc.existingMinTLSVersion, minTLSVersionPath...); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, errs := NewTLSSecurityProfileObserver(tc.required...)(listers, recorder, existingConfig)
+			if len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			minTLSVersion, _, err := unstructured.NestedString(result, minTLSVersionPath...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if minTLSVersion != tc.expectedMinTLSVersion {
+				t.Errorf("expected minTLSVersion %s, got %s", tc.expectedMinTLSVersion, minTLSVersion)
+			}
+			cipherSuites, _, err := unstructured.NestedStringSlice(result, cipherSuitesPath...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tc.expectedCipherSuites, cipherSuites) {
+				t.Errorf("unexpected cipherSuites: %s", diff.ObjectDiff(tc.expectedCipherSuites, cipherSuites))
+			}
+
+			warned := false
+			for _, event := range recorder.Events() {
+				if event.Type == "Warning" && event.Reason == "ObserveTLSSecurityProfile" {
+					warned = true
+				}
+			}
+			if warned != tc.expectWarning {
+				t.Errorf("expected warning event %v, got %v", tc.expectWarning, warned)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package apiserver
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+// WatchCacheSizesAnnotation on the cluster APIServer config sets the watch cache size of some resources, as a comma
+// separated list of resource[.group]#size entries, e.g. "secrets#2000,deployments.apps#1000". A size of 0 disables
+// the watch cache of the resource.
+const WatchCacheSizesAnnotation = "kubeapiserver.operator.openshift.io/watch-cache-sizes"
+
+var watchCacheSizesPath = []string{"apiServerArguments", "watch-cache-sizes"}
+
+// defaultWatchCacheSizes are the sizes the annotation is merged into. They match the kube-apiserver defaults: the
+// watch cache of events is disabled, the resource is written a lot and hardly ever watched.
+var defaultWatchCacheSizes = map[schema.GroupResource]int{
+	{Resource: "events"}: 0,
+}
+
+// ObserveWatchCacheSizes sets watch-cache-sizes from the WatchCacheSizesAnnotation of the cluster APIServer config
+// merged into the default sizes, the sizes of the annotation win. An annotation with an invalid entry is rejected with
+// a warning and the previously observed sizes are kept. Nothing is set when the annotation is not.
+func ObserveWatchCacheSizes(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	defer func() {
+		ret = configobserver.Pruned(ret, watchCacheSizesPath)
+	}()
+
+	listers := genericListers.(configobservation.Listers)
+	apiServer, err := listers.APIServerLister().Get("cluster")
+	if apierrors.IsNotFound(err) {
+		return map[string]interface{}{}, errs
+	}
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+	value, ok := apiServer.Annotations[WatchCacheSizesAnnotation]
+	if !ok {
+		return map[string]interface{}{}, errs
+	}
+
+	sizes := map[schema.GroupResource]int{}
+	for gr, size := range defaultWatchCacheSizes {
+		sizes[gr] = size
+	}
+	var entryErrs []error
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		gr, size, err := parseWatchCacheSize(entry)
+		if err != nil {
+			entryErrs = append(entryErrs, fmt.Errorf("entry %q: %v", entry, err))
+			continue
+		}
+		sizes[gr] = size
+	}
+
+	observedConfig := map[string]interface{}{}
+	if len(entryErrs) > 0 {
+		if err := KeepPreviousValue(recorder, "ObserveWatchCacheSizes", WatchCacheSizesAnnotation, value, utilerrors.NewAggregate(entryErrs), existingConfig, observedConfig, watchCacheSizesPath); err != nil {
+			errs = append(errs, err)
+		}
+		return observedConfig, errs
+	}
+
+	var watchCacheSizes []string
+	for gr, size := range sizes {
+		watchCacheSizes = append(watchCacheSizes, fmt.Sprintf("%s#%d", gr.String(), size))
+	}
+	sort.Strings(watchCacheSizes)
+
+	if err := unstructured.SetNestedStringSlice(observedConfig, watchCacheSizes, watchCacheSizesPath...); err != nil {
+		return existingConfig, append(errs, err)
+	}
+	return observedConfig, errs
+}
+
+// parseWatchCacheSize parses a resource[.group]#size entry, the resource must be a lowercase plural resource name
+// and the size a non-negative integer.
+func parseWatchCacheSize(entry string) (schema.GroupResource, int, error) {
+	tokens := strings.Split(entry, "#")
+	if len(tokens) != 2 {
+		return schema.GroupResource{}, 0, fmt.Errorf("must be resource[.group]#size")
+	}
+	gr := schema.ParseGroupResource(tokens[0])
+	if errs := validation.IsDNS1123Label(gr.Resource); len(errs) > 0 {
+		return schema.GroupResource{}, 0, fmt.Errorf("invalid resource %q: %s", gr.Resource, strings.Join(errs, ", "))
+	}
+	if len(gr.Group) > 0 {
+		if errs := validation.IsDNS1123Subdomain(gr.Group); len(errs) > 0 {
+			return schema.GroupResource{}, 0, fmt.Errorf("invalid group %q: %s", gr.Group, strings.Join(errs, ", "))
+		}
+	}
+	size, err := strconv.Atoi(tokens[1])
+	if err != nil {
+		return schema.GroupResource{}, 0, fmt.Errorf("the size must be an integer")
+	}
+	if size < 0 {
+		return schema.GroupResource{}, 0, fmt.Errorf("the size must not be negative")
+	}
+	return gr, size, nil
+}
@@ -0,0 +1,98 @@
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestObserveWatchCacheSizes(t *testing.T) {
+	scenarios := []struct {
+		name            string
+		annotations     map[string]string
+		existingConfig  map[string]interface{}
+		expectedConfig  map[string]interface{}
+		expectedWarning bool
+	}{
+		{
+			name:           "not set: the kube-apiserver defaults apply",
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name:        "custom sizes are merged with the defaults",
+			annotations: map[string]string{WatchCacheSizesAnnotation: "secrets#2000, deployments.apps#1000"},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"watch-cache-sizes": []interface{}{"deployments.apps#1000", "events#0", "secrets#2000"},
+			}},
+		},
+		{
+			name:        "a default is overridden",
+			annotations: map[string]string{WatchCacheSizesAnnotation: "events#500"},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"watch-cache-sizes": []interface{}{"events#500"},
+			}},
+		},
+		{
+			name:        "a watch cache is disabled",
+			annotations: map[string]string{WatchCacheSizesAnnotation: "leases.coordination.k8s.io#0"},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"watch-cache-sizes": []interface{}{"events#0", "leases.coordination.k8s.io#0"},
+			}},
+		},
+		{
+			name:        "invalid entries reject the annotation and keep the previous sizes",
+			annotations: map[string]string{WatchCacheSizesAnnotation: "pods#-1,Secrets#100,configmaps,nodes#many,routes.route_openshift#10,services#3000"},
+			existingConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"watch-cache-sizes": []interface{}{"events#0", "secrets#2000"},
+			}},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"watch-cache-sizes": []interface{}{"events#0", "secrets#2000"},
+			}},
+			expectedWarning: true,
+		},
+		{
+			name:            "invalid entries without previous sizes",
+			annotations:     map[string]string{WatchCacheSizesAnnotation: "pods#-1,services#3000"},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+		{
+			name:        "empty: the defaults are set",
+			annotations: map[string]string{WatchCacheSizesAnnotation: ""},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"watch-cache-sizes": []interface{}{"events#0"},
+			}},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			eventRecorder := events.NewInMemoryRecorder("")
+			listers := configobservation.Listers{
+				APIServerLister_: apiServerListerWithAnnotations(t, scenario.annotations),
+			}
+
+			existingConfig := scenario.existingConfig
+			if existingConfig == nil {
+				existingConfig = map[string]interface{}{}
+			}
+
+			observedConfig, errs := ObserveWatchCacheSizes(listers, eventRecorder, existingConfig)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if !cmp.Equal(scenario.expectedConfig, observedConfig) {
+				t.Fatalf("unexpected configuration, diff = %v", cmp.Diff(scenario.expectedConfig, observedConfig))
+			}
+			if warned := len(eventRecorder.Events()) > 0; warned != scenario.expectedWarning {
+				t.Fatalf("expected warning %v, got events %v", scenario.expectedWarning, eventRecorder.Events())
+			}
+			if scenario.expectedWarning && len(eventRecorder.Events()) != 1 {
+				t.Fatalf("expected a single warning for the rejected annotation, got events %v", eventRecorder.Events())
+			}
+		})
+	}
+}
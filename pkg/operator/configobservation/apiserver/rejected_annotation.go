@@ -0,0 +1,36 @@
+package apiserver
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+// KeepPreviousValue is how the annotation observers reject an invalid annotation value: it warns about the value with
+// the reason of the observer and copies the previously observed value at path, if any, from the existing config into
+// the observed config. Callers go on with the other arguments they observe.
+func KeepPreviousValue(recorder events.Recorder, reason, annotation, value string, invalid error, existingConfig, observedConfig map[string]interface{}, path []string) error {
+	recorder.Warningf(reason, "Rejecting invalid %s annotation value %q, keeping the previous value: %v", annotation, value, invalid)
+	return copyPreviousValue(existingConfig, observedConfig, path)
+}
+
+// copyPreviousValue copies the previously observed value at path, if any, from the existing config into the observed
+// config. The value is an argument, a string slice, or a string field like servingInfo.minTLSVersion.
+func copyPreviousValue(existingConfig, observedConfig map[string]interface{}, path []string) error {
+	if current, ok, _ := unstructured.NestedString(existingConfig, path...); ok {
+		if len(current) == 0 {
+			return nil
+		}
+		return unstructured.SetNestedField(observedConfig, current, path...)
+	}
+	current, _, err := unstructured.NestedStringSlice(existingConfig, path...)
+	if err != nil {
+		return fmt.Errorf("unable to extract %s from the existing config: %v", path[len(path)-1], err)
+	}
+	if len(current) == 0 {
+		return nil
+	}
+	return unstructured.SetNestedStringSlice(observedConfig, current, path...)
+}
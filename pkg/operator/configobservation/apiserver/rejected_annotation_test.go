@@ -0,0 +1,58 @@
+package apiserver
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestKeepPreviousValue(t *testing.T) {
+	path := []string{"apiServerArguments", "event-ttl"}
+	scenarios := []struct {
+		name           string
+		existingConfig map[string]interface{}
+		expectedConfig map[string]interface{}
+		expectError    bool
+	}{
+		{
+			name:           "no previous value",
+			existingConfig: map[string]interface{}{},
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name:           "the previous value is kept",
+			existingConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{"event-ttl": []interface{}{"1h0m0s"}, "other": []interface{}{"x"}}},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{"event-ttl": []interface{}{"1h0m0s"}}},
+		},
+		{
+			name:           "a previous string value is kept",
+			existingConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{"event-ttl": "1h0m0s"}},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{"event-ttl": "1h0m0s"}},
+		},
+		{
+			name:           "unreadable previous value",
+			existingConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{"event-ttl": int64(3600)}},
+			expectedConfig: map[string]interface{}{},
+			expectError:    true,
+		},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			recorder := events.NewInMemoryRecorder(t.Name())
+			observedConfig := map[string]interface{}{}
+			err := KeepPreviousValue(recorder, "ObserveEventTTL", EventTTLAnnotation, "-1h", fmt.Errorf("must be positive"), scenario.existingConfig, observedConfig, path)
+			if (err != nil) != scenario.expectError {
+				t.Errorf("expected error %v, got %v", scenario.expectError, err)
+			}
+			if diff := cmp.Diff(scenario.expectedConfig, observedConfig); diff != "" {
+				t.Errorf("unexpected observed config (-want +got):\n%s", diff)
+			}
+			if events := recorder.Events(); len(events) != 1 || events[0].Type != "Warning" || events[0].Reason != "ObserveEventTTL" {
+				t.Errorf("expected one ObserveEventTTL warning, got %v", events)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation/apiserver"
+)
+
+const (
+	// RequestHeaderUsernameHeadersAnnotation on the cluster APIServer config adds comma separated headers to
+	// requestheader-username-headers, the headers the username of a request authenticated by the front proxy
+	// certificate is read from.
+	RequestHeaderUsernameHeadersAnnotation = "kubeapiserver.operator.openshift.io/requestheader-username-headers"
+	// RequestHeaderGroupHeadersAnnotation adds comma separated headers to requestheader-group-headers.
+	RequestHeaderGroupHeadersAnnotation = "kubeapiserver.operator.openshift.io/requestheader-group-headers"
+	// RequestHeaderExtraHeadersPrefixAnnotation adds comma separated header prefixes to
+	// requestheader-extra-headers-prefix.
+	RequestHeaderExtraHeadersPrefixAnnotation = "kubeapiserver.operator.openshift.io/requestheader-extra-headers-prefix"
+)
+
+type requestHeaderArgument struct {
+	annotation string
+	path       []string
+	// defaultHeader is the header of the default config. The aggregator always proxies the user in it, so it is kept
+	// first in the list for the aggregated apiservers reading the list from extension-apiserver-authentication.
+	defaultHeader string
+}
+
+var requestHeaderArguments = []requestHeaderArgument{
+	{annotation: RequestHeaderUsernameHeadersAnnotation, path: []string{"apiServerArguments", "requestheader-username-headers"}, defaultHeader: "X-Remote-User"},
+	{annotation: RequestHeaderGroupHeadersAnnotation, path: []string{"apiServerArguments", "requestheader-group-headers"}, defaultHeader: "X-Remote-Group"},
+	{annotation: RequestHeaderExtraHeadersPrefixAnnotation, path: []string{"apiServerArguments", "requestheader-extra-headers-prefix"}, defaultHeader: "X-Remote-Extra-"},
+}
+
+// ObserveRequestHeaders sets the requestheader username, group and extra headers prefix arguments from the
+// annotations of the cluster APIServer config. The headers of an annotation follow the default header, the default
+// config values are used for the arguments whose annotation is not set. An annotation with an empty or invalid
+// header, or a header carrying credentials, is rejected as a whole with a warning and the previously observed value of
+// its argument is kept.
+func ObserveRequestHeaders(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	requestHeaderPaths := [][]string{}
+	for _, arg := range requestHeaderArguments {
+		requestHeaderPaths = append(requestHeaderPaths, arg.path)
+	}
+	defer func() {
+		ret = configobserver.Pruned(ret, requestHeaderPaths...)
+	}()
+
+	listers := genericListers.(configobservation.Listers)
+	apiServer, err := listers.APIServerLister().Get("cluster")
+	if apierrors.IsNotFound(err) {
+		return map[string]interface{}{}, errs
+	}
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+
+	observedConfig := map[string]interface{}{}
+	for _, arg := range requestHeaderArguments {
+		value, ok := apiServer.Annotations[arg.annotation]
+		if !ok {
+			continue
+		}
+		headers, err := parseRequestHeaders(value)
+		if err != nil {
+			if err := apiserver.KeepPreviousValue(recorder, "ObserveRequestHeaders", arg.annotation, value, err, existingConfig, observedConfig, arg.path); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		rendered := []string{arg.defaultHeader}
+		seen := sets.NewString(http.CanonicalHeaderKey(arg.defaultHeader))
+		for _, header := range headers {
+			if seen.Has(http.CanonicalHeaderKey(header)) {
+				continue
+			}
+			seen.Insert(http.CanonicalHeaderKey(header))
+			rendered = append(rendered, header)
+		}
+		if err := unstructured.SetNestedStringSlice(observedConfig, rendered, arg.path...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return observedConfig, errs
+}
+
+// parseRequestHeaders returns the comma separated header names, which must be non-empty valid header names that do
+// not carry credentials or impersonation.
+func parseRequestHeaders(value string) ([]string, error) {
+	var headers []string
+	for _, header := range strings.Split(value, ",") {
+		header = strings.TrimSpace(header)
+		if len(header) == 0 {
+			return nil, fmt.Errorf("headers must not be empty")
+		}
+		if msgs := validation.IsHTTPHeaderName(header); len(msgs) > 0 {
+			return nil, fmt.Errorf("%q is not a valid header name: %s", header, strings.Join(msgs, ", "))
+		}
+		if canonical := http.CanonicalHeaderKey(header); canonical == "Authorization" || strings.HasPrefix(canonical, "Impersonate-") {
+			return nil, fmt.Errorf("%q is reserved", header)
+		}
+		headers = append(headers, header)
+	}
+	return headers, nil
+}
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+)
+
+func TestObserveRequestHeaders(t *testing.T) {
+	scenarios := []struct {
+		name            string
+		annotations     map[string]string
+		existingConfig  map[string]interface{}
+		expectedConfig  map[string]interface{}
+		expectedWarning bool
+	}{
+		{
+			name: "not set: the default config values apply",
+			existingConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"requestheader-username-headers": []interface{}{"X-Remote-User", "X-Forwarded-User"},
+			}},
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name: "custom headers follow the default ones",
+			annotations: map[string]string{
+				RequestHeaderUsernameHeadersAnnotation:    "X-Forwarded-User",
+				RequestHeaderGroupHeadersAnnotation:       "X-Forwarded-Groups, X-Proxy-Group",
+				RequestHeaderExtraHeadersPrefixAnnotation: "X-Forwarded-Extra-",
+			},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"requestheader-username-headers":     []interface{}{"X-Remote-User", "X-Forwarded-User"},
+				"requestheader-group-headers":        []interface{}{"X-Remote-Group", "X-Forwarded-Groups", "X-Proxy-Group"},
+				"requestheader-extra-headers-prefix": []interface{}{"X-Remote-Extra-", "X-Forwarded-Extra-"},
+			}},
+		},
+		{
+			name:        "the default header is not repeated",
+			annotations: map[string]string{RequestHeaderUsernameHeadersAnnotation: "x-remote-user,X-Forwarded-User"},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"requestheader-username-headers": []interface{}{"X-Remote-User", "X-Forwarded-User"},
+			}},
+		},
+		{
+			name:            "empty prefix is rejected",
+			annotations:     map[string]string{RequestHeaderExtraHeadersPrefixAnnotation: ""},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+		{
+			name:            "empty entry is rejected",
+			annotations:     map[string]string{RequestHeaderGroupHeadersAnnotation: "X-Forwarded-Groups,,X-Proxy-Group"},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+		{
+			name: "invalid and reserved headers are rejected, valid ones applied",
+			annotations: map[string]string{
+				RequestHeaderUsernameHeadersAnnotation:    "X Forwarded User",
+				RequestHeaderGroupHeadersAnnotation:       "Impersonate-Group",
+				RequestHeaderExtraHeadersPrefixAnnotation: "X-Forwarded-Extra-",
+			},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"requestheader-extra-headers-prefix": []interface{}{"X-Remote-Extra-", "X-Forwarded-Extra-"},
+			}},
+			expectedWarning: true,
+		},
+		{
+			name:        "a rejected annotation keeps the previous value",
+			annotations: map[string]string{RequestHeaderGroupHeadersAnnotation: "X-Forwarded-Groups,,X-Proxy-Group"},
+			existingConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"requestheader-group-headers": []interface{}{"X-Remote-Group", "X-Forwarded-Groups"},
+			}},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"requestheader-group-headers": []interface{}{"X-Remote-Group", "X-Forwarded-Groups"},
+			}},
+			expectedWarning: true,
+		},
+		{
+			name:            "the authorization header is rejected",
+			annotations:     map[string]string{RequestHeaderUsernameHeadersAnnotation: "authorization"},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := indexer.Add(&configv1.APIServer{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Annotations: scenario.annotations}}); err != nil {
+				t.Fatal(err)
+			}
+			eventRecorder := events.NewInMemoryRecorder("")
+			listers := configobservation.Listers{
+				APIServerLister_: configlistersv1.NewAPIServerLister(indexer),
+			}
+			existingConfig := scenario.existingConfig
+			if existingConfig == nil {
+				existingConfig = map[string]interface{}{}
+			}
+
+			observedConfig, errs := ObserveRequestHeaders(listers, eventRecorder, existingConfig)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if !cmp.Equal(scenario.expectedConfig, observedConfig) {
+				t.Fatalf("unexpected configuration, diff = %v", cmp.Diff(scenario.expectedConfig, observedConfig))
+			}
+			if warned := len(eventRecorder.Events()) > 0; warned != scenario.expectedWarning {
+				t.Fatalf("expected warning %v, got events %v", scenario.expectedWarning, eventRecorder.Events())
+			}
+		})
+	}
+}
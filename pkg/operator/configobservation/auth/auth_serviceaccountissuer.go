@@ -3,7 +3,9 @@ package auth
 import (
 	"fmt"
 	"net/url"
+	"sort"
 	"strings"
+	"time"
 
 	"k8s.io/klog/v2"
 
@@ -21,11 +23,31 @@ var (
 	serviceAccountIssuerPath = []string{"apiServerArguments", "service-account-issuer"}
 	audiencesPath            = []string{"apiServerArguments", "api-audiences"}
 	jwksURIPath              = []string{"apiServerArguments", "service-account-jwks-uri"}
+	// trustedIssuersPath holds the previous issuers that are still accepted by the kube-apiserver
+	// along with the time they stop being trusted. They are only recorded in the observed config,
+	// which is the state carried from one observation to the next.
+	trustedIssuersPath = []string{"serviceAccountIssuerRotation", "trustedIssuers"}
+)
+
+const (
+	// AdditionalAPIAudiencesAnnotation on the cluster Authentication config lists, comma-separated, the audiences the
+	// kube-apiserver accepts in service account tokens on top of the ones of the service account issuers.
+	AdditionalAPIAudiencesAnnotation = "kubeapiserver.operator.openshift.io/additional-api-audiences"
+
+	// defaultServiceAccountIssuer is the issuer set by config-overrides.yaml when
+	// Authentication.Spec.ServiceAccountIssuer is empty.
+	defaultServiceAccountIssuer = "https://kubernetes.default.svc"
+
+	// trustedIssuerGracePeriod is how long a previous issuer keeps being accepted after a
+	// rotation, so that tokens issued before the rotation can be refreshed by their consumers.
+	trustedIssuerGracePeriod = 24 * time.Hour
 )
 
 // ObserveServiceAccountIssuer changes apiServerArguments.service-account-issuer from
 // the default value if Authentication.Spec.ServiceAccountIssuer specifies a valid
-// non-empty value.
+// non-empty value. When the issuer is rotated, the previous issuer is kept as an
+// additional service-account-issuer, after the new one, until trustedIssuerGracePeriod
+// has elapsed. The api-audiences track the issuers, see apiAudiences.
 func ObserveServiceAccountIssuer(
 	genericListers configobserver.Listers,
 	recorder events.Recorder,
@@ -33,8 +55,8 @@ func ObserveServiceAccountIssuer(
 ) (map[string]interface{}, []error) {
 
 	listers := genericListers.(configobservation.Listers)
-	ret, errs := observedConfig(existingConfig, listers.AuthConfigLister.Get, listers.InfrastructureLister().Get, recorder)
-	return configobserver.Pruned(ret, serviceAccountIssuerPath, audiencesPath, jwksURIPath), errs
+	ret, errs := observedConfig(existingConfig, listers.AuthConfigLister.Get, listers.InfrastructureLister().Get, recorder, time.Now())
+	return configobserver.Pruned(ret, serviceAccountIssuerPath, audiencesPath, jwksURIPath, trustedIssuersPath), errs
 }
 
 // observedConfig returns an unstructured fragment of KubeAPIServerConfig that may
@@ -45,6 +67,7 @@ func observedConfig(
 	getAuthConfig func(string) (*configv1.Authentication, error),
 	getInfrastructureConfig func(string) (*configv1.Infrastructure, error),
 	recorder events.Recorder,
+	now time.Time,
 ) (map[string]interface{}, []error) {
 
 	errs := []error{}
@@ -85,19 +108,19 @@ func observedConfig(
 		return existingConfig, append(errs, err)
 	}
 
+	trustedIssuers, err := observedTrustedIssuers(existingConfig, existingIssuer, newIssuer, now)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	additionalAudiences := additionalAPIAudiences(authConfig.Annotations[AdditionalAPIAudiencesAnnotation])
+
 	if len(newIssuer) != 0 {
 		issuerChanged = existingIssuer != newIssuer
 		// configure the issuer if set by the user and is a valid issuer
-		return map[string]interface{}{
-			"apiServerArguments": map[string]interface{}{
-				"service-account-issuer": []interface{}{
-					newIssuer,
-				},
-				"api-audiences": []interface{}{
-					newIssuer,
-				},
-			},
-		}, errs
+		ret := map[string]interface{}{}
+		setIssuers(ret, newIssuer, trustedIssuers)
+		setAudiences(ret, newIssuer, trustedIssuers, additionalAudiences)
+		return ret, errs
 	}
 
 	// if the issuer is not set, rely on the config-overrides.yaml to set both
@@ -114,13 +137,137 @@ func observedConfig(
 	}
 
 	issuerChanged = existingIssuer != newIssuer
-	return map[string]interface{}{
+	ret := map[string]interface{}{
 		"apiServerArguments": map[string]interface{}{
 			"service-account-jwks-uri": []interface{}{
 				apiServerInternalURL + "/openid/v1/jwks",
 			},
 		},
-	}, errs
+	}
+	if len(trustedIssuers) > 0 {
+		// config-overrides.yaml only knows about the default issuer, so the
+		// previous ones have to be listed explicitly until they expire.
+		setIssuers(ret, defaultServiceAccountIssuer, trustedIssuers)
+	}
+	if len(trustedIssuers) > 0 || len(additionalAudiences) > 0 {
+		// likewise config-overrides.yaml only sets the audience of the default issuer
+		setAudiences(ret, defaultServiceAccountIssuer, trustedIssuers, additionalAudiences)
+	}
+	return ret, errs
+}
+
+// trustedIssuer is a previous service account issuer still accepted by the kube-apiserver.
+type trustedIssuer struct {
+	name           string
+	expirationTime time.Time
+}
+
+// observedTrustedIssuers returns the previous issuers that must still be trusted, ordered from the
+// most recently rotated one. The issuer being replaced is added with a fresh expiration time, the
+// new issuer and the expired ones are dropped.
+func observedTrustedIssuers(existingConfig map[string]interface{}, existingIssuer, newIssuer string, now time.Time) ([]trustedIssuer, error) {
+	if len(existingIssuer) == 0 {
+		existingIssuer = defaultServiceAccountIssuer
+	}
+	if len(newIssuer) == 0 {
+		newIssuer = defaultServiceAccountIssuer
+	}
+
+	var trusted []trustedIssuer
+	if existingIssuer != newIssuer {
+		trusted = append(trusted, trustedIssuer{name: existingIssuer, expirationTime: now.Add(trustedIssuerGracePeriod)})
+	}
+
+	existingTrusted, _, err := unstructured.NestedSlice(existingConfig, trustedIssuersPath...)
+	if err != nil {
+		return trusted, fmt.Errorf("unable to extract trusted service account issuers from unstructured: %v", err)
+	}
+	var errs []string
+	for _, raw := range existingTrusted {
+		entry, ok := raw.(map[string]interface{})
+		if !ok {
+			errs = append(errs, fmt.Sprintf("unexpected trusted issuer %v", raw))
+			continue
+		}
+		name, _, _ := unstructured.NestedString(entry, "name")
+		expiration, _, _ := unstructured.NestedString(entry, "expirationTime")
+		expirationTime, err := time.Parse(time.RFC3339, expiration)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("invalid expiration time for trusted issuer %q: %v", name, err))
+			continue
+		}
+		if len(name) == 0 || name == newIssuer || name == existingIssuer || !now.Before(expirationTime) {
+			continue
+		}
+		trusted = append(trusted, trustedIssuer{name: name, expirationTime: expirationTime})
+	}
+	if len(errs) > 0 {
+		return trusted, fmt.Errorf("dropped trusted service account issuers: %s", strings.Join(errs, ", "))
+	}
+	return trusted, nil
+}
+
+// setIssuers configures the issuer, followed by the trusted previous issuers.
+func setIssuers(config map[string]interface{}, issuer string, trustedIssuers []trustedIssuer) {
+	issuers := []interface{}{issuer}
+	trusted := []interface{}{}
+	for _, t := range trustedIssuers {
+		issuers = append(issuers, t.name)
+		trusted = append(trusted, map[string]interface{}{
+			"name":           t.name,
+			"expirationTime": t.expirationTime.UTC().Format(time.RFC3339),
+		})
+	}
+
+	_ = unstructured.SetNestedField(config, issuers, serviceAccountIssuerPath...)
+	if len(trusted) > 0 {
+		_ = unstructured.SetNestedField(config, trusted, trustedIssuersPath...)
+	}
+}
+
+// setAudiences configures the api-audiences returned by apiAudiences.
+func setAudiences(config map[string]interface{}, issuer string, trustedIssuers []trustedIssuer, additionalAudiences []string) {
+	audiences := []interface{}{}
+	for _, audience := range apiAudiences(issuer, trustedIssuers, additionalAudiences) {
+		audiences = append(audiences, audience)
+	}
+	_ = unstructured.SetNestedField(config, audiences, audiencesPath...)
+}
+
+// apiAudiences returns the audiences accepted in service account tokens: the issuer first, the kube-apiserver uses
+// the first audience for the tokens requested without any, then the trusted previous issuers so that their tokens are
+// accepted until they expire, the in-cluster audience, and the additional audiences. The duplicates are dropped, the
+// order only changes with the issuers so that reordering the additional audiences does not roll out a new revision.
+func apiAudiences(issuer string, trustedIssuers []trustedIssuer, additionalAudiences []string) []string {
+	candidates := []string{issuer}
+	for _, t := range trustedIssuers {
+		candidates = append(candidates, t.name)
+	}
+	candidates = append(candidates, defaultServiceAccountIssuer)
+	candidates = append(candidates, additionalAudiences...)
+
+	var audiences []string
+	seen := map[string]bool{}
+	for _, audience := range candidates {
+		if seen[audience] {
+			continue
+		}
+		seen[audience] = true
+		audiences = append(audiences, audience)
+	}
+	return audiences
+}
+
+// additionalAPIAudiences returns the sorted audiences of the AdditionalAPIAudiencesAnnotation value.
+func additionalAPIAudiences(value string) []string {
+	var audiences []string
+	for _, audience := range strings.Split(value, ",") {
+		if audience = strings.TrimSpace(audience); len(audience) > 0 {
+			audiences = append(audiences, audience)
+		}
+	}
+	sort.Strings(audiences)
+	return audiences
 }
 
 // checkIssuer validates the issuer in the same way that it will be validated by
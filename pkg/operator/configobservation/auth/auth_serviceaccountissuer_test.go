@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/require"
@@ -23,13 +24,14 @@ func TestObservedConfig(t *testing.T) {
 	expectedErrInfra := fmt.Errorf("bar")
 
 	for _, tc := range []struct {
-		name           string
-		issuer         string
-		existingIssuer string
-		authError      error
-		infraError     error
-		expectedIssuer string
-		expectedChange bool
+		name            string
+		issuer          string
+		existingIssuer  string
+		authError       error
+		infraError      error
+		expectedIssuer  string
+		expectedTrusted []string
+		expectedChange  bool
 	}{
 		{
 			name:           "no issuer, no previous issuer",
@@ -38,18 +40,20 @@ func TestObservedConfig(t *testing.T) {
 			expectedIssuer: "",
 		},
 		{
-			name:           "no issuer, previous issuer set",
-			existingIssuer: "https://example.com",
-			issuer:         "",
-			expectedIssuer: "",
-			expectedChange: true,
+			name:            "no issuer, previous issuer set",
+			existingIssuer:  "https://example.com",
+			issuer:          "",
+			expectedIssuer:  "",
+			expectedTrusted: []string{"https://example.com"},
+			expectedChange:  true,
 		},
 		{
-			name:           "issuer set, no previous issuer",
-			existingIssuer: "",
-			issuer:         "https://example.com",
-			expectedIssuer: "https://example.com",
-			expectedChange: true,
+			name:            "issuer set, no previous issuer",
+			existingIssuer:  "",
+			issuer:          "https://example.com",
+			expectedIssuer:  "https://example.com",
+			expectedTrusted: []string{"https://kubernetes.default.svc"},
+			expectedChange:  true,
 		},
 		{
 			name:           "issuer set, previous issuer same",
@@ -58,11 +62,12 @@ func TestObservedConfig(t *testing.T) {
 			expectedIssuer: "https://example.com",
 		},
 		{
-			name:           "issuer set, previous issuer different",
-			existingIssuer: "https://example.com",
-			issuer:         "https://example2.com",
-			expectedIssuer: "https://example2.com",
-			expectedChange: true,
+			name:            "issuer set, previous issuer different",
+			existingIssuer:  "https://example.com",
+			issuer:          "https://example2.com",
+			expectedIssuer:  "https://example2.com",
+			expectedTrusted: []string{"https://example.com"},
+			expectedChange:  true,
 		},
 		{
 			name:           "auth getter error",
@@ -95,13 +100,14 @@ func TestObservedConfig(t *testing.T) {
 					}, tc.infraError
 				},
 				testRecorder,
+				time.Now(),
 			)
 
 			var expectedConfig *kubecontrolplanev1.KubeAPIServerConfig
 			if tc.authError == nil && tc.infraError == nil {
 				require.Len(t, errs, 0)
 			}
-			expectedConfig = apiConfigForIssuer(tc.expectedIssuer)
+			expectedConfig = apiConfigForIssuer(tc.expectedIssuer, tc.expectedTrusted...)
 
 			// Check that errors are passed through
 			if tc.authError != nil {
@@ -138,19 +144,19 @@ func authConfigForIssuer(issuer string) *configv1.Authentication {
 	}
 }
 
-func apiConfigForIssuer(issuer string) *kubecontrolplanev1.KubeAPIServerConfig {
+func apiConfigForIssuer(issuer string, trustedIssuers ...string) *kubecontrolplanev1.KubeAPIServerConfig {
 	args := map[string]kubecontrolplanev1.Arguments{
-		"service-account-issuer": {
-			issuer,
-		},
-		"api-audiences": {
-			issuer,
-		},
+		"service-account-issuer": append([]string{issuer}, trustedIssuers...),
+		"api-audiences":          withInClusterAudience(append([]string{issuer}, trustedIssuers...)),
 	}
 	if len(issuer) == 0 {
 		delete(args, "service-account-issuer")
 		delete(args, "api-audiences")
 		args["service-account-jwks-uri"] = kubecontrolplanev1.Arguments{testLBURI}
+		if len(trustedIssuers) > 0 {
+			args["service-account-issuer"] = append([]string{defaultServiceAccountIssuer}, trustedIssuers...)
+			args["api-audiences"] = withInClusterAudience(append([]string{defaultServiceAccountIssuer}, trustedIssuers...))
+		}
 	}
 
 	return &kubecontrolplanev1.KubeAPIServerConfig{
@@ -161,6 +167,16 @@ func apiConfigForIssuer(issuer string) *kubecontrolplanev1.KubeAPIServerConfig {
 	}
 }
 
+// withInClusterAudience appends the in-cluster audience to the issuers unless it is one of them.
+func withInClusterAudience(issuers []string) []string {
+	for _, issuer := range issuers {
+		if issuer == defaultServiceAccountIssuer {
+			return issuers
+		}
+	}
+	return append(issuers, defaultServiceAccountIssuer)
+}
+
 // unstructuredAPIConfigForIssuer round-trips through the golang type
 // to ensure the input to the function under test will match what will
 // be received at runtime.
@@ -176,3 +192,159 @@ func unstructuredAPIConfigForIssuer(t *testing.T, issuer string) map[string]inte
 	require.NoError(t, json.Unmarshal(marshalledConfig, unstructuredObj))
 	return unstructuredObj.Object
 }
+
+func TestObservedConfigTrustedIssuers(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	withTrusted := func(issuers []interface{}, trusted ...map[string]interface{}) map[string]interface{} {
+		config := map[string]interface{}{}
+		require.NoError(t, unstructured.SetNestedField(config, issuers, serviceAccountIssuerPath...))
+		if len(trusted) > 0 {
+			entries := []interface{}{}
+			for _, t := range trusted {
+				entries = append(entries, t)
+			}
+			require.NoError(t, unstructured.SetNestedField(config, entries, trustedIssuersPath...))
+		}
+		return config
+	}
+	trustedUntil := func(name string, expiration time.Time) map[string]interface{} {
+		return map[string]interface{}{"name": name, "expirationTime": expiration.Format(time.RFC3339)}
+	}
+
+	for _, tc := range []struct {
+		name            string
+		existingConfig  map[string]interface{}
+		issuer          string
+		expectedIssuers []string
+		expectedTrusted []interface{}
+		expectErr       bool
+	}{
+		{
+			name:            "rotation keeps the previous issuers after the new one",
+			existingConfig:  withTrusted([]interface{}{"https://b.example.com", "https://a.example.com"}, trustedUntil("https://a.example.com", now.Add(time.Hour))),
+			issuer:          "https://c.example.com",
+			expectedIssuers: []string{"https://c.example.com", "https://b.example.com", "https://a.example.com"},
+			expectedTrusted: []interface{}{
+				trustedUntil("https://b.example.com", now.Add(trustedIssuerGracePeriod)),
+				trustedUntil("https://a.example.com", now.Add(time.Hour)),
+			},
+		},
+		{
+			name:            "expired previous issuer is pruned",
+			existingConfig:  withTrusted([]interface{}{"https://b.example.com", "https://a.example.com"}, trustedUntil("https://a.example.com", now.Add(-time.Second))),
+			issuer:          "https://b.example.com",
+			expectedIssuers: []string{"https://b.example.com"},
+		},
+		{
+			name:            "rotating back to a trusted issuer drops it from the trusted ones",
+			existingConfig:  withTrusted([]interface{}{"https://b.example.com", "https://a.example.com"}, trustedUntil("https://a.example.com", now.Add(time.Hour))),
+			issuer:          "https://a.example.com",
+			expectedIssuers: []string{"https://a.example.com", "https://b.example.com"},
+			expectedTrusted: []interface{}{
+				trustedUntil("https://b.example.com", now.Add(trustedIssuerGracePeriod)),
+			},
+		},
+		{
+			name: "invalid expiration time is dropped with an error",
+			existingConfig: withTrusted([]interface{}{"https://b.example.com", "https://a.example.com"},
+				map[string]interface{}{"name": "https://a.example.com", "expirationTime": "tomorrow"}),
+			issuer:          "https://b.example.com",
+			expectedIssuers: []string{"https://b.example.com"},
+			expectErr:       true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			newConfig, errs := observedConfig(
+				tc.existingConfig,
+				func(_ string) (*configv1.Authentication, error) {
+					return authConfigForIssuer(tc.issuer), nil
+				},
+				func(_ string) (*configv1.Infrastructure, error) {
+					return &configv1.Infrastructure{Status: configv1.InfrastructureStatus{APIServerInternalURL: "https://lb.example.com"}}, nil
+				},
+				events.NewInMemoryRecorder("SAIssuerTest"),
+				now,
+			)
+			require.Equal(t, tc.expectErr, len(errs) > 0, "unexpected errors: %v", errs)
+
+			issuers, _, err := unstructured.NestedStringSlice(newConfig, serviceAccountIssuerPath...)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedIssuers, issuers)
+
+			trusted, _, err := unstructured.NestedSlice(newConfig, trustedIssuersPath...)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedTrusted, trusted)
+		})
+	}
+}
+
+func TestObservedConfigAPIAudiences(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	issuers := func(issuers ...interface{}) map[string]interface{} {
+		config := map[string]interface{}{}
+		require.NoError(t, unstructured.SetNestedField(config, issuers, serviceAccountIssuerPath...))
+		return config
+	}
+
+	for _, tc := range []struct {
+		name              string
+		existingConfig    map[string]interface{}
+		issuer            string
+		annotations       map[string]string
+		expectedAudiences []string
+	}{
+		{
+			name:              "default issuer",
+			existingConfig:    map[string]interface{}{},
+			expectedAudiences: nil,
+		},
+		{
+			name:              "single issuer",
+			existingConfig:    issuers("https://a.example.com"),
+			issuer:            "https://a.example.com",
+			expectedAudiences: []string{"https://a.example.com", "https://kubernetes.default.svc"},
+		},
+		{
+			name:              "rotation keeps the audience of the previous issuer",
+			existingConfig:    issuers("https://a.example.com"),
+			issuer:            "https://b.example.com",
+			expectedAudiences: []string{"https://b.example.com", "https://a.example.com", "https://kubernetes.default.svc"},
+		},
+		{
+			name:           "extra audiences are sorted and deduplicated",
+			existingConfig: issuers("https://a.example.com"),
+			issuer:         "https://a.example.com",
+			annotations: map[string]string{
+				AdditionalAPIAudiencesAnnotation: "vault, https://a.example.com,,aws-sts ,vault",
+			},
+			expectedAudiences: []string{"https://a.example.com", "https://kubernetes.default.svc", "aws-sts", "vault"},
+		},
+		{
+			name:              "extra audiences with the default issuer",
+			existingConfig:    map[string]interface{}{},
+			annotations:       map[string]string{AdditionalAPIAudiencesAnnotation: "vault"},
+			expectedAudiences: []string{"https://kubernetes.default.svc", "vault"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			newConfig, errs := observedConfig(
+				tc.existingConfig,
+				func(_ string) (*configv1.Authentication, error) {
+					authConfig := authConfigForIssuer(tc.issuer)
+					authConfig.Annotations = tc.annotations
+					return authConfig, nil
+				},
+				func(_ string) (*configv1.Infrastructure, error) {
+					return &configv1.Infrastructure{Status: configv1.InfrastructureStatus{APIServerInternalURL: "https://lb.example.com"}}, nil
+				},
+				events.NewInMemoryRecorder("SAIssuerTest"),
+				now,
+			)
+			require.Len(t, errs, 0)
+
+			audiences, _, err := unstructured.NestedStringSlice(newConfig, audiencesPath...)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedAudiences, audiences)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation/apiserver"
+)
+
+// ServiceAccountMaxTokenExpirationAnnotation on the cluster APIServer config sets
+// service-account-max-token-expiration, the longest validity of the tokens issued by the bound token signer, as a
+// duration, e.g. "48h".
+const ServiceAccountMaxTokenExpirationAnnotation = "kubeapiserver.operator.openshift.io/service-account-max-token-expiration"
+
+const (
+	// minMaxTokenExpiration is the lowest value accepted by the kube-apiserver.
+	minMaxTokenExpiration = time.Hour
+	// maxMaxTokenExpiration is the validity of the extended pod tokens, longer tokens are as good as non-expiring.
+	maxMaxTokenExpiration = 365 * 24 * time.Hour
+
+	// warnMaxTokenExpiration is the expiration the kubelet requests for the projected pod tokens. The kube-apiserver
+	// only extends those tokens for the clients not reloading them (service-account-extend-token-expiration) if they
+	// are requested with exactly this expiration, a lower cap shortens them and the extension no longer applies.
+	warnMaxTokenExpiration = 3607 * time.Second
+)
+
+var serviceAccountMaxTokenExpirationPath = []string{"apiServerArguments", "service-account-max-token-expiration"}
+
+// ObserveServiceAccountMaxTokenExpiration sets service-account-max-token-expiration from the annotation of the cluster
+// APIServer config. A value that cannot be parsed or is out of bounds is rejected with a warning and the previously
+// observed value is kept. A value low enough to cut the projected pod tokens is accepted with a warning.
+func ObserveServiceAccountMaxTokenExpiration(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	defer func() {
+		ret = configobserver.Pruned(ret, serviceAccountMaxTokenExpirationPath)
+	}()
+
+	listers := genericListers.(configobservation.Listers)
+	apiServer, err := listers.APIServerLister().Get("cluster")
+	if apierrors.IsNotFound(err) {
+		return map[string]interface{}{}, errs
+	}
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+
+	value, ok := apiServer.Annotations[ServiceAccountMaxTokenExpirationAnnotation]
+	if !ok {
+		return map[string]interface{}{}, errs
+	}
+	maxExpiration, err := parseMaxTokenExpiration(value)
+	if err != nil {
+		observedConfig := map[string]interface{}{}
+		if err := apiserver.KeepPreviousValue(recorder, "ObserveServiceAccountMaxTokenExpiration", ServiceAccountMaxTokenExpirationAnnotation, value, err, existingConfig, observedConfig, serviceAccountMaxTokenExpirationPath); err != nil {
+			errs = append(errs, err)
+		}
+		return observedConfig, errs
+	}
+	if maxExpiration < warnMaxTokenExpiration {
+		recorder.Warningf("ObserveServiceAccountMaxTokenExpiration", "The %s annotation value %q is below %v, the service account tokens of the pods are no longer extended for the clients not reloading them", ServiceAccountMaxTokenExpirationAnnotation, value, warnMaxTokenExpiration)
+	}
+
+	observedConfig := map[string]interface{}{}
+	if err := unstructured.SetNestedStringSlice(observedConfig, []string{maxExpiration.String()}, serviceAccountMaxTokenExpirationPath...); err != nil {
+		return existingConfig, append(errs, err)
+	}
+	return observedConfig, errs
+}
+
+// parseMaxTokenExpiration accepts durations between minMaxTokenExpiration and maxMaxTokenExpiration.
+func parseMaxTokenExpiration(value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("must be a duration")
+	}
+	if d < minMaxTokenExpiration || d > maxMaxTokenExpiration {
+		return 0, fmt.Errorf("must be between %v and %v", minMaxTokenExpiration, maxMaxTokenExpiration)
+	}
+	return d, nil
+}
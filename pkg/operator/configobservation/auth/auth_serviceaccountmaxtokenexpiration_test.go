@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+)
+
+func TestObserveServiceAccountMaxTokenExpiration(t *testing.T) {
+	capped := map[string]interface{}{"apiServerArguments": map[string]interface{}{
+		"service-account-max-token-expiration": []interface{}{"48h0m0s"},
+	}}
+
+	scenarios := []struct {
+		name            string
+		annotations     map[string]string
+		existingConfig  map[string]interface{}
+		expectedConfig  map[string]interface{}
+		expectedWarning bool
+	}{
+		{
+			name:           "not set: the tokens are not capped",
+			existingConfig: capped,
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name:           "valid",
+			annotations:    map[string]string{ServiceAccountMaxTokenExpirationAnnotation: "48h"},
+			expectedConfig: capped,
+		},
+		{
+			name:        "below the projected pod token expiration",
+			annotations: map[string]string{ServiceAccountMaxTokenExpirationAnnotation: "1h"},
+			expectedConfig: map[string]interface{}{"apiServerArguments": map[string]interface{}{
+				"service-account-max-token-expiration": []interface{}{"1h0m0s"},
+			}},
+			expectedWarning: true,
+		},
+		{
+			name:            "too short keeps the previous value",
+			annotations:     map[string]string{ServiceAccountMaxTokenExpirationAnnotation: "30m"},
+			existingConfig:  capped,
+			expectedConfig:  capped,
+			expectedWarning: true,
+		},
+		{
+			name:            "too long",
+			annotations:     map[string]string{ServiceAccountMaxTokenExpirationAnnotation: "10000h"},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+		{
+			name:            "unparsable",
+			annotations:     map[string]string{ServiceAccountMaxTokenExpirationAnnotation: "two days"},
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := indexer.Add(&configv1.APIServer{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Annotations: scenario.annotations}}); err != nil {
+				t.Fatal(err)
+			}
+			eventRecorder := events.NewInMemoryRecorder("")
+			listers := configobservation.Listers{
+				APIServerLister_: configlistersv1.NewAPIServerLister(indexer),
+			}
+			existingConfig := scenario.existingConfig
+			if existingConfig == nil {
+				existingConfig = map[string]interface{}{}
+			}
+
+			observedConfig, errs := ObserveServiceAccountMaxTokenExpiration(listers, eventRecorder, existingConfig)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if !cmp.Equal(scenario.expectedConfig, observedConfig) {
+				t.Fatalf("unexpected configuration, diff = %v", cmp.Diff(scenario.expectedConfig, observedConfig))
+			}
+			if warned := len(eventRecorder.Events()) > 0; warned != scenario.expectedWarning {
+				t.Fatalf("expected warning %v, got events %v", scenario.expectedWarning, eventRecorder.Events())
+			}
+		})
+	}
+}
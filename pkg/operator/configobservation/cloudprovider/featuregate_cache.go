@@ -0,0 +1,63 @@
+package cloudprovider
+
+import (
+	"sync"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/cloudprovider"
+)
+
+// parseExternalCloudProvider is the uncached check against the feature gate, a variable for testing.
+var parseExternalCloudProvider = cloudprovider.IsCloudProviderExternal
+
+// externalCloudProviderCache memoizes the result of parsing the cluster FeatureGate for the last observed
+// resource version and platform, so that repeated observations of unchanged objects skip the parse.
+type externalCloudProviderCache struct {
+	lock sync.Mutex
+
+	resourceVersion string
+	platform        platformKey
+	external        bool
+}
+
+// platformKey holds the platform fields the external cloud provider decision depends on.
+type platformKey struct {
+	platformType   configv1.PlatformType
+	azureCloudName configv1.AzureCloudEnvironment
+}
+
+func newPlatformKey(platform *configv1.PlatformStatus) platformKey {
+	if platform == nil {
+		return platformKey{}
+	}
+	key := platformKey{platformType: platform.Type}
+	if platform.Azure != nil {
+		key.azureCloudName = platform.Azure.CloudName
+	}
+	return key
+}
+
+var featureGateCache = &externalCloudProviderCache{}
+
+// isCloudProviderExternal returns the cached result when neither the feature gate resource version nor the
+// platform changed, and parses the feature gate otherwise. Objects without a resource version are never cached.
+func (c *externalCloudProviderCache) isCloudProviderExternal(platform *configv1.PlatformStatus, featureGate *configv1.FeatureGate) (bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	resourceVersion := featureGate.ResourceVersion
+	key := newPlatformKey(platform)
+	if len(resourceVersion) > 0 && resourceVersion == c.resourceVersion && key == c.platform {
+		return c.external, nil
+	}
+
+	external, err := parseExternalCloudProvider(platform, featureGate)
+	if err != nil {
+		c.resourceVersion = ""
+		return false, err
+	}
+	c.resourceVersion = resourceVersion
+	c.platform = key
+	c.external = external
+	return external, nil
+}
@@ -0,0 +1,101 @@
+package cloudprovider
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+func countingParser(calls *int) func(*configv1.PlatformStatus, *configv1.FeatureGate) (bool, error) {
+	parse := parseExternalCloudProvider
+	return func(platform *configv1.PlatformStatus, featureGate *configv1.FeatureGate) (bool, error) {
+		*calls++
+		return parse(platform, featureGate)
+	}
+}
+
+func featureGate(resourceVersion string, enabled ...string) *configv1.FeatureGate {
+	return &configv1.FeatureGate{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster", ResourceVersion: resourceVersion},
+		Spec: configv1.FeatureGateSpec{
+			FeatureGateSelection: configv1.FeatureGateSelection{
+				FeatureSet:      configv1.CustomNoUpgrade,
+				CustomNoUpgrade: &configv1.CustomFeatureGates{Enabled: enabled},
+			},
+		},
+	}
+}
+
+func TestExternalCloudProviderCache(t *testing.T) {
+	calls := 0
+	defer func(parse func(*configv1.PlatformStatus, *configv1.FeatureGate) (bool, error)) {
+		parseExternalCloudProvider = parse
+	}(parseExternalCloudProvider)
+	parseExternalCloudProvider = countingParser(&calls)
+
+	aws := &configv1.PlatformStatus{Type: configv1.AWSPlatformType}
+	gcp := &configv1.PlatformStatus{Type: configv1.GCPPlatformType}
+	steps := []struct {
+		name          string
+		platform      *configv1.PlatformStatus
+		featureGate   *configv1.FeatureGate
+		expected      bool
+		expectedCalls int
+	}{
+		{name: "initial parse", platform: aws, featureGate: featureGate("1"), expected: false, expectedCalls: 1},
+		{name: "unchanged gate is cached", platform: aws, featureGate: featureGate("1"), expected: false, expectedCalls: 1},
+		{name: "gate change invalidates", platform: aws, featureGate: featureGate("2", "ExternalCloudProvider"), expected: true, expectedCalls: 2},
+		{name: "changed gate is cached", platform: aws, featureGate: featureGate("2", "ExternalCloudProvider"), expected: true, expectedCalls: 2},
+		{name: "platform change invalidates", platform: gcp, featureGate: featureGate("2", "ExternalCloudProvider"), expected: true, expectedCalls: 3},
+		{name: "gate without resource version is not cached", platform: gcp, featureGate: featureGate(""), expected: false, expectedCalls: 4},
+		{name: "gate without resource version is parsed again", platform: gcp, featureGate: featureGate(""), expected: false, expectedCalls: 5},
+	}
+
+	cache := &externalCloudProviderCache{}
+	for _, step := range steps {
+		external, err := cache.isCloudProviderExternal(step.platform, step.featureGate)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", step.name, err)
+		}
+		if external != step.expected {
+			t.Errorf("%s: expected external=%v, got %v", step.name, step.expected, external)
+		}
+		if calls != step.expectedCalls {
+			t.Errorf("%s: expected %d parse calls, got %d", step.name, step.expectedCalls, calls)
+		}
+	}
+}
+
+func BenchmarkExternalCloudProviderCache(b *testing.B) {
+	defer func(parse func(*configv1.PlatformStatus, *configv1.FeatureGate) (bool, error)) {
+		parseExternalCloudProvider = parse
+	}(parseExternalCloudProvider)
+
+	platform := &configv1.PlatformStatus{Type: configv1.AWSPlatformType}
+	gate := featureGate("1", "ExternalCloudProvider")
+
+	b.Run("uncached", func(b *testing.B) {
+		calls := 0
+		parseExternalCloudProvider = countingParser(&calls)
+		for i := 0; i < b.N; i++ {
+			if _, err := parseExternalCloudProvider(platform, gate); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(calls)/float64(b.N), "parses/op")
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		calls := 0
+		parseExternalCloudProvider = countingParser(&calls)
+		cache := &externalCloudProviderCache{}
+		for i := 0; i < b.N; i++ {
+			if _, err := cache.isCloudProviderExternal(platform, gate); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(calls)/float64(b.N), "parses/op")
+	})
+}
@@ -0,0 +1,24 @@
+package cloudprovider
+
+import (
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+var (
+	registerMetrics sync.Once
+
+	cloudConfigObservedChangesCounter = metrics.NewCounter(&metrics.CounterOpts{
+		Name: "kube_apiserver_cloud_config_observed_changes_total",
+		Help: "Report the number of times the observed cloud-config file path of the kube-apiserver changed",
+	})
+)
+
+// RegisterMetrics registers the cloud provider observer metrics with the legacy registry.
+func RegisterMetrics() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(cloudConfigObservedChangesCounter)
+	})
+}
@@ -0,0 +1,60 @@
+package cloudprovider
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/testutil"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestCloudConfigObservedChangesCounter(t *testing.T) {
+	registry := metrics.NewKubeRegistry()
+	registry.MustRegister(cloudConfigObservedChangesCounter)
+	cloudConfigObservedChangesCounter.Reset()
+
+	listers, _ := newFakeListers(t, &configv1.PlatformStatus{Type: configv1.AWSPlatformType}, "cloud-provider-config", nil, nil)
+	observe := NewCloudProviderObserver("openshift-kube-apiserver", cloudProviderNamePath, cloudProviderConfigPath)
+	recorder := events.NewInMemoryRecorder(t.Name())
+
+	existing := map[string]interface{}{}
+	expectedCounts := []float64{
+		// the first observation changes the config file from unset to the infrastructure config
+		1,
+		// subsequent observations are no-ops
+		1,
+		1,
+	}
+	for i, expected := range expectedCounts {
+		observed, errs := observe(listers, recorder, existing)
+		if len(errs) > 0 {
+			t.Fatalf("unexpected errors: %v", errs)
+		}
+		actual, err := testutil.GetCounterMetricValue(cloudConfigObservedChangesCounter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Errorf("observation %d: expected counter to be %v, got %v", i, expected, actual)
+		}
+		existing = observed
+	}
+
+	// a real change of the observed path increments the counter once more
+	if err := unstructured.SetNestedStringSlice(existing, []string{"/etc/kubernetes/static-pod-resources/configmaps/cloud-config/other"}, cloudProviderConfigPath...); err != nil {
+		t.Fatal(err)
+	}
+	if _, errs := observe(listers, recorder, existing); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	actual, err := testutil.GetCounterMetricValue(cloudConfigObservedChangesCounter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual != 2 {
+		t.Errorf("expected counter to be 2 after a path change, got %v", actual)
+	}
+}
@@ -0,0 +1,338 @@
+package cloudprovider
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corelisterv1 "k8s.io/client-go/listers/core/v1"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resourcesynccontroller"
+)
+
+const (
+	cloudProviderConfFilePath       = "/etc/kubernetes/static-pod-resources/configmaps/cloud-config/%s"
+	configNamespace                 = "openshift-config"
+	machineSpecifiedConfigNamespace = "openshift-config-managed"
+	machineSpecifiedConfig          = "kube-cloud-config"
+	azureStackHubCloudConfigKey     = "azurestackhub.conf"
+
+	// nutanixPlatformType is not yet part of the vendored openshift/api.
+	nutanixPlatformType configv1.PlatformType = "Nutanix"
+)
+
+// ErrPlatformNotReady is returned by the observer when infrastructures.config.openshift.io/cluster exists,
+// but its status.platform is not populated yet. It allows callers to distinguish "not ready yet" from "no cloud provider".
+var ErrPlatformNotReady = fmt.Errorf("infrastructures.%s/cluster status.platform is not set", configv1.GroupName)
+
+// defaultValidCloudProviders are the in-tree cloud providers that consume a cloud config.
+var defaultValidCloudProviders = sets.NewString("aws", "azure", "gce", "openstack", "vsphere")
+
+// alwaysExternalPlatforms are platforms without an in-tree cloud provider that always run the external
+// cloud-controller-manager, regardless of the ExternalCloudProvider feature gate.
+var alwaysExternalPlatforms = sets.NewString(
+	string(configv1.IBMCloudPlatformType),
+	string(configv1.PowerVSPlatformType),
+	string(nutanixPlatformType),
+)
+
+// FeatureGateLister lists feature gate information
+type FeatureGateLister interface {
+	FeatureGateLister() configlistersv1.FeatureGateLister
+}
+
+// InfrastructureLister lists infrastrucre information and allows resources to be synced
+type InfrastructureLister interface {
+	InfrastructureLister() configlistersv1.InfrastructureLister
+	FeatureGateLister() configlistersv1.FeatureGateLister
+	ResourceSyncer() resourcesynccontroller.ResourceSyncer
+	ConfigMapLister() corelisterv1.ConfigMapLister
+}
+
+// CloudProviderObserverOption configures optional behaviour of the cloudprovider observer.
+type CloudProviderObserverOption func(*cloudProviderObserver)
+
+// WithCloudConfigValidator validates the cloud config of the given cloud provider before it is synced.
+func WithCloudConfigValidator(cloudProvider string, validator CloudConfigValidator) CloudProviderObserverOption {
+	return func(c *cloudProviderObserver) {
+		c.cloudConfigValidators[cloudProvider] = validator
+	}
+}
+
+// WithValidCloudProviders replaces the set of cloud providers the cloud config is synced for.
+func WithValidCloudProviders(cloudProviders ...string) CloudProviderObserverOption {
+	return func(c *cloudProviderObserver) {
+		c.validCloudProviders = sets.NewString(cloudProviders...)
+	}
+}
+
+// WithDryRun computes the observed config without syncing the cloud config, the intended sync is recorded as an event instead.
+func WithDryRun(dryRun bool) CloudProviderObserverOption {
+	return func(c *cloudProviderObserver) {
+		c.dryRun = dryRun
+	}
+}
+
+// WithDefaultCloudConfigValidators validates the cloud config syntax of the in-tree cloud providers before it is synced.
+func WithDefaultCloudConfigValidators() CloudProviderObserverOption {
+	return func(c *cloudProviderObserver) {
+		for cloudProvider, validator := range defaultCloudConfigValidators {
+			c.cloudConfigValidators[cloudProvider] = validator
+		}
+	}
+}
+
+// NewCloudProviderObserver returns a new cloudprovider observer for syncing cloud provider specific
+// information to controller-manager and api-server.
+func NewCloudProviderObserver(targetNamespaceName string, cloudProviderNamePath, cloudProviderConfigPath []string, opts ...CloudProviderObserverOption) configobserver.ObserveConfigFunc {
+	cloudObserver := &cloudProviderObserver{
+		targetNamespaceName:     targetNamespaceName,
+		cloudProviderNamePath:   cloudProviderNamePath,
+		cloudProviderConfigPath: cloudProviderConfigPath,
+		validCloudProviders:     defaultValidCloudProviders,
+		cloudConfigValidators:   map[string]CloudConfigValidator{},
+	}
+	for _, opt := range opts {
+		opt(cloudObserver)
+	}
+	return cloudObserver.ObserveCloudProviderNames
+}
+
+type cloudProviderObserver struct {
+	targetNamespaceName     string
+	cloudProviderNamePath   []string
+	cloudProviderConfigPath []string
+
+	// validCloudProviders are the cloud providers the cloud config is synced for
+	validCloudProviders sets.String
+
+	// cloudConfigValidators are keyed by cloud provider name
+	cloudConfigValidators map[string]CloudConfigValidator
+
+	// dryRun skips the resource sync
+	dryRun bool
+}
+
+// ObserveCloudProviderNames observes the cloud provider from the global cluster infrastructure resource.
+func (c *cloudProviderObserver) ObserveCloudProviderNames(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, _ []error) {
+	defer func() {
+		ret = configobserver.Pruned(ret, c.cloudProviderConfigPath, c.cloudProviderNamePath)
+	}()
+
+	listers := genericListers.(InfrastructureLister)
+	var errs []error
+	observedConfig := map[string]interface{}{}
+
+	infrastructure, err := listers.InfrastructureLister().Get("cluster")
+	if errors.IsNotFound(err) {
+		recorder.Warningf("ObserveCloudProviderNames", "Required infrastructures.%s/cluster not found", configv1.GroupName)
+		return observedConfig, errs
+	}
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+	if len(infrastructure.Status.Platform) == 0 {
+		// the platform is populated during installation, until then we cannot tell whether there is a cloud provider
+		recorder.Warningf("ObserveCloudProvidersFailed", "Required status.platform field is not set in infrastructures.%s/cluster", configv1.GroupName)
+		return existingConfig, append(errs, ErrPlatformNotReady)
+	}
+
+	external, err := IsCloudProviderExternal(listers, infrastructure.Status.PlatformStatus)
+	if err != nil {
+		recorder.Warningf("ObserveCloudProviderNames", "Could not determine external cloud provider state: %v", err)
+		return existingConfig, append(errs, err)
+	}
+
+	// Still using in-tree cloud provider, fall back to setting provider information based on platform type.
+	cloudProvider := GetPlatformName(infrastructure.Status.Platform, recorder)
+	if external {
+		if err := unstructured.SetNestedStringSlice(observedConfig, []string{"external"}, c.cloudProviderNamePath...); err != nil {
+			errs = append(errs, err)
+		}
+	} else if len(cloudProvider) > 0 {
+		if err := unstructured.SetNestedStringSlice(observedConfig, []string{cloudProvider}, c.cloudProviderNamePath...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	sourceCloudConfigMap := infrastructure.Spec.CloudConfig.Name
+	sourceCloudConfigNamespace := configNamespace
+	sourceCloudConfigKey := infrastructure.Spec.CloudConfig.Key
+	managedCloudConfigFound := false
+	azureStackHub := false
+
+	// If a managed cloud-provider config is available, it should be used instead of the default. If the configmap is not
+	// found, the default values should be used.
+	if managedCloudConfig, err := listers.ConfigMapLister().ConfigMaps(machineSpecifiedConfigNamespace).Get(machineSpecifiedConfig); err == nil {
+		sourceCloudConfigMap = machineSpecifiedConfig
+		sourceCloudConfigNamespace = machineSpecifiedConfigNamespace
+		sourceCloudConfigKey = "cloud.conf"
+		managedCloudConfigFound = true
+
+		// Azure Stack Hub differs from the standard Azure cloud config, it is shipped under its own key when present.
+		if _, ok := managedCloudConfig.Data[azureStackHubCloudConfigKey]; ok && isAzureStackHub(infrastructure.Status.PlatformStatus) {
+			sourceCloudConfigKey = azureStackHubCloudConfigKey
+			azureStackHub = true
+		}
+	} else if !errors.IsNotFound(err) {
+		return existingConfig, append(errs, err)
+	}
+
+	sourceLocation := resourcesynccontroller.ResourceLocation{
+		Namespace: sourceCloudConfigNamespace,
+		Name:      sourceCloudConfigMap,
+	}
+
+	// we set cloudprovider configmap values only for some cloud providers.
+	// Platforms that are always external have no in-tree name, but still consume the managed cloud config when present.
+	if !c.validCloudProviders.Has(cloudProvider) && !(managedCloudConfigFound && isAlwaysExternal(infrastructure.Status.PlatformStatus)) {
+		sourceCloudConfigMap = ""
+	}
+
+	if len(sourceCloudConfigMap) == 0 {
+		sourceLocation = resourcesynccontroller.ResourceLocation{}
+	}
+
+	// refuse to sync a malformed cloud config, the kube-apiserver would crashloop on it
+	if err := c.validateCloudConfig(listers, cloudProvider, sourceLocation, sourceCloudConfigKey); err != nil {
+		recorder.Warningf("ObserveCloudProviderNames", "Invalid cloud config in %s/%s: %v", sourceLocation.Namespace, sourceLocation.Name, err)
+		return existingConfig, append(errs, err)
+	}
+
+	targetLocation := resourcesynccontroller.ResourceLocation{
+		Namespace: c.targetNamespaceName,
+		Name:      "cloud-config",
+	}
+	if c.dryRun {
+		recorder.Eventf("ObserveCloudProviderNamesDryRun", "Would sync configmap %s/%s from %q", targetLocation.Namespace, targetLocation.Name, sourceLocation.Namespace+"/"+sourceLocation.Name)
+	} else if err := listers.ResourceSyncer().SyncConfigMap(targetLocation, sourceLocation); err != nil {
+		return existingConfig, append(errs, err)
+	}
+
+	if len(sourceCloudConfigMap) == 0 {
+		return observedConfig, errs
+	}
+
+	staticCloudConfFile := fmt.Sprintf(cloudProviderConfFilePath, sourceCloudConfigKey)
+
+	if err := unstructured.SetNestedStringSlice(observedConfig, []string{staticCloudConfFile}, c.cloudProviderConfigPath...); err != nil {
+		recorder.Warningf("ObserveCloudProviderNames", "Failed setting cloud-config : %v", err)
+		return existingConfig, append(errs, err)
+	}
+
+	existingCloudConfig, _, err := unstructured.NestedStringSlice(existingConfig, c.cloudProviderConfigPath...)
+	if err != nil {
+		errs = append(errs, err)
+		// keep going on read error from existing config
+	}
+
+	if !equality.Semantic.DeepEqual(existingCloudConfig, []string{staticCloudConfFile}) {
+		recorder.Eventf("ObserveCloudProviderNamesChanges", "CloudProvider config file changed to %s", staticCloudConfFile)
+		cloudConfigObservedChangesCounter.Inc()
+		if azureStackHub {
+			recorder.Eventf("ObserveCloudProviderNamesAzureStackHub", "Using the Azure Stack Hub cloud config from key %q of %s/%s", azureStackHubCloudConfigKey, machineSpecifiedConfigNamespace, machineSpecifiedConfig)
+		}
+	}
+
+	return observedConfig, errs
+}
+
+// validateCloudConfig runs the validator registered for the cloud provider against the key of the source configmap.
+// Missing configmaps or keys are not considered invalid, the resource sync handles them.
+func (c *cloudProviderObserver) validateCloudConfig(listers InfrastructureLister, cloudProvider string, source resourcesynccontroller.ResourceLocation, key string) error {
+	validator, ok := c.cloudConfigValidators[cloudProvider]
+	if !ok || len(source.Name) == 0 {
+		return nil
+	}
+	configMap, err := listers.ConfigMapLister().ConfigMaps(source.Namespace).Get(source.Name)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	content, ok := configMap.Data[key]
+	if !ok {
+		return nil
+	}
+	if err := validator.Validate(content); err != nil {
+		return fmt.Errorf("key %q is not a valid %s cloud config: %v", key, cloudProvider, err)
+	}
+	return nil
+}
+
+// IsCloudProviderExternal is used to determine if the cluster should use external cloud providers.
+// Platforms without an in-tree implementation are always external. For the others this is currently opt in
+// via a feature gate. If no feature gate is present, the cluster should remain using the in-tree implementation.
+func IsCloudProviderExternal(listers FeatureGateLister, platform *configv1.PlatformStatus) (bool, error) {
+	if isAlwaysExternal(platform) {
+		return true, nil
+	}
+
+	// the lister of the config observers returns the feature gate snapshot of the observe pass
+	featureGate, err := listers.FeatureGateLister().Get("cluster")
+	if errors.IsNotFound(err) {
+		// No feature gate is set, therefore cannot be external.
+		// This is not an error as the feature gate is an optional resource.
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("could not fetch featuregate: %v", err)
+	}
+
+	external, err := featureGateCache.isCloudProviderExternal(platform, featureGate)
+	if err != nil {
+		return false, fmt.Errorf("could not determine if cloud provider is external from featuregate: %v", err)
+	}
+
+	return external, nil
+}
+
+// isAlwaysExternal returns true if the platform runs the external cloud-controller-manager independent of the feature gate.
+func isAlwaysExternal(platform *configv1.PlatformStatus) bool {
+	return platform != nil && (alwaysExternalPlatforms.Has(string(platform.Type)) || isAzureStackHub(platform))
+}
+
+// isAzureStackHub returns true if the Azure platform runs on Azure Stack Hub.
+func isAzureStackHub(platform *configv1.PlatformStatus) bool {
+	return platform != nil && platform.Azure != nil && platform.Azure.CloudName == configv1.AzureStackCloud
+}
+
+// GetPlatformName returns the platform name as required by flags such as `cloud-provider`.
+// If no in-tree cloud provider exists for a platform, an empty value will be returned.
+func GetPlatformName(platformType configv1.PlatformType, recorder events.Recorder) string {
+	cloudProvider := ""
+	switch platformType {
+	case "":
+		recorder.Warningf("ObserveCloudProvidersFailed", "Required status.platform field is not set in infrastructures.%s/cluster", configv1.GroupName)
+	case configv1.AWSPlatformType:
+		cloudProvider = "aws"
+	case configv1.AzurePlatformType:
+		cloudProvider = "azure"
+	case configv1.VSpherePlatformType:
+		cloudProvider = "vsphere"
+	case configv1.BareMetalPlatformType:
+	case configv1.GCPPlatformType:
+		cloudProvider = "gce"
+	case configv1.LibvirtPlatformType:
+	case configv1.OpenStackPlatformType:
+		cloudProvider = "openstack"
+	case configv1.IBMCloudPlatformType:
+	case configv1.PowerVSPlatformType:
+	case nutanixPlatformType:
+	case configv1.NonePlatformType:
+	case configv1.OvirtPlatformType:
+	case configv1.KubevirtPlatformType:
+	case configv1.AlibabaCloudPlatformType:
+	default:
+		// the new doc on the infrastructure fields requires that we treat an unrecognized thing the same bare metal.
+		// TODO find a way to indicate to the user that we didn't honor their choice
+		recorder.Warningf("ObserveCloudProvidersFailed", fmt.Sprintf("No recognized cloud provider platform found in infrastructures.%s/cluster.status.platform", configv1.GroupName))
+	}
+	return cloudProvider
+}
@@ -0,0 +1,389 @@
+package cloudprovider
+
+import (
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/diff"
+	corelisterv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resourcesynccontroller"
+)
+
+var (
+	cloudProviderNamePath   = []string{"apiServerArguments", "cloud-provider"}
+	cloudProviderConfigPath = []string{"apiServerArguments", "cloud-config"}
+)
+
+type fakeListers struct {
+	infrastructureLister configlistersv1.InfrastructureLister
+	featureGateLister    configlistersv1.FeatureGateLister
+	configMapLister      corelisterv1.ConfigMapLister
+	resourceSync         resourcesynccontroller.ResourceSyncer
+}
+
+func (l fakeListers) InfrastructureLister() configlistersv1.InfrastructureLister {
+	return l.infrastructureLister
+}
+
+func (l fakeListers) FeatureGateLister() configlistersv1.FeatureGateLister {
+	return l.featureGateLister
+}
+
+func (l fakeListers) ConfigMapLister() corelisterv1.ConfigMapLister {
+	return l.configMapLister
+}
+
+func (l fakeListers) ResourceSyncer() resourcesynccontroller.ResourceSyncer {
+	return l.resourceSync
+}
+
+func (l fakeListers) PreRunHasSynced() []cache.InformerSynced {
+	return nil
+}
+
+type fakeResourceSyncer struct {
+	synced map[resourcesynccontroller.ResourceLocation]resourcesynccontroller.ResourceLocation
+}
+
+func (s *fakeResourceSyncer) SyncConfigMap(destination, source resourcesynccontroller.ResourceLocation) error {
+	s.synced[destination] = source
+	return nil
+}
+
+func (s *fakeResourceSyncer) SyncSecret(destination, source resourcesynccontroller.ResourceLocation) error {
+	s.synced[destination] = source
+	return nil
+}
+
+func TestObserveCloudProviderNames(t *testing.T) {
+	targetLocation := resourcesynccontroller.ResourceLocation{Namespace: "openshift-kube-apiserver", Name: "cloud-config"}
+
+	testCases := []struct {
+		name                 string
+		platformType         configv1.PlatformType
+		azure                *configv1.AzurePlatformStatus
+		infrastructureConfig string
+		featureGate          *configv1.FeatureGate
+		managedConfigMap     *corev1.ConfigMap
+		expected             map[string]interface{}
+		expectedSource       resourcesynccontroller.ResourceLocation
+		expectedEventReasons []string
+	}{
+		{
+			name:                 "AWS in-tree",
+			platformType:         configv1.AWSPlatformType,
+			infrastructureConfig: "cloud-provider-config",
+			expected: map[string]interface{}{
+				"apiServerArguments": map[string]interface{}{
+					"cloud-provider": []interface{}{"aws"},
+					"cloud-config":   []interface{}{"/etc/kubernetes/static-pod-resources/configmaps/cloud-config/config"},
+				},
+			},
+			expectedSource: resourcesynccontroller.ResourceLocation{Namespace: "openshift-config", Name: "cloud-provider-config"},
+		},
+		{
+			name:             "Azure uses the managed cloud config",
+			platformType:     configv1.AzurePlatformType,
+			azure:            &configv1.AzurePlatformStatus{CloudName: configv1.AzurePublicCloud},
+			managedConfigMap: managedCloudConfig(),
+			expected: map[string]interface{}{
+				"apiServerArguments": map[string]interface{}{
+					"cloud-provider": []interface{}{"azure"},
+					"cloud-config":   []interface{}{"/etc/kubernetes/static-pod-resources/configmaps/cloud-config/cloud.conf"},
+				},
+			},
+			expectedSource: resourcesynccontroller.ResourceLocation{Namespace: "openshift-config-managed", Name: "kube-cloud-config"},
+		},
+		{
+			name:             "Azure Stack Hub uses its own key of the managed cloud config",
+			platformType:     configv1.AzurePlatformType,
+			azure:            &configv1.AzurePlatformStatus{CloudName: configv1.AzureStackCloud},
+			managedConfigMap: managedCloudConfig(),
+			expected: map[string]interface{}{
+				"apiServerArguments": map[string]interface{}{
+					"cloud-provider": []interface{}{"external"},
+					"cloud-config":   []interface{}{"/etc/kubernetes/static-pod-resources/configmaps/cloud-config/azurestackhub.conf"},
+				},
+			},
+			expectedSource:       resourcesynccontroller.ResourceLocation{Namespace: "openshift-config-managed", Name: "kube-cloud-config"},
+			expectedEventReasons: []string{"ObserveCloudProviderNamesAzureStackHub"},
+		},
+		{
+			name:         "BareMetal without cloud provider",
+			platformType: configv1.BareMetalPlatformType,
+			expected:     map[string]interface{}{},
+		},
+		{
+			name:         "IBMCloud is external without a feature gate",
+			platformType: configv1.IBMCloudPlatformType,
+			expected: map[string]interface{}{
+				"apiServerArguments": map[string]interface{}{
+					"cloud-provider": []interface{}{"external"},
+				},
+			},
+		},
+		{
+			name:             "IBMCloud syncs the managed cloud config",
+			platformType:     configv1.IBMCloudPlatformType,
+			managedConfigMap: managedCloudConfig(),
+			expected: map[string]interface{}{
+				"apiServerArguments": map[string]interface{}{
+					"cloud-provider": []interface{}{"external"},
+					"cloud-config":   []interface{}{"/etc/kubernetes/static-pod-resources/configmaps/cloud-config/cloud.conf"},
+				},
+			},
+			expectedSource: resourcesynccontroller.ResourceLocation{Namespace: "openshift-config-managed", Name: "kube-cloud-config"},
+		},
+		{
+			name:             "Nutanix syncs the managed cloud config",
+			platformType:     nutanixPlatformType,
+			managedConfigMap: managedCloudConfig(),
+			expected: map[string]interface{}{
+				"apiServerArguments": map[string]interface{}{
+					"cloud-provider": []interface{}{"external"},
+					"cloud-config":   []interface{}{"/etc/kubernetes/static-pod-resources/configmaps/cloud-config/cloud.conf"},
+				},
+			},
+			expectedSource: resourcesynccontroller.ResourceLocation{Namespace: "openshift-config-managed", Name: "kube-cloud-config"},
+		},
+		{
+			name:         "PowerVS is external with the default feature set",
+			platformType: configv1.PowerVSPlatformType,
+			featureGate:  &configv1.FeatureGate{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}},
+			expected: map[string]interface{}{
+				"apiServerArguments": map[string]interface{}{
+					"cloud-provider": []interface{}{"external"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			listers, syncer := newFakeListers(t, &configv1.PlatformStatus{Type: tc.platformType, Azure: tc.azure}, tc.infrastructureConfig, tc.featureGate, tc.managedConfigMap)
+
+			observe := NewCloudProviderObserver("openshift-kube-apiserver", cloudProviderNamePath, cloudProviderConfigPath)
+			recorder := events.NewInMemoryRecorder(t.Name())
+			result, errs := observe(listers, recorder, map[string]interface{}{})
+			if len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			if !equality.Semantic.DeepEqual(tc.expected, result) {
+				t.Errorf("result does not match expected config: %s", diff.ObjectDiff(tc.expected, result))
+			}
+			if source := syncer.synced[targetLocation]; source != tc.expectedSource {
+				t.Errorf("expected %v to be synced from %v, got %v", targetLocation, tc.expectedSource, source)
+			}
+			for _, reason := range tc.expectedEventReasons {
+				if !hasEventWithReason(recorder, reason) {
+					t.Errorf("expected an event with reason %s", reason)
+				}
+			}
+		})
+	}
+}
+
+func TestObserveCloudProviderNamesValidCloudProviders(t *testing.T) {
+	targetLocation := resourcesynccontroller.ResourceLocation{Namespace: "openshift-kube-apiserver", Name: "cloud-config"}
+
+	testCases := []struct {
+		name           string
+		platformType   configv1.PlatformType
+		expectedSource resourcesynccontroller.ResourceLocation
+	}{
+		{
+			name:           "registered provider is synced",
+			platformType:   configv1.GCPPlatformType,
+			expectedSource: resourcesynccontroller.ResourceLocation{Namespace: "openshift-config", Name: "cloud-provider-config"},
+		},
+		{
+			name:         "default provider that is not registered is not synced",
+			platformType: configv1.AWSPlatformType,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			listers, syncer := newFakeListers(t, &configv1.PlatformStatus{Type: tc.platformType}, "cloud-provider-config", nil, nil)
+
+			observe := NewCloudProviderObserver("openshift-kube-apiserver", cloudProviderNamePath, cloudProviderConfigPath, WithValidCloudProviders("gce"))
+			if _, errs := observe(listers, events.NewInMemoryRecorder(t.Name()), map[string]interface{}{}); len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			if source := syncer.synced[targetLocation]; source != tc.expectedSource {
+				t.Errorf("expected %v to be synced from %v, got %v", targetLocation, tc.expectedSource, source)
+			}
+		})
+	}
+}
+
+func TestObserveCloudProviderNamesDryRun(t *testing.T) {
+	platform := &configv1.PlatformStatus{Type: configv1.AWSPlatformType}
+
+	listers, syncer := newFakeListers(t, platform, "cloud-provider-config", nil, nil)
+	observe := NewCloudProviderObserver("openshift-kube-apiserver", cloudProviderNamePath, cloudProviderConfigPath)
+	expected, errs := observe(listers, events.NewInMemoryRecorder(t.Name()), map[string]interface{}{})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(syncer.synced) != 1 {
+		t.Fatalf("expected the cloud config to be synced without dry-run, got %v", syncer.synced)
+	}
+
+	listers, syncer = newFakeListers(t, platform, "cloud-provider-config", nil, nil)
+	recorder := events.NewInMemoryRecorder(t.Name())
+	observe = NewCloudProviderObserver("openshift-kube-apiserver", cloudProviderNamePath, cloudProviderConfigPath, WithDryRun(true))
+	result, errs := observe(listers, recorder, map[string]interface{}{})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(syncer.synced) != 0 {
+		t.Errorf("expected no sync in dry-run, got %v", syncer.synced)
+	}
+	if !equality.Semantic.DeepEqual(expected, result) {
+		t.Errorf("dry-run result does not match the normal path: %s", diff.ObjectDiff(expected, result))
+	}
+	if !hasEventWithReason(recorder, "ObserveCloudProviderNamesDryRun") {
+		t.Errorf("expected the intended sync to be recorded")
+	}
+}
+
+func TestObserveCloudProviderNamesPlatformNotReady(t *testing.T) {
+	existing := map[string]interface{}{
+		"apiServerArguments": map[string]interface{}{
+			"cloud-provider": []interface{}{"aws"},
+		},
+	}
+
+	testCases := []struct {
+		name           string
+		infrastructure *configv1.Infrastructure
+		expected       map[string]interface{}
+		expectedErr    error
+	}{
+		{
+			name: "empty platform",
+			infrastructure: &configv1.Infrastructure{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+			},
+			expected:    existing,
+			expectedErr: ErrPlatformNotReady,
+		},
+		{
+			name:     "missing infrastructure",
+			expected: map[string]interface{}{},
+		},
+		{
+			name: "populated platform",
+			infrastructure: &configv1.Infrastructure{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Status: configv1.InfrastructureStatus{
+					Platform:       configv1.AWSPlatformType,
+					PlatformStatus: &configv1.PlatformStatus{Type: configv1.AWSPlatformType},
+				},
+			},
+			expected: existing,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			infraIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if tc.infrastructure != nil {
+				if err := infraIndexer.Add(tc.infrastructure); err != nil {
+					t.Fatal(err)
+				}
+			}
+			listers := fakeListers{
+				infrastructureLister: configlistersv1.NewInfrastructureLister(infraIndexer),
+				featureGateLister:    configlistersv1.NewFeatureGateLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})),
+				configMapLister:      corelisterv1.NewConfigMapLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})),
+				resourceSync:         &fakeResourceSyncer{synced: map[resourcesynccontroller.ResourceLocation]resourcesynccontroller.ResourceLocation{}},
+			}
+
+			observe := NewCloudProviderObserver("openshift-kube-apiserver", cloudProviderNamePath, cloudProviderConfigPath)
+			result, errs := observe(listers, events.NewInMemoryRecorder(t.Name()), existing)
+			if !equality.Semantic.DeepEqual(tc.expected, result) {
+				t.Errorf("result does not match expected config: %s", diff.ObjectDiff(tc.expected, result))
+			}
+			if tc.expectedErr == nil {
+				if len(errs) > 0 {
+					t.Errorf("unexpected errors: %v", errs)
+				}
+				return
+			}
+			found := false
+			for _, err := range errs {
+				if errors.Is(err, tc.expectedErr) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("expected %v, got %v", tc.expectedErr, errs)
+			}
+		})
+	}
+}
+
+func newFakeListers(t *testing.T, platformStatus *configv1.PlatformStatus, infrastructureConfig string, featureGate *configv1.FeatureGate, configMap *corev1.ConfigMap) (fakeListers, *fakeResourceSyncer) {
+	infraIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := infraIndexer.Add(&configv1.Infrastructure{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: configv1.InfrastructureSpec{
+			CloudConfig: configv1.ConfigMapFileReference{Name: infrastructureConfig, Key: "config"},
+		},
+		Status: configv1.InfrastructureStatus{
+			Platform:       platformStatus.Type,
+			PlatformStatus: platformStatus,
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	featureGateIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if featureGate != nil {
+		if err := featureGateIndexer.Add(featureGate); err != nil {
+			t.Fatal(err)
+		}
+	}
+	configMapIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	if configMap != nil {
+		if err := configMapIndexer.Add(configMap); err != nil {
+			t.Fatal(err)
+		}
+	}
+	syncer := &fakeResourceSyncer{synced: map[resourcesynccontroller.ResourceLocation]resourcesynccontroller.ResourceLocation{}}
+	return fakeListers{
+		infrastructureLister: configlistersv1.NewInfrastructureLister(infraIndexer),
+		featureGateLister:    configlistersv1.NewFeatureGateLister(featureGateIndexer),
+		configMapLister:      corelisterv1.NewConfigMapLister(configMapIndexer),
+		resourceSync:         syncer,
+	}, syncer
+}
+
+func TestGetPlatformNameNutanix(t *testing.T) {
+	recorder := events.NewInMemoryRecorder(t.Name())
+	if name := GetPlatformName(nutanixPlatformType, recorder); name != "" {
+		t.Errorf("expected no in-tree cloud provider for Nutanix, got %q", name)
+	}
+	for _, event := range recorder.Events() {
+		if event.Reason == "ObserveCloudProvidersFailed" {
+			t.Errorf("unexpected warning event: %s", event.Message)
+		}
+	}
+}
+
+func managedCloudConfig() *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-config-managed", Name: "kube-cloud-config"},
+		Data: map[string]string{
+			"cloud.conf":         "[global]\n",
+			"azurestackhub.conf": "{}",
+		},
+	}
+}
@@ -0,0 +1,53 @@
+package cloudprovider
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CloudConfigValidator validates the content of a cloud provider config file before it is synced to the kube-apiserver.
+type CloudConfigValidator interface {
+	Validate(content string) error
+}
+
+// CloudConfigValidatorFunc is a function implementing CloudConfigValidator.
+type CloudConfigValidatorFunc func(content string) error
+
+func (f CloudConfigValidatorFunc) Validate(content string) error {
+	return f(content)
+}
+
+// defaultCloudConfigValidators maps in-tree cloud provider names to the syntax of their cloud config file.
+var defaultCloudConfigValidators = map[string]CloudConfigValidator{
+	"aws":       CloudConfigValidatorFunc(ValidateINI),
+	"openstack": CloudConfigValidatorFunc(ValidateINI),
+	"vsphere":   CloudConfigValidatorFunc(ValidateINI),
+	"gce":       CloudConfigValidatorFunc(ValidateINI),
+}
+
+// ValidateINI checks that content is a syntactically valid gcfg style INI file, as used by the in-tree cloud providers.
+func ValidateINI(content string) error {
+	inSection := false
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case len(line) == 0, strings.HasPrefix(line, "#"), strings.HasPrefix(line, ";"):
+			continue
+		case strings.HasPrefix(line, "["):
+			if !strings.HasSuffix(line, "]") || len(strings.TrimSpace(line[1:len(line)-1])) == 0 {
+				return fmt.Errorf("line %d: malformed section header %q", i+1, line)
+			}
+			inSection = true
+		default:
+			if !inSection {
+				return fmt.Errorf("line %d: variable outside of a section", i+1)
+			}
+			// variables without a value are valid and denote a boolean true
+			name := strings.TrimSpace(strings.SplitN(line, "=", 2)[0])
+			if len(name) == 0 || strings.ContainsAny(name, " \t[]") {
+				return fmt.Errorf("line %d: invalid variable name %q", i+1, name)
+			}
+		}
+	}
+	return nil
+}
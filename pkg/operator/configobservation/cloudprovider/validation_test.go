@@ -0,0 +1,146 @@
+package cloudprovider
+
+import (
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resourcesynccontroller"
+)
+
+const (
+	validINI = `[Global]
+# comment
+; another comment
+zone = us-east-1a
+DisableStrictZoneCheck
+
+[Workspace "datacenter"]
+server = 10.0.0.1
+`
+	validGCE = `[global]
+project-id = openshift
+regional = true
+multizone = true
+node-tags = openshift-master
+node-instance-prefix = openshift
+subnetwork-name = openshift-worker-subnet
+`
+)
+
+func TestDefaultCloudConfigValidators(t *testing.T) {
+	testCases := []struct {
+		cloudProvider string
+		content       string
+		expectError   bool
+	}{
+		{cloudProvider: "aws", content: validINI},
+		{cloudProvider: "aws", content: "zone = us-east-1a\n", expectError: true},
+		{cloudProvider: "openstack", content: validINI},
+		{cloudProvider: "openstack", content: "[Global\nauth-url = https://keystone\n", expectError: true},
+		{cloudProvider: "vsphere", content: validINI},
+		{cloudProvider: "vsphere", content: "[Global]\n = 10.0.0.1\n", expectError: true},
+		{cloudProvider: "gce", content: validGCE},
+		{cloudProvider: "gce", content: `{"global": {"project-id": "openshift"}}`, expectError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%s/valid=%v", tc.cloudProvider, !tc.expectError), func(t *testing.T) {
+			err := defaultCloudConfigValidators[tc.cloudProvider].Validate(tc.content)
+			if tc.expectError && err == nil {
+				t.Errorf("expected an error")
+			}
+			if !tc.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestObserveCloudProviderNamesValidation(t *testing.T) {
+	existing := map[string]interface{}{
+		"apiServerArguments": map[string]interface{}{
+			"cloud-provider": []interface{}{"aws"},
+			"cloud-config":   []interface{}{"/etc/kubernetes/static-pod-resources/configmaps/cloud-config/config"},
+		},
+	}
+
+	testCases := []struct {
+		name        string
+		content     string
+		opts        []CloudProviderObserverOption
+		expectError bool
+	}{
+		{
+			name:    "valid config is synced",
+			content: validINI,
+			opts:    []CloudProviderObserverOption{WithDefaultCloudConfigValidators()},
+		},
+		{
+			name:        "invalid config keeps the existing config",
+			content:     "zone = us-east-1a\n",
+			opts:        []CloudProviderObserverOption{WithDefaultCloudConfigValidators()},
+			expectError: true,
+		},
+		{
+			name:    "invalid config is synced without validators",
+			content: "zone = us-east-1a\n",
+		},
+		{
+			name:        "custom validator",
+			content:     validINI,
+			opts:        []CloudProviderObserverOption{WithCloudConfigValidator("aws", CloudConfigValidatorFunc(func(string) error { return fmt.Errorf("rejected") }))},
+			expectError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configMap := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-config", Name: "cloud-provider-config"},
+				Data:       map[string]string{"config": tc.content},
+			}
+			listers, syncer := newFakeListers(t, &configv1.PlatformStatus{Type: configv1.AWSPlatformType}, "cloud-provider-config", nil, configMap)
+			recorder := events.NewInMemoryRecorder(t.Name())
+
+			observe := NewCloudProviderObserver("openshift-kube-apiserver", cloudProviderNamePath, cloudProviderConfigPath, tc.opts...)
+			result, errs := observe(listers, recorder, existing)
+			if !equality.Semantic.DeepEqual(existing, result) {
+				t.Errorf("expected the existing config to be kept, got %v", result)
+			}
+
+			targetLocation := resourcesynccontroller.ResourceLocation{Namespace: "openshift-kube-apiserver", Name: "cloud-config"}
+			_, synced := syncer.synced[targetLocation]
+			if tc.expectError {
+				if len(errs) == 0 {
+					t.Errorf("expected an error")
+				}
+				if synced {
+					t.Errorf("expected the invalid cloud config not to be synced")
+				}
+				if !hasEventWithReason(recorder, "ObserveCloudProviderNames") {
+					t.Errorf("expected a warning event")
+				}
+				return
+			}
+			if len(errs) > 0 {
+				t.Errorf("unexpected errors: %v", errs)
+			}
+			if !synced {
+				t.Errorf("expected the cloud config to be synced")
+			}
+		})
+	}
+}
+
+func hasEventWithReason(recorder events.InMemoryRecorder, reason string) bool {
+	for _, event := range recorder.Events() {
+		if event.Reason == reason {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package configobservercontroller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+)
+
+const (
+	// FeatureGateObservationProgressingConditionType is True while the feature gate decisions of the observed config
+	// do not agree with the cluster feature gate. The config observers read the feature gate independently, a change
+	// of it in the middle of an observation leaves some of them on the previous version until the next one.
+	FeatureGateObservationProgressingConditionType = "FeatureGateObservationProgressing"
+
+	FeatureGateChangingReason = "FeatureGateChanging"
+	AsExpectedReason          = "AsExpected"
+)
+
+var (
+	featureGatesPath            = []string{"apiServerArguments", "feature-gates"}
+	disableAdmissionPluginsPath = []string{"apiServerArguments", "disable-admission-plugins"}
+)
+
+// FeatureGateConsistencyController compares the decisions the config observers took from the cluster feature gate,
+// the feature-gates argument and the disabled admission plugins of the feature gates, with the cluster feature gate
+// read through the same cached accessor. It sets FeatureGateObservationProgressing while they disagree, until the
+// observed config catches up with the cluster feature gate.
+type FeatureGateConsistencyController struct {
+	factory.Controller
+	operatorClient        v1helpers.OperatorClient
+	featureGates          *configobservation.FeatureGates
+	featureBlacklist      sets.String
+	pluginsForFeatureGate map[string][]string
+}
+
+func NewFeatureGateConsistencyController(
+	operatorClient v1helpers.OperatorClient,
+	featureGates *configobservation.FeatureGates,
+	featureGateInformer factory.Informer,
+	featureBlacklist sets.String,
+	pluginsForFeatureGate map[string][]string,
+	eventRecorder events.Recorder,
+) *FeatureGateConsistencyController {
+	c := &FeatureGateConsistencyController{
+		operatorClient:        operatorClient,
+		featureGates:          featureGates,
+		featureBlacklist:      featureBlacklist,
+		pluginsForFeatureGate: pluginsForFeatureGate,
+	}
+	c.Controller = factory.New().
+		WithSync(c.sync).
+		WithInformers(operatorClient.Informer(), featureGateInformer).
+		ToController("FeatureGateConsistencyController", eventRecorder.WithComponentSuffix("feature-gate-consistency-controller"))
+	return c
+}
+
+func (c *FeatureGateConsistencyController) sync(_ context.Context, syncCtx factory.SyncContext) error {
+	spec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(spec.ManagementState) {
+		return nil
+	}
+
+	observedConfig := map[string]interface{}{}
+	if len(spec.ObservedConfig.Raw) > 0 {
+		if err := json.NewDecoder(bytes.NewBuffer(spec.ObservedConfig.Raw)).Decode(&observedConfig); err != nil {
+			return fmt.Errorf("unable to decode the observed config: %v", err)
+		}
+	}
+	enabled, disabled, resourceVersion, err := c.featureGates.ClusterFeatures()
+	if err != nil {
+		return err
+	}
+
+	condition := operatorv1.OperatorCondition{
+		Type:   FeatureGateObservationProgressingConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: AsExpectedReason,
+	}
+	if inconsistencies := c.inconsistencies(observedConfig, enabled, disabled); len(inconsistencies) > 0 {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = FeatureGateChangingReason
+		condition.Message = fmt.Sprintf("The observed config does not reflect the cluster feature gate at resourceVersion %q yet: %s", resourceVersion, strings.Join(inconsistencies, "; "))
+	}
+
+	_, _, err = v1helpers.UpdateStatus(c.operatorClient, v1helpers.UpdateConditionFn(condition))
+	return err
+}
+
+// inconsistencies lists how the observed config disagrees with the given features.
+func (c *FeatureGateConsistencyController) inconsistencies(observedConfig map[string]interface{}, enabled, disabled sets.String) []string {
+	var inconsistencies []string
+
+	expected := sets.NewString()
+	for _, feature := range enabled.Difference(c.featureBlacklist).UnsortedList() {
+		expected.Insert(feature + "=true")
+	}
+	for _, feature := range disabled.Difference(c.featureBlacklist).UnsortedList() {
+		expected.Insert(feature + "=false")
+	}
+	observedFeatureGates, _, _ := unstructured.NestedStringSlice(observedConfig, featureGatesPath...)
+	observed := sets.NewString(observedFeatureGates...)
+	if missing := expected.Difference(observed); missing.Len() > 0 {
+		inconsistencies = append(inconsistencies, fmt.Sprintf("feature-gates lacks %s", strings.Join(missing.List(), ",")))
+	}
+	if unexpected := observed.Difference(expected); unexpected.Len() > 0 {
+		inconsistencies = append(inconsistencies, fmt.Sprintf("feature-gates has %s", strings.Join(unexpected.List(), ",")))
+	}
+
+	toEnable, toDisable := sets.NewString(), sets.NewString()
+	for gate, plugins := range c.pluginsForFeatureGate {
+		switch {
+		case enabled.Has(gate):
+			toEnable.Insert(plugins...)
+		case disabled.Has(gate):
+			toDisable.Insert(plugins...)
+		}
+	}
+	toEnable = toEnable.Difference(toDisable)
+	observedDisabledPlugins, _, _ := unstructured.NestedStringSlice(observedConfig, disableAdmissionPluginsPath...)
+	observedDisabled := sets.NewString(observedDisabledPlugins...)
+	if notDisabled := toDisable.Difference(observedDisabled); notDisabled.Len() > 0 {
+		inconsistencies = append(inconsistencies, fmt.Sprintf("admission plugins %s are not disabled", strings.Join(notDisabled.List(), ",")))
+	}
+	if stillDisabled := toEnable.Intersection(observedDisabled); stillDisabled.Len() > 0 {
+		inconsistencies = append(inconsistencies, fmt.Sprintf("admission plugins %s are still disabled", strings.Join(stillDisabled.List(), ",")))
+	}
+
+	return inconsistencies
+}
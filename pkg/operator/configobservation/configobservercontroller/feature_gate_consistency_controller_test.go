@@ -0,0 +1,128 @@
+package configobservercontroller
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+)
+
+func customFeatureGate(resourceVersion string, enabled, disabled []string) *configv1.FeatureGate {
+	return &configv1.FeatureGate{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster", ResourceVersion: resourceVersion},
+		Spec: configv1.FeatureGateSpec{FeatureGateSelection: configv1.FeatureGateSelection{
+			FeatureSet:      configv1.CustomNoUpgrade,
+			CustomNoUpgrade: &configv1.CustomFeatureGates{Enabled: enabled, Disabled: disabled},
+		}},
+	}
+}
+
+func TestFeatureGateConsistencyController(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(customFeatureGate("1", []string{"NewThing"}, []string{"Blacklisted"})); err != nil {
+		t.Fatal(err)
+	}
+	operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+	c := &FeatureGateConsistencyController{
+		operatorClient:        operatorClient,
+		featureGates:          configobservation.NewFeatureGates(configlistersv1.NewFeatureGateLister(indexer)),
+		featureBlacklist:      sets.NewString("Blacklisted"),
+		pluginsForFeatureGate: map[string][]string{"NewThing": {"example.openshift.io/NewThing"}},
+	}
+
+	syncCtx := factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))
+	observe := func(observedConfig map[string]interface{}) *operatorv1.OperatorCondition {
+		t.Helper()
+		raw, err := json.Marshal(observedConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := v1helpers.UpdateSpec(operatorClient, func(spec *operatorv1.OperatorSpec) error {
+			spec.ObservedConfig = runtime.RawExtension{Raw: raw}
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.sync(context.TODO(), syncCtx); err != nil {
+			t.Fatal(err)
+		}
+		_, status, _, err := operatorClient.GetOperatorState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v1helpers.FindOperatorCondition(status.Conditions, FeatureGateObservationProgressingConditionType)
+	}
+	arguments := func(args map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"apiServerArguments": args}
+	}
+
+	// the observers agree with the feature gate
+	if cond := observe(arguments(map[string]interface{}{"feature-gates": []interface{}{"NewThing=true"}})); cond == nil || cond.Status != operatorv1.ConditionFalse {
+		t.Fatalf("expected a consistent observation, got %#v", cond)
+	}
+
+	// the gate flips in the middle of an observation: the feature-gates observer sees the new version, the
+	// admission plugins observer still the previous one
+	if err := indexer.Update(customFeatureGate("2", nil, []string{"NewThing", "Blacklisted"})); err != nil {
+		t.Fatal(err)
+	}
+	cond := observe(arguments(map[string]interface{}{"feature-gates": []interface{}{"NewThing=false"}}))
+	if cond.Status != operatorv1.ConditionTrue || cond.Reason != FeatureGateChangingReason {
+		t.Fatalf("expected the inconsistent observation to be reported, got %#v", cond)
+	}
+	if !strings.Contains(cond.Message, `"2"`) || !strings.Contains(cond.Message, "example.openshift.io/NewThing are not disabled") {
+		t.Errorf("expected the message to name the feature gate version and the admission plugin, got %q", cond.Message)
+	}
+
+	// the next observation catches up
+	cond = observe(arguments(map[string]interface{}{
+		"feature-gates":             []interface{}{"NewThing=false"},
+		"disable-admission-plugins": []interface{}{"example.openshift.io/NewThing"},
+	}))
+	if cond.Status != operatorv1.ConditionFalse {
+		t.Fatalf("expected the observation to be consistent again, got %#v", cond)
+	}
+
+	// an observation still on the previous version
+	cond = observe(arguments(map[string]interface{}{"feature-gates": []interface{}{"NewThing=true"}}))
+	if cond.Status != operatorv1.ConditionTrue || !strings.Contains(cond.Message, "feature-gates lacks NewThing=false") || !strings.Contains(cond.Message, "feature-gates has NewThing=true") {
+		t.Fatalf("expected the stale feature-gates to be reported, got %#v", cond)
+	}
+}
+
+func TestFeatureGatesCachedPerResourceVersion(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(customFeatureGate("1", []string{"NewThing"}, nil)); err != nil {
+		t.Fatal(err)
+	}
+	featureGates := configobservation.NewFeatureGates(configlistersv1.NewFeatureGateLister(indexer))
+
+	if enabled, _, resourceVersion, err := featureGates.ClusterFeatures(); err != nil || !enabled.Has("NewThing") || resourceVersion != "1" {
+		t.Fatalf("unexpected features %v at %q: %v", enabled, resourceVersion, err)
+	}
+	if err := indexer.Update(customFeatureGate("2", nil, []string{"NewThing"})); err != nil {
+		t.Fatal(err)
+	}
+	enabled, disabled, resourceVersion, err := featureGates.ClusterFeatures()
+	if err != nil || enabled.Has("NewThing") || !disabled.Has("NewThing") || resourceVersion != "2" {
+		t.Fatalf("expected the new version to be picked up, got %v, %v at %q: %v", enabled, disabled, resourceVersion, err)
+	}
+	// the returned sets are copies
+	disabled.Delete("NewThing")
+	if _, disabled, _, _ := featureGates.ClusterFeatures(); !disabled.Has("NewThing") {
+		t.Errorf("expected the cached features not to be modified by the caller")
+	}
+}
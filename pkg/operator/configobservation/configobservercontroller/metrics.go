@@ -0,0 +1,24 @@
+package configobservercontroller
+
+import (
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+var (
+	registerMetrics sync.Once
+
+	observedConfigChangesTotal = metrics.NewCounterVec(&metrics.CounterOpts{
+		Name: "kube_apiserver_operator_observed_config_changes_total",
+		Help: "Counts the changes of each key of the observed config, the apiServerArguments being counted per argument",
+	}, []string{"key"})
+)
+
+// RegisterMetrics registers the observed config metrics with the legacy registry.
+func RegisterMetrics() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(observedConfigChangesTotal)
+	})
+}
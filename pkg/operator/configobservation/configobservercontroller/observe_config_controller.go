@@ -7,8 +7,6 @@ import (
 	configinformers "github.com/openshift/client-go/config/informers/externalversions"
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/configobserver"
-	libgoapiserver "github.com/openshift/library-go/pkg/operator/configobserver/apiserver"
-	"github.com/openshift/library-go/pkg/operator/configobserver/cloudprovider"
 	"github.com/openshift/library-go/pkg/operator/configobserver/featuregates"
 	"github.com/openshift/library-go/pkg/operator/configobserver/proxy"
 	encryption "github.com/openshift/library-go/pkg/operator/encryption/observer"
@@ -17,16 +15,24 @@ import (
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
 
 	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation/admission"
 	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation/apiserver"
 	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation/auth"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation/cloudprovider"
 	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation/etcdendpoints"
 	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation/images"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation/konnectivity"
 	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation/network"
 	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation/scheduler"
 	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
 )
 
-var FeatureBlacklist sets.String
+// FeatureBlacklist are the feature gates not passed on to the kube-apiserver --feature-gates: the gates of the operator
+// only, which the kube-apiserver does not know and fails to start on.
+var FeatureBlacklist = sets.NewString(apiserver.StorageLeaseTuningFeatureGate)
+
+// FeatureGateAdmissionPlugins lists the admission plugins enabled with, and disabled without, each feature gate.
+var FeatureGateAdmissionPlugins = map[string][]string{}
 
 type ConfigObserver struct {
 	factory.Controller
@@ -36,6 +42,8 @@ func NewConfigObserver(
 	operatorClient v1helpers.OperatorClient,
 	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
 	configInformer configinformers.SharedInformerFactory,
+	featureGates *configobservation.FeatureGates,
+	riskyChangeGuard *RiskyConfigChangeGuard,
 	resourceSyncer resourcesynccontroller.ResourceSyncer,
 	eventRecorder events.Recorder,
 ) *ConfigObserver {
@@ -78,6 +86,7 @@ func NewConfigObserver(
 				APIServerLister_:      configInformer.Config().V1().APIServers().Lister(),
 				AuthConfigLister:      configInformer.Config().V1().Authentications().Lister(),
 				FeatureGateLister_:    configInformer.Config().V1().FeatureGates().Lister(),
+				FeatureGates_:         featureGates,
 				ImageConfigLister:     configInformer.Config().V1().Images().Lister(),
 				InfrastructureLister_: configInformer.Config().V1().Infrastructures().Lister(),
 				NetworkLister:         configInformer.Config().V1().Networks().Lister(),
@@ -107,42 +116,77 @@ func NewConfigObserver(
 				),
 			},
 			infomers,
-			// We are disabling this because it doesn't work today and customers aren't going to be able to get the kube service network options right.
-			// Customers may only use SNI.  I'm leaving this code in case we ever come up with a way to make an SNI-like thing based on IPs.
-			//apiserver.ObserveDefaultUserServingCertificate,
-			apiserver.ObserveNamedCertificates,
-			apiserver.ObserveUserClientCABundle,
-			apiserver.ObserveAdditionalCORSAllowedOrigins,
-			apiserver.ObserveShutdownDelayDuration,
-			apiserver.ObserveGracefulTerminationDuration,
-			libgoapiserver.ObserveTLSSecurityProfile,
-			auth.ObserveAuthMetadata,
-			auth.ObserveServiceAccountIssuer,
-			auth.ObserveWebhookTokenAuthenticator,
-			encryption.NewEncryptionConfigObserver(
-				operatorclient.TargetNamespace,
-				// static path at which we expect to find the encryption config secret
-				"/etc/kubernetes/static-pod-resources/secrets/encryption-config/encryption-config",
-			),
-			etcdendpoints.ObserveStorageURLs,
-			cloudprovider.NewCloudProviderObserver(
-				"openshift-kube-apiserver",
-				[]string{"apiServerArguments", "cloud-provider"},
-				[]string{"apiServerArguments", "cloud-config"}),
-			featuregates.NewObserveFeatureFlagsFunc(
-				nil,
-				FeatureBlacklist,
-				[]string{"apiServerArguments", "feature-gates"},
-			),
-			network.ObserveRestrictedCIDRs,
-			network.ObserveServicesSubnet,
-			network.ObserveExternalIPPolicy,
-			network.ObserveServicesNodePortRange,
-			proxy.NewProxyObserveFunc([]string{"targetconfigcontroller", "proxy"}),
-			images.ObserveInternalRegistryHostname,
-			images.ObserveExternalRegistryHostnames,
-			images.ObserveAllowedRegistriesForImport,
-			scheduler.ObserveDefaultNodeSelector,
+			// every observer reads the same snapshot of the feature gate in an observe pass
+			featureGates.Observers(
+				// We are disabling this because it doesn't work today and customers aren't going to be able to get the kube service network options right.
+				// Customers may only use SNI.  I'm leaving this code in case we ever come up with a way to make an SNI-like thing based on IPs.
+				//apiserver.ObserveDefaultUserServingCertificate,
+				apiserver.ObserveNamedCertificates,
+				apiserver.ObserveUserClientCABundle,
+				apiserver.ObserveAdditionalCORSAllowedOrigins,
+				apiserver.ObserveShutdownDelayDuration,
+				apiserver.ObserveShutdownSendRetryAfter,
+				apiserver.ObserveGracefulTerminationDuration,
+				apiserver.ObserveAuditLogRotation,
+				apiserver.ObserveAuditLogFormat,
+				apiserver.ObserveLoggingFormat,
+				apiserver.ObserveMaxInflightRequests,
+				apiserver.ObservePriorityAndFairness,
+				apiserver.ObserveRequestTimeout,
+				apiserver.ObserveWatchCacheSizes,
+				apiserver.ObserveGoawayChance,
+				apiserver.ObserveAuditWebhook,
+				apiserver.ObserveRuntimeConfig,
+				apiserver.ObserveProfiling,
+				apiserver.ObserveContentionProfiling,
+				apiserver.ObserveKubeletPreferredAddressTypes,
+				apiserver.ObserveEventTTL,
+				apiserver.ObserveStorageMediaType,
+				apiserver.ObserveHTTP2MaxStreamsPerConnection,
+				apiserver.ObserveLeaseReuseDuration,
+				apiserver.ObserveMaxConnectionBytesPerSec,
+				apiserver.NewTLSSecurityProfileObserver(
+					// HTTP/2 requires TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 with TLS 1.2, see RFC 7540 section 9.2.2
+					"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+					"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+				),
+				auth.ObserveAuthMetadata,
+				riskyChangeGuard.Guard("service-account-issuer", auth.ObserveServiceAccountIssuer),
+				auth.ObserveServiceAccountMaxTokenExpiration,
+				auth.ObserveRequestHeaders,
+				auth.ObserveWebhookTokenAuthenticator,
+				encryption.NewEncryptionConfigObserver(
+					operatorclient.TargetNamespace,
+					// static path at which we expect to find the encryption config secret
+					"/etc/kubernetes/static-pod-resources/secrets/encryption-config/encryption-config",
+				),
+				etcdendpoints.ObserveStorageURLs,
+				cloudprovider.NewCloudProviderObserver(
+					"openshift-kube-apiserver",
+					[]string{"apiServerArguments", "cloud-provider"},
+					[]string{"apiServerArguments", "cloud-config"},
+					cloudprovider.WithDefaultCloudConfigValidators()),
+				featuregates.NewObserveFeatureFlagsFunc(
+					nil,
+					FeatureBlacklist,
+					[]string{"apiServerArguments", "feature-gates"},
+				),
+				admission.NewFeatureGateAdmissionPluginsObserver(FeatureGateAdmissionPlugins),
+				admission.ObserveAdmissionPluginConfigs,
+				admission.ObservePodNodeSelector,
+				admission.ObserveDefaultTolerationSeconds,
+				network.ObserveRestrictedCIDRs,
+				riskyChangeGuard.Guard("services-subnet", network.ObserveServicesSubnet),
+				network.ObserveExternalIPPolicy,
+				network.ObserveServicesNodePortRange,
+				network.ObserveEnableAggregatorRouting,
+				konnectivity.ObserveEgressSelector,
+				proxy.NewProxyObserveFunc([]string{"targetconfigcontroller", "proxy"}),
+				images.ObserveInternalRegistryHostname,
+				images.ObserveExternalRegistryHostnames,
+				images.ObserveAllowedRegistriesForImport,
+				scheduler.ObserveDefaultNodeSelector,
+			)...,
 		),
 	}
 
@@ -0,0 +1,48 @@
+package configobservercontroller
+
+import (
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/operator/configobserver/featuregates"
+	"github.com/openshift/library-go/pkg/operator/events"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation/apiserver"
+)
+
+func TestFeatureBlacklistKeepsOperatorGatesFromTheKubeAPIServer(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(&configv1.FeatureGate{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: configv1.FeatureGateSpec{FeatureGateSelection: configv1.FeatureGateSelection{
+			FeatureSet:      configv1.CustomNoUpgrade,
+			CustomNoUpgrade: &configv1.CustomFeatureGates{Enabled: []string{"APIPriorityAndFairness", apiserver.StorageLeaseTuningFeatureGate}},
+		}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	listers := configobservation.Listers{FeatureGateLister_: configlistersv1.NewFeatureGateLister(indexer)}
+
+	observe := featuregates.NewObserveFeatureFlagsFunc(nil, FeatureBlacklist, []string{"apiServerArguments", "feature-gates"})
+	observedConfig, errs := observe(listers, events.NewInMemoryRecorder(t.Name()), map[string]interface{}{})
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	gates, _, err := unstructured.NestedStringSlice(observedConfig, "apiServerArguments", "feature-gates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gates) == 0 {
+		t.Fatalf("expected the feature gates to be observed, got %v", observedConfig)
+	}
+	for _, gate := range gates {
+		if gate == apiserver.StorageLeaseTuningFeatureGate+"=true" {
+			t.Errorf("expected the %s feature gate of the operator not to be passed to the kube-apiserver, got %v", apiserver.StorageLeaseTuningFeatureGate, gates)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package configobservercontroller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+// apiServerArgumentsKey holds the kube-apiserver flags. Its changes are reported per flag, which is what tells why
+// the kube-apiserver rolled out.
+const apiServerArgumentsKey = "apiServerArguments"
+
+// ObservedConfigChangeController reports which keys of the observed config changed whenever the config observers
+// write a new one: an ObservedConfigKeysChanged event lists them, and the
+// kube_apiserver_operator_observed_config_changes_total metric counts the changes of each key. The top-level keys are
+// reported, except for the apiServerArguments which are reported per argument. The observed config it compares with
+// is kept in memory, the first one seen after a restart is not reported.
+type ObservedConfigChangeController struct {
+	factory.Controller
+	operatorClient v1helpers.OperatorClient
+
+	lastObservedConfig map[string]interface{}
+}
+
+func NewObservedConfigChangeController(operatorClient v1helpers.OperatorClient, eventRecorder events.Recorder) *ObservedConfigChangeController {
+	c := &ObservedConfigChangeController{operatorClient: operatorClient}
+	c.Controller = factory.New().
+		WithSync(c.sync).
+		WithInformers(operatorClient.Informer()).
+		ToController("ObservedConfigChangeController", eventRecorder.WithComponentSuffix("observed-config-change-controller"))
+	return c
+}
+
+func (c *ObservedConfigChangeController) sync(_ context.Context, syncCtx factory.SyncContext) error {
+	spec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+
+	observedConfig := map[string]interface{}{}
+	if len(spec.ObservedConfig.Raw) > 0 {
+		if err := json.NewDecoder(bytes.NewBuffer(spec.ObservedConfig.Raw)).Decode(&observedConfig); err != nil {
+			return fmt.Errorf("unable to decode the observed config: %v", err)
+		}
+	}
+
+	if c.lastObservedConfig != nil {
+		if changed := changedObservedConfigKeys(c.lastObservedConfig, observedConfig); len(changed) > 0 {
+			klog.V(2).Infof("Observed config keys changed: %s", strings.Join(changed, ", "))
+			syncCtx.Recorder().Eventf("ObservedConfigKeysChanged", "Observed config keys changed: %s", strings.Join(changed, ", "))
+			for _, key := range changed {
+				observedConfigChangesTotal.WithLabelValues(key).Inc()
+			}
+		}
+	}
+	c.lastObservedConfig = observedConfig
+	return nil
+}
+
+// changedObservedConfigKeys returns the sorted keys whose value differs between the two observed configs, including
+// the ones only set in one of them. The apiServerArguments are compared per argument, as "apiServerArguments.<name>".
+func changedObservedConfigKeys(old, new map[string]interface{}) []string {
+	var changed []string
+	for _, key := range unionKeys(old, new).List() {
+		if equality.Semantic.DeepEqual(old[key], new[key]) {
+			continue
+		}
+		if key == apiServerArgumentsKey {
+			oldArgs, oldOK := old[key].(map[string]interface{})
+			newArgs, newOK := new[key].(map[string]interface{})
+			if (oldOK || old[key] == nil) && (newOK || new[key] == nil) {
+				for _, arg := range unionKeys(oldArgs, newArgs).List() {
+					if !equality.Semantic.DeepEqual(oldArgs[arg], newArgs[arg]) {
+						changed = append(changed, apiServerArgumentsKey+"."+arg)
+					}
+				}
+				continue
+			}
+		}
+		changed = append(changed, key)
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func unionKeys(a, b map[string]interface{}) sets.String {
+	keys := sets.NewString()
+	for key := range a {
+		keys.Insert(key)
+	}
+	for key := range b {
+		keys.Insert(key)
+	}
+	return keys
+}
@@ -0,0 +1,109 @@
+package configobservercontroller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestObservedConfigChangeController(t *testing.T) {
+	operatorClient := v1helpers.NewFakeOperatorClient(
+		&operatorv1.OperatorSpec{
+			ManagementState: operatorv1.Managed,
+			ObservedConfig: runtime.RawExtension{Raw: []byte(`{
+				"admission": {"pluginConfig": {"network.openshift.io/RestrictedEndpointsAdmission": {"configuration": {"restrictedCIDRs": ["10.128.0.0/14"]}}}},
+				"apiServerArguments": {"feature-gates": ["RotateKubeletServerCertificate=true"], "goaway-chance": ["0.001"]},
+				"servingInfo": {"minTLSVersion": "VersionTLS12"}
+			}`)},
+		},
+		&operatorv1.OperatorStatus{},
+		nil,
+	)
+	c := &ObservedConfigChangeController{operatorClient: operatorClient}
+
+	recorder := events.NewInMemoryRecorder("test")
+	syncCtx := factory.NewSyncContext("test", recorder)
+	observe := func(observedConfig string) []string {
+		t.Helper()
+		if _, _, err := v1helpers.UpdateSpec(operatorClient, func(spec *operatorv1.OperatorSpec) error {
+			spec.ObservedConfig = runtime.RawExtension{Raw: []byte(observedConfig)}
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		before := len(recorder.Events())
+		if err := c.sync(context.TODO(), syncCtx); err != nil {
+			t.Fatal(err)
+		}
+		var messages []string
+		for _, event := range recorder.Events()[before:] {
+			if event.Reason == "ObservedConfigKeysChanged" {
+				messages = append(messages, event.Message)
+			}
+		}
+		return messages
+	}
+
+	// the observed config found at startup is the baseline
+	if err := c.sync(context.TODO(), syncCtx); err != nil {
+		t.Fatal(err)
+	}
+	if len(recorder.Events()) > 0 {
+		t.Fatalf("expected no event for the initial observed config, got %v", recorder.Events())
+	}
+
+	for _, tc := range []struct {
+		name           string
+		observedConfig string
+		expectedKeys   string
+	}{
+		{
+			name:           "unchanged",
+			observedConfig: `{"admission": {"pluginConfig": {"network.openshift.io/RestrictedEndpointsAdmission": {"configuration": {"restrictedCIDRs": ["10.128.0.0/14"]}}}}, "apiServerArguments": {"feature-gates": ["RotateKubeletServerCertificate=true"], "goaway-chance": ["0.001"]}, "servingInfo": {"minTLSVersion": "VersionTLS12"}}`,
+		},
+		{
+			name:           "argument changed",
+			observedConfig: `{"admission": {"pluginConfig": {"network.openshift.io/RestrictedEndpointsAdmission": {"configuration": {"restrictedCIDRs": ["10.128.0.0/14"]}}}}, "apiServerArguments": {"feature-gates": ["RotateKubeletServerCertificate=true"], "goaway-chance": ["0.002"]}, "servingInfo": {"minTLSVersion": "VersionTLS12"}}`,
+			expectedKeys:   "apiServerArguments.goaway-chance",
+		},
+		{
+			name:           "arguments added and removed",
+			observedConfig: `{"admission": {"pluginConfig": {"network.openshift.io/RestrictedEndpointsAdmission": {"configuration": {"restrictedCIDRs": ["10.128.0.0/14"]}}}}, "apiServerArguments": {"feature-gates": ["RotateKubeletServerCertificate=true"], "profiling": ["true"]}, "servingInfo": {"minTLSVersion": "VersionTLS12"}}`,
+			expectedKeys:   "apiServerArguments.goaway-chance, apiServerArguments.profiling",
+		},
+		{
+			name:           "nested top-level key changed",
+			observedConfig: `{"admission": {"pluginConfig": {"network.openshift.io/RestrictedEndpointsAdmission": {"configuration": {"restrictedCIDRs": ["10.128.0.0/14", "172.30.0.0/16"]}}}}, "apiServerArguments": {"feature-gates": ["RotateKubeletServerCertificate=true"], "profiling": ["true"]}, "servingInfo": {"minTLSVersion": "VersionTLS13"}}`,
+			expectedKeys:   "admission, servingInfo",
+		},
+		{
+			name:           "top-level keys added and removed",
+			observedConfig: `{"apiServerArguments": {"feature-gates": ["RotateKubeletServerCertificate=true"], "profiling": ["true"]}, "corsAllowedOrigins": ["//127\\.0\\.0\\.1(:|$)"], "servingInfo": {"minTLSVersion": "VersionTLS13"}}`,
+			expectedKeys:   "admission, corsAllowedOrigins",
+		},
+		{
+			name:           "all arguments removed",
+			observedConfig: `{"corsAllowedOrigins": ["//127\\.0\\.0\\.1(:|$)"], "servingInfo": {"minTLSVersion": "VersionTLS13"}}`,
+			expectedKeys:   "apiServerArguments.feature-gates, apiServerArguments.profiling",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			messages := observe(tc.observedConfig)
+			if len(tc.expectedKeys) == 0 {
+				if len(messages) > 0 {
+					t.Fatalf("expected no change to be reported, got %v", messages)
+				}
+				return
+			}
+			if len(messages) != 1 || !strings.HasSuffix(messages[0], ": "+tc.expectedKeys) {
+				t.Fatalf("expected the changed keys %q to be reported, got %v", tc.expectedKeys, messages)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package configobservercontroller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/encryption/statemachine"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// RiskyConfigChangesDeferredConditionType is True while observed config changes of RiskyObservedConfigChanges are
+	// held back because the control plane is degraded. They are applied once all the masters are available again.
+	RiskyConfigChangesDeferredConditionType = "RiskyConfigChangesDeferred"
+
+	ControlPlaneDegradedReason = "ControlPlaneDegraded"
+)
+
+// RiskyObservedConfigChange names the paths of the observed config written by one observer whose change rolls out a
+// kube-apiserver that must not be split across a degraded control plane.
+type RiskyObservedConfigChange struct {
+	Name  string
+	Paths [][]string
+}
+
+// RiskyObservedConfigChanges are the observed config changes deferred while the control plane is degraded:
+//   - services-subnet: a kube-apiserver on a different service network than its peers allocates conflicting
+//     service IPs;
+//   - service-account-issuer: the kube-apiservers not on the new issuer reject the tokens it signs.
+//
+// The etcd endpoints are not in the list, they change precisely when an etcd member of a degraded control plane is
+// replaced. Encryption is not either: the observed encryption config only follows the encryption controllers, which
+// the guard pauses through their Deployer instead.
+var RiskyObservedConfigChanges = []RiskyObservedConfigChange{
+	{
+		Name:  "services-subnet",
+		Paths: [][]string{{"servicesSubnet"}, {"servingInfo", "bindAddress"}, {"servingInfo", "bindNetwork"}},
+	},
+	{
+		Name: "service-account-issuer",
+		Paths: [][]string{
+			{"apiServerArguments", "service-account-issuer"},
+			{"apiServerArguments", "api-audiences"},
+			{"apiServerArguments", "service-account-jwks-uri"},
+			{"serviceAccountIssuerRotation", "trustedIssuers"},
+		},
+	},
+}
+
+// RiskyConfigChangeGuard holds back the RiskyObservedConfigChanges while fewer masters than the operator installs the
+// kube-apiserver on are Ready: the observers guarded by it keep their previous observed config until the control
+// plane is healthy again, the config observer picks the change up on its next resync. The guard does not apply
+// before the kube-apiserver was first rolled out, nothing can be split then. The controller reports the deferred
+// changes in the RiskyConfigChangesDeferred condition.
+type RiskyConfigChangeGuard struct {
+	factory.Controller
+	operatorClient v1helpers.StaticPodOperatorClient
+	nodeInformer   cache.SharedIndexInformer
+	nodeLister     corev1listers.NodeLister
+
+	lock     sync.Mutex
+	deferred map[string]string
+}
+
+func NewRiskyConfigChangeGuard(
+	operatorClient v1helpers.StaticPodOperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	eventRecorder events.Recorder,
+) *RiskyConfigChangeGuard {
+	nodeInformer := kubeInformersForNamespaces.InformersFor("").Core().V1().Nodes()
+	g := &RiskyConfigChangeGuard{
+		operatorClient: operatorClient,
+		nodeInformer:   nodeInformer.Informer(),
+		nodeLister:     nodeInformer.Lister(),
+		deferred:       map[string]string{},
+	}
+	g.Controller = factory.New().
+		WithSync(g.sync).
+		WithInformers(operatorClient.Informer(), nodeInformer.Informer()).
+		ResyncEvery(time.Minute).
+		ToController("RiskyConfigChangeGuard", eventRecorder.WithComponentSuffix("risky-config-change-guard"))
+	return g
+}
+
+// Guard wraps the observer writing the paths of the named RiskyObservedConfigChange. While the control plane is
+// degraded and the observed paths differ from the existing config, the observer returns the existing values instead.
+func (g *RiskyConfigChangeGuard) Guard(name string, observer configobserver.ObserveConfigFunc) configobserver.ObserveConfigFunc {
+	var change *RiskyObservedConfigChange
+	for i := range RiskyObservedConfigChanges {
+		if RiskyObservedConfigChanges[i].Name == name {
+			change = &RiskyObservedConfigChanges[i]
+		}
+	}
+	if change == nil {
+		panic(fmt.Sprintf("unknown risky observed config change %q", name))
+	}
+
+	return func(listers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		observedConfig, errs := observer(listers, recorder, existingConfig)
+
+		previousConfig := configobserver.Pruned(existingConfig, change.Paths...)
+		if equality.Semantic.DeepEqual(configobserver.Pruned(observedConfig, change.Paths...), previousConfig) {
+			g.setDeferred(change.Name, "")
+			return observedConfig, errs
+		}
+		healthy, message, err := g.controlPlaneHealth()
+		if err != nil {
+			// without knowing the health of the control plane the change is held back
+			healthy, message = false, fmt.Sprintf("unable to determine the control plane health: %v", err)
+		}
+		if healthy {
+			g.setDeferred(change.Name, "")
+			return observedConfig, errs
+		}
+
+		klog.Infof("Deferring the %s observed config change: %s", change.Name, message)
+		if g.setDeferred(change.Name, message) {
+			recorder.Warningf("RiskyConfigChangeDeferred", "Deferring the %s observed config change until the control plane is healthy: %s", change.Name, message)
+		}
+		return previousConfig, errs
+	}
+}
+
+// Deployer pauses the encryption controllers using the deployer while the control plane is degraded: it reports the
+// deployed encryption config as not converged, so they neither create keys, nor change the encryption config, nor
+// start a migration, which all need every kube-apiserver on the same encryption config. The controllers resume on
+// the next node change once all the masters are available again.
+func (g *RiskyConfigChangeGuard) Deployer(deployer statemachine.Deployer) statemachine.Deployer {
+	return &guardedDeployer{Deployer: deployer, guard: g}
+}
+
+type guardedDeployer struct {
+	statemachine.Deployer
+	guard *RiskyConfigChangeGuard
+}
+
+func (d *guardedDeployer) DeployedEncryptionConfigSecret() (*corev1.Secret, bool, error) {
+	secret, converged, err := d.Deployer.DeployedEncryptionConfigSecret()
+	if err != nil || !converged {
+		return secret, converged, err
+	}
+	healthy, message, err := d.guard.controlPlaneHealth()
+	if err != nil {
+		healthy, message = false, fmt.Sprintf("unable to determine the control plane health: %v", err)
+	}
+	if !healthy {
+		if d.guard.setDeferred("encryption", message) {
+			klog.Infof("Pausing the encryption controllers: %s", message)
+		}
+		return secret, false, nil
+	}
+	d.guard.setDeferred("encryption", "")
+	return secret, true, nil
+}
+
+func (d *guardedDeployer) AddEventHandler(handler cache.ResourceEventHandler) {
+	d.Deployer.AddEventHandler(handler)
+	d.guard.nodeInformer.AddEventHandler(handler)
+}
+
+func (d *guardedDeployer) HasSynced() bool {
+	return d.Deployer.HasSynced() && d.guard.nodeInformer.HasSynced()
+}
+
+// setDeferred records whether the change is deferred, with the reason, and returns whether it was not before.
+func (g *RiskyConfigChangeGuard) setDeferred(name, message string) bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	_, wasDeferred := g.deferred[name]
+	if len(message) == 0 {
+		delete(g.deferred, name)
+		return false
+	}
+	g.deferred[name] = message
+	return !wasDeferred
+}
+
+// controlPlaneHealth returns whether every node the kube-apiserver is installed on is Ready, and why not.
+// It is always healthy before the kube-apiserver was first rolled out.
+func (g *RiskyConfigChangeGuard) controlPlaneHealth() (bool, string, error) {
+	_, status, _, err := g.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		return false, "", err
+	}
+	rolledOut := false
+	var notReady []string
+	for _, ns := range status.NodeStatuses {
+		if ns.CurrentRevision > 0 {
+			rolledOut = true
+		}
+		node, err := g.nodeLister.Get(ns.NodeName)
+		if apierrors.IsNotFound(err) {
+			notReady = append(notReady, ns.NodeName)
+			continue
+		}
+		if err != nil {
+			return false, "", err
+		}
+		if !nodeReady(node) {
+			notReady = append(notReady, ns.NodeName)
+		}
+	}
+	if !rolledOut || len(notReady) == 0 {
+		return true, "", nil
+	}
+	sort.Strings(notReady)
+	return false, fmt.Sprintf("%d of %d masters are available, not ready: %s", len(status.NodeStatuses)-len(notReady), len(status.NodeStatuses), strings.Join(notReady, ", ")), nil
+}
+
+func nodeReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func (g *RiskyConfigChangeGuard) sync(_ context.Context, _ factory.SyncContext) error {
+	spec, _, _, err := g.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(spec.ManagementState) {
+		return nil
+	}
+
+	g.lock.Lock()
+	var deferred []string
+	for name, message := range g.deferred {
+		deferred = append(deferred, fmt.Sprintf("%s (%s)", name, message))
+	}
+	g.lock.Unlock()
+	sort.Strings(deferred)
+
+	condition := operatorv1.OperatorCondition{
+		Type:   RiskyConfigChangesDeferredConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: AsExpectedReason,
+	}
+	if len(deferred) > 0 {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = ControlPlaneDegradedReason
+		condition.Message = fmt.Sprintf("Risky config changes are deferred until all the masters are available: %s", strings.Join(deferred, "; "))
+	}
+
+	_, _, err = v1helpers.UpdateStaticPodStatus(g.operatorClient, v1helpers.UpdateStaticPodConditionFn(condition))
+	return err
+}
@@ -0,0 +1,244 @@
+package configobservercontroller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/encryption/statemachine"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func node(name string, ready corev1.ConditionStatus) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}}},
+	}
+}
+
+func TestRiskyConfigChangeGuard(t *testing.T) {
+	issuer := map[string]interface{}{"apiServerArguments": map[string]interface{}{
+		"service-account-issuer": []interface{}{"https://issuer.example.com"},
+		"api-audiences":          []interface{}{"https://issuer.example.com"},
+	}}
+	rolledOut := []operatorv1.NodeStatus{
+		{NodeName: "master-0", CurrentRevision: 3},
+		{NodeName: "master-1", CurrentRevision: 3},
+		{NodeName: "master-2", CurrentRevision: 3},
+	}
+
+	tests := []struct {
+		name           string
+		nodeStatuses   []operatorv1.NodeStatus
+		nodes          []*corev1.Node
+		existingConfig map[string]interface{}
+		observedConfig map[string]interface{}
+
+		expectedConfig   map[string]interface{}
+		expectedDeferred bool
+	}{
+		{
+			name:           "healthy control plane, the change applies",
+			nodeStatuses:   rolledOut,
+			nodes:          []*corev1.Node{node("master-0", corev1.ConditionTrue), node("master-1", corev1.ConditionTrue), node("master-2", corev1.ConditionTrue)},
+			existingConfig: map[string]interface{}{},
+			observedConfig: issuer,
+			expectedConfig: issuer,
+		},
+		{
+			name:             "a master not ready, setting the issuer is deferred",
+			nodeStatuses:     rolledOut,
+			nodes:            []*corev1.Node{node("master-0", corev1.ConditionTrue), node("master-1", corev1.ConditionFalse), node("master-2", corev1.ConditionTrue)},
+			existingConfig:   map[string]interface{}{},
+			observedConfig:   issuer,
+			expectedConfig:   map[string]interface{}{},
+			expectedDeferred: true,
+		},
+		{
+			name:             "a master missing, resetting the issuer is deferred",
+			nodeStatuses:     rolledOut,
+			nodes:            []*corev1.Node{node("master-0", corev1.ConditionTrue), node("master-2", corev1.ConditionTrue)},
+			existingConfig:   issuer,
+			observedConfig:   map[string]interface{}{},
+			expectedConfig:   issuer,
+			expectedDeferred: true,
+		},
+		{
+			name:           "degraded control plane without a change",
+			nodeStatuses:   rolledOut,
+			nodes:          []*corev1.Node{node("master-0", corev1.ConditionTrue)},
+			existingConfig: issuer,
+			observedConfig: issuer,
+			expectedConfig: issuer,
+		},
+		{
+			name: "not rolled out yet, the change applies",
+			nodeStatuses: []operatorv1.NodeStatus{
+				{NodeName: "master-0"}, {NodeName: "master-1"}, {NodeName: "master-2"},
+			},
+			nodes:          []*corev1.Node{node("master-0", corev1.ConditionTrue)},
+			existingConfig: map[string]interface{}{},
+			observedConfig: issuer,
+			expectedConfig: issuer,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, n := range test.nodes {
+				if err := indexer.Add(n); err != nil {
+					t.Fatal(err)
+				}
+			}
+			operatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+				&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+				&operatorv1.StaticPodOperatorStatus{NodeStatuses: test.nodeStatuses},
+				nil,
+				nil,
+			)
+			guard := &RiskyConfigChangeGuard{
+				operatorClient: operatorClient,
+				nodeLister:     corev1listers.NewNodeLister(indexer),
+				deferred:       map[string]string{},
+			}
+
+			observer := guard.Guard("service-account-issuer", func(configobserver.Listers, events.Recorder, map[string]interface{}) (map[string]interface{}, []error) {
+				return test.observedConfig, nil
+			})
+			recorder := events.NewInMemoryRecorder("test")
+			observedConfig, errs := observer(nil, recorder, test.existingConfig)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if diff := cmp.Diff(test.expectedConfig, observedConfig); diff != "" {
+				t.Errorf("unexpected observed config (-want +got):\n%s", diff)
+			}
+			if warned := len(recorder.Events()) > 0; warned != test.expectedDeferred {
+				t.Errorf("expected a deferral event %v, got %v", test.expectedDeferred, recorder.Events())
+			}
+
+			if err := guard.sync(context.TODO(), factory.NewSyncContext("test", recorder)); err != nil {
+				t.Fatal(err)
+			}
+			_, status, _, err := operatorClient.GetStaticPodOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			condition := v1helpers.FindOperatorCondition(status.Conditions, RiskyConfigChangesDeferredConditionType)
+			if condition == nil {
+				t.Fatal("expected the condition to be set")
+			}
+			if deferred := condition.Status == operatorv1.ConditionTrue; deferred != test.expectedDeferred {
+				t.Errorf("expected deferred %v, got %#v", test.expectedDeferred, condition)
+			}
+			if test.expectedDeferred && !strings.Contains(condition.Message, "service-account-issuer (2 of 3 masters are available") {
+				t.Errorf("unexpected message %q", condition.Message)
+			}
+		})
+	}
+}
+
+func TestRiskyConfigChangeGuardRecovers(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(node("master-0", corev1.ConditionFalse)); err != nil {
+		t.Fatal(err)
+	}
+	guard := &RiskyConfigChangeGuard{
+		operatorClient: v1helpers.NewFakeStaticPodOperatorClient(
+			&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+			&operatorv1.StaticPodOperatorStatus{NodeStatuses: []operatorv1.NodeStatus{{NodeName: "master-0", CurrentRevision: 1}}},
+			nil,
+			nil,
+		),
+		nodeLister: corev1listers.NewNodeLister(indexer),
+		deferred:   map[string]string{},
+	}
+	observedSubnet := map[string]interface{}{"servicesSubnet": "172.31.0.0/16", "servingInfo": map[string]interface{}{"bindAddress": "0.0.0.0:6443", "bindNetwork": "tcp4"}}
+	existingSubnet := map[string]interface{}{"servicesSubnet": "172.30.0.0/16", "servingInfo": map[string]interface{}{"bindAddress": "0.0.0.0:6443", "bindNetwork": "tcp4"}}
+	observer := guard.Guard("services-subnet", func(configobserver.Listers, events.Recorder, map[string]interface{}) (map[string]interface{}, []error) {
+		return observedSubnet, nil
+	})
+	recorder := events.NewInMemoryRecorder("test")
+
+	if observedConfig, _ := observer(nil, recorder, existingSubnet); !cmp.Equal(existingSubnet, observedConfig) {
+		t.Fatalf("expected the change to be deferred, got %v", observedConfig)
+	}
+	// a second deferral does not emit another event
+	observer(nil, recorder, existingSubnet)
+	if len(recorder.Events()) != 1 {
+		t.Errorf("expected one deferral event, got %v", recorder.Events())
+	}
+
+	if err := indexer.Update(node("master-0", corev1.ConditionTrue)); err != nil {
+		t.Fatal(err)
+	}
+	if observedConfig, _ := observer(nil, recorder, existingSubnet); !cmp.Equal(observedSubnet, observedConfig) {
+		t.Fatalf("expected the change to apply once the control plane is healthy, got %v", observedConfig)
+	}
+	if len(guard.deferred) > 0 {
+		t.Errorf("expected no deferred change, got %v", guard.deferred)
+	}
+}
+
+type fakeDeployer struct {
+	statemachine.Deployer
+	secret    *corev1.Secret
+	converged bool
+}
+
+func (d *fakeDeployer) DeployedEncryptionConfigSecret() (*corev1.Secret, bool, error) {
+	return d.secret, d.converged, nil
+}
+
+func TestRiskyConfigChangeGuardPausesEncryption(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(node("master-0", corev1.ConditionFalse)); err != nil {
+		t.Fatal(err)
+	}
+	guard := &RiskyConfigChangeGuard{
+		operatorClient: v1helpers.NewFakeStaticPodOperatorClient(
+			&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+			&operatorv1.StaticPodOperatorStatus{NodeStatuses: []operatorv1.NodeStatus{{NodeName: "master-0", CurrentRevision: 1}}},
+			nil,
+			nil,
+		),
+		nodeLister: corev1listers.NewNodeLister(indexer),
+		deferred:   map[string]string{},
+	}
+	encryptionConfig := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-kube-apiserver", Name: "encryption-config-1"}}
+	inner := &fakeDeployer{secret: encryptionConfig, converged: true}
+	deployer := guard.Deployer(inner)
+
+	if secret, converged, err := deployer.DeployedEncryptionConfigSecret(); err != nil || converged || secret != encryptionConfig {
+		t.Fatalf("expected the encryption config not to be converged on a degraded control plane, got %v, %v, %v", secret, converged, err)
+	}
+	if _, ok := guard.deferred["encryption"]; !ok {
+		t.Errorf("expected the encryption to be reported as deferred, got %v", guard.deferred)
+	}
+
+	if err := indexer.Update(node("master-0", corev1.ConditionTrue)); err != nil {
+		t.Fatal(err)
+	}
+	if _, converged, err := deployer.DeployedEncryptionConfigSecret(); err != nil || !converged {
+		t.Fatalf("expected the encryption config to be converged once the control plane is healthy, got %v, %v", converged, err)
+	}
+	if len(guard.deferred) > 0 {
+		t.Errorf("expected no deferred change, got %v", guard.deferred)
+	}
+
+	// a deployer still converging is reported as is
+	inner.converged = false
+	if _, converged, err := deployer.DeployedEncryptionConfigSecret(); err != nil || converged {
+		t.Fatalf("expected the encryption config not to be converged, got %v, %v", converged, err)
+	}
+}
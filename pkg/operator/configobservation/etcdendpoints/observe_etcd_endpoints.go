@@ -1,6 +1,10 @@
 package etcdendpoints
 
 import (
+	"fmt"
+	"strconv"
+	"time"
+
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/openshift/library-go/pkg/operator/configobserver"
@@ -8,9 +12,27 @@ import (
 	"github.com/openshift/library-go/pkg/operator/events"
 )
 
+var (
+	// the etcdEndpointsShrinkGate holds the state of a pending shrink of the storage URLs. It is not part of the
+	// KubeAPIServerConfig and is only kept in the observed config of the operator.
+	shrinkGateLastKnownGoodPath   = []string{"etcdEndpointsShrinkGate", "lastKnownGoodCount"}
+	shrinkGateFirstObservedAtPath = []string{"etcdEndpointsShrinkGate", "firstObservedAt"}
+)
+
+// storageURLsShrinkStabilityWindow is how long a smaller set of storage URLs has to be observed before it replaces
+// the current one. It keeps a transient etcd outage, during which members drop out of the endpoints, from cutting
+// the kube-apiserver off the remaining members.
+const storageURLsShrinkStabilityWindow = 5 * time.Minute
+
 // ObserveStorageURLs observes the storage config URLs. If there is a problem observing the current storage config URLs,
 // then the previously observed storage config URLs will be re-used.
+// A smaller set of storage URLs than the last known good one is only used once it has been observed for
+// storageURLsShrinkStabilityWindow.
 func ObserveStorageURLs(genericListers configobserver.Listers, recorder events.Recorder, currentConfig map[string]interface{}) (map[string]interface{}, []error) {
+	return observeStorageURLs(genericListers, recorder, currentConfig, time.Now())
+}
+
+func observeStorageURLs(genericListers configobserver.Listers, recorder events.Recorder, currentConfig map[string]interface{}, now time.Time) (map[string]interface{}, []error) {
 	var errs []error
 
 	// get the current config either from the old path or the new one
@@ -23,17 +45,73 @@ func ObserveStorageURLs(genericListers configobserver.Listers, recorder events.R
 	if err != nil {
 		errs = append(errs, err)
 	}
+	var currentEtcdURLs []string
 	if newFound {
-		if err := unstructured.SetNestedStringSlice(previouslyObservedConfig, newCurrentEtcdURLs, libgoetcd.StorageConfigURLsPath...); err != nil {
-			errs = append(errs, err)
-		}
+		currentEtcdURLs = newCurrentEtcdURLs
 	} else if oldFound {
-		if err := unstructured.SetNestedStringSlice(previouslyObservedConfig, oldCurrentEtcdURLs, libgoetcd.StorageConfigURLsPath...); err != nil {
+		currentEtcdURLs = oldCurrentEtcdURLs
+	}
+	if len(currentEtcdURLs) > 0 {
+		if err := unstructured.SetNestedStringSlice(previouslyObservedConfig, currentEtcdURLs, libgoetcd.StorageConfigURLsPath...); err != nil {
 			errs = append(errs, err)
 		}
 	}
 
 	// always stores the config at the new path
 	updatedConfig, newErrs := libgoetcd.ObserveStorageURLsToArgumentsWithAlwaysLocal(genericListers, recorder, previouslyObservedConfig)
-	return updatedConfig, append(errs, newErrs...)
+	errs = append(errs, newErrs...)
+
+	observedEtcdURLs, _, err := unstructured.NestedStringSlice(updatedConfig, libgoetcd.StorageConfigURLsPath...)
+	if err != nil {
+		return previouslyObservedConfig, append(errs, err)
+	}
+	// the current URLs are the last known good ones, they were either accepted or held by a previous observation
+	lastKnownGoodCount := len(currentEtcdURLs)
+	if len(observedEtcdURLs) >= lastKnownGoodCount {
+		return updatedConfig, errs
+	}
+
+	firstObservedAt, err := shrinkFirstObservedAt(currentConfig, lastKnownGoodCount)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if firstObservedAt.IsZero() {
+		firstObservedAt = now
+	}
+	if stableFor := now.Sub(firstObservedAt); stableFor >= storageURLsShrinkStabilityWindow {
+		recorder.Eventf("ObserveStorageURLsShrinkAccepted", "Storage URLs shrank from %d to %d entries for %v, using %v", lastKnownGoodCount, len(observedEtcdURLs), stableFor.Round(time.Second), observedEtcdURLs)
+		return updatedConfig, errs
+	}
+
+	recorder.Warningf("ObserveStorageURLsShrinkHeld", "Keeping the %d last known good storage URLs instead of the %d observed ones %v until they are stable for %v", lastKnownGoodCount, len(observedEtcdURLs), observedEtcdURLs, storageURLsShrinkStabilityWindow)
+	heldConfig := previouslyObservedConfig
+	if err := unstructured.SetNestedField(heldConfig, strconv.Itoa(lastKnownGoodCount), shrinkGateLastKnownGoodPath...); err != nil {
+		errs = append(errs, err)
+	}
+	if err := unstructured.SetNestedField(heldConfig, firstObservedAt.UTC().Format(time.RFC3339), shrinkGateFirstObservedAtPath...); err != nil {
+		errs = append(errs, err)
+	}
+	return heldConfig, errs
+}
+
+// shrinkFirstObservedAt returns when the pending shrink of the lastKnownGoodCount storage URLs was first observed,
+// or the zero time when there is none.
+func shrinkFirstObservedAt(currentConfig map[string]interface{}, lastKnownGoodCount int) (time.Time, error) {
+	count, found, err := unstructured.NestedString(currentConfig, shrinkGateLastKnownGoodPath...)
+	if err != nil || !found {
+		return time.Time{}, err
+	}
+	// a pending shrink recorded for another set of storage URLs is stale
+	if count != strconv.Itoa(lastKnownGoodCount) {
+		return time.Time{}, nil
+	}
+	firstObservedAt, _, err := unstructured.NestedString(currentConfig, shrinkGateFirstObservedAtPath...)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := time.Parse(time.RFC3339, firstObservedAt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %v: %v", shrinkGateFirstObservedAtPath, err)
+	}
+	return t, nil
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/openshift/library-go/pkg/operator/configobserver"
 	"github.com/openshift/library-go/pkg/operator/events"
@@ -156,3 +157,86 @@ func fallback(observed map[string]interface{}, errs ...error) configobserver.Obs
 		return observed, errs
 	}
 }
+
+func TestObserveStorageURLsShrinkGate(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	lister := configobservation.Listers{
+		ConfigmapLister_: corev1listers.NewConfigMapLister(indexer),
+	}
+	setEndpoints := func(ips ...string) {
+		var configs []func(*v1.ConfigMap)
+		for _, ip := range ips {
+			configs = append(configs, withAddress(ip))
+		}
+		if err := indexer.Update(endpoints(configs...)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	threeMembers := observedConfig(withStorageURL("https://10.0.0.1:2379"), withStorageURL("https://10.0.0.2:2379"), withStorageURL("https://10.0.0.3:2379"), withLocalhostStorageURLs())
+	twoMembers := observedConfig(withStorageURL("https://10.0.0.1:2379"), withStorageURL("https://10.0.0.2:2379"), withLocalhostStorageURLs())
+	start := time.Date(2021, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	observe := func(currentConfig map[string]interface{}, now time.Time) (map[string]interface{}, events.InMemoryRecorder) {
+		t.Helper()
+		recorder := events.NewInMemoryRecorder("test")
+		actual, errs := observeStorageURLs(lister, recorder, currentConfig, now)
+		if len(errs) != 0 {
+			t.Fatalf("unexpected errors: %v", errs)
+		}
+		return actual, recorder
+	}
+	expectConfig := func(expected, actual map[string]interface{}) {
+		t.Helper()
+		if !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("unexpected config:\n%s", mergepatch.ToYAMLOrError(actual))
+		}
+	}
+	expectEvent := func(recorder events.InMemoryRecorder, reason string) {
+		t.Helper()
+		for _, ev := range recorder.Events() {
+			if ev.Reason == reason {
+				return
+			}
+		}
+		t.Fatalf("expected %s event, got %v", reason, recorder.Events())
+	}
+
+	// all members are observed
+	setEndpoints("10.0.0.1", "10.0.0.2", "10.0.0.3")
+	config, _ := observe(observedConfig(), start)
+	expectConfig(threeMembers, config)
+
+	// a member drops out of the endpoints, the larger set is held
+	setEndpoints("10.0.0.1", "10.0.0.2")
+	config, recorder := observe(config, start)
+	expectEvent(recorder, "ObserveStorageURLsShrinkHeld")
+	held := observedConfig(withStorageURL("https://10.0.0.1:2379"), withStorageURL("https://10.0.0.2:2379"), withStorageURL("https://10.0.0.3:2379"), withLocalhostStorageURLs(), withShrinkGate("4", start))
+	expectConfig(held, config)
+
+	// still within the stability window, the first observation time is kept
+	config, _ = observe(config, start.Add(storageURLsShrinkStabilityWindow-time.Second))
+	expectConfig(held, config)
+
+	// the member comes back, the pending shrink is dropped
+	setEndpoints("10.0.0.1", "10.0.0.2", "10.0.0.3")
+	config, _ = observe(config, start.Add(storageURLsShrinkStabilityWindow))
+	expectConfig(threeMembers, config)
+
+	// the member drops out again, the stability window starts over
+	setEndpoints("10.0.0.1", "10.0.0.2")
+	restart := start.Add(2 * storageURLsShrinkStabilityWindow)
+	config, _ = observe(config, restart)
+	expectConfig(observedConfig(withStorageURL("https://10.0.0.1:2379"), withStorageURL("https://10.0.0.2:2379"), withStorageURL("https://10.0.0.3:2379"), withLocalhostStorageURLs(), withShrinkGate("4", restart)), config)
+
+	// the smaller set is stable for the whole window and replaces the larger one
+	config, recorder = observe(config, restart.Add(storageURLsShrinkStabilityWindow))
+	expectEvent(recorder, "ObserveStorageURLsShrinkAccepted")
+	expectConfig(twoMembers, config)
+}
+
+func withShrinkGate(lastKnownGoodCount string, firstObservedAt time.Time) func(map[string]interface{}) {
+	return func(observedConfig map[string]interface{}) {
+		_ = unstructured.SetNestedField(observedConfig, lastKnownGoodCount, "etcdEndpointsShrinkGate", "lastKnownGoodCount")
+		_ = unstructured.SetNestedField(observedConfig, firstObservedAt.Format(time.RFC3339), "etcdEndpointsShrinkGate", "firstObservedAt")
+	}
+}
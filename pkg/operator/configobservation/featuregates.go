@@ -0,0 +1,169 @@
+package configobservation
+
+import (
+	"fmt"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+// ClusterFeatures returns the enabled and disabled features of the cluster feature gate.
+// Like for the feature-gates argument, a missing feature gate means the default feature set.
+func ClusterFeatures(featureGateLister configlistersv1.FeatureGateLister) (sets.String, sets.String, error) {
+	featureGate, err := clusterFeatureGate(featureGateLister.Get("cluster"))
+	if err != nil {
+		return nil, nil, err
+	}
+	return features(featureGate)
+}
+
+// clusterFeatureGate returns the result of a get of the cluster feature gate, a missing one means the default feature set.
+func clusterFeatureGate(featureGate *configv1.FeatureGate, err error) (*configv1.FeatureGate, error) {
+	if apierrors.IsNotFound(err) {
+		return &configv1.FeatureGate{Spec: configv1.FeatureGateSpec{FeatureGateSelection: configv1.FeatureGateSelection{FeatureSet: configv1.Default}}}, nil
+	}
+	return featureGate, err
+}
+
+func features(featureGate *configv1.FeatureGate) (sets.String, sets.String, error) {
+	if featureGate.Spec.FeatureSet == configv1.CustomNoUpgrade {
+		if featureGate.Spec.CustomNoUpgrade == nil {
+			return sets.NewString(), sets.NewString(), nil
+		}
+		return sets.NewString(featureGate.Spec.CustomNoUpgrade.Enabled...), sets.NewString(featureGate.Spec.CustomNoUpgrade.Disabled...), nil
+	}
+	featureSet, ok := configv1.FeatureSets[featureGate.Spec.FeatureSet]
+	if !ok {
+		return nil, nil, fmt.Errorf(".spec.featureSet %q not found", featureGate.Spec.FeatureSet)
+	}
+	return sets.NewString(featureSet.Enabled...), sets.NewString(featureSet.Disabled...), nil
+}
+
+// FeatureGates is the single accessor of the cluster feature gate for the config observers. The observers returned by
+// Observers read one snapshot of the feature gate per observe pass, outside of a pass the lister is read. It computes
+// the enabled and disabled features once per resourceVersion of the feature gate, and reports the resourceVersion
+// they were computed from so that decisions taken from different versions can be told apart.
+type FeatureGates struct {
+	lister configlistersv1.FeatureGateLister
+
+	lock            sync.Mutex
+	resourceVersion string
+	enabled         sets.String
+	disabled        sets.String
+
+	// pending is the number of observers still to run in the current observe pass, zero outside of one.
+	pending int
+	// snapshot and snapshotErr are what the lister returned for the cluster feature gate when the pass started.
+	snapshot    *configv1.FeatureGate
+	snapshotErr error
+}
+
+func NewFeatureGates(lister configlistersv1.FeatureGateLister) *FeatureGates {
+	return &FeatureGates{lister: lister}
+}
+
+// ClusterFeatures returns the enabled and disabled features of the cluster feature gate like ClusterFeatures, and the
+// resourceVersion of the feature gate they were computed from, empty when it does not exist. The returned sets are
+// copies.
+func (f *FeatureGates) ClusterFeatures() (sets.String, sets.String, string, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	featureGate, err := clusterFeatureGate(f.get("cluster"))
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if f.enabled == nil || f.resourceVersion != featureGate.ResourceVersion {
+		enabled, disabled, err := features(featureGate)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		f.resourceVersion, f.enabled, f.disabled = featureGate.ResourceVersion, enabled, disabled
+	}
+	return sets.NewString(f.enabled.UnsortedList()...), sets.NewString(f.disabled.UnsortedList()...), f.resourceVersion, nil
+}
+
+// Observers returns the observers reading the cluster feature gate from one snapshot per observe pass. The first of
+// them to run in a pass takes the snapshot, the others of the pass read the same feature gate through ClusterFeatures
+// and the Lister, and the last one drops it. The observers of a pass run one after another, as in the ConfigObserver.
+func (f *FeatureGates) Observers(observers ...configobserver.ObserveConfigFunc) []configobserver.ObserveConfigFunc {
+	wrapped := make([]configobserver.ObserveConfigFunc, 0, len(observers))
+	for _, observer := range observers {
+		observer := observer
+		wrapped = append(wrapped, func(listers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+			f.startObserver(len(observers))
+			defer f.finishObserver()
+			return observer(listers, recorder, existingConfig)
+		})
+	}
+	return wrapped
+}
+
+func (f *FeatureGates) startObserver(passObservers int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.pending == 0 {
+		f.pending = passObservers
+		f.snapshot, f.snapshotErr = f.lister.Get("cluster")
+	}
+}
+
+func (f *FeatureGates) finishObserver() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.pending--
+	if f.pending == 0 {
+		f.snapshot, f.snapshotErr = nil, nil
+	}
+}
+
+// Lister returns the feature gate lister of the config observers, it returns the snapshot of the current observe pass
+// for the cluster feature gate.
+func (f *FeatureGates) Lister() configlistersv1.FeatureGateLister {
+	return &featureGateSnapshotLister{featureGates: f}
+}
+
+// get returns the named feature gate, the snapshot of the current observe pass for the cluster one. The lock is held.
+func (f *FeatureGates) get(name string) (*configv1.FeatureGate, error) {
+	if f.pending == 0 || name != "cluster" {
+		return f.lister.Get(name)
+	}
+	return f.snapshot, f.snapshotErr
+}
+
+type featureGateSnapshotLister struct {
+	featureGates *FeatureGates
+}
+
+func (l *featureGateSnapshotLister) List(selector labels.Selector) ([]*configv1.FeatureGate, error) {
+	f := l.featureGates
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	featureGates, err := f.lister.List(selector)
+	if err != nil || f.pending == 0 {
+		return featureGates, err
+	}
+	var ret []*configv1.FeatureGate
+	for _, featureGate := range featureGates {
+		if featureGate.Name != "cluster" {
+			ret = append(ret, featureGate)
+		}
+	}
+	if f.snapshot != nil && selector.Matches(labels.Set(f.snapshot.Labels)) {
+		ret = append(ret, f.snapshot)
+	}
+	return ret, nil
+}
+
+func (l *featureGateSnapshotLister) Get(name string) (*configv1.FeatureGate, error) {
+	l.featureGates.lock.Lock()
+	defer l.featureGates.lock.Unlock()
+	return l.featureGates.get(name)
+}
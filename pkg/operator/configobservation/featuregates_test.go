@@ -0,0 +1,74 @@
+package configobservation
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestFeatureGatesObserversReadOneSnapshotPerPass(t *testing.T) {
+	featureGate := func(resourceVersion string, featureSet configv1.FeatureSet) *configv1.FeatureGate {
+		return &configv1.FeatureGate{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster", ResourceVersion: resourceVersion},
+			Spec:       configv1.FeatureGateSpec{FeatureGateSelection: configv1.FeatureGateSelection{FeatureSet: featureSet}},
+		}
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(featureGate("1", configv1.Default)); err != nil {
+		t.Fatal(err)
+	}
+	featureGates := NewFeatureGates(configlistersv1.NewFeatureGateLister(indexer))
+	listers := Listers{FeatureGates_: featureGates}
+
+	// the feature gate changes while the first observer of the pass runs
+	var seen []string
+	observe := func(update bool) configobserver.ObserveConfigFunc {
+		return func(genericListers configobserver.Listers, _ events.Recorder, _ map[string]interface{}) (map[string]interface{}, []error) {
+			if update {
+				if err := indexer.Update(featureGate("2", configv1.TechPreviewNoUpgrade)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			_, _, resourceVersion, err := genericListers.(Listers).FeatureGates().ClusterFeatures()
+			if err != nil {
+				t.Fatal(err)
+			}
+			fromLister, err := genericListers.(Listers).FeatureGateLister().Get("cluster")
+			if err != nil {
+				t.Fatal(err)
+			}
+			seen = append(seen, resourceVersion+"/"+fromLister.ResourceVersion)
+			return map[string]interface{}{}, nil
+		}
+	}
+	observers := featureGates.Observers(observe(true), observe(false), observe(false))
+	for _, observer := range observers {
+		observer(listers, events.NewInMemoryRecorder(t.Name()), map[string]interface{}{})
+	}
+	for _, s := range seen {
+		if s != "1/1" {
+			t.Fatalf("expected every observer of the pass to read the snapshot of resourceVersion 1, got %v", seen)
+		}
+	}
+
+	// the next pass, and any read outside of a pass, sees the change
+	if _, _, resourceVersion, err := featureGates.ClusterFeatures(); err != nil || resourceVersion != "2" {
+		t.Errorf("expected resourceVersion 2 outside of a pass, got %q, %v", resourceVersion, err)
+	}
+	seen = nil
+	for _, observer := range observers[1:] {
+		observer(listers, events.NewInMemoryRecorder(t.Name()), map[string]interface{}{})
+	}
+	observers[0](listers, events.NewInMemoryRecorder(t.Name()), map[string]interface{}{})
+	for _, s := range seen {
+		if s != "2/2" {
+			t.Fatalf("expected every observer of the next pass to read resourceVersion 2, got %v", seen)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	"github.com/openshift/library-go/pkg/operator/configobserver"
 	"github.com/openshift/library-go/pkg/operator/events"
@@ -90,8 +91,7 @@ func ObserveExternalRegistryHostnames(genericListers configobserver.Listers, rec
 
 	// User provided values take precedence, first entry in the array
 	// has special significance.
-	externalRegistryHostnames := configImage.Spec.ExternalRegistryHostnames
-	externalRegistryHostnames = append(externalRegistryHostnames, configImage.Status.ExternalRegistryHostnames...)
+	externalRegistryHostnames := uniqueHostnames(configImage.Spec.ExternalRegistryHostnames, configImage.Status.ExternalRegistryHostnames)
 
 	if len(externalRegistryHostnames) > 0 {
 		if err = unstructured.SetNestedStringSlice(observedConfig, externalRegistryHostnames, externalRegistryHostnamePath...); err != nil {
@@ -106,6 +106,23 @@ func ObserveExternalRegistryHostnames(genericListers configobserver.Listers, rec
 	return observedConfig, errs
 }
 
+// uniqueHostnames concatenates the hostnames lists dropping the empty and duplicate entries. The first occurrence
+// of a hostname is kept so that the order, and so the observed config, stays stable.
+func uniqueHostnames(hostnameLists ...[]string) []string {
+	var hostnames []string
+	seen := sets.NewString()
+	for _, list := range hostnameLists {
+		for _, hostname := range list {
+			if len(hostname) == 0 || seen.Has(hostname) {
+				continue
+			}
+			seen.Insert(hostname)
+			hostnames = append(hostnames, hostname)
+		}
+	}
+	return hostnames
+}
+
 // ObserveAllowedRegistriesForImport maps the user provided list of allowed registries for importing images to the kube api server
 // configuration.
 func ObserveAllowedRegistriesForImport(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
@@ -105,6 +105,21 @@ func TestObserveImageConfig(t *testing.T) {
 			expectedExternalRegistryHostnames: []string{"spec.external.host.com", "status.external.host.com"},
 			expectedEventReasons:              []string{"ObserveExternalRegistryHostnameChanged"},
 		},
+		{
+			imageConfig: &configv1.Image{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "cluster",
+				},
+				Spec: configv1.ImageSpec{
+					ExternalRegistryHostnames: []string{"spec.external.host.com", "shared.external.host.com", "spec.external.host.com"},
+				},
+				Status: configv1.ImageStatus{
+					ExternalRegistryHostnames: []string{"status.external.host.com", "", "shared.external.host.com"},
+				},
+			},
+			expectedExternalRegistryHostnames: []string{"spec.external.host.com", "shared.external.host.com", "status.external.host.com"},
+			expectedEventReasons:              []string{"ObserveExternalRegistryHostnameChanged"},
+		},
 		{
 			imageConfig: &configv1.Image{
 				ObjectMeta: metav1.ObjectMeta{
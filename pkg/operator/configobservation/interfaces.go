@@ -5,18 +5,21 @@ import (
 	"k8s.io/client-go/tools/cache"
 
 	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
-	"github.com/openshift/library-go/pkg/operator/configobserver/cloudprovider"
 	libgoetcd "github.com/openshift/library-go/pkg/operator/configobserver/etcd"
 	"github.com/openshift/library-go/pkg/operator/resourcesynccontroller"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation/cloudprovider"
 )
 
 var _ cloudprovider.InfrastructureLister = Listers{}
 var _ libgoetcd.ConfigMapLister = Listers{}
 
 type Listers struct {
-	APIServerLister_      configlistersv1.APIServerLister
-	AuthConfigLister      configlistersv1.AuthenticationLister
-	FeatureGateLister_    configlistersv1.FeatureGateLister
+	APIServerLister_   configlistersv1.APIServerLister
+	AuthConfigLister   configlistersv1.AuthenticationLister
+	FeatureGateLister_ configlistersv1.FeatureGateLister
+	// FeatureGates_ is the cached accessor of the cluster feature gate, built from the FeatureGateLister_ if unset.
+	FeatureGates_         *FeatureGates
 	InfrastructureLister_ configlistersv1.InfrastructureLister
 	ImageConfigLister     configlistersv1.ImageLister
 	NetworkLister         configlistersv1.NetworkLister
@@ -35,10 +38,21 @@ func (l Listers) APIServerLister() configlistersv1.APIServerLister {
 	return l.APIServerLister_
 }
 
+// FeatureGateLister returns the lister of FeatureGates_, which reads the snapshot of the current observe pass, if set.
 func (l Listers) FeatureGateLister() configlistersv1.FeatureGateLister {
+	if l.FeatureGates_ != nil {
+		return l.FeatureGates_.Lister()
+	}
 	return l.FeatureGateLister_
 }
 
+func (l Listers) FeatureGates() *FeatureGates {
+	if l.FeatureGates_ == nil {
+		return NewFeatureGates(l.FeatureGateLister_)
+	}
+	return l.FeatureGates_
+}
+
 func (l Listers) InfrastructureLister() configlistersv1.InfrastructureLister {
 	return l.InfrastructureLister_
 }
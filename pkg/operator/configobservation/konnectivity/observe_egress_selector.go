@@ -0,0 +1,98 @@
+package konnectivity
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resourcesynccontroller"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+const (
+	// FeatureGate enables routing the kube-apiserver traffic to the cluster network through konnectivity.
+	FeatureGate = "KonnectivityEgressSelector"
+
+	// ClientSecretName is the secret in openshift-config holding the mTLS client certificate (tls.crt, tls.key)
+	// of the kube-apiserver and the CA bundle (ca.crt) of the konnectivity server. It is synced under the
+	// same name into the target namespace.
+	ClientSecretName = "konnectivity-client"
+
+	// EgressSelectorConfigMapName is the configmap in the target namespace holding the egress selector config.
+	// It is rendered by the target config controller while the egress selector config file is observed.
+	EgressSelectorConfigMapName = "egress-selector-config"
+)
+
+var (
+	EgressSelectorConfigFilePath = []string{"apiServerArguments", "egress-selector-config-file"}
+	egressSelectorConfigFile     = []interface{}{"/etc/kubernetes/static-pod-resources/configmaps/egress-selector-config/config.yaml"}
+
+	clientSecretKeys = []string{"tls.crt", "tls.key", "ca.crt"}
+)
+
+// ObserveEgressSelector configures the kube-apiserver to reach the cluster network through konnectivity while the
+// KonnectivityEgressSelector feature gate is enabled. It syncs the konnectivity client secret into the target namespace,
+// and removes both the egress-selector-config-file argument and the synced secret when the feature gate is disabled.
+func ObserveEgressSelector(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	defer func() {
+		ret = configobserver.Pruned(ret, EgressSelectorConfigFilePath)
+	}()
+
+	listers := genericListers.(configobservation.Listers)
+	resourceSyncer := genericListers.ResourceSyncer()
+
+	existingEgressSelector, _, err := unstructured.NestedSlice(existingConfig, EgressSelectorConfigFilePath...)
+	if err != nil {
+		// keep going on read error from existing config
+		errs = append(errs, err)
+	}
+	existingEnabled := len(existingEgressSelector) > 0
+
+	enabledFeatures, _, _, err := listers.FeatureGates().ClusterFeatures()
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+	observedEnabled := enabledFeatures.Has(FeatureGate)
+
+	observedConfig := map[string]interface{}{}
+	if observedEnabled {
+		// don't point the kube-apiserver at konnectivity without the credentials to reach it
+		clientSecret, err := listers.ConfigSecretLister().Secrets(operatorclient.GlobalUserSpecifiedConfigNamespace).Get(ClientSecretName)
+		if err != nil {
+			return existingConfig, append(errs, fmt.Errorf("failed to get secret %s/%s: %w", operatorclient.GlobalUserSpecifiedConfigNamespace, ClientSecretName, err))
+		}
+		for _, key := range clientSecretKeys {
+			if len(clientSecret.Data[key]) == 0 {
+				return existingConfig, append(errs, fmt.Errorf("secret %s/%s is invalid: missing required %q key", operatorclient.GlobalUserSpecifiedConfigNamespace, ClientSecretName, key))
+			}
+		}
+
+		if err := unstructured.SetNestedField(observedConfig, egressSelectorConfigFile, EgressSelectorConfigFilePath...); err != nil {
+			return existingConfig, append(errs, err)
+		}
+		if err := resourceSyncer.SyncSecret(
+			resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: ClientSecretName},
+			resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalUserSpecifiedConfigNamespace, Name: ClientSecretName},
+		); err != nil {
+			return existingConfig, append(errs, err)
+		}
+	} else {
+		// don't sync anything and remove whatever we synced
+		if err := resourceSyncer.SyncSecret(
+			resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: ClientSecretName},
+			resourcesynccontroller.ResourceLocation{Namespace: "", Name: ""},
+		); err != nil {
+			return existingConfig, append(errs, err)
+		}
+	}
+
+	if observedEnabled != existingEnabled {
+		recorder.Eventf("ObserveEgressSelector", "konnectivity egress selector changed from %v to %v", existingEnabled, observedEnabled)
+	}
+
+	return observedConfig, errs
+}
@@ -0,0 +1,159 @@
+package konnectivity
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelistersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resourcesynccontroller"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+)
+
+func TestObserveEgressSelector(t *testing.T) {
+	enabledConfig := map[string]interface{}{"apiServerArguments": map[string]interface{}{
+		"egress-selector-config-file": []interface{}{"/etc/kubernetes/static-pod-resources/configmaps/egress-selector-config/config.yaml"},
+	}}
+	validClientSecret := map[string][]byte{"tls.crt": []byte("cert"), "tls.key": []byte("key"), "ca.crt": []byte("ca")}
+
+	scenarios := []struct {
+		name           string
+		featureEnabled bool
+		clientSecret   map[string][]byte
+		existingConfig map[string]interface{}
+		expectedConfig map[string]interface{}
+		expectedSynced map[string]string
+		expectErrs     bool
+		expectEvents   bool
+	}{
+		{
+			name:           "disabled",
+			expectedConfig: map[string]interface{}{},
+			expectedSynced: map[string]string{"secret/konnectivity-client.openshift-kube-apiserver": "DELETE"},
+		},
+		{
+			name:           "enabled",
+			featureEnabled: true,
+			clientSecret:   validClientSecret,
+			existingConfig: enabledConfig,
+			expectedConfig: enabledConfig,
+			expectedSynced: map[string]string{"secret/konnectivity-client.openshift-kube-apiserver": "secret/konnectivity-client.openshift-config"},
+		},
+		{
+			name:           "enabling",
+			featureEnabled: true,
+			clientSecret:   validClientSecret,
+			expectedConfig: enabledConfig,
+			expectedSynced: map[string]string{"secret/konnectivity-client.openshift-kube-apiserver": "secret/konnectivity-client.openshift-config"},
+			expectEvents:   true,
+		},
+		{
+			name:           "disabling",
+			clientSecret:   validClientSecret,
+			existingConfig: enabledConfig,
+			expectedConfig: map[string]interface{}{},
+			expectedSynced: map[string]string{"secret/konnectivity-client.openshift-kube-apiserver": "DELETE"},
+			expectEvents:   true,
+		},
+		{
+			name:           "enabled without the client secret",
+			featureEnabled: true,
+			expectedConfig: map[string]interface{}{},
+			expectedSynced: map[string]string{},
+			expectErrs:     true,
+		},
+		{
+			name:           "enabled with an incomplete client secret keeps the existing config",
+			featureEnabled: true,
+			clientSecret:   map[string][]byte{"tls.crt": []byte("cert"), "tls.key": []byte("key")},
+			existingConfig: enabledConfig,
+			expectedConfig: enabledConfig,
+			expectedSynced: map[string]string{},
+			expectErrs:     true,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			featureGate := &configv1.FeatureGate{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Spec: configv1.FeatureGateSpec{FeatureGateSelection: configv1.FeatureGateSelection{
+					FeatureSet:      configv1.CustomNoUpgrade,
+					CustomNoUpgrade: &configv1.CustomFeatureGates{},
+				}},
+			}
+			if scenario.featureEnabled {
+				featureGate.Spec.CustomNoUpgrade.Enabled = []string{FeatureGate}
+			}
+			featureGateIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := featureGateIndexer.Add(featureGate); err != nil {
+				t.Fatal(err)
+			}
+			secretIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if scenario.clientSecret != nil {
+				if err := secretIndexer.Add(&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-config", Name: ClientSecretName},
+					Data:       scenario.clientSecret,
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			synced := map[string]string{}
+			listers := configobservation.Listers{
+				FeatureGateLister_:  configlistersv1.NewFeatureGateLister(featureGateIndexer),
+				ConfigSecretLister_: corelistersv1.NewSecretLister(secretIndexer),
+				ResourceSync:        &mockResourceSyncer{t: t, synced: synced},
+			}
+			eventRecorder := events.NewInMemoryRecorder("")
+			existingConfig := scenario.existingConfig
+			if existingConfig == nil {
+				existingConfig = map[string]interface{}{}
+			}
+
+			observedConfig, errs := ObserveEgressSelector(listers, eventRecorder, existingConfig)
+			if gotErrs := len(errs) > 0; gotErrs != scenario.expectErrs {
+				t.Fatalf("expected errors %v, got %v", scenario.expectErrs, errs)
+			}
+			if !cmp.Equal(scenario.expectedConfig, observedConfig) {
+				t.Errorf("unexpected configuration, diff = %v", cmp.Diff(scenario.expectedConfig, observedConfig))
+			}
+			if !cmp.Equal(scenario.expectedSynced, synced) {
+				t.Errorf("unexpected synced resources, diff = %v", cmp.Diff(scenario.expectedSynced, synced))
+			}
+			if gotEvents := len(eventRecorder.Events()) > 0; gotEvents != scenario.expectEvents {
+				t.Errorf("expected events %v, got %v", scenario.expectEvents, eventRecorder.Events())
+			}
+		})
+	}
+}
+
+type mockResourceSyncer struct {
+	t      *testing.T
+	synced map[string]string
+}
+
+func (rs *mockResourceSyncer) SyncConfigMap(destination, source resourcesynccontroller.ResourceLocation) error {
+	if (source == resourcesynccontroller.ResourceLocation{}) {
+		rs.synced[fmt.Sprintf("configmap/%v.%v", destination.Name, destination.Namespace)] = "DELETE"
+	} else {
+		rs.synced[fmt.Sprintf("configmap/%v.%v", destination.Name, destination.Namespace)] = fmt.Sprintf("configmap/%v.%v", source.Name, source.Namespace)
+	}
+	return nil
+}
+
+func (rs *mockResourceSyncer) SyncSecret(destination, source resourcesynccontroller.ResourceLocation) error {
+	if (source == resourcesynccontroller.ResourceLocation{}) {
+		rs.synced[fmt.Sprintf("secret/%v.%v", destination.Name, destination.Namespace)] = "DELETE"
+	} else {
+		rs.synced[fmt.Sprintf("secret/%v.%v", destination.Name, destination.Namespace)] = fmt.Sprintf("secret/%v.%v", source.Name, source.Namespace)
+	}
+	return nil
+}
@@ -1,16 +1,21 @@
 package network
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	utilnet "k8s.io/utils/net"
 
+	configv1 "github.com/openshift/api/config/v1"
 	"github.com/openshift/library-go/pkg/operator/configobserver"
 	"github.com/openshift/library-go/pkg/operator/configobserver/network"
 	"github.com/openshift/library-go/pkg/operator/events"
 
 	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation/apiserver"
 )
 
 // ObserveRestrictedCIDRs watches the network configuration and updates the
@@ -186,7 +191,9 @@ func ObserveExternalIPPolicy(genericListers configobserver.Listers, recorder eve
 }
 
 // ObserveServiceNodePortRange watches the network configuration and generates the
-// serviceNodePortRange
+// serviceNodePortRange. A range which is not "low-high", is inverted or is out of
+// [minServiceNodePort, 65535] is rejected with a warning and the previously observed
+// range, if any, is kept. The kube-apiserver default applies while none is set.
 func ObserveServicesNodePortRange(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
 	listers := genericListers.(configobservation.Listers)
 
@@ -195,7 +202,11 @@ func ObserveServicesNodePortRange(genericListers configobserver.Listers, recorde
 
 	previouslyObservedConfig, errs := extractPreviouslyObservedConfig(existingConfig, servicesNodePortRangeConfigPath)
 
-	serviceNodePortRange, err := network.GetServiceNodePortRange(listers.NetworkLister, recorder)
+	networkConfig, err := listers.NetworkLister.Get("cluster")
+	if apierrors.IsNotFound(err) {
+		recorder.Warningf("ObserveServicesNodePortRange", "Required networks.%s/cluster not found", configv1.GroupName)
+		return map[string]interface{}{}, errs
+	}
 	if err != nil {
 		errs = append(errs, err)
 		return previouslyObservedConfig, errs
@@ -209,10 +220,15 @@ func ObserveServicesNodePortRange(genericListers configobserver.Listers, recorde
 	// The third change should fail customresourcevalidation. So once the field
 	// is set in the yaml, it should never come here for anything other than the
 	// default case of never being set.
-
+	serviceNodePortRange := networkConfig.Spec.ServiceNodePortRange
 	if serviceNodePortRange == "" {
 		return map[string]interface{}{}, errs
 	}
+	if err := validateServiceNodePortRange(serviceNodePortRange); err != nil {
+		recorder.Warningf("ObserveServicesNodePortRange", "Rejecting invalid serviceNodePortRange %q of networks.%s/cluster: %v", serviceNodePortRange, configv1.GroupName, err)
+		return previouslyObservedConfig, errs
+	}
+
 	snpr := []string{serviceNodePortRange}
 	if err := unstructured.SetNestedStringSlice(out, snpr, servicesNodePortRangeConfigPath...); err != nil {
 		errs = append(errs, err)
@@ -221,6 +237,88 @@ func ObserveServicesNodePortRange(genericListers configobserver.Listers, recorde
 	return out, errs
 }
 
+// minServiceNodePort keeps the node ports out of the range of the privileged ports, which the host services of the
+// nodes listen on.
+const minServiceNodePort = 1024
+
+// validateServiceNodePortRange accepts an inclusive "low-high" range of non-privileged ports.
+func validateServiceNodePortRange(serviceNodePortRange string) error {
+	parts := strings.Split(serviceNodePortRange, "-")
+	if len(parts) != 2 {
+		return fmt.Errorf("must be low-high")
+	}
+	low, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return fmt.Errorf("invalid low port %q", parts[0])
+	}
+	high, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return fmt.Errorf("invalid high port %q", parts[1])
+	}
+	if high < low {
+		return fmt.Errorf("the range is inverted, %d is lower than %d", high, low)
+	}
+	if low < minServiceNodePort || high > 65535 {
+		return fmt.Errorf("the ports must be between %d and 65535", minServiceNodePort)
+	}
+	return nil
+}
+
+const (
+	// EnableAggregatorRoutingAnnotation on networks.config.openshift.io/cluster sets enable-aggregator-routing to
+	// "true", routing the aggregated API requests to the endpoint IPs of their service, or to "false", routing them to
+	// the service cluster IP for network topologies where the pod IPs are not reachable from the kube-apiserver.
+	// Without it the default config value, "true", applies.
+	EnableAggregatorRoutingAnnotation = "kubeapiserver.operator.openshift.io/enable-aggregator-routing"
+
+	// defaultEnableAggregatorRouting is the value of the default config.
+	defaultEnableAggregatorRouting = "true"
+)
+
+// ObserveEnableAggregatorRouting watches the network configuration and sets enable-aggregator-routing from its
+// EnableAggregatorRoutingAnnotation. An invalid value is rejected with a warning and the previously observed value,
+// if any, is kept. An event is emitted when the effective value changes.
+func ObserveEnableAggregatorRouting(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+	listers := genericListers.(configobservation.Listers)
+	enableAggregatorRoutingConfigPath := []string{"apiServerArguments", "enable-aggregator-routing"}
+
+	previouslyObservedConfig, errs := extractPreviouslyObservedConfig(existingConfig, enableAggregatorRoutingConfigPath)
+	previous := defaultEnableAggregatorRouting
+	if values, _, _ := unstructured.NestedStringSlice(previouslyObservedConfig, enableAggregatorRoutingConfigPath...); len(values) > 0 {
+		previous = values[0]
+	}
+
+	networkConfig, err := listers.NetworkLister.Get("cluster")
+	if apierrors.IsNotFound(err) {
+		recorder.Warningf("ObserveEnableAggregatorRouting", "Required networks.%s/cluster not found", configv1.GroupName)
+		return map[string]interface{}{}, errs
+	}
+	if err != nil {
+		return previouslyObservedConfig, append(errs, err)
+	}
+
+	out := map[string]interface{}{}
+	observed := defaultEnableAggregatorRouting
+	if value, ok := networkConfig.Annotations[EnableAggregatorRoutingAnnotation]; ok {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			if err := apiserver.KeepPreviousValue(recorder, "ObserveEnableAggregatorRouting", EnableAggregatorRoutingAnnotation, value, fmt.Errorf("it must be true or false"), existingConfig, out, enableAggregatorRoutingConfigPath); err != nil {
+				errs = append(errs, err)
+			}
+			return out, errs
+		}
+		observed = strconv.FormatBool(enabled)
+		if err := unstructured.SetNestedStringSlice(out, []string{observed}, enableAggregatorRoutingConfigPath...); err != nil {
+			return previouslyObservedConfig, append(errs, err)
+		}
+	}
+
+	if observed != previous {
+		recorder.Eventf("ObserveEnableAggregatorRouting", "enable-aggregator-routing changed from %s to %s", previous, observed)
+	}
+	return out, errs
+}
+
 // extractPreviouslyObservedConfig extracts the previously observed config from the existing config.
 func extractPreviouslyObservedConfig(existing map[string]interface{}, paths ...[]string) (map[string]interface{}, []error) {
 	var errs []error
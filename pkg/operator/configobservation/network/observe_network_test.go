@@ -382,6 +382,91 @@ func TestObserveServiceNodePortRange(t *testing.T) {
 	}
 }
 
+func TestObserveServiceNodePortRangeValidation(t *testing.T) {
+	nodePortRange := func(value string) map[string]interface{} {
+		return map[string]interface{}{"apiServerArguments": map[string]interface{}{"service-node-port-range": []interface{}{value}}}
+	}
+
+	for _, tc := range []struct {
+		name            string
+		nodePortRange   string
+		existingConfig  map[string]interface{}
+		expectedConfig  map[string]interface{}
+		expectedWarning bool
+	}{
+		{
+			name:           "default",
+			existingConfig: nodePortRange("30000-33000"),
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name:           "valid range",
+			nodePortRange:  "30000-40000",
+			existingConfig: nodePortRange("30000-33000"),
+			expectedConfig: nodePortRange("30000-40000"),
+		},
+		{
+			name:            "inverted range keeps the previous range",
+			nodePortRange:   "40000-30000",
+			existingConfig:  nodePortRange("30000-33000"),
+			expectedConfig:  nodePortRange("30000-33000"),
+			expectedWarning: true,
+		},
+		{
+			name:            "inverted range without previous range",
+			nodePortRange:   "40000-30000",
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+		{
+			name:            "not low-high",
+			nodePortRange:   "30000+10000",
+			existingConfig:  nodePortRange("30000-33000"),
+			expectedConfig:  nodePortRange("30000-33000"),
+			expectedWarning: true,
+		},
+		{
+			name:            "privileged ports",
+			nodePortRange:   "80-32767",
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+		{
+			name:            "out of the port range",
+			nodePortRange:   "30000-70000",
+			expectedConfig:  map[string]interface{}{},
+			expectedWarning: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := indexer.Add(&configv1.Network{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Spec:       configv1.NetworkSpec{ServiceNodePortRange: tc.nodePortRange},
+			}); err != nil {
+				t.Fatal(err)
+			}
+			listers := configobservation.Listers{
+				NetworkLister: configlistersv1.NewNetworkLister(indexer),
+			}
+			existingConfig := tc.existingConfig
+			if existingConfig == nil {
+				existingConfig = map[string]interface{}{}
+			}
+			recorder := events.NewInMemoryRecorder("network")
+
+			result, errs := ObserveServicesNodePortRange(listers, recorder, existingConfig)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			assert.Equal(t, tc.expectedConfig, result)
+			if warned := len(recorder.Events()) > 0; warned != tc.expectedWarning {
+				t.Errorf("expected warning %v, got events %v", tc.expectedWarning, recorder.Events())
+			}
+		})
+	}
+}
+
 func shouldMatchYaml(t *testing.T, obj map[string]interface{}, expected string) {
 	t.Helper()
 	exp := map[string]interface{}{}
@@ -391,3 +476,83 @@ func shouldMatchYaml(t *testing.T, obj map[string]interface{}, expected string)
 	}
 	assert.Equal(t, exp, obj)
 }
+
+func TestObserveEnableAggregatorRouting(t *testing.T) {
+	aggregatorRouting := func(value string) map[string]interface{} {
+		return map[string]interface{}{"apiServerArguments": map[string]interface{}{"enable-aggregator-routing": []interface{}{value}}}
+	}
+
+	for _, tc := range []struct {
+		name           string
+		annotations    map[string]string
+		existingConfig map[string]interface{}
+		expectedConfig map[string]interface{}
+		expectedEvent  string
+	}{
+		{
+			name:           "default",
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name:           "enabled",
+			annotations:    map[string]string{EnableAggregatorRoutingAnnotation: "true"},
+			expectedConfig: aggregatorRouting("true"),
+		},
+		{
+			name:           "disabled",
+			annotations:    map[string]string{EnableAggregatorRoutingAnnotation: "false"},
+			expectedConfig: aggregatorRouting("false"),
+			expectedEvent:  "changed from true to false",
+		},
+		{
+			name:           "disabled already",
+			annotations:    map[string]string{EnableAggregatorRoutingAnnotation: "False"},
+			existingConfig: aggregatorRouting("false"),
+			expectedConfig: aggregatorRouting("false"),
+		},
+		{
+			name:           "back to the default",
+			existingConfig: aggregatorRouting("false"),
+			expectedConfig: map[string]interface{}{},
+			expectedEvent:  "changed from false to true",
+		},
+		{
+			name:           "invalid value keeps the previous value",
+			annotations:    map[string]string{EnableAggregatorRoutingAnnotation: "off"},
+			existingConfig: aggregatorRouting("false"),
+			expectedConfig: aggregatorRouting("false"),
+			expectedEvent:  "Rejecting invalid",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := indexer.Add(&configv1.Network{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster", Annotations: tc.annotations},
+			}); err != nil {
+				t.Fatal(err)
+			}
+			listers := configobservation.Listers{
+				NetworkLister: configlistersv1.NewNetworkLister(indexer),
+			}
+			existingConfig := tc.existingConfig
+			if existingConfig == nil {
+				existingConfig = map[string]interface{}{}
+			}
+			recorder := events.NewInMemoryRecorder("network")
+
+			result, errs := ObserveEnableAggregatorRouting(listers, recorder, existingConfig)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			assert.Equal(t, tc.expectedConfig, result)
+			recorded := recorder.Events()
+			if len(tc.expectedEvent) == 0 {
+				assert.Empty(t, recorded)
+				return
+			}
+			if assert.Len(t, recorded, 1) {
+				assert.Contains(t, recorded[0].Message, tc.expectedEvent)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package connectivitycheckcontroller
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/util/cert"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+const (
+	EtcdClientCertificateMismatchConditionType = "EtcdClientCertificateMismatch"
+
+	EtcdClientCertificateNotValidReason   = "CertificateNotValid"
+	EtcdClientCertificateNotTrustedReason = "CertificateNotTrusted"
+)
+
+// etcdClientCertCheckController cross-checks the etcd client certificate synced for the kube-apiserver against the
+// etcd CA synced with it. The certificate has to be within its validity period and chain up to the etcd CA for
+// client authentication: a certificate signed by a rotated out signer, or not usable as a client certificate, is
+// rejected by every etcd member, the etcd operator reissues it. The outcome is reported in the informational
+// EtcdClientCertificateMismatch condition, it does not block anything.
+type etcdClientCertCheckController struct {
+	operatorClient  v1helpers.StaticPodOperatorClient
+	secretLister    corev1listers.SecretLister
+	configMapLister corev1listers.ConfigMapLister
+	now             func() time.Time
+}
+
+func NewEtcdClientCertCheckController(
+	operatorClient v1helpers.StaticPodOperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	recorder events.Recorder,
+) factory.Controller {
+	targetInformers := kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace)
+	c := &etcdClientCertCheckController{
+		operatorClient:  operatorClient,
+		secretLister:    targetInformers.Core().V1().Secrets().Lister(),
+		configMapLister: targetInformers.Core().V1().ConfigMaps().Lister(),
+		now:             time.Now,
+	}
+
+	// the certificate expires without any object changing, resync to notice it
+	return factory.New().
+		WithInformers(operatorClient.Informer(), targetInformers.Core().V1().Secrets().Informer(), targetInformers.Core().V1().ConfigMaps().Informer()).
+		WithSync(c.sync).
+		ResyncEvery(time.Minute).
+		ToController("EtcdClientCertCheckController", recorder.WithComponentSuffix("etcd-client-cert-check-controller"))
+}
+
+func (c *etcdClientCertCheckController) sync(_ context.Context, _ factory.SyncContext) error {
+	operatorSpec, _, _, err := c.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+
+	condition := operatorv1.OperatorCondition{
+		Type:   EtcdClientCertificateMismatchConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: AsExpectedReason,
+	}
+	certs, certErr := c.etcdClientCertificates()
+	roots, caErr := c.etcdCA()
+	switch {
+	case certErr != nil:
+		condition.Status = operatorv1.ConditionUnknown
+		condition.Reason = NoEtcdClientCredentialsReason
+		condition.Message = certErr.Error()
+	case caErr != nil:
+		condition.Status = operatorv1.ConditionUnknown
+		condition.Reason = NoEtcdClientCredentialsReason
+		condition.Message = caErr.Error()
+	default:
+		now := c.now()
+		if err := certificateValidAt(certs[0], now); err != nil {
+			condition.Status = operatorv1.ConditionTrue
+			condition.Reason = EtcdClientCertificateNotValidReason
+			condition.Message = fmt.Sprintf("The etcd client certificate of the %s/%s secret %v", operatorclient.TargetNamespace, etcdClientSecretName, err)
+			break
+		}
+		if err := verifyClientCertificate(certs, roots, now); err != nil {
+			condition.Status = operatorv1.ConditionTrue
+			condition.Reason = EtcdClientCertificateNotTrustedReason
+			condition.Message = fmt.Sprintf("The etcd client certificate of the %s/%s secret is not trusted by the etcd CA of the %s/%s configmap, it has to be reissued: %v",
+				operatorclient.TargetNamespace, etcdClientSecretName, operatorclient.TargetNamespace, etcdServingCAConfigMap, err)
+		}
+	}
+
+	_, _, err = v1helpers.UpdateStaticPodStatus(c.operatorClient, v1helpers.UpdateStaticPodConditionFn(condition))
+	return err
+}
+
+// etcdClientCertificates returns the synced etcd client certificate, the leaf first.
+func (c *etcdClientCertCheckController) etcdClientCertificates() ([]*x509.Certificate, error) {
+	secret, err := c.secretLister.Secrets(operatorclient.TargetNamespace).Get(etcdClientSecretName)
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("the %s/%s secret is not synced yet", operatorclient.TargetNamespace, etcdClientSecretName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	certs, err := cert.ParseCertsPEM(secret.Data["tls.crt"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid etcd client certificate in the %s/%s secret: %v", operatorclient.TargetNamespace, etcdClientSecretName, err)
+	}
+	return certs, nil
+}
+
+// etcdCA returns the CA bundle the etcd members are synced with.
+func (c *etcdClientCertCheckController) etcdCA() (*x509.CertPool, error) {
+	configMap, err := c.configMapLister.ConfigMaps(operatorclient.TargetNamespace).Get(etcdServingCAConfigMap)
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("the %s/%s configmap is not synced yet", operatorclient.TargetNamespace, etcdServingCAConfigMap)
+	}
+	if err != nil {
+		return nil, err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM([]byte(configMap.Data[etcdServingCABundleKey])) {
+		return nil, fmt.Errorf("no certificates in %s of the %s/%s configmap", etcdServingCABundleKey, operatorclient.TargetNamespace, etcdServingCAConfigMap)
+	}
+	return roots, nil
+}
+
+// certificateValidAt returns an error when the certificate is not valid yet or expired at the given time.
+func certificateValidAt(certificate *x509.Certificate, now time.Time) error {
+	if now.Before(certificate.NotBefore) {
+		return fmt.Errorf("is not valid before %s", certificate.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if now.After(certificate.NotAfter) {
+		return fmt.Errorf("expired at %s", certificate.NotAfter.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// verifyClientCertificate verifies that the leaf of certs chains up to the roots for client authentication, through
+// the intermediates following it.
+func verifyClientCertificate(certs []*x509.Certificate, roots *x509.CertPool, now time.Time) error {
+	intermediates := x509.NewCertPool()
+	for _, intermediate := range certs[1:] {
+		intermediates.AddCert(intermediate)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	return err
+}
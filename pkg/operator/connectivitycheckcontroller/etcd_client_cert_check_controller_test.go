@@ -0,0 +1,156 @@
+package connectivitycheckcontroller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestEtcdClientCertCheckController(t *testing.T) {
+	newCA := func(name string) (*crypto.CA, []byte) {
+		t.Helper()
+		caConfig, err := crypto.MakeSelfSignedCAConfigForDuration(name, 24*time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		caPEM, _, err := caConfig.GetPEMBytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &crypto.CA{Config: caConfig, SerialGenerator: &crypto.RandomSerialGenerator{}}, caPEM
+	}
+	pemOf := func(certConfig *crypto.TLSCertificateConfig, err error) []byte {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		certPEM, _, err := certConfig.GetPEMBytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return certPEM
+	}
+	etcdCA, etcdCAPEM := newCA("etcd-signer")
+	rotatedCA, _ := newCA("etcd-signer")
+	client := &user.DefaultInfo{Name: "etcd"}
+
+	tests := []struct {
+		name            string
+		certificate     []byte
+		caBundle        []byte
+		now             time.Time
+		expectedStatus  operatorv1.ConditionStatus
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			name:           "client certificate of the etcd CA",
+			certificate:    pemOf(etcdCA.MakeClientCertificateForDuration(client, time.Hour)),
+			caBundle:       etcdCAPEM,
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: AsExpectedReason,
+		},
+		{
+			name:            "client certificate of a rotated out signer",
+			certificate:     pemOf(rotatedCA.MakeClientCertificateForDuration(client, time.Hour)),
+			caBundle:        etcdCAPEM,
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  EtcdClientCertificateNotTrustedReason,
+			expectedMessage: "is not trusted by the etcd CA of the openshift-kube-apiserver/etcd-serving-ca configmap",
+		},
+		{
+			name:            "serving certificate of the etcd CA",
+			certificate:     pemOf(etcdCA.MakeServerCertForDuration(sets.NewString("10.0.0.1"), time.Hour)),
+			caBundle:        etcdCAPEM,
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  EtcdClientCertificateNotTrustedReason,
+			expectedMessage: "key usage",
+		},
+		{
+			name:            "expired certificate",
+			certificate:     pemOf(etcdCA.MakeClientCertificateForDuration(client, time.Hour)),
+			caBundle:        etcdCAPEM,
+			now:             time.Now().Add(2 * time.Hour),
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  EtcdClientCertificateNotValidReason,
+			expectedMessage: "expired at",
+		},
+		{
+			name:            "no CA synced",
+			certificate:     pemOf(etcdCA.MakeClientCertificateForDuration(client, time.Hour)),
+			expectedStatus:  operatorv1.ConditionUnknown,
+			expectedReason:  NoEtcdClientCredentialsReason,
+			expectedMessage: "configmap is not synced yet",
+		},
+		{
+			name:            "no certificate synced",
+			caBundle:        etcdCAPEM,
+			expectedStatus:  operatorv1.ConditionUnknown,
+			expectedReason:  NoEtcdClientCredentialsReason,
+			expectedMessage: "secret is not synced yet",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secrets := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			if test.certificate != nil {
+				if err := secrets.Add(&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-kube-apiserver", Name: "etcd-client"},
+					Data:       map[string][]byte{"tls.crt": test.certificate},
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			configMaps := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			if test.caBundle != nil {
+				if err := configMaps.Add(&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-kube-apiserver", Name: "etcd-serving-ca"},
+					Data:       map[string]string{"ca-bundle.crt": string(test.caBundle)},
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			operatorSpec := &operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}}
+			operatorClient := v1helpers.NewFakeStaticPodOperatorClient(operatorSpec, &operatorv1.StaticPodOperatorStatus{}, nil, nil)
+			now := test.now
+			if now.IsZero() {
+				now = time.Now()
+			}
+			c := &etcdClientCertCheckController{
+				operatorClient:  operatorClient,
+				secretLister:    corev1listers.NewSecretLister(secrets),
+				configMapLister: corev1listers.NewConfigMapLister(configMaps),
+				now:             func() time.Time { return now },
+			}
+			if err := c.sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+				t.Fatal(err)
+			}
+
+			_, status, _, err := operatorClient.GetStaticPodOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			condition := v1helpers.FindOperatorCondition(status.Conditions, EtcdClientCertificateMismatchConditionType)
+			if condition == nil || condition.Status != test.expectedStatus || condition.Reason != test.expectedReason {
+				t.Fatalf("expected condition %s with reason %s, got %#v", test.expectedStatus, test.expectedReason, condition)
+			}
+			if !strings.Contains(condition.Message, test.expectedMessage) {
+				t.Errorf("expected %q in the message, got %q", test.expectedMessage, condition.Message)
+			}
+		})
+	}
+}
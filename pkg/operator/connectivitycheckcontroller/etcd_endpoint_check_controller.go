@@ -0,0 +1,266 @@
+package connectivitycheckcontroller
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+const (
+	EtcdEndpointsReachableConditionType = "EtcdEndpointsReachable"
+
+	EtcdEndpointsUnreachableReason = "EtcdEndpointsUnreachable"
+	NoEtcdClientCredentialsReason  = "NoEtcdClientCredentials"
+	NoEtcdEndpointsReason          = "NoEtcdEndpoints"
+	AsExpectedReason               = "AsExpected"
+
+	etcdClientSecretName    = "etcd-client"
+	etcdServingCAConfigMap  = "etcd-serving-ca"
+	etcdServingCABundleKey  = "ca-bundle.crt"
+	etcdEndpointDialTimeout = 5 * time.Second
+	etcdHealthPath          = "/health"
+)
+
+// tlsDialFunc opens a TLS connection to the address, completing the handshake.
+type tlsDialFunc func(ctx context.Context, address string, config *tls.Config) (net.Conn, error)
+
+func dialTLS(ctx context.Context, address string, config *tls.Config) (net.Conn, error) {
+	dialer := &tls.Dialer{NetDialer: &net.Dialer{Timeout: etcdEndpointDialTimeout}, Config: config}
+	return dialer.DialContext(ctx, "tcp", address)
+}
+
+// etcdEndpointCheckController requests the health of each etcd server of the observed config over TLS, with the
+// etcd client certificate synced for the kube-apiserver. The reachability and dial duration of each endpoint are
+// reported in metrics, the unreachable endpoints in the informational EtcdEndpointsReachable condition. Unlike the pod
+// network connectivity checks run from the kube-apiserver pods, this tells whether etcd accepts the kube-apiserver
+// credentials independently of the kube-apiserver.
+type etcdEndpointCheckController struct {
+	operatorClient  v1helpers.StaticPodOperatorClient
+	secretLister    corev1listers.SecretLister
+	configMapLister corev1listers.ConfigMapLister
+	dial            tlsDialFunc
+	now             func() time.Time
+
+	// reported holds the endpoints currently exposed by the metrics, so that the series of removed endpoints can be
+	// deleted.
+	reported sets.String
+}
+
+func NewEtcdEndpointCheckController(
+	operatorClient v1helpers.StaticPodOperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	recorder events.Recorder,
+) factory.Controller {
+	targetInformers := kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace)
+	c := &etcdEndpointCheckController{
+		operatorClient:  operatorClient,
+		secretLister:    targetInformers.Core().V1().Secrets().Lister(),
+		configMapLister: targetInformers.Core().V1().ConfigMaps().Lister(),
+		dial:            dialTLS,
+		now:             time.Now,
+		reported:        sets.NewString(),
+	}
+
+	// reachability changes without any object changing, resync to keep the metrics current
+	return factory.New().
+		WithInformers(
+			operatorClient.Informer(),
+			targetInformers.Core().V1().Secrets().Informer(),
+			targetInformers.Core().V1().ConfigMaps().Informer(),
+		).
+		WithSync(c.sync).
+		ResyncEvery(time.Minute).
+		ToController("EtcdEndpointCheckController", recorder.WithComponentSuffix("etcd-endpoint-check-controller"))
+}
+
+func (c *etcdEndpointCheckController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	operatorSpec, _, _, err := c.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+
+	endpoints, err := etcdEndpoints(operatorSpec.ObservedConfig.Raw)
+	if err != nil {
+		return err
+	}
+
+	condition := operatorv1.OperatorCondition{
+		Type:   EtcdEndpointsReachableConditionType,
+		Status: operatorv1.ConditionTrue,
+		Reason: AsExpectedReason,
+	}
+	tlsConfig, err := c.etcdClientTLSConfig()
+	switch {
+	case err != nil:
+		condition.Status = operatorv1.ConditionUnknown
+		condition.Reason = NoEtcdClientCredentialsReason
+		condition.Message = err.Error()
+		endpoints = nil
+	case len(endpoints) == 0:
+		condition.Status = operatorv1.ConditionUnknown
+		condition.Reason = NoEtcdEndpointsReason
+		condition.Message = "No etcd servers are observed yet"
+	}
+
+	var unreachable []string
+	seen := sets.NewString()
+	for _, endpoint := range endpoints {
+		seen.Insert(endpoint.Host)
+		duration, err := c.check(ctx, endpoint, tlsConfig)
+		etcdEndpointDialDurationGauge.WithLabelValues(endpoint.Host).Set(duration.Seconds())
+		if err != nil {
+			klog.V(2).Infof("etcd endpoint %s is unreachable after %v: %v", endpoint.Host, duration, err)
+			etcdEndpointReachableGauge.WithLabelValues(endpoint.Host).Set(0)
+			unreachable = append(unreachable, fmt.Sprintf("%s: %v", endpoint.Host, err))
+			continue
+		}
+		klog.V(4).Infof("etcd endpoint %s is reachable in %v", endpoint.Host, duration)
+		etcdEndpointReachableGauge.WithLabelValues(endpoint.Host).Set(1)
+	}
+	for _, endpoint := range c.reported.Difference(seen).UnsortedList() {
+		etcdEndpointReachableGauge.Delete(map[string]string{"endpoint": endpoint})
+		etcdEndpointDialDurationGauge.Delete(map[string]string{"endpoint": endpoint})
+	}
+	c.reported = seen
+
+	if len(unreachable) > 0 {
+		condition.Status = operatorv1.ConditionFalse
+		condition.Reason = EtcdEndpointsUnreachableReason
+		condition.Message = fmt.Sprintf("%d of %d etcd endpoints are unreachable with the kube-apiserver etcd client certificate: %s", len(unreachable), len(endpoints), strings.Join(unreachable, "; "))
+	}
+
+	_, _, err = v1helpers.UpdateStaticPodStatus(c.operatorClient, v1helpers.UpdateStaticPodConditionFn(condition))
+	return err
+}
+
+// check dials the endpoint and requests its health, it returns how long the dial took. With TLS 1.3 the client
+// handshake completes before etcd verifies the client certificate, a rejected certificate only shows on the first
+// read, so a response to the request is what tells the certificate was accepted.
+func (c *etcdEndpointCheckController) check(ctx context.Context, endpoint *url.URL, tlsConfig *tls.Config) (time.Duration, error) {
+	config := tlsConfig.Clone()
+	config.ServerName = endpoint.Hostname()
+
+	ctx, cancel := context.WithTimeout(ctx, etcdEndpointDialTimeout)
+	defer cancel()
+	start := c.now()
+	conn, err := c.dial(ctx, endpoint.Host, config)
+	duration := c.now().Sub(start)
+	if err != nil {
+		return duration, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return duration, err
+		}
+	}
+	req := &http.Request{
+		Method: http.MethodGet,
+		URL:    &url.URL{Scheme: "https", Host: endpoint.Host, Path: etcdHealthPath},
+		Host:   endpoint.Host,
+		Header: http.Header{},
+		Close:  true,
+	}
+	if err := req.Write(conn); err != nil {
+		return duration, fmt.Errorf("unable to request %s: %v", etcdHealthPath, err)
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return duration, fmt.Errorf("no response to %s: %v", etcdHealthPath, err)
+	}
+	resp.Body.Close()
+	// any response means the client certificate was accepted, whether etcd is healthy is reported by etcd itself
+	klog.V(4).Infof("etcd endpoint %s answered %s with %s", endpoint.Host, etcdHealthPath, resp.Status)
+	return duration, nil
+}
+
+// etcdClientTLSConfig returns the TLS config of the kube-apiserver etcd client, from the synced etcd client
+// certificate and etcd serving CA bundle.
+func (c *etcdEndpointCheckController) etcdClientTLSConfig() (*tls.Config, error) {
+	secret, err := c.secretLister.Secrets(operatorclient.TargetNamespace).Get(etcdClientSecretName)
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("the %s/%s secret is not synced yet", operatorclient.TargetNamespace, etcdClientSecretName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	clientCert, err := tls.X509KeyPair(secret.Data["tls.crt"], secret.Data["tls.key"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid etcd client certificate in the %s/%s secret: %v", operatorclient.TargetNamespace, etcdClientSecretName, err)
+	}
+
+	configMap, err := c.configMapLister.ConfigMaps(operatorclient.TargetNamespace).Get(etcdServingCAConfigMap)
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("the %s/%s configmap is not synced yet", operatorclient.TargetNamespace, etcdServingCAConfigMap)
+	}
+	if err != nil {
+		return nil, err
+	}
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM([]byte(configMap.Data[etcdServingCABundleKey])) {
+		return nil, fmt.Errorf("no certificates in %s of the %s/%s configmap", etcdServingCABundleKey, operatorclient.TargetNamespace, etcdServingCAConfigMap)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{clientCert},
+		RootCAs:      rootCAs,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// etcdEndpoints returns the etcd servers of the observed config sorted by host. The loopback servers used during
+// bootstrap are skipped, they are not reachable from the operator pod.
+func etcdEndpoints(rawObservedConfig []byte) ([]*url.URL, error) {
+	observedConfig := map[string]interface{}{}
+	if len(rawObservedConfig) > 0 {
+		if err := yaml.Unmarshal(rawObservedConfig, &observedConfig); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal the observedConfig: %w", err)
+		}
+	}
+	servers, _, err := unstructured.NestedStringSlice(observedConfig, "apiServerArguments", "etcd-servers")
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get the etcd server urls from observedConfig: %w", err)
+	}
+
+	var endpoints []*url.URL
+	for _, server := range servers {
+		endpoint, err := url.Parse(server)
+		if err != nil {
+			klog.Warningf("Skipping the etcd server %q of the observedConfig: %v", server, err)
+			continue
+		}
+		switch endpoint.Hostname() {
+		case "localhost", "127.0.0.1", "::1":
+			continue
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].Host < endpoints[j].Host })
+	return endpoints, nil
+}
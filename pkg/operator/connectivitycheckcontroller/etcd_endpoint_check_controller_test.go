@@ -0,0 +1,175 @@
+package connectivitycheckcontroller
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+func TestEtcdEndpointCheckController(t *testing.T) {
+	registry := metrics.NewKubeRegistry()
+	registry.MustRegister(etcdEndpointReachableGauge, etcdEndpointDialDurationGauge)
+	etcdEndpointReachableGauge.Reset()
+	etcdEndpointDialDurationGauge.Reset()
+
+	ca, err := crypto.MakeSelfSignedCAConfigForDuration("etcd-signer", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, keyPEM, err := ca.GetPEMBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	configMaps := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	clientSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-kube-apiserver", Name: "etcd-client"},
+		Data:       map[string][]byte{"tls.crt": certPEM, "tls.key": keyPEM},
+	}
+	if err := configMaps.Add(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-kube-apiserver", Name: "etcd-serving-ca"},
+		Data:       map[string]string{"ca-bundle.crt": string(certPEM)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	operatorSpec := &operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{
+		ManagementState: operatorv1.Managed,
+		ObservedConfig: runtime.RawExtension{Raw: []byte(`{"apiServerArguments":{"etcd-servers":[` +
+			`"https://10.0.0.1:2379","https://10.0.0.2:2379","https://10.0.0.3:2379","https://localhost:2379"]}}`)},
+	}}
+	operatorClient := v1helpers.NewFakeStaticPodOperatorClient(operatorSpec, &operatorv1.StaticPodOperatorStatus{}, nil, nil)
+
+	// the fake clock advances by 10ms on every read, a dial takes 10ms
+	now := time.Now()
+	unreachable := sets.NewString("10.0.0.2:2379")
+	// the handshake completes, the client certificate is only rejected on the first read
+	rejected := sets.NewString("10.0.0.3:2379")
+	dialed := sets.NewString()
+	c := &etcdEndpointCheckController{
+		operatorClient:  operatorClient,
+		secretLister:    corev1listers.NewSecretLister(secrets),
+		configMapLister: corev1listers.NewConfigMapLister(configMaps),
+		dial: func(ctx context.Context, address string, config *tls.Config) (net.Conn, error) {
+			dialed.Insert(address)
+			if host, _, _ := net.SplitHostPort(address); config.ServerName != host {
+				t.Errorf("expected the server name %q to be verified, got %q", host, config.ServerName)
+			}
+			if len(config.Certificates) != 1 || config.RootCAs == nil {
+				t.Errorf("expected the etcd client certificate and serving CA to be used")
+			}
+			if unreachable.Has(address) {
+				return nil, fmt.Errorf("connection refused")
+			}
+			client, server := net.Pipe()
+			go func() {
+				defer server.Close()
+				req, err := http.ReadRequest(bufio.NewReader(server))
+				if err != nil || req.URL.Path != "/health" {
+					t.Errorf("expected a /health request, got %v %v", req, err)
+					return
+				}
+				if rejected.Has(address) {
+					return
+				}
+				fmt.Fprint(server, "HTTP/1.1 200 OK\r\nContent-Length: 17\r\n\r\n{\"health\":\"true\"}")
+			}()
+			return client, nil
+		},
+		now: func() time.Time {
+			now = now.Add(10 * time.Millisecond)
+			return now
+		},
+		reported: sets.NewString(),
+	}
+	syncCtx := factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))
+
+	expectCondition := func(status operatorv1.ConditionStatus, reason string) *operatorv1.OperatorCondition {
+		t.Helper()
+		_, operatorStatus, _, err := operatorClient.GetStaticPodOperatorState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		condition := v1helpers.FindOperatorCondition(operatorStatus.Conditions, EtcdEndpointsReachableConditionType)
+		if condition == nil || condition.Status != status || condition.Reason != reason {
+			t.Fatalf("expected condition %s with reason %s, got %#v", status, reason, condition)
+		}
+		return condition
+	}
+	expectGauge := func(gauge *metrics.GaugeVec, endpoint string, expected float64) {
+		t.Helper()
+		actual, err := testutil.GetGaugeMetricValue(gauge.WithLabelValues(endpoint))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Errorf("expected %v for endpoint %s, got %v", expected, endpoint, actual)
+		}
+	}
+
+	// no client certificate synced yet
+	if err := c.sync(context.TODO(), syncCtx); err != nil {
+		t.Fatal(err)
+	}
+	expectCondition(operatorv1.ConditionUnknown, NoEtcdClientCredentialsReason)
+	if dialed.Len() > 0 {
+		t.Fatalf("expected no endpoint to be dialed without client certificate, got %v", dialed.List())
+	}
+
+	// mixed reachability
+	if err := secrets.Add(clientSecret); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.sync(context.TODO(), syncCtx); err != nil {
+		t.Fatal(err)
+	}
+	condition := expectCondition(operatorv1.ConditionFalse, EtcdEndpointsUnreachableReason)
+	if !strings.Contains(condition.Message, "2 of 3") || !strings.Contains(condition.Message, "10.0.0.2:2379: connection refused") || !strings.Contains(condition.Message, "10.0.0.3:2379: no response to /health") {
+		t.Errorf("expected the unreachable endpoints in the message, got %q", condition.Message)
+	}
+	if expected := sets.NewString("10.0.0.1:2379", "10.0.0.2:2379", "10.0.0.3:2379"); !dialed.Equal(expected) {
+		t.Errorf("expected %v to be dialed, got %v", expected.List(), dialed.List())
+	}
+	expectGauge(etcdEndpointReachableGauge, "10.0.0.1:2379", 1)
+	expectGauge(etcdEndpointReachableGauge, "10.0.0.2:2379", 0)
+	expectGauge(etcdEndpointReachableGauge, "10.0.0.3:2379", 0)
+	expectGauge(etcdEndpointDialDurationGauge, "10.0.0.1:2379", 0.01)
+
+	// all reachable
+	unreachable = sets.NewString()
+	rejected = sets.NewString()
+	if err := c.sync(context.TODO(), syncCtx); err != nil {
+		t.Fatal(err)
+	}
+	expectCondition(operatorv1.ConditionTrue, AsExpectedReason)
+	expectGauge(etcdEndpointReachableGauge, "10.0.0.2:2379", 1)
+
+	// the series of removed endpoints go away
+	operatorSpec.ObservedConfig.Raw = []byte(`{"apiServerArguments":{"etcd-servers":["https://10.0.0.1:2379"]}}`)
+	if err := c.sync(context.TODO(), syncCtx); err != nil {
+		t.Fatal(err)
+	}
+	if deleted := etcdEndpointReachableGauge.Delete(map[string]string{"endpoint": "10.0.0.2:2379"}); deleted {
+		t.Errorf("expected the series of a removed endpoint to be deleted")
+	}
+}
@@ -0,0 +1,29 @@
+package connectivitycheckcontroller
+
+import (
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+var (
+	registerMetrics sync.Once
+
+	etcdEndpointReachableGauge = metrics.NewGaugeVec(&metrics.GaugeOpts{
+		Name: "kube_apiserver_operator_etcd_endpoint_reachable",
+		Help: "Report whether the operator completed a TLS handshake with the etcd endpoint using the kube-apiserver etcd client certificate, 1 if it did, 0 otherwise",
+	}, []string{"endpoint"})
+
+	etcdEndpointDialDurationGauge = metrics.NewGaugeVec(&metrics.GaugeOpts{
+		Name: "kube_apiserver_operator_etcd_endpoint_dial_duration_seconds",
+		Help: "Report the number of seconds the last TLS connection attempt to the etcd endpoint took",
+	}, []string{"endpoint"})
+)
+
+// RegisterMetrics registers the connectivity check metrics with the legacy registry.
+func RegisterMetrics() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(etcdEndpointReachableGauge, etcdEndpointDialDurationGauge)
+	})
+}
@@ -0,0 +1,114 @@
+package encryptionconvergencecontroller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/encryption/encryptionconfig"
+	"github.com/openshift/library-go/pkg/operator/encryption/statemachine"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+const (
+	EncryptionConfigProgressingConditionType = "KubeAPIServerEncryptionConfigProgressing"
+
+	RevisionsDivergeReason        = "RevisionsDiverge"
+	EncryptionConfigLaggingReason = "EncryptionConfigLagging"
+	AsExpectedReason              = "AsExpected"
+)
+
+// desiredEncryptionConfigName is the encryption config written by the encryption controllers, which is synced into
+// the target namespace and copied into the next revision.
+var desiredEncryptionConfigName = fmt.Sprintf("%s-%s", encryptionconfig.EncryptionConfSecretName, operatorclient.TargetNamespace)
+
+// EncryptionConvergenceController compares the desired encryption config to the one of the revision all the
+// kube-apiservers serve. KubeAPIServerEncryptionConfigProgressing is True while they differ, e.g. in the window
+// after a key rotation where the new config is not rolled out yet, or while the kube-apiservers are not all on the
+// same revision. Anything depending on the kube-apiservers having loaded the desired config, like the migration to a
+// new key, can wait for it to be False.
+type EncryptionConvergenceController struct {
+	operatorClient v1helpers.OperatorClient
+	secretLister   corev1listers.SecretNamespaceLister
+	deployer       statemachine.Deployer
+}
+
+func NewEncryptionConvergenceController(
+	operatorClient v1helpers.OperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	deployer statemachine.Deployer,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	secretInformer := kubeInformersForNamespaces.InformersFor(operatorclient.GlobalMachineSpecifiedConfigNamespace).Core().V1().Secrets()
+	c := &EncryptionConvergenceController{
+		operatorClient: operatorClient,
+		secretLister:   secretInformer.Lister().Secrets(operatorclient.GlobalMachineSpecifiedConfigNamespace),
+		deployer:       deployer,
+	}
+	// the deployer informs about the revisions of the kube-apiserver pods and their encryption config
+	return factory.New().
+		WithInformers(operatorClient.Informer(), secretInformer.Informer(), deployer).
+		WithSync(c.sync).
+		ToController("EncryptionConvergenceController", eventRecorder.WithComponentSuffix("encryption-convergence-controller"))
+}
+
+func (c *EncryptionConvergenceController) sync(_ context.Context, _ factory.SyncContext) error {
+	operatorSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+
+	condition := operatorv1.OperatorCondition{
+		Type:   EncryptionConfigProgressingConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: AsExpectedReason,
+	}
+
+	desired, err := c.secretLister.Get(desiredEncryptionConfigName)
+	switch {
+	case apierrors.IsNotFound(err):
+		// encryption has never been enabled, there is nothing to converge to
+	case err != nil:
+		return err
+	default:
+		desiredConfig := desired.Data[encryptionconfig.EncryptionConfSecretKey]
+		deployed, converged, err := c.deployer.DeployedEncryptionConfigSecret()
+		if err != nil {
+			return err
+		}
+		switch {
+		case !converged:
+			condition.Status = operatorv1.ConditionTrue
+			condition.Reason = RevisionsDivergeReason
+			condition.Message = "The kube-apiservers are not all serving the same revision yet"
+		case deployed == nil || !bytes.Equal(deployed.Data[encryptionconfig.EncryptionConfSecretKey], desiredConfig):
+			condition.Status = operatorv1.ConditionTrue
+			condition.Reason = EncryptionConfigLaggingReason
+			condition.Message = fmt.Sprintf("The kube-apiservers serve %s, not the desired encryption config %s yet", configHash(deployed), configHash(desired))
+		}
+	}
+
+	_, _, err = v1helpers.UpdateStatus(c.operatorClient, v1helpers.UpdateConditionFn(condition))
+	return err
+}
+
+// configHash describes the encryption config of the secret by the sha256 of its content.
+func configHash(secret *corev1.Secret) string {
+	if secret == nil {
+		return "no encryption config"
+	}
+	return fmt.Sprintf("encryption config sha256 %x", sha256.Sum256(secret.Data[encryptionconfig.EncryptionConfSecretKey]))
+}
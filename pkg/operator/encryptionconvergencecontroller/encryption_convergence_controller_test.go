@@ -0,0 +1,109 @@
+package encryptionconvergencecontroller
+
+import (
+	"context"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+type fakeDeployer struct {
+	secret    *corev1.Secret
+	converged bool
+}
+
+func (d *fakeDeployer) DeployedEncryptionConfigSecret() (*corev1.Secret, bool, error) {
+	return d.secret, d.converged, nil
+}
+
+func (d *fakeDeployer) AddEventHandler(cache.ResourceEventHandler) {}
+
+func (d *fakeDeployer) HasSynced() bool { return true }
+
+func encryptionConfigSecret(namespace, name, config string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Data:       map[string][]byte{"encryption-config": []byte(config)},
+	}
+}
+
+func TestEncryptionConvergenceController(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		desired        *corev1.Secret
+		deployer       *fakeDeployer
+		expectedStatus operatorv1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name:           "encryption never enabled",
+			deployer:       &fakeDeployer{converged: true},
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: AsExpectedReason,
+		},
+		{
+			name:           "converged",
+			desired:        encryptionConfigSecret("openshift-config-managed", "encryption-config-openshift-kube-apiserver", "key-2"),
+			deployer:       &fakeDeployer{secret: encryptionConfigSecret("openshift-kube-apiserver", "encryption-config-5", "key-2"), converged: true},
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: AsExpectedReason,
+		},
+		{
+			name:           "the serving revision lags after a key rotation",
+			desired:        encryptionConfigSecret("openshift-config-managed", "encryption-config-openshift-kube-apiserver", "key-3"),
+			deployer:       &fakeDeployer{secret: encryptionConfigSecret("openshift-kube-apiserver", "encryption-config-5", "key-2"), converged: true},
+			expectedStatus: operatorv1.ConditionTrue,
+			expectedReason: EncryptionConfigLaggingReason,
+		},
+		{
+			name:           "the serving revision has no encryption config yet",
+			desired:        encryptionConfigSecret("openshift-config-managed", "encryption-config-openshift-kube-apiserver", "key-1"),
+			deployer:       &fakeDeployer{converged: true},
+			expectedStatus: operatorv1.ConditionTrue,
+			expectedReason: EncryptionConfigLaggingReason,
+		},
+		{
+			name:           "the kube-apiservers are on different revisions",
+			desired:        encryptionConfigSecret("openshift-config-managed", "encryption-config-openshift-kube-apiserver", "key-3"),
+			deployer:       &fakeDeployer{},
+			expectedStatus: operatorv1.ConditionTrue,
+			expectedReason: RevisionsDivergeReason,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			if scenario.desired != nil {
+				if err := indexer.Add(scenario.desired); err != nil {
+					t.Fatal(err)
+				}
+			}
+			operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+			c := &EncryptionConvergenceController{
+				operatorClient: operatorClient,
+				secretLister:   corev1listers.NewSecretLister(indexer).Secrets("openshift-config-managed"),
+				deployer:       scenario.deployer,
+			}
+			if err := c.sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+				t.Fatal(err)
+			}
+
+			_, status, _, err := operatorClient.GetOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			cond := v1helpers.FindOperatorCondition(status.Conditions, EncryptionConfigProgressingConditionType)
+			if cond == nil || cond.Status != scenario.expectedStatus || cond.Reason != scenario.expectedReason {
+				t.Errorf("expected %s with reason %s, got %#v", scenario.expectedStatus, scenario.expectedReason, cond)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package encryptionkeyrotation
+
+import (
+	"encoding/json"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// ForceKeyRotationAnnotation on the kubeapiserver/cluster operator config forces the rotation of the encryption key,
+// e.g. after a suspected key compromise. The value is the reason for the rotation, every new value rotates the key
+// once: a new key is created, made the write key and all encrypted resources are migrated to it.
+const ForceKeyRotationAnnotation = "kubeapiserver.operator.openshift.io/force-encryption-key-rotation"
+
+// ReasonFunc returns whether a key rotation is forced and its reason.
+type ReasonFunc func() (reason string, forced bool)
+
+// AnnotationReasonFunc returns a ReasonFunc reading the ForceKeyRotationAnnotation of the operator config
+// from the operator informer.
+func AnnotationReasonFunc(operatorInformer cache.SharedIndexInformer) ReasonFunc {
+	return func() (string, bool) {
+		obj, exists, err := operatorInformer.GetStore().GetByKey("cluster")
+		if err != nil || !exists {
+			return "", false
+		}
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			klog.Warningf("Unable to read the %s annotation: %v", ForceKeyRotationAnnotation, err)
+			return "", false
+		}
+		reason, forced := accessor.GetAnnotations()[ForceKeyRotationAnnotation]
+		if len(reason) == 0 {
+			return "", false
+		}
+		return reason, forced
+	}
+}
+
+// forcedRotationClient is handed to the encryption controllers. The key controller creates a new key whenever the
+// encryption.reason of the unsupported config overrides differs from the reason of the latest key, the client
+// presents the reason of the ForceKeyRotationAnnotation there. The spec stored in the API is left untouched.
+type forcedRotationClient struct {
+	v1helpers.OperatorClient
+	forcedReason ReasonFunc
+}
+
+// NewOperatorClient wraps the operator client so that the encryption controllers rotate the key when forcedReason is set.
+func NewOperatorClient(delegate v1helpers.OperatorClient, forcedReason ReasonFunc) v1helpers.OperatorClient {
+	return &forcedRotationClient{OperatorClient: delegate, forcedReason: forcedReason}
+}
+
+func (c *forcedRotationClient) GetOperatorState() (*operatorv1.OperatorSpec, *operatorv1.OperatorStatus, string, error) {
+	spec, status, resourceVersion, err := c.OperatorClient.GetOperatorState()
+	if err != nil {
+		return spec, status, resourceVersion, err
+	}
+	reason, forced := c.forcedReason()
+	if !forced {
+		return spec, status, resourceVersion, nil
+	}
+	overrides, err := withEncryptionReason(spec.UnsupportedConfigOverrides.Raw, reason)
+	if err != nil {
+		klog.Warningf("Ignoring the %s annotation, the unsupported config overrides cannot be read: %v", ForceKeyRotationAnnotation, err)
+		return spec, status, resourceVersion, nil
+	}
+	spec = spec.DeepCopy()
+	spec.UnsupportedConfigOverrides.Raw = overrides
+	spec.UnsupportedConfigOverrides.Object = nil
+	return spec, status, resourceVersion, nil
+}
+
+// withEncryptionReason sets encryption.reason in the raw unsupported config overrides.
+func withEncryptionReason(raw []byte, reason string) ([]byte, error) {
+	overrides := map[string]interface{}{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &overrides); err != nil {
+			return nil, err
+		}
+	}
+	if overrides == nil {
+		overrides = map[string]interface{}{}
+	}
+	if err := unstructured.SetNestedField(overrides, reason, "encryption", "reason"); err != nil {
+		return nil, err
+	}
+	return json.Marshal(overrides)
+}
@@ -0,0 +1,203 @@
+package encryptionkeyrotation
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configv1client "github.com/openshift/client-go/config/clientset/versioned/typed/config/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/encryption"
+	"github.com/openshift/library-go/pkg/operator/encryption/controllers"
+	"github.com/openshift/library-go/pkg/operator/encryption/encryptionconfig"
+	"github.com/openshift/library-go/pkg/operator/encryption/secrets"
+	"github.com/openshift/library-go/pkg/operator/encryption/state"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	apiserverconfigv1 "k8s.io/apiserver/pkg/apis/config/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+var encryptedGRs = []schema.GroupResource{{Resource: "secrets"}, {Resource: "configmaps"}}
+
+func TestWithEncryptionReason(t *testing.T) {
+	testCases := []struct {
+		name     string
+		raw      string
+		expected map[string]interface{}
+	}{
+		{
+			name:     "no overrides",
+			expected: map[string]interface{}{"encryption": map[string]interface{}{"reason": "INC-42"}},
+		},
+		{
+			name: "other overrides are kept",
+			raw:  `{"apiServerArguments":{"v":["4"]},"encryption":{"reason":"older"}}`,
+			expected: map[string]interface{}{
+				"apiServerArguments": map[string]interface{}{"v": []interface{}{"4"}},
+				"encryption":         map[string]interface{}{"reason": "INC-42"},
+			},
+		},
+		{
+			name:     "null overrides",
+			raw:      `null`,
+			expected: map[string]interface{}{"encryption": map[string]interface{}{"reason": "INC-42"}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			raw, err := withEncryptionReason([]byte(tc.raw), "INC-42")
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual := map[string]interface{}{}
+			if err := json.Unmarshal(raw, &actual); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected overrides (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// fakeDeployer reports the given encryption config as deployed on all the nodes.
+type fakeDeployer struct {
+	secret *corev1.Secret
+}
+
+func (d *fakeDeployer) DeployedEncryptionConfigSecret() (*corev1.Secret, bool, error) {
+	return d.secret, true, nil
+}
+func (d *fakeDeployer) AddEventHandler(cache.ResourceEventHandler) {}
+func (d *fakeDeployer) HasSynced() bool                            { return true }
+
+// fakeAPIServerClient only implements Get.
+type fakeAPIServerClient struct {
+	configv1client.APIServerInterface
+	apiServer *configv1.APIServer
+}
+
+func (c *fakeAPIServerClient) Get(context.Context, string, metav1.GetOptions) (*configv1.APIServer, error) {
+	return c.apiServer.DeepCopy(), nil
+}
+
+type fakeAPIServerInformer struct {
+	informer cache.SharedIndexInformer
+}
+
+func (i *fakeAPIServerInformer) Informer() cache.SharedIndexInformer { return i.informer }
+func (i *fakeAPIServerInformer) Lister() configlistersv1.APIServerLister {
+	return configlistersv1.NewAPIServerLister(i.informer.GetIndexer())
+}
+
+func newAPIServerInformer(t *testing.T, apiServer *configv1.APIServer) *fakeAPIServerInformer {
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &configv1.APIServer{}, 0, cache.Indexers{})
+	if apiServer != nil {
+		if err := informer.GetIndexer().Add(apiServer); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &fakeAPIServerInformer{informer: informer}
+}
+
+func aescbcAPIServer() *configv1.APIServer {
+	return &configv1.APIServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec:       configv1.APIServerSpec{Encryption: configv1.APIServerEncryption{Type: configv1.EncryptionTypeAESCBC}},
+	}
+}
+
+func keySecret(t *testing.T, id, externalReason string, migrated []schema.GroupResource) *corev1.Secret {
+	s, err := secrets.FromKeyState(operatorclient.TargetNamespace, state.KeyState{
+		Key:            apiserverconfigv1.Key{Name: id, Secret: base64.StdEncoding.EncodeToString(make([]byte, 32))},
+		Mode:           state.AESCBC,
+		ExternalReason: externalReason,
+		Migrated:       state.MigrationState{Timestamp: time.Now(), Resources: migrated},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestForcedRotationCreatesKey(t *testing.T) {
+	key1Secret := keySecret(t, "1", "", encryptedGRs)
+	key1, err := secrets.ToKeyState(key1Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grState := map[schema.GroupResource]state.GroupResourceState{}
+	for _, gr := range encryptedGRs {
+		grState[gr] = state.GroupResourceState{WriteKey: key1, ReadKeys: []state.KeyState{key1}}
+	}
+	encryptionConfigSecret, err := encryptionconfig.ToSecret(operatorclient.TargetNamespace, "encryption-config", encryptionconfig.FromEncryptionState(grState))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kubeClient := fake.NewSimpleClientset(key1Secret)
+	reason, forced := "", false
+	operatorClient := NewOperatorClient(
+		v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil),
+		func() (string, bool) { return reason, forced },
+	)
+	keyController := controllers.NewKeyController(
+		operatorclient.TargetNamespace,
+		nil,
+		encryption.StaticEncryptionProvider(encryptedGRs),
+		&fakeDeployer{secret: encryptionConfigSecret},
+		func() (bool, error) { return true, nil },
+		operatorClient,
+		&fakeAPIServerClient{apiServer: aescbcAPIServer()},
+		newAPIServerInformer(t, aescbcAPIServer()),
+		v1helpers.NewKubeInformersForNamespaces(kubeClient, operatorclient.GlobalMachineSpecifiedConfigNamespace),
+		kubeClient.CoreV1(),
+		metav1.ListOptions{LabelSelector: secrets.EncryptionKeySecretsLabel + "=" + operatorclient.TargetNamespace},
+		events.NewInMemoryRecorder("test"),
+	)
+	syncKeys := func() []state.KeyState {
+		t.Helper()
+		if err := keyController.Sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+			t.Fatal(err)
+		}
+		keySecrets, err := kubeClient.CoreV1().Secrets(operatorclient.GlobalMachineSpecifiedConfigNamespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var keys []state.KeyState
+		for i := range keySecrets.Items {
+			key, err := secrets.ToKeyState(&keySecrets.Items[i])
+			if err != nil {
+				t.Fatal(err)
+			}
+			keys = append(keys, key)
+		}
+		return state.SortRecentFirst(keys)
+	}
+
+	if keys := syncKeys(); len(keys) != 1 {
+		t.Fatalf("expected no new key before the rotation is forced, got %d keys", len(keys))
+	}
+
+	reason, forced = "INC-42", true
+	keys := syncKeys()
+	if len(keys) != 2 {
+		t.Fatalf("expected a new key once the rotation is forced, got %d keys", len(keys))
+	}
+	if keys[0].Key.Name != "2" || keys[0].ExternalReason != "INC-42" {
+		t.Errorf("expected key 2 to be created for INC-42, got key %s for %q", keys[0].Key.Name, keys[0].ExternalReason)
+	}
+}
@@ -0,0 +1,163 @@
+package encryptionkeyrotation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configv1informers "github.com/openshift/client-go/config/informers/externalversions/config/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/encryption/secrets"
+	"github.com/openshift/library-go/pkg/operator/encryption/state"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+const (
+	KeyRotationInProgressConditionType = "KubeAPIServerEncryptionKeyRotationInProgress"
+
+	WaitingForKeyReason      = "WaitingForKey"
+	MigratingReason          = "Migrating"
+	EncryptionDisabledReason = "EncryptionDisabled"
+	AsExpectedReason         = "AsExpected"
+)
+
+// KeyRotationController reports the progress of a key rotation forced by the ForceKeyRotationAnnotation in the
+// KubeAPIServerEncryptionKeyRotationInProgress condition: it is True until a key created for the requested reason
+// is the one all encrypted resources are migrated to.
+type KeyRotationController struct {
+	component       string
+	encryptedGRs    []schema.GroupResource
+	operatorClient  v1helpers.OperatorClient
+	apiServerLister configlistersv1.APIServerLister
+	secretLister    corev1listers.SecretNamespaceLister
+	forcedReason    ReasonFunc
+}
+
+func NewKeyRotationController(
+	component string,
+	encryptedGRs []schema.GroupResource,
+	operatorClient v1helpers.OperatorClient,
+	apiServerInformer configv1informers.APIServerInformer,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	forcedReason ReasonFunc,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	secretInformer := kubeInformersForNamespaces.InformersFor(operatorclient.GlobalMachineSpecifiedConfigNamespace).Core().V1().Secrets()
+	c := &KeyRotationController{
+		component:       component,
+		encryptedGRs:    encryptedGRs,
+		operatorClient:  operatorClient,
+		apiServerLister: apiServerInformer.Lister(),
+		secretLister:    secretInformer.Lister().Secrets(operatorclient.GlobalMachineSpecifiedConfigNamespace),
+		forcedReason:    forcedReason,
+	}
+	return factory.New().
+		WithInformers(operatorClient.Informer(), apiServerInformer.Informer(), secretInformer.Informer()).
+		WithSync(c.sync).
+		ToController("EncryptionKeyRotationController", eventRecorder.WithComponentSuffix("encryption-key-rotation-controller"))
+}
+
+func (c *KeyRotationController) sync(_ context.Context, syncCtx factory.SyncContext) error {
+	operatorSpec, operatorStatus, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+
+	condition, err := c.rotationCondition()
+	if err != nil {
+		return err
+	}
+
+	if existing := v1helpers.FindOperatorCondition(operatorStatus.Conditions, KeyRotationInProgressConditionType); existing != nil && existing.Status == operatorv1.ConditionTrue && condition.Status == operatorv1.ConditionFalse && condition.Reason == AsExpectedReason {
+		syncCtx.Recorder().Eventf("EncryptionKeyRotationCompleted", "%s", condition.Message)
+	}
+
+	_, _, err = v1helpers.UpdateStatus(c.operatorClient, v1helpers.UpdateConditionFn(condition))
+	return err
+}
+
+func (c *KeyRotationController) rotationCondition() (operatorv1.OperatorCondition, error) {
+	condition := operatorv1.OperatorCondition{
+		Type:   KeyRotationInProgressConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: AsExpectedReason,
+	}
+	reason, forced := c.forcedReason()
+	if !forced {
+		return condition, nil
+	}
+
+	apiServer, err := c.apiServerLister.Get("cluster")
+	if err != nil && !apierrors.IsNotFound(err) {
+		return condition, err
+	}
+	if apiServer == nil || apiServer.Spec.Encryption.Type == "" || apiServer.Spec.Encryption.Type == configv1.EncryptionTypeIdentity {
+		condition.Reason = EncryptionDisabledReason
+		condition.Message = fmt.Sprintf("Encryption is disabled, the key rotation forced for %q is ignored", reason)
+		return condition, nil
+	}
+
+	key, found, err := c.latestKeyForReason(reason)
+	if err != nil {
+		return condition, err
+	}
+	if !found {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = WaitingForKeyReason
+		condition.Message = fmt.Sprintf("Waiting for a new encryption key to be created for %q, it is created once the migration to the current key completes", reason)
+		return condition, nil
+	}
+	if migrated, missing, _ := state.MigratedFor(c.encryptedGRs, key); !migrated {
+		var resources []string
+		for _, gr := range missing {
+			resources = append(resources, gr.String())
+		}
+		sort.Strings(resources)
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = MigratingReason
+		condition.Message = fmt.Sprintf("Encryption key %s was created for %q, waiting for %s to be migrated to it", key.Key.Name, reason, strings.Join(resources, ", "))
+		return condition, nil
+	}
+
+	condition.Message = fmt.Sprintf("Encryption key %s created for %q is in use, all encrypted resources are migrated to it", key.Key.Name, reason)
+	return condition, nil
+}
+
+// latestKeyForReason returns the most recent key of the component whose external reason is the given one.
+func (c *KeyRotationController) latestKeyForReason(reason string) (state.KeyState, bool, error) {
+	keySecrets, err := c.secretLister.List(labels.SelectorFromSet(labels.Set{secrets.EncryptionKeySecretsLabel: c.component}))
+	if err != nil {
+		return state.KeyState{}, false, err
+	}
+	var keys []state.KeyState
+	for _, s := range keySecrets {
+		key, err := secrets.ToKeyState(s)
+		if err != nil {
+			klog.Warningf("Skipping invalid encryption key secret: %v", err)
+			continue
+		}
+		if key.ExternalReason == reason {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return state.KeyState{}, false, nil
+	}
+	return state.SortRecentFirst(keys)[0], true, nil
+}
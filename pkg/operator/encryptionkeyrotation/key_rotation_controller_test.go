@@ -0,0 +1,114 @@
+package encryptionkeyrotation
+
+import (
+	"context"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+func TestKeyRotationController(t *testing.T) {
+	testCases := []struct {
+		name            string
+		reason          string
+		apiServer       *configv1.APIServer
+		keys            []runtime.Object
+		expectedStatus  operatorv1.ConditionStatus
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			name:           "NotForced",
+			apiServer:      aescbcAPIServer(),
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: AsExpectedReason,
+		},
+		{
+			name:            "EncryptionDisabled",
+			reason:          "INC-42",
+			apiServer:       &configv1.APIServer{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}},
+			expectedStatus:  operatorv1.ConditionFalse,
+			expectedReason:  EncryptionDisabledReason,
+			expectedMessage: `Encryption is disabled, the key rotation forced for "INC-42" is ignored`,
+		},
+		{
+			name:            "WaitingForKey",
+			reason:          "INC-42",
+			apiServer:       aescbcAPIServer(),
+			keys:            []runtime.Object{keySecret(t, "1", "", encryptedGRs)},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  WaitingForKeyReason,
+			expectedMessage: `Waiting for a new encryption key to be created for "INC-42", it is created once the migration to the current key completes`,
+		},
+		{
+			name:      "Migrating",
+			reason:    "INC-42",
+			apiServer: aescbcAPIServer(),
+			keys: []runtime.Object{
+				keySecret(t, "1", "", encryptedGRs),
+				keySecret(t, "2", "INC-42", []schema.GroupResource{{Resource: "secrets"}}),
+			},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  MigratingReason,
+			expectedMessage: `Encryption key 2 was created for "INC-42", waiting for configmaps to be migrated to it`,
+		},
+		{
+			name:      "Completed",
+			reason:    "INC-42",
+			apiServer: aescbcAPIServer(),
+			keys: []runtime.Object{
+				keySecret(t, "1", "", encryptedGRs),
+				keySecret(t, "2", "INC-42", encryptedGRs),
+			},
+			expectedStatus:  operatorv1.ConditionFalse,
+			expectedReason:  AsExpectedReason,
+			expectedMessage: `Encryption key 2 created for "INC-42" is in use, all encrypted resources are migrated to it`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset(tc.keys...)
+			kubeInformers := v1helpers.NewKubeInformersForNamespaces(kubeClient, operatorclient.GlobalMachineSpecifiedConfigNamespace)
+			for _, key := range tc.keys {
+				if err := kubeInformers.InformersFor(operatorclient.GlobalMachineSpecifiedConfigNamespace).Core().V1().Secrets().Informer().GetIndexer().Add(key); err != nil {
+					t.Fatal(err)
+				}
+			}
+			operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+			c := NewKeyRotationController(
+				operatorclient.TargetNamespace,
+				encryptedGRs,
+				operatorClient,
+				newAPIServerInformer(t, tc.apiServer),
+				kubeInformers,
+				func() (string, bool) { return tc.reason, len(tc.reason) > 0 },
+				events.NewInMemoryRecorder("test"),
+			)
+			if err := c.Sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+				t.Fatal(err)
+			}
+
+			_, status, _, err := operatorClient.GetOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			condition := v1helpers.FindOperatorCondition(status.Conditions, KeyRotationInProgressConditionType)
+			if condition == nil {
+				t.Fatalf("expected %s condition", KeyRotationInProgressConditionType)
+			}
+			if condition.Status != tc.expectedStatus || condition.Reason != tc.expectedReason || condition.Message != tc.expectedMessage {
+				t.Errorf("expected %s %s %q, got %s %s %q", tc.expectedStatus, tc.expectedReason, tc.expectedMessage, condition.Status, condition.Reason, condition.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package encryptionmigration
+
+import (
+	"sync"
+
+	"github.com/blang/semver"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+
+	encryptionsecrets "github.com/openshift/library-go/pkg/operator/encryption/secrets"
+	"github.com/openshift/library-go/pkg/operator/encryption/state"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+var (
+	registerMetrics sync.Once
+
+	pendingMigrationsDesc = prometheus.NewDesc(
+		"kube_apiserver_operator_encryption_migration_pending_resources",
+		"Report the number of encrypted resources whose storage migration to the current write key is not finished",
+		nil, nil,
+	)
+
+	completedMigrationsCounter = metrics.NewCounterVec(&metrics.CounterOpts{
+		Name: "kube_apiserver_operator_encryption_migrations_completed_total",
+		Help: "Report the number of storage migrations of an encrypted resource to a new write key that finished successfully",
+	}, []string{"resource"})
+)
+
+// RegisterMetrics registers the encryption migration metrics with the legacy registry. The pending migrations are
+// read from the key secrets in secretLister, for the encryptedResources.
+func RegisterMetrics(secretLister corev1listers.SecretNamespaceLister, encryptedResources []schema.GroupResource) {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(&pendingMigrationsCollector{secretLister: secretLister, encryptedResources: encryptedResources})
+		legacyregistry.MustRegister(completedMigrationsCounter)
+	})
+}
+
+// pendingMigrationsCollector computes the pending migrations on every scrape from the migrated resources recorded
+// in the key secrets, so that the value survives restarts of the operator. Nothing is reported when the key secrets
+// cannot be read, rather than a stale value.
+type pendingMigrationsCollector struct {
+	secretLister       corev1listers.SecretNamespaceLister
+	encryptedResources []schema.GroupResource
+}
+
+func (c *pendingMigrationsCollector) Create(version *semver.Version) bool {
+	return true
+}
+
+func (c *pendingMigrationsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- pendingMigrationsDesc
+}
+
+func (c *pendingMigrationsCollector) Collect(ch chan<- prometheus.Metric) {
+	pending, err := c.pendingMigrations()
+	if err != nil {
+		klog.Warningf("Unable to report the pending encryption migrations: %v", err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(pendingMigrationsDesc, prometheus.GaugeValue, float64(pending))
+}
+
+// pendingMigrations returns the number of encrypted resources not recorded as migrated to the latest key, which
+// the migration controller migrates them to once it is rolled out.
+func (c *pendingMigrationsCollector) pendingMigrations() (int, error) {
+	keySecrets, err := c.secretLister.List(labels.SelectorFromSet(labels.Set{encryptionsecrets.EncryptionKeySecretsLabel: operatorclient.TargetNamespace}))
+	if err != nil {
+		return 0, err
+	}
+	if len(keySecrets) == 0 {
+		return 0, nil
+	}
+	keys := make([]state.KeyState, 0, len(keySecrets))
+	for _, keySecret := range keySecrets {
+		key, err := encryptionsecrets.ToKeyState(keySecret)
+		if err != nil {
+			return 0, err
+		}
+		keys = append(keys, key)
+	}
+	_, missing, _ := state.MigratedFor(c.encryptedResources, state.SortRecentFirst(keys)[0])
+	return len(missing), nil
+}
+
+func (c *pendingMigrationsCollector) ClearState() {}
+
+func (c *pendingMigrationsCollector) FQName() string {
+	return "kube_apiserver_operator_encryption_migration_pending_resources"
+}
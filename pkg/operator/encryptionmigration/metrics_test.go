@@ -0,0 +1,99 @@
+package encryptionmigration
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+func TestPendingMigrationsCollector(t *testing.T) {
+	keySecret := func(name, migratedResources string) *corev1.Secret {
+		s := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "openshift-config-managed",
+				Name:      name,
+				Labels:    map[string]string{"encryption.apiserver.operator.openshift.io/component": "openshift-kube-apiserver"},
+				Annotations: map[string]string{
+					"encryption.apiserver.operator.openshift.io/mode": "aescbc",
+				},
+			},
+			Data: map[string][]byte{"encryption.apiserver.operator.openshift.io-key": []byte("0123456789abcdef0123456789abcdef")},
+		}
+		if len(migratedResources) > 0 {
+			s.Annotations["encryption.apiserver.operator.openshift.io/migrated-resources"] = migratedResources
+		}
+		return s
+	}
+	const metricName = "kube_apiserver_operator_encryption_migration_pending_resources"
+	pendingMetric := func(value string) string {
+		return `
+# HELP kube_apiserver_operator_encryption_migration_pending_resources Report the number of encrypted resources whose storage migration to the current write key is not finished
+# TYPE kube_apiserver_operator_encryption_migration_pending_resources gauge
+kube_apiserver_operator_encryption_migration_pending_resources ` + value + `
+`
+	}
+
+	scenarios := []struct {
+		name       string
+		keySecrets []*corev1.Secret
+		expected   string
+	}{
+		{
+			name:     "encryption never enabled",
+			expected: pendingMetric("0"),
+		},
+		{
+			name: "migration to the latest key in progress",
+			keySecrets: []*corev1.Secret{
+				keySecret("openshift-kube-apiserver-encryption-1", `{"resources":[{"group":"","resource":"secrets"},{"group":"","resource":"configmaps"}]}`),
+				keySecret("openshift-kube-apiserver-encryption-2", `{"resources":[{"group":"","resource":"secrets"}]}`),
+			},
+			expected: pendingMetric("1"),
+		},
+		{
+			name: "latest key not migrated to yet",
+			keySecrets: []*corev1.Secret{
+				keySecret("openshift-kube-apiserver-encryption-1", `{"resources":[{"group":"","resource":"secrets"},{"group":"","resource":"configmaps"}]}`),
+				keySecret("openshift-kube-apiserver-encryption-2", ""),
+			},
+			expected: pendingMetric("2"),
+		},
+		{
+			name: "migration finished",
+			keySecrets: []*corev1.Secret{
+				keySecret("openshift-kube-apiserver-encryption-2", `{"resources":[{"group":"","resource":"configmaps"},{"group":"","resource":"secrets"}]}`),
+			},
+			expected: pendingMetric("0"),
+		},
+		{
+			name: "unreadable key secret is not reported",
+			keySecrets: []*corev1.Secret{
+				keySecret("openshift-kube-apiserver-encryption-2", `{"resources":`),
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, s := range scenario.keySecrets {
+				if err := indexer.Add(s); err != nil {
+					t.Fatal(err)
+				}
+			}
+			collector := &pendingMigrationsCollector{
+				secretLister:       corev1listers.NewSecretLister(indexer).Secrets("openshift-config-managed"),
+				encryptedResources: []schema.GroupResource{{Resource: "secrets"}, {Resource: "configmaps"}},
+			}
+			if err := testutil.CollectAndCompare(collector, strings.NewReader(scenario.expected), metricName); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package encryptionmigration
+
+import (
+	"sync"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/encryption/controllers/migrators"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// metricsMigrator is handed to the encryption migration controller. It counts every successful migration of a
+// resource to a new write key once. The pending migrations are reported from the key secrets instead, see
+// pendingMigrationsCollector.
+type metricsMigrator struct {
+	migrators.Migrator
+
+	lock sync.Mutex
+	// completed holds the write key of the last successful migration of every resource.
+	completed map[schema.GroupResource]string
+}
+
+// NewMetricsMigrator wraps the migrator to count the successful storage migrations.
+func NewMetricsMigrator(delegate migrators.Migrator) migrators.Migrator {
+	return &metricsMigrator{
+		Migrator:  delegate,
+		completed: map[schema.GroupResource]string{},
+	}
+}
+
+func (m *metricsMigrator) EnsureMigration(gr schema.GroupResource, writeKey string) (bool, error, time.Time, error) {
+	finished, result, ts, err := m.Migrator.EnsureMigration(gr, writeKey)
+	if err != nil || !finished || result != nil {
+		return finished, result, ts, err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.completed[gr] != writeKey {
+		m.completed[gr] = writeKey
+		completedMigrationsCounter.WithLabelValues(gr.String()).Inc()
+	}
+
+	return finished, result, ts, err
+}
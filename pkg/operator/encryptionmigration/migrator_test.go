@@ -0,0 +1,96 @@
+package encryptionmigration
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/encryption/controllers/migrators"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+var (
+	secrets    = schema.GroupResource{Resource: "secrets"}
+	configMaps = schema.GroupResource{Resource: "configmaps"}
+)
+
+// fakeMigrator finishes the migrations listed in finished, with the error in failed if any.
+type fakeMigrator struct {
+	migrators.Migrator
+	finished map[schema.GroupResource]bool
+	failed   map[schema.GroupResource]error
+	// ensured counts the calls to EnsureMigration
+	ensured int
+}
+
+func (m *fakeMigrator) EnsureMigration(gr schema.GroupResource, _ string) (bool, error, time.Time, error) {
+	m.ensured++
+	if err, ok := m.failed[gr]; ok {
+		return true, err, time.Now(), nil
+	}
+	return m.finished[gr], nil, time.Now(), nil
+}
+
+func (m *fakeMigrator) PruneMigration(gr schema.GroupResource) error {
+	delete(m.finished, gr)
+	return nil
+}
+
+func TestMetricsMigrator(t *testing.T) {
+	registry := metrics.NewKubeRegistry()
+	registry.MustRegister(completedMigrationsCounter)
+
+	delegate := &fakeMigrator{finished: map[schema.GroupResource]bool{}, failed: map[schema.GroupResource]error{}}
+	migrator := NewMetricsMigrator(delegate)
+
+	// runMigrations ensures the migration of both resources to the write key, like the migration controller does
+	runMigrations := func(writeKey string) {
+		t.Helper()
+		for _, gr := range []schema.GroupResource{configMaps, secrets} {
+			if _, _, _, err := migrator.EnsureMigration(gr, writeKey); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	expectCompleted := func(gr schema.GroupResource, expected float64) {
+		t.Helper()
+		completed, err := testutil.GetCounterMetricValue(completedMigrationsCounter.WithLabelValues(gr.String()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if completed != expected {
+			t.Errorf("expected %v completed migrations of %s, got %v", expected, gr, completed)
+		}
+	}
+
+	runMigrations("2")
+
+	delegate.finished[secrets] = true
+	runMigrations("2")
+	expectCompleted(secrets, 1)
+
+	// a failed migration is not counted until it is retried successfully
+	delegate.failed[configMaps] = fmt.Errorf("migration failed")
+	runMigrations("2")
+	expectCompleted(configMaps, 0)
+
+	delete(delegate.failed, configMaps)
+	delegate.finished[configMaps] = true
+	runMigrations("2")
+	expectCompleted(configMaps, 1)
+
+	// a finished migration is counted once
+	runMigrations("2")
+	expectCompleted(secrets, 1)
+	expectCompleted(configMaps, 1)
+
+	// the next key rotation
+	delegate.finished = map[schema.GroupResource]bool{}
+	runMigrations("3")
+	delegate.finished[secrets], delegate.finished[configMaps] = true, true
+	runMigrations("3")
+	expectCompleted(secrets, 2)
+	expectCompleted(configMaps, 2)
+}
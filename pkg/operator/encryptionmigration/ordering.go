@@ -0,0 +1,377 @@
+package encryptionmigration
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/encryption/controllers/migrators"
+	"github.com/openshift/library-go/pkg/operator/encryption/encryptionconfig"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	coreclientv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/rolloutfreeze"
+)
+
+const (
+	// EncryptionMigrationOrderingConditionType is True while a storage migration waits for a revision rollout, or a
+	// revision rollout waits for a storage migration.
+	EncryptionMigrationOrderingConditionType = "KubeAPIServerEncryptionMigrationOrdering"
+
+	MigrationWaitingForRolloutReason = "MigrationWaitingForRollout"
+	RolloutWaitingForMigrationReason = "RolloutWaitingForMigration"
+	AsExpectedReason                 = "AsExpected"
+
+	// MigrationOrderingConfigMapName is the configmap of the operator namespace holding the running migrations, keyed
+	// by group resource with their RFC 3339 start, and the hash of the desired encryption config they were started
+	// with under migratingConfigKey.
+	MigrationOrderingConfigMapName = "encryption-migration-ordering"
+	migratingConfigKey             = "config"
+
+	// maxRolloutFreezeDuringMigration bounds how long a storage migration holds back the rollout of new revisions, a
+	// stuck migration must not freeze the kube-apiserver forever.
+	maxRolloutFreezeDuringMigration = time.Hour
+)
+
+// desiredEncryptionConfigName is the encryption config written by the encryption controllers.
+var desiredEncryptionConfigName = fmt.Sprintf("%s-%s", encryptionconfig.EncryptionConfSecretName, operatorclient.TargetNamespace)
+
+// MigrationOrdering orders the storage migrations to a new encryption key and the revision rollouts. The library-go
+// migration controller only migrates once all the kube-apiservers serve the desired encryption config, but it does
+// not know about a revision made available and not rolled out yet: a migration started then is interrupted by the
+// restart of every kube-apiserver, and the revision may carry another encryption config. The migrator returned by
+// Migrator therefore does not start a migration while a node is not on the latest available revision. The other way
+// around, the FreezeFunc holds back new revisions while a migration runs, unless the desired encryption config changed
+// since it started, as the new config has to be rolled out to supersede the migration, or it has been running for
+// maxRolloutFreezeDuringMigration. Migrations already running are never held. The running migrations are persisted in
+// the MigrationOrderingConfigMapName configmap, so that a restarted operator neither forgets the freeze nor restarts
+// its maxRolloutFreezeDuringMigration bound. The state is reported in the KubeAPIServerEncryptionMigrationOrdering
+// condition.
+type MigrationOrdering struct {
+	factory.Controller
+	operatorClient  v1helpers.StaticPodOperatorClient
+	secretLister    corev1listers.SecretNamespaceLister
+	configMapClient coreclientv1.ConfigMapsGetter
+	recorder        events.Recorder
+	now             func() time.Time
+
+	lock sync.Mutex
+	// restored is whether the running migrations were read from the configmap.
+	restored bool
+	// migrating holds the start of the running migrations.
+	migrating map[schema.GroupResource]time.Time
+	// migratingConfig is the hash of the desired encryption config the running migrations were started with.
+	migratingConfig string
+	// held holds the migrations not started because of a revision rollout.
+	held map[schema.GroupResource]bool
+}
+
+func NewMigrationOrdering(
+	operatorClient v1helpers.StaticPodOperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	configMapClient coreclientv1.ConfigMapsGetter,
+	eventRecorder events.Recorder,
+) *MigrationOrdering {
+	secretInformer := kubeInformersForNamespaces.InformersFor(operatorclient.GlobalMachineSpecifiedConfigNamespace).Core().V1().Secrets()
+	o := &MigrationOrdering{
+		operatorClient:  operatorClient,
+		secretLister:    secretInformer.Lister().Secrets(operatorclient.GlobalMachineSpecifiedConfigNamespace),
+		configMapClient: configMapClient,
+		recorder:        eventRecorder,
+		now:             time.Now,
+		migrating:       map[schema.GroupResource]time.Time{},
+		held:            map[schema.GroupResource]bool{},
+	}
+	// the migrations are not informers, resync to notice them starting and finishing
+	o.Controller = factory.New().
+		WithSync(o.sync).
+		WithInformers(operatorClient.Informer(), secretInformer.Informer()).
+		ResyncEvery(time.Minute).
+		ToController("EncryptionMigrationOrdering", eventRecorder.WithComponentSuffix("encryption-migration-ordering"))
+	return o
+}
+
+// Migrator wraps the migrator handed to the migration controller, holding the start of migrations while a revision
+// rolls out.
+func (o *MigrationOrdering) Migrator(delegate migrators.Migrator) migrators.Migrator {
+	return &orderedMigrator{Migrator: delegate, ordering: o}
+}
+
+// FreezeFunc freezes rollouts while a migration runs.
+func (o *MigrationOrdering) FreezeFunc() rolloutfreeze.FreezeFunc {
+	return func() (string, bool) {
+		desiredConfig, err := o.desiredConfigHash()
+		if err != nil {
+			klog.Warningf("Unable to read the desired encryption config: %v", err)
+			return "", false
+		}
+		resources, frozen, err := o.freezingMigrations(desiredConfig)
+		if err != nil {
+			klog.Warningf("Unable to read the running storage migrations: %v", err)
+			return "", false
+		}
+		if !frozen {
+			return "", false
+		}
+		return fmt.Sprintf("the storage migration of %s to a new encryption key is in progress", strings.Join(resources, ", ")), true
+	}
+}
+
+func (o *MigrationOrdering) sync(_ context.Context, _ factory.SyncContext) error {
+	operatorSpec, _, _, err := o.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+	desiredConfig, err := o.desiredConfigHash()
+	if err != nil {
+		return err
+	}
+	rollingOut, err := o.revisionRollingOut()
+	if err != nil {
+		return err
+	}
+
+	condition := operatorv1.OperatorCondition{
+		Type:   EncryptionMigrationOrderingConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: AsExpectedReason,
+	}
+	if !rollingOut {
+		o.clearHeld()
+	}
+	held := o.heldMigrations()
+	migrating, frozen, err := o.freezingMigrations(desiredConfig)
+	if err != nil {
+		return err
+	}
+	if frozen {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = RolloutWaitingForMigrationReason
+		condition.Message = fmt.Sprintf("New revisions are held until the storage migration of %s to a new encryption key finishes", strings.Join(migrating, ", "))
+	} else if len(held) > 0 && rollingOut {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = MigrationWaitingForRolloutReason
+		condition.Message = fmt.Sprintf("The storage migration of %s to a new encryption key waits for the latest revision to be rolled out to all the nodes", strings.Join(held, ", "))
+	}
+
+	_, _, err = v1helpers.UpdateStaticPodStatus(o.operatorClient, v1helpers.UpdateStaticPodConditionFn(condition))
+	return err
+}
+
+// revisionRollingOut returns whether a node is not on the latest available revision.
+func (o *MigrationOrdering) revisionRollingOut() (bool, error) {
+	_, operatorStatus, _, err := o.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		return false, err
+	}
+	for _, ns := range operatorStatus.NodeStatuses {
+		if ns.CurrentRevision != operatorStatus.LatestAvailableRevision {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// desiredConfigHash returns the sha256 of the desired encryption config, empty if encryption was never enabled.
+func (o *MigrationOrdering) desiredConfigHash() (string, error) {
+	desired, err := o.secretLister.Get(desiredEncryptionConfigName)
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(desired.Data[encryptionconfig.EncryptionConfSecretKey])), nil
+}
+
+// freezingMigrations returns the running migrations and whether they freeze the rollouts.
+func (o *MigrationOrdering) freezingMigrations(desiredConfig string) ([]string, bool, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if err := o.restore(); err != nil {
+		return nil, false, err
+	}
+	if len(o.migrating) == 0 || desiredConfig != o.migratingConfig {
+		return nil, false, nil
+	}
+	var resources []string
+	frozen := false
+	for gr, start := range o.migrating {
+		resources = append(resources, gr.String())
+		if o.now().Sub(start) < maxRolloutFreezeDuringMigration {
+			frozen = true
+		}
+	}
+	sort.Strings(resources)
+	return resources, frozen, nil
+}
+
+func (o *MigrationOrdering) heldMigrations() []string {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	var resources []string
+	for gr := range o.held {
+		resources = append(resources, gr.String())
+	}
+	sort.Strings(resources)
+	return resources
+}
+
+func (o *MigrationOrdering) clearHeld() {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.held = map[schema.GroupResource]bool{}
+}
+
+func (o *MigrationOrdering) isMigrating(gr schema.GroupResource) (bool, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if err := o.restore(); err != nil {
+		return false, err
+	}
+	_, ok := o.migrating[gr]
+	return ok, nil
+}
+
+func (o *MigrationOrdering) setHeld(gr schema.GroupResource) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.held[gr] = true
+}
+
+func (o *MigrationOrdering) setMigrating(gr schema.GroupResource, desiredConfig string) error {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if err := o.restore(); err != nil {
+		return err
+	}
+	delete(o.held, gr)
+	if _, ok := o.migrating[gr]; ok {
+		return nil
+	}
+	if len(o.migrating) == 0 {
+		o.migratingConfig = desiredConfig
+	}
+	o.migrating[gr] = o.now()
+	return o.persist()
+}
+
+func (o *MigrationOrdering) setDone(gr schema.GroupResource) error {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if err := o.restore(); err != nil {
+		return err
+	}
+	delete(o.held, gr)
+	if _, ok := o.migrating[gr]; !ok {
+		return nil
+	}
+	delete(o.migrating, gr)
+	return o.persist()
+}
+
+// restore reads the running migrations persisted by a previous operator process, once. The lock must be held.
+func (o *MigrationOrdering) restore() error {
+	if o.restored {
+		return nil
+	}
+	configMap, err := o.configMapClient.ConfigMaps(operatorclient.OperatorNamespace).Get(context.TODO(), MigrationOrderingConfigMapName, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		for key, value := range configMap.Data {
+			if key == migratingConfigKey {
+				o.migratingConfig = value
+				continue
+			}
+			start, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				klog.Warningf("Ignoring the invalid start %q of the %s storage migration in %s/%s: %v", value, key, operatorclient.OperatorNamespace, MigrationOrderingConfigMapName, err)
+				continue
+			}
+			o.migrating[schema.ParseGroupResource(key)] = start
+		}
+	}
+	o.restored = true
+	return nil
+}
+
+// persist writes the running migrations to the configmap. The lock must be held.
+func (o *MigrationOrdering) persist() error {
+	data := map[string]string{}
+	if len(o.migrating) > 0 {
+		data[migratingConfigKey] = o.migratingConfig
+	}
+	for gr, start := range o.migrating {
+		data[gr.String()] = start.UTC().Format(time.RFC3339)
+	}
+	_, _, err := resourceapply.ApplyConfigMap(context.TODO(), o.configMapClient, o.recorder, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.OperatorNamespace, Name: MigrationOrderingConfigMapName},
+		Data:       data,
+	})
+	return err
+}
+
+// orderedMigrator does not start migrations while a revision rolls out and records the running ones.
+type orderedMigrator struct {
+	migrators.Migrator
+	ordering *MigrationOrdering
+}
+
+func (m *orderedMigrator) EnsureMigration(gr schema.GroupResource, writeKey string) (bool, error, time.Time, error) {
+	migrating, err := m.ordering.isMigrating(gr)
+	if err != nil {
+		return false, nil, time.Time{}, err
+	}
+	if !migrating {
+		rollingOut, err := m.ordering.revisionRollingOut()
+		if err != nil {
+			return false, nil, time.Time{}, err
+		}
+		if rollingOut {
+			// not finished, the migration controller retries
+			m.ordering.setHeld(gr)
+			return false, nil, time.Time{}, nil
+		}
+	}
+
+	finished, result, ts, err := m.Migrator.EnsureMigration(gr, writeKey)
+	if err != nil {
+		return finished, result, ts, err
+	}
+	if finished {
+		return finished, result, ts, m.ordering.setDone(gr)
+	}
+	desiredConfig, err := m.ordering.desiredConfigHash()
+	if err != nil {
+		klog.Warningf("Unable to read the desired encryption config: %v", err)
+	}
+	return finished, result, ts, m.ordering.setMigrating(gr, desiredConfig)
+}
+
+func (m *orderedMigrator) PruneMigration(gr schema.GroupResource) error {
+	if err := m.ordering.setDone(gr); err != nil {
+		return err
+	}
+	return m.Migrator.PruneMigration(gr)
+}
@@ -0,0 +1,177 @@
+package encryptionmigration
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/encryption/controllers/migrators"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/rolloutfreeze"
+)
+
+func TestMigrationOrdering(t *testing.T) {
+	operatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+		&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+		&operatorv1.StaticPodOperatorStatus{},
+		nil,
+		nil,
+	)
+	secretIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	setDesiredConfig := func(config string) {
+		t.Helper()
+		if err := secretIndexer.Update(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: desiredEncryptionConfigName},
+			Data:       map[string][]byte{"encryption-config": []byte(config)},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	setDesiredConfig("key-2")
+
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	kubeClient := fake.NewSimpleClientset()
+	delegate := &fakeMigrator{finished: map[schema.GroupResource]bool{}, failed: map[schema.GroupResource]error{}}
+	var ordering *MigrationOrdering
+	var migrator migrators.Migrator
+	var isFrozen rolloutfreeze.FreezeFunc
+	// restart starts over with a new operator process
+	restart := func() {
+		ordering = &MigrationOrdering{
+			operatorClient:  operatorClient,
+			secretLister:    corev1listers.NewSecretLister(secretIndexer).Secrets(operatorclient.GlobalMachineSpecifiedConfigNamespace),
+			configMapClient: kubeClient.CoreV1(),
+			recorder:        events.NewInMemoryRecorder("test"),
+			now:             func() time.Time { return now },
+			migrating:       map[schema.GroupResource]time.Time{},
+			held:            map[schema.GroupResource]bool{},
+		}
+		migrator = ordering.Migrator(delegate)
+		isFrozen = ordering.FreezeFunc()
+	}
+	restart()
+
+	setRevisions := func(latest int32, current ...int32) {
+		t.Helper()
+		status := &operatorv1.StaticPodOperatorStatus{LatestAvailableRevision: latest}
+		for i, revision := range current {
+			status.NodeStatuses = append(status.NodeStatuses, operatorv1.NodeStatus{NodeName: string(rune('a' + i)), CurrentRevision: revision})
+		}
+		_, _, resourceVersion, err := operatorClient.GetStaticPodOperatorState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := operatorClient.UpdateStaticPodOperatorStatus(resourceVersion, status); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ensureMigration := func(gr schema.GroupResource, expectFinished bool) {
+		t.Helper()
+		finished, result, _, err := migrator.EnsureMigration(gr, "key-2")
+		if err != nil || result != nil {
+			t.Fatalf("unexpected migration error %v, result %v", err, result)
+		}
+		if finished != expectFinished {
+			t.Fatalf("expected the migration of %s finished %v, got %v", gr, expectFinished, finished)
+		}
+	}
+	expectCondition := func(status operatorv1.ConditionStatus, reason string) {
+		t.Helper()
+		if err := ordering.sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+			t.Fatal(err)
+		}
+		_, operatorStatus, _, err := operatorClient.GetStaticPodOperatorState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		condition := v1helpers.FindOperatorCondition(operatorStatus.Conditions, EncryptionMigrationOrderingConditionType)
+		if condition == nil || condition.Status != status || condition.Reason != reason {
+			t.Fatalf("expected condition %s with reason %s, got %#v", status, reason, condition)
+		}
+	}
+	expectFrozen := func(expected bool) {
+		t.Helper()
+		reason, frozen := isFrozen()
+		if frozen != expected {
+			t.Fatalf("expected rollouts frozen %v, got %v (%q)", expected, frozen, reason)
+		}
+		if frozen && !strings.Contains(reason, "secrets") {
+			t.Errorf("expected the migrated resource in the reason, got %q", reason)
+		}
+	}
+
+	// the new key is pending together with an unrelated config change in revision 5
+	setRevisions(5, 4, 5, 5)
+	ensureMigration(secrets, false)
+	if delegate.ensured != 0 {
+		t.Fatalf("expected the migration not to start during the rollout, got %d calls", delegate.ensured)
+	}
+	expectFrozen(false)
+	expectCondition(operatorv1.ConditionTrue, MigrationWaitingForRolloutReason)
+
+	// revision 5 is rolled out, the migration starts and holds back new revisions
+	setRevisions(5, 5, 5, 5)
+	ensureMigration(secrets, false)
+	if delegate.ensured != 1 {
+		t.Fatalf("expected the migration to start, got %d calls", delegate.ensured)
+	}
+	expectFrozen(true)
+	expectCondition(operatorv1.ConditionTrue, RolloutWaitingForMigrationReason)
+
+	// a running migration is not held by a rollout, a new one is
+	setRevisions(6, 5, 5, 5)
+	ensureMigration(secrets, false)
+	ensureMigration(configMaps, false)
+	if delegate.ensured != 2 {
+		t.Fatalf("expected only the running migration to be ensured, got %d calls", delegate.ensured)
+	}
+	setRevisions(5, 5, 5, 5)
+
+	// a restarted operator keeps the running migration and the time it started
+	now = now.Add(maxRolloutFreezeDuringMigration / 2)
+	restart()
+	expectFrozen(true)
+	expectCondition(operatorv1.ConditionTrue, RolloutWaitingForMigrationReason)
+	now = now.Add(maxRolloutFreezeDuringMigration / 2)
+	expectFrozen(false)
+	now = now.Add(-maxRolloutFreezeDuringMigration)
+
+	// a stuck migration does not freeze rollouts forever
+	now = now.Add(maxRolloutFreezeDuringMigration)
+	expectFrozen(false)
+	now = now.Add(-maxRolloutFreezeDuringMigration)
+	expectFrozen(true)
+
+	// a new desired encryption config has to roll out, it supersedes the migration
+	setDesiredConfig("key-3")
+	expectFrozen(false)
+	setDesiredConfig("key-2")
+	expectFrozen(true)
+
+	// the migration finishes
+	delegate.finished[secrets] = true
+	ensureMigration(secrets, true)
+	expectFrozen(false)
+	expectCondition(operatorv1.ConditionFalse, AsExpectedReason)
+	configMap, err := kubeClient.CoreV1().ConfigMaps(operatorclient.OperatorNamespace).Get(context.TODO(), MigrationOrderingConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configMap.Data) > 0 {
+		t.Errorf("expected no running migration to be persisted, got %v", configMap.Data)
+	}
+	restart()
+	expectFrozen(false)
+}
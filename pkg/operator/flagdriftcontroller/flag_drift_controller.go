@@ -0,0 +1,172 @@
+package flagdriftcontroller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+const (
+	FlagDriftDegradedConditionType = "KubeAPIServerFlagDriftDegraded"
+
+	FlagsDriftedReason = "FlagsDrifted"
+	AsExpectedReason   = "AsExpected"
+
+	kubeAPIServerContainerName = "kube-apiserver"
+)
+
+var kubeAPIServerPodSelector = labels.SelectorFromSet(labels.Set{"app": "openshift-kube-apiserver"})
+
+// FlagDriftController compares the command line of the kube-apiserver container of each running kube-apiserver pod
+// with the one of the pod manifest of its revision, the pod.yaml of the kube-apiserver-pod-<revision> configmap. The
+// mirror pods reflect the manifests on the nodes, a difference means the manifest was edited by hand or not fully
+// written. The drifted flags are listed in the KubeAPIServerFlagDriftDegraded condition. The drift is not reverted:
+// the installer only writes the manifest of a new revision, forcing a redeployment reinstalls the expected one.
+type FlagDriftController struct {
+	factory.Controller
+	operatorClient  v1helpers.OperatorClient
+	podLister       corev1listers.PodNamespaceLister
+	configMapLister corev1listers.ConfigMapNamespaceLister
+}
+
+func NewFlagDriftController(
+	operatorClient v1helpers.OperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	recorder events.Recorder,
+) *FlagDriftController {
+	informers := kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1()
+	c := &FlagDriftController{
+		operatorClient:  operatorClient,
+		podLister:       informers.Pods().Lister().Pods(operatorclient.TargetNamespace),
+		configMapLister: informers.ConfigMaps().Lister().ConfigMaps(operatorclient.TargetNamespace),
+	}
+	c.Controller = factory.New().
+		WithSync(c.sync).
+		WithInformers(operatorClient.Informer(), informers.Pods().Informer(), informers.ConfigMaps().Informer()).
+		ToController("FlagDriftController", recorder.WithComponentSuffix("flag-drift-controller"))
+	return c
+}
+
+func (c *FlagDriftController) sync(_ context.Context, _ factory.SyncContext) error {
+	operatorSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+
+	pods, err := c.podLister.List(kubeAPIServerPodSelector)
+	if err != nil {
+		return err
+	}
+	var drifted []string
+	for _, pod := range pods {
+		revision := pod.Labels["revision"]
+		if len(revision) == 0 {
+			continue
+		}
+		expected, err := c.expectedPod(revision)
+		if apierrors.IsNotFound(err) {
+			// pruned, or not created yet
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if diff := flagDrift(containerFlags(expected), containerFlags(pod)); len(diff) > 0 {
+			drifted = append(drifted, fmt.Sprintf("%s (revision %s): %s", pod.Spec.NodeName, revision, strings.Join(diff, ", ")))
+		}
+	}
+	sort.Strings(drifted)
+
+	condition := operatorv1.OperatorCondition{
+		Type:   FlagDriftDegradedConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: AsExpectedReason,
+	}
+	if len(drifted) > 0 {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = FlagsDriftedReason
+		condition.Message = fmt.Sprintf("The kube-apiserver command line differs from the manifest of its revision, force a redeployment to reinstall it: %s", strings.Join(drifted, "; "))
+	}
+
+	_, _, err = v1helpers.UpdateStatus(c.operatorClient, v1helpers.UpdateConditionFn(condition))
+	return err
+}
+
+// expectedPod returns the pod manifest of the revision.
+func (c *FlagDriftController) expectedPod(revision string) (*corev1.Pod, error) {
+	configMap, err := c.configMapLister.Get("kube-apiserver-pod-" + revision)
+	if err != nil {
+		return nil, err
+	}
+	pod, err := resourceread.ReadPodV1([]byte(configMap.Data["pod.yaml"]))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode the pod manifest of revision %s: %v", revision, err)
+	}
+	return pod, nil
+}
+
+// containerFlags returns the flags passed on the command line of the kube-apiserver container, by name. The command
+// is a shell script, the flags are its words starting with a dash. A flag repeated with different values is kept
+// with its values joined in order.
+func containerFlags(pod *corev1.Pod) map[string]string {
+	flags := map[string]string{}
+	for _, container := range pod.Spec.Containers {
+		if container.Name != kubeAPIServerContainerName {
+			continue
+		}
+		for _, arg := range append(append([]string{}, container.Command...), container.Args...) {
+			for _, word := range strings.Fields(arg) {
+				if !strings.HasPrefix(word, "-") || strings.Trim(word, "-") == "" {
+					continue
+				}
+				name, value := word, ""
+				if i := strings.Index(word, "="); i > 0 {
+					name, value = word[:i], word[i+1:]
+				}
+				if existing, ok := flags[name]; ok && existing != value {
+					value = existing + "," + value
+				}
+				flags[name] = value
+			}
+		}
+	}
+	return flags
+}
+
+// flagDrift returns the sorted flags that are missing, unexpected or set to a different value.
+func flagDrift(expected, actual map[string]string) []string {
+	var drift []string
+	for name, value := range expected {
+		actualValue, ok := actual[name]
+		switch {
+		case !ok:
+			drift = append(drift, fmt.Sprintf("%s missing", name))
+		case actualValue != value:
+			drift = append(drift, fmt.Sprintf("%s changed", name))
+		}
+	}
+	for name := range actual {
+		if _, ok := expected[name]; !ok {
+			drift = append(drift, fmt.Sprintf("%s unexpected", name))
+		}
+	}
+	sort.Strings(drift)
+	return drift
+}
@@ -0,0 +1,121 @@
+package flagdriftcontroller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const expectedScript = `exec watch-termination --graceful-termination-duration=135s -- hyperkube kube-apiserver --openshift-config=/etc/kubernetes/static-pod-resources/configmaps/config/config.yaml --advertise-address=${HOST_IP} -v=2 --permit-address-sharing`
+
+func kubeAPIServerPod(name, nodeName, revision, script string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-kube-apiserver",
+			Name:      name,
+			Labels:    map[string]string{"app": "openshift-kube-apiserver", "revision": revision},
+		},
+		Spec: corev1.PodSpec{
+			NodeName: nodeName,
+			Containers: []corev1.Container{
+				{Name: "kube-apiserver", Command: []string{"/bin/bash", "-ec"}, Args: []string{script}},
+				{Name: "kube-apiserver-check-endpoints", Args: []string{"--listen=0.0.0.0:17697"}},
+			},
+		},
+	}
+}
+
+func revisionPodConfigMap(revision string) *corev1.ConfigMap {
+	manifest := kubeAPIServerPod("kube-apiserver", "", revision, expectedScript)
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-kube-apiserver", Name: "kube-apiserver-pod-" + revision},
+		Data:       map[string]string{"pod.yaml": resourceread.WritePodV1OrDie(manifest)},
+	}
+}
+
+func TestFlagDriftController(t *testing.T) {
+	tests := []struct {
+		name            string
+		pods            []*corev1.Pod
+		expectedStatus  operatorv1.ConditionStatus
+		expectedMessage []string
+	}{
+		{
+			name: "matching pod spec",
+			pods: []*corev1.Pod{
+				kubeAPIServerPod("kube-apiserver-master-0", "master-0", "3", expectedScript),
+			},
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name: "drifted pod spec",
+			pods: []*corev1.Pod{
+				kubeAPIServerPod("kube-apiserver-master-0", "master-0", "3", expectedScript),
+				kubeAPIServerPod("kube-apiserver-master-1", "master-1", "3",
+					strings.Replace(strings.Replace(expectedScript, "-v=2", "-v=8 --enable-admission-plugins=Foo", 1), " --permit-address-sharing", "", 1)),
+			},
+			expectedStatus: operatorv1.ConditionTrue,
+			expectedMessage: []string{
+				"master-1 (revision 3): --enable-admission-plugins unexpected, --permit-address-sharing missing, -v changed",
+			},
+		},
+		{
+			name: "the manifest of the revision is gone",
+			pods: []*corev1.Pod{
+				kubeAPIServerPod("kube-apiserver-master-0", "master-0", "2", "hyperkube kube-apiserver"),
+			},
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			podIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for _, pod := range test.pods {
+				if err := podIndexer.Add(pod); err != nil {
+					t.Fatal(err)
+				}
+			}
+			configMapIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			if err := configMapIndexer.Add(revisionPodConfigMap("3")); err != nil {
+				t.Fatal(err)
+			}
+			operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+			c := &FlagDriftController{
+				operatorClient:  operatorClient,
+				podLister:       corev1listers.NewPodLister(podIndexer).Pods("openshift-kube-apiserver"),
+				configMapLister: corev1listers.NewConfigMapLister(configMapIndexer).ConfigMaps("openshift-kube-apiserver"),
+			}
+			if err := c.sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+				t.Fatal(err)
+			}
+
+			_, status, _, err := operatorClient.GetOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			condition := v1helpers.FindOperatorCondition(status.Conditions, FlagDriftDegradedConditionType)
+			if condition == nil || condition.Status != test.expectedStatus {
+				t.Fatalf("expected condition status %q, got %#v", test.expectedStatus, condition)
+			}
+			for _, expected := range test.expectedMessage {
+				if !strings.Contains(condition.Message, expected) {
+					t.Errorf("expected %q in the message %q", expected, condition.Message)
+				}
+			}
+			if strings.Contains(condition.Message, "master-0") {
+				t.Errorf("expected master-0 not to be reported, got %q", condition.Message)
+			}
+		})
+	}
+}
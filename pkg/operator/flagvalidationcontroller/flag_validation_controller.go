@@ -0,0 +1,188 @@
+package flagvalidationcontroller
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/ghodss/yaml"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/bindata"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/rolloutfreeze"
+)
+
+const (
+	FlagValidationDegradedConditionType = "FlagValidationControllerDegraded"
+
+	UnknownFlagsReason   = "UnknownFlags"
+	NoFlagManifestReason = "NoFlagManifest"
+	AsExpectedReason     = "AsExpected"
+
+	// configMapName is the rendered config that is copied into the next revision.
+	configMapName = "config"
+	configKey     = "config.yaml"
+)
+
+// FlagValidationController checks the flags rendered into the kube-apiserver config against the flags known to the
+// kube-apiserver binary of the target version, listed in an embedded manifest per minor version. A flag the binary
+// does not know would crashloop the new revision, so the rendered config is reported Degraded and its FreezeFunc holds
+// new revisions back until the flag is removed. Without a manifest for the target version the flags are not validated.
+type FlagValidationController struct {
+	factory.Controller
+	operatorClient   v1helpers.StaticPodOperatorClient
+	configMapLister  corev1listers.ConfigMapLister
+	apiServerVersion semver.Version
+	// knownFlags is nil without a manifest for apiServerVersion.
+	knownFlags sets.String
+}
+
+func NewFlagValidationController(
+	apiServerVersion semver.Version,
+	operatorClient v1helpers.StaticPodOperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	recorder events.Recorder,
+) (*FlagValidationController, error) {
+	knownFlags, err := KnownFlags(apiServerVersion)
+	if err != nil {
+		return nil, err
+	}
+	c := &FlagValidationController{
+		operatorClient:   operatorClient,
+		configMapLister:  kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().ConfigMaps().Lister(),
+		apiServerVersion: apiServerVersion,
+		knownFlags:       knownFlags,
+	}
+	c.Controller = factory.New().
+		WithInformers(
+			operatorClient.Informer(),
+			kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().ConfigMaps().Informer(),
+		).
+		WithSync(c.sync).
+		ToController("FlagValidationController", recorder.WithComponentSuffix("flag-validation-controller"))
+	return c, nil
+}
+
+// KnownFlags returns the flags of the kube-apiserver binary of the given version from the embedded manifest, nil if
+// there is no manifest for the minor version.
+func KnownFlags(apiServerVersion semver.Version) (sets.String, error) {
+	manifest, err := bindata.Asset(fmt.Sprintf("assets/kube-apiserver-flags/v%d.%d.txt", apiServerVersion.Major, apiServerVersion.Minor))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	knownFlags := sets.NewString()
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		knownFlags.Insert(line)
+	}
+	return knownFlags, scanner.Err()
+}
+
+// UnknownFlags returns the sorted apiServerArguments of the rendered config missing from the known flags.
+func UnknownFlags(config []byte, knownFlags sets.String) ([]string, error) {
+	renderedConfig := struct {
+		APIServerArguments map[string]interface{} `json:"apiServerArguments"`
+	}{}
+	if err := yaml.Unmarshal(config, &renderedConfig); err != nil {
+		return nil, err
+	}
+	unknownFlags := sets.NewString()
+	for flag := range renderedConfig.APIServerArguments {
+		if !knownFlags.Has(flag) {
+			unknownFlags.Insert(flag)
+		}
+	}
+	return unknownFlags.List(), nil
+}
+
+// FreezeFunc freezes rollouts while the rendered config has flags unknown to the kube-apiserver binary.
+func (c *FlagValidationController) FreezeFunc() rolloutfreeze.FreezeFunc {
+	return func() (string, bool) {
+		unknownFlags, err := c.unknownFlags()
+		if err != nil {
+			klog.Warningf("Unable to validate the kube-apiserver flags: %v", err)
+			return "", false
+		}
+		if len(unknownFlags) == 0 {
+			return "", false
+		}
+		return fmt.Sprintf("the kube-apiserver %d.%d binary does not know the flags %s", c.apiServerVersion.Major, c.apiServerVersion.Minor, strings.Join(unknownFlags, ", ")), true
+	}
+}
+
+// unknownFlags returns the flags of the rendered config unknown to the kube-apiserver binary, none if they cannot be
+// validated.
+func (c *FlagValidationController) unknownFlags() ([]string, error) {
+	if c.knownFlags == nil {
+		return nil, nil
+	}
+	configMap, err := c.configMapLister.ConfigMaps(operatorclient.TargetNamespace).Get(configMapName)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	unknownFlags, err := UnknownFlags([]byte(configMap.Data[configKey]), c.knownFlags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s of the %s/%s configmap: %w", configKey, operatorclient.TargetNamespace, configMapName, err)
+	}
+	return unknownFlags, nil
+}
+
+func (c *FlagValidationController) sync(_ context.Context, syncCtx factory.SyncContext) error {
+	operatorSpec, operatorStatus, _, err := c.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+
+	condition := operatorv1.OperatorCondition{
+		Type:   FlagValidationDegradedConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: AsExpectedReason,
+	}
+	if c.knownFlags == nil {
+		condition.Reason = NoFlagManifestReason
+		condition.Message = fmt.Sprintf("There is no flag manifest for kube-apiserver %d.%d, the rendered flags are not validated", c.apiServerVersion.Major, c.apiServerVersion.Minor)
+	}
+	unknownFlags, err := c.unknownFlags()
+	if err != nil {
+		return err
+	}
+	if len(unknownFlags) > 0 {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = UnknownFlagsReason
+		condition.Message = fmt.Sprintf("New revisions are held back, the kube-apiserver %d.%d binary does not know the rendered flags: %s", c.apiServerVersion.Major, c.apiServerVersion.Minor, strings.Join(unknownFlags, ", "))
+	}
+
+	if existing := v1helpers.FindOperatorCondition(operatorStatus.Conditions, FlagValidationDegradedConditionType); condition.Status == operatorv1.ConditionTrue && (existing == nil || existing.Message != condition.Message) {
+		syncCtx.Recorder().Warningf("UnknownKubeAPIServerFlags", "%s", condition.Message)
+	}
+
+	_, _, err = v1helpers.UpdateStaticPodStatus(c.operatorClient, v1helpers.UpdateStaticPodConditionFn(condition))
+	return err
+}
@@ -0,0 +1,142 @@
+package flagvalidationcontroller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/bindata"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+var supportedVersion = semver.MustParse("1.22.1")
+
+func TestDefaultConfigFlagsAreKnown(t *testing.T) {
+	knownFlags, err := KnownFlags(supportedVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if knownFlags == nil {
+		t.Fatalf("expected a flag manifest for %v", supportedVersion)
+	}
+	for _, asset := range []string{"assets/config/defaultconfig.yaml", "assets/config/config-overrides.yaml"} {
+		unknownFlags, err := UnknownFlags(bindata.MustAsset(asset), knownFlags)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(unknownFlags) > 0 {
+			t.Errorf("%s renders flags missing from the manifest: %v", asset, unknownFlags)
+		}
+	}
+}
+
+func TestFlagValidation(t *testing.T) {
+	testCases := []struct {
+		name             string
+		apiServerVersion semver.Version
+		config           string
+		expectedStatus   operatorv1.ConditionStatus
+		expectedReason   string
+		expectedFrozen   bool
+	}{
+		{
+			name:             "known flags",
+			apiServerVersion: supportedVersion,
+			config:           string(bindata.MustAsset("assets/config/defaultconfig.yaml")),
+			expectedStatus:   operatorv1.ConditionFalse,
+			expectedReason:   AsExpectedReason,
+		},
+		{
+			name:             "unsupported flag",
+			apiServerVersion: supportedVersion,
+			config:           `{"apiServerArguments":{"authorization-config":["/etc/kubernetes/authz.yaml"],"authorization-mode":["RBAC","Node"]}}`,
+			expectedStatus:   operatorv1.ConditionTrue,
+			expectedReason:   UnknownFlagsReason,
+			expectedFrozen:   true,
+		},
+		{
+			name:             "no manifest for the version",
+			apiServerVersion: semver.MustParse("1.99.0"),
+			config:           `{"apiServerArguments":{"authorization-config":["/etc/kubernetes/authz.yaml"]}}`,
+			expectedStatus:   operatorv1.ConditionFalse,
+			expectedReason:   NoFlagManifestReason,
+		},
+		{
+			name:             "no rendered config yet",
+			apiServerVersion: supportedVersion,
+			expectedStatus:   operatorv1.ConditionFalse,
+			expectedReason:   AsExpectedReason,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			if len(tc.config) > 0 {
+				if err := indexer.Add(&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: configMapName},
+					Data:       map[string]string{configKey: tc.config},
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			knownFlags, err := KnownFlags(tc.apiServerVersion)
+			if err != nil {
+				t.Fatal(err)
+			}
+			operatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+				&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+				&operatorv1.StaticPodOperatorStatus{},
+				nil,
+				nil,
+			)
+			c := &FlagValidationController{
+				operatorClient:   operatorClient,
+				configMapLister:  corev1listers.NewConfigMapLister(indexer),
+				apiServerVersion: tc.apiServerVersion,
+				knownFlags:       knownFlags,
+			}
+			recorder := events.NewInMemoryRecorder("test")
+
+			if err := c.sync(context.TODO(), factory.NewSyncContext("test", recorder)); err != nil {
+				t.Fatal(err)
+			}
+
+			_, status, _, err := operatorClient.GetStaticPodOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			condition := v1helpers.FindOperatorCondition(status.Conditions, FlagValidationDegradedConditionType)
+			if condition == nil {
+				t.Fatalf("expected the %s condition", FlagValidationDegradedConditionType)
+			}
+			if condition.Status != tc.expectedStatus || condition.Reason != tc.expectedReason {
+				t.Errorf("expected %s/%s, got %s/%s: %s", tc.expectedStatus, tc.expectedReason, condition.Status, condition.Reason, condition.Message)
+			}
+			if tc.expectedFrozen && !strings.Contains(condition.Message, "authorization-config") {
+				t.Errorf("expected the unknown flag to be listed, got %q", condition.Message)
+			}
+			if tc.expectedFrozen && strings.Contains(condition.Message, "authorization-mode") {
+				t.Errorf("expected the known flag not to be listed, got %q", condition.Message)
+			}
+			if warned := len(recorder.Events()) > 0; warned != tc.expectedFrozen {
+				t.Errorf("expected a warning %v, got events %v", tc.expectedFrozen, recorder.Events())
+			}
+
+			reason, frozen := c.FreezeFunc()()
+			if frozen != tc.expectedFrozen {
+				t.Errorf("expected frozen %v, got %v: %s", tc.expectedFrozen, frozen, reason)
+			}
+		})
+	}
+}
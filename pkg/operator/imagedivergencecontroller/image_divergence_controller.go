@@ -0,0 +1,156 @@
+package imagedivergencecontroller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+const (
+	ImageDivergenceDegradedConditionType = "KubeAPIServerImageDivergenceDegraded"
+
+	ImagesDivergedReason = "ImagesDiverged"
+	AsExpectedReason     = "AsExpected"
+
+	// divergenceWindow is how long the nodes may run different kube-apiserver images, as they do while a new image
+	// is rolled out one node at a time. It matches the default timeout of a revision rollout.
+	divergenceWindow = 45 * time.Minute
+)
+
+var kubeAPIServerPodSelector = labels.SelectorFromSet(labels.Set{"app": "openshift-kube-apiserver"})
+
+// ImageDivergenceController sets KubeAPIServerImageDivergenceDegraded=True when the kube-apiserver containers of the
+// nodes have been running different image digests for longer than the divergence window, naming the nodes which do
+// not run the image most of the nodes run. The start of a divergence is not persisted: an operator restart restarts
+// the window.
+type ImageDivergenceController struct {
+	factory.Controller
+	operatorClient v1helpers.OperatorClient
+	podLister      corev1listers.PodLister
+	now            func() time.Time
+
+	// divergenceStart is when the images were first seen diverging, zero while they are converged.
+	divergenceStart time.Time
+}
+
+func NewImageDivergenceController(
+	operatorClient v1helpers.OperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	recorder events.Recorder,
+) *ImageDivergenceController {
+	podInformer := kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Pods()
+	c := &ImageDivergenceController{
+		operatorClient: operatorClient,
+		podLister:      podInformer.Lister(),
+		now:            time.Now,
+	}
+	// the window elapses without anything changing, resync to notice it
+	c.Controller = factory.New().
+		WithSync(c.sync).
+		WithInformers(operatorClient.Informer(), podInformer.Informer()).
+		ResyncEvery(time.Minute).
+		ToController("ImageDivergenceController", recorder.WithComponentSuffix("image-divergence-controller"))
+	return c
+}
+
+func (c *ImageDivergenceController) sync(_ context.Context, _ factory.SyncContext) error {
+	operatorSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+
+	pods, err := c.podLister.Pods(operatorclient.TargetNamespace).List(kubeAPIServerPodSelector)
+	if err != nil {
+		return err
+	}
+
+	condition := operatorv1.OperatorCondition{
+		Type:   ImageDivergenceDegradedConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: AsExpectedReason,
+	}
+	divergentNodes, image := divergentNodes(nodeImages(pods))
+	switch {
+	case len(divergentNodes) == 0:
+		c.divergenceStart = time.Time{}
+	case c.divergenceStart.IsZero():
+		c.divergenceStart = c.now()
+	case c.now().Sub(c.divergenceStart) > divergenceWindow:
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = ImagesDivergedReason
+		condition.Message = fmt.Sprintf("The kube-apiserver images have diverged for more than %v, nodes not running %s: %s", divergenceWindow, image, strings.Join(divergentNodes, ", "))
+	}
+
+	_, _, err = v1helpers.UpdateStatus(c.operatorClient, v1helpers.UpdateConditionFn(condition))
+	return err
+}
+
+// nodeImages returns the image digest of the running kube-apiserver container of each node. Nodes whose container has
+// not started yet are left out.
+func nodeImages(pods []*corev1.Pod) map[string]string {
+	images := map[string]string{}
+	for _, pod := range pods {
+		if len(pod.Spec.NodeName) == 0 {
+			continue
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name == "kube-apiserver" && len(status.ImageID) > 0 {
+				images[pod.Spec.NodeName] = imageDigest(status.ImageID)
+			}
+		}
+	}
+	return images
+}
+
+// imageDigest returns the sha256 digest of an image ID. The same image is reported with different references
+// depending on the runtime and the repository it was pulled from, only the digest identifies it. An image ID without
+// a digest is returned as is.
+func imageDigest(imageID string) string {
+	if i := strings.LastIndex(imageID, "@sha256:"); i >= 0 {
+		return imageID[i+1:]
+	}
+	return imageID
+}
+
+// divergentNodes returns the sorted names of the nodes not running the image most nodes run, with the image they
+// run, and that most common image. A tie is broken by the image name to be stable across syncs.
+func divergentNodes(images map[string]string) ([]string, string) {
+	counts := map[string]int{}
+	for _, image := range images {
+		counts[image]++
+	}
+	if len(counts) < 2 {
+		return nil, ""
+	}
+
+	var common string
+	for image, count := range counts {
+		if len(common) == 0 || count > counts[common] || (count == counts[common] && image < common) {
+			common = image
+		}
+	}
+	var nodes []string
+	for node, image := range images {
+		if image != common {
+			nodes = append(nodes, fmt.Sprintf("%s (running %s)", node, image))
+		}
+	}
+	sort.Strings(nodes)
+	return nodes, common
+}
@@ -0,0 +1,162 @@
+package imagedivergencecontroller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func kubeAPIServerPod(node, imageID string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-kube-apiserver",
+			Name:      "kube-apiserver-" + node,
+			Labels:    map[string]string{"app": "openshift-kube-apiserver"},
+		},
+		Spec: corev1.PodSpec{NodeName: node},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+			{Name: "kube-apiserver", ImageID: imageID},
+			{Name: "kube-apiserver-check-endpoints", ImageID: "quay.io/openshift/operator@sha256:ccc"},
+		}},
+	}
+}
+
+func TestImageDivergenceController(t *testing.T) {
+	tests := []struct {
+		name            string
+		pods            []*corev1.Pod
+		elapsed         time.Duration
+		expectedStatus  operatorv1.ConditionStatus
+		expectedMessage string
+	}{
+		{
+			name: "converged",
+			pods: []*corev1.Pod{
+				kubeAPIServerPod("master-0", "quay.io/openshift/hyperkube@sha256:aaa"),
+				kubeAPIServerPod("master-1", "quay.io/openshift/hyperkube@sha256:aaa"),
+				kubeAPIServerPod("master-2", "quay.io/openshift/hyperkube@sha256:aaa"),
+			},
+			elapsed:        time.Hour,
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name: "same digest from different references",
+			pods: []*corev1.Pod{
+				kubeAPIServerPod("master-0", "docker-pullable://quay.io/openshift/hyperkube@sha256:aaa"),
+				kubeAPIServerPod("master-1", "quay.io/openshift-release-dev/ocp-v4.0-art-dev@sha256:aaa"),
+				kubeAPIServerPod("master-2", "mirror.example.com/openshift/hyperkube@sha256:aaa"),
+			},
+			elapsed:        time.Hour,
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name: "diverged within the rollout window",
+			pods: []*corev1.Pod{
+				kubeAPIServerPod("master-0", "quay.io/openshift/hyperkube@sha256:bbb"),
+				kubeAPIServerPod("master-1", "quay.io/openshift/hyperkube@sha256:aaa"),
+				kubeAPIServerPod("master-2", "quay.io/openshift/hyperkube@sha256:aaa"),
+			},
+			elapsed:        10 * time.Minute,
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name: "diverged beyond the rollout window",
+			pods: []*corev1.Pod{
+				kubeAPIServerPod("master-2", "quay.io/openshift/hyperkube@sha256:bbb"),
+				kubeAPIServerPod("master-0", "quay.io/openshift/hyperkube@sha256:aaa"),
+				kubeAPIServerPod("master-1", "quay.io/openshift/hyperkube@sha256:aaa"),
+			},
+			elapsed:         time.Hour,
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedMessage: "The kube-apiserver images have diverged for more than 45m0s, nodes not running sha256:aaa: master-2 (running sha256:bbb)",
+		},
+		{
+			name: "three images",
+			pods: []*corev1.Pod{
+				kubeAPIServerPod("master-0", "quay.io/openshift/hyperkube@sha256:ccc"),
+				kubeAPIServerPod("master-1", "quay.io/openshift/hyperkube@sha256:bbb"),
+				kubeAPIServerPod("master-2", "quay.io/openshift/hyperkube@sha256:aaa"),
+			},
+			elapsed:         time.Hour,
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedMessage: "The kube-apiserver images have diverged for more than 45m0s, nodes not running sha256:aaa: master-0 (running sha256:ccc), master-1 (running sha256:bbb)",
+		},
+		{
+			name: "node still starting",
+			pods: []*corev1.Pod{
+				kubeAPIServerPod("master-0", ""),
+				kubeAPIServerPod("master-1", "quay.io/openshift/hyperkube@sha256:aaa"),
+			},
+			elapsed:        time.Hour,
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for _, pod := range tt.pods {
+				if err := indexer.Add(pod); err != nil {
+					t.Fatal(err)
+				}
+			}
+			operatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+				&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+				&operatorv1.StaticPodOperatorStatus{},
+				nil,
+				nil,
+			)
+			now := time.Now()
+			c := &ImageDivergenceController{
+				operatorClient: operatorClient,
+				podLister:      corev1listers.NewPodLister(indexer),
+				now:            func() time.Time { return now },
+			}
+			syncCtx := factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))
+
+			// the first sync sees the divergence start, the second one happens after the elapsed time
+			if err := c.sync(context.TODO(), syncCtx); err != nil {
+				t.Fatal(err)
+			}
+			now = now.Add(tt.elapsed)
+			if err := c.sync(context.TODO(), syncCtx); err != nil {
+				t.Fatal(err)
+			}
+
+			_, status, _, err := operatorClient.GetOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			condition := v1helpers.FindOperatorCondition(status.Conditions, ImageDivergenceDegradedConditionType)
+			if condition == nil || condition.Status != tt.expectedStatus || condition.Message != tt.expectedMessage {
+				t.Fatalf("expected condition %s with message %q, got %#v", tt.expectedStatus, tt.expectedMessage, condition)
+			}
+
+			// the condition clears once the images converge
+			for _, pod := range tt.pods {
+				if err := indexer.Update(kubeAPIServerPod(pod.Spec.NodeName, "quay.io/openshift/hyperkube@sha256:ddd")); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := c.sync(context.TODO(), syncCtx); err != nil {
+				t.Fatal(err)
+			}
+			_, status, _, err = operatorClient.GetOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if condition := v1helpers.FindOperatorCondition(status.Conditions, ImageDivergenceDegradedConditionType); condition.Status != operatorv1.ConditionFalse {
+				t.Errorf("expected the condition to clear once converged, got %#v", condition)
+			}
+		})
+	}
+}
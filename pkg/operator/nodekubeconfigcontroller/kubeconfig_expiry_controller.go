@@ -0,0 +1,146 @@
+package nodekubeconfigcontroller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+const (
+	KubeconfigCertsExpiringDegradedConditionType = "KubeconfigClientCertsExpiringDegraded"
+
+	ClientCertsExpiringReason = "ClientCertsExpiring"
+	AsExpectedReason          = "AsExpected"
+
+	// expiryThreshold is the remaining validity below which a client certificate is reported. The certificate of the
+	// node kubeconfigs is rotated when it has 90 days left, so 30 days left means the rotation failed for two months.
+	expiryThreshold = 30 * 24 * time.Hour
+)
+
+// managedKubeconfigSecrets are the secrets in the target namespace holding kubeconfigs generated by the operator,
+// one per key.
+var managedKubeconfigSecrets = []string{"node-kubeconfigs"}
+
+// KubeconfigExpiryController reports the remaining validity of the client certificates embedded in the managed
+// kubeconfigs in the kube_apiserver_operator_kubeconfig_client_cert_expiry_seconds metric, and sets
+// KubeconfigClientCertsExpiringDegraded=True when one of them is within the expiry threshold, until it is rotated.
+type KubeconfigExpiryController struct {
+	factory.Controller
+	operatorClient v1helpers.OperatorClient
+	secretLister   corev1listers.SecretLister
+	now            func() time.Time
+
+	// reported holds the namespace/name/kubeconfig of the series currently exposed by the metric, so that the
+	// series of removed kubeconfigs can be deleted.
+	reported sets.String
+}
+
+func NewKubeconfigExpiryController(
+	operatorClient v1helpers.OperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	recorder events.Recorder,
+) *KubeconfigExpiryController {
+	secretInformer := kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets()
+	c := &KubeconfigExpiryController{
+		operatorClient: operatorClient,
+		secretLister:   secretInformer.Lister(),
+		now:            time.Now,
+		reported:       sets.NewString(),
+	}
+	// the remaining validity decreases even when nothing changes, resync to keep the metric current
+	c.Controller = factory.New().
+		WithSync(c.sync).
+		WithInformers(operatorClient.Informer(), secretInformer.Informer()).
+		ResyncEvery(time.Minute).
+		ToController("KubeconfigExpiryController", recorder.WithComponentSuffix("kubeconfig-expiry-controller"))
+	return c
+}
+
+func (c *KubeconfigExpiryController) sync(_ context.Context, _ factory.SyncContext) error {
+	operatorSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+
+	var expiring []string
+	seen := sets.NewString()
+	for _, name := range managedKubeconfigSecrets {
+		secret, err := c.secretLister.Secrets(operatorclient.TargetNamespace).Get(name)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for key, data := range secret.Data {
+			notAfter, ok := clientCertNotAfter(data)
+			if !ok {
+				klog.V(4).Infof("No client certificate found in kubeconfig %s of secret %s/%s", key, secret.Namespace, secret.Name)
+				continue
+			}
+			remaining := notAfter.Sub(c.now())
+			kubeconfigClientCertExpirySecondsGauge.WithLabelValues(secret.Namespace, secret.Name, key).Set(remaining.Seconds())
+			seen.Insert(strings.Join([]string{secret.Namespace, secret.Name, key}, "/"))
+			if remaining < expiryThreshold {
+				expiring = append(expiring, fmt.Sprintf("%s/%s[%s] (expires %s)", secret.Namespace, secret.Name, key, notAfter.UTC().Format(time.RFC3339)))
+			}
+		}
+	}
+	for _, series := range c.reported.Difference(seen).UnsortedList() {
+		labels := strings.SplitN(series, "/", 3)
+		kubeconfigClientCertExpirySecondsGauge.Delete(map[string]string{"namespace": labels[0], "name": labels[1], "kubeconfig": labels[2]})
+	}
+	c.reported = seen
+
+	condition := operatorv1.OperatorCondition{
+		Type:   KubeconfigCertsExpiringDegradedConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: AsExpectedReason,
+	}
+	if len(expiring) > 0 {
+		sort.Strings(expiring)
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = ClientCertsExpiringReason
+		condition.Message = fmt.Sprintf("The client certificates of the kubeconfigs expire in less than %v and have not been rotated: %s", expiryThreshold, strings.Join(expiring, ", "))
+	}
+	_, _, err = v1helpers.UpdateStatus(c.operatorClient, v1helpers.UpdateConditionFn(condition))
+	return err
+}
+
+// clientCertNotAfter returns the earliest expiration of the client certificates embedded in the kubeconfig.
+func clientCertNotAfter(kubeconfig []byte) (time.Time, bool) {
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return time.Time{}, false
+	}
+	var notAfter time.Time
+	for _, authInfo := range config.AuthInfos {
+		certs, err := certutil.ParseCertsPEM(authInfo.ClientCertificateData)
+		if err != nil || len(certs) == 0 {
+			continue
+		}
+		if notAfter.IsZero() || certs[0].NotAfter.Before(notAfter) {
+			notAfter = certs[0].NotAfter
+		}
+	}
+	return notAfter, !notAfter.IsZero()
+}
@@ -0,0 +1,132 @@
+package nodekubeconfigcontroller
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+func kubeconfigWithClientCert(t *testing.T, validity time.Duration) []byte {
+	t.Helper()
+	signerConfig, err := crypto.MakeSelfSignedCAConfigForDuration("node-system-admin-signer", 365*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := &crypto.CA{Config: signerConfig, SerialGenerator: &crypto.RandomSerialGenerator{}}
+	clientCert, err := signer.MakeClientCertificateForDuration(&user.DefaultInfo{Name: "system:admin"}, validity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, keyPEM, err := clientCert.GetPEMBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []byte(fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: lb-ext
+  cluster:
+    server: https://api.example.com:6443
+contexts:
+- name: admin
+  context:
+    cluster: lb-ext
+    user: admin
+current-context: admin
+users:
+- name: admin
+  user:
+    client-certificate-data: %s
+    client-key-data: %s
+`, base64.StdEncoding.EncodeToString(certPEM), base64.StdEncoding.EncodeToString(keyPEM)))
+}
+
+func TestKubeconfigExpiryController(t *testing.T) {
+	registry := metrics.NewKubeRegistry()
+	registry.MustRegister(kubeconfigClientCertExpirySecondsGauge)
+	kubeconfigClientCertExpirySecondsGauge.Reset()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-kube-apiserver", Name: "node-kubeconfigs"},
+		Data: map[string][]byte{
+			"lb-ext.kubeconfig": kubeconfigWithClientCert(t, 10*24*time.Hour),
+			"lb-int.kubeconfig": kubeconfigWithClientCert(t, 90*24*time.Hour),
+		},
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	if err := indexer.Add(secret); err != nil {
+		t.Fatal(err)
+	}
+	operatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+		&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+		&operatorv1.StaticPodOperatorStatus{},
+		nil,
+		nil,
+	)
+	c := &KubeconfigExpiryController{
+		operatorClient: operatorClient,
+		secretLister:   corev1listers.NewSecretLister(indexer),
+		now:            time.Now,
+		reported:       sets.NewString(),
+	}
+	syncCtx := factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))
+	condition := func() *operatorv1.OperatorCondition {
+		t.Helper()
+		_, status, _, err := operatorClient.GetOperatorState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v1helpers.FindOperatorCondition(status.Conditions, KubeconfigCertsExpiringDegradedConditionType)
+	}
+
+	if err := c.sync(context.TODO(), syncCtx); err != nil {
+		t.Fatal(err)
+	}
+	for key, validity := range map[string]time.Duration{"lb-ext.kubeconfig": 10 * 24 * time.Hour, "lb-int.kubeconfig": 90 * 24 * time.Hour} {
+		actual, err := testutil.GetGaugeMetricValue(kubeconfigClientCertExpirySecondsGauge.WithLabelValues(secret.Namespace, secret.Name, key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// allow for the time elapsed since the certificate was created
+		if expected := validity.Seconds(); math.Abs(actual-expected) > 5 {
+			t.Errorf("expected the gauge of %s to be about %v, got %v", key, expected, actual)
+		}
+	}
+	if cond := condition(); cond == nil || cond.Status != operatorv1.ConditionTrue || !strings.Contains(cond.Message, "node-kubeconfigs[lb-ext.kubeconfig]") || strings.Contains(cond.Message, "lb-int") {
+		t.Fatalf("expected the near-expiry kubeconfig only to be reported, got %#v", cond)
+	}
+
+	// the near-expiry kubeconfig is rotated, the other one is removed
+	rotated := secret.DeepCopy()
+	rotated.Data = map[string][]byte{"lb-ext.kubeconfig": kubeconfigWithClientCert(t, 90*24*time.Hour)}
+	if err := indexer.Update(rotated); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.sync(context.TODO(), syncCtx); err != nil {
+		t.Fatal(err)
+	}
+	if cond := condition(); cond.Status != operatorv1.ConditionFalse {
+		t.Errorf("expected the condition to clear once rotated, got %#v", cond)
+	}
+	if c.reported.Has("openshift-kube-apiserver/node-kubeconfigs/lb-int.kubeconfig") {
+		t.Errorf("expected the series of the removed kubeconfig to be deleted")
+	}
+}
@@ -0,0 +1,24 @@
+package nodekubeconfigcontroller
+
+import (
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+var (
+	registerMetrics sync.Once
+
+	kubeconfigClientCertExpirySecondsGauge = metrics.NewGaugeVec(&metrics.GaugeOpts{
+		Name: "kube_apiserver_operator_kubeconfig_client_cert_expiry_seconds",
+		Help: "Report the number of seconds until the client certificate embedded in a kubeconfig managed by the operator expires",
+	}, []string{"namespace", "name", "kubeconfig"})
+)
+
+// RegisterMetrics registers the managed kubeconfig metrics with the legacy registry.
+func RegisterMetrics() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(kubeconfigClientCertExpirySecondsGauge)
+	})
+}
@@ -0,0 +1,217 @@
+package orphanedrevisioncontroller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/staticpod/controller/revision"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	coreclientv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+)
+
+const (
+	revisionStatusConfigMapName = "revision-status"
+
+	// defaultRevisionLimit matches the default of the library-go prune controller.
+	defaultRevisionLimit = 5
+)
+
+// OrphanedRevisionController deletes revisioned configmaps and secrets that the static pod pruning leaves behind.
+// Revision resources are owned by their revision-status configmap and garbage collected with it, but a revision
+// whose creation failed half way, or whose owner reference was lost, stays around forever. A revision resource is
+// deleted when it is not owned by a live revision-status configmap, its revision is older than the retained window
+// of the latest available revision, and no node is on it, on the revision before it, targeting it or failed on it.
+type OrphanedRevisionController struct {
+	targetNamespace string
+	configMaps      []string
+	secrets         []string
+
+	operatorClient  v1helpers.StaticPodOperatorClient
+	configMapLister corev1listers.ConfigMapNamespaceLister
+	secretLister    corev1listers.SecretNamespaceLister
+	configMapClient coreclientv1.ConfigMapsGetter
+	secretClient    coreclientv1.SecretsGetter
+}
+
+func NewOrphanedRevisionController(
+	targetNamespace string,
+	configMaps []revision.RevisionResource,
+	secrets []revision.RevisionResource,
+	operatorClient v1helpers.StaticPodOperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	configMapClient coreclientv1.ConfigMapsGetter,
+	secretClient coreclientv1.SecretsGetter,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &OrphanedRevisionController{
+		targetNamespace: targetNamespace,
+		operatorClient:  operatorClient,
+		configMapLister: kubeInformersForNamespaces.InformersFor(targetNamespace).Core().V1().ConfigMaps().Lister().ConfigMaps(targetNamespace),
+		secretLister:    kubeInformersForNamespaces.InformersFor(targetNamespace).Core().V1().Secrets().Lister().Secrets(targetNamespace),
+		configMapClient: configMapClient,
+		secretClient:    secretClient,
+	}
+	for _, cm := range configMaps {
+		c.configMaps = append(c.configMaps, cm.Name)
+	}
+	for _, s := range secrets {
+		c.secrets = append(c.secrets, s.Name)
+	}
+
+	return factory.New().
+		WithInformers(
+			operatorClient.Informer(),
+			kubeInformersForNamespaces.InformersFor(targetNamespace).Core().V1().ConfigMaps().Informer(),
+			kubeInformersForNamespaces.InformersFor(targetNamespace).Core().V1().Secrets().Informer(),
+		).
+		WithSync(c.sync).
+		ToController("OrphanedRevisionController", eventRecorder.WithComponentSuffix("orphaned-revision-controller"))
+}
+
+func (c *OrphanedRevisionController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	operatorSpec, operatorStatus, _, err := c.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+	if operatorStatus.LatestAvailableRevision == 0 {
+		return nil
+	}
+	limit, unlimited := revisionLimit(operatorSpec)
+	if unlimited {
+		return nil
+	}
+	protected := protectedRevisions(operatorStatus, limit)
+
+	var errs []error
+	configMaps, err := c.configMapLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, cm := range configMaps {
+		if !c.isOrphaned(cm.ObjectMeta, c.configMaps, operatorStatus.LatestAvailableRevision, protected) {
+			continue
+		}
+		err := c.configMapClient.ConfigMaps(c.targetNamespace).Delete(ctx, cm.Name, metav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to delete orphaned revision configmap %s/%s: %w", cm.Namespace, cm.Name, err))
+			continue
+		}
+		syncCtx.Recorder().Eventf("OrphanedRevisionConfigMapDeleted", "Deleted orphaned revision configmap %s/%s", cm.Namespace, cm.Name)
+	}
+
+	secrets, err := c.secretLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, s := range secrets {
+		if !c.isOrphaned(s.ObjectMeta, c.secrets, operatorStatus.LatestAvailableRevision, protected) {
+			continue
+		}
+		err := c.secretClient.Secrets(c.targetNamespace).Delete(ctx, s.Name, metav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to delete orphaned revision secret %s/%s: %w", s.Namespace, s.Name, err))
+			continue
+		}
+		syncCtx.Recorder().Eventf("OrphanedRevisionSecretDeleted", "Deleted orphaned revision secret %s/%s", s.Namespace, s.Name)
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// isOrphaned returns whether the object is a revision of one of the given resources that can be deleted.
+func (c *OrphanedRevisionController) isOrphaned(obj metav1.ObjectMeta, resourceNames []string, latestAvailableRevision int32, protected sets.Int32) bool {
+	revision, ok := revisionOf(obj.Name, resourceNames)
+	if !ok {
+		return false
+	}
+	if revision > latestAvailableRevision || protected.Has(revision) {
+		return false
+	}
+	return !c.ownedByRevisionStatus(obj, revision)
+}
+
+// ownedByRevisionStatus returns whether the object is owned by the live revision-status configmap of its revision.
+// These are garbage collected once the prune controller deletes the revision-status configmap.
+func (c *OrphanedRevisionController) ownedByRevisionStatus(obj metav1.ObjectMeta, revision int32) bool {
+	statusName := fmt.Sprintf("%s-%d", revisionStatusConfigMapName, revision)
+	status, err := c.configMapLister.Get(statusName)
+	if err != nil {
+		// not found or not readable, keep the object if a read error hides a live owner
+		return !apierrors.IsNotFound(err)
+	}
+	for _, ref := range obj.OwnerReferences {
+		if ref.Kind == "ConfigMap" && ref.Name == statusName && ref.UID == status.UID {
+			return true
+		}
+	}
+	return false
+}
+
+// revisionOf returns the revision of a revisioned copy of one of the resources, named <resource>-<revision>.
+func revisionOf(name string, resourceNames []string) (int32, bool) {
+	for _, resourceName := range resourceNames {
+		suffix := strings.TrimPrefix(name, resourceName+"-")
+		if suffix == name {
+			continue
+		}
+		revision, err := strconv.ParseInt(suffix, 10, 32)
+		if err != nil || revision <= 0 {
+			continue
+		}
+		return int32(revision), true
+	}
+	return 0, false
+}
+
+// revisionLimit returns the number of revisions retained below the latest available revision, the larger of the
+// failed and succeeded revision limits like the prune controller does.
+func revisionLimit(operatorSpec *operatorv1.StaticPodOperatorSpec) (limit int32, unlimited bool) {
+	failedLimit, succeededLimit := int32(defaultRevisionLimit), int32(defaultRevisionLimit)
+	if operatorSpec.FailedRevisionLimit != 0 {
+		failedLimit = operatorSpec.FailedRevisionLimit
+	}
+	if operatorSpec.SucceededRevisionLimit != 0 {
+		succeededLimit = operatorSpec.SucceededRevisionLimit
+	}
+	if failedLimit < 0 || succeededLimit < 0 {
+		return 0, true
+	}
+	if failedLimit > succeededLimit {
+		return failedLimit, false
+	}
+	return succeededLimit, false
+}
+
+// protectedRevisions returns the retained window, the latest available revision and the limit-1 revisions before
+// it, and the current, previous, target and last failed revisions of every node.
+func protectedRevisions(status *operatorv1.StaticPodOperatorStatus, limit int32) sets.Int32 {
+	protected := sets.NewInt32()
+	for revision := status.LatestAvailableRevision - limit + 1; revision <= status.LatestAvailableRevision; revision++ {
+		protected.Insert(revision)
+	}
+	for _, ns := range status.NodeStatuses {
+		protected.Insert(ns.CurrentRevision, ns.CurrentRevision-1, ns.TargetRevision, ns.LastFailedRevision)
+	}
+	return protected
+}
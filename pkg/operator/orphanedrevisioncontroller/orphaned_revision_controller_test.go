@@ -0,0 +1,200 @@
+package orphanedrevisioncontroller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+)
+
+const targetNamespace = "openshift-kube-apiserver"
+
+func revisionStatus(revision int32) *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Namespace: targetNamespace,
+		Name:      fmt.Sprintf("revision-status-%d", revision),
+		UID:       types.UID(fmt.Sprintf("revision-status-%d-uid", revision)),
+	}}
+}
+
+func ownedBy(status *corev1.ConfigMap) []metav1.OwnerReference {
+	return []metav1.OwnerReference{{APIVersion: "v1", Kind: "ConfigMap", Name: status.Name, UID: status.UID}}
+}
+
+func configMap(name string, ownerRefs []metav1.OwnerReference) *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: targetNamespace, Name: name, OwnerReferences: ownerRefs}}
+}
+
+func secret(name string, ownerRefs []metav1.OwnerReference) *corev1.Secret {
+	return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: targetNamespace, Name: name, OwnerReferences: ownerRefs}}
+}
+
+func TestOrphanedRevisionController(t *testing.T) {
+	revisionStatus4 := revisionStatus(4)
+	revisionStatus9 := revisionStatus(9)
+	revisionStatus10 := revisionStatus(10)
+	staleRevisionStatus := revisionStatus(5)
+	staleRevisionStatus.UID = "recreated"
+
+	tests := []struct {
+		name       string
+		spec       operatorv1.StaticPodOperatorSpec
+		status     operatorv1.StaticPodOperatorStatus
+		objects    []runtime.Object
+		expectedCM []string
+		expectedS  []string
+	}{
+		{
+			name: "orphans below the retained window are deleted",
+			status: operatorv1.StaticPodOperatorStatus{
+				LatestAvailableRevision: 10,
+				NodeStatuses: []operatorv1.NodeStatus{
+					{NodeName: "master-0", CurrentRevision: 3, TargetRevision: 10},
+					{NodeName: "master-1", CurrentRevision: 10},
+					{NodeName: "master-2", CurrentRevision: 10, LastFailedRevision: 1},
+				},
+			},
+			objects: []runtime.Object{
+				revisionStatus4, staleRevisionStatus, revisionStatus9, revisionStatus10,
+				// revision 1 failed on master-2
+				configMap("config-1", nil),
+				// previous revision of master-0
+				configMap("config-2", nil),
+				// current revision of master-0
+				configMap("config-3", nil),
+				configMap("kube-apiserver-pod-3", nil),
+				// left to the garbage collector
+				configMap("config-4", ownedBy(revisionStatus4)),
+				// owned by a revision-status configmap that was recreated
+				configMap("config-5", ownedBy(revisionStatus(5))),
+				// never made available, creation failed half way
+				configMap("kube-apiserver-pod-5", nil),
+				// the retained window, whether owned or not
+				configMap("config-6", nil),
+				configMap("config-9", ownedBy(revisionStatus9)),
+				configMap("config-10", ownedBy(revisionStatus10)),
+				// being created
+				configMap("config-11", nil),
+				// not revisions
+				configMap("config", nil),
+				configMap("config-backup", nil),
+				configMap("kube-apiserver-cert-syncer-kubeconfig", nil),
+				secret("etcd-client-5", nil),
+				secret("etcd-client-7", nil),
+				secret("etcd-client", nil),
+				secret("localhost-recovery-client-token-2", nil),
+			},
+			expectedCM: []string{"config-5", "kube-apiserver-pod-5"},
+			expectedS:  []string{"etcd-client-5"},
+		},
+		{
+			name: "the latest revisions are never deleted",
+			spec: operatorv1.StaticPodOperatorSpec{SucceededRevisionLimit: 2, FailedRevisionLimit: 3},
+			status: operatorv1.StaticPodOperatorStatus{
+				LatestAvailableRevision: 6,
+				NodeStatuses: []operatorv1.NodeStatus{
+					{NodeName: "master-0", CurrentRevision: 6},
+				},
+			},
+			objects: []runtime.Object{
+				configMap("config-2", nil),
+				configMap("config-3", nil),
+				configMap("config-4", nil),
+				configMap("config-5", nil),
+				configMap("config-6", nil),
+			},
+			expectedCM: []string{"config-2", "config-3"},
+		},
+		{
+			name: "nothing is deleted without a revision limit",
+			spec: operatorv1.StaticPodOperatorSpec{SucceededRevisionLimit: -1},
+			status: operatorv1.StaticPodOperatorStatus{
+				LatestAvailableRevision: 10,
+				NodeStatuses:            []operatorv1.NodeStatus{{NodeName: "master-0", CurrentRevision: 10}},
+			},
+			objects: []runtime.Object{configMap("config-1", nil)},
+		},
+		{
+			name: "nothing is deleted when unmanaged",
+			spec: operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Unmanaged}},
+			status: operatorv1.StaticPodOperatorStatus{
+				LatestAvailableRevision: 10,
+				NodeStatuses:            []operatorv1.NodeStatus{{NodeName: "master-0", CurrentRevision: 10}},
+			},
+			objects: []runtime.Object{configMap("config-1", nil)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if len(test.spec.ManagementState) == 0 {
+				test.spec.ManagementState = operatorv1.Managed
+			}
+			configMapIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			secretIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for _, obj := range test.objects {
+				indexer := configMapIndexer
+				if _, ok := obj.(*corev1.Secret); ok {
+					indexer = secretIndexer
+				}
+				if err := indexer.Add(obj); err != nil {
+					t.Fatal(err)
+				}
+			}
+			kubeClient := fake.NewSimpleClientset(test.objects...)
+
+			c := &OrphanedRevisionController{
+				targetNamespace: targetNamespace,
+				configMaps:      []string{"kube-apiserver-pod", "config", "kube-apiserver-cert-syncer-kubeconfig"},
+				secrets:         []string{"etcd-client", "localhost-recovery-client-token"},
+				operatorClient:  v1helpers.NewFakeStaticPodOperatorClient(&test.spec, &test.status, nil, nil),
+				configMapLister: corev1listers.NewConfigMapLister(configMapIndexer).ConfigMaps(targetNamespace),
+				secretLister:    corev1listers.NewSecretLister(secretIndexer).Secrets(targetNamespace),
+				configMapClient: kubeClient.CoreV1(),
+				secretClient:    kubeClient.CoreV1(),
+			}
+			recorder := events.NewInMemoryRecorder("test")
+			if err := c.sync(context.TODO(), factory.NewSyncContext("test", recorder)); err != nil {
+				t.Fatal(err)
+			}
+
+			deletedConfigMaps, deletedSecrets := sets.NewString(), sets.NewString()
+			for _, action := range kubeClient.Actions() {
+				deleteAction, ok := action.(clienttesting.DeleteAction)
+				if !ok {
+					t.Errorf("unexpected action %v", action)
+					continue
+				}
+				switch deleteAction.GetResource().Resource {
+				case "configmaps":
+					deletedConfigMaps.Insert(deleteAction.GetName())
+				case "secrets":
+					deletedSecrets.Insert(deleteAction.GetName())
+				}
+			}
+			if diff := cmp.Diff(sets.NewString(test.expectedCM...).List(), deletedConfigMaps.List()); diff != "" {
+				t.Errorf("unexpected deleted configmaps (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(sets.NewString(test.expectedS...).List(), deletedSecrets.List()); diff != "" {
+				t.Errorf("unexpected deleted secrets (-want +got):\n%s", diff)
+			}
+			if expected, got := len(test.expectedCM)+len(test.expectedS), len(recorder.Events()); expected != got {
+				t.Errorf("expected %d events, got %d", expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package resourcesynccontroller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coreclientv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+const (
+	etcdServingCAName = "etcd-serving-ca"
+	caBundleKey       = "ca-bundle.crt"
+)
+
+// EtcdServingCASyncController copies the etcd serving CA bundle from openshift-config into the target namespace.
+// etcd-serving-ca is a revisioned configmap, so every change of its content rolls out a new kube-apiserver revision.
+// Unlike the generic resource sync, the bundle is normalized before being compared and copied, so that an update
+// which only re-orders or duplicates the certificates does not cause a rollout.
+type EtcdServingCASyncController struct {
+	operatorClient  v1helpers.OperatorClient
+	configMapLister corev1listers.ConfigMapLister
+	configMapClient coreclientv1.ConfigMapsGetter
+}
+
+func NewEtcdServingCASyncController(
+	operatorClient v1helpers.OperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	configMapClient coreclientv1.ConfigMapsGetter,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &EtcdServingCASyncController{
+		operatorClient:  operatorClient,
+		configMapLister: kubeInformersForNamespaces.ConfigMapLister(),
+		configMapClient: configMapClient,
+	}
+
+	return factory.New().WithInformers(
+		operatorClient.Informer(),
+		kubeInformersForNamespaces.InformersFor(operatorclient.GlobalUserSpecifiedConfigNamespace).Core().V1().ConfigMaps().Informer(),
+		kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().ConfigMaps().Informer(),
+	).WithSync(c.sync).WithSyncDegradedOnError(operatorClient).ToController("EtcdServingCASyncController", eventRecorder.WithComponentSuffix("etcd-serving-ca-sync-controller"))
+}
+
+func (c *EtcdServingCASyncController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	operatorSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+
+	source, err := c.configMapLister.ConfigMaps(operatorclient.GlobalUserSpecifiedConfigNamespace).Get(etcdServingCAName)
+	if err != nil {
+		// never remove the synced bundle, the kube-apiserver cannot reach etcd without it
+		return fmt.Errorf("unable to get %s/%s: %w", operatorclient.GlobalUserSpecifiedConfigNamespace, etcdServingCAName, err)
+	}
+	normalized, err := normalizeCABundle([]byte(source.Data[caBundleKey]))
+	if err != nil {
+		return fmt.Errorf("invalid %s/%s: %w", operatorclient.GlobalUserSpecifiedConfigNamespace, etcdServingCAName, err)
+	}
+
+	existing, err := c.configMapLister.ConfigMaps(operatorclient.TargetNamespace).Get(etcdServingCAName)
+	switch {
+	case err == nil:
+		// a bundle that cannot be parsed is replaced
+		if current, err := normalizeCABundle([]byte(existing.Data[caBundleKey])); err == nil && caBundleHash(current) == caBundleHash(normalized) {
+			return nil
+		}
+	case !apierrors.IsNotFound(err):
+		return err
+	}
+
+	required := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: etcdServingCAName},
+		Data:       map[string]string{caBundleKey: string(normalized)},
+	}
+	_, modified, err := resourceapply.ApplyConfigMap(ctx, c.configMapClient, syncCtx.Recorder(), required)
+	if err != nil {
+		return err
+	}
+	if modified {
+		syncCtx.Recorder().Eventf("EtcdServingCABundleChanged", "The etcd serving CA bundle changed (sha256 %s), a new revision will be rolled out", caBundleHash(normalized))
+	}
+	return nil
+}
+
+// normalizeCABundle returns the certificates of the bundle de-duplicated and sorted, so that two bundles
+// holding the same certificates are identical.
+func normalizeCABundle(bundle []byte) ([]byte, error) {
+	var certs [][]byte
+	seen := map[string]bool{}
+	for rest := bundle; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("unexpected %q PEM block", block.Type)
+		}
+		if seen[string(block.Bytes)] {
+			continue
+		}
+		seen[string(block.Bytes)] = true
+		certs = append(certs, block.Bytes)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found")
+	}
+
+	sort.Slice(certs, func(i, j int) bool { return bytes.Compare(certs[i], certs[j]) < 0 })
+	normalized := &bytes.Buffer{}
+	for _, cert := range certs {
+		if err := pem.Encode(normalized, &pem.Block{Type: "CERTIFICATE", Bytes: cert}); err != nil {
+			return nil, err
+		}
+	}
+	return normalized.Bytes(), nil
+}
+
+func caBundleHash(normalized []byte) string {
+	hash := sha256.Sum256(normalized)
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,171 @@
+package resourcesynccontroller
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+func newCACert(t *testing.T, name string) string {
+	ca, err := crypto.MakeSelfSignedCAConfigForDuration(name, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certBytes, keyBytes := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := ca.WriteCertConfig(certBytes, keyBytes); err != nil {
+		t.Fatal(err)
+	}
+	return certBytes.String()
+}
+
+func etcdServingCAConfigMap(namespace, bundle string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: etcdServingCAName},
+		Data:       map[string]string{caBundleKey: bundle},
+	}
+}
+
+func TestEtcdServingCASyncController(t *testing.T) {
+	signerA, signerB, signerC := newCACert(t, "etcd-signer-a"), newCACert(t, "etcd-signer-b"), newCACert(t, "etcd-signer-c")
+	normalizedAB, err := normalizeCABundle([]byte(signerA + signerB))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		name           string
+		sourceBundle   string
+		existingBundle string
+		expectUpdate   bool
+	}{
+		{
+			name:         "initial sync",
+			sourceBundle: signerA + signerB,
+			expectUpdate: true,
+		},
+		{
+			name:           "unchanged bundle",
+			sourceBundle:   signerA + signerB,
+			existingBundle: string(normalizedAB),
+		},
+		{
+			name:           "reordered and duplicated certificates",
+			sourceBundle:   signerB + signerA + signerB,
+			existingBundle: string(normalizedAB),
+		},
+		{
+			name:           "rotated bundle",
+			sourceBundle:   signerB + signerC,
+			existingBundle: string(normalizedAB),
+			expectUpdate:   true,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			objects := []runtime.Object{etcdServingCAConfigMap(operatorclient.GlobalUserSpecifiedConfigNamespace, scenario.sourceBundle)}
+			if len(scenario.existingBundle) > 0 {
+				objects = append(objects, etcdServingCAConfigMap(operatorclient.TargetNamespace, scenario.existingBundle))
+			}
+			for _, obj := range objects {
+				if err := indexer.Add(obj); err != nil {
+					t.Fatal(err)
+				}
+			}
+			kubeClient := fake.NewSimpleClientset(objects...)
+			recorder := events.NewInMemoryRecorder("test")
+
+			c := &EtcdServingCASyncController{
+				operatorClient:  v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil),
+				configMapLister: corev1listers.NewConfigMapLister(indexer),
+				configMapClient: kubeClient.CoreV1(),
+			}
+			if err := c.sync(context.TODO(), factory.NewSyncContext("test", recorder)); err != nil {
+				t.Fatal(err)
+			}
+
+			var writes []clienttesting.Action
+			for _, action := range kubeClient.Actions() {
+				if action.Matches("create", "configmaps") || action.Matches("update", "configmaps") {
+					writes = append(writes, action)
+				}
+			}
+			if !scenario.expectUpdate {
+				if len(writes) > 0 {
+					t.Fatalf("expected no write, got %v", writes)
+				}
+				return
+			}
+			if len(writes) != 1 {
+				t.Fatalf("expected exactly one write, got %v", writes)
+			}
+
+			synced, err := kubeClient.CoreV1().ConfigMaps(operatorclient.TargetNamespace).Get(context.TODO(), etcdServingCAName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected, err := normalizeCABundle([]byte(scenario.sourceBundle))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if synced.Data[caBundleKey] != string(expected) {
+				t.Errorf("expected the normalized source bundle to be synced, got %q", synced.Data[caBundleKey])
+			}
+			found := false
+			for _, ev := range recorder.Events() {
+				if ev.Reason == "EtcdServingCABundleChanged" {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("expected EtcdServingCABundleChanged event, got %v", recorder.Events())
+			}
+		})
+	}
+}
+
+func TestNormalizeCABundle(t *testing.T) {
+	signerA, signerB := newCACert(t, "etcd-signer-a"), newCACert(t, "etcd-signer-b")
+
+	ab, err := normalizeCABundle([]byte(signerA + signerB))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ba, err := normalizeCABundle([]byte(signerB + "\n" + signerA + signerA))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caBundleHash(ab) != caBundleHash(ba) {
+		t.Errorf("expected re-ordered bundles to hash the same")
+	}
+
+	a, err := normalizeCABundle([]byte(signerA))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caBundleHash(a) == caBundleHash(ab) {
+		t.Errorf("expected different bundles to hash differently")
+	}
+
+	if _, err := normalizeCABundle([]byte("not a certificate")); err == nil {
+		t.Errorf("expected an error for a bundle without certificates")
+	}
+}
@@ -0,0 +1,137 @@
+package resourcesynccontroller
+
+import (
+	"bytes"
+	"context"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coreclientv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	certutil "k8s.io/client-go/util/cert"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+const (
+	kubeletServingCAName = "kubelet-serving-ca"
+	// kubeletServingCASourceName is the CA bundle of the CSR signer of the kube-controller-manager, which signs the
+	// kubelet serving certificates.
+	kubeletServingCASourceName = "csr-controller-ca"
+)
+
+// KubeletServingCASyncController copies the CA bundle verifying the kubelet serving certificates from
+// openshift-config-managed into the target namespace, where the kube-apiserver reads it as
+// kubelet-certificate-authority. kubelet-serving-ca is a revisioned configmap, so every change of its content rolls
+// out a new kube-apiserver revision. The CAs of the synced bundle are kept until they expire even once they are gone
+// from the source: the kubelets keep serving certificates of the previous CA until they are renewed, and logs and
+// exec to those nodes must keep working while they rotate.
+type KubeletServingCASyncController struct {
+	operatorClient  v1helpers.OperatorClient
+	configMapLister corev1listers.ConfigMapLister
+	configMapClient coreclientv1.ConfigMapsGetter
+	now             func() time.Time
+}
+
+func NewKubeletServingCASyncController(
+	operatorClient v1helpers.OperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	configMapClient coreclientv1.ConfigMapsGetter,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &KubeletServingCASyncController{
+		operatorClient:  operatorClient,
+		configMapLister: kubeInformersForNamespaces.ConfigMapLister(),
+		configMapClient: configMapClient,
+		now:             time.Now,
+	}
+
+	// the previous CAs expire without anything changing, resync to drop them
+	return factory.New().WithInformers(
+		operatorClient.Informer(),
+		kubeInformersForNamespaces.InformersFor(operatorclient.GlobalMachineSpecifiedConfigNamespace).Core().V1().ConfigMaps().Informer(),
+		kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().ConfigMaps().Informer(),
+	).WithSync(c.sync).WithSyncDegradedOnError(operatorClient).ResyncEvery(time.Hour).ToController("KubeletServingCASyncController", eventRecorder.WithComponentSuffix("kubelet-serving-ca-sync-controller"))
+}
+
+func (c *KubeletServingCASyncController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	operatorSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+
+	source, err := c.configMapLister.ConfigMaps(operatorclient.GlobalMachineSpecifiedConfigNamespace).Get(kubeletServingCASourceName)
+	if err != nil {
+		// never remove the synced bundle, logs and exec break without it
+		return fmt.Errorf("unable to get %s/%s: %w", operatorclient.GlobalMachineSpecifiedConfigNamespace, kubeletServingCASourceName, err)
+	}
+	sourceBundle := []byte(source.Data[caBundleKey])
+	if _, err := normalizeCABundle(sourceBundle); err != nil {
+		return fmt.Errorf("invalid %s/%s: %w", operatorclient.GlobalMachineSpecifiedConfigNamespace, kubeletServingCASourceName, err)
+	}
+
+	var current []byte
+	existing, err := c.configMapLister.ConfigMaps(operatorclient.TargetNamespace).Get(kubeletServingCAName)
+	switch {
+	case err == nil:
+		// a bundle that cannot be parsed is replaced
+		if normalized, err := normalizeCABundle([]byte(existing.Data[caBundleKey])); err == nil {
+			current = normalized
+		}
+	case !apierrors.IsNotFound(err):
+		return err
+	}
+
+	desired, err := normalizeCABundle(append(append([]byte{}, sourceBundle...), c.unexpiredCerts(current)...))
+	if err != nil {
+		return err
+	}
+	if current != nil && caBundleHash(current) == caBundleHash(desired) {
+		return nil
+	}
+
+	required := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: kubeletServingCAName},
+		Data:       map[string]string{caBundleKey: string(desired)},
+	}
+	_, modified, err := resourceapply.ApplyConfigMap(ctx, c.configMapClient, syncCtx.Recorder(), required)
+	if err != nil {
+		return err
+	}
+	if modified {
+		syncCtx.Recorder().Eventf("KubeletServingCABundleChanged", "The kubelet serving CA bundle changed (sha256 %s), a new revision will be rolled out", caBundleHash(desired))
+	}
+	return nil
+}
+
+// unexpiredCerts returns the PEM encoded certificates of the normalized bundle that have not expired yet.
+func (c *KubeletServingCASyncController) unexpiredCerts(normalized []byte) []byte {
+	if len(normalized) == 0 {
+		return nil
+	}
+	certs, err := certutil.ParseCertsPEM(normalized)
+	if err != nil {
+		return nil
+	}
+	unexpired := &bytes.Buffer{}
+	for _, cert := range certs {
+		if c.now().Before(cert.NotAfter) {
+			// encoding to a buffer cannot fail
+			_ = pem.Encode(unexpired, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+		}
+	}
+	return unexpired.Bytes()
+}
@@ -0,0 +1,119 @@
+package resourcesynccontroller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+func TestKubeletServingCASyncController(t *testing.T) {
+	// both CAs are valid for an hour
+	oldCA, newCA := newCACert(t, "kube-csr-signer-old"), newCACert(t, "kube-csr-signer-new")
+	normalized := func(bundle string) string {
+		t.Helper()
+		n, err := normalizeCABundle([]byte(bundle))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(n)
+	}
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	kubeClient := fake.NewSimpleClientset()
+	now := time.Now()
+	c := &KubeletServingCASyncController{
+		operatorClient:  v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil),
+		configMapLister: corev1listers.NewConfigMapLister(indexer),
+		configMapClient: kubeClient.CoreV1(),
+		now:             func() time.Time { return now },
+	}
+
+	// sync runs the controller with the given source bundle and returns the synced bundle and whether it was written
+	sync := func(sourceBundle string) (string, bool) {
+		t.Helper()
+		if err := indexer.Update(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: kubeletServingCASourceName},
+			Data:       map[string]string{caBundleKey: sourceBundle},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		recorder := events.NewInMemoryRecorder("test")
+		if err := c.sync(context.TODO(), factory.NewSyncContext("test", recorder)); err != nil {
+			t.Fatal(err)
+		}
+		synced, err := kubeClient.CoreV1().ConfigMaps(operatorclient.TargetNamespace).Get(context.TODO(), kubeletServingCAName, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the informer catches up with the write
+		if err := indexer.Update(synced); err != nil {
+			t.Fatal(err)
+		}
+		changed := false
+		for _, ev := range recorder.Events() {
+			if ev.Reason == "KubeletServingCABundleChanged" {
+				changed = true
+			}
+		}
+		return synced.Data[caBundleKey], changed
+	}
+
+	if bundle, changed := sync(oldCA); !changed || bundle != normalized(oldCA) {
+		t.Fatalf("expected the initial sync of the source bundle, got changed %v and %q", changed, bundle)
+	}
+	if _, changed := sync(oldCA); changed {
+		t.Errorf("expected no new revision in steady state")
+	}
+
+	// the CSR signer rotates, the source publishes the new CA next to the old one
+	if bundle, changed := sync(oldCA + newCA); !changed || bundle != normalized(oldCA+newCA) {
+		t.Fatalf("expected the new CA to be synced, got changed %v and %q", changed, bundle)
+	}
+	// the source drops the old CA, which is still trusted until it expires
+	if bundle, changed := sync(newCA); changed || bundle != normalized(oldCA+newCA) {
+		t.Fatalf("expected the old CA to be kept, got changed %v and %q", changed, bundle)
+	}
+	now = now.Add(2 * time.Hour)
+	if bundle, changed := sync(newCA); !changed || bundle != normalized(newCA) {
+		t.Fatalf("expected the expired old CA to be dropped, got changed %v and %q", changed, bundle)
+	}
+	if _, changed := sync(newCA); changed {
+		t.Errorf("expected no new revision once rotated")
+	}
+}
+
+func TestKubeletServingCASyncControllerInvalidSource(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	if err := indexer.Add(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: kubeletServingCASourceName},
+		Data:       map[string]string{caBundleKey: "not a certificate"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	kubeClient := fake.NewSimpleClientset()
+	c := &KubeletServingCASyncController{
+		operatorClient:  v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil),
+		configMapLister: corev1listers.NewConfigMapLister(indexer),
+		configMapClient: kubeClient.CoreV1(),
+		now:             time.Now,
+	}
+	if err := c.sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err == nil {
+		t.Errorf("expected an error for an invalid source bundle")
+	}
+	if len(kubeClient.Actions()) > 0 {
+		t.Errorf("expected nothing to be written, got %v", kubeClient.Actions())
+	}
+}
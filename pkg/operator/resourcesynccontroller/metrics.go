@@ -0,0 +1,24 @@
+package resourcesynccontroller
+
+import (
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+var (
+	registerMetrics sync.Once
+
+	syncDriftCounter = metrics.NewCounterVec(&metrics.CounterOpts{
+		Name: "kube_apiserver_operator_resource_sync_drift_total",
+		Help: "Report the number of times a resource synced by the operator was found to differ from its source",
+	}, []string{"resource", "namespace", "name"})
+)
+
+// RegisterMetrics registers the resource sync metrics with the legacy registry.
+func RegisterMetrics() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(syncDriftCounter)
+	})
+}
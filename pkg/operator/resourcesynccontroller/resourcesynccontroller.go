@@ -10,6 +10,57 @@ import (
 	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
 )
 
+// syncRule copies the source resource to the destination.
+type syncRule struct {
+	destination resourcesynccontroller.ResourceLocation
+	source      resourcesynccontroller.ResourceLocation
+}
+
+var syncedSecrets = []syncRule{
+	{
+		destination: resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "etcd-client"},
+		source:      resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalUserSpecifiedConfigNamespace, Name: "etcd-client"},
+	},
+}
+
+var syncedConfigMaps = []syncRule{
+	// etcd-serving-ca is synced by the EtcdServingCASyncController which normalizes the bundle
+
+	// this configmaps holds the certs used to verify the SA token JWTs created by the kube-controller-manager-operator
+	{
+		destination: resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "sa-token-signing-certs"},
+		source:      resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "sa-token-signing-certs"},
+	},
+	// this ca bundle contains certs to verify the aggregator.  We copy it from the shared location to here.
+	{
+		destination: resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "aggregator-client-ca"},
+		source:      resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "kube-apiserver-aggregator-client-ca"},
+	},
+	// kubelet-serving-ca, which allows us to verify the kubelet serving certs, is synced by the
+	// KubeletServingCASyncController which keeps the previous CAs during a rotation
+
+	// this ca bundle contains certs used by the kube-apiserver to verify client certs
+	{
+		destination: resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "kube-apiserver-client-ca"},
+		source:      resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "client-ca"},
+	},
+	// this ca bundle contains certs that can be used to verify a kubelet
+	{
+		destination: resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "kubelet-serving-ca"},
+		source:      resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "kubelet-serving-ca"},
+	},
+	// this ca bundle contains certs that can be used to verify a kube-apiserver
+	{
+		destination: resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "kube-apiserver-server-ca"},
+		source:      resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "kube-apiserver-server-ca"},
+	},
+	// this ca bundle contains public keys that can be used to verify bound tokens issued by the kube-apiserver
+	{
+		destination: resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "bound-sa-token-signing-certs"},
+		source:      resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "bound-sa-token-signing-certs"},
+	},
+}
+
 func NewResourceSyncController(
 	operatorConfigClient v1helpers.OperatorClient,
 	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
@@ -24,74 +75,15 @@ func NewResourceSyncController(
 		eventRecorder,
 	)
 
-	if err := resourceSyncController.SyncConfigMap(
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "etcd-serving-ca"},
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalUserSpecifiedConfigNamespace, Name: "etcd-serving-ca"},
-	); err != nil {
-		return nil, err
+	for _, rule := range syncedSecrets {
+		if err := resourceSyncController.SyncSecret(rule.destination, rule.source); err != nil {
+			return nil, err
+		}
 	}
-
-	if err := resourceSyncController.SyncSecret(
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "etcd-client"},
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalUserSpecifiedConfigNamespace, Name: "etcd-client"},
-	); err != nil {
-		return nil, err
-	}
-
-	// this configmaps holds the certs used to verify the SA token JWTs created by the kube-controller-manager-operator
-	if err := resourceSyncController.SyncConfigMap(
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "sa-token-signing-certs"},
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "sa-token-signing-certs"},
-	); err != nil {
-		return nil, err
-	}
-
-	// this ca bundle contains certs to verify the aggregator.  We copy it from the shared location to here.
-	if err := resourceSyncController.SyncConfigMap(
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "aggregator-client-ca"},
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "kube-apiserver-aggregator-client-ca"},
-	); err != nil {
-		return nil, err
-	}
-
-	// this configmap allows us to verify the kubelet serving certs
-	if err := resourceSyncController.SyncConfigMap(
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "kubelet-serving-ca"},
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "csr-controller-ca"},
-	); err != nil {
-		return nil, err
-	}
-
-	// this ca bundle contains certs used by the kube-apiserver to verify client certs
-	if err := resourceSyncController.SyncConfigMap(
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "kube-apiserver-client-ca"},
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "client-ca"},
-	); err != nil {
-		return nil, err
-	}
-
-	// this ca bundle contains certs that can be used to verify a kubelet
-	if err := resourceSyncController.SyncConfigMap(
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "kubelet-serving-ca"},
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "kubelet-serving-ca"},
-	); err != nil {
-		return nil, err
-	}
-
-	// this ca bundle contains certs that can be used to verify a kube-apiserver
-	if err := resourceSyncController.SyncConfigMap(
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "kube-apiserver-server-ca"},
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "kube-apiserver-server-ca"},
-	); err != nil {
-		return nil, err
-	}
-
-	// this ca bundle contains public keys that can be used to verify bound tokens issued by the kube-apiserver
-	if err := resourceSyncController.SyncConfigMap(
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "bound-sa-token-signing-certs"},
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "bound-sa-token-signing-certs"},
-	); err != nil {
-		return nil, err
+	for _, rule := range syncedConfigMaps {
+		if err := resourceSyncController.SyncConfigMap(rule.destination, rule.source); err != nil {
+			return nil, err
+		}
 	}
 
 	return resourceSyncController, nil
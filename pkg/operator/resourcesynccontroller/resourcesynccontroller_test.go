@@ -0,0 +1,86 @@
+package resourcesynccontroller
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resourcesynccontroller"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+func TestResourceSyncIsBinarySafe(t *testing.T) {
+	// not valid UTF-8
+	der := []byte{0x30, 0x82, 0x01, 0x0a, 0x02, 0x82, 0x01, 0x01, 0x00, 0xc3, 0x28, 0xff, 0xfe, 0x0d, 0x0a, 0x00}
+
+	sourceConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.GlobalUserSpecifiedConfigNamespace, Name: "cloud-provider-config"},
+		Data:       map[string]string{"config": "[Global]\n"},
+		BinaryData: map[string][]byte{"ca.der": der},
+	}
+	sourceSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.GlobalUserSpecifiedConfigNamespace, Name: "binary-secret"},
+		Data:       map[string][]byte{"tls.der": der},
+	}
+	kubeClient := fake.NewSimpleClientset(sourceConfigMap, sourceSecret)
+	kubeInformersForNamespaces := v1helpers.NewKubeInformersForNamespaces(kubeClient, operatorclient.GlobalUserSpecifiedConfigNamespace, operatorclient.GlobalMachineSpecifiedConfigNamespace, operatorclient.TargetNamespace)
+	operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+	recorder := events.NewInMemoryRecorder("test")
+
+	c, err := NewResourceSyncController(operatorClient, kubeInformersForNamespaces, kubeClient, recorder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SyncConfigMap(
+		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "cloud-config"},
+		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalUserSpecifiedConfigNamespace, Name: "cloud-provider-config"},
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SyncSecret(
+		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "binary-secret"},
+		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalUserSpecifiedConfigNamespace, Name: "binary-secret"},
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	kubeInformersForNamespaces.Start(ctx.Done())
+	for namespace := range kubeInformersForNamespaces.Namespaces() {
+		kubeInformersForNamespaces.InformersFor(namespace).WaitForCacheSync(ctx.Done())
+	}
+
+	// the other sync rules fail on their missing sources
+	if err := c.Sync(ctx, factory.NewSyncContext("test", recorder)); err != nil {
+		t.Fatal(err)
+	}
+
+	syncedConfigMap, err := kubeClient.CoreV1().ConfigMaps(operatorclient.TargetNamespace).Get(ctx, "cloud-config", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if syncedConfigMap.Data["config"] != sourceConfigMap.Data["config"] {
+		t.Errorf("expected the configmap data to be synced, got %q", syncedConfigMap.Data["config"])
+	}
+	if !bytes.Equal(syncedConfigMap.BinaryData["ca.der"], der) {
+		t.Errorf("expected the configmap binary data to be synced byte for byte, got %v", syncedConfigMap.BinaryData["ca.der"])
+	}
+
+	syncedSecret, err := kubeClient.CoreV1().Secrets(operatorclient.TargetNamespace).Get(ctx, "binary-secret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(syncedSecret.Data["tls.der"], der) {
+		t.Errorf("expected the secret data to be synced byte for byte, got %v", syncedSecret.Data["tls.der"])
+	}
+}
@@ -0,0 +1,152 @@
+package resourcesynccontroller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	coreclientv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+// SyncIntegrityController periodically verifies that the resources copied by the resource sync controller still
+// match their source. The resource sync controller only reacts to changes it is notified about, a copy mutated
+// by another actor while an update is missed stays wrong until the source changes again. On drift the copy is
+// re-synced, a SyncDrift event is emitted and the drift counter of the resource is increased.
+type SyncIntegrityController struct {
+	operatorClient  v1helpers.OperatorClient
+	configMapLister corev1listers.ConfigMapLister
+	secretLister    corev1listers.SecretLister
+	configMapClient coreclientv1.ConfigMapsGetter
+	secretClient    coreclientv1.SecretsGetter
+
+	configMaps []syncRule
+	secrets    []syncRule
+}
+
+func NewSyncIntegrityController(
+	operatorClient v1helpers.OperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	configMapClient coreclientv1.ConfigMapsGetter,
+	secretClient coreclientv1.SecretsGetter,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &SyncIntegrityController{
+		operatorClient:  operatorClient,
+		configMapLister: kubeInformersForNamespaces.ConfigMapLister(),
+		secretLister:    kubeInformersForNamespaces.SecretLister(),
+		configMapClient: configMapClient,
+		secretClient:    secretClient,
+		configMaps:      syncedConfigMaps,
+		secrets:         syncedSecrets,
+	}
+
+	return factory.New().WithSync(c.sync).ResyncEvery(5*time.Minute).ToController("ResourceSyncIntegrityController", eventRecorder.WithComponentSuffix("resource-sync-integrity-controller"))
+}
+
+func (c *SyncIntegrityController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	operatorSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+
+	var errs []error
+	for _, rule := range c.configMaps {
+		drifted, err := c.configMapDrifted(rule)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !drifted {
+			continue
+		}
+		syncDriftCounter.WithLabelValues("configmap", rule.destination.Namespace, rule.destination.Name).Inc()
+		syncCtx.Recorder().Warningf("SyncDrift", "configmap %s/%s differs from its source %s/%s, re-syncing it", rule.destination.Namespace, rule.destination.Name, rule.source.Namespace, rule.source.Name)
+		if _, _, err := resourceapply.SyncConfigMap(ctx, c.configMapClient, syncCtx.Recorder(), rule.source.Namespace, rule.source.Name, rule.destination.Namespace, rule.destination.Name, nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, rule := range c.secrets {
+		drifted, err := c.secretDrifted(rule)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !drifted {
+			continue
+		}
+		syncDriftCounter.WithLabelValues("secret", rule.destination.Namespace, rule.destination.Name).Inc()
+		syncCtx.Recorder().Warningf("SyncDrift", "secret %s/%s differs from its source %s/%s, re-syncing it", rule.destination.Namespace, rule.destination.Name, rule.source.Namespace, rule.source.Name)
+		if _, _, err := resourceapply.SyncSecret(ctx, c.secretClient, syncCtx.Recorder(), rule.source.Namespace, rule.source.Name, rule.destination.Namespace, rule.destination.Name, nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// configMapDrifted returns whether the destination of the rule is missing or its data differs from the source.
+// A missing source is not a drift, removing the destination is left to the resource sync controller.
+func (c *SyncIntegrityController) configMapDrifted(rule syncRule) (bool, error) {
+	source, err := c.configMapLister.ConfigMaps(rule.source.Namespace).Get(rule.source.Name)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to get configmap %s/%s: %w", rule.source.Namespace, rule.source.Name, err)
+	}
+	destination, err := c.configMapLister.ConfigMaps(rule.destination.Namespace).Get(rule.destination.Name)
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to get configmap %s/%s: %w", rule.destination.Namespace, rule.destination.Name, err)
+	}
+	return !mapsEqual(source.Data, destination.Data) || !bytesMapsEqual(source.BinaryData, destination.BinaryData), nil
+}
+
+// secretDrifted returns whether the destination of the rule is missing or its data differs from the source.
+// A missing source is not a drift, removing the destination is left to the resource sync controller.
+func (c *SyncIntegrityController) secretDrifted(rule syncRule) (bool, error) {
+	source, err := c.secretLister.Secrets(rule.source.Namespace).Get(rule.source.Name)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to get secret %s/%s: %w", rule.source.Namespace, rule.source.Name, err)
+	}
+	destination, err := c.secretLister.Secrets(rule.destination.Namespace).Get(rule.destination.Name)
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to get secret %s/%s: %w", rule.destination.Namespace, rule.destination.Name, err)
+	}
+	return !bytesMapsEqual(source.Data, destination.Data), nil
+}
+
+// mapsEqual treats nil and empty maps as equal, the API server does not preserve the difference.
+func mapsEqual(a, b map[string]string) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func bytesMapsEqual(a, b map[string][]byte) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
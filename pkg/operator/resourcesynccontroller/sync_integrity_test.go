@@ -0,0 +1,151 @@
+package resourcesynccontroller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/testutil"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resourcesynccontroller"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+func TestSyncIntegrityController(t *testing.T) {
+	registry := metrics.NewKubeRegistry()
+	registry.MustRegister(syncDriftCounter)
+	configMapRule := syncRule{
+		destination: resourcesynccontroller.ResourceLocation{Namespace: "destination", Name: "ca"},
+		source:      resourcesynccontroller.ResourceLocation{Namespace: "source", Name: "ca"},
+	}
+	secretRule := syncRule{
+		destination: resourcesynccontroller.ResourceLocation{Namespace: "destination", Name: "client"},
+		source:      resourcesynccontroller.ResourceLocation{Namespace: "source", Name: "client"},
+	}
+	configMap := func(namespace, data string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "ca"},
+			Data:       map[string]string{"ca-bundle.crt": data},
+		}
+	}
+	secret := func(namespace, data string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "client"},
+			Data:       map[string][]byte{"tls.key": []byte(data)},
+		}
+	}
+
+	withBinaryData := func(configMap *corev1.ConfigMap, data []byte) *corev1.ConfigMap {
+		configMap.BinaryData = map[string][]byte{"ca.der": data}
+		return configMap
+	}
+
+	scenarios := []struct {
+		name            string
+		objects         []runtime.Object
+		expectedDrifted []string
+	}{
+		{
+			name:    "in sync",
+			objects: []runtime.Object{configMap("source", "ca"), configMap("destination", "ca"), secret("source", "key"), secret("destination", "key")},
+		},
+		{
+			name:    "missing source",
+			objects: []runtime.Object{configMap("destination", "ca")},
+		},
+		{
+			name:            "mutated destinations",
+			objects:         []runtime.Object{configMap("source", "ca"), configMap("destination", "mutated"), secret("source", "key"), secret("destination", "mutated")},
+			expectedDrifted: []string{"configmap", "secret"},
+		},
+		{
+			name:            "mutated binary data",
+			objects:         []runtime.Object{withBinaryData(configMap("source", "ca"), []byte{0x30, 0x82, 0xff}), withBinaryData(configMap("destination", "ca"), []byte{0x30, 0x82, 0xfe})},
+			expectedDrifted: []string{"configmap"},
+		},
+		{
+			name:            "missing destination",
+			objects:         []runtime.Object{configMap("source", "ca"), secret("source", "key"), secret("destination", "key")},
+			expectedDrifted: []string{"configmap"},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			syncDriftCounter.Reset()
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for _, obj := range scenario.objects {
+				if err := indexer.Add(obj); err != nil {
+					t.Fatal(err)
+				}
+			}
+			kubeClient := fake.NewSimpleClientset(scenario.objects...)
+			recorder := events.NewInMemoryRecorder("test")
+
+			c := &SyncIntegrityController{
+				operatorClient:  v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil),
+				configMapLister: corev1listers.NewConfigMapLister(indexer),
+				secretLister:    corev1listers.NewSecretLister(indexer),
+				configMapClient: kubeClient.CoreV1(),
+				secretClient:    kubeClient.CoreV1(),
+				configMaps:      []syncRule{configMapRule},
+				secrets:         []syncRule{secretRule},
+			}
+			if err := c.sync(context.TODO(), factory.NewSyncContext("test", recorder)); err != nil {
+				t.Fatal(err)
+			}
+
+			drifted := []string{}
+			for _, ev := range recorder.Events() {
+				if ev.Reason == "SyncDrift" {
+					drifted = append(drifted, ev.Message)
+				}
+			}
+			if len(drifted) != len(scenario.expectedDrifted) {
+				t.Fatalf("expected %d SyncDrift events, got %v", len(scenario.expectedDrifted), drifted)
+			}
+
+			for _, resource := range scenario.expectedDrifted {
+				switch resource {
+				case "configmap":
+					synced, err := kubeClient.CoreV1().ConfigMaps("destination").Get(context.TODO(), "ca", metav1.GetOptions{})
+					if err != nil {
+						t.Fatal(err)
+					}
+					source, err := kubeClient.CoreV1().ConfigMaps("source").Get(context.TODO(), "ca", metav1.GetOptions{})
+					if err != nil {
+						t.Fatal(err)
+					}
+					if !equality.Semantic.DeepEqual(synced.Data, source.Data) || !equality.Semantic.DeepEqual(synced.BinaryData, source.BinaryData) {
+						t.Errorf("expected the configmap to be re-synced, got %v %v", synced.Data, synced.BinaryData)
+					}
+				case "secret":
+					synced, err := kubeClient.CoreV1().Secrets("destination").Get(context.TODO(), "client", metav1.GetOptions{})
+					if err != nil {
+						t.Fatal(err)
+					}
+					if string(synced.Data["tls.key"]) != "key" {
+						t.Errorf("expected the secret to be re-synced, got %v", synced.Data)
+					}
+				}
+				count, err := testutil.GetCounterMetricValue(syncDriftCounter.WithLabelValues(resource, "destination", map[string]string{"configmap": "ca", "secret": "client"}[resource]))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if count != 1 {
+					t.Errorf("expected the %s drift to be counted once, got %v", resource, count)
+				}
+			}
+		})
+	}
+}
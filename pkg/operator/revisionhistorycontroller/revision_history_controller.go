@@ -0,0 +1,202 @@
+package revisionhistorycontroller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coreclientv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+const (
+	// RevisionHistoryConfigMapName is the configmap in the operator namespace holding the revision history, as a JSON
+	// list of entries, oldest first, in its history key.
+	RevisionHistoryConfigMapName = "kube-apiserver-revision-history"
+	revisionHistoryKey           = "history"
+
+	// maxRevisionHistoryLength is the number of rollouts retained, the oldest entries are dropped first.
+	maxRevisionHistoryLength = 50
+)
+
+// RevisionHistoryEntry records a revision rolled out to all the nodes.
+type RevisionHistoryEntry struct {
+	Revision int32 `json:"revision"`
+	// Timestamp is when the controller saw the revision on all the nodes.
+	Timestamp metav1.Time `json:"timestamp"`
+	// Reason is the reason the revision controller created the revision with.
+	Reason string `json:"reason,omitempty"`
+}
+
+// RevisionHistoryController appends an entry to the revision history configmap each time the latest available
+// revision is rolled out to all the nodes. Unlike the revision-status configmaps of the target namespace, which are
+// pruned together with their revision, the history survives the pruning and keeps the last maxRevisionHistoryLength
+// rollouts. The configmap is updated with a read-modify-write retried on conflicts, other writers are not overwritten.
+type RevisionHistoryController struct {
+	factory.Controller
+	operatorClient v1helpers.StaticPodOperatorClient
+	// revisionStatusLister lists the configmaps of the target namespace
+	revisionStatusLister corev1listers.ConfigMapNamespaceLister
+	// historyLister lists the configmaps of the operator namespace
+	historyLister   corev1listers.ConfigMapNamespaceLister
+	configMapClient coreclientv1.ConfigMapsGetter
+	now             func() time.Time
+}
+
+func NewRevisionHistoryController(
+	operatorClient v1helpers.StaticPodOperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	configMapClient coreclientv1.ConfigMapsGetter,
+	recorder events.Recorder,
+) *RevisionHistoryController {
+	targetConfigMaps := kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().ConfigMaps()
+	operatorConfigMaps := kubeInformersForNamespaces.InformersFor(operatorclient.OperatorNamespace).Core().V1().ConfigMaps()
+	c := &RevisionHistoryController{
+		operatorClient:       operatorClient,
+		revisionStatusLister: targetConfigMaps.Lister().ConfigMaps(operatorclient.TargetNamespace),
+		historyLister:        operatorConfigMaps.Lister().ConfigMaps(operatorclient.OperatorNamespace),
+		configMapClient:      configMapClient,
+		now:                  time.Now,
+	}
+	c.Controller = factory.New().
+		WithSync(c.sync).
+		WithInformers(operatorClient.Informer(), targetConfigMaps.Informer(), operatorConfigMaps.Informer()).
+		ToController("RevisionHistoryController", recorder.WithComponentSuffix("revision-history-controller"))
+	return c
+}
+
+func (c *RevisionHistoryController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	operatorSpec, operatorStatus, _, err := c.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+
+	revision := operatorStatus.LatestAvailableRevision
+	if revision == 0 || len(operatorStatus.NodeStatuses) == 0 {
+		return nil
+	}
+	for _, ns := range operatorStatus.NodeStatuses {
+		if ns.CurrentRevision != revision {
+			// still rolling out
+			return nil
+		}
+	}
+
+	// skip the write when the cached history already has the revision
+	if existing, err := c.historyLister.Get(RevisionHistoryConfigMapName); err == nil {
+		if history, err := decodeHistory(existing); err == nil && len(history) > 0 && history[len(history)-1].Revision == revision {
+			return nil
+		}
+	} else if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	reason := ""
+	status, err := c.revisionStatusLister.Get(fmt.Sprintf("revision-status-%d", revision))
+	switch {
+	case err == nil:
+		reason = status.Data["reason"]
+	case !apierrors.IsNotFound(err):
+		return err
+	}
+
+	entry := RevisionHistoryEntry{Revision: revision, Timestamp: metav1.NewTime(c.now()), Reason: reason}
+	recorded, err := c.record(ctx, syncCtx.Recorder(), entry)
+	if err != nil {
+		return fmt.Errorf("unable to record revision %d in %s/%s: %w", revision, operatorclient.OperatorNamespace, RevisionHistoryConfigMapName, err)
+	}
+	if recorded {
+		syncCtx.Recorder().Eventf("RevisionHistoryRecorded", "Recorded the rollout of revision %d in the revision history", revision)
+	}
+	return nil
+}
+
+// record appends the entry to the history unless its revision is already the last one, trimming the history to
+// maxRevisionHistoryLength. The configmap is read from the server and written back with its resource version, the
+// write is retried on conflicts with a concurrent writer, or a concurrent creation.
+func (c *RevisionHistoryController) record(ctx context.Context, recorder events.Recorder, entry RevisionHistoryEntry) (bool, error) {
+	recorded := false
+	err := retry.OnError(retry.DefaultBackoff, func(err error) bool {
+		return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+	}, func() error {
+		recorded = false
+		client := c.configMapClient.ConfigMaps(operatorclient.OperatorNamespace)
+		configMap, err := client.Get(ctx, RevisionHistoryConfigMapName, metav1.GetOptions{})
+		create := apierrors.IsNotFound(err)
+		switch {
+		case create:
+			configMap = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.OperatorNamespace, Name: RevisionHistoryConfigMapName},
+			}
+		case err != nil:
+			return err
+		default:
+			configMap = configMap.DeepCopy()
+		}
+
+		history, err := decodeHistory(configMap)
+		if err != nil {
+			recorder.Warningf("RevisionHistoryReset", "Resetting the unreadable revision history: %v", err)
+			history = nil
+		}
+		if len(history) > 0 && history[len(history)-1].Revision == entry.Revision {
+			return nil
+		}
+		history = appendEntry(history, entry)
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		encoded, err := json.Marshal(history)
+		if err != nil {
+			return err
+		}
+		configMap.Data[revisionHistoryKey] = string(encoded)
+
+		if create {
+			_, err = client.Create(ctx, configMap, metav1.CreateOptions{})
+		} else {
+			_, err = client.Update(ctx, configMap, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			return err
+		}
+		recorded = true
+		return nil
+	})
+	return recorded, err
+}
+
+// appendEntry appends the entry and drops the oldest entries beyond maxRevisionHistoryLength.
+func appendEntry(history []RevisionHistoryEntry, entry RevisionHistoryEntry) []RevisionHistoryEntry {
+	history = append(history, entry)
+	if len(history) > maxRevisionHistoryLength {
+		history = history[len(history)-maxRevisionHistoryLength:]
+	}
+	return history
+}
+
+func decodeHistory(configMap *corev1.ConfigMap) ([]RevisionHistoryEntry, error) {
+	data, ok := configMap.Data[revisionHistoryKey]
+	if !ok || len(data) == 0 {
+		return nil, nil
+	}
+	var history []RevisionHistoryEntry
+	if err := json.Unmarshal([]byte(data), &history); err != nil {
+		return nil, fmt.Errorf("invalid %s key: %v", revisionHistoryKey, err)
+	}
+	return history, nil
+}
@@ -0,0 +1,198 @@
+package revisionhistorycontroller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+func historyConfigMap(t *testing.T, revisions ...int32) *corev1.ConfigMap {
+	var history []RevisionHistoryEntry
+	for _, revision := range revisions {
+		history = append(history, RevisionHistoryEntry{Revision: revision, Reason: "older"})
+	}
+	encoded, err := json.Marshal(history)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.OperatorNamespace, Name: RevisionHistoryConfigMapName, ResourceVersion: "1"},
+		Data:       map[string]string{revisionHistoryKey: string(encoded)},
+	}
+}
+
+func TestRevisionHistoryController(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	fullHistory, trimmedHistory := []int32{}, []int32{}
+	for revision := int32(1); revision <= maxRevisionHistoryLength; revision++ {
+		fullHistory = append(fullHistory, revision)
+		if revision > 1 {
+			trimmedHistory = append(trimmedHistory, revision)
+		}
+	}
+	trimmedHistory = append(trimmedHistory, maxRevisionHistoryLength+1)
+
+	tests := []struct {
+		name              string
+		latestRevision    int32
+		nodeRevisions     []int32
+		existing          *corev1.ConfigMap
+		conflicts         int
+		expectedRevisions []int32
+		expectedWrite     bool
+	}{
+		{
+			name:              "first rollout creates the history",
+			nodeRevisions:     []int32{7, 7, 7},
+			expectedRevisions: []int32{7},
+			expectedWrite:     true,
+		},
+		{
+			name:              "rollout is appended",
+			nodeRevisions:     []int32{7, 7, 7},
+			existing:          historyConfigMap(t, 5, 6),
+			expectedRevisions: []int32{5, 6, 7},
+			expectedWrite:     true,
+		},
+		{
+			name:              "rollout in progress is not recorded",
+			nodeRevisions:     []int32{7, 6, 6},
+			existing:          historyConfigMap(t, 5, 6),
+			expectedRevisions: []int32{5, 6},
+		},
+		{
+			name:              "recorded rollout is not appended twice",
+			nodeRevisions:     []int32{7, 7, 7},
+			existing:          historyConfigMap(t, 6, 7),
+			expectedRevisions: []int32{6, 7},
+		},
+		{
+			name:              "the oldest entries are trimmed",
+			latestRevision:    maxRevisionHistoryLength + 1,
+			nodeRevisions:     []int32{maxRevisionHistoryLength + 1, maxRevisionHistoryLength + 1, maxRevisionHistoryLength + 1},
+			existing:          historyConfigMap(t, fullHistory...),
+			expectedRevisions: trimmedHistory,
+			expectedWrite:     true,
+		},
+		{
+			name:              "conflicting update is retried",
+			nodeRevisions:     []int32{7, 7, 7},
+			existing:          historyConfigMap(t, 5, 6),
+			conflicts:         2,
+			expectedRevisions: []int32{5, 6, 7},
+			expectedWrite:     true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			latestRevision := test.latestRevision
+			if latestRevision == 0 {
+				latestRevision = 7
+			}
+			var nodeStatuses []operatorv1.NodeStatus
+			for i, revision := range test.nodeRevisions {
+				nodeStatuses = append(nodeStatuses, operatorv1.NodeStatus{NodeName: string(rune('a' + i)), CurrentRevision: revision})
+			}
+			operatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+				&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+				&operatorv1.StaticPodOperatorStatus{LatestAvailableRevision: latestRevision, NodeStatuses: nodeStatuses},
+				nil,
+				nil,
+			)
+
+			targetIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			revisionStatus := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: fmt.Sprintf("revision-status-%d", latestRevision)},
+				Data:       map[string]string{"revision": fmt.Sprint(latestRevision), "reason": "configmap/config has changed"},
+			}
+			if err := targetIndexer.Add(revisionStatus); err != nil {
+				t.Fatal(err)
+			}
+			operatorIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			var objects []runtime.Object
+			if test.existing != nil {
+				if err := operatorIndexer.Add(test.existing); err != nil {
+					t.Fatal(err)
+				}
+				objects = append(objects, test.existing)
+			}
+			kubeClient := fake.NewSimpleClientset(objects...)
+			conflicts := test.conflicts
+			kubeClient.PrependReactor("update", "configmaps", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				if conflicts == 0 {
+					return false, nil, nil
+				}
+				conflicts--
+				return true, nil, apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, RevisionHistoryConfigMapName, nil)
+			})
+
+			c := &RevisionHistoryController{
+				operatorClient:       operatorClient,
+				revisionStatusLister: corev1listers.NewConfigMapLister(targetIndexer).ConfigMaps(operatorclient.TargetNamespace),
+				historyLister:        corev1listers.NewConfigMapLister(operatorIndexer).ConfigMaps(operatorclient.OperatorNamespace),
+				configMapClient:      kubeClient.CoreV1(),
+				now:                  func() time.Time { return now },
+			}
+			recorder := events.NewInMemoryRecorder("test")
+			if err := c.sync(context.TODO(), factory.NewSyncContext("test", recorder)); err != nil {
+				t.Fatal(err)
+			}
+
+			written := false
+			for _, action := range kubeClient.Actions() {
+				if action.GetVerb() == "create" || action.GetVerb() == "update" {
+					written = true
+				}
+			}
+			if written != test.expectedWrite {
+				t.Fatalf("expected write %v, got actions %v", test.expectedWrite, kubeClient.Actions())
+			}
+			if conflicts != 0 {
+				t.Fatalf("expected the conflicts to be retried, %d left", conflicts)
+			}
+
+			configMap, err := kubeClient.CoreV1().ConfigMaps(operatorclient.OperatorNamespace).Get(context.TODO(), RevisionHistoryConfigMapName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			history, err := decodeHistory(configMap)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var revisions []int32
+			for _, entry := range history {
+				revisions = append(revisions, entry.Revision)
+			}
+			if !reflect.DeepEqual(revisions, test.expectedRevisions) {
+				t.Fatalf("expected revisions %v, got %v", test.expectedRevisions, revisions)
+			}
+			if !test.expectedWrite {
+				return
+			}
+			last := history[len(history)-1]
+			if last.Reason != "configmap/config has changed" || !last.Timestamp.Time.Equal(now) {
+				t.Errorf("unexpected entry %#v", last)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package revisionquarantinecontroller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/staticpod/controller/installer"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	coreclientv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+const (
+	RevisionQuarantineDegradedConditionType = "KubeAPIServerRevisionQuarantineDegraded"
+
+	RevisionQuarantinedReason = "RevisionQuarantined"
+	AsExpectedReason          = "AsExpected"
+
+	// crashThreshold is the number of recorded crashes of a revision past which it is quarantined.
+	crashThreshold = 5
+
+	// configMapName records the crash counts, keyed by revision, in the operator namespace so that they survive
+	// both the pods being recreated and the operator being restarted.
+	configMapName = "revision-quarantine"
+)
+
+var kubeAPIServerPodSelector = labels.SelectorFromSet(labels.Set{"app": "openshift-kube-apiserver"})
+
+// RevisionQuarantineController records how many times the kube-apiserver of each revision crashed: the restarts of
+// the kube-apiserver container of the revision's pods, and the fallbacks of the startup monitor to the previous
+// revision. Only the crashes while the revision rolls out count: once every node runs it, its count is dropped and
+// the restarts of its long running pods are not held against it. Once the latest available revision crashed
+// crashThreshold times it is quarantined: its
+// InstallerPodMutationFunc refuses to create installer pods for it, so it is not installed on further nodes nor
+// retried on the ones it failed on, and the KubeAPIServerRevisionQuarantineDegraded condition is set. The quarantine
+// is lifted when the inputs change and a new revision becomes available; the counts of superseded revisions are
+// dropped.
+type RevisionQuarantineController struct {
+	factory.Controller
+	operatorClient  v1helpers.StaticPodOperatorClient
+	podLister       corev1listers.PodLister
+	configMapLister corev1listers.ConfigMapLister
+	configMapClient coreclientv1.ConfigMapsGetter
+}
+
+func NewRevisionQuarantineController(
+	operatorClient v1helpers.StaticPodOperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	configMapClient coreclientv1.ConfigMapsGetter,
+	recorder events.Recorder,
+) *RevisionQuarantineController {
+	podInformer := kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Pods()
+	configMapInformer := kubeInformersForNamespaces.InformersFor(operatorclient.OperatorNamespace).Core().V1().ConfigMaps()
+	c := &RevisionQuarantineController{
+		operatorClient:  operatorClient,
+		podLister:       podInformer.Lister(),
+		configMapLister: configMapInformer.Lister(),
+		configMapClient: configMapClient,
+	}
+	c.Controller = factory.New().
+		WithInformers(operatorClient.Informer(), podInformer.Informer(), configMapInformer.Informer()).
+		WithSync(c.sync).
+		ToController("RevisionQuarantineController", recorder.WithComponentSuffix("revision-quarantine-controller"))
+	return c
+}
+
+func (c *RevisionQuarantineController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	operatorSpec, status, _, err := c.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+
+	pods, err := c.podLister.Pods(operatorclient.TargetNamespace).List(kubeAPIServerPodSelector)
+	if err != nil {
+		return err
+	}
+	recorded, err := c.recordedCrashes()
+	if err != nil {
+		return err
+	}
+
+	// the restart counts start over when a pod is recreated, the recorded count never goes down while the revision
+	// rolls out. The pods of a revision are created by its rollout, so their restart counts are the crashes since then.
+	crashes := map[int32]int32{}
+	if isRollingOut(status) {
+		for revision, count := range recorded {
+			if revision >= status.LatestAvailableRevision {
+				crashes[revision] = count
+			}
+		}
+		for revision, count := range observedCrashes(pods, status.NodeStatuses) {
+			if revision >= status.LatestAvailableRevision && count > crashes[revision] {
+				crashes[revision] = count
+			}
+		}
+	}
+	if !equalCrashes(recorded, crashes) {
+		data := map[string]string{}
+		for revision, count := range crashes {
+			data[strconv.Itoa(int(revision))] = strconv.Itoa(int(count))
+		}
+		required := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.OperatorNamespace, Name: configMapName},
+			Data:       data,
+		}
+		if _, _, err := resourceapply.ApplyConfigMap(ctx, c.configMapClient, syncCtx.Recorder(), required); err != nil {
+			return err
+		}
+	}
+
+	condition := operatorv1.OperatorCondition{
+		Type:   RevisionQuarantineDegradedConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: AsExpectedReason,
+	}
+	if count := crashes[status.LatestAvailableRevision]; count >= crashThreshold {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = RevisionQuarantinedReason
+		condition.Message = fmt.Sprintf("kube-apiserver revision %d crashed %d times and is quarantined, it is not installed until its inputs change and a new revision is available", status.LatestAvailableRevision, count)
+	}
+
+	if existing := v1helpers.FindOperatorCondition(status.Conditions, RevisionQuarantineDegradedConditionType); existing == nil || existing.Status != condition.Status {
+		if condition.Status == operatorv1.ConditionTrue {
+			syncCtx.Recorder().Warningf("RevisionQuarantined", "%s", condition.Message)
+		} else if existing != nil {
+			syncCtx.Recorder().Eventf("RevisionQuarantineLifted", "No revision is quarantined, the latest available revision is %d", status.LatestAvailableRevision)
+		}
+	}
+
+	_, _, err = v1helpers.UpdateStaticPodStatus(c.operatorClient, v1helpers.UpdateStaticPodConditionFn(condition))
+	return err
+}
+
+// InstallerPodMutationFunc refuses to create the installer pods of a quarantined revision. The installer controller
+// retries them, and moves on as soon as a newer revision is available.
+func (c *RevisionQuarantineController) InstallerPodMutationFunc() installer.InstallerPodMutationFunc {
+	return func(_ *corev1.Pod, nodeName string, _ *operatorv1.StaticPodOperatorSpec, revision int32) error {
+		recorded, err := c.recordedCrashes()
+		if err != nil {
+			klog.Warningf("Unable to read the crash counts of revision %d: %v", revision, err)
+			return nil
+		}
+		if count := recorded[revision]; count >= crashThreshold {
+			return fmt.Errorf("revision %d crashed %d times and is quarantined, not installing it on node %q", revision, count, nodeName)
+		}
+		return nil
+	}
+}
+
+// isRollingOut returns whether some node does not run the latest available revision yet.
+func isRollingOut(status *operatorv1.StaticPodOperatorStatus) bool {
+	for _, nodeStatus := range status.NodeStatuses {
+		if nodeStatus.CurrentRevision != status.LatestAvailableRevision {
+			return true
+		}
+	}
+	return false
+}
+
+// recordedCrashes returns the crash counts per revision recorded in the configmap, none if it does not exist.
+// Entries that cannot be parsed are ignored.
+func (c *RevisionQuarantineController) recordedCrashes() (map[int32]int32, error) {
+	configMap, err := c.configMapLister.ConfigMaps(operatorclient.OperatorNamespace).Get(configMapName)
+	if apierrors.IsNotFound(err) {
+		return map[int32]int32{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	recorded := map[int32]int32{}
+	for key, value := range configMap.Data {
+		revision, err := strconv.ParseInt(key, 10, 32)
+		if err != nil {
+			continue
+		}
+		count, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			continue
+		}
+		recorded[int32(revision)] = int32(count)
+	}
+	return recorded, nil
+}
+
+// observedCrashes returns the crashes per revision currently visible: the restarts of the kube-apiserver containers
+// of the revision's pods plus the fallbacks of the nodes that last failed the revision.
+func observedCrashes(pods []*corev1.Pod, nodeStatuses []operatorv1.NodeStatus) map[int32]int32 {
+	crashes := map[int32]int32{}
+	for _, pod := range pods {
+		revision, err := strconv.ParseInt(pod.Labels["revision"], 10, 32)
+		if err != nil {
+			continue
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name == "kube-apiserver" {
+				crashes[int32(revision)] += status.RestartCount
+			}
+		}
+	}
+	for _, nodeStatus := range nodeStatuses {
+		if nodeStatus.LastFailedRevision > 0 {
+			crashes[nodeStatus.LastFailedRevision] += int32(nodeStatus.LastFallbackCount)
+		}
+	}
+	for revision, count := range crashes {
+		if count == 0 {
+			delete(crashes, revision)
+		}
+	}
+	return crashes
+}
+
+func equalCrashes(a, b map[int32]int32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for revision, count := range a {
+		if other, ok := b[revision]; !ok || other != count {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,159 @@
+package revisionquarantinecontroller
+
+import (
+	"context"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func kubeAPIServerPod(node, revision string, restarts int32) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-kube-apiserver",
+			Name:      "kube-apiserver-" + node,
+			Labels:    map[string]string{"app": "openshift-kube-apiserver", "revision": revision},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "kube-apiserver", RestartCount: restarts}},
+		},
+	}
+}
+
+func TestRevisionQuarantineController(t *testing.T) {
+	podIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	configMapIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	kubeClient := fake.NewSimpleClientset()
+	operatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+		&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+		&operatorv1.StaticPodOperatorStatus{
+			LatestAvailableRevision: 2,
+			NodeStatuses: []operatorv1.NodeStatus{
+				{NodeName: "master-0", CurrentRevision: 1, TargetRevision: 2},
+				{NodeName: "master-1", CurrentRevision: 1},
+			},
+		},
+		nil,
+		nil,
+	)
+	c := &RevisionQuarantineController{
+		operatorClient:  operatorClient,
+		podLister:       corev1listers.NewPodLister(podIndexer),
+		configMapLister: corev1listers.NewConfigMapLister(configMapIndexer),
+		configMapClient: kubeClient.CoreV1(),
+	}
+	installerPodMutation := c.InstallerPodMutationFunc()
+
+	syncCtx := factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))
+	sync := func(pods ...*corev1.Pod) {
+		t.Helper()
+		for _, obj := range podIndexer.List() {
+			if err := podIndexer.Delete(obj); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, pod := range pods {
+			if err := podIndexer.Add(pod); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := c.sync(context.TODO(), syncCtx); err != nil {
+			t.Fatal(err)
+		}
+		// the informer catches up with the write
+		if configMap, err := kubeClient.CoreV1().ConfigMaps("openshift-kube-apiserver-operator").Get(context.TODO(), configMapName, metav1.GetOptions{}); err == nil {
+			if err := configMapIndexer.Update(configMap); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	condition := func() *operatorv1.OperatorCondition {
+		t.Helper()
+		_, status, _, err := operatorClient.GetStaticPodOperatorState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v1helpers.FindOperatorCondition(status.Conditions, RevisionQuarantineDegradedConditionType)
+	}
+
+	// revision 2 crashes, but not often enough to be quarantined
+	sync(kubeAPIServerPod("master-0", "2", 3), kubeAPIServerPod("master-1", "1", 0))
+	if cond := condition(); cond == nil || cond.Status != operatorv1.ConditionFalse {
+		t.Fatalf("expected no quarantine below the threshold, got %#v", cond)
+	}
+	if err := installerPodMutation(&corev1.Pod{}, "master-1", nil, 2); err != nil {
+		t.Fatalf("expected revision 2 to be installable, got %v", err)
+	}
+
+	// the pod is recreated and keeps crashing, the count carries over the restart count reset
+	sync(kubeAPIServerPod("master-0", "2", 1), kubeAPIServerPod("master-1", "1", 0))
+	sync(kubeAPIServerPod("master-0", "2", 2), kubeAPIServerPod("master-1", "1", 0))
+	if cond := condition(); cond.Status != operatorv1.ConditionFalse {
+		t.Fatalf("expected the highest observed count to be kept, not summed, got %#v", cond)
+	}
+
+	// the startup monitor falls back to the previous revision twice
+	updateStatus := func(update func(status *operatorv1.StaticPodOperatorStatus)) {
+		t.Helper()
+		_, status, resourceVersion, err := operatorClient.GetStaticPodOperatorState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		status = status.DeepCopy()
+		update(status)
+		if _, err := operatorClient.UpdateStaticPodOperatorStatus(resourceVersion, status); err != nil {
+			t.Fatal(err)
+		}
+	}
+	updateStatus(func(status *operatorv1.StaticPodOperatorStatus) {
+		status.NodeStatuses[0].LastFailedRevision = 2
+		status.NodeStatuses[0].LastFallbackCount = 2
+	})
+	sync(kubeAPIServerPod("master-0", "2", 3), kubeAPIServerPod("master-1", "1", 0))
+	if cond := condition(); cond.Status != operatorv1.ConditionTrue || cond.Reason != RevisionQuarantinedReason {
+		t.Fatalf("expected revision 2 to be quarantined, got %#v", cond)
+	}
+	if err := installerPodMutation(&corev1.Pod{}, "master-1", nil, 2); err == nil {
+		t.Fatalf("expected no installer pod for the quarantined revision")
+	}
+
+	// the quarantine is recorded, it holds once the crashing pod is gone
+	sync(kubeAPIServerPod("master-0", "1", 0), kubeAPIServerPod("master-1", "1", 0))
+	if cond := condition(); cond.Status != operatorv1.ConditionTrue {
+		t.Fatalf("expected revision 2 to stay quarantined, got %#v", cond)
+	}
+
+	// the inputs change and revision 3 is available
+	updateStatus(func(status *operatorv1.StaticPodOperatorStatus) { status.LatestAvailableRevision = 3 })
+	sync(kubeAPIServerPod("master-0", "1", 0), kubeAPIServerPod("master-1", "1", 0))
+	if cond := condition(); cond.Status != operatorv1.ConditionFalse {
+		t.Fatalf("expected the quarantine to be lifted by the new revision, got %#v", cond)
+	}
+	if err := installerPodMutation(&corev1.Pod{}, "master-0", nil, 3); err != nil {
+		t.Fatalf("expected revision 3 to be installable, got %v", err)
+	}
+	if recorded, err := c.recordedCrashes(); err != nil || len(recorded) > 0 {
+		t.Errorf("expected the counts of the superseded revision to be dropped, got %v, %v", recorded, err)
+	}
+
+	// revision 3 rolls out to every node, the later restarts of its pods do not count
+	updateStatus(func(status *operatorv1.StaticPodOperatorStatus) {
+		status.NodeStatuses[0].CurrentRevision = 3
+		status.NodeStatuses[1].CurrentRevision = 3
+	})
+	sync(kubeAPIServerPod("master-0", "3", 4), kubeAPIServerPod("master-1", "3", 6))
+	if cond := condition(); cond.Status != operatorv1.ConditionFalse {
+		t.Fatalf("expected the restarts of a rolled out revision not to quarantine it, got %#v", cond)
+	}
+	if recorded, err := c.recordedCrashes(); err != nil || len(recorded) > 0 {
+		t.Errorf("expected no counts for a rolled out revision, got %v, %v", recorded, err)
+	}
+}
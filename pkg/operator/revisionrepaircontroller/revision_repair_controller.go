@@ -0,0 +1,355 @@
+package revisionrepaircontroller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/staticpod/controller/revision"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	coreclientv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+)
+
+const (
+	// MissingRevisionResourcesDegradedConditionType is True while a resource of a revision a node is currently on is
+	// missing and cannot be regenerated because its source is gone or changed since the revision was created.
+	MissingRevisionResourcesDegradedConditionType = "MissingRevisionResourcesDegraded"
+
+	SourceMissingOrChangedReason = "SourceMissingOrChanged"
+	AsExpectedReason             = "AsExpected"
+
+	// ResourceHashesAnnotation on the revision-status configmap of a revision records the hashes of the configmaps
+	// and secrets of the revision, taken while they exist, as a JSON object keyed by "configmap/<source name>" and
+	// "secret/<source name>".
+	ResourceHashesAnnotation = "kubeapiserver.operator.openshift.io/revision-resource-hashes"
+
+	revisionStatusConfigMapName = "revision-status"
+)
+
+// RevisionRepairController verifies that the configmaps and secrets of the older revisions the nodes are currently on
+// exist. The kubelet restarts the kube-apiserver from the files the installer wrote, but the installer of a node
+// recovering from a reboot or the startup monitor falling back read them again, and fail on a revision resource
+// deleted out from under the running revision.
+//
+// A revision is immutable, so a missing copy is only regenerated from its source, the unrevisioned resource the
+// revision controller copies it from, if the source provably still holds the content of the revision: the controller
+// records the hashes of the copies of the latest and the current revisions in their revision-status configmap, and
+// regenerates a copy only from a source of the recorded hash, owned by the revision-status configmap like the revision
+// controller does. The copies of the latest available revision are left to the revision controller, which may still
+// be creating them.
+//
+// Nothing is fabricated: a copy whose source is gone, changed since the revision was created, or whose hash was never
+// recorded is reported by the MissingRevisionResourcesDegraded condition. Optional resources are not repaired, a
+// missing copy of one cannot be told apart from one absent when the revision was created.
+type RevisionRepairController struct {
+	targetNamespace string
+	configMaps      []string
+	secrets         []string
+
+	operatorClient  v1helpers.StaticPodOperatorClient
+	configMapLister corev1listers.ConfigMapNamespaceLister
+	secretLister    corev1listers.SecretNamespaceLister
+	configMapClient coreclientv1.ConfigMapsGetter
+	secretClient    coreclientv1.SecretsGetter
+}
+
+func NewRevisionRepairController(
+	targetNamespace string,
+	configMaps []revision.RevisionResource,
+	secrets []revision.RevisionResource,
+	operatorClient v1helpers.StaticPodOperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	configMapClient coreclientv1.ConfigMapsGetter,
+	secretClient coreclientv1.SecretsGetter,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &RevisionRepairController{
+		targetNamespace: targetNamespace,
+		configMaps:      requiredNames(configMaps),
+		secrets:         requiredNames(secrets),
+		operatorClient:  operatorClient,
+		configMapLister: kubeInformersForNamespaces.InformersFor(targetNamespace).Core().V1().ConfigMaps().Lister().ConfigMaps(targetNamespace),
+		secretLister:    kubeInformersForNamespaces.InformersFor(targetNamespace).Core().V1().Secrets().Lister().Secrets(targetNamespace),
+		configMapClient: configMapClient,
+		secretClient:    secretClient,
+	}
+
+	return factory.New().
+		WithInformers(
+			operatorClient.Informer(),
+			kubeInformersForNamespaces.InformersFor(targetNamespace).Core().V1().ConfigMaps().Informer(),
+			kubeInformersForNamespaces.InformersFor(targetNamespace).Core().V1().Secrets().Informer(),
+		).
+		WithSync(c.sync).
+		ToController("RevisionRepairController", eventRecorder.WithComponentSuffix("revision-repair-controller"))
+}
+
+func (c *RevisionRepairController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	operatorSpec, operatorStatus, _, err := c.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+
+	// the hashes of the latest available revision are recorded before the nodes move on from it
+	revisions := sets.NewInt32()
+	if operatorStatus.LatestAvailableRevision > 0 {
+		revisions.Insert(operatorStatus.LatestAvailableRevision)
+	}
+	for _, ns := range operatorStatus.NodeStatuses {
+		if ns.CurrentRevision > 0 {
+			revisions.Insert(ns.CurrentRevision)
+		}
+	}
+
+	var errs []error
+	var unrepairable []string
+	for _, revision := range revisions.List() {
+		status, err := c.configMapLister.Get(nameFor(revisionStatusConfigMapName, revision))
+		if apierrors.IsNotFound(err) {
+			status = nil
+		} else if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		recorded, err := recordedHashes(status)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		hashes := map[string]string{}
+		for key, hash := range recorded {
+			hashes[key] = hash
+		}
+
+		repair := revision != operatorStatus.LatestAvailableRevision
+		for _, name := range c.configMaps {
+			missing, err := c.repairConfigMap(ctx, syncCtx.Recorder(), name, revision, status, hashes, repair)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			if missing {
+				unrepairable = append(unrepairable, fmt.Sprintf("configmap/%s", nameFor(name, revision)))
+			}
+		}
+		for _, name := range c.secrets {
+			missing, err := c.repairSecret(ctx, syncCtx.Recorder(), name, revision, status, hashes, repair)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			if missing {
+				unrepairable = append(unrepairable, fmt.Sprintf("secret/%s", nameFor(name, revision)))
+			}
+		}
+
+		if status != nil && !reflect.DeepEqual(hashes, recorded) {
+			if err := c.recordHashes(ctx, status, hashes); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	condition := operatorv1.OperatorCondition{
+		Type:   MissingRevisionResourcesDegradedConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: AsExpectedReason,
+	}
+	if len(unrepairable) > 0 {
+		sort.Strings(unrepairable)
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = SourceMissingOrChangedReason
+		condition.Message = fmt.Sprintf("Resources of the current revisions are missing and their source is gone or changed since the revision was created, the kube-apiserver cannot be reinstalled on them: %s", strings.Join(unrepairable, ", "))
+	}
+	if _, _, err := v1helpers.UpdateStaticPodStatus(c.operatorClient, v1helpers.UpdateStaticPodConditionFn(condition)); err != nil {
+		errs = append(errs, err)
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// repairConfigMap records the hash of the copy of the configmap for the revision in hashes. If repair is set and the
+// copy is missing, it regenerates it from a source of the recorded hash. It returns whether the copy is missing and
+// cannot be regenerated.
+func (c *RevisionRepairController) repairConfigMap(ctx context.Context, recorder events.Recorder, name string, revision int32, status *corev1.ConfigMap, hashes map[string]string, repair bool) (bool, error) {
+	key := "configmap/" + name
+	targetName := nameFor(name, revision)
+	existing, err := c.configMapLister.Get(targetName)
+	if err == nil {
+		if _, ok := hashes[key]; !ok {
+			hashes[key] = configMapHash(existing)
+		}
+		return false, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return false, err
+	}
+	if !repair {
+		return false, nil
+	}
+	source, err := c.configMapLister.Get(name)
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if hash, ok := hashes[key]; !ok || hash != configMapHash(source) {
+		return true, nil
+	}
+
+	required := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: c.targetNamespace, Name: targetName, OwnerReferences: ownerReferences(status)},
+		Data:       source.Data,
+		BinaryData: source.BinaryData,
+	}
+	if _, _, err := resourceapply.ApplyConfigMap(ctx, c.configMapClient, recorder, required); err != nil {
+		return false, fmt.Errorf("unable to regenerate revision configmap %s/%s: %w", c.targetNamespace, targetName, err)
+	}
+	recorder.Warningf("RevisionConfigMapRegenerated", "Regenerated missing configmap %s/%s of current revision %d from configmap %s/%s", c.targetNamespace, targetName, revision, c.targetNamespace, name)
+	return false, nil
+}
+
+// repairSecret records the hash of the copy of the secret for the revision in hashes. If repair is set and the copy
+// is missing, it regenerates it from a source of the recorded hash. It returns whether the copy is missing and cannot
+// be regenerated.
+func (c *RevisionRepairController) repairSecret(ctx context.Context, recorder events.Recorder, name string, revision int32, status *corev1.ConfigMap, hashes map[string]string, repair bool) (bool, error) {
+	key := "secret/" + name
+	targetName := nameFor(name, revision)
+	existing, err := c.secretLister.Get(targetName)
+	if err == nil {
+		if _, ok := hashes[key]; !ok {
+			hashes[key] = secretHash(existing)
+		}
+		return false, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return false, err
+	}
+	if !repair {
+		return false, nil
+	}
+	source, err := c.secretLister.Get(name)
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if hash, ok := hashes[key]; !ok || hash != secretHash(source) {
+		return true, nil
+	}
+
+	required := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: c.targetNamespace, Name: targetName, OwnerReferences: ownerReferences(status)},
+		Type:       source.Type,
+		Data:       source.Data,
+	}
+	if _, _, err := resourceapply.ApplySecret(ctx, c.secretClient, recorder, required); err != nil {
+		return false, fmt.Errorf("unable to regenerate revision secret %s/%s: %w", c.targetNamespace, targetName, err)
+	}
+	recorder.Warningf("RevisionSecretRegenerated", "Regenerated missing secret %s/%s of current revision %d from secret %s/%s", c.targetNamespace, targetName, revision, c.targetNamespace, name)
+	return false, nil
+}
+
+// recordHashes sets the hashes in the ResourceHashesAnnotation of the revision-status configmap.
+func (c *RevisionRepairController) recordHashes(ctx context.Context, status *corev1.ConfigMap, hashes map[string]string) error {
+	value, err := json.Marshal(hashes)
+	if err != nil {
+		return err
+	}
+	status = status.DeepCopy()
+	if status.Annotations == nil {
+		status.Annotations = map[string]string{}
+	}
+	status.Annotations[ResourceHashesAnnotation] = string(value)
+	if _, err := c.configMapClient.ConfigMaps(c.targetNamespace).Update(ctx, status, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("unable to record the resource hashes of configmap %s/%s: %w", c.targetNamespace, status.Name, err)
+	}
+	return nil
+}
+
+// recordedHashes returns the hashes recorded in the revision-status configmap, none if it is gone.
+func recordedHashes(status *corev1.ConfigMap) (map[string]string, error) {
+	hashes := map[string]string{}
+	if status == nil {
+		return hashes, nil
+	}
+	value, ok := status.Annotations[ResourceHashesAnnotation]
+	if !ok {
+		return hashes, nil
+	}
+	if err := json.Unmarshal([]byte(value), &hashes); err != nil {
+		return nil, fmt.Errorf("bad %s annotation of configmap %s/%s: %w", ResourceHashesAnnotation, status.Namespace, status.Name, err)
+	}
+	return hashes, nil
+}
+
+// configMapHash returns the hash of the content of the configmap, the revision controller copies its data.
+func configMapHash(configMap *corev1.ConfigMap) string {
+	return contentHash(struct {
+		Data       map[string]string `json:"data,omitempty"`
+		BinaryData map[string][]byte `json:"binaryData,omitempty"`
+	}{configMap.Data, configMap.BinaryData})
+}
+
+// secretHash returns the hash of the content of the secret, the revision controller copies its type and data.
+func secretHash(secret *corev1.Secret) string {
+	return contentHash(struct {
+		Type corev1.SecretType `json:"type,omitempty"`
+		Data map[string][]byte `json:"data,omitempty"`
+	}{secret.Type, secret.Data})
+}
+
+func contentHash(content interface{}) string {
+	// the maps are encoded with sorted keys
+	value, err := json.Marshal(content)
+	if err != nil {
+		panic(err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(value))
+}
+
+// ownerReferences returns the owner reference to the revision-status configmap of the revision, none if it is gone.
+func ownerReferences(status *corev1.ConfigMap) []metav1.OwnerReference {
+	if status == nil {
+		return nil
+	}
+	return []metav1.OwnerReference{{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Name:       status.Name,
+		UID:        status.UID,
+	}}
+}
+
+// requiredNames returns the names of the resources that are not optional.
+func requiredNames(resources []revision.RevisionResource) []string {
+	var names []string
+	for _, resource := range resources {
+		if !resource.Optional {
+			names = append(names, resource.Name)
+		}
+	}
+	return names
+}
+
+func nameFor(name string, revision int32) string {
+	return fmt.Sprintf("%s-%d", name, revision)
+}
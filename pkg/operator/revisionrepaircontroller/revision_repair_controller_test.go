@@ -0,0 +1,349 @@
+package revisionrepaircontroller
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/staticpod/controller/revision"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+)
+
+const targetNamespace = "openshift-kube-apiserver"
+
+func configMap(name string, data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: targetNamespace, Name: name, UID: types.UID("uid-" + name)}, Data: data}
+}
+
+func secret(name string, data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: targetNamespace, Name: name}, Type: corev1.SecretTypeOpaque, Data: data}
+}
+
+// revisionStatus returns the revision-status configmap with the hashes of the given copies recorded.
+func revisionStatus(t *testing.T, name string, copies ...runtime.Object) *corev1.ConfigMap {
+	t.Helper()
+	status := configMap(name, nil)
+	if len(copies) == 0 {
+		return status
+	}
+	hashes := map[string]string{}
+	for _, obj := range copies {
+		switch obj := obj.(type) {
+		case *corev1.ConfigMap:
+			hashes["configmap/"+obj.Name] = configMapHash(obj)
+		case *corev1.Secret:
+			hashes["secret/"+obj.Name] = secretHash(obj)
+		}
+	}
+	value, err := json.Marshal(hashes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Annotations = map[string]string{ResourceHashesAnnotation: string(value)}
+	return status
+}
+
+func TestRevisionRepairController(t *testing.T) {
+	tests := []struct {
+		name   string
+		status operatorv1.StaticPodOperatorStatus
+		// objects are the resources in the target namespace
+		objects []runtime.Object
+
+		expectedConfigMaps map[string]*corev1.ConfigMap
+		expectedSecrets    map[string]*corev1.Secret
+		// expectedHashes are the hashes newly recorded, by revision-status configmap
+		expectedHashes    map[string]map[string]string
+		expectedCondition operatorv1.ConditionStatus
+		expectedMessage   string
+	}{
+		{
+			name: "nothing missing",
+			status: operatorv1.StaticPodOperatorStatus{LatestAvailableRevision: 4, NodeStatuses: []operatorv1.NodeStatus{
+				{NodeName: "master-0", CurrentRevision: 3},
+			}},
+			objects: []runtime.Object{
+				configMap("revision-status-3", nil),
+				configMap("config", map[string]string{"config.yaml": "new"}),
+				configMap("config-3", map[string]string{"config.yaml": "old"}),
+				secret("etcd-client", nil),
+				secret("etcd-client-3", nil),
+			},
+			expectedHashes: map[string]map[string]string{
+				"revision-status-3": {
+					"configmap/config":   configMapHash(configMap("config", map[string]string{"config.yaml": "old"})),
+					"secret/etcd-client": secretHash(secret("etcd-client", nil)),
+				},
+			},
+			expectedCondition: operatorv1.ConditionFalse,
+		},
+		{
+			name: "deleted revision configmap and secret with source present",
+			status: operatorv1.StaticPodOperatorStatus{LatestAvailableRevision: 4, NodeStatuses: []operatorv1.NodeStatus{
+				{NodeName: "master-0", CurrentRevision: 3, TargetRevision: 4},
+				{NodeName: "master-1", CurrentRevision: 3},
+			}},
+			objects: []runtime.Object{
+				revisionStatus(t, "revision-status-3", configMap("config", map[string]string{"config.yaml": "current"}), secret("etcd-client", map[string][]byte{"tls.key": []byte("key")})),
+				configMap("config", map[string]string{"config.yaml": "current"}),
+				secret("etcd-client", map[string][]byte{"tls.key": []byte("key")}),
+				// the copies of the latest revision are not created yet
+				configMap("revision-status-4", nil),
+			},
+			expectedConfigMaps: map[string]*corev1.ConfigMap{
+				"config-3": {
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace:       targetNamespace,
+						Name:            "config-3",
+						OwnerReferences: []metav1.OwnerReference{{APIVersion: "v1", Kind: "ConfigMap", Name: "revision-status-3", UID: "uid-revision-status-3"}},
+					},
+					Data: map[string]string{"config.yaml": "current"},
+				},
+			},
+			expectedSecrets: map[string]*corev1.Secret{
+				"etcd-client-3": {
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace:       targetNamespace,
+						Name:            "etcd-client-3",
+						OwnerReferences: []metav1.OwnerReference{{APIVersion: "v1", Kind: "ConfigMap", Name: "revision-status-3", UID: "uid-revision-status-3"}},
+					},
+					Type: corev1.SecretTypeOpaque,
+					Data: map[string][]byte{"tls.key": []byte("key")},
+				},
+			},
+			expectedCondition: operatorv1.ConditionFalse,
+		},
+		{
+			name: "the latest revision is left to the revision controller",
+			status: operatorv1.StaticPodOperatorStatus{LatestAvailableRevision: 4, NodeStatuses: []operatorv1.NodeStatus{
+				{NodeName: "master-0", CurrentRevision: 4},
+				{NodeName: "master-1", CurrentRevision: 3, TargetRevision: 4},
+			}},
+			objects: []runtime.Object{
+				configMap("revision-status-3", nil),
+				configMap("revision-status-4", nil),
+				configMap("config", map[string]string{"config.yaml": "current"}),
+				configMap("config-3", map[string]string{"config.yaml": "old"}),
+				secret("etcd-client", nil),
+				secret("etcd-client-3", nil),
+				// config-4 and etcd-client-4 are not created yet
+			},
+			expectedHashes: map[string]map[string]string{
+				"revision-status-3": {
+					"configmap/config":   configMapHash(configMap("config", map[string]string{"config.yaml": "old"})),
+					"secret/etcd-client": secretHash(secret("etcd-client", nil)),
+				},
+			},
+			expectedCondition: operatorv1.ConditionFalse,
+		},
+		{
+			name: "deleted revision configmap whose source changed since the revision was created",
+			status: operatorv1.StaticPodOperatorStatus{LatestAvailableRevision: 4, NodeStatuses: []operatorv1.NodeStatus{
+				{NodeName: "master-0", CurrentRevision: 3},
+			}},
+			objects: []runtime.Object{
+				revisionStatus(t, "revision-status-3", configMap("config", map[string]string{"config.yaml": "old"}), secret("etcd-client", nil)),
+				configMap("config", map[string]string{"config.yaml": "new"}),
+				secret("etcd-client", nil),
+				secret("etcd-client-3", nil),
+			},
+			expectedCondition: operatorv1.ConditionTrue,
+			expectedMessage:   "configmap/config-3",
+		},
+		{
+			name: "deleted revision secret without a recorded hash",
+			status: operatorv1.StaticPodOperatorStatus{LatestAvailableRevision: 4, NodeStatuses: []operatorv1.NodeStatus{
+				{NodeName: "master-0", CurrentRevision: 3},
+			}},
+			objects: []runtime.Object{
+				configMap("revision-status-3", nil),
+				configMap("config", map[string]string{"config.yaml": "old"}),
+				configMap("config-3", map[string]string{"config.yaml": "old"}),
+				secret("etcd-client", nil),
+			},
+			expectedHashes: map[string]map[string]string{
+				"revision-status-3": {
+					"configmap/config": configMapHash(configMap("config", map[string]string{"config.yaml": "old"})),
+				},
+			},
+			expectedCondition: operatorv1.ConditionTrue,
+			expectedMessage:   "secret/etcd-client-3",
+		},
+		{
+			name: "the hashes of the latest revision are recorded",
+			status: operatorv1.StaticPodOperatorStatus{LatestAvailableRevision: 4, NodeStatuses: []operatorv1.NodeStatus{
+				{NodeName: "master-0", CurrentRevision: 3, TargetRevision: 4},
+			}},
+			objects: []runtime.Object{
+				revisionStatus(t, "revision-status-3", configMap("config", map[string]string{"config.yaml": "old"}), secret("etcd-client", nil)),
+				configMap("revision-status-4", nil),
+				configMap("config", map[string]string{"config.yaml": "new"}),
+				configMap("config-3", map[string]string{"config.yaml": "old"}),
+				configMap("config-4", map[string]string{"config.yaml": "new"}),
+				secret("etcd-client", nil),
+				secret("etcd-client-3", nil),
+				secret("etcd-client-4", nil),
+			},
+			expectedHashes: map[string]map[string]string{
+				"revision-status-4": {
+					"configmap/config":   configMapHash(configMap("config", map[string]string{"config.yaml": "new"})),
+					"secret/etcd-client": secretHash(secret("etcd-client", nil)),
+				},
+			},
+			expectedCondition: operatorv1.ConditionFalse,
+		},
+		{
+			name: "deleted revision configmap with source absent",
+			status: operatorv1.StaticPodOperatorStatus{LatestAvailableRevision: 4, NodeStatuses: []operatorv1.NodeStatus{
+				{NodeName: "master-0", CurrentRevision: 3},
+			}},
+			objects: []runtime.Object{
+				configMap("revision-status-3", nil),
+				secret("etcd-client", nil),
+				secret("etcd-client-3", nil),
+			},
+			expectedHashes: map[string]map[string]string{
+				"revision-status-3": {
+					"secret/etcd-client": secretHash(secret("etcd-client", nil)),
+				},
+			},
+			expectedCondition: operatorv1.ConditionTrue,
+			expectedMessage:   "configmap/config-3",
+		},
+		{
+			name: "optional resources are not repaired",
+			status: operatorv1.StaticPodOperatorStatus{LatestAvailableRevision: 4, NodeStatuses: []operatorv1.NodeStatus{
+				{NodeName: "master-0", CurrentRevision: 3},
+			}},
+			objects: []runtime.Object{
+				configMap("revision-status-3", nil),
+				configMap("config-3", nil),
+				configMap("oauth-metadata", map[string]string{"oauthMetadata": "{}"}),
+				secret("etcd-client-3", nil),
+				secret("audit-webhook", nil),
+			},
+			expectedHashes: map[string]map[string]string{
+				"revision-status-3": {
+					"configmap/config":   configMapHash(configMap("config", nil)),
+					"secret/etcd-client": secretHash(secret("etcd-client", nil)),
+				},
+			},
+			expectedCondition: operatorv1.ConditionFalse,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configMapIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			secretIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for _, obj := range test.objects {
+				indexer := configMapIndexer
+				if _, ok := obj.(*corev1.Secret); ok {
+					indexer = secretIndexer
+				}
+				if err := indexer.Add(obj); err != nil {
+					t.Fatal(err)
+				}
+			}
+			kubeClient := fake.NewSimpleClientset(test.objects...)
+			operatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+				&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+				&test.status,
+				nil,
+				nil,
+			)
+
+			c := &RevisionRepairController{
+				targetNamespace: targetNamespace,
+				configMaps:      requiredNames([]revision.RevisionResource{{Name: "config"}, {Name: "oauth-metadata", Optional: true}}),
+				secrets:         requiredNames([]revision.RevisionResource{{Name: "etcd-client"}, {Name: "audit-webhook", Optional: true}}),
+				operatorClient:  operatorClient,
+				configMapLister: corev1listers.NewConfigMapLister(configMapIndexer).ConfigMaps(targetNamespace),
+				secretLister:    corev1listers.NewSecretLister(secretIndexer).Secrets(targetNamespace),
+				configMapClient: kubeClient.CoreV1(),
+				secretClient:    kubeClient.CoreV1(),
+			}
+			recorder := events.NewInMemoryRecorder("test")
+			if err := c.sync(context.TODO(), factory.NewSyncContext("test", recorder)); err != nil {
+				t.Fatal(err)
+			}
+
+			createdConfigMaps, createdSecrets := map[string]*corev1.ConfigMap{}, map[string]*corev1.Secret{}
+			recordedHashes := map[string]map[string]string{}
+			for _, action := range kubeClient.Actions() {
+				if updateAction, ok := action.(clienttesting.UpdateAction); ok && action.GetVerb() == "update" {
+					if status, ok := updateAction.GetObject().(*corev1.ConfigMap); ok {
+						hashes := map[string]string{}
+						if err := json.Unmarshal([]byte(status.Annotations[ResourceHashesAnnotation]), &hashes); err != nil {
+							t.Fatal(err)
+						}
+						recordedHashes[status.Name] = hashes
+					}
+					continue
+				}
+				createAction, ok := action.(clienttesting.CreateAction)
+				if !ok {
+					continue
+				}
+				switch obj := createAction.GetObject().(type) {
+				case *corev1.ConfigMap:
+					createdConfigMaps[obj.Name] = obj
+				case *corev1.Secret:
+					createdSecrets[obj.Name] = obj
+				}
+			}
+			if test.expectedConfigMaps == nil {
+				test.expectedConfigMaps = map[string]*corev1.ConfigMap{}
+			}
+			if test.expectedSecrets == nil {
+				test.expectedSecrets = map[string]*corev1.Secret{}
+			}
+			if diff := cmp.Diff(test.expectedConfigMaps, createdConfigMaps); diff != "" {
+				t.Errorf("unexpected regenerated configmaps (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.expectedSecrets, createdSecrets); diff != "" {
+				t.Errorf("unexpected regenerated secrets (-want +got):\n%s", diff)
+			}
+			if test.expectedHashes == nil {
+				test.expectedHashes = map[string]map[string]string{}
+			}
+			if diff := cmp.Diff(test.expectedHashes, recordedHashes); diff != "" {
+				t.Errorf("unexpected recorded hashes (-want +got):\n%s", diff)
+			}
+			regenerated := 0
+			for _, event := range recorder.Events() {
+				if event.Reason == "RevisionConfigMapRegenerated" || event.Reason == "RevisionSecretRegenerated" {
+					regenerated++
+				}
+			}
+			if expected := len(test.expectedConfigMaps) + len(test.expectedSecrets); regenerated != expected {
+				t.Errorf("expected %d regeneration events, got %d", expected, regenerated)
+			}
+
+			_, status, _, err := operatorClient.GetStaticPodOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			condition := v1helpers.FindOperatorCondition(status.Conditions, MissingRevisionResourcesDegradedConditionType)
+			if condition == nil || condition.Status != test.expectedCondition {
+				t.Fatalf("expected condition status %q, got %#v", test.expectedCondition, condition)
+			}
+			if !strings.Contains(condition.Message, test.expectedMessage) {
+				t.Errorf("expected the message to contain %q, got %q", test.expectedMessage, condition.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package rolloutfreeze
+
+import (
+	"fmt"
+
+	"github.com/openshift/library-go/pkg/operator/revisioncontroller"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/informers"
+	coreinformers "k8s.io/client-go/informers/core"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// FrozenRevisionInputs returns the kube informers for the static pod controllers, which read the sources of the
+// revisioned configmaps and secrets through them. While isFrozen, the sources in the target namespace read as their
+// copies in the latest available revision, so that the revision controller finds the latest revision current and
+// creates none. The content changed in the meantime makes the next revision once the freeze is lifted.
+func FrozenRevisionInputs(
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	operatorClient v1helpers.StaticPodOperatorClient,
+	targetNamespace string,
+	revisionConfigMaps, revisionSecrets []revisioncontroller.RevisionResource,
+	isFrozen FreezeFunc,
+) v1helpers.KubeInformersForNamespaces {
+	return &frozenInformersForNamespaces{
+		KubeInformersForNamespaces: kubeInformersForNamespaces,
+		inputs: &frozenInputs{
+			operatorClient: operatorClient,
+			configMaps:     resourceNames(revisionConfigMaps),
+			secrets:        resourceNames(revisionSecrets),
+			isFrozen:       isFrozen,
+		},
+		targetNamespace: targetNamespace,
+	}
+}
+
+func resourceNames(resources []revisioncontroller.RevisionResource) sets.String {
+	names := sets.NewString()
+	for _, resource := range resources {
+		names.Insert(resource.Name)
+	}
+	return names
+}
+
+// frozenInputs decides which name the revision inputs are read from.
+type frozenInputs struct {
+	operatorClient v1helpers.StaticPodOperatorClient
+	configMaps     sets.String
+	secrets        sets.String
+	isFrozen       FreezeFunc
+}
+
+// frozenName returns the name of the copy in the latest available revision to read instead of the named source
+// while rollouts are frozen.
+func (f *frozenInputs) frozenName(sources sets.String, name string) (string, bool) {
+	if !sources.Has(name) {
+		return "", false
+	}
+	reason, frozen := f.isFrozen()
+	if !frozen {
+		return "", false
+	}
+	_, status, _, err := f.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		klog.Warningf("Unable to get the latest available revision, reading %s unfrozen: %v", name, err)
+		return "", false
+	}
+	if status.LatestAvailableRevision == 0 {
+		return "", false
+	}
+	klog.V(4).Infof("Rollouts are frozen, reading %s from revision %d: %q", name, status.LatestAvailableRevision, reason)
+	return fmt.Sprintf("%s-%d", name, status.LatestAvailableRevision), true
+}
+
+type frozenInformersForNamespaces struct {
+	v1helpers.KubeInformersForNamespaces
+	inputs          *frozenInputs
+	targetNamespace string
+}
+
+func (i *frozenInformersForNamespaces) InformersFor(namespace string) informers.SharedInformerFactory {
+	factory := i.KubeInformersForNamespaces.InformersFor(namespace)
+	if namespace != i.targetNamespace || factory == nil {
+		return factory
+	}
+	return &frozenInformerFactory{SharedInformerFactory: factory, inputs: i.inputs}
+}
+
+type frozenInformerFactory struct {
+	informers.SharedInformerFactory
+	inputs *frozenInputs
+}
+
+func (f *frozenInformerFactory) Core() coreinformers.Interface {
+	return &frozenCoreInformers{Interface: f.SharedInformerFactory.Core(), inputs: f.inputs}
+}
+
+type frozenCoreInformers struct {
+	coreinformers.Interface
+	inputs *frozenInputs
+}
+
+func (c *frozenCoreInformers) V1() corev1informers.Interface {
+	return &frozenCoreV1Informers{Interface: c.Interface.V1(), inputs: c.inputs}
+}
+
+type frozenCoreV1Informers struct {
+	corev1informers.Interface
+	inputs *frozenInputs
+}
+
+func (c *frozenCoreV1Informers) ConfigMaps() corev1informers.ConfigMapInformer {
+	return &frozenConfigMapInformer{ConfigMapInformer: c.Interface.ConfigMaps(), inputs: c.inputs}
+}
+
+func (c *frozenCoreV1Informers) Secrets() corev1informers.SecretInformer {
+	return &frozenSecretInformer{SecretInformer: c.Interface.Secrets(), inputs: c.inputs}
+}
+
+type frozenConfigMapInformer struct {
+	corev1informers.ConfigMapInformer
+	inputs *frozenInputs
+}
+
+func (i *frozenConfigMapInformer) Lister() corev1listers.ConfigMapLister {
+	return &frozenConfigMapLister{ConfigMapLister: i.ConfigMapInformer.Lister(), inputs: i.inputs}
+}
+
+type frozenConfigMapLister struct {
+	corev1listers.ConfigMapLister
+	inputs *frozenInputs
+}
+
+func (l *frozenConfigMapLister) ConfigMaps(namespace string) corev1listers.ConfigMapNamespaceLister {
+	return &frozenConfigMapNamespaceLister{ConfigMapNamespaceLister: l.ConfigMapLister.ConfigMaps(namespace), inputs: l.inputs}
+}
+
+type frozenConfigMapNamespaceLister struct {
+	corev1listers.ConfigMapNamespaceLister
+	inputs *frozenInputs
+}
+
+func (l *frozenConfigMapNamespaceLister) Get(name string) (*corev1.ConfigMap, error) {
+	frozenName, frozen := l.inputs.frozenName(l.inputs.configMaps, name)
+	if !frozen {
+		return l.ConfigMapNamespaceLister.Get(name)
+	}
+	configMap, err := l.ConfigMapNamespaceLister.Get(frozenName)
+	if err != nil {
+		return nil, err
+	}
+	configMap = configMap.DeepCopy()
+	configMap.Name = name
+	return configMap, nil
+}
+
+type frozenSecretInformer struct {
+	corev1informers.SecretInformer
+	inputs *frozenInputs
+}
+
+func (i *frozenSecretInformer) Lister() corev1listers.SecretLister {
+	return &frozenSecretLister{SecretLister: i.SecretInformer.Lister(), inputs: i.inputs}
+}
+
+type frozenSecretLister struct {
+	corev1listers.SecretLister
+	inputs *frozenInputs
+}
+
+func (l *frozenSecretLister) Secrets(namespace string) corev1listers.SecretNamespaceLister {
+	return &frozenSecretNamespaceLister{SecretNamespaceLister: l.SecretLister.Secrets(namespace), inputs: l.inputs}
+}
+
+type frozenSecretNamespaceLister struct {
+	corev1listers.SecretNamespaceLister
+	inputs *frozenInputs
+}
+
+func (l *frozenSecretNamespaceLister) Get(name string) (*corev1.Secret, error) {
+	frozenName, frozen := l.inputs.frozenName(l.inputs.secrets, name)
+	if !frozen {
+		return l.SecretNamespaceLister.Get(name)
+	}
+	secret, err := l.SecretNamespaceLister.Get(frozenName)
+	if err != nil {
+		return nil, err
+	}
+	secret = secret.DeepCopy()
+	secret.Name = name
+	return secret, nil
+}
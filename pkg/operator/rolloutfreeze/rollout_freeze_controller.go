@@ -0,0 +1,111 @@
+package rolloutfreeze
+
+import (
+	"context"
+	"fmt"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// RolloutFreezeAnnotation on the kubeapiserver/cluster operator config freezes the kube-apiserver on its latest
+	// available revision: new revisions are not created, and so not rolled out, until it is removed.
+	// The value is the reason for the freeze, e.g. a link to the incident.
+	RolloutFreezeAnnotation = "kubeapiserver.operator.openshift.io/rollout-freeze"
+
+	RolloutFrozenConditionType = "KubeAPIServerRolloutFrozen"
+
+	RolloutFrozenReason = "RolloutFreezeRequested"
+	AsExpectedReason    = "AsExpected"
+)
+
+// FreezeFunc returns whether rollouts are frozen and the reason for the freeze.
+type FreezeFunc func() (reason string, frozen bool)
+
+// AnnotationFreezeFunc returns a FreezeFunc reading the RolloutFreezeAnnotation of the operator config
+// from the operator informer.
+func AnnotationFreezeFunc(operatorInformer cache.SharedIndexInformer) FreezeFunc {
+	return func() (string, bool) {
+		obj, exists, err := operatorInformer.GetStore().GetByKey("cluster")
+		if err != nil || !exists {
+			return "", false
+		}
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			klog.Warningf("Unable to read the %s annotation: %v", RolloutFreezeAnnotation, err)
+			return "", false
+		}
+		reason, frozen := accessor.GetAnnotations()[RolloutFreezeAnnotation]
+		return reason, frozen
+	}
+}
+
+// AnyFreezeFunc returns a FreezeFunc that freezes rollouts as long as any of the given FreezeFuncs does, with the
+// reason of the first one.
+func AnyFreezeFunc(isFrozenFuncs ...FreezeFunc) FreezeFunc {
+	return func() (string, bool) {
+		for _, isFrozen := range isFrozenFuncs {
+			if reason, frozen := isFrozen(); frozen {
+				return reason, true
+			}
+		}
+		return "", false
+	}
+}
+
+// RolloutFreezeController reports whether rollouts are frozen in the KubeAPIServerRolloutFrozen condition.
+type RolloutFreezeController struct {
+	operatorClient v1helpers.StaticPodOperatorClient
+	isFrozen       FreezeFunc
+}
+
+func NewRolloutFreezeController(
+	operatorClient v1helpers.StaticPodOperatorClient,
+	isFrozen FreezeFunc,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &RolloutFreezeController{
+		operatorClient: operatorClient,
+		isFrozen:       isFrozen,
+	}
+	return factory.New().WithInformers(operatorClient.Informer()).WithSync(c.sync).
+		ToController("RolloutFreezeController", eventRecorder.WithComponentSuffix("rollout-freeze-controller"))
+}
+
+func (c *RolloutFreezeController) sync(_ context.Context, syncCtx factory.SyncContext) error {
+	_, status, _, err := c.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		return err
+	}
+
+	condition := operatorv1.OperatorCondition{
+		Type:   RolloutFrozenConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: AsExpectedReason,
+	}
+	if reason, frozen := c.isFrozen(); frozen {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = RolloutFrozenReason
+		condition.Message = fmt.Sprintf("New revisions are not created, the kube-apiserver is held at revision %d by the %s annotation", status.LatestAvailableRevision, RolloutFreezeAnnotation)
+		if len(reason) > 0 {
+			condition.Message += fmt.Sprintf(": %s", reason)
+		}
+	}
+
+	if existing := v1helpers.FindOperatorCondition(status.Conditions, RolloutFrozenConditionType); existing == nil || existing.Status != condition.Status {
+		if condition.Status == operatorv1.ConditionTrue {
+			syncCtx.Recorder().Warningf("RolloutFrozen", "%s", condition.Message)
+		} else if existing != nil {
+			syncCtx.Recorder().Eventf("RolloutResumed", "Rollouts are resumed at revision %d", status.LatestAvailableRevision)
+		}
+	}
+
+	_, _, err = v1helpers.UpdateStaticPodStatus(c.operatorClient, v1helpers.UpdateStaticPodConditionFn(condition))
+	return err
+}
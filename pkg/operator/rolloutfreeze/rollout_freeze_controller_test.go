@@ -0,0 +1,193 @@
+package rolloutfreeze
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/revisioncontroller"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+const targetNamespace = "openshift-kube-apiserver"
+
+func TestNoRevisionsAreCreatedWhileFrozen(t *testing.T) {
+	podConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: targetNamespace, Name: "kube-apiserver-pod"},
+		Data:       map[string]string{"pod.yaml": "revision 1"},
+	}
+	podConfigMapRevision1 := podConfigMap.DeepCopy()
+	podConfigMapRevision1.Name = "kube-apiserver-pod-1"
+	kubeClient := fake.NewSimpleClientset(podConfigMap, podConfigMapRevision1)
+	kubeInformers := v1helpers.NewKubeInformersForNamespaces(kubeClient, targetNamespace)
+
+	operatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+		&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+		&operatorv1.StaticPodOperatorStatus{LatestAvailableRevision: 1},
+		nil,
+		nil,
+	)
+	frozen := true
+	isFrozen := func() (string, bool) { return "investigating an incident", frozen }
+
+	revisionConfigMaps := []revisioncontroller.RevisionResource{{Name: "kube-apiserver-pod"}}
+	revisionInputs := FrozenRevisionInputs(kubeInformers, operatorClient, targetNamespace, revisionConfigMaps, nil, isFrozen)
+	recorder := events.NewInMemoryRecorder("test")
+	revisionController := revisioncontroller.NewRevisionController(
+		targetNamespace,
+		revisionConfigMaps,
+		nil,
+		revisionInputs.InformersFor(targetNamespace),
+		revisioncontroller.StaticPodLatestRevisionClient{StaticPodOperatorClient: operatorClient},
+		v1helpers.CachedConfigMapGetter(kubeClient.CoreV1(), revisionInputs),
+		v1helpers.CachedSecretGetter(kubeClient.CoreV1(), revisionInputs),
+		recorder,
+	)
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	revisionInputs.InformersFor(targetNamespace).Core().V1().ConfigMaps().Informer()
+	revisionInputs.InformersFor(targetNamespace).Core().V1().Secrets().Informer()
+	kubeInformers.Start(ctx.Done())
+	waitForConfigMap := func(name, content string) {
+		t.Helper()
+		lister := kubeInformers.InformersFor(targetNamespace).Core().V1().ConfigMaps().Lister().ConfigMaps(targetNamespace)
+		if err := wait.PollImmediate(10*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+			configMap, err := lister.Get(name)
+			if errors.IsNotFound(err) {
+				return false, nil
+			}
+			return err == nil && configMap.Data["pod.yaml"] == content, err
+		}); err != nil {
+			t.Fatalf("configmap %s with %q not observed: %v", name, content, err)
+		}
+	}
+	waitForConfigMap("kube-apiserver-pod-1", "revision 1")
+
+	syncRevisions := func() {
+		t.Helper()
+		if err := revisionController.Sync(context.TODO(), factory.NewSyncContext("test", recorder)); err != nil && err != factory.SyntheticRequeueError {
+			t.Fatal(err)
+		}
+	}
+	expectLatestAvailableRevision := func(expected int32) {
+		t.Helper()
+		_, status, _, err := operatorClient.GetStaticPodOperatorState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.LatestAvailableRevision != expected {
+			t.Fatalf("expected latest available revision %d, got %d", expected, status.LatestAvailableRevision)
+		}
+	}
+
+	// the content changes while rollouts are frozen
+	podConfigMap.Data["pod.yaml"] = "revision 2"
+	if _, err := kubeClient.CoreV1().ConfigMaps(targetNamespace).Update(context.TODO(), podConfigMap, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitForConfigMap("kube-apiserver-pod", "revision 2")
+	syncRevisions()
+	syncRevisions()
+	expectLatestAvailableRevision(1)
+	configMaps, err := kubeClient.CoreV1().ConfigMaps(targetNamespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, configMap := range configMaps.Items {
+		if configMap.Name != "kube-apiserver-pod" && configMap.Name != "kube-apiserver-pod-1" {
+			t.Errorf("expected no revision to be created while frozen, found configmap %s", configMap.Name)
+		}
+	}
+
+	// the freeze is lifted
+	frozen = false
+	syncRevisions()
+	expectLatestAvailableRevision(2)
+	revision2, err := kubeClient.CoreV1().ConfigMaps(targetNamespace).Get(context.TODO(), "kube-apiserver-pod-2", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revision2.Data["pod.yaml"] != "revision 2" {
+		t.Errorf("expected revision 2 to hold the latest content, got %q", revision2.Data["pod.yaml"])
+	}
+}
+
+func TestRolloutFreezeController(t *testing.T) {
+	operatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+		&operatorv1.StaticPodOperatorSpec{},
+		&operatorv1.StaticPodOperatorStatus{LatestAvailableRevision: 7},
+		nil,
+		nil,
+	)
+	frozen := true
+	c := &RolloutFreezeController{
+		operatorClient: operatorClient,
+		isFrozen:       func() (string, bool) { return "INC-1234", frozen },
+	}
+	recorder := events.NewInMemoryRecorder("test")
+
+	expectCondition := func(expectedStatus operatorv1.ConditionStatus, expectedMessage string) {
+		t.Helper()
+		if err := c.sync(context.TODO(), factory.NewSyncContext("test", recorder)); err != nil {
+			t.Fatal(err)
+		}
+		_, status, _, err := operatorClient.GetStaticPodOperatorState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		condition := v1helpers.FindOperatorCondition(status.Conditions, RolloutFrozenConditionType)
+		if condition == nil {
+			t.Fatalf("expected %s condition", RolloutFrozenConditionType)
+		}
+		if condition.Status != expectedStatus || condition.Message != expectedMessage {
+			t.Fatalf("expected %s %q, got %s %q", expectedStatus, expectedMessage, condition.Status, condition.Message)
+		}
+	}
+
+	expectCondition(operatorv1.ConditionTrue, "New revisions are not created, the kube-apiserver is held at revision 7 by the kubeapiserver.operator.openshift.io/rollout-freeze annotation: INC-1234")
+	frozen = false
+	expectCondition(operatorv1.ConditionFalse, "")
+
+	reasons := []string{}
+	for _, ev := range recorder.Events() {
+		reasons = append(reasons, ev.Reason)
+	}
+	if len(reasons) != 2 || reasons[0] != "RolloutFrozen" || reasons[1] != "RolloutResumed" {
+		t.Errorf("expected RolloutFrozen and RolloutResumed events, got %v", reasons)
+	}
+}
+
+func TestAnnotationFreezeFunc(t *testing.T) {
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &unstructured.Unstructured{}, 0, cache.Indexers{})
+	isFrozen := AnnotationFreezeFunc(informer)
+	if _, frozen := isFrozen(); frozen {
+		t.Errorf("expected rollouts not to be frozen without an operator config")
+	}
+
+	operatorConfig := &unstructured.Unstructured{}
+	operatorConfig.SetName("cluster")
+	if err := informer.GetStore().Add(operatorConfig); err != nil {
+		t.Fatal(err)
+	}
+	if _, frozen := isFrozen(); frozen {
+		t.Errorf("expected rollouts not to be frozen without the annotation")
+	}
+
+	operatorConfig.SetAnnotations(map[string]string{RolloutFreezeAnnotation: "INC-1234"})
+	if err := informer.GetStore().Update(operatorConfig); err != nil {
+		t.Fatal(err)
+	}
+	if reason, frozen := isFrozen(); !frozen || reason != "INC-1234" {
+		t.Errorf("expected rollouts to be frozen for INC-1234, got %v %q", frozen, reason)
+	}
+}
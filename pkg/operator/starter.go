@@ -7,28 +7,51 @@ import (
 	"os"
 	"time"
 
+	"github.com/blang/semver"
 	configv1 "github.com/openshift/api/config/v1"
 	operatorv1 "github.com/openshift/api/operator/v1"
 	configv1client "github.com/openshift/client-go/config/clientset/versioned"
 	configv1informers "github.com/openshift/client-go/config/informers/externalversions"
 	operatorcontrolplaneclient "github.com/openshift/client-go/operatorcontrolplane/clientset/versioned"
 	"github.com/openshift/cluster-kube-apiserver-operator/bindata"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/apiserviceavailabilitycontroller"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/auditpolicycontroller"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/bootstrapteardowncontroller"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/bootstraptrustcontroller"
 	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/boundsatokensignercontroller"
 	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/certrotationcontroller"
 	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/certrotationtimeupgradeablecontroller"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/clockskewcontroller"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/cloudprovidercontroller"
 	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configmetrics"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation/cloudprovider"
 	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation/configobservercontroller"
 	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/connectivitycheckcontroller"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/encryptionconvergencecontroller"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/encryptionkeyrotation"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/encryptionmigration"
 	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/featureupgradablecontroller"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/flagdriftcontroller"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/flagvalidationcontroller"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/imagedivergencecontroller"
 	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/kubeletversionskewcontroller"
 	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/nodekubeconfigcontroller"
 	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/orphanedrevisioncontroller"
 	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/resourcesynccontroller"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/revisionhistorycontroller"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/revisionquarantinecontroller"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/revisionrepaircontroller"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/rolloutfreeze"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/startupfailurecontroller"
 	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/startupmonitorreadiness"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/stuckrolloutcontroller"
 	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/targetconfigcontroller"
 	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/terminationobserver"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/tlscompatibilitycontroller"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/webhookreachabilitycontroller"
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
-	"github.com/openshift/library-go/pkg/operator/apiserver/controller/auditpolicy"
 	"github.com/openshift/library-go/pkg/operator/certrotation"
 	"github.com/openshift/library-go/pkg/operator/encryption"
 	"github.com/openshift/library-go/pkg/operator/encryption/controllers/migrators"
@@ -113,14 +136,58 @@ func RunOperator(ctx context.Context, controllerContext *controllercmd.Controlle
 		return err
 	}
 
+	etcdServingCASyncController := resourcesynccontroller.NewEtcdServingCASyncController(
+		operatorClient,
+		kubeInformersForNamespaces,
+		kubeClient.CoreV1(),
+		controllerContext.EventRecorder,
+	)
+
+	kubeletServingCASyncController := resourcesynccontroller.NewKubeletServingCASyncController(
+		operatorClient,
+		kubeInformersForNamespaces,
+		kubeClient.CoreV1(),
+		controllerContext.EventRecorder,
+	)
+
+	resourceSyncIntegrityController := resourcesynccontroller.NewSyncIntegrityController(
+		operatorClient,
+		kubeInformersForNamespaces,
+		kubeClient.CoreV1(),
+		kubeClient.CoreV1(),
+		controllerContext.EventRecorder,
+	)
+
+	featureGates := configobservation.NewFeatureGates(configInformers.Config().V1().FeatureGates().Lister())
+	riskyConfigChangeGuard := configobservercontroller.NewRiskyConfigChangeGuard(
+		operatorClient,
+		kubeInformersForNamespaces,
+		controllerContext.EventRecorder,
+	)
 	configObserver := configobservercontroller.NewConfigObserver(
 		operatorClient,
 		kubeInformersForNamespaces,
 		configInformers,
+		featureGates,
+		riskyConfigChangeGuard,
 		resourceSyncController,
 		controllerContext.EventRecorder,
 	)
 
+	featureGateConsistencyController := configobservercontroller.NewFeatureGateConsistencyController(
+		operatorClient,
+		featureGates,
+		configInformers.Config().V1().FeatureGates().Informer(),
+		configobservercontroller.FeatureBlacklist,
+		configobservercontroller.FeatureGateAdmissionPlugins,
+		controllerContext.EventRecorder,
+	)
+
+	observedConfigChangeController := configobservercontroller.NewObservedConfigChangeController(
+		operatorClient,
+		controllerContext.EventRecorder,
+	)
+
 	eventWatcher := eventwatch.New().
 		WithEventHandler(operatorclient.TargetNamespace, "LateConnections", terminationobserver.ProcessLateConnectionEvents).
 		ToController(kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace), kubeClient.CoreV1(), controllerContext.EventRecorder)
@@ -202,6 +269,16 @@ func RunOperator(ctx context.Context, controllerContext *controllercmd.Controlle
 		apiextensionsInformers,
 		controllerContext.EventRecorder,
 	)
+	etcdEndpointCheckController := connectivitycheckcontroller.NewEtcdEndpointCheckController(
+		operatorClient,
+		kubeInformersForNamespaces,
+		controllerContext.EventRecorder,
+	)
+	etcdClientCertCheckController := connectivitycheckcontroller.NewEtcdClientCertCheckController(
+		operatorClient,
+		kubeInformersForNamespaces,
+		controllerContext.EventRecorder,
+	)
 
 	// don't change any versions until we sync
 	versionRecorder := status.NewVersionGetter()
@@ -214,9 +291,35 @@ func RunOperator(ctx context.Context, controllerContext *controllercmd.Controlle
 	}
 	versionRecorder.SetVersion("raw-internal", status.VersionForOperatorFromEnv())
 
-	staticPodControllers, err := staticpod.NewBuilder(operatorClient, kubeClient, kubeInformersForNamespaces).
+	flagValidationController, err := flagvalidationcontroller.NewFlagValidationController(
+		semver.MustParse(status.VersionForOperandFromEnv()),
+		operatorClient,
+		kubeInformersForNamespaces,
+		controllerContext.EventRecorder,
+	)
+	if err != nil {
+		return err
+	}
+
+	revisionQuarantineController := revisionquarantinecontroller.NewRevisionQuarantineController(
+		operatorClient,
+		kubeInformersForNamespaces,
+		kubeClient.CoreV1(),
+		controllerContext.EventRecorder,
+	)
+
+	encryptionMigrationOrdering := encryptionmigration.NewMigrationOrdering(
+		operatorClient,
+		kubeInformersForNamespaces,
+		kubeClient.CoreV1(),
+		controllerContext.EventRecorder,
+	)
+
+	isRolloutFrozen := rolloutfreeze.AnnotationFreezeFunc(operatorClient.Informer())
+	revisionInputs := rolloutfreeze.FrozenRevisionInputs(kubeInformersForNamespaces, operatorClient, operatorclient.TargetNamespace, RevisionConfigMaps, RevisionSecrets, rolloutfreeze.AnyFreezeFunc(isRolloutFrozen, flagValidationController.FreezeFunc(), encryptionMigrationOrdering.FreezeFunc()))
+	staticPodControllers, err := staticpod.NewBuilder(operatorClient, kubeClient, revisionInputs).
 		WithEvents(controllerContext.EventRecorder).
-		WithCustomInstaller([]string{"cluster-kube-apiserver-operator", "installer"}, installerErrorInjector(operatorClient)).
+		WithCustomInstaller([]string{"cluster-kube-apiserver-operator", "installer"}, chainInstallerPodMutations(installerErrorInjector(operatorClient), revisionQuarantineController.InstallerPodMutationFunc())).
 		WithPruning([]string{"cluster-kube-apiserver-operator", "prune"}, "kube-apiserver-pod").
 		WithRevisionedResources(operatorclient.TargetNamespace, "kube-apiserver", RevisionConfigMaps, RevisionSecrets).
 		WithUnrevisionedCerts("kube-apiserver-certs", CertConfigMaps, CertSecrets).
@@ -277,16 +380,18 @@ func RunOperator(ctx context.Context, controllerContext *controllercmd.Controlle
 	migrationInformer := migrationv1alpha1informer.NewSharedInformerFactory(migrationClient, time.Minute*30)
 	migrator := migrators.NewKubeStorageVersionMigrator(migrationClient, migrationInformer.Migration().V1alpha1(), kubeClient.Discovery())
 
+	encryptedResources := []schema.GroupResource{
+		{Group: "", Resource: "secrets"},
+		{Group: "", Resource: "configmaps"},
+	}
+	forcedKeyRotationReason := encryptionkeyrotation.AnnotationReasonFunc(operatorClient.Informer())
 	encryptionControllers, err := encryption.NewControllers(
 		operatorclient.TargetNamespace,
 		nil,
-		encryption.StaticEncryptionProvider{
-			schema.GroupResource{Group: "", Resource: "secrets"},
-			schema.GroupResource{Group: "", Resource: "configmaps"},
-		},
-		deployer,
-		migrator,
-		operatorClient,
+		encryption.StaticEncryptionProvider(encryptedResources),
+		riskyConfigChangeGuard.Deployer(deployer),
+		encryptionmigration.NewMetricsMigrator(encryptionMigrationOrdering.Migrator(migrator)),
+		encryptionkeyrotation.NewOperatorClient(operatorClient, forcedKeyRotationReason),
 		configClient.ConfigV1().APIServers(),
 		configInformers.Config().V1().APIServers(),
 		kubeInformersForNamespaces,
@@ -297,12 +402,35 @@ func RunOperator(ctx context.Context, controllerContext *controllercmd.Controlle
 		return err
 	}
 
+	encryptionKeyRotationController := encryptionkeyrotation.NewKeyRotationController(
+		operatorclient.TargetNamespace,
+		encryptedResources,
+		operatorClient,
+		configInformers.Config().V1().APIServers(),
+		kubeInformersForNamespaces,
+		forcedKeyRotationReason,
+		controllerContext.EventRecorder,
+	)
+
+	encryptionConvergenceController := encryptionconvergencecontroller.NewEncryptionConvergenceController(
+		operatorClient,
+		kubeInformersForNamespaces,
+		deployer,
+		controllerContext.EventRecorder,
+	)
+
 	featureUpgradeableController := featureupgradablecontroller.NewFeatureUpgradeableController(
 		operatorClient,
 		configInformers,
 		controllerContext.EventRecorder,
 	)
 
+	cloudProviderController := cloudprovidercontroller.NewCloudProviderController(
+		operatorClient,
+		configInformers,
+		controllerContext.EventRecorder,
+	)
+
 	certRotationTimeUpgradeableController := certrotationtimeupgradeablecontroller.NewCertRotationTimeUpgradeableController(
 		operatorClient,
 		kubeInformersForNamespaces.InformersFor(operatorclient.GlobalUserSpecifiedConfigNamespace).Core().V1().ConfigMaps(),
@@ -323,7 +451,7 @@ func RunOperator(ctx context.Context, controllerContext *controllercmd.Controlle
 		controllerContext.EventRecorder,
 	)
 
-	auditPolicyController := auditpolicy.NewAuditPolicyController(
+	auditPolicyController := auditpolicycontroller.NewAuditPolicyController(
 		operatorclient.TargetNamespace,
 		"kube-apiserver-audit-policies",
 		configInformers.Config().V1().APIServers().Lister(),
@@ -344,18 +472,148 @@ func RunOperator(ctx context.Context, controllerContext *controllercmd.Controlle
 		controllerContext.EventRecorder,
 	)
 
+	clockSkewController := clockskewcontroller.NewClockSkewController(
+		operatorClient,
+		kubeInformersForNamespaces,
+		controllerContext.EventRecorder,
+	)
+
 	kubeletVersionSkewController := kubeletversionskewcontroller.NewKubeletVersionSkewController(
 		operatorClient,
 		kubeInformersForNamespaces,
 		controllerContext.EventRecorder,
 	)
 
+	rolloutFreezeController := rolloutfreeze.NewRolloutFreezeController(
+		operatorClient,
+		isRolloutFrozen,
+		controllerContext.EventRecorder,
+	)
+
+	orphanedRevisionController := orphanedrevisioncontroller.NewOrphanedRevisionController(
+		operatorclient.TargetNamespace,
+		RevisionConfigMaps,
+		RevisionSecrets,
+		operatorClient,
+		kubeInformersForNamespaces,
+		kubeClient.CoreV1(),
+		kubeClient.CoreV1(),
+		controllerContext.EventRecorder,
+	)
+
+	revisionRepairController := revisionrepaircontroller.NewRevisionRepairController(
+		operatorclient.TargetNamespace,
+		RevisionConfigMaps,
+		RevisionSecrets,
+		operatorClient,
+		kubeInformersForNamespaces,
+		kubeClient.CoreV1(),
+		kubeClient.CoreV1(),
+		controllerContext.EventRecorder,
+	)
+
+	revisionHistoryController := revisionhistorycontroller.NewRevisionHistoryController(
+		operatorClient,
+		kubeInformersForNamespaces,
+		kubeClient.CoreV1(),
+		controllerContext.EventRecorder,
+	)
+
+	bootstrapTeardownController := bootstrapteardowncontroller.NewBootstrapTeardownController(
+		operatorClient,
+		kubeInformersForNamespaces,
+		controllerContext.EventRecorder,
+	)
+
+	bootstrapTrustController := bootstraptrustcontroller.NewBootstrapTrustController(
+		operatorClient,
+		kubeInformersForNamespaces,
+		controllerContext.EventRecorder,
+	)
+
+	apiServiceAvailabilityController := apiserviceavailabilitycontroller.NewAPIServiceAvailabilityController(
+		operatorClient,
+		dynamicInformers.ForResource(apiserviceavailabilitycontroller.APIServicesResource),
+		controllerContext.EventRecorder,
+	)
+
+	tlsCompatibilityController := tlscompatibilitycontroller.NewTLSCompatibilityController(
+		operatorClient,
+		configInformers,
+		controllerContext.EventRecorder,
+	)
+
+	webhookReachabilityController := webhookreachabilitycontroller.NewWebhookReachabilityController(
+		operatorClient,
+		kubeInformersForNamespaces,
+		kubeClient.CoreV1(),
+		controllerContext.EventRecorder,
+	)
+
+	webhookTLSController := webhookreachabilitycontroller.NewWebhookTLSController(
+		operatorClient,
+		kubeInformersForNamespaces,
+		kubeClient.CoreV1(),
+		controllerContext.EventRecorder,
+	)
+
+	startupFailureController := startupfailurecontroller.NewStartupFailureController(
+		operatorClient,
+		kubeInformersForNamespaces,
+		controllerContext.EventRecorder,
+	)
+
+	stuckRolloutController := stuckrolloutcontroller.NewStuckRolloutController(
+		operatorClient,
+		kubeInformersForNamespaces,
+		controllerContext.EventRecorder,
+	)
+
+	flagDriftController := flagdriftcontroller.NewFlagDriftController(
+		operatorClient,
+		kubeInformersForNamespaces,
+		controllerContext.EventRecorder,
+	)
+
+	imageDivergenceController := imagedivergencecontroller.NewImageDivergenceController(
+		operatorClient,
+		kubeInformersForNamespaces,
+		controllerContext.EventRecorder,
+	)
+
+	kubeconfigExpiryController := nodekubeconfigcontroller.NewKubeconfigExpiryController(
+		operatorClient,
+		kubeInformersForNamespaces,
+		controllerContext.EventRecorder,
+	)
+
 	// register termination metrics
 	terminationobserver.RegisterMetrics()
 
 	// register config metrics
 	configmetrics.Register(configInformers)
 
+	// register cloud provider observer metrics
+	cloudprovider.RegisterMetrics()
+
+	// register observed config change metrics
+	configobservercontroller.RegisterMetrics()
+
+	// register cert rotation metrics
+	certrotationcontroller.RegisterMetrics()
+
+	// register managed kubeconfig metrics
+	nodekubeconfigcontroller.RegisterMetrics()
+
+	// register resource sync drift metrics
+	resourcesynccontroller.RegisterMetrics()
+
+	// register encryption migration metrics
+	encryptionmigration.RegisterMetrics(kubeInformersForNamespaces.InformersFor(operatorclient.GlobalMachineSpecifiedConfigNamespace).Core().V1().Secrets().Lister().Secrets(operatorclient.GlobalMachineSpecifiedConfigNamespace), encryptedResources)
+
+	// register etcd endpoint check metrics
+	connectivitycheckcontroller.RegisterMetrics()
+
 	kubeInformersForNamespaces.Start(ctx.Done())
 	configInformers.Start(ctx.Done())
 	dynamicInformers.Start(ctx.Done())
@@ -364,14 +622,24 @@ func RunOperator(ctx context.Context, controllerContext *controllercmd.Controlle
 
 	go staticPodControllers.Start(ctx)
 	go resourceSyncController.Run(ctx, 1)
+	go etcdServingCASyncController.Run(ctx, 1)
+	go kubeletServingCASyncController.Run(ctx, 1)
+	go resourceSyncIntegrityController.Run(ctx, 1)
 	go staticResourceController.Run(ctx, 1)
 	go targetConfigReconciler.Run(ctx, 1)
 	go nodeKubeconfigController.Run(ctx, 1)
 	go configObserver.Run(ctx, 1)
+	go observedConfigChangeController.Run(ctx, 1)
+	go featureGateConsistencyController.Run(ctx, 1)
+	go riskyConfigChangeGuard.Run(ctx, 1)
 	go clusterOperatorStatus.Run(ctx, 1)
 	go certRotationController.Run(ctx, 1)
 	go encryptionControllers.Run(ctx, 1)
+	go encryptionKeyRotationController.Run(ctx, 1)
+	go encryptionConvergenceController.Run(ctx, 1)
+	go encryptionMigrationOrdering.Run(ctx, 1)
 	go featureUpgradeableController.Run(ctx, 1)
+	go cloudProviderController.Run(ctx, 1)
 	go certRotationTimeUpgradeableController.Run(ctx, 1)
 	go terminationObserver.Run(ctx, 1)
 	go eventWatcher.Run(ctx, 1)
@@ -379,12 +647,44 @@ func RunOperator(ctx context.Context, controllerContext *controllercmd.Controlle
 	go auditPolicyController.Run(ctx, 1)
 	go staleConditionsController.Run(ctx, 1)
 	go connectivityCheckController.Run(ctx, 1)
+	go etcdEndpointCheckController.Run(ctx, 1)
+	go etcdClientCertCheckController.Run(ctx, 1)
 	go kubeletVersionSkewController.Run(ctx, 1)
+	go clockSkewController.Run(ctx, 1)
+	go startupFailureController.Run(ctx, 1)
+	go stuckRolloutController.Run(ctx, 1)
+	go imageDivergenceController.Run(ctx, 1)
+	go flagDriftController.Run(ctx, 1)
+	go kubeconfigExpiryController.Run(ctx, 1)
+	go revisionQuarantineController.Run(ctx, 1)
+	go rolloutFreezeController.Run(ctx, 1)
+	go flagValidationController.Run(ctx, 1)
+	go orphanedRevisionController.Run(ctx, 1)
+	go revisionRepairController.Run(ctx, 1)
+	go revisionHistoryController.Run(ctx, 1)
+	go bootstrapTeardownController.Run(ctx, 1)
+	go bootstrapTrustController.Run(ctx, 1)
+	go webhookReachabilityController.Run(ctx, 1)
+	go webhookTLSController.Run(ctx, 1)
+	go apiServiceAvailabilityController.Run(ctx, 1)
+	go tlsCompatibilityController.Run(ctx, 1)
 
 	<-ctx.Done()
 	return nil
 }
 
+// chainInstallerPodMutations runs the mutations in order, stopping at the first error.
+func chainInstallerPodMutations(mutationFns ...installer.InstallerPodMutationFunc) installer.InstallerPodMutationFunc {
+	return func(pod *corev1.Pod, nodeName string, operatorSpec *operatorv1.StaticPodOperatorSpec, revision int32) error {
+		for _, fn := range mutationFns {
+			if err := fn(pod, nodeName, operatorSpec, revision); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
 // installerErrorInjector mutates the given installer pod to fail or OOM depending on the propability (
 // - 0 <= unsupportedConfigOverrides.installerErrorInjection.failPropability <= 1.0: fail the pod (crash loop)
 // - 0 <= unsupportedConfigOverrides.installerErrorInjection.oomPropability <= 1.0: cause OOM due to 1 MB memory limits
@@ -450,6 +750,9 @@ var RevisionConfigMaps = []revision.RevisionResource{
 	{Name: "kube-apiserver-cert-syncer-kubeconfig"},
 	{Name: "oauth-metadata", Optional: true},
 	{Name: "cloud-config", Optional: true},
+	{Name: "egress-selector-config", Optional: true},
+	// the service CA bundle added to the system trust bundle
+	{Name: "service-ca-bundle", Optional: true},
 
 	// This configmap is managed by the operator, but ensuring a revision history
 	// supports signing key promotion. Promotion requires knowing whether the current
@@ -478,6 +781,8 @@ var RevisionSecrets = []revision.RevisionResource{
 	{Name: "localhost-recovery-client-token"},
 
 	{Name: "webhook-authenticator", Optional: true},
+	{Name: "audit-webhook", Optional: true},
+	{Name: "konnectivity-client", Optional: true},
 }
 
 var CertConfigMaps = []installer.UnrevisionedResource{
@@ -0,0 +1,157 @@
+package startupfailurecontroller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+const (
+	StartupFailuresDegradedConditionType = "KubeAPIServerStartupFailuresDegraded"
+
+	RepeatedStartupFailuresReason = "RepeatedStartupFailures"
+	AsExpectedReason              = "AsExpected"
+
+	// startupFailureThreshold is the number of restarts of a not yet ready kube-apiserver container,
+	// summed over all the pods of a revision, past which the revision is reported as failing to start.
+	startupFailureThreshold = 3
+
+	// maxErrorMessageLength bounds, in characters, the termination message copied into the condition.
+	maxErrorMessageLength = 256
+)
+
+var kubeAPIServerPodSelector = labels.SelectorFromSet(labels.Set{"app": "openshift-kube-apiserver"})
+
+// StartupFailureController sets KubeAPIServerStartupFailuresDegraded=True when the kube-apiserver
+// container of a revision keeps being restarted without ever becoming ready, i.e. when it
+// repeatedly fails its probes during startup.
+type StartupFailureController interface {
+	factory.Controller
+}
+
+func NewStartupFailureController(
+	operatorClient v1helpers.OperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	recorder events.Recorder,
+) *startupFailureController {
+	podInformer := kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Pods()
+	c := &startupFailureController{
+		operatorClient: operatorClient,
+		podLister:      podInformer.Lister(),
+	}
+	c.Controller = factory.New().
+		WithSync(c.sync).
+		WithInformers(operatorClient.Informer(), podInformer.Informer()).
+		ToController("StartupFailureController", recorder.WithComponentSuffix("startup-failure-controller"))
+	return c
+}
+
+type startupFailureController struct {
+	factory.Controller
+	operatorClient v1helpers.OperatorClient
+	podLister      corev1listers.PodLister
+}
+
+// revisionFailures aggregates the startup failures of the pods of a single revision.
+type revisionFailures struct {
+	revision int
+	failures int32
+	// lastTermination is the most recent termination of the kube-apiserver container in the revision.
+	lastTermination *corev1.ContainerStateTerminated
+}
+
+func (c *startupFailureController) sync(_ context.Context, _ factory.SyncContext) error {
+	operatorSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+
+	pods, err := c.podLister.Pods(operatorclient.TargetNamespace).List(kubeAPIServerPodSelector)
+	if err != nil {
+		return err
+	}
+
+	condition := operatorv1.OperatorCondition{
+		Type:   StartupFailuresDegradedConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: AsExpectedReason,
+	}
+	if failing := failingRevisions(pods); len(failing) > 0 {
+		worst := failing[0]
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = RepeatedStartupFailuresReason
+		condition.Message = fmt.Sprintf("kube-apiserver revision %d failed to start %d times", worst.revision, worst.failures)
+		if worst.lastTermination != nil {
+			condition.Message += fmt.Sprintf(", last error: %s", terminationMessage(worst.lastTermination))
+		}
+	}
+
+	_, _, err = v1helpers.UpdateStatus(c.operatorClient, v1helpers.UpdateConditionFn(condition))
+	return err
+}
+
+// failingRevisions returns the revisions whose failures reached startupFailureThreshold,
+// the most recent revision first.
+func failingRevisions(pods []*corev1.Pod) []*revisionFailures {
+	byRevision := map[int]*revisionFailures{}
+	for _, pod := range pods {
+		revision, err := strconv.Atoi(pod.Labels["revision"])
+		if err != nil {
+			continue
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			// restarts of a container that eventually became ready are not startup failures
+			if status.Name != "kube-apiserver" || status.Ready || status.RestartCount == 0 {
+				continue
+			}
+			r, ok := byRevision[revision]
+			if !ok {
+				r = &revisionFailures{revision: revision}
+				byRevision[revision] = r
+			}
+			r.failures += status.RestartCount
+			if last := status.LastTerminationState.Terminated; last != nil {
+				if r.lastTermination == nil || r.lastTermination.FinishedAt.Before(&last.FinishedAt) {
+					r.lastTermination = last
+				}
+			}
+		}
+	}
+
+	var failing []*revisionFailures
+	for _, r := range byRevision {
+		if r.failures >= startupFailureThreshold {
+			failing = append(failing, r)
+		}
+	}
+	sort.Slice(failing, func(i, j int) bool { return failing[i].revision > failing[j].revision })
+	return failing
+}
+
+func terminationMessage(terminated *corev1.ContainerStateTerminated) string {
+	msg := fmt.Sprintf("%s (exit code %d)", terminated.Reason, terminated.ExitCode)
+	if len(terminated.Message) == 0 {
+		return msg
+	}
+	message := terminated.Message
+	// the termination message holds the last lines of the log, keep its tail without splitting a character
+	if runes := []rune(message); len(runes) > maxErrorMessageLength {
+		message = string(runes[len(runes)-maxErrorMessageLength:])
+	}
+	return fmt.Sprintf("%s: %s", msg, message)
+}
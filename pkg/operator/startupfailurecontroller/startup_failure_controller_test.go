@@ -0,0 +1,182 @@
+package startupfailurecontroller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+func kubeAPIServerPod(node, revision string, ready bool, restarts int32, lastTermination *corev1.ContainerStateTerminated) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: operatorclient.TargetNamespace,
+			Name:      "kube-apiserver-" + node,
+			Labels:    map[string]string{"app": "openshift-kube-apiserver", "revision": revision},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name:                 "kube-apiserver",
+					Ready:                ready,
+					RestartCount:         restarts,
+					LastTerminationState: corev1.ContainerState{Terminated: lastTermination},
+				},
+				{
+					Name:  "kube-apiserver-check-endpoints",
+					Ready: true,
+				},
+			},
+		},
+	}
+}
+
+func TestStartupFailureControllerSync(t *testing.T) {
+	probeFailure := func(finishedAt time.Time, message string) *corev1.ContainerStateTerminated {
+		return &corev1.ContainerStateTerminated{ExitCode: 1, Reason: "Error", Message: message, FinishedAt: metav1.NewTime(finishedAt)}
+	}
+	now := time.Now()
+
+	testCases := []struct {
+		name            string
+		pods            []*corev1.Pod
+		expectedStatus  operatorv1.ConditionStatus
+		expectedMessage string
+	}{
+		{
+			name: "NoRestarts",
+			pods: []*corev1.Pod{
+				kubeAPIServerPod("master-0", "5", true, 0, nil),
+				kubeAPIServerPod("master-1", "5", true, 0, nil),
+			},
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name: "BelowThreshold",
+			pods: []*corev1.Pod{
+				kubeAPIServerPod("master-0", "5", false, 2, probeFailure(now, "connection refused")),
+			},
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name: "RestartedButReady",
+			pods: []*corev1.Pod{
+				kubeAPIServerPod("master-0", "5", true, 10, probeFailure(now, "connection refused")),
+			},
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name: "RepeatedFailuresSummedOverThePodsOfARevision",
+			pods: []*corev1.Pod{
+				kubeAPIServerPod("master-0", "5", false, 2, probeFailure(now.Add(-time.Minute), "older failure")),
+				kubeAPIServerPod("master-1", "5", false, 1, probeFailure(now, "etcd unreachable")),
+				kubeAPIServerPod("master-2", "4", true, 0, nil),
+			},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedMessage: "kube-apiserver revision 5 failed to start 3 times, last error: Error (exit code 1): etcd unreachable",
+		},
+		{
+			name: "LongMessageTruncatedOnACharacterBoundary",
+			pods: []*corev1.Pod{
+				kubeAPIServerPod("master-0", "5", false, 3, probeFailure(now, "x"+strings.Repeat("é", maxErrorMessageLength))),
+			},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedMessage: "kube-apiserver revision 5 failed to start 3 times, last error: Error (exit code 1): " + strings.Repeat("é", maxErrorMessageLength),
+		},
+		{
+			name: "MostRecentFailingRevisionReported",
+			pods: []*corev1.Pod{
+				kubeAPIServerPod("master-0", "6", false, 3, nil),
+				kubeAPIServerPod("master-1", "5", false, 7, probeFailure(now, "etcd unreachable")),
+			},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedMessage: "kube-apiserver revision 6 failed to start 3 times",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for _, pod := range tc.pods {
+				if err := indexer.Add(pod); err != nil {
+					t.Fatal(err)
+				}
+			}
+			operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+			c := &startupFailureController{
+				operatorClient: operatorClient,
+				podLister:      corev1listers.NewPodLister(indexer),
+			}
+			if err := c.sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+				t.Fatal(err)
+			}
+
+			_, status, _, err := operatorClient.GetOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			condition := v1helpers.FindOperatorCondition(status.Conditions, StartupFailuresDegradedConditionType)
+			if condition == nil {
+				t.Fatalf("expected %s condition", StartupFailuresDegradedConditionType)
+			}
+			if condition.Status != tc.expectedStatus {
+				t.Errorf("expected status %s, got %s", tc.expectedStatus, condition.Status)
+			}
+			if condition.Message != tc.expectedMessage {
+				t.Errorf("expected message %q, got %q", tc.expectedMessage, condition.Message)
+			}
+		})
+	}
+}
+
+func TestStartupFailureControllerConditionToggles(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+	c := &startupFailureController{
+		operatorClient: operatorClient,
+		podLister:      corev1listers.NewPodLister(indexer),
+	}
+	syncCtx := factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))
+
+	expectStatus := func(expected operatorv1.ConditionStatus) {
+		t.Helper()
+		if err := c.sync(context.TODO(), syncCtx); err != nil {
+			t.Fatal(err)
+		}
+		_, status, _, err := operatorClient.GetOperatorState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if condition := v1helpers.FindOperatorCondition(status.Conditions, StartupFailuresDegradedConditionType); condition == nil || condition.Status != expected {
+			t.Fatalf("expected %s=%s, got %v", StartupFailuresDegradedConditionType, expected, condition)
+		}
+	}
+
+	// the revision keeps failing its probes until it crosses the threshold
+	for restarts := int32(1); restarts <= startupFailureThreshold; restarts++ {
+		if err := indexer.Update(kubeAPIServerPod("master-0", "7", false, restarts, &corev1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"})); err != nil {
+			t.Fatal(err)
+		}
+		if restarts < startupFailureThreshold {
+			expectStatus(operatorv1.ConditionFalse)
+		}
+	}
+	expectStatus(operatorv1.ConditionTrue)
+
+	// the next revision comes up
+	if err := indexer.Update(kubeAPIServerPod("master-0", "8", true, 0, nil)); err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(operatorv1.ConditionFalse)
+}
@@ -0,0 +1,160 @@
+package stuckrolloutcontroller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	corelistersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
+)
+
+const (
+	// RolloutTimeoutAnnotation on the kubeapiserver/cluster operator config sets how long a revision may take to roll
+	// out to all the nodes before the rollout is reported stuck, as a duration, e.g. "1h".
+	RolloutTimeoutAnnotation = "kubeapiserver.operator.openshift.io/rollout-timeout"
+
+	StuckRolloutDegradedConditionType = "StuckRolloutDegraded"
+
+	RolloutTimedOutReason = "RolloutTimedOut"
+	AsExpectedReason      = "AsExpected"
+
+	// defaultRolloutTimeout leaves room for the graceful termination and the startup of the kube-apiserver on each
+	// of the nodes, one node at a time.
+	defaultRolloutTimeout = 45 * time.Minute
+)
+
+// StuckRolloutController sets StuckRolloutDegraded=True when the latest available revision has not been rolled out
+// to all the nodes within the rollout timeout, naming the nodes lagging behind. A rollout starts when its revision is
+// created, the creation time of the revision-status configmap of the revision, so that neither status updates nor
+// operator restarts restart the timeout. Without that configmap the time the controller first saw the revision
+// available and not on all the nodes is used.
+type StuckRolloutController struct {
+	factory.Controller
+	operatorClient   v1helpers.StaticPodOperatorClient
+	operatorInformer cache.SharedIndexInformer
+	configMapLister  corelistersv1.ConfigMapNamespaceLister
+	now              func() time.Time
+
+	// rolloutRevision is the revision being rolled out since rolloutStart, 0 if no rollout is in progress.
+	rolloutRevision int32
+	rolloutStart    time.Time
+}
+
+func NewStuckRolloutController(
+	operatorClient v1helpers.StaticPodOperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	recorder events.Recorder,
+) *StuckRolloutController {
+	configMapInformer := kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().ConfigMaps()
+	c := &StuckRolloutController{
+		operatorClient:   operatorClient,
+		operatorInformer: operatorClient.Informer(),
+		configMapLister:  configMapInformer.Lister().ConfigMaps(operatorclient.TargetNamespace),
+		now:              time.Now,
+	}
+	// a rollout times out without anything changing, resync to notice it
+	c.Controller = factory.New().
+		WithInformers(operatorClient.Informer(), configMapInformer.Informer()).
+		WithSync(c.sync).
+		ResyncEvery(time.Minute).
+		ToController("StuckRolloutController", recorder.WithComponentSuffix("stuck-rollout-controller"))
+	return c
+}
+
+func (c *StuckRolloutController) sync(_ context.Context, syncCtx factory.SyncContext) error {
+	operatorSpec, operatorStatus, _, err := c.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+
+	condition := operatorv1.OperatorCondition{
+		Type:   StuckRolloutDegradedConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: AsExpectedReason,
+	}
+
+	revision := operatorStatus.LatestAvailableRevision
+	laggingNodes := laggingNodes(operatorStatus.NodeStatuses, revision)
+	switch {
+	case len(laggingNodes) == 0:
+		c.rolloutRevision = 0
+	default:
+		if c.rolloutRevision != revision {
+			c.rolloutRevision = revision
+			c.rolloutStart = c.now()
+		}
+		timeout := c.rolloutTimeout(syncCtx.Recorder())
+		if elapsed := c.now().Sub(c.revisionCreated(revision)); elapsed > timeout {
+			condition.Status = operatorv1.ConditionTrue
+			condition.Reason = RolloutTimedOutReason
+			condition.Message = fmt.Sprintf("Revision %d has not been rolled out within %v, lagging nodes: %s", revision, timeout, strings.Join(laggingNodes, ", "))
+		}
+	}
+
+	_, _, err = v1helpers.UpdateStaticPodStatus(c.operatorClient, v1helpers.UpdateStaticPodConditionFn(condition))
+	return err
+}
+
+// revisionCreated returns the creation time of the revision-status configmap of the revision, when the controller
+// first saw the rollout of the revision if the configmap is not found.
+func (c *StuckRolloutController) revisionCreated(revision int32) time.Time {
+	revisionStatus, err := c.configMapLister.Get(fmt.Sprintf("revision-status-%d", revision))
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.Warningf("Unable to get the creation time of revision %d: %v", revision, err)
+		}
+		return c.rolloutStart
+	}
+	return revisionStatus.CreationTimestamp.Time
+}
+
+// rolloutTimeout returns the timeout of the RolloutTimeoutAnnotation, the default timeout if it is not set or invalid.
+func (c *StuckRolloutController) rolloutTimeout(recorder events.Recorder) time.Duration {
+	obj, exists, err := c.operatorInformer.GetStore().GetByKey("cluster")
+	if err != nil || !exists {
+		return defaultRolloutTimeout
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		klog.Warningf("Unable to read the %s annotation: %v", RolloutTimeoutAnnotation, err)
+		return defaultRolloutTimeout
+	}
+	value, ok := accessor.GetAnnotations()[RolloutTimeoutAnnotation]
+	if !ok {
+		return defaultRolloutTimeout
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		recorder.Warningf("InvalidRolloutTimeout", "Ignoring the %s annotation %q, it must be a positive duration: using %v", RolloutTimeoutAnnotation, value, defaultRolloutTimeout)
+		return defaultRolloutTimeout
+	}
+	return timeout
+}
+
+// laggingNodes returns the sorted names of the nodes not running the revision, with the revision they run.
+func laggingNodes(nodeStatuses []operatorv1.NodeStatus, revision int32) []string {
+	var nodes []string
+	for _, nodeStatus := range nodeStatuses {
+		if nodeStatus.CurrentRevision != revision {
+			nodes = append(nodes, fmt.Sprintf("%s (at revision %d)", nodeStatus.NodeName, nodeStatus.CurrentRevision))
+		}
+	}
+	sort.Strings(nodes)
+	return nodes
+}
@@ -0,0 +1,243 @@
+package stuckrolloutcontroller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	corelistersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func emptyConfigMapLister() corelistersv1.ConfigMapNamespaceLister {
+	return corelistersv1.NewConfigMapLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})).ConfigMaps("openshift-kube-apiserver")
+}
+
+func TestStuckRolloutController(t *testing.T) {
+	tests := []struct {
+		name            string
+		annotations     map[string]string
+		nodeStatuses    []operatorv1.NodeStatus
+		elapsed         time.Duration
+		expectedStatus  operatorv1.ConditionStatus
+		expectedMessage string
+	}{
+		{
+			name: "rolled out",
+			nodeStatuses: []operatorv1.NodeStatus{
+				{NodeName: "master-0", CurrentRevision: 3},
+				{NodeName: "master-1", CurrentRevision: 3},
+			},
+			elapsed:        time.Hour,
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name: "healthy rollout in progress",
+			nodeStatuses: []operatorv1.NodeStatus{
+				{NodeName: "master-0", CurrentRevision: 3},
+				{NodeName: "master-1", CurrentRevision: 2},
+			},
+			elapsed:        10 * time.Minute,
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name: "stuck node",
+			nodeStatuses: []operatorv1.NodeStatus{
+				{NodeName: "master-2", CurrentRevision: 2},
+				{NodeName: "master-0", CurrentRevision: 3},
+				{NodeName: "master-1", CurrentRevision: 1},
+			},
+			elapsed:         time.Hour,
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedMessage: "Revision 3 has not been rolled out within 45m0s, lagging nodes: master-1 (at revision 1), master-2 (at revision 2)",
+		},
+		{
+			name:        "stuck within a custom timeout",
+			annotations: map[string]string{RolloutTimeoutAnnotation: "5m"},
+			nodeStatuses: []operatorv1.NodeStatus{
+				{NodeName: "master-0", CurrentRevision: 2},
+			},
+			elapsed:         10 * time.Minute,
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedMessage: "Revision 3 has not been rolled out within 5m0s, lagging nodes: master-0 (at revision 2)",
+		},
+		{
+			name:        "invalid custom timeout falls back to the default",
+			annotations: map[string]string{RolloutTimeoutAnnotation: "soon"},
+			nodeStatuses: []operatorv1.NodeStatus{
+				{NodeName: "master-0", CurrentRevision: 2},
+			},
+			elapsed:        10 * time.Minute,
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+				&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+				&operatorv1.StaticPodOperatorStatus{LatestAvailableRevision: 3, NodeStatuses: tt.nodeStatuses},
+				nil,
+				nil,
+			)
+			informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &unstructured.Unstructured{}, 0, cache.Indexers{})
+			operatorConfig := &unstructured.Unstructured{}
+			operatorConfig.SetName("cluster")
+			operatorConfig.SetAnnotations(tt.annotations)
+			if err := informer.GetStore().Add(operatorConfig); err != nil {
+				t.Fatal(err)
+			}
+			now := time.Now()
+			c := &StuckRolloutController{
+				operatorClient:   operatorClient,
+				operatorInformer: informer,
+				configMapLister:  emptyConfigMapLister(),
+				now:              func() time.Time { return now },
+			}
+			syncCtx := factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))
+
+			// the first sync starts the rollout, the second one happens after the elapsed time
+			if err := c.sync(context.TODO(), syncCtx); err != nil {
+				t.Fatal(err)
+			}
+			now = now.Add(tt.elapsed)
+			if err := c.sync(context.TODO(), syncCtx); err != nil {
+				t.Fatal(err)
+			}
+
+			_, status, _, err := operatorClient.GetStaticPodOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			condition := v1helpers.FindOperatorCondition(status.Conditions, StuckRolloutDegradedConditionType)
+			if condition == nil || condition.Status != tt.expectedStatus || condition.Message != tt.expectedMessage {
+				t.Fatalf("expected condition %s with message %q, got %#v", tt.expectedStatus, tt.expectedMessage, condition)
+			}
+
+			// the condition clears once the rollout completes
+			for i := range status.NodeStatuses {
+				status.NodeStatuses[i].CurrentRevision = 3
+			}
+			if err := c.sync(context.TODO(), syncCtx); err != nil {
+				t.Fatal(err)
+			}
+			_, status, _, err = operatorClient.GetStaticPodOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if condition := v1helpers.FindOperatorCondition(status.Conditions, StuckRolloutDegradedConditionType); condition.Status != operatorv1.ConditionFalse {
+				t.Errorf("expected the condition to clear on completion, got %#v", condition)
+			}
+		})
+	}
+}
+
+func TestStuckRolloutControllerNewRevisionRestartsTimeout(t *testing.T) {
+	status := &operatorv1.StaticPodOperatorStatus{
+		LatestAvailableRevision: 3,
+		NodeStatuses:            []operatorv1.NodeStatus{{NodeName: "master-0", CurrentRevision: 2}},
+	}
+	operatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+		&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+		status, nil, nil,
+	)
+	now := time.Now()
+	c := &StuckRolloutController{
+		operatorClient:   operatorClient,
+		operatorInformer: cache.NewSharedIndexInformer(&cache.ListWatch{}, &unstructured.Unstructured{}, 0, cache.Indexers{}),
+		configMapLister:  emptyConfigMapLister(),
+		now:              func() time.Time { return now },
+	}
+	syncCtx := factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))
+
+	if err := c.sync(context.TODO(), syncCtx); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(30 * time.Minute)
+	// a newer revision supersedes the one being rolled out
+	_, status, _, err := operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.LatestAvailableRevision = 4
+	if err := c.sync(context.TODO(), syncCtx); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(30 * time.Minute)
+	if err := c.sync(context.TODO(), syncCtx); err != nil {
+		t.Fatal(err)
+	}
+
+	_, status, _, err = operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if condition := v1helpers.FindOperatorCondition(status.Conditions, StuckRolloutDegradedConditionType); condition.Status != operatorv1.ConditionFalse {
+		t.Errorf("expected the timeout to restart with the new revision, got %#v", condition)
+	}
+	now = now.Add(30 * time.Minute)
+	if err := c.sync(context.TODO(), syncCtx); err != nil {
+		t.Fatal(err)
+	}
+	_, status, _, err = operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if condition := v1helpers.FindOperatorCondition(status.Conditions, StuckRolloutDegradedConditionType); condition.Status != operatorv1.ConditionTrue || !strings.Contains(condition.Message, "Revision 4") {
+		t.Errorf("expected revision 4 to be reported stuck, got %#v", condition)
+	}
+}
+
+func TestStuckRolloutControllerTimeoutStartsAtTheRevisionCreation(t *testing.T) {
+	now := time.Now()
+	status := &operatorv1.StaticPodOperatorStatus{
+		LatestAvailableRevision: 3,
+		NodeStatuses:            []operatorv1.NodeStatus{{NodeName: "master-0", CurrentRevision: 2}},
+	}
+	operatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+		&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+		status, nil, nil,
+	)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Namespace:         "openshift-kube-apiserver",
+		Name:              "revision-status-3",
+		CreationTimestamp: metav1.NewTime(now.Add(-time.Hour)),
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	// a controller that just started, e.g. after an operator restart
+	c := &StuckRolloutController{
+		operatorClient:   operatorClient,
+		operatorInformer: cache.NewSharedIndexInformer(&cache.ListWatch{}, &unstructured.Unstructured{}, 0, cache.Indexers{}),
+		configMapLister:  corelistersv1.NewConfigMapLister(indexer).ConfigMaps("openshift-kube-apiserver"),
+		now:              func() time.Time { return now },
+	}
+	syncCtx := factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))
+
+	// status updates do not restart the timeout
+	for i := 0; i < 2; i++ {
+		if _, _, err := v1helpers.UpdateStaticPodStatus(operatorClient, v1helpers.UpdateStaticPodConditionFn(operatorv1.OperatorCondition{Type: "OtherDegraded", Status: operatorv1.ConditionFalse})); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.sync(context.TODO(), syncCtx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, status, _, err := operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if condition := v1helpers.FindOperatorCondition(status.Conditions, StuckRolloutDegradedConditionType); condition == nil || condition.Status != operatorv1.ConditionTrue {
+		t.Errorf("expected the revision created an hour ago to be reported stuck, got %#v", condition)
+	}
+}
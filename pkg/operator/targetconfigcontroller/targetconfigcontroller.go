@@ -16,6 +16,7 @@ import (
 	kubecontrolplanev1 "github.com/openshift/api/kubecontrolplane/v1"
 	operatorv1 "github.com/openshift/api/operator/v1"
 	"github.com/openshift/cluster-kube-apiserver-operator/bindata"
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation/konnectivity"
 	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/operatorclient"
 	"github.com/openshift/cluster-kube-apiserver-operator/pkg/version"
 	"github.com/openshift/library-go/pkg/controller/factory"
@@ -47,6 +48,9 @@ type TargetConfigController struct {
 	configMapLister corev1listers.ConfigMapLister
 
 	isStartupMonitorEnabledFn func() (bool, error)
+
+	// suppressedConfig is the last config a spurious revision was suppressed for, so that it is reported once
+	suppressedConfig *string
 }
 
 func NewTargetConfigController(
@@ -65,6 +69,7 @@ func NewTargetConfigController(
 		kubeClient:                kubeClient,
 		configMapLister:           kubeInformersForNamespaces.ConfigMapLister(),
 		isStartupMonitorEnabledFn: isStartupMonitorEnabledFn,
+		suppressedConfig:          new(string),
 	}
 
 	return factory.New().WithInformers(
@@ -155,7 +160,7 @@ func isRequiredConfigPresent(config []byte) error {
 func createTargetConfig(ctx context.Context, c TargetConfigController, recorder events.Recorder, operatorSpec *operatorv1.StaticPodOperatorSpec) (bool, error) {
 	errors := []error{}
 
-	_, _, err := manageKubeAPIServerConfig(ctx, c.kubeClient.CoreV1(), recorder, operatorSpec)
+	_, _, err := manageKubeAPIServerConfig(ctx, c.kubeClient.CoreV1(), recorder, operatorSpec, c.suppressedConfig)
 	if err != nil {
 		errors = append(errors, fmt.Errorf("%q: %v", "configmap/config", err))
 	}
@@ -163,6 +168,10 @@ func createTargetConfig(ctx context.Context, c TargetConfigController, recorder
 	if err != nil {
 		errors = append(errors, fmt.Errorf("%q: %v", "configmap/kube-apiserver-pod", err))
 	}
+	_, err = manageEgressSelectorConfig(ctx, c.configMapLister, c.kubeClient.CoreV1(), recorder, operatorSpec)
+	if err != nil {
+		errors = append(errors, fmt.Errorf("%q: %v", "configmap/"+konnectivity.EgressSelectorConfigMapName, err))
+	}
 	_, _, err = ManageClientCABundle(ctx, c.configMapLister, c.kubeClient.CoreV1(), recorder)
 	if err != nil {
 		errors = append(errors, fmt.Errorf("%q: %v", "configmap/client-ca", err))
@@ -172,6 +181,11 @@ func createTargetConfig(ctx context.Context, c TargetConfigController, recorder
 		errors = append(errors, fmt.Errorf("%q: %v", "configmap/kube-apiserver-server-ca", err))
 	}
 
+	_, err = manageServiceCABundle(ctx, c.configMapLister, c.kubeClient.CoreV1(), recorder)
+	if err != nil {
+		errors = append(errors, fmt.Errorf("%q: %v", "configmap/"+serviceCABundleConfigMapName, err))
+	}
+
 	err = ensureKubeAPIServerTrustedCA(ctx, c.kubeClient.CoreV1(), recorder)
 	if err != nil {
 		errors = append(errors, fmt.Errorf("%q: %v", "configmap/trusted-ca-bundle", err))
@@ -206,26 +220,104 @@ func createTargetConfig(ctx context.Context, c TargetConfigController, recorder
 	return false, nil
 }
 
-func manageKubeAPIServerConfig(ctx context.Context, client coreclientv1.ConfigMapsGetter, recorder events.Recorder, operatorSpec *operatorv1.StaticPodOperatorSpec) (*corev1.ConfigMap, bool, error) {
-	configMap := resourceread.ReadConfigMapV1OrDie(bindata.MustAsset("assets/kube-apiserver/cm.yaml"))
+func manageKubeAPIServerConfig(ctx context.Context, client coreclientv1.ConfigMapsGetter, recorder events.Recorder, operatorSpec *operatorv1.StaticPodOperatorSpec, suppressedConfig *string) (*corev1.ConfigMap, bool, error) {
+	requiredConfigMap := resourceread.ReadConfigMapV1OrDie(bindata.MustAsset("assets/kube-apiserver/cm.yaml"))
+	config, err := RenderKubeAPIServerConfig(operatorSpec)
+	if err != nil {
+		return nil, false, err
+	}
+	config, err = canonicalConfig(config)
+	if err != nil {
+		return nil, false, err
+	}
+	requiredConfigMap.Data["config.yaml"] = string(config)
+
+	// the revision controller copies the config byte for byte, keep the existing encoding of a semantically equal
+	// config so that it does not roll out a new revision
+	existing, err := client.ConfigMaps(requiredConfigMap.Namespace).Get(ctx, requiredConfigMap.Name, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, false, err
+	}
+	if err == nil && existing != nil {
+		if existingConfig, ok := existing.Data["config.yaml"]; ok && existingConfig != string(config) {
+			if canonicalExisting, err := canonicalConfig([]byte(existingConfig)); err == nil && bytes.Equal(canonicalExisting, config) {
+				requiredConfigMap.Data["config.yaml"] = existingConfig
+				// the existing encoding is kept on every sync, only report it for a new config
+				if *suppressedConfig != string(config) {
+					recorder.Eventf("SpuriousRevisionSuppressed", "No new revision for configmap %s/%s, its config only differs in encoding", requiredConfigMap.Namespace, requiredConfigMap.Name)
+					*suppressedConfig = string(config)
+				}
+			}
+		}
+	}
+	return resourceapply.ApplyConfigMap(ctx, client, recorder, requiredConfigMap)
+}
+
+// canonicalConfig returns the JSON encoding of the config with sorted keys and without insignificant whitespace,
+// equal for semantically equal configs.
+func canonicalConfig(config []byte) ([]byte, error) {
+	jsonConfig, err := yaml.YAMLToJSON(config)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(jsonConfig))
+	// keep the numbers as written, they do not all fit a float64
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// RenderKubeAPIServerConfig returns the kube-apiserver config rendered from the operator spec: the default config,
+// overlaid with the config overrides, the observed config and the unsupported config overrides.
+func RenderKubeAPIServerConfig(operatorSpec *operatorv1.StaticPodOperatorSpec) ([]byte, error) {
 	defaultConfig := bindata.MustAsset("assets/config/defaultconfig.yaml")
 	configOverrides := bindata.MustAsset("assets/config/config-overrides.yaml")
 	specialMergeRules := map[string]resourcemerge.MergeFunc{}
 
-	requiredConfigMap, _, err := resourcemerge.MergePrunedConfigMap(
+	return resourcemerge.MergePrunedProcessConfig(
 		&kubecontrolplanev1.KubeAPIServerConfig{},
-		configMap,
-		"config.yaml",
 		specialMergeRules,
 		defaultConfig,
 		configOverrides,
 		operatorSpec.ObservedConfig.Raw,
 		operatorSpec.UnsupportedConfigOverrides.Raw,
 	)
+}
+
+// manageEgressSelectorConfig renders the egress selector config while the konnectivity egress selector is observed,
+// and removes it otherwise.
+func manageEgressSelectorConfig(ctx context.Context, lister corev1listers.ConfigMapLister, client coreclientv1.ConfigMapsGetter, recorder events.Recorder, operatorSpec *operatorv1.StaticPodOperatorSpec) (bool, error) {
+	var observedConfig map[string]interface{}
+	if err := yaml.Unmarshal(operatorSpec.ObservedConfig.Raw, &observedConfig); err != nil {
+		return false, fmt.Errorf("failed to unmarshal the observedConfig: %v", err)
+	}
+	egressSelectorConfigFile, _, err := unstructured.NestedStringSlice(observedConfig, konnectivity.EgressSelectorConfigFilePath...)
 	if err != nil {
-		return nil, false, err
+		return false, fmt.Errorf("couldn't get the egress selector config file from observedConfig: %v", err)
 	}
-	return resourceapply.ApplyConfigMap(ctx, client, recorder, requiredConfigMap)
+
+	if len(egressSelectorConfigFile) == 0 {
+		if _, err := lister.ConfigMaps(operatorclient.TargetNamespace).Get(konnectivity.EgressSelectorConfigMapName); apierrors.IsNotFound(err) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		err := client.ConfigMaps(operatorclient.TargetNamespace).Delete(ctx, konnectivity.EgressSelectorConfigMapName, metav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		recorder.Eventf("EgressSelectorConfigDeleted", "Deleted configmap %s/%s because the konnectivity egress selector is disabled", operatorclient.TargetNamespace, konnectivity.EgressSelectorConfigMapName)
+		return true, nil
+	}
+
+	_, modified, err := resourceapply.ApplyConfigMap(ctx, client, recorder, resourceread.ReadConfigMapV1OrDie(bindata.MustAsset("assets/kube-apiserver/egress-selector-config-cm.yaml")))
+	return modified, err
 }
 
 func managePods(ctx context.Context, client coreclientv1.ConfigMapsGetter, isStartupMonitorEnabledFn func() (bool, error), recorder events.Recorder, operatorSpec *operatorv1.StaticPodOperatorSpec, imagePullSpec, operatorImagePullSpec string) (*corev1.ConfigMap, bool, error) {
@@ -235,16 +327,10 @@ func managePods(ctx context.Context, client coreclientv1.ConfigMapsGetter, isSta
 	}
 	required := resourceread.ReadPodV1OrDie([]byte(appliedPodTemplate))
 
-	var observedConfig map[string]interface{}
-	if err := yaml.Unmarshal(operatorSpec.ObservedConfig.Raw, &observedConfig); err != nil {
-		return nil, false, fmt.Errorf("failed to unmarshal the observedConfig: %v", err)
-	}
-	proxyConfig, _, err := unstructured.NestedStringMap(observedConfig, "targetconfigcontroller", "proxy")
+	proxyEnvVars, err := proxyEnvVarsFromObservedConfig(operatorSpec.ObservedConfig.Raw)
 	if err != nil {
-		return nil, false, fmt.Errorf("couldn't get the proxy config from observedConfig: %v", err)
+		return nil, false, err
 	}
-
-	proxyEnvVars := proxyMapToEnvVars(proxyConfig)
 	for i, container := range required.Spec.Containers {
 		required.Spec.Containers[i].Env = append(container.Env, proxyEnvVars...)
 	}
@@ -326,6 +412,45 @@ func manageKubeAPIServerCABundle(ctx context.Context, lister corev1listers.Confi
 	return resourceapply.ApplyConfigMap(ctx, client, recorder, requiredConfigMap)
 }
 
+// serviceCABundleConfigMapName is the revisioned copy of the service CA bundle the kube-apiserver adds to its system
+// trust bundle, verifying the webhooks and aggregated apiservers serving with service serving certificates.
+const serviceCABundleConfigMapName = "service-ca-bundle"
+
+// manageServiceCABundle copies the service CA bundle published by the service-ca-operator into the target namespace,
+// or removes the copy when there is none. The bundle is revisioned so that a rotation of the service CA, or a custom
+// service CA, rolls out a revision trusting it.
+func manageServiceCABundle(ctx context.Context, lister corev1listers.ConfigMapLister, client coreclientv1.ConfigMapsGetter, recorder events.Recorder) (bool, error) {
+	requiredConfigMap, err := resourcesynccontroller.CombineCABundleConfigMaps(
+		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: serviceCABundleConfigMapName},
+		lister,
+		// this is from the service-ca-operator and contains the current and, during a rotation, the previous service CA
+		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "service-ca"},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	if len(requiredConfigMap.Data["ca-bundle.crt"]) == 0 {
+		if _, err := lister.ConfigMaps(operatorclient.TargetNamespace).Get(serviceCABundleConfigMapName); apierrors.IsNotFound(err) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		err := client.ConfigMaps(operatorclient.TargetNamespace).Delete(ctx, serviceCABundleConfigMapName, metav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		recorder.Eventf("ServiceCABundleDeleted", "Deleted configmap %s/%s because there is no service CA bundle", operatorclient.TargetNamespace, serviceCABundleConfigMapName)
+		return true, nil
+	}
+
+	_, modified, err := resourceapply.ApplyConfigMap(ctx, client, recorder, requiredConfigMap)
+	return modified, err
+}
+
 func ensureKubeAPIServerTrustedCA(ctx context.Context, client coreclientv1.CoreV1Interface, recorder events.Recorder) error {
 	required := resourceread.ReadConfigMapV1OrDie(bindata.MustAsset("assets/kube-apiserver/trusted-ca-cm.yaml"))
 	cmCLient := client.ConfigMaps(operatorclient.TargetNamespace)
@@ -397,6 +522,21 @@ func ensureLocalhostRecoverySAToken(ctx context.Context, client coreclientv1.Cor
 	return err
 }
 
+// proxyEnvVarsFromObservedConfig returns the proxy environment variables observed from the
+// proxy.config.openshift.io/cluster status, at the path configured for the proxy observer.
+func proxyEnvVarsFromObservedConfig(rawObservedConfig []byte) ([]corev1.EnvVar, error) {
+	var observedConfig map[string]interface{}
+	if err := yaml.Unmarshal(rawObservedConfig, &observedConfig); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the observedConfig: %v", err)
+	}
+	proxyConfig, _, err := unstructured.NestedStringMap(observedConfig, "targetconfigcontroller", "proxy")
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get the proxy config from observedConfig: %v", err)
+	}
+
+	return proxyMapToEnvVars(proxyConfig), nil
+}
+
 func proxyMapToEnvVars(proxyConfig map[string]string) []corev1.EnvVar {
 	if proxyConfig == nil {
 		return nil
@@ -1,13 +1,31 @@
 package targetconfigcontroller
 
 import (
+	"context"
+	"encoding/json"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/ghodss/yaml"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 
+	configv1 "github.com/openshift/api/config/v1"
 	operatorv1 "github.com/openshift/api/operator/v1"
-	"k8s.io/client-go/kubernetes/scheme"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/configobserver/proxy"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-apiserver-operator/pkg/operator/configobservation"
 )
 
 var codec = scheme.Codecs.LegacyCodec(scheme.Scheme.PrioritizedVersionsAllGroups()...)
@@ -194,3 +212,360 @@ func TestManageTemplate(t *testing.T) {
 		})
 	}
 }
+
+func TestProxyEnvVarsFromObservedProxy(t *testing.T) {
+	tests := []struct {
+		name     string
+		proxy    *configv1.Proxy
+		expected []corev1.EnvVar
+	}{
+		{
+			name: "proxy set",
+			proxy: &configv1.Proxy{
+				Spec: configv1.ProxySpec{
+					HTTPProxy:  "http://proxy.example.com",
+					HTTPSProxy: "https://proxy.example.com",
+					NoProxy:    "example.org",
+				},
+				Status: configv1.ProxyStatus{
+					HTTPProxy:  "http://proxy.example.com",
+					HTTPSProxy: "https://proxy.example.com",
+					NoProxy:    ".cluster.local,.svc,10.0.0.0/16,127.0.0.1,api-int.example.com,example.org,localhost",
+				},
+			},
+			expected: []corev1.EnvVar{
+				{Name: "HTTPS_PROXY", Value: "https://proxy.example.com"},
+				{Name: "HTTP_PROXY", Value: "http://proxy.example.com"},
+				// NO_PROXY comes from the status, which includes the cluster-internal destinations
+				{Name: "NO_PROXY", Value: ".cluster.local,.svc,10.0.0.0/16,127.0.0.1,api-int.example.com,example.org,localhost"},
+			},
+		},
+		{
+			name: "proxy partially set",
+			proxy: &configv1.Proxy{
+				Spec: configv1.ProxySpec{
+					HTTPSProxy: "https://proxy.example.com",
+				},
+				Status: configv1.ProxyStatus{
+					HTTPSProxy: "https://proxy.example.com",
+					NoProxy:    ".cluster.local,.svc,localhost",
+				},
+			},
+			expected: []corev1.EnvVar{
+				{Name: "HTTPS_PROXY", Value: "https://proxy.example.com"},
+				{Name: "NO_PROXY", Value: ".cluster.local,.svc,localhost"},
+			},
+		},
+		{
+			name: "proxy spec not reconciled into status yet",
+			proxy: &configv1.Proxy{
+				Spec: configv1.ProxySpec{
+					HTTPProxy: "http://proxy.example.com",
+					NoProxy:   "example.org",
+				},
+			},
+		},
+		{
+			name:  "proxy unset",
+			proxy: &configv1.Proxy{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.proxy.ObjectMeta = metav1.ObjectMeta{Name: "cluster"}
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := indexer.Add(tt.proxy); err != nil {
+				t.Fatal(err)
+			}
+			listers := configobservation.Listers{ProxyLister_: configlistersv1.NewProxyLister(indexer)}
+
+			// same path as the proxy observer wired in the config observer controller
+			observe := proxy.NewProxyObserveFunc([]string{"targetconfigcontroller", "proxy"})
+			observed, errs := observe(listers, events.NewInMemoryRecorder(t.Name()), map[string]interface{}{})
+			if len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			raw, err := json.Marshal(observed)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := proxyEnvVarsFromObservedConfig(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tt.expected, actual) {
+				t.Errorf("expected %v, got %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestManageEgressSelectorConfig(t *testing.T) {
+	enabledObservedConfig := []byte(`{"apiServerArguments":{"egress-selector-config-file":["/etc/kubernetes/static-pod-resources/configmaps/egress-selector-config/config.yaml"]}}`)
+	existingEgressSelectorConfig := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-kube-apiserver", Name: "egress-selector-config"}}
+
+	tests := []struct {
+		name           string
+		observedConfig []byte
+		existing       []runtime.Object
+		expectModified bool
+		expectExists   bool
+	}{
+		{
+			name:           "disabled",
+			observedConfig: []byte(`{}`),
+		},
+		{
+			name:           "enabling renders the config",
+			observedConfig: enabledObservedConfig,
+			expectModified: true,
+			expectExists:   true,
+		},
+		{
+			name:           "disabling removes the config",
+			observedConfig: []byte(`{}`),
+			existing:       []runtime.Object{existingEgressSelectorConfig},
+			expectModified: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for _, obj := range tt.existing {
+				if err := indexer.Add(obj); err != nil {
+					t.Fatal(err)
+				}
+			}
+			kubeClient := fake.NewSimpleClientset(tt.existing...)
+			operatorSpec := &operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ObservedConfig: runtime.RawExtension{Raw: tt.observedConfig}}}
+
+			modified, err := manageEgressSelectorConfig(context.TODO(), corev1listers.NewConfigMapLister(indexer), kubeClient.CoreV1(), events.NewInMemoryRecorder("test"), operatorSpec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if modified != tt.expectModified {
+				t.Errorf("expected modified %v, got %v", tt.expectModified, modified)
+			}
+			if !tt.expectModified {
+				for _, action := range kubeClient.Actions() {
+					if action.GetVerb() == "delete" {
+						t.Errorf("expected no delete of a missing egress selector config, got %v", action)
+					}
+				}
+			}
+
+			configMap, err := kubeClient.CoreV1().ConfigMaps("openshift-kube-apiserver").Get(context.TODO(), "egress-selector-config", metav1.GetOptions{})
+			if exists := err == nil; exists != tt.expectExists {
+				t.Fatalf("expected the egress selector config to exist %v, got %v", tt.expectExists, err)
+			}
+			if tt.expectExists && !strings.Contains(configMap.Data["config.yaml"], "kind: EgressSelectorConfiguration") {
+				t.Errorf("expected an egress selector configuration, got %q", configMap.Data["config.yaml"])
+			}
+		})
+	}
+}
+
+func TestManageServiceCABundle(t *testing.T) {
+	newCABundle := func(name string) string {
+		ca, err := crypto.MakeSelfSignedCAConfigForDuration(name, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		certPEM, _, err := ca.GetPEMBytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(certPEM)
+	}
+	customCA, rotatedCA := newCABundle("custom-service-ca"), newCABundle("rotated-service-ca")
+	serviceCA := func(bundle string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-config-managed", Name: "service-ca"},
+			Data:       map[string]string{"ca-bundle.crt": bundle},
+		}
+	}
+	serviceCABundle := func(bundle string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-kube-apiserver", Name: "service-ca-bundle"},
+			Data:       map[string]string{"ca-bundle.crt": bundle},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		serviceCA      *corev1.ConfigMap
+		existing       []runtime.Object
+		expectModified bool
+		expectedBundle []string
+	}{
+		{
+			name: "no service CA",
+		},
+		{
+			name:           "custom service CA bundle is copied",
+			serviceCA:      serviceCA(customCA),
+			expectModified: true,
+			expectedBundle: []string{customCA},
+		},
+		{
+			name:           "rotated service CA bundle is copied",
+			serviceCA:      serviceCA(rotatedCA + customCA),
+			existing:       []runtime.Object{serviceCABundle(customCA)},
+			expectModified: true,
+			expectedBundle: []string{rotatedCA, customCA},
+		},
+		{
+			name:           "unchanged service CA bundle",
+			serviceCA:      serviceCA(customCA),
+			existing:       []runtime.Object{serviceCABundle(customCA)},
+			expectedBundle: []string{customCA},
+		},
+		{
+			name:           "removed service CA removes the copy",
+			existing:       []runtime.Object{serviceCABundle(customCA)},
+			expectModified: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			if tt.serviceCA != nil {
+				if err := indexer.Add(tt.serviceCA); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, obj := range tt.existing {
+				if err := indexer.Add(obj); err != nil {
+					t.Fatal(err)
+				}
+			}
+			kubeClient := fake.NewSimpleClientset(tt.existing...)
+
+			modified, err := manageServiceCABundle(context.TODO(), corev1listers.NewConfigMapLister(indexer), kubeClient.CoreV1(), events.NewInMemoryRecorder("test"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if modified != tt.expectModified {
+				t.Errorf("expected modified %v, got %v", tt.expectModified, modified)
+			}
+			if !tt.expectModified {
+				for _, action := range kubeClient.Actions() {
+					if action.GetVerb() == "delete" {
+						t.Errorf("expected no delete of a missing service CA bundle, got %v", action)
+					}
+				}
+			}
+
+			configMap, err := kubeClient.CoreV1().ConfigMaps("openshift-kube-apiserver").Get(context.TODO(), "service-ca-bundle", metav1.GetOptions{})
+			if exists := err == nil; exists != (len(tt.expectedBundle) > 0) {
+				t.Fatalf("expected the service CA bundle to exist %v, got %v", len(tt.expectedBundle) > 0, err)
+			}
+			if len(tt.expectedBundle) == 0 {
+				return
+			}
+			if expected := strings.Join(tt.expectedBundle, ""); configMap.Data["ca-bundle.crt"] != expected {
+				t.Errorf("expected bundle %q, got %q", expected, configMap.Data["ca-bundle.crt"])
+			}
+		})
+	}
+}
+
+func TestManageKubeAPIServerConfigSuppressesSpuriousRevisions(t *testing.T) {
+	operatorSpec := &operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{
+		ObservedConfig: runtime.RawExtension{Raw: []byte(`{"apiServerArguments":{"event-ttl":["1h0m0s"],"audit-log-format":["json"]}}`)},
+	}}
+	rendered, err := RenderKubeAPIServerConfig(operatorSpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendered, err = canonicalConfig(rendered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the same config with its keys in another order and another indentation
+	reordered, err := yaml.JSONToYAML(rendered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changedSpec := operatorSpec.DeepCopy()
+	changedSpec.ObservedConfig.Raw = []byte(`{"apiServerArguments":{"event-ttl":["2h0m0s"],"audit-log-format":["json"]}}`)
+
+	tests := []struct {
+		name           string
+		existingConfig string
+		noConfigMap    bool
+		operatorSpec   *operatorv1.StaticPodOperatorSpec
+		expectModified bool
+		expectEvent    bool
+	}{
+		{
+			name:           "reordered but equal config",
+			existingConfig: string(reordered),
+			operatorSpec:   operatorSpec,
+			expectEvent:    true,
+		},
+		{
+			name:           "identical config",
+			existingConfig: string(rendered),
+			operatorSpec:   operatorSpec,
+		},
+		{
+			name:           "changed config",
+			existingConfig: string(reordered),
+			operatorSpec:   changedSpec,
+			expectModified: true,
+		},
+		{
+			name:           "no config yet",
+			noConfigMap:    true,
+			operatorSpec:   operatorSpec,
+			expectModified: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset()
+			if !tt.noConfigMap {
+				kubeClient = fake.NewSimpleClientset(&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-kube-apiserver", Name: "config"},
+					Data:       map[string]string{"config.yaml": tt.existingConfig},
+				})
+			}
+			recorder := events.NewInMemoryRecorder("test")
+			suppressedConfig := new(string)
+
+			// the controller syncs the same config again and again, the suppression is reported once
+			for i := 0; i < 3; i++ {
+				_, modified, err := manageKubeAPIServerConfig(context.TODO(), kubeClient.CoreV1(), recorder, tt.operatorSpec, suppressedConfig)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if expectModified := tt.expectModified && i == 0; modified != expectModified {
+					t.Fatalf("sync %d: expected modified %v, got %v", i, expectModified, modified)
+				}
+			}
+			configMap, err := kubeClient.CoreV1().ConfigMaps("openshift-kube-apiserver").Get(context.TODO(), "config", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.noConfigMap {
+				if unchanged := configMap.Data["config.yaml"] == tt.existingConfig; unchanged == tt.expectModified {
+					t.Errorf("expected the config changed %v, got %q", tt.expectModified, configMap.Data["config.yaml"])
+				}
+			}
+			suppressions := 0
+			for _, event := range recorder.Events() {
+				if event.Reason == "SpuriousRevisionSuppressed" {
+					suppressions++
+				}
+			}
+			if expected := map[bool]int{true: 1}[tt.expectEvent]; suppressions != expected {
+				t.Errorf("expected %d suppression reports, got events %v", expected, recorder.Events())
+			}
+		})
+	}
+}
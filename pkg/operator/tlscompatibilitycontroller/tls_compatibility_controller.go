@@ -0,0 +1,192 @@
+package tlscompatibilitycontroller
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configinformers "github.com/openshift/client-go/config/informers/externalversions"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// LoadBalancerTLSAnnotation on the cluster APIServer config describes the TLS the load balancer in front of the
+	// kube-apiserver uses when it terminates the client connections and re-encrypts them to the kube-apiserver, as
+	// YAML:
+	//
+	//	maxTLSVersion: VersionTLS12
+	//	ciphers: ["ECDHE-RSA-AES128-GCM-SHA256"]
+	//
+	// maxTLSVersion defaults to VersionTLS13, the ciphers, in OpenSSL or IANA names, are only relevant below TLS 1.3
+	// whose cipher suites are not configurable. Load balancers passing the TCP connections through need no annotation.
+	LoadBalancerTLSAnnotation = "kubeapiserver.operator.openshift.io/loadbalancer-tls"
+
+	LoadBalancerTLSIncompatibleConditionType = "KubeAPIServerLoadBalancerTLSIncompatible"
+
+	TLSVersionsIncompatibleReason = "TLSVersionsIncompatible"
+	NoCommonCipherSuiteReason     = "NoCommonCipherSuite"
+	InvalidExpectationReason      = "InvalidExpectation"
+	AsExpectedReason              = "AsExpected"
+)
+
+var (
+	minTLSVersionPath = []string{"servingInfo", "minTLSVersion"}
+	cipherSuitesPath  = []string{"servingInfo", "cipherSuites"}
+)
+
+// loadBalancerTLS is the content of the LoadBalancerTLSAnnotation.
+type loadBalancerTLS struct {
+	MaxTLSVersion string   `json:"maxTLSVersion,omitempty"`
+	Ciphers       []string `json:"ciphers,omitempty"`
+}
+
+// TLSCompatibilityController compares the TLS versions and cipher suites the kube-apiserver serves with, the observed
+// servingInfo of the TLS security profile, with the ones of the load balancer described in the
+// LoadBalancerTLSAnnotation. A load balancer limited to versions below the minimum version of the kube-apiserver, or
+// without any cipher suite in common with it below TLS 1.3, fails every connection to the kube-apiserver. The
+// incompatibility is reported in the KubeAPIServerLoadBalancerTLSIncompatible condition, neither side is changed.
+type TLSCompatibilityController struct {
+	factory.Controller
+	operatorClient  v1helpers.OperatorClient
+	apiServerLister configv1listers.APIServerLister
+}
+
+func NewTLSCompatibilityController(
+	operatorClient v1helpers.OperatorClient,
+	configInformers configinformers.SharedInformerFactory,
+	recorder events.Recorder,
+) *TLSCompatibilityController {
+	c := &TLSCompatibilityController{
+		operatorClient:  operatorClient,
+		apiServerLister: configInformers.Config().V1().APIServers().Lister(),
+	}
+	c.Controller = factory.New().
+		WithSync(c.sync).
+		WithInformers(operatorClient.Informer(), configInformers.Config().V1().APIServers().Informer()).
+		ToController("TLSCompatibilityController", recorder.WithComponentSuffix("tls-compatibility-controller"))
+	return c
+}
+
+func (c *TLSCompatibilityController) sync(_ context.Context, _ factory.SyncContext) error {
+	operatorSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+
+	condition := operatorv1.OperatorCondition{
+		Type:   LoadBalancerTLSIncompatibleConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: AsExpectedReason,
+	}
+
+	var annotations map[string]string
+	apiServer, err := c.apiServerLister.Get("cluster")
+	switch {
+	case err == nil:
+		annotations = apiServer.Annotations
+	case !apierrors.IsNotFound(err):
+		return err
+	}
+	if value, ok := annotations[LoadBalancerTLSAnnotation]; ok {
+		minVersion, cipherSuites, err := servingTLS(operatorSpec.ObservedConfig.Raw)
+		if err != nil {
+			return err
+		}
+		lb, err := parseLoadBalancerTLS(value)
+		if err != nil {
+			condition.Status = operatorv1.ConditionUnknown
+			condition.Reason = InvalidExpectationReason
+			condition.Message = fmt.Sprintf("Invalid %s annotation: %v", LoadBalancerTLSAnnotation, err)
+		} else if reason, message := incompatibility(minVersion, cipherSuites, lb); len(reason) > 0 {
+			condition.Status = operatorv1.ConditionTrue
+			condition.Reason = reason
+			condition.Message = message
+		}
+	}
+
+	_, _, err = v1helpers.UpdateStatus(c.operatorClient, v1helpers.UpdateConditionFn(condition))
+	return err
+}
+
+// servingTLS returns the minimum TLS version and the IANA names of the cipher suites of the observed servingInfo.
+func servingTLS(rawObservedConfig []byte) (string, []string, error) {
+	observedConfig := map[string]interface{}{}
+	if len(rawObservedConfig) > 0 {
+		if err := yaml.Unmarshal(rawObservedConfig, &observedConfig); err != nil {
+			return "", nil, fmt.Errorf("failed to unmarshal the observedConfig: %w", err)
+		}
+	}
+	minVersion, _, err := unstructured.NestedString(observedConfig, minTLSVersionPath...)
+	if err != nil {
+		return "", nil, err
+	}
+	cipherSuites, _, err := unstructured.NestedStringSlice(observedConfig, cipherSuitesPath...)
+	if err != nil {
+		return "", nil, err
+	}
+	return minVersion, cipherSuites, nil
+}
+
+func parseLoadBalancerTLS(value string) (*loadBalancerTLS, error) {
+	lb := &loadBalancerTLS{}
+	if err := yaml.Unmarshal([]byte(value), lb); err != nil {
+		return nil, err
+	}
+	if len(lb.MaxTLSVersion) == 0 {
+		lb.MaxTLSVersion = "VersionTLS13"
+	}
+	if _, err := crypto.TLSVersion(lb.MaxTLSVersion); err != nil {
+		return nil, fmt.Errorf("maxTLSVersion: %v", err)
+	}
+	return lb, nil
+}
+
+// incompatibility returns the reason and message of the incompatibility of the load balancer with the kube-apiserver
+// serving with the given minimum TLS version and cipher suites, none if they are compatible. An empty minimum version
+// or list of cipher suites, before the TLS security profile is observed, is the one of the default Intermediate
+// profile the kube-apiserver serves with then.
+func incompatibility(minVersion string, cipherSuites []string, lb *loadBalancerTLS) (string, string) {
+	defaultProfile := configv1.TLSProfiles[configv1.TLSProfileIntermediateType]
+	if len(minVersion) == 0 {
+		minVersion = string(defaultProfile.MinTLSVersion)
+	}
+	if len(cipherSuites) == 0 {
+		cipherSuites = crypto.OpenSSLToIANACipherSuites(defaultProfile.Ciphers)
+	}
+	apiServerMin, err := crypto.TLSVersion(minVersion)
+	if err != nil {
+		// the observer does not render unknown versions
+		apiServerMin = crypto.TLSVersionOrDie(string(defaultProfile.MinTLSVersion))
+	}
+	lbMax := crypto.TLSVersionOrDie(lb.MaxTLSVersion)
+	if lbMax < apiServerMin {
+		return TLSVersionsIncompatibleReason, fmt.Sprintf("The load balancer speaks up to %s, the kube-apiserver requires at least %s", lb.MaxTLSVersion, crypto.TLSVersionToNameOrDie(apiServerMin))
+	}
+	if lbMax >= tls.VersionTLS13 || len(lb.Ciphers) == 0 {
+		return "", ""
+	}
+
+	served := sets.NewString(cipherSuites...)
+	for _, cipher := range lb.Ciphers {
+		if served.Has(cipher) || served.HasAny(crypto.OpenSSLToIANACipherSuites([]string{cipher})...) {
+			return "", ""
+		}
+	}
+	return NoCommonCipherSuiteReason, fmt.Sprintf("The load balancer ciphers %s have no cipher suite in common with the kube-apiserver up to %s, it serves %s",
+		strings.Join(lb.Ciphers, ", "), lb.MaxTLSVersion, strings.Join(served.List(), ", "))
+}
@@ -0,0 +1,136 @@
+package tlscompatibilitycontroller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+const intermediateServingInfo = `{"servingInfo":{"minTLSVersion":"VersionTLS12","cipherSuites":["TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256","TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"]}}`
+
+func TestTLSCompatibilityController(t *testing.T) {
+	tests := []struct {
+		name            string
+		observedConfig  string
+		annotations     map[string]string
+		expectedStatus  operatorv1.ConditionStatus
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			name:           "no load balancer expectation",
+			observedConfig: intermediateServingInfo,
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: AsExpectedReason,
+		},
+		{
+			name:           "common cipher in OpenSSL name",
+			observedConfig: intermediateServingInfo,
+			annotations:    map[string]string{LoadBalancerTLSAnnotation: `{"maxTLSVersion":"VersionTLS12","ciphers":["AES128-SHA","ECDHE-RSA-AES128-GCM-SHA256"]}`},
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: AsExpectedReason,
+		},
+		{
+			name:           "common cipher in IANA name",
+			observedConfig: intermediateServingInfo,
+			annotations:    map[string]string{LoadBalancerTLSAnnotation: `{"maxTLSVersion":"VersionTLS12","ciphers":["TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"]}`},
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: AsExpectedReason,
+		},
+		{
+			name:           "TLS 1.3 load balancer",
+			observedConfig: intermediateServingInfo,
+			annotations:    map[string]string{LoadBalancerTLSAnnotation: `{"ciphers":["AES128-SHA"]}`},
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: AsExpectedReason,
+		},
+		{
+			name:            "no common cipher",
+			observedConfig:  intermediateServingInfo,
+			annotations:     map[string]string{LoadBalancerTLSAnnotation: `{"maxTLSVersion":"VersionTLS12","ciphers":["AES128-SHA","AES256-SHA"]}`},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  NoCommonCipherSuiteReason,
+			expectedMessage: "AES128-SHA, AES256-SHA have no cipher suite in common",
+		},
+		{
+			name:            "load balancer below the modern profile",
+			observedConfig:  `{"servingInfo":{"minTLSVersion":"VersionTLS13"}}`,
+			annotations:     map[string]string{LoadBalancerTLSAnnotation: `{"maxTLSVersion":"VersionTLS12","ciphers":["ECDHE-RSA-AES128-GCM-SHA256"]}`},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  TLSVersionsIncompatibleReason,
+			expectedMessage: "up to VersionTLS12, the kube-apiserver requires at least VersionTLS13",
+		},
+		{
+			name:            "no common cipher before the profile is observed",
+			observedConfig:  `{}`,
+			annotations:     map[string]string{LoadBalancerTLSAnnotation: `{"maxTLSVersion":"VersionTLS12","ciphers":["AES128-SHA"]}`},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  NoCommonCipherSuiteReason,
+			expectedMessage: "AES128-SHA have no cipher suite in common",
+		},
+		{
+			name:            "load balancer below the default profile before it is observed",
+			observedConfig:  `{}`,
+			annotations:     map[string]string{LoadBalancerTLSAnnotation: `{"maxTLSVersion":"VersionTLS11","ciphers":["ECDHE-RSA-AES128-GCM-SHA256"]}`},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  TLSVersionsIncompatibleReason,
+			expectedMessage: "the kube-apiserver requires at least VersionTLS12",
+		},
+		{
+			name:           "common cipher before the profile is observed",
+			observedConfig: `{}`,
+			annotations:    map[string]string{LoadBalancerTLSAnnotation: `{"maxTLSVersion":"VersionTLS12","ciphers":["ECDHE-RSA-AES128-GCM-SHA256"]}`},
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: AsExpectedReason,
+		},
+		{
+			name:           "invalid expectation",
+			observedConfig: intermediateServingInfo,
+			annotations:    map[string]string{LoadBalancerTLSAnnotation: `{"maxTLSVersion":"TLS1.2"}`},
+			expectedStatus: operatorv1.ConditionUnknown,
+			expectedReason: InvalidExpectationReason,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := indexer.Add(&configv1.APIServer{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Annotations: test.annotations}}); err != nil {
+				t.Fatal(err)
+			}
+			operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{
+				ManagementState: operatorv1.Managed,
+				ObservedConfig:  runtime.RawExtension{Raw: []byte(test.observedConfig)},
+			}, &operatorv1.OperatorStatus{}, nil)
+			c := &TLSCompatibilityController{
+				operatorClient:  operatorClient,
+				apiServerLister: configv1listers.NewAPIServerLister(indexer),
+			}
+			if err := c.sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+				t.Fatal(err)
+			}
+
+			_, status, _, err := operatorClient.GetOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			condition := v1helpers.FindOperatorCondition(status.Conditions, LoadBalancerTLSIncompatibleConditionType)
+			if condition == nil || condition.Status != test.expectedStatus || condition.Reason != test.expectedReason {
+				t.Fatalf("expected condition %s with reason %s, got %#v", test.expectedStatus, test.expectedReason, condition)
+			}
+			if !strings.Contains(condition.Message, test.expectedMessage) {
+				t.Errorf("expected %q in the message, got %q", test.expectedMessage, condition.Message)
+			}
+		})
+	}
+}
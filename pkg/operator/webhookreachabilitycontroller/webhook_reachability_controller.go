@@ -0,0 +1,194 @@
+package webhookreachabilitycontroller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	coreclientv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	admissionregistrationlisters "k8s.io/client-go/listers/admissionregistration/v1"
+)
+
+const (
+	// WebhooksUnreachableConditionType is True when a webhook that fails closed cannot be reached. It is informational
+	// and does not degrade the operator: such a webhook rejects every request it intercepts, which can wedge the
+	// cluster, but fixing it is up to the webhook owner.
+	WebhooksUnreachableConditionType = "KubeAPIServerWebhooksUnreachable"
+
+	WebhooksUnreachableReason = "WebhooksUnreachable"
+	AsExpectedReason          = "AsExpected"
+
+	defaultWebhookPort = 443
+	dialTimeout        = 5 * time.Second
+)
+
+// Resolver checks whether the target of a webhook client config can be reached.
+type Resolver interface {
+	Resolve(ctx context.Context, clientConfig admissionregistrationv1.WebhookClientConfig) error
+}
+
+// WebhookReachabilityController periodically resolves the targets of the validating and mutating webhooks with
+// failurePolicy=Fail and lists the unreachable ones in the KubeAPIServerWebhooksUnreachable condition.
+// The webhook configurations are never modified.
+type WebhookReachabilityController interface {
+	factory.Controller
+}
+
+func NewWebhookReachabilityController(
+	operatorClient v1helpers.OperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	serviceClient coreclientv1.ServicesGetter,
+	recorder events.Recorder,
+) *webhookReachabilityController {
+	webhookInformers := kubeInformersForNamespaces.InformersFor("").Admissionregistration().V1()
+	c := &webhookReachabilityController{
+		operatorClient:   operatorClient,
+		validatingLister: webhookInformers.ValidatingWebhookConfigurations().Lister(),
+		mutatingLister:   webhookInformers.MutatingWebhookConfigurations().Lister(),
+		resolver:         &dialResolver{serviceClient: serviceClient},
+	}
+	c.Controller = factory.New().
+		WithSync(c.sync).
+		WithInformers(
+			operatorClient.Informer(),
+			webhookInformers.ValidatingWebhookConfigurations().Informer(),
+			webhookInformers.MutatingWebhookConfigurations().Informer(),
+		).
+		ResyncEvery(5*time.Minute).
+		ToController("WebhookReachabilityController", recorder.WithComponentSuffix("webhook-reachability-controller"))
+	return c
+}
+
+type webhookReachabilityController struct {
+	factory.Controller
+	operatorClient   v1helpers.OperatorClient
+	validatingLister admissionregistrationlisters.ValidatingWebhookConfigurationLister
+	mutatingLister   admissionregistrationlisters.MutatingWebhookConfigurationLister
+	resolver         Resolver
+}
+
+func (c *webhookReachabilityController) sync(ctx context.Context, _ factory.SyncContext) error {
+	operatorSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+
+	validating, err := c.validatingLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	mutating, err := c.mutatingLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	var unreachable []string
+	for _, config := range validating {
+		for _, webhook := range config.Webhooks {
+			if !failsClosed(webhook.FailurePolicy) {
+				continue
+			}
+			if err := c.resolver.Resolve(ctx, webhook.ClientConfig); err != nil {
+				unreachable = append(unreachable, fmt.Sprintf("validatingwebhookconfiguration/%s webhook %s: %v", config.Name, webhook.Name, err))
+			}
+		}
+	}
+	for _, config := range mutating {
+		for _, webhook := range config.Webhooks {
+			if !failsClosed(webhook.FailurePolicy) {
+				continue
+			}
+			if err := c.resolver.Resolve(ctx, webhook.ClientConfig); err != nil {
+				unreachable = append(unreachable, fmt.Sprintf("mutatingwebhookconfiguration/%s webhook %s: %v", config.Name, webhook.Name, err))
+			}
+		}
+	}
+	sort.Strings(unreachable)
+
+	condition := operatorv1.OperatorCondition{
+		Type:   WebhooksUnreachableConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: AsExpectedReason,
+	}
+	if len(unreachable) > 0 {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = WebhooksUnreachableReason
+		condition.Message = fmt.Sprintf("Requests intercepted by these webhooks are rejected, their failurePolicy is Fail: %s", strings.Join(unreachable, "; "))
+	}
+
+	_, _, err = v1helpers.UpdateStatus(c.operatorClient, v1helpers.UpdateConditionFn(condition))
+	return err
+}
+
+// failsClosed returns whether requests are rejected when the webhook cannot be called, Fail is the default policy.
+func failsClosed(policy *admissionregistrationv1.FailurePolicyType) bool {
+	return policy == nil || *policy == admissionregistrationv1.Fail
+}
+
+// dialResolver opens a TCP connection to the target, the service ClusterIP or external name, or the URL host.
+type dialResolver struct {
+	serviceClient coreclientv1.ServicesGetter
+}
+
+func (r *dialResolver) Resolve(ctx context.Context, clientConfig admissionregistrationv1.WebhookClientConfig) error {
+	address, err := r.address(ctx, clientConfig)
+	if err != nil {
+		return err
+	}
+	dialer := net.Dialer{Timeout: dialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func (r *dialResolver) address(ctx context.Context, clientConfig admissionregistrationv1.WebhookClientConfig) (string, error) {
+	if ref := clientConfig.Service; ref != nil {
+		port := int32(defaultWebhookPort)
+		if ref.Port != nil {
+			port = *ref.Port
+		}
+		service, err := r.serviceClient.Services(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("unable to get service %s/%s: %w", ref.Namespace, ref.Name, err)
+		}
+		if service.Spec.Type == corev1.ServiceTypeExternalName {
+			return net.JoinHostPort(service.Spec.ExternalName, strconv.Itoa(int(port))), nil
+		}
+		if len(service.Spec.ClusterIP) == 0 || service.Spec.ClusterIP == corev1.ClusterIPNone {
+			return "", fmt.Errorf("service %s/%s has no cluster IP", ref.Namespace, ref.Name)
+		}
+		return net.JoinHostPort(service.Spec.ClusterIP, strconv.Itoa(int(port))), nil
+	}
+
+	if clientConfig.URL == nil {
+		return "", fmt.Errorf("neither a service nor a URL is set")
+	}
+	u, err := url.Parse(*clientConfig.URL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", *clientConfig.URL, err)
+	}
+	port := u.Port()
+	if len(port) == 0 {
+		port = strconv.Itoa(defaultWebhookPort)
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
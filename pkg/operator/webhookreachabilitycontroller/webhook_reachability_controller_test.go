@@ -0,0 +1,189 @@
+package webhookreachabilitycontroller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	admissionregistrationlisters "k8s.io/client-go/listers/admissionregistration/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// fakeResolver fails the targets, service names or URLs, listed in unreachable.
+type fakeResolver struct {
+	unreachable map[string]bool
+}
+
+func (r *fakeResolver) Resolve(_ context.Context, clientConfig admissionregistrationv1.WebhookClientConfig) error {
+	target := ""
+	if clientConfig.Service != nil {
+		target = clientConfig.Service.Namespace + "/" + clientConfig.Service.Name
+	} else if clientConfig.URL != nil {
+		target = *clientConfig.URL
+	}
+	if r.unreachable[target] {
+		return fmt.Errorf("%s is unreachable", target)
+	}
+	return nil
+}
+
+func serviceClientConfig(namespace, name string) admissionregistrationv1.WebhookClientConfig {
+	return admissionregistrationv1.WebhookClientConfig{Service: &admissionregistrationv1.ServiceReference{Namespace: namespace, Name: name}}
+}
+
+func urlClientConfig(url string) admissionregistrationv1.WebhookClientConfig {
+	return admissionregistrationv1.WebhookClientConfig{URL: &url}
+}
+
+func policy(p admissionregistrationv1.FailurePolicyType) *admissionregistrationv1.FailurePolicyType {
+	return &p
+}
+
+func TestWebhookReachabilityControllerSync(t *testing.T) {
+	validating := []*admissionregistrationv1.ValidatingWebhookConfiguration{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "policy"},
+			Webhooks: []admissionregistrationv1.ValidatingWebhook{
+				{Name: "fail.policy.example.com", ClientConfig: serviceClientConfig("policy", "webhook"), FailurePolicy: policy(admissionregistrationv1.Fail)},
+				{Name: "default.policy.example.com", ClientConfig: serviceClientConfig("policy", "webhook")},
+				{Name: "ignore.policy.example.com", ClientConfig: serviceClientConfig("policy", "webhook"), FailurePolicy: policy(admissionregistrationv1.Ignore)},
+				{Name: "healthy.policy.example.com", ClientConfig: serviceClientConfig("policy", "healthy"), FailurePolicy: policy(admissionregistrationv1.Fail)},
+			},
+		},
+	}
+	mutating := []*admissionregistrationv1.MutatingWebhookConfiguration{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "injector"},
+			Webhooks: []admissionregistrationv1.MutatingWebhook{
+				{Name: "inject.example.com", ClientConfig: urlClientConfig("https://injector.example.com:8443/inject"), FailurePolicy: policy(admissionregistrationv1.Fail)},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name            string
+		unreachable     map[string]bool
+		expectedStatus  operatorv1.ConditionStatus
+		expectedMessage string
+	}{
+		{
+			name:           "AllReachable",
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name:            "UnreachableService",
+			unreachable:     map[string]bool{"policy/webhook": true},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedMessage: "Requests intercepted by these webhooks are rejected, their failurePolicy is Fail: validatingwebhookconfiguration/policy webhook default.policy.example.com: policy/webhook is unreachable; validatingwebhookconfiguration/policy webhook fail.policy.example.com: policy/webhook is unreachable",
+		},
+		{
+			name:            "UnreachableURL",
+			unreachable:     map[string]bool{"https://injector.example.com:8443/inject": true},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedMessage: "Requests intercepted by these webhooks are rejected, their failurePolicy is Fail: mutatingwebhookconfiguration/injector webhook inject.example.com: https://injector.example.com:8443/inject is unreachable",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			validatingIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, config := range validating {
+				if err := validatingIndexer.Add(config.DeepCopy()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			mutatingIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, config := range mutating {
+				if err := mutatingIndexer.Add(config.DeepCopy()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+			c := &webhookReachabilityController{
+				operatorClient:   operatorClient,
+				validatingLister: admissionregistrationlisters.NewValidatingWebhookConfigurationLister(validatingIndexer),
+				mutatingLister:   admissionregistrationlisters.NewMutatingWebhookConfigurationLister(mutatingIndexer),
+				resolver:         &fakeResolver{unreachable: tc.unreachable},
+			}
+			if err := c.sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+				t.Fatal(err)
+			}
+
+			_, status, _, err := operatorClient.GetOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			condition := v1helpers.FindOperatorCondition(status.Conditions, WebhooksUnreachableConditionType)
+			if condition == nil {
+				t.Fatalf("expected %s condition", WebhooksUnreachableConditionType)
+			}
+			if condition.Status != tc.expectedStatus || condition.Message != tc.expectedMessage {
+				t.Errorf("expected %s %q, got %s %q", tc.expectedStatus, tc.expectedMessage, condition.Status, condition.Message)
+			}
+		})
+	}
+}
+
+func TestDialResolverAddress(t *testing.T) {
+	port := int32(9443)
+	kubeClient := fake.NewSimpleClientset(
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "policy", Name: "webhook"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP, ClusterIP: "172.30.0.42"},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "policy", Name: "external"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: "webhook.example.com"},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "policy", Name: "headless"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP, ClusterIP: corev1.ClusterIPNone},
+		},
+	)
+	r := &dialResolver{serviceClient: kubeClient.CoreV1()}
+
+	testCases := []struct {
+		name            string
+		clientConfig    admissionregistrationv1.WebhookClientConfig
+		expectedAddress string
+		expectError     bool
+	}{
+		{name: "ServiceDefaultPort", clientConfig: serviceClientConfig("policy", "webhook"), expectedAddress: "172.30.0.42:443"},
+		{
+			name:            "ServicePort",
+			clientConfig:    admissionregistrationv1.WebhookClientConfig{Service: &admissionregistrationv1.ServiceReference{Namespace: "policy", Name: "webhook", Port: &port}},
+			expectedAddress: "172.30.0.42:9443",
+		},
+		{name: "ExternalName", clientConfig: serviceClientConfig("policy", "external"), expectedAddress: "webhook.example.com:443"},
+		{name: "Headless", clientConfig: serviceClientConfig("policy", "headless"), expectError: true},
+		{name: "MissingService", clientConfig: serviceClientConfig("policy", "missing"), expectError: true},
+		{name: "URLDefaultPort", clientConfig: urlClientConfig("https://webhook.example.com/validate"), expectedAddress: "webhook.example.com:443"},
+		{name: "URLPort", clientConfig: urlClientConfig("https://[fd00::1]:8443/validate"), expectedAddress: "[fd00::1]:8443"},
+		{name: "NoTarget", expectError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			address, err := r.address(context.TODO(), tc.clientConfig)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got address %q", address)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if address != tc.expectedAddress {
+				t.Errorf("expected address %q, got %q", tc.expectedAddress, address)
+			}
+		})
+	}
+}
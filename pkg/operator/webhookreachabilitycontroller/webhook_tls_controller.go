@@ -0,0 +1,209 @@
+package webhookreachabilitycontroller
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	coreclientv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	admissionregistrationlisters "k8s.io/client-go/listers/admissionregistration/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// WebhookServingCertificatesUntrustedConditionType is True when the serving certificate of a webhook does not
+	// verify against the caBundle of the webhook. The kube-apiserver fails every call to such a webhook. Like
+	// KubeAPIServerWebhooksUnreachable it is informational, fixing it is up to the webhook owner.
+	WebhookServingCertificatesUntrustedConditionType = "KubeAPIServerWebhookServingCertificatesUntrusted"
+
+	ServingCertificatesUntrustedReason = "ServingCertificatesUntrusted"
+)
+
+// CertificateDialer returns the certificate chain served by the target of a webhook client config.
+type CertificateDialer interface {
+	ServingCertificates(ctx context.Context, clientConfig admissionregistrationv1.WebhookClientConfig) ([]*x509.Certificate, error)
+}
+
+// WebhookTLSController periodically connects to the targets of the validating and mutating webhooks with a caBundle
+// and verifies their serving certificates against it, for the host name the kube-apiserver calls them with. The
+// untrusted ones are listed in the KubeAPIServerWebhookServingCertificatesUntrusted condition. Targets that cannot be
+// reached are left to the WebhookReachabilityController. The webhook configurations are never modified.
+type WebhookTLSController interface {
+	factory.Controller
+}
+
+func NewWebhookTLSController(
+	operatorClient v1helpers.OperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	serviceClient coreclientv1.ServicesGetter,
+	recorder events.Recorder,
+) *webhookTLSController {
+	webhookInformers := kubeInformersForNamespaces.InformersFor("").Admissionregistration().V1()
+	c := &webhookTLSController{
+		operatorClient:   operatorClient,
+		validatingLister: webhookInformers.ValidatingWebhookConfigurations().Lister(),
+		mutatingLister:   webhookInformers.MutatingWebhookConfigurations().Lister(),
+		dialer:           &tlsDialer{resolver: &dialResolver{serviceClient: serviceClient}},
+		now:              time.Now,
+	}
+	c.Controller = factory.New().
+		WithSync(c.sync).
+		WithInformers(
+			operatorClient.Informer(),
+			webhookInformers.ValidatingWebhookConfigurations().Informer(),
+			webhookInformers.MutatingWebhookConfigurations().Informer(),
+		).
+		ResyncEvery(5*time.Minute).
+		ToController("WebhookTLSController", recorder.WithComponentSuffix("webhook-tls-controller"))
+	return c
+}
+
+type webhookTLSController struct {
+	factory.Controller
+	operatorClient   v1helpers.OperatorClient
+	validatingLister admissionregistrationlisters.ValidatingWebhookConfigurationLister
+	mutatingLister   admissionregistrationlisters.MutatingWebhookConfigurationLister
+	dialer           CertificateDialer
+	now              func() time.Time
+}
+
+func (c *webhookTLSController) sync(ctx context.Context, _ factory.SyncContext) error {
+	operatorSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+
+	validating, err := c.validatingLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	mutating, err := c.mutatingLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	var untrusted []string
+	for _, config := range validating {
+		for _, webhook := range config.Webhooks {
+			if err := c.verify(ctx, webhook.ClientConfig); err != nil {
+				untrusted = append(untrusted, fmt.Sprintf("validatingwebhookconfiguration/%s webhook %s: %v", config.Name, webhook.Name, err))
+			}
+		}
+	}
+	for _, config := range mutating {
+		for _, webhook := range config.Webhooks {
+			if err := c.verify(ctx, webhook.ClientConfig); err != nil {
+				untrusted = append(untrusted, fmt.Sprintf("mutatingwebhookconfiguration/%s webhook %s: %v", config.Name, webhook.Name, err))
+			}
+		}
+	}
+	sort.Strings(untrusted)
+
+	condition := operatorv1.OperatorCondition{
+		Type:   WebhookServingCertificatesUntrustedConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: AsExpectedReason,
+	}
+	if len(untrusted) > 0 {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = ServingCertificatesUntrustedReason
+		condition.Message = fmt.Sprintf("Calls to these webhooks fail, their serving certificate is not trusted by their caBundle: %s", strings.Join(untrusted, "; "))
+	}
+
+	_, _, err = v1helpers.UpdateStatus(c.operatorClient, v1helpers.UpdateConditionFn(condition))
+	return err
+}
+
+// verify returns an error when the serving certificate of the target does not verify against the caBundle. Webhooks
+// without a caBundle, verified against the system roots, and targets that cannot be reached are not checked.
+func (c *webhookTLSController) verify(ctx context.Context, clientConfig admissionregistrationv1.WebhookClientConfig) error {
+	if len(clientConfig.CABundle) == 0 {
+		return nil
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(clientConfig.CABundle) {
+		return fmt.Errorf("the caBundle contains no PEM certificate")
+	}
+
+	chain, err := c.dialer.ServingCertificates(ctx, clientConfig)
+	if err != nil {
+		klog.V(4).Infof("Unable to get the serving certificates of webhook target: %v", err)
+		return nil
+	}
+	if len(chain) == 0 {
+		return fmt.Errorf("no serving certificate is served")
+	}
+	intermediates := x509.NewCertPool()
+	for _, certificate := range chain[1:] {
+		intermediates.AddCert(certificate)
+	}
+	serverName, err := serverName(clientConfig)
+	if err != nil {
+		return err
+	}
+	_, err = chain[0].Verify(x509.VerifyOptions{
+		DNSName:       serverName,
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   c.now(),
+	})
+	return err
+}
+
+// serverName returns the host name the kube-apiserver verifies the serving certificate of the target for.
+func serverName(clientConfig admissionregistrationv1.WebhookClientConfig) (string, error) {
+	if ref := clientConfig.Service; ref != nil {
+		return ref.Name + "." + ref.Namespace + ".svc", nil
+	}
+	if clientConfig.URL == nil {
+		return "", fmt.Errorf("neither a service nor a URL is set")
+	}
+	u, err := url.Parse(*clientConfig.URL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", *clientConfig.URL, err)
+	}
+	return u.Hostname(), nil
+}
+
+// tlsDialer completes a TLS handshake with the address of the dialResolver and returns the peer certificates.
+type tlsDialer struct {
+	resolver *dialResolver
+}
+
+func (d *tlsDialer) ServingCertificates(ctx context.Context, clientConfig admissionregistrationv1.WebhookClientConfig) ([]*x509.Certificate, error) {
+	address, err := d.resolver.address(ctx, clientConfig)
+	if err != nil {
+		return nil, err
+	}
+	serverName, err := serverName(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+	dialer := tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: dialTimeout},
+		// the chain is verified against the caBundle by the caller, the handshake only retrieves it
+		Config: &tls.Config{ServerName: serverName, InsecureSkipVerify: true},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.(*tls.Conn).ConnectionState().PeerCertificates, nil
+}
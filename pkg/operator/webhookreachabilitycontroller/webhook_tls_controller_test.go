@@ -0,0 +1,166 @@
+package webhookreachabilitycontroller
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	admissionregistrationlisters "k8s.io/client-go/listers/admissionregistration/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// fakeDialer serves the certificates of the targets, service names or URLs, in served.
+type fakeDialer struct {
+	served map[string][]*x509.Certificate
+}
+
+func (d *fakeDialer) ServingCertificates(_ context.Context, clientConfig admissionregistrationv1.WebhookClientConfig) ([]*x509.Certificate, error) {
+	target := ""
+	if clientConfig.Service != nil {
+		target = clientConfig.Service.Namespace + "/" + clientConfig.Service.Name
+	} else if clientConfig.URL != nil {
+		target = *clientConfig.URL
+	}
+	certificates, ok := d.served[target]
+	if !ok {
+		return nil, fmt.Errorf("%s is unreachable", target)
+	}
+	return certificates, nil
+}
+
+func TestWebhookTLSControllerSync(t *testing.T) {
+	newCA := func(name string) (*crypto.CA, []byte) {
+		t.Helper()
+		caConfig, err := crypto.MakeSelfSignedCAConfigForDuration(name, 24*time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		caPEM, _, err := caConfig.GetPEMBytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &crypto.CA{Config: caConfig, SerialGenerator: &crypto.RandomSerialGenerator{}}, caPEM
+	}
+	servingCertificate := func(ca *crypto.CA, hostnames ...string) []*x509.Certificate {
+		t.Helper()
+		certConfig, err := ca.MakeServerCertForDuration(sets.NewString(hostnames...), time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return certConfig.Certs
+	}
+	policyCA, policyBundle := newCA("policy-signer")
+	otherCA, _ := newCA("other-signer")
+
+	withBundle := func(clientConfig admissionregistrationv1.WebhookClientConfig, caBundle []byte) admissionregistrationv1.WebhookClientConfig {
+		clientConfig.CABundle = caBundle
+		return clientConfig
+	}
+	validating := []*admissionregistrationv1.ValidatingWebhookConfiguration{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "policy"},
+			Webhooks: []admissionregistrationv1.ValidatingWebhook{
+				{Name: "validate.policy.example.com", ClientConfig: withBundle(serviceClientConfig("policy", "webhook"), policyBundle)},
+				{Name: "system-roots.policy.example.com", ClientConfig: serviceClientConfig("policy", "system-roots")},
+			},
+		},
+	}
+	mutating := []*admissionregistrationv1.MutatingWebhookConfiguration{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "injector"},
+			Webhooks: []admissionregistrationv1.MutatingWebhook{
+				{Name: "inject.example.com", ClientConfig: withBundle(urlClientConfig("https://injector.example.com:8443/inject"), policyBundle)},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name            string
+		served          map[string][]*x509.Certificate
+		expectedStatus  operatorv1.ConditionStatus
+		expectedMessage string
+	}{
+		{
+			name: "MatchingCABundles",
+			served: map[string][]*x509.Certificate{
+				"policy/webhook":                           servingCertificate(policyCA, "webhook.policy.svc"),
+				"policy/system-roots":                      servingCertificate(otherCA, "system-roots.policy.svc"),
+				"https://injector.example.com:8443/inject": servingCertificate(policyCA, "injector.example.com"),
+			},
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name: "MismatchingCABundle",
+			served: map[string][]*x509.Certificate{
+				"policy/webhook": servingCertificate(otherCA, "webhook.policy.svc"),
+				"https://injector.example.com:8443/inject": servingCertificate(policyCA, "injector.example.com"),
+			},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedMessage: "Calls to these webhooks fail, their serving certificate is not trusted by their caBundle: validatingwebhookconfiguration/policy webhook validate.policy.example.com: x509: certificate signed by unknown authority",
+		},
+		{
+			name: "MismatchingHostName",
+			served: map[string][]*x509.Certificate{
+				"policy/webhook": servingCertificate(policyCA, "webhook.policy.svc"),
+				"https://injector.example.com:8443/inject": servingCertificate(policyCA, "injector.policy.svc"),
+			},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedMessage: "mutatingwebhookconfiguration/injector webhook inject.example.com: x509: certificate is valid for injector.policy.svc, not injector.example.com",
+		},
+		{
+			name:           "UnreachableTargetsAreNotChecked",
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			validatingIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, config := range validating {
+				if err := validatingIndexer.Add(config.DeepCopy()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			mutatingIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, config := range mutating {
+				if err := mutatingIndexer.Add(config.DeepCopy()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+			c := &webhookTLSController{
+				operatorClient:   operatorClient,
+				validatingLister: admissionregistrationlisters.NewValidatingWebhookConfigurationLister(validatingIndexer),
+				mutatingLister:   admissionregistrationlisters.NewMutatingWebhookConfigurationLister(mutatingIndexer),
+				dialer:           &fakeDialer{served: tc.served},
+				now:              time.Now,
+			}
+			if err := c.sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+				t.Fatal(err)
+			}
+
+			_, status, _, err := operatorClient.GetOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			condition := v1helpers.FindOperatorCondition(status.Conditions, WebhookServingCertificatesUntrustedConditionType)
+			if condition == nil {
+				t.Fatalf("expected %s condition", WebhookServingCertificatesUntrustedConditionType)
+			}
+			if condition.Status != tc.expectedStatus || !strings.Contains(condition.Message, tc.expectedMessage) {
+				t.Errorf("expected %s %q, got %s %q", tc.expectedStatus, tc.expectedMessage, condition.Status, condition.Message)
+			}
+		})
+	}
+}